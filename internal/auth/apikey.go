@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key, so they are recognizable in
+// Authorization headers and secret scanners
+const APIKeyPrefix = "dotfiles_"
+
+// apiKeyBytes is how much randomness an API key carries
+const apiKeyBytes = 32
+
+// GenerateAPIKey returns a new API key and the hash to store for it
+func GenerateAPIKey() (key, hash string, err error) {
+	b := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	key = APIKeyPrefix + hex.EncodeToString(b)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey hashes an API key for storage and lookup. Keys are random, so
+// a fast unsalted hash is enough to keep stored hashes from being usable.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// BearerAPIKey returns the API key in an Authorization header of the form
+// "Bearer dotfiles_<key>", or false when the header holds no API key
+func BearerAPIKey(header string) (string, bool) {
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, strings.HasPrefix(token, APIKeyPrefix)
+}
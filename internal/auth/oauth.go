@@ -1,45 +1,40 @@
 package auth
 
 import (
-	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
-	"net/http"
-	"os"
+	"sort"
 	"sync"
 	"time"
-
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/github"
 )
 
-// OAuthState represents a time-limited OAuth state token
+// OAuthState represents a time-limited OAuth state token. A state is only
+// valid on the callback of the provider it was issued for.
 type OAuthState struct {
 	Token     string
+	Provider  string
 	ExpiresAt time.Time
+	// LinkUserID is set when the flow links the provider account to an
+	// already signed-in user rather than signing in
+	LinkUserID string
 }
 
-// OAuthService handles OAuth configuration and operations
+// OAuthService handles OAuth state and the enabled sign-in providers
 type OAuthService struct {
-	config *oauth2.Config
-	states map[string]*OAuthState
-	mutex  sync.RWMutex
+	providers map[string]Provider
+	states    map[string]*OAuthState
+	mutex     sync.RWMutex
 }
 
-// NewOAuthService creates a new OAuth service
-func NewOAuthService() *OAuthService {
-	config := &oauth2.Config{
-		ClientID:     os.Getenv("GITHUB_CLIENT_ID"),
-		ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
-		RedirectURL:  os.Getenv("OAUTH_REDIRECT_URL"),
-		Scopes:       []string{"user:email"},
-		Endpoint:     github.Endpoint,
-	}
-
+// NewOAuthService creates a new OAuth service for the given providers
+func NewOAuthService(providers ...Provider) *OAuthService {
 	service := &OAuthService{
-		config: config,
-		states: make(map[string]*OAuthState),
+		providers: make(map[string]Provider, len(providers)),
+		states:    make(map[string]*OAuthState),
+	}
+	for _, provider := range providers {
+		service.providers[provider.Name()] = provider
 	}
 
 	// Start cleanup goroutine
@@ -48,6 +43,12 @@ func NewOAuthService() *OAuthService {
 	return service
 }
 
+// Provider returns the enabled provider with the given name
+func (s *OAuthService) Provider(name string) (Provider, bool) {
+	provider, ok := s.providers[name]
+	return provider, ok
+}
+
 // generateState generates a cryptographically secure state token
 func (s *OAuthService) generateState() (string, error) {
 	b := make([]byte, 32)
@@ -57,8 +58,19 @@ func (s *OAuthService) generateState() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
-// GetAuthURL returns the OAuth authorization URL with a unique state token
-func (s *OAuthService) GetAuthURL() (string, error) {
+// GetAuthURL returns the provider's authorization URL with a unique state
+// token
+func (s *OAuthService) GetAuthURL(provider Provider) (string, error) {
+	return s.authURL(provider, "")
+}
+
+// GetLinkURL returns the provider's authorization URL with a state token
+// that links the provider account to the given user on callback
+func (s *OAuthService) GetLinkURL(provider Provider, userID string) (string, error) {
+	return s.authURL(provider, userID)
+}
+
+func (s *OAuthService) authURL(provider Provider, linkUserID string) (string, error) {
 	stateToken, err := s.generateState()
 	if err != nil {
 		return "", err
@@ -67,55 +79,58 @@ func (s *OAuthService) GetAuthURL() (string, error) {
 	// Store state with 10 minute expiration
 	s.mutex.Lock()
 	s.states[stateToken] = &OAuthState{
-		Token:     stateToken,
-		ExpiresAt: time.Now().Add(10 * time.Minute),
+		Token:      stateToken,
+		Provider:   provider.Name(),
+		ExpiresAt:  time.Now().Add(10 * time.Minute),
+		LinkUserID: linkUserID,
 	}
 	s.mutex.Unlock()
 
-	return s.config.AuthCodeURL(stateToken, oauth2.AccessTypeOffline), nil
-}
-
-// ExchangeCode exchanges an OAuth code for a token
-func (s *OAuthService) ExchangeCode(ctx context.Context, code string) (*oauth2.Token, error) {
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-	return s.config.Exchange(ctx, code)
+	return provider.AuthURL(stateToken), nil
 }
 
-// GetClient returns an HTTP client for the given token
-func (s *OAuthService) GetClient(ctx context.Context, token *oauth2.Token) *http.Client {
-	return s.config.Client(ctx, token)
-}
-
-// ValidateState validates the OAuth state parameter and removes it
-func (s *OAuthService) ValidateState(state string) bool {
+// ValidateState validates the OAuth state parameter for a provider's
+// callback and removes it
+func (s *OAuthService) ValidateState(state, provider string) (*OAuthState, bool) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	oauthState, exists := s.states[state]
 	if !exists {
-		return false
-	}
-
-	// Check if expired
-	if time.Now().After(oauthState.ExpiresAt) {
-		delete(s.states, state)
-		return false
+		return nil, false
 	}
 
 	// Remove state after use (one-time use)
 	delete(s.states, state)
-	return true
+
+	// Check if expired
+	if time.Now().After(oauthState.ExpiresAt) || oauthState.Provider != provider {
+		return nil, false
+	}
+	return oauthState, true
 }
 
-// IsConfigured returns true if OAuth is properly configured
+// IsConfigured returns true if any provider is properly configured
 func (s *OAuthService) IsConfigured() bool {
-	return s.config.ClientID != ""
+	for _, provider := range s.providers {
+		if provider.Configured() {
+			return true
+		}
+	}
+	return false
 }
 
-// GetConfig returns the OAuth config (for backward compatibility)
-func (s *OAuthService) GetConfig() *oauth2.Config {
-	return s.config
+// ConfiguredProviders returns the names of the enabled providers that have
+// credentials, sorted
+func (s *OAuthService) ConfiguredProviders() []string {
+	names := make([]string, 0, len(s.providers))
+	for name, provider := range s.providers {
+		if provider.Configured() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
 }
 
 // cleanupExpiredStates removes expired state tokens periodically
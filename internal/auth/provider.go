@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+)
+
+// Names of the supported OAuth providers, as used in /auth/:provider
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// ExternalUser is a user's profile as reported by an OAuth provider,
+// normalized across providers
+type ExternalUser struct {
+	Provider   string
+	ExternalID string
+	Username   string
+	Name       string
+	Email      string
+	// EmailVerified reports whether the provider vouches that Email is the
+	// user's. Config claims and organization invites match against the
+	// stored email, so only verified ones are kept.
+	EmailVerified bool
+	AvatarURL     string
+	Bio           string
+	Location      string
+	Website       string
+}
+
+// Provider signs users in through an external OAuth identity provider
+type Provider interface {
+	// Name is the provider's URL name, e.g. "github"
+	Name() string
+	// Configured reports whether the provider has OAuth app credentials
+	Configured() bool
+	// AuthURL returns the provider's authorization URL carrying state
+	AuthURL(state string) string
+	// Exchange trades an authorization code for an access token
+	Exchange(ctx context.Context, code string) (*oauth2.Token, error)
+	// FetchUser loads the profile of the user the token belongs to
+	FetchUser(ctx context.Context, token *oauth2.Token) (*ExternalUser, error)
+}
+
+// ProviderConfig holds an OAuth app's credentials. BaseURL and APIURL point
+// at a self-hosted instance; empty uses the provider's public service.
+type ProviderConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	BaseURL      string
+	APIURL       string
+}
+
+// oauthProvider implements the OAuth steps shared by every provider
+type oauthProvider struct {
+	name   string
+	config *oauth2.Config
+	apiURL string
+}
+
+func (p *oauthProvider) Name() string {
+	return p.name
+}
+
+func (p *oauthProvider) Configured() bool {
+	return p.config.ClientID != ""
+}
+
+func (p *oauthProvider) AuthURL(state string) string {
+	return p.config.AuthCodeURL(state, oauth2.AccessTypeOffline)
+}
+
+func (p *oauthProvider) Exchange(ctx context.Context, code string) (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	return p.config.Exchange(ctx, code)
+}
+
+// getJSON decodes the response to an authenticated GET of an API path
+func (p *oauthProvider) getJSON(ctx context.Context, token *oauth2.Token, path string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	resp, err := p.config.Client(ctx, token).Get(p.apiURL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s API returned %s", p.name, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// GitHubProvider signs users in with GitHub
+type GitHubProvider struct {
+	oauthProvider
+}
+
+// NewGitHubProvider creates a GitHub provider. A BaseURL selects a GitHub
+// Enterprise server, whose API defaults to BaseURL/api/v3.
+func NewGitHubProvider(config ProviderConfig) *GitHubProvider {
+	endpoint := github.Endpoint
+	apiURL := "https://api.github.com"
+	if base := strings.TrimSuffix(config.BaseURL, "/"); base != "" {
+		endpoint = oauth2.Endpoint{
+			AuthURL:  base + "/login/oauth/authorize",
+			TokenURL: base + "/login/oauth/access_token",
+		}
+		apiURL = base + "/api/v3"
+	}
+	if config.APIURL != "" {
+		apiURL = strings.TrimSuffix(config.APIURL, "/")
+	}
+
+	return &GitHubProvider{oauthProvider{
+		name: ProviderGitHub,
+		config: &oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Scopes:       []string{"user:email"},
+			Endpoint:     endpoint,
+		},
+		apiURL: apiURL,
+	}}
+}
+
+// FetchUser loads the signed-in GitHub user
+func (p *GitHubProvider) FetchUser(ctx context.Context, token *oauth2.Token) (*ExternalUser, error) {
+	var githubUser struct {
+		ID        int    `json:"id"`
+		Username  string `json:"login"`
+		Name      string `json:"name"`
+		Email     string `json:"email"`
+		AvatarURL string `json:"avatar_url"`
+		Bio       string `json:"bio"`
+		Location  string `json:"location"`
+		Website   string `json:"blog"`
+	}
+	if err := p.getJSON(ctx, token, "/user", &githubUser); err != nil {
+		return nil, err
+	}
+
+	return &ExternalUser{
+		Provider:   ProviderGitHub,
+		ExternalID: strconv.Itoa(githubUser.ID),
+		Username:   githubUser.Username,
+		Name:       githubUser.Name,
+		Email:      githubUser.Email,
+		// GitHub only publishes verified addresses
+		EmailVerified: githubUser.Email != "",
+		AvatarURL:     githubUser.AvatarURL,
+		Bio:           githubUser.Bio,
+		Location:      githubUser.Location,
+		Website:       githubUser.Website,
+	}, nil
+}
+
+// GitLabProvider signs users in with GitLab.com or a self-hosted GitLab
+type GitLabProvider struct {
+	oauthProvider
+}
+
+// NewGitLabProvider creates a GitLab provider. BaseURL defaults to
+// https://gitlab.com and the API to BaseURL/api/v4.
+func NewGitLabProvider(config ProviderConfig) *GitLabProvider {
+	base := strings.TrimSuffix(config.BaseURL, "/")
+	if base == "" {
+		base = "https://gitlab.com"
+	}
+	apiURL := base + "/api/v4"
+	if config.APIURL != "" {
+		apiURL = strings.TrimSuffix(config.APIURL, "/")
+	}
+
+	return &GitLabProvider{oauthProvider{
+		name: ProviderGitLab,
+		config: &oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Scopes:       []string{"read_user"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  base + "/oauth/authorize",
+				TokenURL: base + "/oauth/token",
+			},
+		},
+		apiURL: apiURL,
+	}}
+}
+
+// FetchUser loads the signed-in GitLab user. GitLab instances can be
+// configured to skip email confirmation, so its email is never treated as
+// verified.
+func (p *GitLabProvider) FetchUser(ctx context.Context, token *oauth2.Token) (*ExternalUser, error) {
+	var gitlabUser struct {
+		ID        int    `json:"id"`
+		Username  string `json:"username"`
+		Name      string `json:"name"`
+		Email     string `json:"email"`
+		AvatarURL string `json:"avatar_url"`
+		Bio       string `json:"bio"`
+		Location  string `json:"location"`
+		Website   string `json:"website_url"`
+	}
+	if err := p.getJSON(ctx, token, "/user", &gitlabUser); err != nil {
+		return nil, err
+	}
+
+	return &ExternalUser{
+		Provider:   ProviderGitLab,
+		ExternalID: strconv.Itoa(gitlabUser.ID),
+		Username:   gitlabUser.Username,
+		Name:       gitlabUser.Name,
+		Email:      gitlabUser.Email,
+		AvatarURL:  gitlabUser.AvatarURL,
+		Bio:        gitlabUser.Bio,
+		Location:   gitlabUser.Location,
+		Website:    gitlabUser.Website,
+	}, nil
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"log"
 	"sync"
 	"time"
 
@@ -18,6 +19,63 @@ type Session struct {
 	CreatedAt time.Time              `json:"created_at"`
 	ExpiresAt time.Time              `json:"expires_at"`
 	Data      map[string]interface{} `json:"data"`
+	// LastSeenAt is when the session was last used, or created
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Impersonated marks a session a site admin opened as another user;
+	// ImpersonatedBy is that admin's user ID
+	Impersonated   bool   `json:"impersonated,omitempty"`
+	ImpersonatedBy string `json:"impersonated_by,omitempty"`
+}
+
+// ImpersonationTimeout is how long an impersonation session lasts. Unlike
+// normal sessions it is not extended by use.
+const ImpersonationTimeout = time.Hour
+
+// GitHubTokenKey is the session data key holding the user's GitHub OAuth
+// access token
+const GitHubTokenKey = "github_token"
+
+// OrgMembershipsKey is the session data key holding the user's cached
+// *OrgMemberships
+const OrgMembershipsKey = "org_memberships"
+
+// OrgMemberships caches a user's organization roles in their session so
+// access checks need not read them from the store on every request. It is
+// never modified once stored; a refresh stores a new value.
+type OrgMemberships struct {
+	// Roles maps organization ID to the user's role in it
+	Roles map[string]string
+	// Version is the user's membership version when Roles was loaded
+	Version int64
+	// CheckedAt is when Version was last confirmed current
+	CheckedAt time.Time
+}
+
+// SessionAgeBuckets are the upper bounds of the session age histogram in
+// SessionStats
+var SessionAgeBuckets = []time.Duration{
+	time.Hour,
+	6 * time.Hour,
+	24 * time.Hour,
+	3 * 24 * time.Hour,
+	7 * 24 * time.Hour,
+	30 * 24 * time.Hour,
+}
+
+// SessionStats summarizes the live sessions, for capacity planning
+type SessionStats struct {
+	// Active counts unexpired sessions
+	Active int
+	// AgeCounts[i] counts active sessions no older than
+	// SessionAgeBuckets[i], so the counts are cumulative
+	AgeCounts []int
+	// AgeSum totals the ages of the active sessions
+	AgeSum time.Duration
+	// Pruned counts the expired sessions removed since startup
+	Pruned int64
+	// Evicted counts the unexpired sessions removed since startup to stay
+	// within the session limit
+	Evicted int64
 }
 
 // SessionManager manages user sessions
@@ -25,6 +83,11 @@ type SessionManager struct {
 	sessions map[string]*Session
 	mutex    sync.RWMutex
 	timeout  time.Duration
+	pruned   int64
+	evicted  int64
+
+	// maxSessions caps len(sessions); zero means unlimited
+	maxSessions int
 }
 
 // NewSessionManager creates a new session manager
@@ -40,6 +103,15 @@ func NewSessionManager(timeout time.Duration) *SessionManager {
 	return manager
 }
 
+// SetMaxSessions caps how many sessions are held at once. A new session
+// that would exceed it first prunes expired sessions and, if that frees no
+// room, evicts the least recently seen ones. Zero means unlimited.
+func (sm *SessionManager) SetMaxSessions(max int) {
+	sm.mutex.Lock()
+	sm.maxSessions = max
+	sm.mutex.Unlock()
+}
+
 // CreateSession creates a new session for a user
 func (sm *SessionManager) CreateSession(userID, username, email string) (*Session, error) {
 	sessionID, err := generateSessionID()
@@ -47,23 +119,82 @@ func (sm *SessionManager) CreateSession(userID, username, email string) (*Sessio
 		return nil, err
 	}
 
+	now := time.Now()
 	session := &Session{
-		ID:        sessionID,
-		UserID:    userID,
-		Username:  username,
-		Email:     email,
-		CreatedAt: time.Now(),
-		ExpiresAt: time.Now().Add(sm.timeout),
-		Data:      make(map[string]interface{}),
+		ID:         sessionID,
+		UserID:     userID,
+		Username:   username,
+		Email:      email,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(sm.timeout),
+		LastSeenAt: now,
+		Data:       make(map[string]interface{}),
 	}
 
-	sm.mutex.Lock()
-	sm.sessions[sessionID] = session
-	sm.mutex.Unlock()
+	sm.store(session)
+
+	return session, nil
+}
+
+// CreateImpersonationSession creates a session for a user on behalf of the
+// site admin adminUserID. It expires after ImpersonationTimeout.
+func (sm *SessionManager) CreateImpersonationSession(userID, username, email, adminUserID string) (*Session, error) {
+	sessionID, err := generateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:             sessionID,
+		UserID:         userID,
+		Username:       username,
+		Email:          email,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(ImpersonationTimeout),
+		LastSeenAt:     now,
+		Data:           make(map[string]interface{}),
+		Impersonated:   true,
+		ImpersonatedBy: adminUserID,
+	}
+
+	sm.store(session)
 
 	return session, nil
 }
 
+// store adds a session, making room for it first if the manager is full
+func (sm *SessionManager) store(session *Session) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	if sm.maxSessions > 0 && len(sm.sessions) >= sm.maxSessions {
+		sm.makeRoom(session.CreatedAt)
+	}
+	sm.sessions[session.ID] = session
+}
+
+// makeRoom removes sessions until there is room for one more, preferring
+// sessions expired at now over the least recently seen. The caller must
+// hold the write lock.
+func (sm *SessionManager) makeRoom(now time.Time) {
+	if pruned := sm.deleteExpired(now); pruned > 0 {
+		log.Printf("Pruned %d expired sessions to stay within the limit of %d", pruned, sm.maxSessions)
+	}
+	for len(sm.sessions) >= sm.maxSessions {
+		var oldest *Session
+		for _, session := range sm.sessions {
+			if oldest == nil || session.LastSeenAt.Before(oldest.LastSeenAt) {
+				oldest = session
+			}
+		}
+		delete(sm.sessions, oldest.ID)
+		sm.evicted++
+		log.Printf("Evicted session of user %s, last seen %s, to stay within the limit of %d sessions",
+			oldest.UserID, oldest.LastSeenAt.Format(time.RFC3339), sm.maxSessions)
+	}
+}
+
 // GetSession retrieves a session by ID
 func (sm *SessionManager) GetSession(sessionID string) (*Session, bool) {
 	sm.mutex.Lock()
@@ -75,13 +206,17 @@ func (sm *SessionManager) GetSession(sessionID string) (*Session, bool) {
 	}
 
 	// Check if session is expired
-	if time.Now().After(session.ExpiresAt) {
+	now := time.Now()
+	if now.After(session.ExpiresAt) {
 		delete(sm.sessions, sessionID)
 		return nil, false
 	}
 
-	// Extend session expiry
-	session.ExpiresAt = time.Now().Add(sm.timeout)
+	// Extend session expiry; impersonation sessions keep their fixed hour
+	session.LastSeenAt = now
+	if !session.Impersonated {
+		session.ExpiresAt = now.Add(sm.timeout)
+	}
 
 	return session, true
 }
@@ -105,6 +240,19 @@ func (sm *SessionManager) UpdateSession(sessionID string, data map[string]interf
 	}
 }
 
+// GetData returns a value from a session's data
+func (sm *SessionManager) GetData(sessionID, key string) (interface{}, bool) {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	session, exists := sm.sessions[sessionID]
+	if !exists {
+		return nil, false
+	}
+	value, ok := session.Data[key]
+	return value, ok
+}
+
 // GetSessionFromContext extracts session from gin context
 func (sm *SessionManager) GetSessionFromContext(c *gin.Context) (*Session, bool) {
 	// Try to get session ID from cookie
@@ -121,10 +269,15 @@ func (sm *SessionManager) SetSessionCookie(c *gin.Context, session *Session) {
 	// Determine if we're in production (HTTPS) or development
 	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
 
+	maxAge := sm.timeout
+	if session.Impersonated {
+		maxAge = ImpersonationTimeout
+	}
+
 	c.SetCookie(
 		"session_id",
 		session.ID,
-		int(sm.timeout.Seconds()),
+		int(maxAge.Seconds()),
 		"/",
 		"",
 		secure, // secure flag - true in production with HTTPS
@@ -156,16 +309,62 @@ func (sm *SessionManager) cleanupExpiredSessions() {
 	for {
 		select {
 		case <-ticker.C:
-			now := time.Now()
-			sm.mutex.Lock()
-			for id, session := range sm.sessions {
-				if now.After(session.ExpiresAt) {
-					delete(sm.sessions, id)
-				}
+			if pruned, remaining := sm.pruneExpired(time.Now()); pruned > 0 {
+				log.Printf("Pruned %d expired sessions, %d remaining", pruned, remaining)
+			}
+		}
+	}
+}
+
+// pruneExpired removes the sessions expired at now and returns how many it
+// removed and how many remain
+func (sm *SessionManager) pruneExpired(now time.Time) (int, int) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+
+	pruned := sm.deleteExpired(now)
+	return pruned, len(sm.sessions)
+}
+
+// deleteExpired removes the sessions expired at now and returns how many
+// it removed. The caller must hold the write lock.
+func (sm *SessionManager) deleteExpired(now time.Time) int {
+	pruned := 0
+	for id, session := range sm.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(sm.sessions, id)
+			pruned++
+		}
+	}
+	sm.pruned += int64(pruned)
+	return pruned
+}
+
+// Stats summarizes the sessions unexpired at now. Ages are measured from
+// each session's creation.
+func (sm *SessionManager) Stats(now time.Time) SessionStats {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+
+	stats := SessionStats{
+		AgeCounts: make([]int, len(SessionAgeBuckets)),
+		Pruned:    sm.pruned,
+		Evicted:   sm.evicted,
+	}
+	for _, session := range sm.sessions {
+		if now.After(session.ExpiresAt) {
+			continue
+		}
+		age := now.Sub(session.CreatedAt)
+		stats.Active++
+		stats.AgeSum += age
+		for i, bound := range SessionAgeBuckets {
+			if age <= bound {
+				stats.AgeCounts[i]++
 			}
-			sm.mutex.Unlock()
 		}
 	}
+	return stats
 }
 
 // generateSessionID generates a cryptographically secure session ID
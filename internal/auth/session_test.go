@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionLimitEvictsLeastRecentlySeen(t *testing.T) {
+	sm := NewSessionManager(time.Hour)
+	sm.SetMaxSessions(2)
+
+	alice, _ := sm.CreateSession("alice-id", "alice", "alice@example.com")
+	bob, _ := sm.CreateSession("bob-id", "bob", "bob@example.com")
+	// Alice was seen more recently than Bob, so Bob goes first
+	sm.mutex.Lock()
+	alice.LastSeenAt = bob.LastSeenAt.Add(time.Second)
+	sm.mutex.Unlock()
+
+	carol, _ := sm.CreateSession("carol-id", "carol", "carol@example.com")
+	if _, ok := sm.GetSession(bob.ID); ok {
+		t.Error("Expected the least recently seen session to be evicted")
+	}
+	for _, session := range []*Session{alice, carol} {
+		if _, ok := sm.GetSession(session.ID); !ok {
+			t.Errorf("Expected %s's session to be kept", session.Username)
+		}
+	}
+	if stats := sm.Stats(time.Now()); stats.Active != 2 || stats.Evicted != 1 {
+		t.Errorf("Expected 2 active and 1 evicted, got %+v", stats)
+	}
+}
+
+func TestSessionLimitPrunesExpiredFirst(t *testing.T) {
+	sm := NewSessionManager(time.Hour)
+	sm.SetMaxSessions(2)
+
+	expired, _ := sm.CreateSession("alice-id", "alice", "alice@example.com")
+	kept, _ := sm.CreateSession("bob-id", "bob", "bob@example.com")
+	sm.mutex.Lock()
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	// Seen most recently, but expired sessions go before any live one
+	expired.LastSeenAt = time.Now().Add(time.Minute)
+	sm.mutex.Unlock()
+
+	if _, err := sm.CreateSession("carol-id", "carol", "carol@example.com"); err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	if _, ok := sm.GetSession(kept.ID); !ok {
+		t.Error("Expected the live session to be kept")
+	}
+	if stats := sm.Stats(time.Now()); stats.Pruned != 1 || stats.Evicted != 0 {
+		t.Errorf("Expected 1 pruned and none evicted, got %+v", stats)
+	}
+}
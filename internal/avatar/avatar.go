@@ -0,0 +1,271 @@
+// Package avatar fetches user avatars server-side and caches them on disk,
+// so clients never contact the avatar host directly.
+package avatar
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrHostNotAllowed     = errors.New("avatar host not allowed")
+	ErrNotFound           = errors.New("avatar not found")
+	ErrTooLarge           = errors.New("avatar too large")
+	ErrUnsupportedContent = errors.New("avatar content type not supported")
+)
+
+// contentTypes are the image types the proxy passes through. SVG is left
+// out since it can carry scripts.
+var contentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Image is an avatar ready to serve
+type Image struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+	FetchedAt   time.Time
+}
+
+// metadata is stored next to each cached image
+type metadata struct {
+	SourceURL   string    `json:"source_url"`
+	ContentType string    `json:"content_type"`
+	ETag        string    `json:"etag"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// Config tunes the proxy
+type Config struct {
+	// CacheDir holds the cached images
+	CacheDir string
+	// TTL is how long a cached image is served before it is fetched again
+	TTL time.Duration
+	// MaxBytes bounds the size of a fetched image
+	MaxBytes int64
+	// AllowedHosts are the hosts avatars may be fetched from
+	AllowedHosts []string
+}
+
+// DefaultConfig caches GitHub avatars of up to 1MB for a day
+func DefaultConfig() Config {
+	return Config{
+		CacheDir:     filepath.Join("static", "avatars"),
+		TTL:          24 * time.Hour,
+		MaxBytes:     1 << 20,
+		AllowedHosts: []string{"avatars.githubusercontent.com"},
+	}
+}
+
+// Proxy fetches avatars and caches them on disk
+type Proxy struct {
+	config     Config
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewProxy creates a proxy
+func NewProxy(config Config) *Proxy {
+	return &Proxy{
+		config:     config,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		now:        time.Now,
+	}
+}
+
+// TTL returns how long fetched avatars are cached
+func (p *Proxy) TTL() time.Duration {
+	return p.config.TTL
+}
+
+// Get returns the avatar at sourceURL cached under key, fetching it again
+// once the cached copy is older than the TTL or sourceURL has changed. When
+// fetching fails, an earlier copy of the same URL is served if there is
+// one.
+func (p *Proxy) Get(ctx context.Context, key, sourceURL string) (*Image, error) {
+	cached, cachedErr := p.load(key)
+	if cachedErr == nil && cached.source == sourceURL && p.now().Sub(cached.FetchedAt) < p.config.TTL {
+		return &cached.Image, nil
+	}
+
+	image, err := p.fetch(ctx, sourceURL)
+	if err != nil {
+		if cachedErr == nil && cached.source == sourceURL {
+			return &cached.Image, nil
+		}
+		return nil, err
+	}
+
+	if err := p.store(key, sourceURL, image); err != nil {
+		return image, fmt.Errorf("failed to cache avatar: %w", err)
+	}
+	return image, nil
+}
+
+// fetch downloads an avatar, rejecting other hosts, content types and
+// anything over MaxBytes
+func (p *Proxy) fetch(ctx context.Context, sourceURL string) (*Image, error) {
+	if err := p.checkURL(sourceURL); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("avatar host returned status %d", resp.StatusCode)
+	}
+
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !contentTypes[contentType] {
+		return nil, ErrUnsupportedContent
+	}
+	if resp.ContentLength > p.config.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	// Read one byte past the limit to tell a full-size image from a
+	// truncated one
+	data, err := io.ReadAll(io.LimitReader(resp.Body, p.config.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > p.config.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	return &Image{
+		Data:        data,
+		ContentType: contentType,
+		ETag:        ETag(data),
+		FetchedAt:   p.now(),
+	}, nil
+}
+
+func (p *Proxy) checkURL(sourceURL string) error {
+	parsed, err := url.Parse(sourceURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return ErrHostNotAllowed
+	}
+	for _, host := range p.config.AllowedHosts {
+		if strings.EqualFold(parsed.Host, host) {
+			return nil
+		}
+	}
+	return ErrHostNotAllowed
+}
+
+type cachedImage struct {
+	Image
+	source string
+}
+
+// paths returns where the image and its metadata are cached. Keys are
+// hashed so they can never name a path outside CacheDir.
+func (p *Proxy) paths(key string) (string, string) {
+	sum := sha256.Sum256([]byte(key))
+	name := filepath.Join(p.config.CacheDir, hex.EncodeToString(sum[:]))
+	return name, name + ".json"
+}
+
+func (p *Proxy) load(key string) (*cachedImage, error) {
+	imagePath, metaPath := p.paths(key)
+
+	raw, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil, err
+	}
+	var meta metadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cachedImage{
+		Image: Image{
+			Data:        data,
+			ContentType: meta.ContentType,
+			ETag:        meta.ETag,
+			FetchedAt:   meta.FetchedAt,
+		},
+		source: meta.SourceURL,
+	}, nil
+}
+
+// store writes the image before its metadata, each through a rename, so a
+// reader never pairs metadata with a half-written image
+func (p *Proxy) store(key, sourceURL string, image *Image) error {
+	if err := os.MkdirAll(p.config.CacheDir, 0o755); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(metadata{
+		SourceURL:   sourceURL,
+		ContentType: image.ContentType,
+		ETag:        image.ETag,
+		FetchedAt:   image.FetchedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	imagePath, metaPath := p.paths(key)
+	if err := writeFile(imagePath, image.Data); err != nil {
+		return err
+	}
+	return writeFile(metaPath, meta)
+}
+
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".avatar-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ETag returns a strong entity tag for data
+func ETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
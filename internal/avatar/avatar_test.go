@@ -0,0 +1,146 @@
+package avatar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var pngBytes = []byte("\x89PNG\r\n\x1a\nfake-image-data")
+
+// newUpstream serves avatars from paths, counting requests
+func newUpstream(t *testing.T) (*httptest.Server, *int) {
+	t.Helper()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/octocat.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(pngBytes)
+		case "/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(bytes.Repeat([]byte("x"), 2048))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestProxy(t *testing.T, server *httptest.Server) *Proxy {
+	t.Helper()
+	upstream, _ := url.Parse(server.URL)
+	return NewProxy(Config{
+		CacheDir:     t.TempDir(),
+		TTL:          time.Hour,
+		MaxBytes:     1024,
+		AllowedHosts: []string{upstream.Host},
+	})
+}
+
+func TestProxyCachesFetchedAvatars(t *testing.T) {
+	server, requests := newUpstream(t)
+	proxy := newTestProxy(t, server)
+	now := time.Now()
+	proxy.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		image, err := proxy.Get(ctx, "user-1", server.URL+"/octocat.png")
+		if err != nil {
+			t.Fatalf("Get %d failed: %v", i+1, err)
+		}
+		if !bytes.Equal(image.Data, pngBytes) || image.ContentType != "image/png" || image.ETag != ETag(pngBytes) {
+			t.Errorf("Get %d: unexpected image %+v", i+1, image)
+		}
+	}
+	if *requests != 1 {
+		t.Errorf("Expected the second Get to be served from the cache, upstream saw %d requests", *requests)
+	}
+
+	// A stale cache is fetched again
+	now = now.Add(2 * time.Hour)
+	if _, err := proxy.Get(ctx, "user-1", server.URL+"/octocat.png"); err != nil {
+		t.Fatalf("Get after the TTL failed: %v", err)
+	}
+	if *requests != 2 {
+		t.Errorf("Expected a refetch after the TTL, upstream saw %d requests", *requests)
+	}
+
+	// So is a changed avatar URL, even within the TTL
+	if _, err := proxy.Get(ctx, "user-1", server.URL+"/missing.png"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for the new URL, got %v", err)
+	}
+}
+
+func TestProxyServesStaleCopyWhenUpstreamFails(t *testing.T) {
+	server, _ := newUpstream(t)
+	proxy := newTestProxy(t, server)
+	now := time.Now()
+	proxy.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, err := proxy.Get(ctx, "user-1", server.URL+"/octocat.png"); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	server.Close()
+	now = now.Add(2 * time.Hour)
+	image, err := proxy.Get(ctx, "user-1", server.URL+"/octocat.png")
+	if err != nil || !bytes.Equal(image.Data, pngBytes) {
+		t.Errorf("Expected the stale copy while upstream is down, got %v, %v", image, err)
+	}
+}
+
+func TestProxyRejectsBadAvatars(t *testing.T) {
+	server, requests := newUpstream(t)
+	proxy := newTestProxy(t, server)
+
+	tests := []struct {
+		name string
+		url  string
+		want error
+	}{
+		{"missing", server.URL + "/missing.png", ErrNotFound},
+		{"oversize", server.URL + "/huge.png", ErrTooLarge},
+		{"not an image", server.URL + "/page.html", ErrUnsupportedContent},
+		{"other host", "https://example.com/octocat.png", ErrHostNotAllowed},
+		{"other scheme", "file:///etc/passwd", ErrHostNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image, err := proxy.Get(context.Background(), "user-"+tt.name, tt.url)
+			if !errors.Is(err, tt.want) || image != nil {
+				t.Errorf("Expected %v, got %v, %v", tt.want, image, err)
+			}
+		})
+	}
+
+	if *requests != 3 {
+		t.Errorf("Expected disallowed URLs never to be requested, upstream saw %d requests", *requests)
+	}
+}
+
+func TestIdenticonIsDeterministic(t *testing.T) {
+	first := Identicon("user-1")
+	if !bytes.Equal(first, Identicon("user-1")) {
+		t.Error("Expected the same identicon for the same seed")
+	}
+	if bytes.Equal(first, Identicon("user-2")) {
+		t.Error("Expected different identicons for different seeds")
+	}
+	if !strings.HasPrefix(string(first), "\x89PNG") {
+		t.Error("Expected a PNG")
+	}
+}
@@ -0,0 +1,58 @@
+package avatar
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+const (
+	// identiconGrid is the number of cells per side
+	identiconGrid = 5
+	// identiconCell is the size of a cell in pixels
+	identiconCell = 40
+	// identiconMargin is the blank border in pixels
+	identiconMargin = 20
+)
+
+// Identicon renders a PNG identicon for seed: a horizontally symmetric 5x5
+// pattern in one color, both taken from a hash of the seed so the same seed
+// always gives the same image
+func Identicon(seed string) []byte {
+	sum := sha256.Sum256([]byte(seed))
+
+	size := identiconGrid*identiconCell + 2*identiconMargin
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: color.RGBA{R: 240, G: 240, B: 240, A: 255}}, image.Point{}, draw.Src)
+
+	// Keep the color dark enough to stand out against the background
+	fill := &image.Uniform{C: color.RGBA{R: sum[0] / 2, G: sum[1] / 2, B: sum[2] / 2, A: 255}}
+
+	// Fill the left half and middle column from the hash, mirroring onto
+	// the right half
+	half := (identiconGrid + 1) / 2
+	for row := 0; row < identiconGrid; row++ {
+		for col := 0; col < half; col++ {
+			if sum[3+row*half+col]%2 == 0 {
+				continue
+			}
+			for _, x := range []int{col, identiconGrid - 1 - col} {
+				cell := image.Rect(
+					identiconMargin+x*identiconCell,
+					identiconMargin+row*identiconCell,
+					identiconMargin+(x+1)*identiconCell,
+					identiconMargin+(row+1)*identiconCell,
+				)
+				draw.Draw(img, cell, fill, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	// Encoding an in-memory RGBA image cannot fail
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
@@ -0,0 +1,135 @@
+// Package brewfile imports the taps, formulae and casks listed in a
+// Homebrew Bundle Brewfile.
+package brewfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Brewfile holds the packages declared in a Brewfile, in file order and
+// without duplicates
+type Brewfile struct {
+	Taps  []string
+	Brews []string
+	Casks []string
+}
+
+// ParseError reports a line that could not be imported
+type ParseError struct {
+	Line    int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// ignored lists Brewfile entries that have no equivalent in a template and
+// are skipped rather than rejected
+var ignored = map[string]bool{
+	"cask_args": true,
+	"mas":       true,
+	"vscode":    true,
+	"whalebrew": true,
+	"go":        true,
+	"cargo":     true,
+}
+
+// Parse reads a Brewfile. Options after the package name (args:, link:,
+// restart_service: and so on) are accepted and dropped.
+func Parse(r io.Reader) (*Brewfile, error) {
+	result := &Brewfile{}
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		directive, rest, _ := strings.Cut(line, " ")
+		if ignored[directive] {
+			continue
+		}
+
+		var target *[]string
+		switch directive {
+		case "tap":
+			target = &result.Taps
+		case "brew":
+			target = &result.Brews
+		case "cask":
+			target = &result.Casks
+		default:
+			return nil, &ParseError{Line: lineNumber, Message: fmt.Sprintf("unknown entry %q", directive)}
+		}
+
+		name, err := quotedName(rest)
+		if err != nil {
+			return nil, &ParseError{Line: lineNumber, Message: err.Error()}
+		}
+
+		key := directive + ":" + name
+		if !seen[key] {
+			seen[key] = true
+			*target = append(*target, name)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// quotedName returns the first quoted string in s, which is the package name
+// in every Brewfile entry
+func quotedName(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", fmt.Errorf("missing package name")
+	}
+
+	quote := s[0]
+	if quote != '"' && quote != '\'' {
+		return "", fmt.Errorf("package name must be quoted")
+	}
+
+	end := strings.IndexByte(s[1:], quote)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated package name")
+	}
+
+	name := strings.TrimSpace(s[1 : end+1])
+	if name == "" {
+		return "", fmt.Errorf("missing package name")
+	}
+
+	return name, nil
+}
+
+// stripComment drops a trailing # comment that is not inside quotes
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,65 @@
+package brewfile
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := `# Taps
+tap "homebrew/bundle"
+tap 'homebrew/cask-fonts'
+
+brew "git"
+brew "neovim", args: ["HEAD"] # nightly
+brew "postgresql@16", restart_service: :changed
+brew "git"
+cask "iterm2"
+cask "font-fira-code"
+mas "Xcode", id: 497799835
+vscode "golang.go"
+`
+
+	got, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse Brewfile: %v", err)
+	}
+
+	want := &Brewfile{
+		Taps:  []string{"homebrew/bundle", "homebrew/cask-fonts"},
+		Brews: []string{"git", "neovim", "postgresql@16"},
+		Casks: []string{"iterm2", "font-fira-code"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+	}{
+		{name: "unknown entry", input: "brew \"git\"\nnpm \"left-pad\"", line: 2},
+		{name: "unquoted name", input: "brew git", line: 1},
+		{name: "unterminated name", input: "\ncask \"iterm2", line: 2},
+		{name: "missing name", input: "tap", line: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected ParseError, got %v", err)
+			}
+			if parseErr.Line != tt.line {
+				t.Errorf("Expected error on line %d, got %d", tt.line, parseErr.Line)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package compat decides which template features a CLI release understands,
+// so downloads can warn clients that would silently drop newer fields.
+package compat
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dotfiles-api/internal/models"
+)
+
+// Version is a CLI release number. Pre-release and build suffixes are
+// ignored.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// ParseVersion parses versions such as "0.5", "0.5.1" and "v1.2.3-beta.1"
+func ParseVersion(raw string) (Version, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	if i := strings.IndexAny(trimmed, "-+"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+
+	parts := strings.Split(trimmed, ".")
+	if trimmed == "" || len(parts) > 3 {
+		return Version{}, fmt.Errorf("invalid version %q", raw)
+	}
+
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version %q", raw)
+		}
+		numbers[i] = n
+	}
+
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, nil
+}
+
+// ParseClient reads the version from an X-Client header, which is either a
+// bare version or "name/version" optionally followed by more tokens, such as
+// "dotfiles-cli/0.4.2 (darwin)". It reports false when no version is found.
+func ParseClient(header string) (Version, bool) {
+	fields := strings.Fields(header)
+	if len(fields) == 0 {
+		return Version{}, false
+	}
+
+	product := fields[0]
+	if i := strings.LastIndex(product, "/"); i >= 0 {
+		product = product[i+1:]
+	}
+
+	version, err := ParseVersion(product)
+	if err != nil {
+		return Version{}, false
+	}
+	return version, true
+}
+
+// Less reports whether v is an older release than other
+func (v Version) Less(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major < other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor < other.Minor
+	}
+	return v.Patch < other.Patch
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Requirement is the oldest CLI release that understands a schema's features
+type Requirement struct {
+	Schema     int
+	Feature    string
+	MinVersion Version
+}
+
+// Matrix lists the requirement of each schema newer than plain package
+// lists
+type Matrix []Requirement
+
+// DefaultMatrix returns the CLI releases that introduced each schema
+func DefaultMatrix() Matrix {
+	return Matrix{
+		{Schema: models.SchemaVersionHooks, Feature: "hooks and package configs", MinVersion: Version{Minor: 4}},
+		{Schema: models.SchemaVersionPins, Feature: "package pins", MinVersion: Version{Minor: 5}},
+	}
+}
+
+// WithMinimum returns a copy of the matrix with the schema's minimum CLI
+// version replaced. Schemas not in the matrix are ignored.
+func (m Matrix) WithMinimum(schema int, version Version) Matrix {
+	updated := make(Matrix, len(m))
+	copy(updated, m)
+	for i := range updated {
+		if updated[i].Schema == schema {
+			updated[i].MinVersion = version
+		}
+	}
+	return updated
+}
+
+// Warnings describes each feature of the schema, and the schemas before it,
+// that the client is too old to understand, oldest feature first
+func (m Matrix) Warnings(schema int, client Version) []string {
+	requirements := make(Matrix, len(m))
+	copy(requirements, m)
+	sort.SliceStable(requirements, func(i, j int) bool {
+		return requirements[i].Schema < requirements[j].Schema
+	})
+
+	var warnings []string
+	for _, requirement := range requirements {
+		if requirement.Schema > schema || !client.Less(requirement.MinVersion) {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("your CLI does not support %s; upgrade to >= %s",
+			requirement.Feature, requirement.MinVersion))
+	}
+	return warnings
+}
@@ -0,0 +1,119 @@
+package compat
+
+import (
+	"reflect"
+	"testing"
+
+	"dotfiles-api/internal/models"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Version
+		wantErr bool
+	}{
+		{raw: "0.5", want: Version{Minor: 5}},
+		{raw: "1.2.3", want: Version{Major: 1, Minor: 2, Patch: 3}},
+		{raw: "v0.4.10-beta.1", want: Version{Minor: 4, Patch: 10}},
+		{raw: "2+build.7", want: Version{Major: 2}},
+		{raw: "", wantErr: true},
+		{raw: "1.2.3.4", wantErr: true},
+		{raw: "latest", wantErr: true},
+		{raw: "1..2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseVersion(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseVersion(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseClient(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Version
+		ok     bool
+	}{
+		{header: "dotfiles-cli/0.4.2", want: Version{Minor: 4, Patch: 2}, ok: true},
+		{header: "dotfiles-cli/v1.0 (darwin; arm64)", want: Version{Major: 1}, ok: true},
+		{header: "0.3.0", want: Version{Minor: 3}, ok: true},
+		{header: "dotfiles-cli", ok: false},
+		{header: "", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseClient(tt.header)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseClient(%q) = %v, %v; want %v, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestVersionLess(t *testing.T) {
+	ordered := []Version{{}, {Patch: 9}, {Minor: 4}, {Minor: 10}, {Major: 1}}
+	for i := range ordered {
+		for j := range ordered {
+			if got := ordered[i].Less(ordered[j]); got != (i < j) {
+				t.Errorf("%v.Less(%v) = %v", ordered[i], ordered[j], got)
+			}
+		}
+	}
+}
+
+func TestMatrixWarnings(t *testing.T) {
+	matrix := DefaultMatrix()
+
+	if got := matrix.Warnings(models.SchemaVersionPlain, Version{Minor: 1}); got != nil {
+		t.Errorf("Expected no warnings for a plain template, got %v", got)
+	}
+	if got := matrix.Warnings(models.SchemaVersionHooks, Version{Minor: 4}); got != nil {
+		t.Errorf("Expected no warnings for a new enough client, got %v", got)
+	}
+
+	want := []string{
+		"your CLI does not support hooks and package configs; upgrade to >= 0.4.0",
+		"your CLI does not support package pins; upgrade to >= 0.5.0",
+	}
+	if got := matrix.Warnings(models.SchemaVersionPins, Version{Minor: 3, Patch: 9}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	raised := matrix.WithMinimum(models.SchemaVersionHooks, Version{Minor: 4, Patch: 2})
+	if got := raised.Warnings(models.SchemaVersionHooks, Version{Minor: 4, Patch: 1}); len(got) != 1 {
+		t.Errorf("Expected the raised minimum to warn, got %v", got)
+	}
+	if got := matrix.Warnings(models.SchemaVersionHooks, Version{Minor: 4, Patch: 1}); got != nil {
+		t.Errorf("Expected WithMinimum to leave the original matrix alone, got %v", got)
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"dotfiles-cli/0.5.0 (Darwin/arm64)", models.PlatformMacARM64},
+		{"dotfiles-cli/0.5.0 (darwin/aarch64)", models.PlatformMacARM64},
+		{"dotfiles-cli/0.5.0 (Darwin/x86_64)", models.PlatformMacX86},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7)", models.PlatformMacX86},
+		{"dotfiles-cli/0.5.0 (Linux/aarch64)", models.PlatformLinuxARM64},
+		{"dotfiles-cli/0.5.0 (Linux/x86_64)", models.PlatformLinuxX86},
+		{"Mozilla/5.0 (X11; Linux x86_64)", models.PlatformLinuxX86},
+		{"curl/8.4.0", ""},
+		{"dotfiles-cli/0.5.0 (Darwin)", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ParsePlatform(tt.userAgent); got != tt.want {
+			t.Errorf("ParsePlatform(%q) = %q, want %q", tt.userAgent, got, tt.want)
+		}
+	}
+}
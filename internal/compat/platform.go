@@ -0,0 +1,31 @@
+package compat
+
+import (
+	"regexp"
+
+	"dotfiles-api/internal/models"
+)
+
+// platformPatterns match User-Agents such as "dotfiles-cli/0.5.0
+// (Darwin/arm64)", checked in order so an architecture wins over a bare OS
+// name
+var platformPatterns = []struct {
+	platform string
+	pattern  *regexp.Regexp
+}{
+	{models.PlatformMacARM64, regexp.MustCompile(`(?i)darwin[/ _-]?(arm64|aarch64)`)},
+	{models.PlatformMacX86, regexp.MustCompile(`(?i)darwin[/ _-]?(x86_64|amd64)|intel mac os x`)},
+	{models.PlatformLinuxARM64, regexp.MustCompile(`(?i)linux[/ _-]?(arm64|aarch64)`)},
+	{models.PlatformLinuxX86, regexp.MustCompile(`(?i)linux`)},
+}
+
+// ParsePlatform returns the platform a User-Agent names, or "" when it
+// names none. Linux without an architecture is taken to be x86.
+func ParsePlatform(userAgent string) string {
+	for _, p := range platformPatterns {
+		if p.pattern.MatchString(userAgent) {
+			return p.platform
+		}
+	}
+	return ""
+}
@@ -2,9 +2,22 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
+
+	"dotfiles-api/internal/auth"
+	"dotfiles-api/internal/avatar"
+	"dotfiles-api/internal/compat"
+	"dotfiles-api/internal/homebrew"
+	"dotfiles-api/internal/images"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/popularity"
 )
 
 type Config struct {
@@ -13,6 +26,7 @@ type Config struct {
 	OAuth    OAuthConfig    `json:"oauth"`
 	Storage  StorageConfig  `json:"storage"`
 	Security SecurityConfig `json:"security"`
+	CORS     CORSConfig     `json:"cors"`
 	Features FeatureConfig  `json:"features"`
 }
 
@@ -32,9 +46,12 @@ type DatabaseConfig struct {
 }
 
 type MongoDB struct {
-	URI        string `json:"uri"`
-	Database   string `json:"database"`
-	Collection string `json:"collection"`
+	URI            string        `json:"uri"`
+	Database       string        `json:"database"`
+	Collection     string        `json:"collection"`
+	ReadPreference string        `json:"read_preference"`
+	ReadTimeout    time.Duration `json:"read_timeout"`
+	WriteTimeout   time.Duration `json:"write_timeout"`
 }
 
 type OAuthConfig struct {
@@ -62,6 +79,47 @@ type SecurityConfig struct {
 	MaxUploadSize       int64         `json:"max_upload_size"`
 	RequireHTTPS        bool          `json:"require_https"`
 	EnableCSRFProtection bool         `json:"enable_csrf_protection"`
+	AdminUsers          []string      `json:"admin_users"`
+}
+
+// RateLimit is how many requests one client IP may make per window. Zero
+// requests turns the limiter off.
+type RateLimit struct {
+	Requests int           `json:"requests"`
+	Window   time.Duration `json:"window"`
+}
+
+// RateLimitConfig holds the limiters applied to incoming requests
+type RateLimitConfig struct {
+	Global RateLimit `json:"global"`
+	// Write applies on top of Global to POST, PUT, PATCH and DELETE requests
+	Write RateLimit `json:"write"`
+	// Report applies on top of both to template reports
+	Report RateLimit `json:"report"`
+	// APIKey replaces Global and Write for requests made with an API key,
+	// counting per key rather than per IP
+	APIKey RateLimit `json:"api_key"`
+	// ExemptPaths are never limited, such as health checks
+	ExemptPaths []string `json:"exempt_paths"`
+}
+
+// MemoryLimits caps the in-memory stores so a server running without
+// MongoDB cannot be made to grow without bound. Zero means unlimited.
+type MemoryLimits struct {
+	Templates int `json:"templates"`
+	Configs   int `json:"configs"`
+	Reviews   int `json:"reviews"`
+	Users     int `json:"users"`
+	// Sessions applies whatever the storage, since sessions are always
+	// held in memory
+	Sessions int `json:"sessions"`
+}
+
+type CORSConfig struct {
+	AllowedMethods []string      `json:"allowed_methods"`
+	AllowedHeaders []string      `json:"allowed_headers"`
+	ExposedHeaders []string      `json:"exposed_headers"`
+	MaxAge         time.Duration `json:"max_age"`
 }
 
 type FeatureConfig struct {
@@ -70,8 +128,28 @@ type FeatureConfig struct {
 	EnableReviews         bool `json:"enable_reviews"`
 	EnableFeaturedContent bool `json:"enable_featured_content"`
 	EnableAnalytics       bool `json:"enable_analytics"`
-	MaxTemplatesPerUser   int  `json:"max_templates_per_user"`
-	MaxOrgsPerUser        int  `json:"max_orgs_per_user"`
+	// EnableAnonymousUploads lets signed-out callers upload configs and
+	// create public templates. Off by default: anonymous content cannot be
+	// traced to an account, so spam and abuse can only be cleaned up by hand.
+	EnableAnonymousUploads bool `json:"enable_anonymous_uploads"`
+	// EnableRequireReviewComment rejects reviews without text; ratings
+	// alone go through the quick rating endpoint instead
+	EnableRequireReviewComment bool `json:"enable_require_review_comment"`
+	// ReviewMinCommentLength is the fewest characters a review comment may
+	// have when one is given; 0 allows any length
+	ReviewMinCommentLength int `json:"review_min_comment_length"`
+	MaxTemplatesPerUser    int `json:"max_templates_per_user"`
+	MaxOrgsPerUser         int `json:"max_orgs_per_user"`
+
+	// Content limits bound how large one template or config may be; zero
+	// turns a limit off. MaxListEntries applies to each package list,
+	// MaxHookCommands to each hook phase and MaxCommandLength to each hook
+	// command. MaxContentBytes caps the whole serialized document.
+	MaxListEntries    int `json:"max_list_entries"`
+	MaxHookCommands   int `json:"max_hook_commands"`
+	MaxCommandLength  int `json:"max_command_length"`
+	MaxPackageConfigs int `json:"max_package_configs"`
+	MaxContentBytes   int `json:"max_content_bytes"`
 }
 
 func Load() (*Config, error) {
@@ -85,12 +163,8 @@ func Load() (*Config, error) {
 			Environment:  getEnv("ENVIRONMENT", "development"),
 		},
 		Database: DatabaseConfig{
-			Type: getEnv("DATABASE_TYPE", "memory"),
-			MongoDB: MongoDB{
-				URI:        getEnv("MONGODB_URI", "mongodb://localhost:27017"),
-				Database:   getEnv("MONGODB_DATABASE", "dotfiles"),
-				Collection: getEnv("MONGODB_COLLECTION", "templates"),
-			},
+			Type:     getEnv("DATABASE_TYPE", "memory"),
+			MongoDB:  LoadMongoDB(),
 			InMemory: getEnvAsBool("DATABASE_IN_MEMORY", true),
 		},
 		OAuth: OAuthConfig{
@@ -111,19 +185,13 @@ func Load() (*Config, error) {
 			RateLimitWindow:       getEnvAsDuration("RATE_LIMIT_WINDOW", time.Hour),
 			AllowedOrigins:        []string{getEnv("ALLOWED_ORIGINS", "*")},
 			InviteTokenExpiry:     getEnvAsDuration("INVITE_TOKEN_EXPIRY", 7*24*time.Hour),
-			MaxUploadSize:         getEnvAsInt64("MAX_UPLOAD_SIZE", 10*1024*1024), // 10MB
+			MaxUploadSize:         LoadMaxUploadSize(),
 			RequireHTTPS:          getEnvAsBool("REQUIRE_HTTPS", false),
 			EnableCSRFProtection:  getEnvAsBool("ENABLE_CSRF_PROTECTION", true),
+			AdminUsers:            LoadAdminUsers(),
 		},
-		Features: FeatureConfig{
-			EnableRegistration:    getEnvAsBool("ENABLE_REGISTRATION", true),
-			EnableOrganizations:   getEnvAsBool("ENABLE_ORGANIZATIONS", true),
-			EnableReviews:         getEnvAsBool("ENABLE_REVIEWS", true),
-			EnableFeaturedContent: getEnvAsBool("ENABLE_FEATURED_CONTENT", true),
-			EnableAnalytics:       getEnvAsBool("ENABLE_ANALYTICS", false),
-			MaxTemplatesPerUser:   getEnvAsInt("MAX_TEMPLATES_PER_USER", 100),
-			MaxOrgsPerUser:        getEnvAsInt("MAX_ORGS_PER_USER", 10),
-		},
+		CORS:     LoadCORS(),
+		Features: LoadFeatures(),
 	}
 
 	if err := config.Validate(); err != nil {
@@ -133,6 +201,309 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
+// DefaultCORSConfig returns the CORS settings used when none are configured
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "X-API-Compat", "X-Client"},
+		ExposedHeaders: []string{"Content-Length", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "Warning"},
+		MaxAge:         24 * time.Hour,
+	}
+}
+
+// LoadCORS reads the CORS settings from the environment, falling back to
+// DefaultCORSConfig for anything unset
+func LoadCORS() CORSConfig {
+	defaults := DefaultCORSConfig()
+	return CORSConfig{
+		AllowedMethods: getEnvAsSlice("CORS_ALLOWED_METHODS", defaults.AllowedMethods),
+		AllowedHeaders: getEnvAsSlice("CORS_ALLOWED_HEADERS", defaults.AllowedHeaders),
+		ExposedHeaders: getEnvAsSlice("CORS_EXPOSED_HEADERS", defaults.ExposedHeaders),
+		MaxAge:         getEnvAsDuration("CORS_MAX_AGE", defaults.MaxAge),
+	}
+}
+
+// LoadTrustedProxies reads TRUSTED_PROXIES, the comma-separated CIDR ranges
+// of reverse proxies whose X-Forwarded-For headers are believed. Bare IPs
+// are taken as single-address ranges. Unset trusts no proxy, so the client
+// IP is always the direct peer.
+func LoadTrustedProxies() ([]string, error) {
+	var cidrs []string
+	for _, entry := range getEnvAsSlice("TRUSTED_PROXIES", nil) {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			entry = fmt.Sprintf("%s/%d", ip, bits)
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q: %w", entry, err)
+		}
+		cidrs = append(cidrs, network.String())
+	}
+	return cidrs, nil
+}
+
+// LoadRateLimits reads the global, write-path, report and API key rate
+// limits
+func LoadRateLimits() RateLimitConfig {
+	return RateLimitConfig{
+		Global: RateLimit{
+			Requests: getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
+			Window:   getEnvAsDuration("RATE_LIMIT_WINDOW", time.Hour),
+		},
+		Write: RateLimit{
+			Requests: getEnvAsInt("RATE_LIMIT_WRITE_REQUESTS", 20),
+			Window:   getEnvAsDuration("RATE_LIMIT_WRITE_WINDOW", time.Hour),
+		},
+		Report: RateLimit{
+			Requests: getEnvAsInt("RATE_LIMIT_REPORT_REQUESTS", 10),
+			Window:   getEnvAsDuration("RATE_LIMIT_REPORT_WINDOW", time.Hour),
+		},
+		APIKey: RateLimit{
+			Requests: getEnvAsInt("RATE_LIMIT_API_KEY_REQUESTS", 1000),
+			Window:   getEnvAsDuration("RATE_LIMIT_API_KEY_WINDOW", time.Hour),
+		},
+		ExemptPaths: getEnvAsSlice("RATE_LIMIT_EXEMPT_PATHS", []string{"/health", "/metrics"}),
+	}
+}
+
+// LoadFeatures reads the feature flags and per-user limits
+func LoadFeatures() FeatureConfig {
+	return FeatureConfig{
+		EnableRegistration:         getEnvAsBool("ENABLE_REGISTRATION", true),
+		EnableOrganizations:        getEnvAsBool("ENABLE_ORGANIZATIONS", true),
+		EnableReviews:              getEnvAsBool("ENABLE_REVIEWS", true),
+		EnableFeaturedContent:      getEnvAsBool("ENABLE_FEATURED_CONTENT", true),
+		EnableAnalytics:            getEnvAsBool("ENABLE_ANALYTICS", false),
+		EnableAnonymousUploads:     getEnvAsBool("ENABLE_ANONYMOUS_UPLOADS", false),
+		EnableRequireReviewComment: getEnvAsBool("ENABLE_REQUIRE_REVIEW_COMMENT", false),
+		ReviewMinCommentLength:     getEnvAsInt("REVIEW_MIN_COMMENT_LENGTH", 0),
+		MaxTemplatesPerUser:        getEnvAsInt("MAX_TEMPLATES_PER_USER", 100),
+		MaxOrgsPerUser:             getEnvAsInt("MAX_ORGS_PER_USER", 10),
+		MaxListEntries:             getEnvAsInt("MAX_LIST_ENTRIES", 500),
+		MaxHookCommands:            getEnvAsInt("MAX_HOOK_COMMANDS", 50),
+		MaxCommandLength:           getEnvAsInt("MAX_COMMAND_LENGTH", 1000),
+		MaxPackageConfigs:          getEnvAsInt("MAX_PACKAGE_CONFIGS", 100),
+		MaxContentBytes:            getEnvAsInt("MAX_CONTENT_BYTES", 256<<10),
+	}
+}
+
+// LoadMongoDB reads the MongoDB connection settings. Reads default to the
+// primary; set MONGODB_READ_PREFERENCE (e.g. secondaryPreferred) to offload
+// get, list and search traffic to replica set secondaries.
+func LoadMongoDB() MongoDB {
+	return MongoDB{
+		URI:            getEnv("MONGODB_URI", "mongodb://localhost:27017"),
+		Database:       getEnv("MONGODB_DATABASE", "dotfiles"),
+		Collection:     getEnv("MONGODB_COLLECTION", "templates"),
+		ReadPreference: getEnv("MONGODB_READ_PREFERENCE", "primary"),
+		ReadTimeout:    getEnvAsDuration("MONGODB_READ_TIMEOUT", 5*time.Second),
+		WriteTimeout:   getEnvAsDuration("MONGODB_WRITE_TIMEOUT", 10*time.Second),
+	}
+}
+
+// LoadMemoryLimits reads the entry limits of the in-memory stores and the
+// session manager
+func LoadMemoryLimits() MemoryLimits {
+	return MemoryLimits{
+		Templates: getEnvAsInt("MEMORY_MAX_TEMPLATES", 10000),
+		Configs:   getEnvAsInt("MEMORY_MAX_CONFIGS", 10000),
+		Reviews:   getEnvAsInt("MEMORY_MAX_REVIEWS", 50000),
+		Users:     getEnvAsInt("MEMORY_MAX_USERS", 10000),
+		Sessions:  getEnvAsInt("MEMORY_MAX_SESSIONS", 50000),
+	}
+}
+
+// LoadRunMigrations reads whether pending MongoDB migrations are applied
+// at startup
+func LoadRunMigrations() bool {
+	return getEnvAsBool("RUN_MIGRATIONS", false)
+}
+
+// LoadInviteTokenBytes reads how many random bytes organization invite
+// tokens carry
+func LoadInviteTokenBytes() int {
+	return getEnvAsInt("INVITE_TOKEN_BYTES", 32)
+}
+
+// LoadMembershipCacheTTL reads how long organization roles cached in a
+// session are trusted before they are checked for changes. Zero turns the
+// cache off.
+func LoadMembershipCacheTTL() time.Duration {
+	return getEnvAsDuration("MEMBERSHIP_CACHE_TTL", time.Minute)
+}
+
+// LoadReportAutoUnlistThreshold reads how many open reports unlist a
+// template pending review. Zero turns automatic unlisting off.
+func LoadReportAutoUnlistThreshold() int {
+	return getEnvAsInt("REPORT_AUTO_UNLIST_THRESHOLD", 5)
+}
+
+// LoadDigestInterval reads how often the tag subscription digest is sent
+func LoadDigestInterval() time.Duration {
+	return getEnvAsDuration("DIGEST_INTERVAL", 24*time.Hour)
+}
+
+// LoadPopularityWeights reads the template popularity score coefficients,
+// falling back to popularity.DefaultWeights for anything unset
+func LoadPopularityWeights() popularity.Weights {
+	defaults := popularity.DefaultWeights()
+	return popularity.Weights{
+		Downloads: getEnvAsFloat("POPULARITY_DOWNLOAD_WEIGHT", defaults.Downloads),
+		Rating:    getEnvAsFloat("POPULARITY_RATING_WEIGHT", defaults.Rating),
+		Favorites: getEnvAsFloat("POPULARITY_FAVORITE_WEIGHT", defaults.Favorites),
+		HalfLife:  getEnvAsDuration("POPULARITY_HALF_LIFE", defaults.HalfLife),
+	}
+}
+
+// LoadPopularityInterval reads how often template popularity scores are
+// recomputed
+func LoadPopularityInterval() time.Duration {
+	return getEnvAsDuration("POPULARITY_INTERVAL", time.Hour)
+}
+
+// LoadIntegritySweepInterval reads how often orphaned reviews, favorites
+// and memberships are swept
+func LoadIntegritySweepInterval() time.Duration {
+	return getEnvAsDuration("INTEGRITY_SWEEP_INTERVAL", 7*24*time.Hour)
+}
+
+// LoadReconcileInterval reads how often denormalized counters are
+// reconciled. Unset or 0 leaves it to the admin endpoint.
+func LoadReconcileInterval() time.Duration {
+	return getEnvAsDuration("RECONCILE_INTERVAL", 0)
+}
+
+// LoadAvatarProxy reads the avatar proxy settings. Avatars are cached in
+// an avatars directory under STATIC_FILES_PATH.
+func LoadAvatarProxy() avatar.Config {
+	defaults := avatar.DefaultConfig()
+	return avatar.Config{
+		CacheDir:     filepath.Join(getEnv("STATIC_FILES_PATH", "./static"), "avatars"),
+		TTL:          getEnvAsDuration("AVATAR_CACHE_TTL", defaults.TTL),
+		MaxBytes:     getEnvAsInt64("AVATAR_MAX_BYTES", defaults.MaxBytes),
+		AllowedHosts: getEnvAsSlice("AVATAR_ALLOWED_HOSTS", defaults.AllowedHosts),
+	}
+}
+
+// LoadOAuthProviders builds the sign-in providers named in OAUTH_PROVIDERS
+// (default: github). Unknown names are logged and skipped.
+func LoadOAuthProviders() []auth.Provider {
+	var providers []auth.Provider
+	for _, name := range getEnvAsSlice("OAUTH_PROVIDERS", []string{auth.ProviderGitHub}) {
+		switch strings.ToLower(name) {
+		case auth.ProviderGitHub:
+			providers = append(providers, auth.NewGitHubProvider(auth.ProviderConfig{
+				ClientID:     getEnv("GITHUB_CLIENT_ID", ""),
+				ClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
+				RedirectURL:  getEnv("OAUTH_REDIRECT_URL", ""),
+				BaseURL:      getEnv("GITHUB_BASE_URL", ""),
+			}))
+		case auth.ProviderGitLab:
+			providers = append(providers, auth.NewGitLabProvider(auth.ProviderConfig{
+				ClientID:     getEnv("GITLAB_CLIENT_ID", ""),
+				ClientSecret: getEnv("GITLAB_CLIENT_SECRET", ""),
+				RedirectURL:  getEnv("GITLAB_REDIRECT_URL", ""),
+				BaseURL:      getEnv("GITLAB_BASE_URL", ""),
+			}))
+		default:
+			slog.Warn("Ignoring unknown OAuth provider", "provider", name)
+		}
+	}
+	return providers
+}
+
+// LoadHomebrew reads where install estimates look up package sizes and how
+// long they are cached
+func LoadHomebrew() homebrew.Config {
+	defaults := homebrew.DefaultConfig()
+	return homebrew.Config{
+		APIURL:      getEnv("HOMEBREW_API_URL", defaults.APIURL),
+		BottleTag:   getEnv("HOMEBREW_BOTTLE_TAG", defaults.BottleTag),
+		CacheTTL:    getEnvAsDuration("HOMEBREW_CACHE_TTL", defaults.CacheTTL),
+		Concurrency: defaults.Concurrency,
+	}
+}
+
+// LoadTemplateImages reads where template images are stored. They are kept
+// in a templates directory under STATIC_FILES_PATH and served from
+// /static/templates.
+func LoadTemplateImages() images.Config {
+	defaults := images.DefaultConfig()
+	return images.Config{
+		Dir:            filepath.Join(getEnv("STATIC_FILES_PATH", "./static"), "templates"),
+		URLPath:        defaults.URLPath,
+		MaxBytes:       defaults.MaxBytes,
+		MaxPerTemplate: defaults.MaxPerTemplate,
+	}
+}
+
+// LoadMaxUploadSize reads the largest request body, in bytes, accepted by
+// the upload and create endpoints
+func LoadMaxUploadSize() int64 {
+	return getEnvAsInt64("MAX_UPLOAD_SIZE", 10*1024*1024) // 10MB
+}
+
+// LoadPublicAPIURL reads the API origin rendered into install snippets.
+// Empty means each request's own host.
+func LoadPublicAPIURL() string {
+	return getEnv("PUBLIC_API_URL", "")
+}
+
+// LoadFrontendURL reads the frontend origin that template share links
+// point at. Empty links to the API instead.
+func LoadFrontendURL() string {
+	return getEnv("FRONTEND_URL", "")
+}
+
+// LoadPrimaryHosts reads the hosts the API itself is served on, which are
+// never treated as organization custom domains. It defaults to the hosts of
+// PUBLIC_API_URL and FRONTEND_URL.
+func LoadPrimaryHosts() []string {
+	var defaults []string
+	for _, raw := range []string{LoadPublicAPIURL(), LoadFrontendURL()} {
+		if parsed, err := url.Parse(raw); err == nil && parsed.Host != "" {
+			defaults = append(defaults, parsed.Host)
+		}
+	}
+	return getEnvAsSlice("PRIMARY_HOSTS", defaults)
+}
+
+// LoadCLIName reads the command-line client's executable name rendered
+// into install snippets
+func LoadCLIName() string {
+	return getEnv("CLI_NAME", "dotfiles")
+}
+
+// LoadClientMatrix reads the oldest CLI release that understands each
+// template schema, warned about on downloads. Unset or unparsable versions
+// keep the defaults.
+func LoadClientMatrix() compat.Matrix {
+	matrix := compat.DefaultMatrix()
+	for schema, key := range map[int]string{
+		models.SchemaVersionHooks: "MIN_CLI_VERSION_HOOKS",
+		models.SchemaVersionPins:  "MIN_CLI_VERSION_PINS",
+	} {
+		if version, err := compat.ParseVersion(getEnv(key, "")); err == nil {
+			matrix = matrix.WithMinimum(schema, version)
+		}
+	}
+	return matrix
+}
+
+// LoadAdminUsers reads the GitHub usernames granted site admin rights
+func LoadAdminUsers() []string {
+	return getEnvAsSlice("ADMIN_USERS", nil)
+}
+
 func (c *Config) Validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
@@ -194,6 +565,15 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
@@ -210,4 +590,19 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 		}
 	}
 	return defaultValue
+}
+
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		var values []string
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+		if len(values) > 0 {
+			return values
+		}
+	}
+	return defaultValue
 }
\ No newline at end of file
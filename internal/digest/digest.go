@@ -0,0 +1,193 @@
+// Package digest sends users a periodic summary of new public templates
+// carrying the tags they follow.
+package digest
+
+import (
+	"context"
+	stderrors "errors"
+	"log"
+	"sort"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/tags"
+	"dotfiles-api/pkg/errors"
+)
+
+// maxTemplatesPerTag caps how many new templates one followed tag adds to a
+// single digest
+const maxTemplatesPerTag = 20
+
+// Notifier delivers a digest to a user
+type Notifier interface {
+	NotifyNewTemplates(ctx context.Context, user *models.User, templates []*models.StoredTemplate) error
+}
+
+// LogNotifier writes digests to the server log. It is the default until a
+// delivery channel such as email is configured.
+type LogNotifier struct{}
+
+// NotifyNewTemplates logs the templates a user would be sent
+func (LogNotifier) NotifyNewTemplates(ctx context.Context, user *models.User, templates []*models.StoredTemplate) error {
+	log.Printf("Digest for %s: %d new templates matching followed tags", user.Username, len(templates))
+	return nil
+}
+
+// Digester builds and sends tag subscription digests
+type Digester struct {
+	subscriptions repository.SubscriptionRepository
+	templates     repository.TemplateRepository
+	users         repository.UserRepository
+	tags          *tags.Registry
+	notifier      Notifier
+	now           func() time.Time
+}
+
+// New creates a digester
+func New(
+	subscriptions repository.SubscriptionRepository,
+	templates repository.TemplateRepository,
+	users repository.UserRepository,
+	tagRegistry *tags.Registry,
+	notifier Notifier,
+) *Digester {
+	return &Digester{
+		subscriptions: subscriptions,
+		templates:     templates,
+		users:         users,
+		tags:          tagRegistry,
+		notifier:      notifier,
+		now:           time.Now,
+	}
+}
+
+// Start sends digests every interval until ctx is done
+func (d *Digester) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sent, err := d.Run(ctx)
+			if err != nil {
+				log.Printf("Tag digest failed: %v", err)
+			}
+			if sent > 0 {
+				log.Printf("Sent %d tag digests", sent)
+			}
+		}
+	}
+}
+
+// Run sends one digest to every subscriber with new matching templates and
+// returns how many were sent. A subscription is only marked notified once
+// its digest is delivered, so a failed delivery is retried on the next run
+// without repeating templates that were already sent.
+func (d *Digester) Run(ctx context.Context) (int, error) {
+	subscriptions, err := d.subscriptions.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+
+	for _, subscription := range subscriptions {
+		delivered, err := d.send(ctx, subscription, d.now())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if delivered {
+			sent++
+		}
+	}
+
+	return sent, stderrors.Join(errs...)
+}
+
+// send delivers one subscription's digest covering templates created after
+// its last digest and up to now
+func (d *Digester) send(ctx context.Context, subscription *models.TagSubscription, now time.Time) (bool, error) {
+	templates, err := d.newTemplates(ctx, subscription, now)
+	if err != nil {
+		return false, err
+	}
+
+	delivered := false
+	if len(templates) > 0 {
+		user, err := d.users.GetByID(ctx, subscription.UserID)
+		if err != nil && !isNotFound(err) {
+			return false, err
+		}
+
+		// Deleted users are skipped but still marked so the backlog does
+		// not grow
+		if user != nil && !user.IsDeleted() {
+			if err := d.notifier.NotifyNewTemplates(ctx, user, templates); err != nil {
+				return false, err
+			}
+			delivered = true
+		}
+	}
+
+	if err := d.subscriptions.MarkNotified(ctx, subscription.UserID, now); err != nil {
+		return delivered, err
+	}
+	return delivered, nil
+}
+
+// newTemplates returns the public templates created after the subscription
+// was last notified, up to now, carrying any followed tag, oldest first
+func (d *Digester) newTemplates(ctx context.Context, subscription *models.TagSubscription, now time.Time) ([]*models.StoredTemplate, error) {
+	public := true
+	since := subscription.LastNotifiedAt
+
+	var result []*models.StoredTemplate
+	seen := make(map[string]bool)
+
+	for _, tag := range subscription.Tags {
+		variants := d.tags.Variants(tag)
+		if len(variants) == 0 {
+			continue
+		}
+
+		templates, err := d.templates.List(ctx, repository.TemplateFilters{
+			Tags:      [][]string{variants},
+			Public:    &public,
+			Limit:     maxTemplatesPerTag,
+			Sort:      []repository.SortKey{{Field: "created_at"}},
+			DateRange: repository.DateRange{CreatedAfter: &since, CreatedBefore: &now},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, template := range templates {
+			// Date bounds are inclusive; a template created exactly at the
+			// last digest was already covered by it
+			if seen[template.ID] || !template.CreatedAt.After(since) {
+				continue
+			}
+			seen[template.ID] = true
+			result = append(result, template)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// isNotFound reports whether a user lookup failed because the user does not
+// exist; some stores report that as an error rather than a nil user
+func isNotFound(err error) bool {
+	var appErr *errors.AppError
+	return stderrors.Is(err, repository.ErrNotFound) ||
+		(stderrors.As(err, &appErr) && appErr.Code == errors.ErrCodeNotFound)
+}
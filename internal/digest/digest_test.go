@@ -0,0 +1,152 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+)
+
+// recordingNotifier remembers the template IDs sent to each user
+type recordingNotifier struct {
+	sent map[string][][]string
+	err  error
+}
+
+func (n *recordingNotifier) NotifyNewTemplates(ctx context.Context, user *models.User, templates []*models.StoredTemplate) error {
+	if n.err != nil {
+		return n.err
+	}
+
+	var ids []string
+	for _, template := range templates {
+		ids = append(ids, template.ID)
+	}
+	sort.Strings(ids)
+	n.sent[user.ID] = append(n.sent[user.ID], ids)
+	return nil
+}
+
+type digestFixture struct {
+	digester      *Digester
+	notifier      *recordingNotifier
+	templates     *memory.TemplateRepository
+	subscriptions *memory.SubscriptionRepository
+}
+
+func newDigestFixture(t *testing.T) *digestFixture {
+	t.Helper()
+	ctx := context.Background()
+
+	users := memory.NewUserRepository()
+	deletedAt := time.Now()
+	for _, user := range []*models.User{
+		{ID: "alice", Username: "alice", Email: "alice@example.com"},
+		{ID: "gone", Username: "gone", Email: "gone@example.com", DeletedAt: &deletedAt},
+	} {
+		if err := users.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	f := &digestFixture{
+		notifier:      &recordingNotifier{sent: make(map[string][][]string)},
+		templates:     memory.NewTemplateRepository(),
+		subscriptions: memory.NewSubscriptionRepository(),
+	}
+	f.digester = New(f.subscriptions, f.templates, users, tags.NewRegistry(), f.notifier)
+
+	for _, userID := range []string{"alice", "gone"} {
+		if _, err := f.subscriptions.AddTags(ctx, userID, []string{"javascript", "kubernetes"}); err != nil {
+			t.Fatalf("Failed to subscribe: %v", err)
+		}
+		// Start the window before any fixture template is created
+		if err := f.subscriptions.MarkNotified(ctx, userID, time.Now().Add(-time.Minute)); err != nil {
+			t.Fatalf("Failed to backdate subscription: %v", err)
+		}
+	}
+
+	return f
+}
+
+func (f *digestFixture) createTemplate(t *testing.T, id string, public bool, tags ...string) {
+	t.Helper()
+
+	template := &models.StoredTemplate{ID: id, Template: models.Template{
+		Public:   public,
+		Metadata: models.ShareMetadata{Name: id, Tags: tags},
+	}}
+	if err := f.templates.Create(context.Background(), template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+}
+
+func TestDigestSendsNewMatchingTemplatesOnce(t *testing.T) {
+	f := newDigestFixture(t)
+	ctx := context.Background()
+
+	f.createTemplate(t, "node", true, "JS")
+	f.createTemplate(t, "cluster", true, "k8s", "javascript")
+	f.createTemplate(t, "private", false, "js")
+	f.createTemplate(t, "python", true, "python")
+
+	sent, err := f.digester.Run(ctx)
+	if err != nil {
+		t.Fatalf("Failed to run digest: %v", err)
+	}
+	if sent != 1 {
+		t.Errorf("Expected 1 digest, got %d", sent)
+	}
+
+	want := [][]string{{"cluster", "node"}}
+	if got := f.notifier.sent["alice"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if _, ok := f.notifier.sent["gone"]; ok {
+		t.Error("Expected deleted users to be skipped")
+	}
+
+	// Nothing new: no digest, and old templates are not repeated
+	if sent, err := f.digester.Run(ctx); err != nil || sent != 0 {
+		t.Errorf("Expected no digest without new templates, got %d (%v)", sent, err)
+	}
+
+	time.Sleep(time.Millisecond)
+	f.createTemplate(t, "react", true, "Front End", "ecmascript")
+
+	if _, err := f.digester.Run(ctx); err != nil {
+		t.Fatalf("Failed to run digest: %v", err)
+	}
+	want = append(want, []string{"react"})
+	if got := f.notifier.sent["alice"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestDigestRetriesFailedDelivery(t *testing.T) {
+	f := newDigestFixture(t)
+	ctx := context.Background()
+
+	f.createTemplate(t, "node", true, "js")
+
+	f.notifier.err = errors.New("mail server down")
+	if _, err := f.digester.Run(ctx); err == nil {
+		t.Fatal("Expected the delivery error to be reported")
+	}
+
+	f.notifier.err = nil
+	if _, err := f.digester.Run(ctx); err != nil {
+		t.Fatalf("Failed to run digest: %v", err)
+	}
+
+	want := [][]string{{"node"}}
+	if got := f.notifier.sent["alice"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the failed digest to be resent, got %v", got)
+	}
+}
@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"dotfiles-api/internal/models"
+	"dotfiles-api/pkg/errors"
+)
+
+// ValidateConfigTags applies the template tag limits to a config's tags: at
+// most 10, none empty or longer than 30 characters
+func ValidateConfigTags(tags []string) *errors.AppError {
+	return validateTags("config", tags)
+}
+
+// ClaimConfigsRequest picks which configs uploaded without an account the
+// caller takes ownership of
+type ClaimConfigsRequest struct {
+	ConfigIDs []string `json:"config_ids" binding:"required,min=1,max=100"`
+}
+
+// ClaimConfigsResponse reports which configs were claimed, and why the rest
+// were not
+type ClaimConfigsResponse struct {
+	Claimed []string          `json:"claimed"`
+	Skipped map[string]string `json:"skipped"`
+}
+
+// RelatedConfigResponse is a public config that shares packages with a
+// template
+type RelatedConfigResponse struct {
+	Config      *models.StoredConfig `json:"config"`
+	SharedBrews []string             `json:"shared_brews"`
+	SharedCasks []string             `json:"shared_casks"`
+	SharedCount int                  `json:"shared_count"`
+}
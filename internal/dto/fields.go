@@ -0,0 +1,187 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"dotfiles-api/pkg/errors"
+)
+
+// FieldSelection is a parsed ?fields= whitelist keyed by JSON field name. A
+// nil value selects the whole field; a non-nil value selects only the
+// listed subfields of an object.
+type FieldSelection map[string]FieldSelection
+
+// templateFieldPaths holds every path ?fields= accepts on template
+// responses, including nested object fields such as "metadata.name"
+var templateFieldPaths = jsonFieldPaths(reflect.TypeOf(TemplateResponse{}), "")
+
+// TemplateFieldPaths lists the field paths that can be selected on template
+// responses, sorted
+func TemplateFieldPaths() []string {
+	paths := make([]string, 0, len(templateFieldPaths))
+	for path := range templateFieldPaths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ParseTemplateFields parses a comma-separated ?fields= value for template
+// responses. An empty value selects every field and returns nil; otherwise
+// id is always selected.
+func ParseTemplateFields(raw string) (FieldSelection, *errors.AppError) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	selection := FieldSelection{"id": nil}
+	for _, path := range strings.Split(raw, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !templateFieldPaths[path] {
+			appErr := errors.NewValidationError("unknown field: " + path)
+			appErr.Details = "valid fields: " + strings.Join(TemplateFieldPaths(), ", ")
+			return nil, appErr
+		}
+		selection.add(strings.Split(path, "."))
+	}
+
+	return selection, nil
+}
+
+// add selects the field at path. Selecting a whole object overrides any
+// earlier selection of its subfields.
+func (s FieldSelection) add(path []string) {
+	name := path[0]
+	sub, selected := s[name]
+	if selected && sub == nil {
+		return
+	}
+
+	if len(path) == 1 {
+		s[name] = nil
+		return
+	}
+
+	if sub == nil {
+		sub = FieldSelection{}
+		s[name] = sub
+	}
+	sub.add(path[1:])
+}
+
+// Paths returns the selected fields as dotted paths, sorted
+func (s FieldSelection) Paths() []string {
+	var paths []string
+	for name, sub := range s {
+		if sub == nil {
+			paths = append(paths, name)
+			continue
+		}
+		for _, path := range sub.Paths() {
+			paths = append(paths, name+"."+path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Has reports whether the named top-level field is selected in whole or in
+// part
+func (s FieldSelection) Has(name string) bool {
+	_, selected := s[name]
+	return selected
+}
+
+// HasPath reports whether the dotted field path, such as "metadata.name",
+// is selected, either itself or through a parent selected in whole
+func (s FieldSelection) HasPath(path string) bool {
+	name, rest, nested := strings.Cut(path, ".")
+	sub, selected := s[name]
+	if !selected {
+		return false
+	}
+	if !nested || sub == nil {
+		return true
+	}
+	return sub.HasPath(rest)
+}
+
+// Project returns the selected fields of v's JSON form. Fields omitted from
+// that form, such as empty omitempty fields, stay omitted.
+func (s FieldSelection) Project(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var document map[string]interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	return s.project(document), nil
+}
+
+func (s FieldSelection) project(document map[string]interface{}) map[string]interface{} {
+	projected := make(map[string]interface{}, len(s))
+	for name, sub := range s {
+		value, present := document[name]
+		if !present {
+			continue
+		}
+
+		if object, ok := value.(map[string]interface{}); ok && sub != nil {
+			projected[name] = sub.project(object)
+			continue
+		}
+		projected[name] = value
+	}
+	return projected
+}
+
+// jsonFieldPaths collects the JSON paths of a struct's fields, descending
+// into nested structs
+func jsonFieldPaths(t reflect.Type, prefix string) map[string]bool {
+	paths := make(map[string]bool)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		path := prefix + name
+		paths[path] = true
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Time{}) {
+			for nested := range jsonFieldPaths(fieldType, path+".") {
+				paths[nested] = true
+			}
+		}
+	}
+
+	return paths
+}
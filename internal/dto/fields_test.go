@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTemplateFields(t *testing.T) {
+	selection, appErr := ParseTemplateFields("metadata.name, downloads,metadata.tags")
+	if appErr != nil {
+		t.Fatalf("Unexpected error: %v", appErr)
+	}
+	if got := selection.Paths(); !reflect.DeepEqual(got, []string{"downloads", "id", "metadata.name", "metadata.tags"}) {
+		t.Errorf("Expected id to be added to the selection, got %v", got)
+	}
+
+	// Selecting a whole object absorbs its subfields in either order
+	for _, raw := range []string{"metadata.name,metadata", "metadata,metadata.name"} {
+		selection, _ := ParseTemplateFields(raw)
+		if got := selection.Paths(); !reflect.DeepEqual(got, []string{"id", "metadata"}) {
+			t.Errorf("%s: expected the whole metadata object, got %v", raw, got)
+		}
+	}
+
+	if selection, appErr := ParseTemplateFields(""); selection != nil || appErr != nil {
+		t.Errorf("Expected an empty value to select everything, got %v (%v)", selection, appErr)
+	}
+
+	_, appErr = ParseTemplateFields("id,metadata.nope")
+	if appErr == nil {
+		t.Fatal("Expected an error for an unknown field")
+	}
+	if !strings.Contains(appErr.Message, "metadata.nope") || !strings.Contains(appErr.Details, "metadata.name") {
+		t.Errorf("Expected the unknown field and the valid ones, got %q / %q", appErr.Message, appErr.Details)
+	}
+}
+
+func TestFieldSelectionProject(t *testing.T) {
+	selection, _ := ParseTemplateFields("metadata.name,downloads,source_repo")
+
+	projected, err := selection.Project(TemplateResponse{
+		ID:        "t1",
+		Brews:     []string{"git"},
+		Downloads: 12,
+		Metadata:  TemplateMetadataResponse{Name: "Dev", Author: "octocat"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to project: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"id":        "t1",
+		"downloads": json.Number("12"),
+		"metadata":  map[string]interface{}{"name": "Dev"},
+	}
+	// Unset omitempty fields such as source_repo stay omitted
+	if !reflect.DeepEqual(projected, want) {
+		t.Errorf("Expected %v, got %v", want, projected)
+	}
+}
@@ -0,0 +1,169 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/pkg/errors"
+)
+
+// ContentLimits bound how large a single template or config may be, so one
+// upload cannot bloat every list response and stored document. A zero
+// limit is not enforced.
+type ContentLimits struct {
+	// MaxListEntries applies to each package list on its own: taps, brews,
+	// casks, stow, apt_packages and pip_packages
+	MaxListEntries int
+	// MaxHookCommands applies to each hook phase, and to each phase of a
+	// package config
+	MaxHookCommands int
+	// MaxCommandLength is the longest a single hook command may be, in bytes
+	MaxCommandLength int
+	// MaxPackageConfigs is how many packages may have a package config
+	MaxPackageConfigs int
+	// MaxTotalBytes is the largest the serialized template or config may be
+	MaxTotalBytes int
+}
+
+// DefaultContentLimits returns the limits used when none are configured
+func DefaultContentLimits() ContentLimits {
+	return ContentLimits{
+		MaxListEntries:    500,
+		MaxHookCommands:   50,
+		MaxCommandLength:  1000,
+		MaxPackageConfigs: 100,
+		MaxTotalBytes:     256 << 10,
+	}
+}
+
+// CheckTemplate checks a template against the limits before it is saved.
+// A template over a limit gets a 413 error naming the limit, with the
+// offending field in Fields.
+func (l ContentLimits) CheckTemplate(template *models.Template) *errors.AppError {
+	if err := l.checkLists("Template", []namedList{
+		{"taps", template.Taps},
+		{"brews", template.Brews},
+		{"casks", template.Casks},
+		{"stow", template.Stow},
+		{"apt_packages", template.AptPackages},
+		{"pip_packages", template.PipPackages},
+	}); err != nil {
+		return err
+	}
+
+	if hooks := template.Hooks; hooks != nil {
+		if err := l.checkCommands([]namedList{
+			{"hooks.pre_install", hooks.PreInstall},
+			{"hooks.post_install", hooks.PostInstall},
+			{"hooks.pre_sync", hooks.PreSync},
+			{"hooks.post_sync", hooks.PostSync},
+			{"hooks.pre_stow", hooks.PreStow},
+			{"hooks.post_stow", hooks.PostStow},
+		}); err != nil {
+			return err
+		}
+	}
+
+	if l.MaxPackageConfigs > 0 && len(template.PackageConfigs) > l.MaxPackageConfigs {
+		return contentLimitError("Template", "package configs", l.MaxPackageConfigs, "package_configs",
+			fmt.Sprintf("must have at most %d entries, got %d", l.MaxPackageConfigs, len(template.PackageConfigs)))
+	}
+	names := make([]string, 0, len(template.PackageConfigs))
+	for name := range template.PackageConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		config := template.PackageConfigs[name]
+		if err := l.checkCommands([]namedList{
+			{"package_configs." + name + ".pre_install", config.PreInstall},
+			{"package_configs." + name + ".post_install", config.PostInstall},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return l.checkTotalSize("Template", template)
+}
+
+// CheckConfig checks an uploaded config against the list and total size
+// limits, as CheckTemplate does for templates
+func (l ContentLimits) CheckConfig(config *models.ShareableConfig) *errors.AppError {
+	if err := l.checkLists("Config", []namedList{
+		{"taps", config.Taps},
+		{"brews", config.Brews},
+		{"casks", config.Casks},
+		{"stow", config.Stow},
+	}); err != nil {
+		return err
+	}
+
+	return l.checkTotalSize("Config", config)
+}
+
+// namedList is a list field and its JSON path
+type namedList struct {
+	field   string
+	entries []string
+}
+
+func (l ContentLimits) checkLists(resource string, lists []namedList) *errors.AppError {
+	if l.MaxListEntries <= 0 {
+		return nil
+	}
+	for _, list := range lists {
+		if len(list.entries) > l.MaxListEntries {
+			return contentLimitError(resource, "list entries", l.MaxListEntries, list.field,
+				fmt.Sprintf("must have at most %d entries, got %d", l.MaxListEntries, len(list.entries)))
+		}
+	}
+	return nil
+}
+
+func (l ContentLimits) checkCommands(phases []namedList) *errors.AppError {
+	for _, phase := range phases {
+		if l.MaxHookCommands > 0 && len(phase.entries) > l.MaxHookCommands {
+			return contentLimitError("Template", "hook commands", l.MaxHookCommands, phase.field,
+				fmt.Sprintf("must have at most %d commands, got %d", l.MaxHookCommands, len(phase.entries)))
+		}
+		if l.MaxCommandLength <= 0 {
+			continue
+		}
+		for i, command := range phase.entries {
+			if len(command) > l.MaxCommandLength {
+				return contentLimitError("Template", "command length", l.MaxCommandLength, fmt.Sprintf("%s[%d]", phase.field, i),
+					fmt.Sprintf("must be at most %d characters, got %d", l.MaxCommandLength, len(command)))
+			}
+		}
+	}
+	return nil
+}
+
+func (l ContentLimits) checkTotalSize(resource string, content interface{}) *errors.AppError {
+	if l.MaxTotalBytes <= 0 {
+		return nil
+	}
+	encoded, err := json.Marshal(content)
+	if err != nil {
+		return errors.NewInternalError("failed to encode "+resource, err)
+	}
+	if len(encoded) > l.MaxTotalBytes {
+		return contentLimitError(resource, "total size", l.MaxTotalBytes, "",
+			fmt.Sprintf("must be at most %d bytes, got %d", l.MaxTotalBytes, len(encoded)))
+	}
+	return nil
+}
+
+// contentLimitError reports content over a limit. The message names the
+// limit; field, when set, points at the offending part of the body.
+func contentLimitError(resource, limit string, max int, field, problem string) *errors.AppError {
+	message := fmt.Sprintf("%s exceeds the %s limit of %d", resource, limit, max)
+	if field == "" {
+		appErr := errors.NewContentTooLargeError(message, nil)
+		appErr.Details = problem
+		return appErr
+	}
+	return errors.NewContentTooLargeError(message, map[string]string{field: problem})
+}
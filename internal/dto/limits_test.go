@@ -0,0 +1,131 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/pkg/errors"
+)
+
+// entries returns n distinct package names
+func entries(n int) []string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = fmt.Sprintf("pkg-%d", i)
+	}
+	return names
+}
+
+func TestContentLimitsBoundaries(t *testing.T) {
+	limits := ContentLimits{
+		MaxListEntries:    3,
+		MaxHookCommands:   2,
+		MaxCommandLength:  10,
+		MaxPackageConfigs: 2,
+	}
+
+	tests := []struct {
+		name  string
+		build func(n int) *models.Template
+		limit int
+		field string
+	}{
+		{"taps", func(n int) *models.Template { return &models.Template{Taps: entries(n)} }, 3, "taps"},
+		{"brews", func(n int) *models.Template { return &models.Template{Brews: entries(n)} }, 3, "brews"},
+		{"casks", func(n int) *models.Template { return &models.Template{Casks: entries(n)} }, 3, "casks"},
+		{"stow", func(n int) *models.Template { return &models.Template{Stow: entries(n)} }, 3, "stow"},
+		{"apt packages", func(n int) *models.Template { return &models.Template{AptPackages: entries(n)} }, 3, "apt_packages"},
+		{"pip packages", func(n int) *models.Template { return &models.Template{PipPackages: entries(n)} }, 3, "pip_packages"},
+		{"hook commands", func(n int) *models.Template {
+			return &models.Template{Hooks: &models.Hooks{PostSync: entries(n)}}
+		}, 2, "hooks.post_sync"},
+		{"package config commands", func(n int) *models.Template {
+			return &models.Template{PackageConfigs: map[string]models.PackageConfig{"git": {PreInstall: entries(n)}}}
+		}, 2, "package_configs.git.pre_install"},
+		{"command length", func(n int) *models.Template {
+			return &models.Template{Hooks: &models.Hooks{PreInstall: []string{strings.Repeat("x", n)}}}
+		}, 10, "hooks.pre_install[0]"},
+		{"package configs", func(n int) *models.Template {
+			configs := make(map[string]models.PackageConfig, n)
+			for _, name := range entries(n) {
+				configs[name] = models.PackageConfig{}
+			}
+			return &models.Template{PackageConfigs: configs}
+		}, 2, "package_configs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := limits.CheckTemplate(tt.build(tt.limit)); err != nil {
+				t.Errorf("Expected %d to be allowed, got %v", tt.limit, err)
+			}
+
+			err := limits.CheckTemplate(tt.build(tt.limit + 1))
+			if err == nil {
+				t.Fatalf("Expected %d to be rejected", tt.limit+1)
+			}
+			if err.StatusCode != http.StatusRequestEntityTooLarge || err.Code != errors.ErrCodeTooLarge {
+				t.Errorf("Expected a 413 PAYLOAD_TOO_LARGE error, got %d %s", err.StatusCode, err.Code)
+			}
+			if _, ok := err.Fields[tt.field]; !ok {
+				t.Errorf("Expected the error to name %s, got %v", tt.field, err.Fields)
+			}
+		})
+	}
+}
+
+func TestContentLimitsTotalSize(t *testing.T) {
+	template := &models.Template{Brews: entries(20)}
+	encoded, _ := json.Marshal(template)
+
+	limits := ContentLimits{MaxTotalBytes: len(encoded)}
+	if err := limits.CheckTemplate(template); err != nil {
+		t.Errorf("Expected a template at the size limit to be allowed, got %v", err)
+	}
+
+	limits.MaxTotalBytes--
+	err := limits.CheckTemplate(template)
+	if err == nil || err.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected a template over the size limit to be rejected with 413, got %v", err)
+	}
+	if !strings.Contains(err.Message, "total size") {
+		t.Errorf("Expected the message to name the total size limit, got %q", err.Message)
+	}
+}
+
+func TestContentLimitsConfig(t *testing.T) {
+	limits := ContentLimits{MaxListEntries: 3}
+	config := &models.ShareableConfig{BasicConfig: models.BasicConfig{Casks: entries(3)}}
+	if err := limits.CheckConfig(config); err != nil {
+		t.Errorf("Expected a config at the limit to be allowed, got %v", err)
+	}
+
+	config.Casks = entries(4)
+	if err := limits.CheckConfig(config); err == nil || err.Fields["casks"] == "" {
+		t.Errorf("Expected a config over the limit to be rejected on casks, got %v", err)
+	}
+
+	encoded, _ := json.Marshal(config)
+	limits = ContentLimits{MaxTotalBytes: len(encoded)}
+	if err := limits.CheckConfig(config); err != nil {
+		t.Errorf("Expected a config at the size limit to be allowed, got %v", err)
+	}
+	limits.MaxTotalBytes--
+	if err := limits.CheckConfig(config); err == nil {
+		t.Error("Expected a config over the size limit to be rejected")
+	}
+}
+
+func TestZeroContentLimitsAreOff(t *testing.T) {
+	template := &models.Template{
+		Brews: entries(1000),
+		Hooks: &models.Hooks{PreSync: []string{strings.Repeat("x", 5000)}},
+	}
+	if err := (ContentLimits{}).CheckTemplate(template); err != nil {
+		t.Errorf("Expected no limits to apply, got %v", err)
+	}
+}
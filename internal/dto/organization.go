@@ -1,18 +1,21 @@
 package dto
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"dotfiles-api/pkg/errors"
 )
 
 type CreateOrganizationRequest struct {
-	Name        string `json:"name" binding:"required"`
-	Slug        string `json:"slug" binding:"required"`
-	Description string `json:"description"`
-	Website     string `json:"website"`
-	Public      bool   `json:"public"`
+	Name              string `json:"name" binding:"required"`
+	Slug              string `json:"slug" binding:"required"`
+	Description       string `json:"description"`
+	Website           string `json:"website"`
+	Public            bool   `json:"public"`
+	AllowJoinRequests bool   `json:"allow_join_requests"`
 }
 
 func (r *CreateOrganizationRequest) Validate() *errors.AppError {
@@ -68,6 +71,17 @@ func (r *UpdateOrganizationRequest) Validate() *errors.AppError {
 	return nil
 }
 
+// OrganizationShareResponse holds the fields for an organization's social
+// card. TemplateCount only counts public templates.
+type OrganizationShareResponse struct {
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	MemberCount   int    `json:"member_count"`
+	TemplateCount int    `json:"template_count"`
+	OGImageURL    string `json:"og_image_url"`
+	URL           string `json:"url"`
+}
+
 type OrganizationResponse struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
@@ -79,6 +93,121 @@ type OrganizationResponse struct {
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
 	MemberCount int    `json:"member_count"`
+
+	// CustomDomain is only reported once the domain is verified
+	CustomDomain string `json:"custom_domain,omitempty"`
+
+	PinnedTemplateIDs []string `json:"pinned_template_ids,omitempty"`
+
+	// AllowJoinRequests reports whether users can ask to join without an
+	// invite
+	AllowJoinRequests bool `json:"allow_join_requests"`
+
+	// Seat usage is only reported to site admins and organization admins.
+	// SeatsUsed counts members plus pending invites; a SeatsTotal of 0
+	// means the organization has no member limit.
+	SeatsUsed  *int `json:"seats_used,omitempty"`
+	SeatsTotal *int `json:"seats_total,omitempty"`
+}
+
+type SetMaxMembersRequest struct {
+	MaxMembers *int `json:"max_members" binding:"required"`
+}
+
+func (r *SetMaxMembersRequest) Validate() *errors.AppError {
+	if *r.MaxMembers < 0 {
+		return errors.NewValidationError("max_members must be 0 (unlimited) or greater")
+	}
+
+	return nil
+}
+
+// MaxPinnedTemplates bounds how many templates an organization can feature
+const MaxPinnedTemplates = 5
+
+// SetPinnedTemplatesRequest replaces the templates an organization
+// features. An empty list unpins them all.
+type SetPinnedTemplatesRequest struct {
+	TemplateIDs []string `json:"template_ids" binding:"required"`
+}
+
+func (r *SetPinnedTemplatesRequest) Validate() *errors.AppError {
+	if len(r.TemplateIDs) > MaxPinnedTemplates {
+		return errors.NewValidationError(fmt.Sprintf("at most %d templates can be pinned", MaxPinnedTemplates))
+	}
+
+	seen := make(map[string]bool, len(r.TemplateIDs))
+	for _, id := range r.TemplateIDs {
+		if strings.TrimSpace(id) == "" {
+			return errors.NewValidationError("template IDs cannot be empty")
+		}
+		if seen[id] {
+			return errors.NewValidationError("template " + id + " is pinned more than once")
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
+
+// SetCustomDomainRequest assigns a custom domain to an organization. An empty
+// domain removes it.
+type SetCustomDomainRequest struct {
+	Domain string `json:"domain"`
+}
+
+// domainLabelPattern matches one DNS label of a hostname
+var domainLabelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+func (r *SetCustomDomainRequest) Validate() *errors.AppError {
+	r.Domain = NormalizeDomain(r.Domain)
+	if r.Domain == "" {
+		return nil
+	}
+
+	if len(r.Domain) > 253 {
+		return errors.NewValidationError("domain cannot be longer than 253 characters")
+	}
+
+	labels := strings.Split(r.Domain, ".")
+	if len(labels) < 2 {
+		return errors.NewValidationError("domain must include a top-level domain, such as acme.example.com")
+	}
+
+	for _, label := range labels {
+		if !domainLabelPattern.MatchString(label) {
+			return errors.NewValidationError("domain must be a hostname made of letters, numbers, hyphens and dots")
+		}
+	}
+
+	return nil
+}
+
+// NormalizeDomain lowercases a hostname and strips surrounding whitespace, a
+// port and a trailing dot, so Host headers compare equal to stored domains
+func NormalizeDomain(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if i := strings.LastIndex(host, ":"); i != -1 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	return strings.TrimSuffix(host, ".")
+}
+
+// CustomDomainResponse describes an organization's custom domain and the DNS
+// record that proves ownership of it
+type CustomDomainResponse struct {
+	Domain     string                   `json:"domain"`
+	Verified   bool                     `json:"verified"`
+	VerifiedAt string                   `json:"verified_at,omitempty"`
+	Challenge  *DomainChallengeResponse `json:"challenge,omitempty"`
+}
+
+// DomainChallengeResponse is the TXT record to publish before verifying a
+// custom domain
+type DomainChallengeResponse struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 type AddMemberRequest struct {
@@ -127,8 +256,9 @@ func (r *InviteUserRequest) Validate() *errors.AppError {
 		return err
 	}
 
-	if err := validateOrganizationRole(r.Role); err != nil {
-		return err
+	// Ownership is never handed out through an invite
+	if r.Role != "admin" && r.Role != "member" {
+		return errors.NewValidationError("invalid role: must be one of admin, member")
 	}
 
 	return nil
@@ -150,6 +280,46 @@ type AcceptInviteRequest struct {
 	Token string `json:"token" binding:"required"`
 }
 
+// MaxJoinRequestTextLength bounds join request messages and rejection
+// reasons, in characters
+const MaxJoinRequestTextLength = 500
+
+// CreateJoinRequestRequest asks to join an organization, optionally saying
+// why
+type CreateJoinRequestRequest struct {
+	Message string `json:"message"`
+}
+
+func (r *CreateJoinRequestRequest) Validate() *errors.AppError {
+	r.Message = strings.TrimSpace(r.Message)
+	return validateJoinRequestText("message", r.Message)
+}
+
+// RejectJoinRequestRequest rejects a join request, optionally telling the
+// requester why
+type RejectJoinRequestRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (r *RejectJoinRequestRequest) Validate() *errors.AppError {
+	r.Reason = strings.TrimSpace(r.Reason)
+	return validateJoinRequestText("reason", r.Reason)
+}
+
+// SetJoinRequestsRequest turns an organization's join requests on or off
+type SetJoinRequestsRequest struct {
+	AllowJoinRequests *bool `json:"allow_join_requests" binding:"required"`
+}
+
+func validateJoinRequestText(field, text string) *errors.AppError {
+	if utf8.RuneCountInString(text) > MaxJoinRequestTextLength {
+		return errors.NewFieldValidationError("Request validation failed", map[string]string{
+			field: fmt.Sprintf("must be at most %d characters", MaxJoinRequestTextLength),
+		})
+	}
+	return nil
+}
+
 func validateOrganizationName(name string) *errors.AppError {
 	name = strings.TrimSpace(name)
 	if name == "" {
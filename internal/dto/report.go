@@ -0,0 +1,26 @@
+package dto
+
+import "dotfiles-api/internal/models"
+
+// ReportTemplateRequest flags a template for site admins to review
+type ReportTemplateRequest struct {
+	Reason  string `json:"reason" binding:"required,oneof=malicious spam broken other"`
+	Details string `json:"details" binding:"max=1000"`
+}
+
+// ResolveReportRequest closes a template's open reports with an action
+type ResolveReportRequest struct {
+	Action string `json:"action" binding:"required,oneof=dismiss unlist_template delete_template"`
+	Note   string `json:"note" binding:"max=1000"`
+}
+
+// ReportGroupResponse gathers the open reports against one template
+type ReportGroupResponse struct {
+	TemplateID string `json:"template_id"`
+	// TemplateName is empty once the template is deleted
+	TemplateName string                   `json:"template_name,omitempty"`
+	Unlisted     bool                     `json:"unlisted"`
+	OpenReports  int                      `json:"open_reports"`
+	Reasons      map[string]int           `json:"reasons"`
+	Reports      []*models.TemplateReport `json:"reports"`
+}
@@ -1,7 +1,9 @@
 package dto
 
 import (
+	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"dotfiles-api/pkg/errors"
 )
@@ -32,6 +34,10 @@ type UpdateReviewRequest struct {
 }
 
 func (r *UpdateReviewRequest) Validate() *errors.AppError {
+	if r.Rating == nil && r.Comment == nil {
+		return errors.NewValidationError("at least one of rating or comment is required")
+	}
+
 	if r.Rating != nil {
 		if err := validateRating(*r.Rating); err != nil {
 			return err
@@ -48,16 +54,22 @@ func (r *UpdateReviewRequest) Validate() *errors.AppError {
 }
 
 type ReviewResponse struct {
-	ID         string `json:"id"`
-	TemplateID string `json:"template_id"`
-	UserID     string `json:"user_id"`
-	Username   string `json:"username"`
-	AvatarURL  string `json:"avatar_url"`
-	Rating     int    `json:"rating"`
-	Comment    string `json:"comment"`
-	Helpful    int    `json:"helpful"`
-	CreatedAt  string `json:"created_at"`
-	UpdatedAt  string `json:"updated_at"`
+	ID             string `json:"id"`
+	TemplateID     string `json:"template_id"`
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	AvatarURL      string `json:"avatar_url"`
+	AvatarProxyURL string `json:"avatar_proxy_url"`
+	Rating         int    `json:"rating"`
+	Comment        string `json:"comment"`
+	Language       string `json:"language"`
+	Helpful        int    `json:"helpful"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+
+	// TemplateName is only set where reviews are listed away from their
+	// template, and is empty once the template is deleted
+	TemplateName string `json:"template_name,omitempty"`
 }
 
 func validateRating(rating int) *errors.AppError {
@@ -75,4 +87,39 @@ func validateReviewComment(comment string) *errors.AppError {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// ReviewCommentRules are the server's requirements for review text. Ratings
+// without a comment are only accepted when Required is false; MinLength
+// applies to any comment that is given.
+type ReviewCommentRules struct {
+	MinLength int
+	Required  bool
+}
+
+// Check validates a review comment against the rules and the 1000 character
+// limit, reporting a failure on the comment field.
+func (r ReviewCommentRules) Check(comment string) *errors.AppError {
+	if err := validateReviewComment(comment); err != nil {
+		return err
+	}
+
+	length := utf8.RuneCountInString(strings.TrimSpace(comment))
+	if length == 0 {
+		if r.Required {
+			appErr := errors.NewFieldValidationError("Review comment is required", map[string]string{
+				"comment": "is required",
+			})
+			appErr.Details = "use PUT /api/templates/{id}/rating to rate without a comment"
+			return appErr
+		}
+		return nil
+	}
+	if length < r.MinLength {
+		return errors.NewFieldValidationError("Review comment is too short", map[string]string{
+			"comment": fmt.Sprintf("must be at least %d characters, got %d", r.MinLength, length),
+		})
+	}
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package dto
+
+// TemplateSchema returns a JSON Schema (draft 2020-12) describing
+// CreateTemplateRequest. It is built from the same limits and package-name
+// patterns as the validators: a body that passes the schema gets no problems
+// from CreateTemplateRequest.Lint apart from inheritance checks that need
+// the server.
+func TemplateSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "Dotfiles template",
+		"description": "Request body for POST /api/templates",
+		"type":        "object",
+		"required":    []string{"metadata"},
+		"properties": map[string]interface{}{
+			"taps":         packageListSchema("Homebrew taps (user/repo)", tapNamePattern.String()),
+			"brews":        packageListSchema("Homebrew formulae", brewNamePattern.String()),
+			"casks":        packageListSchema("Homebrew casks", brewNamePattern.String()),
+			"stow":         stowListSchema(),
+			"apt_packages": packageListSchema("APT packages", aptNamePattern.String()),
+			"pip_packages": packageListSchema("pip requirements", pipNamePattern.String()),
+			"metadata":     templateMetadataSchema(),
+			"extends": map[string]interface{}{
+				"type":        "string",
+				"description": "ID of the template to inherit packages from",
+			},
+			"overrides": map[string]interface{}{
+				"type":        "array",
+				"description": "Inherited packages to drop; only meaningful with extends",
+				"uniqueItems": true,
+				"items": map[string]interface{}{
+					"type":      "string",
+					"minLength": 1,
+					"maxLength": maxOverrideLength,
+					"pattern":   `^\S+$`,
+				},
+			},
+			"add_only": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only add packages when applied, never remove them",
+			},
+			"public":          map[string]interface{}{"type": "boolean"},
+			"featured":        map[string]interface{}{"type": "boolean"},
+			"organization_id": map[string]interface{}{"type": "string"},
+		},
+		// add_only templates never remove packages, so they cannot override
+		"if": map[string]interface{}{
+			"required":   []string{"add_only"},
+			"properties": map[string]interface{}{"add_only": map[string]interface{}{"const": true}},
+		},
+		"then": map[string]interface{}{
+			"properties": map[string]interface{}{"overrides": map[string]interface{}{"maxItems": 0}},
+		},
+	}
+}
+
+func templateMetadataSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "object",
+		"required": []string{"name", "description", "author", "version"},
+		"properties": map[string]interface{}{
+			"name": map[string]interface{}{
+				"type":      "string",
+				"minLength": minTemplateNameLength,
+				"maxLength": maxTemplateNameLength,
+			},
+			"description": map[string]interface{}{
+				"type":      "string",
+				"minLength": minTemplateDescriptionLength,
+				"maxLength": maxTemplateDescriptionLength,
+			},
+			"author": map[string]interface{}{
+				"type":    "string",
+				"pattern": `\S`,
+			},
+			"version": map[string]interface{}{
+				"type":        "string",
+				"description": "Any non-blank version string; major.minor.patch is recommended",
+				"pattern":     `\S`,
+			},
+			"tags": map[string]interface{}{
+				"type":     "array",
+				"maxItems": maxTemplateTags,
+				"items": map[string]interface{}{
+					"type":      "string",
+					"maxLength": maxTemplateTagLength,
+					"pattern":   `\S`,
+				},
+			},
+		},
+	}
+}
+
+func packageListSchema(description, pattern string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "array",
+		"description": description,
+		"items": map[string]interface{}{
+			"type":    "string",
+			"pattern": pattern,
+		},
+	}
+}
+
+func stowListSchema() map[string]interface{} {
+	schema := packageListSchema("Directories in the dotfiles repository to stow", stowNamePattern.String())
+	schema["items"].(map[string]interface{})["not"] = map[string]interface{}{
+		"enum": []string{".", ".."},
+	}
+	return schema
+}
@@ -1,25 +1,65 @@
 package dto
 
 import (
+	"encoding/json"
+	"fmt"
+	"regexp"
 	"strings"
+	"unicode"
 
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/plan"
+	"dotfiles-api/internal/search"
+	"dotfiles-api/internal/semver"
 	"dotfiles-api/pkg/errors"
 )
 
+// LegacyCompatVersion is the X-API-Compat value (or ?compat= query value) that
+// older CLI releases send to receive the deprecated camelCase aliases.
+const LegacyCompatVersion = "v0"
+
 type CreateTemplateRequest struct {
 	Taps           []string                  `json:"taps"`
 	Brews          []string                  `json:"brews"`
 	Casks          []string                  `json:"casks"`
 	Stow           []string                  `json:"stow"`
+	AptPackages    []string                  `json:"apt_packages"`
+	PipPackages    []string                  `json:"pip_packages"`
 	Metadata       CreateTemplateMetadata    `json:"metadata" binding:"required"`
 	Extends        string                    `json:"extends"`
 	Overrides      []string                  `json:"overrides"`
+	// AddOnly applies the template without ever removing packages, while
+	// Overrides drops inherited ones, so the two cannot be combined
 	AddOnly        bool                      `json:"add_only"`
 	Public         bool                      `json:"public"`
 	Featured       bool                      `json:"featured"`
 	OrganizationID string                    `json:"organization_id"`
 }
 
+// MaxBulkImportTemplates is the most templates one bulk import may create
+const MaxBulkImportTemplates = 20
+
+// BulkImportTemplatesRequest is the body of POST /api/templates/bulk-import
+type BulkImportTemplatesRequest struct {
+	Templates []CreateTemplateRequest `json:"templates" binding:"required"`
+}
+
+// Validate checks the number of templates; each entry is validated on its
+// own so every failure can be reported by position
+func (r *BulkImportTemplatesRequest) Validate() *errors.AppError {
+	if len(r.Templates) == 0 {
+		return errors.NewFieldValidationError("Request validation failed", map[string]string{
+			"templates": "is required",
+		})
+	}
+	if len(r.Templates) > MaxBulkImportTemplates {
+		return errors.NewFieldValidationError("Request validation failed", map[string]string{
+			"templates": fmt.Sprintf("must be at most %d items", MaxBulkImportTemplates),
+		})
+	}
+	return nil
+}
+
 type CreateTemplateMetadata struct {
 	Name        string   `json:"name" binding:"required"`
 	Description string   `json:"description" binding:"required"`
@@ -29,6 +69,20 @@ type CreateTemplateMetadata struct {
 }
 
 func (r *CreateTemplateRequest) Validate() *errors.AppError {
+	return r.validate(validateTemplateVersion)
+}
+
+// ValidateUpdate validates the request as the new state of a template
+// currently at previousVersion. Versions stored before semver was required
+// are accepted as long as they are unchanged.
+func (r *CreateTemplateRequest) ValidateUpdate(previousVersion string) *errors.AppError {
+	if r.Metadata.Version == previousVersion && !semver.IsValid(previousVersion) {
+		return r.validate(func(string) *errors.AppError { return nil })
+	}
+	return r.Validate()
+}
+
+func (r *CreateTemplateRequest) validate(validateVersion func(string) *errors.AppError) *errors.AppError {
 	if err := validateTemplateName(r.Metadata.Name); err != nil {
 		return err
 	}
@@ -37,7 +91,7 @@ func (r *CreateTemplateRequest) Validate() *errors.AppError {
 		return err
 	}
 
-	if err := validateTemplateVersion(r.Metadata.Version); err != nil {
+	if err := validateVersion(r.Metadata.Version); err != nil {
 		return err
 	}
 
@@ -45,6 +99,106 @@ func (r *CreateTemplateRequest) Validate() *errors.AppError {
 		return err
 	}
 
+	if err := validateTemplateOverrides(r.Overrides); err != nil {
+		return err
+	}
+
+	if err := validateAddOnlyOverrides(r.AddOnly, r.Overrides); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Warnings reports issues that do not stop the template from being created
+// but that the author probably did not intend
+func (r *CreateTemplateRequest) Warnings() []TemplateProblem {
+	var warnings []TemplateProblem
+	if len(r.Overrides) > 0 && r.Extends == "" {
+		warnings = append(warnings, TemplateProblem{Field: "overrides", Message: "overrides have no effect without extends"})
+	}
+	return warnings
+}
+
+// Lint runs every validation rule instead of stopping at the first failure,
+// adding package-name checks on top of Validate. Problems would make the
+// template unusable; warnings are worth fixing but do not block publishing.
+func (r *CreateTemplateRequest) Lint() (problems, warnings []TemplateProblem) {
+	metadataChecks := []struct {
+		field string
+		err   *errors.AppError
+	}{
+		{"metadata.name", validateTemplateName(r.Metadata.Name)},
+		{"metadata.description", validateTemplateDescription(r.Metadata.Description)},
+		{"metadata.author", validateTemplateAuthor(r.Metadata.Author)},
+		{"metadata.version", validateTemplateVersion(r.Metadata.Version)},
+		{"metadata.tags", validateTemplateTags(r.Metadata.Tags)},
+		{"overrides", validateTemplateOverrides(r.Overrides)},
+		{"add_only", validateAddOnlyOverrides(r.AddOnly, r.Overrides)},
+	}
+	for _, check := range metadataChecks {
+		if check.err != nil {
+			problems = append(problems, TemplateProblem{Field: check.field, Message: check.err.Message})
+		}
+	}
+
+	packageLists := []struct {
+		field    string
+		names    []string
+		validate func(string) bool
+	}{
+		{"taps", r.Taps, tapNamePattern.MatchString},
+		{"brews", r.Brews, brewNamePattern.MatchString},
+		{"casks", r.Casks, brewNamePattern.MatchString},
+		{"stow", r.Stow, isValidStowPackage},
+		{"apt_packages", r.AptPackages, aptNamePattern.MatchString},
+		{"pip_packages", r.PipPackages, pipNamePattern.MatchString},
+	}
+
+	total := 0
+	for _, list := range packageLists {
+		seen := make(map[string]bool, len(list.names))
+		for i, name := range list.names {
+			field := fmt.Sprintf("%s[%d]", list.field, i)
+			if !list.validate(name) {
+				problems = append(problems, TemplateProblem{Field: field, Message: fmt.Sprintf("invalid package name %q", name)})
+				continue
+			}
+			if seen[name] {
+				warnings = append(warnings, TemplateProblem{Field: field, Message: fmt.Sprintf("duplicate package %q", name)})
+			}
+			seen[name] = true
+		}
+		total += len(list.names)
+	}
+
+	if total == 0 && r.Extends == "" {
+		warnings = append(warnings, TemplateProblem{Field: "", Message: "template does not install any packages"})
+	}
+
+	warnings = append(warnings, r.Warnings()...)
+
+	return problems, warnings
+}
+
+// UnmarshalJSON accepts the deprecated "addOnly" key alongside "add_only".
+// When both are present the canonical key wins.
+func (r *CreateTemplateRequest) UnmarshalJSON(data []byte) error {
+	type plain CreateTemplateRequest
+	aux := struct {
+		*plain
+		AddOnly       *bool `json:"add_only"`
+		LegacyAddOnly *bool `json:"addOnly"`
+	}{plain: (*plain)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if addOnly := resolveAddOnly(aux.AddOnly, aux.LegacyAddOnly); addOnly != nil {
+		r.AddOnly = *addOnly
+	}
+
 	return nil
 }
 
@@ -95,15 +249,87 @@ func (r *UpdateTemplateRequest) Validate() *errors.AppError {
 		}
 	}
 
+	if r.Overrides != nil {
+		if err := validateTemplateOverrides(*r.Overrides); err != nil {
+			return err
+		}
+
+		if r.AddOnly != nil {
+			if err := validateAddOnlyOverrides(*r.AddOnly, *r.Overrides); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalJSON accepts the deprecated "addOnly" key alongside "add_only".
+// When both are present the canonical key wins.
+func (r *UpdateTemplateRequest) UnmarshalJSON(data []byte) error {
+	type plain UpdateTemplateRequest
+	aux := struct {
+		*plain
+		AddOnly       *bool `json:"add_only"`
+		LegacyAddOnly *bool `json:"addOnly"`
+	}{plain: (*plain)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	r.AddOnly = resolveAddOnly(aux.AddOnly, aux.LegacyAddOnly)
+
 	return nil
 }
 
+// ImportGitHubTemplateRequest creates a template from a Brewfile in a
+// GitHub repository. Ref defaults to the repository's default branch and
+// Path to "Brewfile".
+type ImportGitHubTemplateRequest struct {
+	Repo   string   `json:"repo"`
+	Ref    string   `json:"ref"`
+	Path   string   `json:"path"`
+	Public bool     `json:"public"`
+	Tags   []string `json:"tags"`
+}
+
+func (r *ImportGitHubTemplateRequest) Validate() *errors.AppError {
+	r.Repo = strings.TrimSpace(r.Repo)
+	if r.Repo == "" {
+		return errors.NewValidationError("repo is required")
+	}
+
+	if !githubRepoPattern.MatchString(r.Repo) {
+		return errors.NewValidationError("repo must be in owner/name form")
+	}
+
+	r.Path = strings.Trim(strings.TrimSpace(r.Path), "/")
+	if r.Path == "" {
+		r.Path = "Brewfile"
+	}
+
+	for _, segment := range strings.Split(r.Path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return errors.NewValidationError("path must be a file path inside the repository")
+		}
+	}
+
+	r.Ref = strings.TrimSpace(r.Ref)
+
+	return validateTemplateTags(r.Tags)
+}
+
+var githubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?/[A-Za-z0-9._-]+$`)
+
 type TemplateResponse struct {
 	ID             string                    `json:"id"`
 	Taps           []string                  `json:"taps"`
 	Brews          []string                  `json:"brews"`
 	Casks          []string                  `json:"casks"`
 	Stow           []string                  `json:"stow"`
+	AptPackages    []string                  `json:"apt_packages,omitempty"`
+	PipPackages    []string                  `json:"pip_packages,omitempty"`
 	Metadata       TemplateMetadataResponse  `json:"metadata"`
 	Extends        string                    `json:"extends"`
 	Overrides      []string                  `json:"overrides"`
@@ -111,19 +337,154 @@ type TemplateResponse struct {
 	Public         bool                      `json:"public"`
 	Featured       bool                      `json:"featured"`
 	OrganizationID string                    `json:"organization_id"`
+	Hooks          *models.Hooks             `json:"hooks,omitempty"`
+	PackageConfigs map[string]models.PackageConfig `json:"package_configs,omitempty"`
 	Downloads      int                       `json:"downloads"`
+	PopularityScore float64                  `json:"popularity_score"`
+	Unlisted       bool                      `json:"unlisted,omitempty"`
 	CreatedAt      string                    `json:"created_at"`
 	UpdatedAt      string                    `json:"updated_at"`
+
+	// LegacyAddOnly mirrors AddOnly under the deprecated camelCase key and is
+	// only populated for v0 compat clients.
+	LegacyAddOnly *bool `json:"addOnly,omitempty"`
+
+	// MatchedFields and Highlight explain why a search result matched and
+	// are only populated by the search endpoint.
+	MatchedFields []string          `json:"matched_fields,omitempty"`
+	Highlight     *search.Highlight `json:"highlight,omitempty"`
+
+	// SourceRepo is set on templates imported from a GitHub Brewfile
+	SourceRepo *SourceRepoResponse `json:"source_repo,omitempty"`
+
+	// Images are the template's screenshots, in display order
+	Images []models.TemplateImage `json:"images,omitempty"`
+
+	// IsFavorited and IsReviewed say whether the caller favorited or
+	// reviewed the template, and are left out for anonymous requests
+	IsFavorited *bool `json:"is_favorited,omitempty"`
+	IsReviewed  *bool `json:"is_reviewed,omitempty"`
+
+	// IsOutdated says whether ?installed_version= is older than the
+	// template's version, and is only populated by the detail endpoint
+	IsOutdated *bool `json:"is_outdated,omitempty"`
+
+	// Owner describes the organization or user owning the template and is
+	// only populated for ?expand=owner
+	Owner *TemplateOwnerResponse `json:"owner,omitempty"`
+
+	// Warnings lists non-blocking problems with a newly created template and
+	// is only populated by the create endpoint
+	Warnings []TemplateProblem `json:"warnings,omitempty"`
+}
+
+type SourceRepoResponse struct {
+	Repo     string `json:"repo"`
+	Ref      string `json:"ref"`
+	Path     string `json:"path"`
+	SyncedAt string `json:"synced_at"`
+}
+
+// Template owner types
+const (
+	OwnerTypeUser         = "user"
+	OwnerTypeOrganization = "organization"
+)
+
+// TemplateOwnerResponse is the lightweight owner badge embedded by
+// ?expand=owner: username and avatar for users, name and slug for
+// organizations
+type TemplateOwnerResponse struct {
+	Type           string `json:"type"`
+	ID             string `json:"id"`
+	Username       string `json:"username,omitempty"`
+	AvatarURL      string `json:"avatar_url,omitempty"`
+	AvatarProxyURL string `json:"avatar_proxy_url,omitempty"`
+	Name           string `json:"name,omitempty"`
+	Slug           string `json:"slug,omitempty"`
+}
+
+// WithLegacyAliases populates the deprecated camelCase aliases expected by
+// v0 compat clients.
+func (r *TemplateResponse) WithLegacyAliases() {
+	addOnly := r.AddOnly
+	r.LegacyAddOnly = &addOnly
+}
+
+// DownloadInfo is added to downloaded templates so clients can tell whether
+// they understand every field
+type DownloadInfo struct {
+	SchemaVersion int      `json:"schema_version"`
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+// TemplateDownload is the raw template document returned by the download
+// endpoint
+type TemplateDownload struct {
+	models.Template
+	DownloadInfo
+}
+
+// LegacyTemplate is the raw template document with the deprecated camelCase
+// aliases added, returned to v0 compat clients by the download endpoint.
+type LegacyTemplate struct {
+	models.Template
+	LegacyAddOnly bool `json:"addOnly"`
+	DownloadInfo
+}
+
+// NewLegacyTemplate wraps a template with its deprecated camelCase aliases.
+func NewLegacyTemplate(template models.Template, info DownloadInfo) LegacyTemplate {
+	return LegacyTemplate{
+		Template:      template,
+		LegacyAddOnly: template.AddOnly,
+		DownloadInfo:  info,
+	}
 }
 
 type TemplateMetadataResponse struct {
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Author      string   `json:"author"`
-	Version     string   `json:"version"`
-	Tags        []string `json:"tags"`
-	CreatedAt   string   `json:"created_at"`
-	UpdatedAt   string   `json:"updated_at"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	Version     string `json:"version"`
+	// NonSemver flags versions stored before semver was required, which
+	// cannot be compared
+	NonSemver bool     `json:"non_semver,omitempty"`
+	Tags      []string `json:"tags"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+// TemplateVersionResponse describes a template's current version
+type TemplateVersionResponse struct {
+	TemplateID string `json:"template_id"`
+	Version    string `json:"version"`
+	NonSemver  bool   `json:"non_semver,omitempty"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// MaxTemplateACLEntries bounds how many users one template can be shared
+// with
+const MaxTemplateACLEntries = 50
+
+// AddTemplateACLEntryRequest shares a template with another user
+type AddTemplateACLEntryRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// Share link lifetimes, in days, and how many usable links one template
+// may have at once
+const (
+	DefaultShareLinkDays  = 7
+	MaxShareLinkDays      = 30
+	MaxTemplateShareLinks = 20
+)
+
+// CreateShareLinkRequest sets when a new share link expires and how many
+// times it may be used. Both are optional; the body may be omitted.
+type CreateShareLinkRequest struct {
+	ExpiresInDays int `json:"expires_in_days" binding:"omitempty,min=1,max=30"`
+	MaxUses       int `json:"max_uses" binding:"omitempty,min=1,max=1000"`
 }
 
 type TemplateStatsResponse struct {
@@ -133,6 +494,60 @@ type TemplateStatsResponse struct {
 	Categories        int `json:"categories"`
 }
 
+// TagResponse is a canonical tag with the synonyms folded into it and the
+// number of public templates using any of them
+type TagResponse struct {
+	Tag      string   `json:"tag"`
+	Synonyms []string `json:"synonyms"`
+	Count    int      `json:"count"`
+}
+
+type SetTagSynonymsRequest struct {
+	Canonical string   `json:"canonical" binding:"required"`
+	Synonyms  []string `json:"synonyms"`
+}
+
+func (r *SetTagSynonymsRequest) Validate() *errors.AppError {
+	if err := validateTemplateTags([]string{r.Canonical}); err != nil {
+		return err
+	}
+
+	if len(r.Synonyms) > 20 {
+		return errors.NewValidationError("a tag cannot have more than 20 synonyms")
+	}
+
+	for _, synonym := range r.Synonyms {
+		if err := validateTemplateTags([]string{synonym}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SubscribeTagsRequest follows tags for the new-template digest
+type SubscribeTagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+func (r *SubscribeTagsRequest) Validate() *errors.AppError {
+	if len(r.Tags) == 0 {
+		return errors.NewValidationError("at least one tag is required")
+	}
+
+	if len(r.Tags) > maxTemplateTags {
+		return errors.NewValidationError(fmt.Sprintf("cannot follow more than %d tags at once", maxTemplateTags))
+	}
+
+	for _, tag := range r.Tags {
+		if err := validateTemplateTags([]string{tag}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type TemplateRatingResponse struct {
 	TemplateID    string         `json:"template_id"`
 	AverageRating float64        `json:"average_rating"`
@@ -140,14 +555,127 @@ type TemplateRatingResponse struct {
 	Distribution  map[string]int `json:"distribution"`
 }
 
+// InstallSnippetResponse holds ready-to-copy commands that install a
+// template. Short is the curl command alone, printing the template to stdout.
+type InstallSnippetResponse struct {
+	TemplateID  string `json:"template_id"`
+	Visibility  string `json:"visibility"`
+	DownloadURL string `json:"download_url"`
+	Curl        string `json:"curl"`
+	Short       string `json:"short"`
+	CLI         string `json:"cli"`
+	Warning     string `json:"warning,omitempty"`
+}
+
+// ShareMetadataResponse holds the Open Graph and Twitter Card fields for a
+// template's social card
+type ShareMetadataResponse struct {
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	OGImageURL    string `json:"og_image_url"`
+	TwitterCard   string `json:"twitter_card"`
+	URL           string `json:"url"`
+	DownloadCount int    `json:"download_count"`
+	RatingSummary string `json:"rating_summary"`
+}
+
+// TemplatePlanResponse lists the steps applying a template runs, after
+// resolving its extends chain. Chain lists the nearest parent first.
+type TemplatePlanResponse struct {
+	TemplateID string      `json:"template_id"`
+	Chain      []string    `json:"chain"`
+	Steps      []plan.Step `json:"steps"`
+}
+
+// TemplateEstimateResponse is the approximate download size of installing a
+// template, after resolving its extends chain. Packages whose size is not
+// known are counted in UnknownCount and left out of the totals.
+type TemplateEstimateResponse struct {
+	TemplateID               string            `json:"template_id"`
+	PackageCount             int               `json:"package_count"`
+	UnknownCount             int               `json:"unknown_count"`
+	TotalSizeBytes           int64             `json:"total_size_bytes"`
+	TotalSize                string            `json:"total_size"`
+	BandwidthMbps            float64           `json:"bandwidth_mbps"`
+	EstimatedDownloadSeconds int               `json:"estimated_download_seconds"`
+	Packages                 []PackageEstimate `json:"packages"`
+}
+
+// PackageEstimate is one brew or cask's approximate download size
+type PackageEstimate struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	SizeBytes *int64 `json:"size_bytes,omitempty"`
+	Unknown   bool   `json:"unknown,omitempty"`
+}
+
+// TemplateProblem is a single finding reported by POST /api/templates/validate.
+// Field uses JSON paths such as "metadata.name" or "brews[2]".
+type TemplateProblem struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type TemplateValidationResponse struct {
+	Valid    bool                      `json:"valid"`
+	Problems []TemplateProblem         `json:"problems"`
+	Warnings []TemplateProblem         `json:"warnings"`
+	Resolved *ResolvedTemplateResponse `json:"resolved,omitempty"`
+}
+
+// ResolvedTemplateResponse is the package set a template ends up with after
+// merging every template in its extends chain.
+type ResolvedTemplateResponse struct {
+	Chain       []string `json:"chain"`
+	Taps        []string `json:"taps"`
+	Brews       []string `json:"brews"`
+	Casks       []string `json:"casks"`
+	Stow        []string `json:"stow"`
+	AptPackages []string `json:"apt_packages"`
+	PipPackages []string `json:"pip_packages"`
+}
+
+var (
+	tapNamePattern  = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+	brewNamePattern = regexp.MustCompile(`^([A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+/)?[a-z0-9][a-z0-9@+._-]*$`)
+	aptNamePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9+.-]+(:[a-z0-9]+)?$`)
+	pipNamePattern  = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?(\[[A-Za-z0-9,._-]+\])?([<>=!~]=?[A-Za-z0-9.*+!-]+)?$`)
+	stowNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+)
+
+// isValidStowPackage accepts a single directory name that stays inside the
+// dotfiles repository
+func isValidStowPackage(name string) bool {
+	return stowNamePattern.MatchString(name) && name != "." && name != ".."
+}
+
+func resolveAddOnly(canonical, legacy *bool) *bool {
+	if canonical != nil {
+		return canonical
+	}
+	return legacy
+}
+
+// Template field limits, shared by the validators and the template JSON
+// schema so the two cannot disagree
+const (
+	minTemplateNameLength        = 3
+	maxTemplateNameLength        = 100
+	minTemplateDescriptionLength = 10
+	maxTemplateDescriptionLength = 500
+	maxTemplateTags              = 10
+	maxTemplateTagLength         = 30
+	maxOverrideLength            = 100
+)
+
 func validateTemplateName(name string) *errors.AppError {
 	name = strings.TrimSpace(name)
 	if name == "" {
 		return errors.NewValidationError("template name is required")
 	}
 
-	if len(name) < 3 || len(name) > 100 {
-		return errors.NewValidationError("template name must be between 3 and 100 characters")
+	if len(name) < minTemplateNameLength || len(name) > maxTemplateNameLength {
+		return errors.NewValidationError(fmt.Sprintf("template name must be between %d and %d characters", minTemplateNameLength, maxTemplateNameLength))
 	}
 
 	return nil
@@ -159,8 +687,16 @@ func validateTemplateDescription(description string) *errors.AppError {
 		return errors.NewValidationError("template description is required")
 	}
 
-	if len(description) < 10 || len(description) > 500 {
-		return errors.NewValidationError("template description must be between 10 and 500 characters")
+	if len(description) < minTemplateDescriptionLength || len(description) > maxTemplateDescriptionLength {
+		return errors.NewValidationError(fmt.Sprintf("template description must be between %d and %d characters", minTemplateDescriptionLength, maxTemplateDescriptionLength))
+	}
+
+	return nil
+}
+
+func validateTemplateAuthor(author string) *errors.AppError {
+	if strings.TrimSpace(author) == "" {
+		return errors.NewValidationError("template author is required")
 	}
 
 	return nil
@@ -172,12 +708,72 @@ func validateTemplateVersion(version string) *errors.AppError {
 		return errors.NewValidationError("template version is required")
 	}
 
+	if _, err := semver.Parse(version); err != nil {
+		appErr := errors.NewValidationError("template version must be a semantic version such as 1.2.0")
+		appErr.Details = err.Error()
+		return appErr
+	}
+
+	return nil
+}
+
+// CanonicalVersion returns a semantic version in canonical form, without a
+// leading "v". Other versions, grandfathered from before semver was
+// required, are returned unchanged.
+func CanonicalVersion(version string) string {
+	if canonical, err := semver.Normalize(version); err == nil {
+		return canonical
+	}
+	return version
+}
+
+// IsNonSemver reports whether a stored version predates the semver rule and
+// cannot be compared
+func IsNonSemver(version string) bool {
+	return version != "" && !semver.IsValid(version)
+}
+
+func validateTemplateOverrides(overrides []string) *errors.AppError {
+	seen := make(map[string]bool, len(overrides))
+	for _, override := range overrides {
+		if override == "" {
+			return errors.NewValidationError("empty overrides are not allowed")
+		}
+
+		if strings.IndexFunc(override, unicode.IsSpace) >= 0 {
+			return errors.NewValidationError(fmt.Sprintf("override %q cannot contain whitespace", override))
+		}
+
+		if len(override) > maxOverrideLength {
+			return errors.NewValidationError(fmt.Sprintf("override cannot be longer than %d characters", maxOverrideLength))
+		}
+
+		if seen[override] {
+			return errors.NewValidationError(fmt.Sprintf("duplicate override %q", override))
+		}
+		seen[override] = true
+	}
+
+	return nil
+}
+
+func validateAddOnlyOverrides(addOnly bool, overrides []string) *errors.AppError {
+	if addOnly && len(overrides) > 0 {
+		return errors.NewValidationError("cannot use overrides with add_only mode")
+	}
+
 	return nil
 }
 
 func validateTemplateTags(tags []string) *errors.AppError {
-	if len(tags) > 10 {
-		return errors.NewValidationError("template cannot have more than 10 tags")
+	return validateTags("template", tags)
+}
+
+// validateTags applies the tag limits to the tags of a resource, named in
+// the error
+func validateTags(resource string, tags []string) *errors.AppError {
+	if len(tags) > maxTemplateTags {
+		return errors.NewValidationError(fmt.Sprintf("%s cannot have more than %d tags", resource, maxTemplateTags))
 	}
 
 	for _, tag := range tags {
@@ -186,8 +782,8 @@ func validateTemplateTags(tags []string) *errors.AppError {
 			return errors.NewValidationError("empty tags are not allowed")
 		}
 
-		if len(tag) > 30 {
-			return errors.NewValidationError("tag cannot be longer than 30 characters")
+		if len(tag) > maxTemplateTagLength {
+			return errors.NewValidationError(fmt.Sprintf("tag cannot be longer than %d characters", maxTemplateTagLength))
 		}
 	}
 
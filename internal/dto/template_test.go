@@ -0,0 +1,285 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCreateTemplateRequestAddOnlyKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{name: "canonical key", body: `{"add_only": true}`, want: true},
+		{name: "legacy key", body: `{"addOnly": true}`, want: true},
+		{name: "canonical wins", body: `{"add_only": false, "addOnly": true}`, want: false},
+		{name: "absent", body: `{}`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req CreateTemplateRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("Failed to unmarshal request: %v", err)
+			}
+
+			if req.AddOnly != tt.want {
+				t.Errorf("Expected AddOnly %v, got %v", tt.want, req.AddOnly)
+			}
+		})
+	}
+}
+
+func TestCreateTemplateRequestKeepsOtherFields(t *testing.T) {
+	body := `{"brews": ["git"], "addOnly": true, "metadata": {"name": "Test Template"}}`
+
+	var req CreateTemplateRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("Failed to unmarshal request: %v", err)
+	}
+
+	if len(req.Brews) != 1 || req.Brews[0] != "git" {
+		t.Errorf("Expected brews [git], got %v", req.Brews)
+	}
+
+	if req.Metadata.Name != "Test Template" {
+		t.Errorf("Expected metadata name to be decoded, got %q", req.Metadata.Name)
+	}
+}
+
+func TestUpdateTemplateRequestAddOnlyKeys(t *testing.T) {
+	var req UpdateTemplateRequest
+	if err := json.Unmarshal([]byte(`{"addOnly": true}`), &req); err != nil {
+		t.Fatalf("Failed to unmarshal request: %v", err)
+	}
+
+	if req.AddOnly == nil || !*req.AddOnly {
+		t.Errorf("Expected legacy key to populate AddOnly, got %v", req.AddOnly)
+	}
+
+	req = UpdateTemplateRequest{}
+	if err := json.Unmarshal([]byte(`{"add_only": true}`), &req); err != nil {
+		t.Fatalf("Failed to unmarshal request: %v", err)
+	}
+
+	if req.AddOnly == nil || !*req.AddOnly {
+		t.Errorf("Expected canonical key to populate AddOnly, got %v", req.AddOnly)
+	}
+
+	req = UpdateTemplateRequest{}
+	if err := json.Unmarshal([]byte(`{}`), &req); err != nil {
+		t.Fatalf("Failed to unmarshal request: %v", err)
+	}
+
+	if req.AddOnly != nil {
+		t.Errorf("Expected AddOnly to stay unset, got %v", *req.AddOnly)
+	}
+}
+
+func TestCreateTemplateRequestLintPackageNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CreateTemplateRequest
+		problem string
+	}{
+		{name: "tap without repo", req: CreateTemplateRequest{Taps: []string{"homebrew"}}, problem: "taps[0]"},
+		{name: "brew with spaces", req: CreateTemplateRequest{Brews: []string{"git", "rm -rf"}}, problem: "brews[1]"},
+		{name: "stow escaping repo", req: CreateTemplateRequest{Stow: []string{".."}}, problem: "stow[0]"},
+		{name: "apt uppercase", req: CreateTemplateRequest{AptPackages: []string{"Curl"}}, problem: "apt_packages[0]"},
+		{name: "pip shell injection", req: CreateTemplateRequest{PipPackages: []string{"httpie; curl evil"}}, problem: "pip_packages[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, _ := tt.req.Lint()
+
+			found := false
+			for _, problem := range problems {
+				if problem.Field == tt.problem {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected problem for %s, got %v", tt.problem, problems)
+			}
+		})
+	}
+}
+
+func TestCreateTemplateRequestLintAcceptsValidTemplate(t *testing.T) {
+	req := CreateTemplateRequest{
+		Taps:        []string{"homebrew/cask-fonts"},
+		Brews:       []string{"git", "python@3.12", "hashicorp/tap/terraform"},
+		Casks:       []string{"font-jetbrains-mono-nerd-font"},
+		Stow:        []string{"zsh", ".config"},
+		AptPackages: []string{"build-essential", "libssl-dev", "g++"},
+		PipPackages: []string{"httpie", "black==24.1.0", "requests[socks]"},
+		Metadata: CreateTemplateMetadata{
+			Name:        "Valid Template",
+			Description: "A template that passes every lint rule",
+			Author:      "tester",
+			Version:     "1.0.0",
+		},
+	}
+
+	problems, warnings := req.Lint()
+	if len(problems) != 0 {
+		t.Errorf("Expected no problems, got %v", problems)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", warnings)
+	}
+
+	if err := req.Validate(); err != nil {
+		t.Errorf("Expected Validate to agree with Lint, got %v", err)
+	}
+}
+
+func TestCreateTemplateRequestValidateOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides []string
+		wantErr   bool
+	}{
+		{name: "valid", overrides: []string{"git", "homebrew/cask/iterm2"}},
+		{name: "empty entry", overrides: []string{""}, wantErr: true},
+		{name: "whitespace", overrides: []string{"neo vim"}, wantErr: true},
+		{name: "too long", overrides: []string{strings.Repeat("a", 101)}, wantErr: true},
+		{name: "duplicate", overrides: []string{"git", "git"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := CreateTemplateRequest{
+				Extends:   "base",
+				Overrides: tt.overrides,
+				Metadata: CreateTemplateMetadata{
+					Name:        "Override Template",
+					Description: "Template overriding parent packages",
+					Author:      "tester",
+					Version:     "1.0.0",
+				},
+			}
+
+			err := req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+
+			problems, _ := req.Lint()
+			if (len(problems) > 0) != tt.wantErr {
+				t.Errorf("Expected Lint to agree with Validate, got %v", problems)
+			}
+		})
+	}
+}
+
+func TestCreateTemplateRequestWarnsOverridesWithoutExtends(t *testing.T) {
+	req := CreateTemplateRequest{Overrides: []string{"git"}}
+
+	warnings := req.Warnings()
+	if len(warnings) != 1 || warnings[0].Field != "overrides" {
+		t.Errorf("Expected an overrides warning, got %v", warnings)
+	}
+
+	req.Extends = "base"
+	if warnings := req.Warnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings with extends set, got %v", warnings)
+	}
+}
+
+func TestTemplateSchemaMatchesValidators(t *testing.T) {
+	// Round-trip through JSON to read the schema as a client would
+	data, err := json.Marshal(TemplateSchema())
+	if err != nil {
+		t.Fatalf("Failed to encode schema: %v", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+
+	metadata := schema["properties"].(map[string]interface{})["metadata"].(map[string]interface{})
+	if required := fmt.Sprint(metadata["required"]); required != "[name description author version]" {
+		t.Errorf("Expected every validated metadata field to be required, got %s", required)
+	}
+
+	properties := metadata["properties"].(map[string]interface{})
+	maxTags := int(properties["tags"].(map[string]interface{})["maxItems"].(float64))
+	maxName := int(properties["name"].(map[string]interface{})["maxLength"].(float64))
+
+	valid := func() CreateTemplateRequest {
+		return CreateTemplateRequest{Metadata: CreateTemplateMetadata{
+			Name:        "Schema Template",
+			Description: "Template checked against the schema",
+			Author:      "tester",
+			Version:     "1.0.0",
+		}}
+	}
+
+	req := valid()
+	req.Metadata.Tags = make([]string, maxTags)
+	for i := range req.Metadata.Tags {
+		req.Metadata.Tags[i] = fmt.Sprintf("tag-%d", i)
+	}
+	if err := req.Validate(); err != nil {
+		t.Errorf("Expected %d tags to pass like the schema says, got %v", maxTags, err)
+	}
+	req.Metadata.Tags = append(req.Metadata.Tags, "one-too-many")
+	if err := req.Validate(); err == nil {
+		t.Errorf("Expected %d tags to fail like the schema says", maxTags+1)
+	}
+
+	req = valid()
+	req.Metadata.Name = strings.Repeat("n", maxName+1)
+	if err := req.Validate(); err == nil {
+		t.Errorf("Expected a name over the schema's %d characters to fail", maxName)
+	}
+
+	// Package patterns must compile as plain regular expressions too
+	for _, field := range []string{"taps", "brews", "casks", "stow", "apt_packages", "pip_packages"} {
+		items := schema["properties"].(map[string]interface{})[field].(map[string]interface{})["items"].(map[string]interface{})
+		if _, err := regexp.Compile(items["pattern"].(string)); err != nil {
+			t.Errorf("Expected %s pattern to compile, got %v", field, err)
+		}
+	}
+}
+
+func TestValidateRejectsOverridesWithAddOnly(t *testing.T) {
+	req := CreateTemplateRequest{
+		Extends:   "base",
+		Overrides: []string{"git"},
+		AddOnly:   true,
+		Metadata: CreateTemplateMetadata{
+			Name:        "Add Only Template",
+			Description: "Template that only adds packages",
+			Author:      "tester",
+			Version:     "1.0.0",
+		},
+	}
+
+	err := req.Validate()
+	if err == nil || err.Message != "cannot use overrides with add_only mode" {
+		t.Errorf("Expected add_only/overrides conflict, got %v", err)
+	}
+
+	problems, _ := req.Lint()
+	if len(problems) != 1 || problems[0].Field != "add_only" {
+		t.Errorf("Expected Lint to report the conflict on add_only, got %v", problems)
+	}
+
+	req.Overrides = nil
+	if err := req.Validate(); err != nil {
+		t.Errorf("Expected add_only without overrides to pass, got %v", err)
+	}
+
+	addOnly, overrides := true, []string{"git"}
+	update := UpdateTemplateRequest{AddOnly: &addOnly, Overrides: &overrides}
+	if err := update.Validate(); err == nil {
+		t.Error("Expected update with add_only and overrides to fail")
+	}
+}
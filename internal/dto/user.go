@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"net/url"
 	"regexp"
 	"strings"
 
@@ -36,6 +37,11 @@ func (r *CreateUserRequest) Validate() *errors.AppError {
 	return nil
 }
 
+// ImpersonateRequest names the user a site admin signs in as
+type ImpersonateRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
 type UpdateUserRequest struct {
 	Name     *string `json:"name"`
 	Bio      *string `json:"bio"`
@@ -54,18 +60,43 @@ func (r *UpdateUserRequest) Validate() *errors.AppError {
 	return nil
 }
 
+// AvatarProxyPath is where GET /api/avatars/:userID serves a user's avatar
+// without sending clients to the avatar host
+func AvatarProxyPath(userID string) string {
+	return "/api/avatars/" + url.PathEscape(userID)
+}
+
 type UserResponse struct {
+	ID             string `json:"id"`
+	Username       string `json:"username"`
+	Name           string `json:"name"`
+	Email          string `json:"email"`
+	AvatarURL      string `json:"avatar_url"`
+	AvatarProxyURL string `json:"avatar_proxy_url"`
+	Bio            string `json:"bio"`
+	Location       string `json:"location"`
+	Website        string `json:"website"`
+	Company        string `json:"company"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+	DeletedAt      string `json:"deleted_at,omitempty"`
+}
+
+// UserSummaryResponse is the short form of a user used in lists of other
+// users, such as followers
+type UserSummaryResponse struct {
 	ID        string `json:"id"`
 	Username  string `json:"username"`
 	Name      string `json:"name"`
-	Email     string `json:"email"`
 	AvatarURL string `json:"avatar_url"`
-	Bio       string `json:"bio"`
-	Location  string `json:"location"`
-	Website   string `json:"website"`
-	Company   string `json:"company"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+}
+
+// DownloadHistoryResponse is one entry of the caller's download history.
+// TemplateName is empty once the template is deleted.
+type DownloadHistoryResponse struct {
+	TemplateID   string `json:"template_id"`
+	TemplateName string `json:"template_name,omitempty"`
+	DownloadedAt string `json:"downloaded_at"`
 }
 
 type UserProfileResponse struct {
@@ -76,6 +107,12 @@ type UserProfileResponse struct {
 	Stats             *UserStatsResponse  `json:"stats"`
 }
 
+// MeResponse is the signed-in user's profile with activity counts
+type MeResponse struct {
+	User  *UserResponse      `json:"user"`
+	Stats *UserStatsResponse `json:"stats"`
+}
+
 type UserStatsResponse struct {
 	TemplateCount     int `json:"template_count"`
 	ReviewCount       int `json:"review_count"`
@@ -122,4 +159,24 @@ func validateURL(url string) *errors.AppError {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// MaxAPIKeysPerUser bounds how many API keys one user can hold
+const MaxAPIKeysPerUser = 10
+
+// CreateAPIKeyRequest names a new API key and, optionally, when it expires
+type CreateAPIKeyRequest struct {
+	Name          string `json:"name" binding:"required,max=100"`
+	ExpiresInDays int    `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
+
+// APIKeyResponse describes an API key. Key is only set in the response
+// that creates it; the key cannot be retrieved afterwards.
+type APIKeyResponse struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Key       string  `json:"key,omitempty"`
+	LastUsed  *string `json:"last_used"`
+	CreatedAt string  `json:"created_at"`
+	ExpiresAt *string `json:"expires_at"`
+}
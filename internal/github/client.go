@@ -0,0 +1,173 @@
+// Package github is a minimal GitHub REST API client for reading repository
+// metadata and file contents.
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the public GitHub REST API
+const DefaultBaseURL = "https://api.github.com"
+
+// maxFileSize bounds how much of a file GetFile will read
+const maxFileSize = 1 << 20
+
+var (
+	ErrRepoNotFound = errors.New("repository not found")
+	ErrFileNotFound = errors.New("file not found")
+	ErrFileTooLarge = errors.New("file too large")
+)
+
+// RateLimitError is returned when GitHub refuses a request because the
+// caller's rate limit is used up
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("GitHub rate limit exceeded, resets at %s", e.Reset.Format(time.RFC3339))
+}
+
+// RetryAfter returns how long to wait before the limit resets
+func (e *RateLimitError) RetryAfter() time.Duration {
+	wait := time.Until(e.Reset)
+	if wait < time.Second {
+		return time.Second
+	}
+	return wait
+}
+
+// Repository is the subset of repository metadata the API uses
+type Repository struct {
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	DefaultBranch string `json:"default_branch"`
+	Private       bool   `json:"private"`
+}
+
+// Client talks to the GitHub REST API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the API at baseURL, or DefaultBaseURL when
+// baseURL is empty
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// GetRepository fetches repository metadata. token may be empty for
+// anonymous access to public repositories.
+func (c *Client) GetRepository(ctx context.Context, repo, token string) (*Repository, error) {
+	resp, err := c.get(ctx, "/repos/"+repo, token, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrRepoNotFound
+	}
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var repository Repository
+	if err := json.NewDecoder(resp.Body).Decode(&repository); err != nil {
+		return nil, fmt.Errorf("decode repository: %w", err)
+	}
+	return &repository, nil
+}
+
+// GetFile fetches the raw contents of path at ref. An empty ref reads the
+// default branch.
+func (c *Client) GetFile(ctx context.Context, repo, ref, path, token string) ([]byte, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	endpoint := "/repos/" + repo + "/contents/" + strings.Join(segments, "/")
+	if ref != "" {
+		endpoint += "?ref=" + url.QueryEscape(ref)
+	}
+
+	resp, err := c.get(ctx, endpoint, token, "application/vnd.github.raw")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrFileNotFound
+	}
+	if err := checkResponse(resp); err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	if len(content) > maxFileSize {
+		return nil, ErrFileTooLarge
+	}
+	return content, nil
+}
+
+func (c *Client) get(ctx context.Context, endpoint, token, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// checkResponse turns rate limiting and other non-2xx responses into errors
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0") {
+		return &RateLimitError{Reset: rateLimitReset(resp)}
+	}
+
+	return fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+}
+
+func rateLimitReset(resp *http.Response) time.Time {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return time.Unix(reset, 0)
+	}
+	return time.Now().Add(time.Minute)
+}
@@ -0,0 +1,70 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetFileSendsToken(t *testing.T) {
+	var authorization, accept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		accept = r.Header.Get("Accept")
+		w.Write([]byte("brew \"git\"\n"))
+	}))
+	defer server.Close()
+
+	content, err := NewClient(server.URL).GetFile(context.Background(), "octocat/private", "main", "Brewfile", "secret")
+	if err != nil {
+		t.Fatalf("Failed to get file: %v", err)
+	}
+
+	if string(content) != "brew \"git\"\n" {
+		t.Errorf("Expected raw file contents, got %q", content)
+	}
+	if authorization != "Bearer secret" {
+		t.Errorf("Expected bearer token, got %q", authorization)
+	}
+	if accept != "application/vnd.github.raw" {
+		t.Errorf("Expected raw media type, got %q", accept)
+	}
+}
+
+func TestRateLimitErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		limited bool
+	}{
+		{name: "secondary limit", status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "30"}, limited: true},
+		{name: "primary limit", status: http.StatusForbidden, headers: map[string]string{"X-RateLimit-Remaining": "0"}, limited: true},
+		{name: "forbidden", status: http.StatusForbidden, limited: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, value := range tt.headers {
+					w.Header().Set(key, value)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			_, err := NewClient(server.URL).GetRepository(context.Background(), "octocat/dotfiles", "")
+
+			var rateLimited *RateLimitError
+			if errors.As(err, &rateLimited) != tt.limited {
+				t.Fatalf("Expected rate limited %v, got %v", tt.limited, err)
+			}
+			if tt.limited && rateLimited.RetryAfter() > time.Hour {
+				t.Errorf("Expected a short retry delay, got %s", rateLimited.RetryAfter())
+			}
+		})
+	}
+}
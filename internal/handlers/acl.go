@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTemplateACL lists the users a template is shared with (author only)
+func (h *TemplateHandler) GetTemplateACL(c *gin.Context) {
+	template, ok := h.loadACLTemplate(c)
+	if !ok {
+		return
+	}
+
+	respondTemplateACL(c, template)
+}
+
+// AddTemplateACLEntry shares a template with another user, who can then see
+// it while it is private (author only). Adding a user twice is a no-op.
+func (h *TemplateHandler) AddTemplateACLEntry(c *gin.Context) {
+	var req dto.AddTemplateACLEntryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	template, ok := h.loadACLTemplate(c)
+	if !ok {
+		return
+	}
+
+	if req.Username == template.Template.Metadata.Author {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("the author can always see their own template"),
+		})
+		return
+	}
+	if slices.Contains(template.AllowedUsers, req.Username) {
+		respondTemplateACL(c, template)
+		return
+	}
+	if len(template.AllowedUsers) >= dto.MaxTemplateACLEntries {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError(fmt.Sprintf("a template can be shared with at most %d users", dto.MaxTemplateACLEntries)),
+		})
+		return
+	}
+
+	user, err := h.userRepo.GetByUsername(c.Request.Context(), req.Username)
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "failed to get user", err)
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("user")})
+		return
+	}
+
+	// Copied so the stored list is untouched if the update fails
+	allowed := append(slices.Clone(template.AllowedUsers), user.Username)
+	h.saveTemplateACL(c, template, allowed)
+}
+
+// RemoveTemplateACLEntry stops sharing a template with a user (author only)
+func (h *TemplateHandler) RemoveTemplateACLEntry(c *gin.Context) {
+	template, ok := h.loadACLTemplate(c)
+	if !ok {
+		return
+	}
+
+	username := c.Param("username")
+	index := slices.Index(template.AllowedUsers, username)
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("ACL entry")})
+		return
+	}
+
+	h.saveTemplateACL(c, template, slices.Delete(slices.Clone(template.AllowedUsers), index, index+1))
+}
+
+// loadACLTemplate loads the template whose ACL is being read or changed and
+// checks that the caller is its author
+func (h *TemplateHandler) loadACLTemplate(c *gin.Context) (*models.StoredTemplate, bool) {
+	template, ok := h.loadTemplate(c, c.Param("id"))
+	if !ok {
+		return nil, false
+	}
+
+	if template.Template.Metadata.Author != c.GetString("username") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("only the template author can manage who it is shared with"),
+		})
+		return nil, false
+	}
+	return template, true
+}
+
+// saveTemplateACL stores allowed as the template's ACL and responds with it
+func (h *TemplateHandler) saveTemplateACL(c *gin.Context, template *models.StoredTemplate, allowed []string) {
+	updated := *template
+	updated.AllowedUsers = allowed
+	if err := h.templateRepo.Update(c.Request.Context(), &updated); err != nil {
+		respondInternalError(c, "failed to update template", err)
+		return
+	}
+
+	respondTemplateACL(c, &updated)
+}
+
+func respondTemplateACL(c *gin.Context, template *models.StoredTemplate) {
+	allowed := template.AllowedUsers
+	if allowed == nil {
+		allowed = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"template_id":   template.ID,
+		"allowed_users": allowed,
+	})
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newACLTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	ctx := context.Background()
+
+	userRepo := memory.NewUserRepository()
+	for _, user := range []*models.User{
+		{ID: "alice-id", Username: "alice", Email: "alice@example.com"},
+		{ID: "bob-id", Username: "bob", Email: "bob@example.com"},
+		{ID: "carol-id", Username: "carol", Email: "carol@example.com"},
+		{ID: "dave-id", Username: "dave", Email: "dave@example.com"},
+	} {
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	orgRepo := memory.NewOrganizationRepository()
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "alice-id"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	if err := orgRepo.AddMember(ctx, &models.OrganizationMember{OrganizationID: "org-acme", UserID: "carol-id", Role: models.RoleMember}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	templateRepo := memory.NewTemplateRepository()
+	for _, template := range []*models.StoredTemplate{
+		{ID: "public", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Author: "alice"}}},
+		{ID: "private", Template: models.Template{Metadata: models.ShareMetadata{Author: "alice"}}},
+		{ID: "acme", Template: models.Template{OrganizationID: "org-acme", Metadata: models.ShareMetadata{Author: "alice"}}},
+	} {
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := NewTemplateHandler(templateRepo, memory.NewTagRepository(), userRepo, memory.NewReviewRepository(), tags.NewRegistry(), NewAuthorizer(orgRepo))
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates", h.ListTemplates)
+	r.GET("/api/templates/search", h.SearchTemplates)
+	r.GET("/api/templates/:id", h.GetTemplate)
+	r.GET("/api/templates/:id/acl", h.GetTemplateACL)
+	r.POST("/api/templates/:id/acl", h.AddTemplateACLEntry)
+	r.DELETE("/api/templates/:id/acl/:username", h.RemoveTemplateACLEntry)
+	return r
+}
+
+func sendAs(r *gin.Engine, method, url, username, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if username != "" {
+		req.Header.Set("X-Test-User", username+"-id")
+		req.Header.Set("X-Test-Username", username)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestTemplateVisibilityWithACL(t *testing.T) {
+	r := newACLTestRouter(t)
+
+	for _, template := range []string{"private", "acme"} {
+		if w := sendAs(r, http.MethodPost, "/api/templates/"+template+"/acl", "alice", `{"username": "bob"}`); w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 sharing the template, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		username string
+		want     int
+	}{
+		{"public to anyone", "public", "", http.StatusOK},
+		{"private to its author", "private", "alice", http.StatusOK},
+		{"private to a listed user", "private", "bob", http.StatusOK},
+		{"private to an unlisted user", "private", "dave", http.StatusNotFound},
+		{"private to anonymous callers", "private", "", http.StatusNotFound},
+		{"organization template to a member", "acme", "carol", http.StatusOK},
+		{"organization template to a non-member", "acme", "dave", http.StatusNotFound},
+		{"organization template to a listed non-member", "acme", "bob", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := sendAs(r, http.MethodGet, "/api/templates/"+tt.template, tt.username, ""); w.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+
+	if w := sendAs(r, http.MethodDelete, "/api/templates/private/acl/bob", "alice", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 unsharing the template, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := sendAs(r, http.MethodGet, "/api/templates/private", "bob", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 once unshared, got %d", w.Code)
+	}
+}
+
+func TestTemplateACLEndpoints(t *testing.T) {
+	r := newACLTestRouter(t)
+
+	allowedUsers := func(w *httptest.ResponseRecorder) []interface{} {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		return decodeBody(t, w)["allowed_users"].([]interface{})
+	}
+
+	if got := allowedUsers(sendAs(r, http.MethodGet, "/api/templates/private/acl", "alice", "")); len(got) != 0 {
+		t.Errorf("Expected an empty ACL, got %v", got)
+	}
+
+	sendAs(r, http.MethodPost, "/api/templates/private/acl", "alice", `{"username": "bob"}`)
+	sendAs(r, http.MethodPost, "/api/templates/private/acl", "alice", `{"username": "carol"}`)
+	// Sharing twice changes nothing
+	got := allowedUsers(sendAs(r, http.MethodPost, "/api/templates/private/acl", "alice", `{"username": "bob"}`))
+	if !reflect.DeepEqual(got, []interface{}{"bob", "carol"}) {
+		t.Errorf("Expected bob and carol, got %v", got)
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		username string
+		body     string
+		want     int
+	}{
+		{"not the author", http.MethodPost, "/api/templates/private/acl", "bob", `{"username": "dave"}`, http.StatusForbidden},
+		{"not the author listing", http.MethodGet, "/api/templates/private/acl", "bob", "", http.StatusForbidden},
+		{"not the author removing", http.MethodDelete, "/api/templates/private/acl/bob", "carol", "", http.StatusForbidden},
+		{"unknown user", http.MethodPost, "/api/templates/private/acl", "alice", `{"username": "nobody"}`, http.StatusNotFound},
+		{"the author", http.MethodPost, "/api/templates/private/acl", "alice", `{"username": "alice"}`, http.StatusBadRequest},
+		{"missing username", http.MethodPost, "/api/templates/private/acl", "alice", `{}`, http.StatusBadRequest},
+		{"unlisted user", http.MethodDelete, "/api/templates/private/acl/dave", "alice", "", http.StatusNotFound},
+		{"unknown template", http.MethodGet, "/api/templates/missing/acl", "alice", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := sendAs(r, tt.method, tt.url, tt.username, tt.body); w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	got = allowedUsers(sendAs(r, http.MethodDelete, "/api/templates/private/acl/bob", "alice", ""))
+	if !reflect.DeepEqual(got, []interface{}{"carol"}) {
+		t.Errorf("Expected only carol left, got %v", got)
+	}
+}
+
+func TestTemplateListingsOnlyShowVisibleTemplates(t *testing.T) {
+	r := newACLTestRouter(t)
+
+	listed := func(url, username string) []string {
+		t.Helper()
+		w := sendAs(r, http.MethodGet, url, username, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 from %s, got %d: %s", url, w.Code, w.Body.String())
+		}
+		var ids []string
+		for _, template := range decodeBody(t, w)["templates"].([]interface{}) {
+			ids = append(ids, template.(map[string]interface{})["id"].(string))
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	tests := []struct {
+		username string
+		want     []string
+	}{
+		{"", []string{"public"}},
+		{"bob", []string{"public"}},
+		{"carol", []string{"acme", "public"}},
+		{"alice", []string{"acme", "private", "public"}},
+	}
+	for _, tt := range tests {
+		t.Run("as "+tt.username, func(t *testing.T) {
+			if got := listed("/api/templates?author=alice", tt.username); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected the list to hold %v, got %v", tt.want, got)
+			}
+			if got := listed("/api/templates/search?q=alice", tt.username); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected the search to find %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// The CSV and NDJSON exports are scoped the same way
+	w := sendAs(r, http.MethodGet, "/api/templates?author=alice&format=csv", "", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "public,") {
+		t.Fatalf("Expected a CSV export with the public template, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); strings.Contains(body, "private,") || strings.Contains(body, "acme,") {
+		t.Errorf("Expected an anonymous CSV export without private templates, got %s", body)
+	}
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+	if lines := readNDJSON(t, server.URL+"/api/templates?author=alice"); len(lines) != 1 || lines[0]["id"] != "public" {
+		t.Errorf("Expected an anonymous NDJSON export of only the public template, got %v", lines)
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"dotfiles-api/internal/auth"
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/middleware"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigureAPIKeys enables the /api/me/api-keys endpoints
+func (h *UserHandler) ConfigureAPIKeys(keys repository.APIKeyRepository) {
+	h.apiKeys = keys
+}
+
+// CreateAPIKey creates an API key for the caller. The key is only ever
+// returned in this response.
+func (h *UserHandler) CreateAPIKey(c *gin.Context) {
+	userID, ok := h.requireAPIKeyManager(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateAPIKeyRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	existing, err := h.apiKeys.ListByUser(ctx, userID)
+	if err != nil {
+		respondInternalError(c, "failed to list API keys", err)
+		return
+	}
+	if len(existing) >= dto.MaxAPIKeysPerUser {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError(fmt.Sprintf("a user can have at most %d API keys", dto.MaxAPIKeysPerUser)),
+		})
+		return
+	}
+
+	key, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		respondInternalError(c, "failed to generate API key", err)
+		return
+	}
+
+	apiKey := &models.APIKey{
+		UserID:    userID,
+		Name:      req.Name,
+		KeyHash:   hash,
+		CreatedAt: time.Now(),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := apiKey.CreatedAt.AddDate(0, 0, req.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+	if err := h.apiKeys.Create(ctx, apiKey); err != nil {
+		respondInternalError(c, "failed to create API key", err)
+		return
+	}
+
+	response := toAPIKeyResponse(apiKey)
+	response.Key = key
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListAPIKeys lists the caller's API keys, newest first, without the keys
+// themselves
+func (h *UserHandler) ListAPIKeys(c *gin.Context) {
+	userID, ok := h.requireAPIKeyManager(c)
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeys.ListByUser(c.Request.Context(), userID)
+	if err != nil {
+		respondInternalError(c, "failed to list API keys", err)
+		return
+	}
+
+	response := make([]dto.APIKeyResponse, len(keys))
+	for i, key := range keys {
+		response[i] = toAPIKeyResponse(key)
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": response})
+}
+
+// DeleteAPIKey revokes one of the caller's API keys
+func (h *UserHandler) DeleteAPIKey(c *gin.Context) {
+	userID, ok := h.requireAPIKeyManager(c)
+	if !ok {
+		return
+	}
+
+	if err := h.apiKeys.Delete(c.Request.Context(), userID, c.Param("id")); err != nil {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("API key")})
+			return
+		}
+		respondInternalError(c, "failed to delete API key", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key deleted successfully"})
+}
+
+// requireAPIKeyManager returns the caller's ID if they may manage API keys.
+// Keys are managed from a signed-in session only, so a leaked key cannot be
+// used to mint more, and never from an impersonation session.
+func (h *UserHandler) requireAPIKeyManager(c *gin.Context) (string, bool) {
+	if h.apiKeys == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": errors.NewUnavailableError("API keys are not enabled"),
+		})
+		return "", false
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return "", false
+	}
+	if c.GetString(middleware.APIKeyIDKey) != "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("API keys cannot be managed with an API key"),
+		})
+		return "", false
+	}
+	// A key would outlive the hour an impersonation session is limited to
+	if session, ok := c.Get("session"); ok && session.(*auth.Session).Impersonated {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("API keys cannot be managed while impersonating"),
+		})
+		return "", false
+	}
+	return userID, true
+}
+
+func toAPIKeyResponse(key *models.APIKey) dto.APIKeyResponse {
+	response := dto.APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		CreatedAt: key.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if key.LastUsed != nil {
+		lastUsed := key.LastUsed.Format("2006-01-02T15:04:05Z")
+		response.LastUsed = &lastUsed
+	}
+	if key.ExpiresAt != nil {
+		expiresAt := key.ExpiresAt.Format("2006-01-02T15:04:05Z")
+		response.ExpiresAt = &expiresAt
+	}
+	return response
+}
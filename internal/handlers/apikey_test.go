@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/auth"
+	"dotfiles-api/internal/middleware"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+	if err := userRepo.Create(ctx, &models.User{ID: "alice-id", Username: "alice", Email: "alice@example.com"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	keyRepo := memory.NewAPIKeyRepository()
+
+	sessionManager := auth.NewSessionManager(24 * time.Hour)
+	authMiddleware := middleware.NewAuthMiddleware(sessionManager, nil)
+	authMiddleware.ConfigureAPIKeys(keyRepo, userRepo)
+	h := NewUserHandler(userRepo, memory.NewTemplateRepository(), memory.NewReviewRepository(), memory.NewOrganizationRepository())
+	h.ConfigureAPIKeys(keyRepo)
+
+	r := gin.New()
+	r.Use(authMiddleware.APIKeyAuth())
+	r.POST("/api/me/api-keys", authMiddleware.RequireAuth(), h.CreateAPIKey)
+	r.GET("/api/me/api-keys", authMiddleware.RequireAuth(), h.ListAPIKeys)
+	r.DELETE("/api/me/api-keys/:id", authMiddleware.RequireAuth(), h.DeleteAPIKey)
+	r.GET("/api/whoami", authMiddleware.RequireAuth(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id")})
+	})
+
+	session, _ := sessionManager.CreateSession("alice-id", "alice", "alice@example.com")
+	send := func(method, url, body string, auth func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		auth(req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	withSession := func(req *http.Request) {
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: session.ID})
+	}
+	withKey := func(key string) func(*http.Request) {
+		return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+key) }
+	}
+
+	w := send(http.MethodPost, "/api/me/api-keys", `{"name": "laptop", "expires_in_days": 30}`, withSession)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	created := decodeBody(t, w)
+	key, _ := created["key"].(string)
+	if !strings.HasPrefix(key, auth.APIKeyPrefix) || created["expires_at"] == nil {
+		t.Fatalf("Expected a prefixed key with an expiry, got %v", created)
+	}
+
+	w = send(http.MethodGet, "/api/whoami", "", withKey(key))
+	if w.Code != http.StatusOK || decodeBody(t, w)["user_id"] != "alice-id" {
+		t.Errorf("Expected the key to authenticate alice, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The key itself is never listed, but its use is
+	w = send(http.MethodGet, "/api/me/api-keys", "", withSession)
+	keys := decodeBody(t, w)["api_keys"].([]interface{})
+	if len(keys) != 1 {
+		t.Fatalf("Expected one key, got %v", keys)
+	}
+	listed := keys[0].(map[string]interface{})
+	if _, ok := listed["key"]; ok || listed["last_used"] == nil {
+		t.Errorf("Expected a used key without the key itself, got %v", listed)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		auth   func(*http.Request)
+		want   int
+	}{
+		{"unknown key", http.MethodGet, "/api/whoami", "", withKey(auth.APIKeyPrefix + "nope"), http.StatusUnauthorized},
+		{"managing keys with a key", http.MethodPost, "/api/me/api-keys", `{"name": "more"}`, withKey(key), http.StatusForbidden},
+		{"missing name", http.MethodPost, "/api/me/api-keys", `{}`, withSession, http.StatusBadRequest},
+		{"expiry out of range", http.MethodPost, "/api/me/api-keys", `{"name": "x", "expires_in_days": 400}`, withSession, http.StatusBadRequest},
+		{"unknown key ID", http.MethodDelete, "/api/me/api-keys/missing", "", withSession, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := send(tt.method, tt.url, tt.body, tt.auth); w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// Expired keys are rejected
+	expired := time.Now().Add(-time.Minute)
+	expiredKey, hash, _ := auth.GenerateAPIKey()
+	if err := keyRepo.Create(ctx, &models.APIKey{UserID: "alice-id", Name: "old", KeyHash: hash, ExpiresAt: &expired}); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if w := send(http.MethodGet, "/api/whoami", "", withKey(expiredKey)); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an expired key, got %d", w.Code)
+	}
+
+	if w := send(http.MethodDelete, "/api/me/api-keys/"+created["id"].(string), "", withSession); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 revoking the key, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodGet, "/api/whoami", "", withKey(key)); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 once revoked, got %d", w.Code)
+	}
+}
+
+func TestDeletedUsersAPIKeysAreRejected(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+	keyRepo := memory.NewAPIKeyRepository()
+	keys := map[string]string{}
+	for _, username := range []string{"alice", "bob"} {
+		if err := userRepo.Create(ctx, &models.User{ID: username + "-id", Username: username, Email: username + "@example.com"}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		key, hash, _ := auth.GenerateAPIKey()
+		if err := keyRepo.Create(ctx, &models.APIKey{UserID: username + "-id", Name: "laptop", KeyHash: hash}); err != nil {
+			t.Fatalf("Failed to create API key: %v", err)
+		}
+		keys[username] = key
+	}
+
+	authMiddleware := middleware.NewAuthMiddleware(auth.NewSessionManager(24*time.Hour), nil)
+	authMiddleware.ConfigureAPIKeys(keyRepo, userRepo)
+	h := NewUserHandler(userRepo, memory.NewTemplateRepository(), memory.NewReviewRepository(), memory.NewOrganizationRepository())
+	h.ConfigureAPIKeys(keyRepo)
+
+	r := gin.New()
+	r.Use(authMiddleware.APIKeyAuth())
+	r.DELETE("/api/users/:id", h.DeleteUser)
+	r.GET("/api/whoami", authMiddleware.RequireAuth(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id")})
+	})
+
+	whoami := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/whoami", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Deleting the account through the handler revokes its keys
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/users/alice-id", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 deleting the user, got %d: %s", w.Code, w.Body.String())
+	}
+	if remaining, _ := keyRepo.ListByUser(ctx, "alice-id"); len(remaining) != 0 {
+		t.Errorf("Expected the deleted user's keys to be revoked, got %d", len(remaining))
+	}
+	if code := whoami(keys["alice"]); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a deleted user's key, got %d", code)
+	}
+
+	// A key that outlives its account is still refused
+	if err := userRepo.Delete(ctx, "bob-id"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	if code := whoami(keys["bob"]); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a deleted user's surviving key, got %d", code)
+	}
+}
@@ -1,11 +1,16 @@
 package handlers
 
 import (
-	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"dotfiles-api/internal/auth"
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/middleware"
 	"dotfiles-api/internal/models"
 	"dotfiles-api/internal/repository"
 	"dotfiles-api/pkg/errors"
@@ -15,46 +20,121 @@ import (
 
 // AuthHandler handles authentication-related HTTP requests
 type AuthHandler struct {
-	oauthService   *auth.OAuthService
-	sessionManager *auth.SessionManager
-	userRepo       repository.UserRepository
+	oauthService      *auth.OAuthService
+	sessionManager    *auth.SessionManager
+	userRepo          repository.UserRepository
+	allowRegistration bool
+	authorizer        *Authorizer
 }
 
-// NewAuthHandler creates a new auth handler
-func NewAuthHandler(oauthService *auth.OAuthService, sessionManager *auth.SessionManager, userRepo repository.UserRepository) *AuthHandler {
+// NewAuthHandler creates a new auth handler. When allowRegistration is false
+// only existing users can sign in.
+func NewAuthHandler(oauthService *auth.OAuthService, sessionManager *auth.SessionManager, userRepo repository.UserRepository, allowRegistration bool) *AuthHandler {
 	return &AuthHandler{
-		oauthService:   oauthService,
-		sessionManager: sessionManager,
-		userRepo:       userRepo,
+		oauthService:      oauthService,
+		sessionManager:    sessionManager,
+		userRepo:          userRepo,
+		allowRegistration: allowRegistration,
 	}
 }
 
-// GitHubLogin handles GitHub OAuth login
-func (h *AuthHandler) GitHubLogin(c *gin.Context) {
-	// Check if OAuth is configured
-	if !h.oauthService.IsConfigured() {
+// ConfigureMemberships loads each new session's organization roles into
+// the authorizer's membership cache
+func (h *AuthHandler) ConfigureMemberships(authorizer *Authorizer) {
+	h.authorizer = authorizer
+}
+
+// verifiedEmail returns the email a provider reported if it vouches for
+// it. Claims and invites trust the stored email, so an unverified one is
+// never stored.
+func verifiedEmail(externalUser *auth.ExternalUser) string {
+	if !externalUser.EmailVerified {
+		return ""
+	}
+	return externalUser.Email
+}
+
+// oauthProvider returns the provider named in the URL, answering 404 for
+// providers that are not enabled and 400 for ones missing credentials
+func (h *AuthHandler) oauthProvider(c *gin.Context) (auth.Provider, bool) {
+	name := c.Param("provider")
+	provider, ok := h.oauthService.Provider(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": errors.NewNotFoundError("OAuth provider"),
+		})
+		return nil, false
+	}
+
+	if !provider.Configured() {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "GitHub OAuth not configured",
-			"message": "Please set GITHUB_CLIENT_ID, GITHUB_CLIENT_SECRET, and OAUTH_REDIRECT_URL environment variables to enable GitHub authentication.",
+			"error":   fmt.Sprintf("%s OAuth not configured", name),
+			"message": fmt.Sprintf("Please set the %s client ID, client secret and redirect URL to enable %s authentication.", name, name),
 		})
+		return nil, false
+	}
+	return provider, true
+}
+
+// Login redirects to the provider named in the URL to sign in
+func (h *AuthHandler) Login(c *gin.Context) {
+	provider, ok := h.oauthProvider(c)
+	if !ok {
 		return
 	}
 
-	url, err := h.oauthService.GetAuthURL()
+	url, err := h.oauthService.GetAuthURL(provider)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to generate OAuth URL", err),
+		respondInternalError(c, "Failed to generate OAuth URL", err)
+		return
+	}
+
+	c.Redirect(http.StatusTemporaryRedirect, url)
+}
+
+// Link redirects a signed-in user to the provider named in the URL to link
+// that account to theirs
+func (h *AuthHandler) Link(c *gin.Context) {
+	provider, ok := h.oauthProvider(c)
+	if !ok {
+		return
+	}
+
+	session, exists := h.sessionManager.GetSessionFromContext(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Sign in to link an account"),
+		})
+		return
+	}
+	// Linking would attach the admin's own account to the impersonated user
+	// for good, outliving the impersonation session
+	if session.Impersonated {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("Accounts cannot be linked while impersonating"),
 		})
 		return
 	}
 
+	url, err := h.oauthService.GetLinkURL(provider, session.UserID)
+	if err != nil {
+		respondInternalError(c, "Failed to generate OAuth URL", err)
+		return
+	}
+
 	c.Redirect(http.StatusTemporaryRedirect, url)
 }
 
-// GitHubCallback handles GitHub OAuth callback
-func (h *AuthHandler) GitHubCallback(c *gin.Context) {
-	state := c.Query("state")
-	if !h.oauthService.ValidateState(state) {
+// Callback handles the OAuth callback of the provider named in the URL,
+// either signing the user in or finishing a link started by Link
+func (h *AuthHandler) Callback(c *gin.Context) {
+	provider, ok := h.oauthProvider(c)
+	if !ok {
+		return
+	}
+
+	state, ok := h.oauthService.ValidateState(c.Query("state"), provider.Name())
+	if !ok {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": errors.NewBadRequestError("Invalid OAuth state"),
 		})
@@ -62,7 +142,7 @@ func (h *AuthHandler) GitHubCallback(c *gin.Context) {
 	}
 
 	code := c.Query("code")
-	token, err := h.oauthService.ExchangeCode(c.Request.Context(), code)
+	token, err := provider.Exchange(c.Request.Context(), code)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": errors.NewBadRequestError("Failed to exchange OAuth code"),
@@ -70,83 +150,95 @@ func (h *AuthHandler) GitHubCallback(c *gin.Context) {
 		return
 	}
 
-	// Get user info from GitHub
-	client := h.oauthService.GetClient(c.Request.Context(), token)
-	resp, err := client.Get("https://api.github.com/user")
+	externalUser, err := provider.FetchUser(c.Request.Context(), token)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to get user info from GitHub", err),
-		})
+		respondInternalError(c, "Failed to get user info from "+provider.Name(), err)
 		return
 	}
-	defer resp.Body.Close()
 
-	var githubUser struct {
-		ID        int    `json:"id"`
-		Username  string `json:"login"`
-		Name      string `json:"name"`
-		Email     string `json:"email"`
-		AvatarURL string `json:"avatar_url"`
-		Bio       string `json:"bio"`
-		Location  string `json:"location"`
-		Website   string `json:"blog"`
+	if state.LinkUserID != "" {
+		h.linkIdentity(c, state.LinkUserID, externalUser)
+		return
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&githubUser); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to decode GitHub user data", err),
-		})
+	user, err := h.findUserByIdentity(c, externalUser)
+	if err != nil {
+		respondInternalError(c, "Failed to check existing user", err)
+		return
+	}
+	if user != nil && user.IsDeleted() {
+		respondAccountDeleted(c)
 		return
 	}
 
-	// Check if user already exists
-	user, err := h.userRepo.GetByGitHubID(c.Request.Context(), githubUser.ID)
-	if err != nil {
-		// If it's not a "not found" error, then it's a real error
-		if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != "NOT_FOUND" {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": errors.NewInternalError("Failed to check existing user", err),
+	// Create or update user
+	if user == nil {
+		if !h.allowRegistration {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": errors.NewForbiddenError("Registration is disabled"),
 			})
 			return
 		}
-		// User doesn't exist yet, that's fine - we'll create them below
-		user = nil
-	}
 
-	// Create or update user
-	if user == nil {
 		user = &models.User{
-			GitHubID:    githubUser.ID,
-			Username:    githubUser.Username,
-			Name:        githubUser.Name,
-			Email:       githubUser.Email,
-			AvatarURL:   githubUser.AvatarURL,
-			Bio:         githubUser.Bio,
-			Location:    githubUser.Location,
-			Website:     githubUser.Website,
+			Username:    externalUser.Username,
+			Name:        externalUser.Name,
+			Email:       verifiedEmail(externalUser),
+			AvatarURL:   externalUser.AvatarURL,
+			Bio:         externalUser.Bio,
+			Location:    externalUser.Location,
+			Website:     externalUser.Website,
 			Favorites:   []string{},
 			Collections: []string{},
+			Identities: []models.Identity{{
+				Provider:   externalUser.Provider,
+				ExternalID: externalUser.ExternalID,
+			}},
+		}
+		if externalUser.Provider == auth.ProviderGitHub {
+			user.GitHubID, _ = strconv.Atoi(externalUser.ExternalID)
 		}
 
 		if err := h.userRepo.Create(c.Request.Context(), user); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": errors.NewInternalError("Failed to create user", err),
-			})
-			return
+			var appErr *errors.AppError
+			if stderrors.As(err, &appErr) && appErr.Code == errors.ErrCodeConflict {
+				// The username or email belongs to an account signed in
+				// through another provider
+				c.JSON(http.StatusConflict, gin.H{
+					"error": errors.NewConflictError(appErr.Message + "; sign in to that account and link " + provider.Name() + " instead"),
+				})
+				return
+			}
+			if !stderrors.Is(err, repository.ErrAlreadyExists) {
+				respondInternalError(c, "Failed to create user", err)
+				return
+			}
+
+			// A concurrent callback for the same provider account created
+			// the user first; sign in as that user instead
+			user, err = h.findUserByIdentity(c, externalUser)
+			if err != nil || user == nil {
+				respondInternalError(c, "Failed to load existing user", err)
+				return
+			}
+			if user.IsDeleted() {
+				respondAccountDeleted(c)
+				return
+			}
 		}
 	} else {
 		// Update existing user info
-		user.Name = githubUser.Name
-		user.Email = githubUser.Email
-		user.AvatarURL = githubUser.AvatarURL
-		user.Bio = githubUser.Bio
-		user.Location = githubUser.Location
-		user.Website = githubUser.Website
+		user.Name = externalUser.Name
+		if externalUser.EmailVerified {
+			user.Email = externalUser.Email
+		}
+		user.AvatarURL = externalUser.AvatarURL
+		user.Bio = externalUser.Bio
+		user.Location = externalUser.Location
+		user.Website = externalUser.Website
 
 		if err := h.userRepo.Update(c.Request.Context(), user); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": errors.NewInternalError("Failed to update user", err),
-			})
+			respondInternalError(c, "Failed to update user", err)
 			return
 		}
 	}
@@ -154,28 +246,144 @@ func (h *AuthHandler) GitHubCallback(c *gin.Context) {
 	// Create session
 	session, err := h.sessionManager.CreateSession(user.ID, user.Username, user.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to create session", err),
-		})
+		respondInternalError(c, "Failed to create session", err)
 		return
 	}
 
+	h.cacheMemberships(c, session, user)
+
+	// Keep the GitHub token so imports can read the user's private repos
+	if provider.Name() == auth.ProviderGitHub {
+		h.sessionManager.UpdateSession(session.ID, map[string]interface{}{
+			auth.GitHubTokenKey: token.AccessToken,
+		})
+	}
+
 	// Set session cookie
 	h.sessionManager.SetSessionCookie(c, session)
 
 	// Redirect to frontend or return success
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Authentication successful",
-		"user": gin.H{
-			"id":         user.ID,
-			"username":   user.Username,
-			"name":       user.Name,
-			"email":      user.Email,
-			"avatar_url": user.AvatarURL,
-		},
+		"user":    sessionUserResponse(user),
+	})
+}
+
+// findUserByIdentity loads the user linked to a provider account. GitHub
+// users created before identities existed are found by GitHub ID and have
+// the identity added. It returns nil when no user is linked, and deleted
+// users as they are, for the caller to refuse.
+func (h *AuthHandler) findUserByIdentity(c *gin.Context, externalUser *auth.ExternalUser) (*models.User, error) {
+	ctx := c.Request.Context()
+	user, err := h.userRepo.GetByIdentity(ctx, externalUser.Provider, externalUser.ExternalID)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	if user != nil || externalUser.Provider != auth.ProviderGitHub {
+		return user, nil
+	}
+
+	githubID, err := strconv.Atoi(externalUser.ExternalID)
+	if err != nil {
+		return nil, nil
+	}
+	user, err = h.userRepo.GetByGitHubID(ctx, githubID)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	if user == nil || user.IsDeleted() {
+		return user, nil
+	}
+
+	identity := models.Identity{Provider: auth.ProviderGitHub, ExternalID: externalUser.ExternalID}
+	if err := h.userRepo.AddIdentity(ctx, user.ID, identity); err != nil {
+		return nil, err
+	}
+	user.Identities = append(user.Identities, identity)
+	return user, nil
+}
+
+// linkIdentity links a provider account to the user who started the link
+func (h *AuthHandler) linkIdentity(c *gin.Context, userID string, externalUser *auth.ExternalUser) {
+	ctx := c.Request.Context()
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "Failed to get user details", err)
+		return
+	}
+	if user == nil || user.IsDeleted() {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("User not found"),
+		})
+		return
+	}
+
+	if existing, ok := user.IdentityFor(externalUser.Provider); ok && existing.ExternalID != externalUser.ExternalID {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": errors.NewConflictError("A different " + externalUser.Provider + " account is already linked"),
+		})
+		return
+	}
+
+	identity := models.Identity{Provider: externalUser.Provider, ExternalID: externalUser.ExternalID}
+	if err := h.userRepo.AddIdentity(ctx, user.ID, identity); err != nil {
+		if stderrors.Is(err, repository.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": errors.NewConflictError("This " + externalUser.Provider + " account is linked to another user"),
+			})
+			return
+		}
+		respondInternalError(c, "Failed to link account", err)
+		return
+	}
+
+	if !user.HasIdentity(identity.Provider, identity.ExternalID) {
+		user.Identities = append(user.Identities, identity)
+	}
+	if externalUser.Provider == auth.ProviderGitHub && user.GitHubID == 0 {
+		user.GitHubID, _ = strconv.Atoi(externalUser.ExternalID)
+		if err := h.userRepo.Update(ctx, user); err != nil {
+			respondInternalError(c, "Failed to update user", err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account linked",
+		"user":    sessionUserResponse(user),
 	})
 }
 
+// respondAccountDeleted refuses a sign-in to a deleted account. Deleted
+// users keep their identities so their reviews still resolve, but they must
+// not be revived or have their personal details written back.
+func respondAccountDeleted(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": errors.NewForbiddenError("This account has been deleted"),
+	})
+}
+
+// sessionUserResponse is the user summary returned after signing in
+func sessionUserResponse(user *models.User) gin.H {
+	return gin.H{
+		"id":               user.ID,
+		"username":         user.Username,
+		"name":             user.Name,
+		"email":            user.Email,
+		"avatar_url":       user.AvatarURL,
+		"avatar_proxy_url": dto.AvatarProxyPath(user.ID),
+		"identities":       userIdentities(user),
+	}
+}
+
+// userIdentities returns the user's linked provider accounts, never nil
+func userIdentities(user *models.User) []models.Identity {
+	if user.Identities == nil {
+		return []models.Identity{}
+	}
+	return user.Identities
+}
+
 // Logout handles user logout
 func (h *AuthHandler) Logout(c *gin.Context) {
 	session, exists := h.sessionManager.GetSessionFromContext(c)
@@ -190,14 +398,122 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	})
 }
 
+// ImpersonateUser signs a site admin in as another user so support can
+// reproduce their issues. The session is flagged as impersonated and expires
+// after auth.ImpersonationTimeout.
+func (h *AuthHandler) ImpersonateUser(c *gin.Context) {
+	var req dto.ImpersonateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	// Impersonation needs an admin at a browser: a key handed to a script,
+	// such as a metrics scraper, must not be able to become any user
+	if c.GetString(middleware.APIKeyIDKey) != "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("Cannot impersonate with an API key"),
+		})
+		return
+	}
+
+	// An impersonation session must not start another and hide the admin
+	if session, ok := c.Get("session"); ok && session.(*auth.Session).Impersonated {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("Cannot impersonate from an impersonation session"),
+		})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), req.UserID)
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "Failed to get user", err)
+		return
+	}
+	if user == nil || user.IsDeleted() {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": errors.NewNotFoundError("user"),
+		})
+		return
+	}
+
+	adminUserID := c.GetString("user_id")
+	session, err := h.sessionManager.CreateImpersonationSession(user.ID, user.Username, user.Email, adminUserID)
+	if err != nil {
+		respondInternalError(c, "Failed to create session", err)
+		return
+	}
+	log.Printf("Admin %s (%s) is impersonating user %s (%s) until %s",
+		c.GetString("username"), adminUserID, user.Username, user.ID, session.ExpiresAt.Format(time.RFC3339))
+	h.cacheMemberships(c, session, user)
+
+	h.sessionManager.SetSessionCookie(c, session)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Impersonation session created",
+		"user":         sessionUserResponse(user),
+		"impersonated": true,
+		"expires_at":   session.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
+// cacheMemberships loads a new session's organization roles. Failing to is
+// not fatal: access checks then load them on demand.
+func (h *AuthHandler) cacheMemberships(c *gin.Context, session *auth.Session, user *models.User) {
+	if _, err := h.authorizer.CacheMemberships(c.Request.Context(), session.ID, user); err != nil {
+		log.Printf("Failed to cache organization memberships for user %s: %v", user.ID, err)
+	}
+}
+
+// RefreshMemberships reloads the caller's organization roles into their
+// session, for clients that know their memberships just changed
+func (h *AuthHandler) RefreshMemberships(c *gin.Context) {
+	value, _ := c.Get("session")
+	session, ok := value.(*auth.Session)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("authentication required"),
+		})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), session.UserID)
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "Failed to get user", err)
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("User not found"),
+		})
+		return
+	}
+
+	memberships, err := h.authorizer.CacheMemberships(c.Request.Context(), session.ID, user)
+	if err != nil {
+		respondInternalError(c, "Failed to load organization memberships", err)
+		return
+	}
+	if memberships == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": errors.NewUnavailableError("Organization membership caching is not enabled"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles":      memberships.Roles,
+		"checked_at": memberships.CheckedAt.Format(time.RFC3339),
+	})
+}
+
 // GetCurrentUser handles getting current user info
 func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 	// Check if OAuth is configured
 	if !h.oauthService.IsConfigured() {
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":       "GitHub OAuth not configured",
-			"configured":  false,
-			"message":     "Authentication is not available. Please configure GitHub OAuth to enable user features.",
+			"error":      "OAuth not configured",
+			"configured": false,
+			"message":    "Authentication is not available. Please configure an OAuth provider to enable user features.",
 		})
 		return
 	}
@@ -214,9 +530,7 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 	// Get full user details
 	user, err := h.userRepo.GetByID(c.Request.Context(), session.UserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to get user details", err),
-		})
+		respondInternalError(c, "Failed to get user details", err)
 		return
 	}
 
@@ -227,18 +541,64 @@ func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user": gin.H{
-			"id":         user.ID,
-			"username":   user.Username,
-			"name":       user.Name,
-			"email":      user.Email,
-			"avatar_url": user.AvatarURL,
-			"bio":        user.Bio,
-			"location":   user.Location,
-			"website":    user.Website,
-			"created_at": user.CreatedAt.Format(time.RFC3339),
-		},
+	response := gin.H{
+		"user":       currentUserResponse(user),
 		"configured": true,
-	})
-}
\ No newline at end of file
+	}
+	if session.Impersonated {
+		response["impersonated"] = true
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetStatus reports whether sign-in is available, through which providers,
+// and who is signed in when the request carries a valid session. Unlike
+// GetCurrentUser it never answers 401, so clients can call it to decide
+// which sign-in buttons to show.
+func (h *AuthHandler) GetStatus(c *gin.Context) {
+	providers := h.oauthService.ConfiguredProviders()
+	response := gin.H{
+		"configured":        len(providers) > 0,
+		"providers":         providers,
+		"registration_open": h.allowRegistration,
+		"authenticated":     false,
+	}
+
+	if session, exists := h.sessionManager.GetSessionFromContext(c); exists {
+		user, err := h.userRepo.GetByID(c.Request.Context(), session.UserID)
+		if err != nil && !isNotFound(err) {
+			respondInternalError(c, "Failed to get user details", err)
+			return
+		}
+		if user != nil {
+			response["authenticated"] = true
+			response["user"] = currentUserResponse(user)
+			if session.Impersonated {
+				response["impersonated"] = true
+			}
+		}
+	}
+
+	// The answer depends on the session cookie
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, response)
+}
+
+// currentUserResponse is the signed-in user's own profile, identities
+// included
+func currentUserResponse(user *models.User) gin.H {
+	return gin.H{
+		"id":               user.ID,
+		"username":         user.Username,
+		"name":             user.Name,
+		"email":            user.Email,
+		"avatar_url":       user.AvatarURL,
+		"avatar_proxy_url": dto.AvatarProxyPath(user.ID),
+		"bio":              user.Bio,
+		"location":         user.Location,
+		"website":          user.Website,
+		"identities":       userIdentities(user),
+		"created_at":       user.CreatedAt.Format(time.RFC3339),
+	}
+}
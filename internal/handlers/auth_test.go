@@ -0,0 +1,482 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/auth"
+	"dotfiles-api/internal/middleware"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newFakeProviderServer serves the OAuth token and user endpoints of GitHub
+// and GitLab. The code sent to the token endpoint picks the user returned.
+func newFakeProviderServer(t *testing.T, users map[string]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/login/oauth/access_token", "/oauth/token":
+			r.ParseForm()
+			json.NewEncoder(w).Encode(map[string]string{
+				"access_token": r.PostForm.Get("code"),
+				"token_type":   "bearer",
+			})
+		case "/api/v3/user", "/api/v4/user":
+			token := r.Header.Get("Authorization")[len("Bearer "):]
+			user, ok := users[token]
+			if !ok {
+				http.Error(w, "bad token", http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(user)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOAuthProviders(t *testing.T) {
+	server := newFakeProviderServer(t, map[string]map[string]interface{}{
+		"alice-github": {"id": 101, "login": "alice", "name": "Alice", "email": "alice@example.com"},
+		"alice-gitlab": {"id": 7, "username": "alice-gl", "name": "Alice", "email": "alice@example.org"},
+		"bob-gitlab":   {"id": 8, "username": "bob", "name": "Bob", "email": "bob@example.com"},
+	})
+	providerConfig := auth.ProviderConfig{ClientID: "client", ClientSecret: "secret", BaseURL: server.URL}
+	oauthService := auth.NewOAuthService(auth.NewGitHubProvider(providerConfig), auth.NewGitLabProvider(providerConfig))
+	sessionManager := auth.NewSessionManager(time.Hour)
+	userRepo := memory.NewUserRepository()
+
+	h := NewAuthHandler(oauthService, sessionManager, userRepo, true)
+	r := gin.New()
+	r.GET("/auth/:provider", h.Login)
+	r.GET("/auth/:provider/callback", h.Callback)
+	r.GET("/auth/:provider/link", h.Link)
+
+	get := func(path, sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if sessionID != "" {
+			req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	// authorize follows a login or link redirect and returns the state
+	authorize := func(path, sessionID string) string {
+		t.Helper()
+		w := get(path, sessionID)
+		if w.Code != http.StatusTemporaryRedirect {
+			t.Fatalf("Expected a redirect from %s, got %d: %s", path, w.Code, w.Body.String())
+		}
+		location, err := url.Parse(w.Header().Get("Location"))
+		if err != nil {
+			t.Fatalf("Failed to parse redirect: %v", err)
+		}
+		return location.Query().Get("state")
+	}
+	callback := func(provider, state, code, sessionID string) *httptest.ResponseRecorder {
+		return get("/auth/"+provider+"/callback?"+url.Values{"state": {state}, "code": {code}}.Encode(), sessionID)
+	}
+
+	if w := get("/auth/bitbucket", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown provider, got %d", w.Code)
+	}
+
+	// A state issued for one provider is rejected by another's callback
+	state := authorize("/auth/github", "")
+	if w := callback("gitlab", state, "alice-gitlab", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for another provider's state, got %d", w.Code)
+	}
+
+	state = authorize("/auth/github", "")
+	w := callback("github", state, "alice-github", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected GitHub sign-in to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	alice, err := userRepo.GetByIdentity(context.Background(), auth.ProviderGitHub, "101")
+	if err != nil || alice.Username != "alice" || alice.GitHubID != 101 {
+		t.Fatalf("Expected alice to be created with her GitHub identity, got %+v (%v)", alice, err)
+	}
+	var sessionID string
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "session_id" {
+			sessionID = cookie.Value
+		}
+	}
+
+	if w := get("/auth/gitlab/link", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 linking while signed out, got %d", w.Code)
+	}
+
+	state = authorize("/auth/gitlab/link", sessionID)
+	if w := callback("gitlab", state, "alice-gitlab", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected GitLab link to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Signing in with the linked GitLab account finds alice
+	state = authorize("/auth/gitlab", "")
+	w = callback("gitlab", state, "alice-gitlab", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected GitLab sign-in to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		User struct {
+			ID         string `json:"id"`
+			Identities []struct {
+				Provider   string `json:"provider"`
+				ExternalID string `json:"external_id"`
+			} `json:"identities"`
+		} `json:"user"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.User.ID != alice.ID || len(body.User.Identities) != 2 {
+		t.Errorf("Expected GitLab sign-in as alice with two identities, got %+v", body.User)
+	}
+	// GitLab does not vouch for emails, so alice keeps her GitHub one
+	if alice, _ := userRepo.GetByID(context.Background(), alice.ID); alice.Email != "alice@example.com" {
+		t.Errorf("Expected alice's GitHub email to be kept, got %q", alice.Email)
+	}
+
+	// Bob cannot link alice's GitLab account
+	state = authorize("/auth/gitlab", "")
+	w = callback("gitlab", state, "bob-gitlab", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected bob's sign-in to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	if bob, _ := userRepo.GetByIdentity(context.Background(), auth.ProviderGitLab, "8"); bob == nil || bob.Email != "" {
+		t.Errorf("Expected bob to sign up without his unverified GitLab email, got %+v", bob)
+	}
+	var bobSessionID string
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == "session_id" {
+			bobSessionID = cookie.Value
+		}
+	}
+	state = authorize("/auth/gitlab/link", bobSessionID)
+	if w := callback("gitlab", state, "alice-gitlab", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 linking another user's identity, got %d", w.Code)
+	}
+	state = authorize("/auth/github/link", bobSessionID)
+	if w := callback("github", state, "alice-github", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 linking another user's GitHub identity, got %d", w.Code)
+	}
+}
+
+func TestDeletedUsersCannotSignIn(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeProviderServer(t, map[string]map[string]interface{}{
+		"alice-github":  {"id": 101, "login": "alice", "name": "Alice", "email": "alice@example.com", "location": "Berlin"},
+		"legacy-github": {"id": 202, "login": "legacy", "name": "Legacy", "email": "legacy@example.com"},
+		"carol-gitlab":  {"id": 9, "username": "carol", "name": "Carol", "email": "carol@example.com"},
+	})
+	providerConfig := auth.ProviderConfig{ClientID: "client", ClientSecret: "secret", BaseURL: server.URL}
+	oauthService := auth.NewOAuthService(auth.NewGitHubProvider(providerConfig), auth.NewGitLabProvider(providerConfig))
+	sessionManager := auth.NewSessionManager(time.Hour)
+	userRepo := memory.NewUserRepository()
+	// Signed up before identities existed, so only the GitHub ID links it
+	if err := userRepo.Create(ctx, &models.User{ID: "legacy-id", Username: "legacy", Email: "legacy@example.com", GitHubID: 202}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	h := NewAuthHandler(oauthService, sessionManager, userRepo, true)
+	r := gin.New()
+	r.GET("/auth/:provider", h.Login)
+	r.GET("/auth/:provider/callback", h.Callback)
+	r.GET("/auth/:provider/link", h.Link)
+
+	get := func(path, sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if sessionID != "" {
+			req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	signIn := func(provider, code, sessionID string) *httptest.ResponseRecorder {
+		t.Helper()
+		path := "/auth/" + provider
+		if sessionID != "" {
+			path += "/link"
+		}
+		location, err := url.Parse(get(path, sessionID).Header().Get("Location"))
+		if err != nil {
+			t.Fatalf("Failed to parse redirect: %v", err)
+		}
+		state := location.Query().Get("state")
+		return get("/auth/"+provider+"/callback?"+url.Values{"state": {state}, "code": {code}}.Encode(), "")
+	}
+
+	if w := signIn("github", "alice-github", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected alice to sign up, got %d: %s", w.Code, w.Body.String())
+	}
+	alice, _ := userRepo.GetByIdentity(ctx, auth.ProviderGitHub, "101")
+	aliceSession, _ := sessionManager.CreateSession(alice.ID, alice.Username, alice.Email)
+	for _, id := range []string{alice.ID, "legacy-id"} {
+		if err := userRepo.Delete(ctx, id); err != nil {
+			t.Fatalf("Failed to delete user: %v", err)
+		}
+	}
+
+	for _, code := range []string{"alice-github", "legacy-github"} {
+		w := signIn("github", code, "")
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected 403 signing in to a deleted account with %s, got %d: %s", code, w.Code, w.Body.String())
+		}
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == "session_id" && cookie.Value != "" {
+				t.Errorf("Expected no session for a deleted account, got %+v", cookie)
+			}
+		}
+	}
+
+	// Nothing is written back to the deleted accounts
+	for _, id := range []string{alice.ID, "legacy-id"} {
+		user, _ := userRepo.GetByID(ctx, id)
+		if user.Email != "" || user.Location != "" || !user.IsDeleted() {
+			t.Errorf("Expected %s to stay deleted and scrubbed, got %+v", id, user)
+		}
+		if id == "legacy-id" && len(user.Identities) != 0 {
+			t.Errorf("Expected no identity added to a deleted account, got %v", user.Identities)
+		}
+	}
+
+	// A session left over from before the deletion cannot link accounts
+	if w := signIn("gitlab", "carol-gitlab", aliceSession.ID); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 linking to a deleted account, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestImpersonateUser(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+	for _, user := range []*models.User{
+		{ID: "admin-id", Username: "admin", Email: "admin@example.com"},
+		{ID: "root-id", Username: "root", Email: "root@example.com"},
+		{ID: "alice-id", Username: "alice", Email: "alice@example.com"},
+	} {
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	sessionManager := auth.NewSessionManager(24 * time.Hour)
+	authMiddleware := middleware.NewAuthMiddleware(sessionManager, []string{"admin", "root"})
+	h := NewAuthHandler(auth.NewOAuthService(auth.NewGitHubProvider(auth.ProviderConfig{ClientID: "client"})), sessionManager, userRepo, true)
+	r := gin.New()
+	r.POST("/api/auth/impersonate", authMiddleware.RequireAuth(), authMiddleware.RequireAdmin(), h.ImpersonateUser)
+	r.GET("/auth/user", h.GetCurrentUser)
+
+	impersonate := func(sessionID, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/impersonate", strings.NewReader(`{"user_id": "`+userID+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	admin, _ := sessionManager.CreateSession("admin-id", "admin", "admin@example.com")
+	alice, _ := sessionManager.CreateSession("alice-id", "alice", "alice@example.com")
+
+	if w := impersonate(alice.ID, "admin-id"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a non-admin, got %d", w.Code)
+	}
+	if w := impersonate(admin.ID, "nobody"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown user, got %d", w.Code)
+	}
+
+	w := impersonate(admin.ID, "alice-id")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected impersonation to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "session_id" {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.MaxAge != int(auth.ImpersonationTimeout.Seconds()) {
+		t.Fatalf("Expected an hour-long session cookie, got %+v", cookie)
+	}
+
+	session, ok := sessionManager.GetSession(cookie.Value)
+	if !ok || !session.Impersonated || session.ImpersonatedBy != "admin-id" || session.UserID != "alice-id" {
+		t.Fatalf("Expected an impersonation session for alice, got %+v", session)
+	}
+	if time.Until(session.ExpiresAt) > auth.ImpersonationTimeout {
+		t.Errorf("Expected the session to expire within an hour, got %s", session.ExpiresAt)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/user", nil)
+	req.AddCookie(cookie)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var body struct {
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+		Impersonated bool `json:"impersonated"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if body.User.ID != "alice-id" || !body.Impersonated {
+		t.Errorf("Expected alice flagged as impersonated, got %s", w.Body.String())
+	}
+
+	// Impersonating an admin does not allow impersonating onwards
+	w = impersonate(admin.ID, "root-id")
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "session_id" {
+			cookie = c
+		}
+	}
+	if w := impersonate(cookie.Value, "alice-id"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 impersonating from an impersonation session, got %d", w.Code)
+	}
+}
+
+func TestImpersonationCannotOutliveItsSession(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+	for _, user := range []*models.User{
+		{ID: "admin-id", Username: "admin", Email: "admin@example.com"},
+		{ID: "alice-id", Username: "alice", Email: "alice@example.com"},
+	} {
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	keyRepo := memory.NewAPIKeyRepository()
+	adminKey, hash, _ := auth.GenerateAPIKey()
+	if err := keyRepo.Create(ctx, &models.APIKey{UserID: "admin-id", Name: "metrics", KeyHash: hash}); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+
+	sessionManager := auth.NewSessionManager(24 * time.Hour)
+	authMiddleware := middleware.NewAuthMiddleware(sessionManager, []string{"admin"})
+	authMiddleware.ConfigureAPIKeys(keyRepo, userRepo)
+	h := NewAuthHandler(auth.NewOAuthService(auth.NewGitHubProvider(auth.ProviderConfig{ClientID: "client"})), sessionManager, userRepo, true)
+	userHandler := NewUserHandler(userRepo, memory.NewTemplateRepository(), memory.NewReviewRepository(), memory.NewOrganizationRepository())
+	userHandler.ConfigureAPIKeys(keyRepo)
+
+	r := gin.New()
+	r.POST("/api/auth/impersonate", authMiddleware.RequireAuth(), authMiddleware.RequireAdmin(), h.ImpersonateUser)
+	r.POST("/api/me/api-keys", authMiddleware.RequireAuth(), userHandler.CreateAPIKey)
+	r.GET("/auth/:provider/link", h.Link)
+
+	send := func(method, url, body string, auth func(*http.Request)) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		auth(req)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	withSession := func(session *auth.Session) func(*http.Request) {
+		return func(req *http.Request) { req.AddCookie(&http.Cookie{Name: "session_id", Value: session.ID}) }
+	}
+
+	alice, _ := sessionManager.CreateSession("alice-id", "alice", "alice@example.com")
+	impersonation, _ := sessionManager.CreateImpersonationSession("alice-id", "alice", "alice@example.com", "admin-id")
+
+	// The user's own session may do both
+	if w := send(http.MethodGet, "/auth/github/link", "", withSession(alice)); w.Code != http.StatusTemporaryRedirect {
+		t.Errorf("Expected the user to be sent to link an account, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/me/api-keys", `{"name": "laptop"}`, withSession(alice)); w.Code != http.StatusCreated {
+		t.Errorf("Expected the user to create a key, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := send(http.MethodGet, "/auth/github/link", "", withSession(impersonation)); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 linking an account while impersonating, got %d", w.Code)
+	}
+	if w := send(http.MethodPost, "/api/me/api-keys", `{"name": "forever"}`, withSession(impersonation)); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 creating a key while impersonating, got %d", w.Code)
+	}
+	if keys, _ := keyRepo.ListByUser(ctx, "alice-id"); len(keys) != 1 {
+		t.Errorf("Expected only the user's own key, got %d", len(keys))
+	}
+
+	// An admin's API key cannot start an impersonation
+	withKey := func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+adminKey) }
+	if w := send(http.MethodPost, "/api/auth/impersonate", `{"user_id": "alice-id"}`, withKey); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 impersonating with an API key, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAuthStatus(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+	if err := userRepo.Create(ctx, &models.User{ID: "alice-id", Username: "alice", Email: "alice@example.com"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	sessionManager := auth.NewSessionManager(time.Hour)
+
+	// GitLab is enabled but has no credentials
+	oauthService := auth.NewOAuthService(
+		auth.NewGitHubProvider(auth.ProviderConfig{ClientID: "client", ClientSecret: "secret"}),
+		auth.NewGitLabProvider(auth.ProviderConfig{}),
+	)
+	r := gin.New()
+	r.GET("/auth/status", NewAuthHandler(oauthService, sessionManager, userRepo, false).GetStatus)
+	r.GET("/unconfigured/status", NewAuthHandler(auth.NewOAuthService(), sessionManager, userRepo, true).GetStatus)
+
+	status := func(url, sessionID string) map[string]interface{} {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if sessionID != "" {
+			req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Cache-Control"); got != "no-store" {
+			t.Errorf("Expected Cache-Control no-store, got %q", got)
+		}
+		return decodeBody(t, w)
+	}
+
+	body := status("/auth/status", "")
+	if body["configured"] != true || body["registration_open"] != false || body["authenticated"] != false || body["user"] != nil {
+		t.Errorf("Expected a configured, signed-out status, got %v", body)
+	}
+	if providers := body["providers"].([]interface{}); len(providers) != 1 || providers[0] != "github" {
+		t.Errorf("Expected only github, got %v", providers)
+	}
+
+	if body := status("/auth/status", "stale-session"); body["authenticated"] != false {
+		t.Errorf("Expected an unknown session to be signed out, got %v", body)
+	}
+
+	session, err := sessionManager.CreateSession("alice-id", "alice", "alice@example.com")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	body = status("/auth/status", session.ID)
+	if user, _ := body["user"].(map[string]interface{}); body["authenticated"] != true || user["username"] != "alice" {
+		t.Errorf("Expected alice signed in, got %v", body)
+	}
+
+	body = status("/unconfigured/status", "")
+	if body["configured"] != false || len(body["providers"].([]interface{})) != 0 {
+		t.Errorf("Expected no providers, got %v", body)
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"dotfiles-api/internal/auth"
+	"dotfiles-api/internal/middleware"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authorizer holds the visibility rules for private resources so every
+// handler that serves them applies the same checks. It reads the caller from
+// the context values set by the auth middleware.
+type Authorizer struct {
+	orgRepo repository.OrganizationRepository
+
+	// Set by ConfigureMembershipCache; without them every check reads the
+	// store
+	sessions      *auth.SessionManager
+	userRepo      repository.UserRepository
+	membershipTTL time.Duration
+	now           func() time.Time
+}
+
+// NewAuthorizer creates an authorizer. orgRepo may be nil when organizations
+// are unavailable, in which case organization membership grants nothing.
+func NewAuthorizer(orgRepo repository.OrganizationRepository) *Authorizer {
+	return &Authorizer{orgRepo: orgRepo, now: time.Now}
+}
+
+// ConfigureMembershipCache keeps each caller's organization roles in their
+// session. Cached roles are trusted for ttl; after that the user's
+// membership version is compared and the roles reloaded if it changed. A ttl
+// of 0 turns the cache off.
+func (a *Authorizer) ConfigureMembershipCache(sessions *auth.SessionManager, userRepo repository.UserRepository, ttl time.Duration) {
+	a.sessions = sessions
+	a.userRepo = userRepo
+	a.membershipTTL = ttl
+}
+
+// CanViewTemplate reports whether the caller may see a template. Public
+// templates are visible to everyone. Private templates are visible to their
+// author, site admins and the users they are shared with, and organization
+// templates also to members of the organization.
+func (a *Authorizer) CanViewTemplate(c *gin.Context, template *models.StoredTemplate) (bool, error) {
+	if template.Template.Public || c.GetBool("is_admin") {
+		return true, nil
+	}
+
+	username := c.GetString("username")
+	if username != "" && (template.Template.Metadata.Author == username || slices.Contains(template.AllowedUsers, username)) {
+		return true, nil
+	}
+
+	role, err := a.OrganizationRole(c, template.Template.OrganizationID)
+	return role != "", err
+}
+
+// TemplateViewer describes the caller for template listings, so they only
+// include the templates CanViewTemplate would allow. Site admins see every
+// template and get nil.
+func (a *Authorizer) TemplateViewer(c *gin.Context) (*repository.TemplateViewer, error) {
+	if c.GetBool("is_admin") {
+		return nil, nil
+	}
+
+	viewer := &repository.TemplateViewer{Username: c.GetString("username")}
+	userID := c.GetString("user_id")
+	if a == nil || a.orgRepo == nil || userID == "" {
+		return viewer, nil
+	}
+
+	memberships, err := a.sessionMemberships(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	if memberships != nil {
+		for orgID := range memberships.Roles {
+			viewer.OrganizationIDs = append(viewer.OrganizationIDs, orgID)
+		}
+		return viewer, nil
+	}
+
+	members, err := a.orgRepo.GetUserMemberships(c.Request.Context(), userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		viewer.OrganizationIDs = append(viewer.OrganizationIDs, member.OrganizationID)
+	}
+	return viewer, nil
+}
+
+// OrganizationRole returns the caller's role in an organization, or "" if
+// they are not a member. Roles cached in the session are used when current;
+// organizations missing from the cache are looked up in the store.
+func (a *Authorizer) OrganizationRole(c *gin.Context, orgID string) (string, error) {
+	userID := c.GetString("user_id")
+	if a == nil || a.orgRepo == nil || orgID == "" || userID == "" {
+		return "", nil
+	}
+
+	memberships, err := a.sessionMemberships(c, userID)
+	if err != nil {
+		return "", err
+	}
+	if memberships != nil {
+		if role, ok := memberships.Roles[orgID]; ok {
+			return role, nil
+		}
+	}
+
+	member, err := a.orgRepo.GetMember(c.Request.Context(), orgID, userID)
+	if err != nil || member == nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// CacheMemberships loads the user's organization roles into a session,
+// returning them. It does nothing when the cache is not configured.
+func (a *Authorizer) CacheMemberships(ctx context.Context, sessionID string, user *models.User) (*auth.OrgMemberships, error) {
+	if !a.cachesMemberships() {
+		return nil, nil
+	}
+
+	// user, and so its version, was read before the memberships, so a
+	// change in between is caught by the next version check
+	members, err := a.orgRepo.GetUserMemberships(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := &auth.OrgMemberships{
+		Roles:     make(map[string]string, len(members)),
+		Version:   user.MembershipVersion,
+		CheckedAt: a.now(),
+	}
+	for _, member := range members {
+		memberships.Roles[member.OrganizationID] = member.Role
+	}
+
+	a.sessions.UpdateSession(sessionID, map[string]interface{}{
+		auth.OrgMembershipsKey: memberships,
+	})
+	return memberships, nil
+}
+
+func (a *Authorizer) cachesMemberships() bool {
+	return a != nil && a.orgRepo != nil && a.sessions != nil && a.userRepo != nil && a.membershipTTL > 0
+}
+
+// sessionMemberships returns the caller's cached roles, revalidating them
+// once they are older than the TTL. It returns nil when the caller has no
+// session or the cache is off.
+func (a *Authorizer) sessionMemberships(c *gin.Context, userID string) (*auth.OrgMemberships, error) {
+	if !a.cachesMemberships() {
+		return nil, nil
+	}
+	value, ok := c.Get("session")
+	if !ok {
+		return nil, nil
+	}
+	session, ok := value.(*auth.Session)
+	if !ok {
+		return nil, nil
+	}
+
+	cached, ok := middleware.OrgMemberships(c)
+	now := a.now()
+	if ok && now.Sub(cached.CheckedAt) < a.membershipTTL {
+		return cached, nil
+	}
+
+	user, err := a.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	var memberships *auth.OrgMemberships
+	if ok && cached.Version == user.MembershipVersion {
+		// Nothing changed, so the roles are good for another TTL
+		memberships = &auth.OrgMemberships{Roles: cached.Roles, Version: cached.Version, CheckedAt: now}
+		a.sessions.UpdateSession(session.ID, map[string]interface{}{
+			auth.OrgMembershipsKey: memberships,
+		})
+	} else {
+		memberships, err = a.CacheMemberships(c.Request.Context(), session.ID, user)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.Set(auth.OrgMembershipsKey, memberships)
+	return memberships, nil
+}
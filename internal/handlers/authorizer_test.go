@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/auth"
+	"dotfiles-api/internal/middleware"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSessionCachedOrganizationRoles(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+	for _, user := range []*models.User{
+		{ID: "owner-id", Username: "owner", Email: "owner@example.com"},
+		{ID: "alice-id", Username: "alice", Email: "alice@example.com"},
+	} {
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	orgRepo := memory.NewOrganizationRepository()
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "owner-id"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	for userID, role := range map[string]string{"owner-id": models.RoleOwner, "alice-id": models.RoleAdmin} {
+		if err := orgRepo.AddMember(ctx, &models.OrganizationMember{OrganizationID: "org-acme", UserID: userID, Role: role}); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	templateRepo := memory.NewTemplateRepository()
+	if err := templateRepo.Create(ctx, &models.StoredTemplate{ID: "acme-private", Template: models.Template{OrganizationID: "org-acme"}}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sessionManager := auth.NewSessionManager(24 * time.Hour)
+	authorizer := NewAuthorizer(orgRepo)
+	authorizer.ConfigureMembershipCache(sessionManager, userRepo, time.Minute)
+	authorizer.now = func() time.Time { return now }
+
+	authMiddleware := middleware.NewAuthMiddleware(sessionManager, nil)
+	authHandler := NewAuthHandler(auth.NewOAuthService(), sessionManager, userRepo, true)
+	authHandler.ConfigureMemberships(authorizer)
+	orgHandler := NewOrganizationHandler(orgRepo, userRepo, templateRepo)
+	templateHandler := NewTemplateHandler(templateRepo, memory.NewTagRepository(), userRepo, memory.NewReviewRepository(), tags.NewRegistry(), authorizer)
+
+	r := gin.New()
+	r.GET("/api/templates/:id", authMiddleware.OptionalAuth(), templateHandler.GetTemplate)
+	r.DELETE("/api/organizations/:slug/members/:username", authMiddleware.RequireAuth(), orgHandler.RemoveMember)
+	r.PUT("/api/organizations/:slug/members/:username", authMiddleware.RequireAuth(), orgHandler.UpdateMemberRole)
+	r.POST("/api/auth/refresh-memberships", authMiddleware.RequireAuth(), authHandler.RefreshMemberships)
+
+	send := func(method, url, sessionID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "session_id", Value: sessionID})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Cache the roles as signing in does
+	login := func(user *models.User) *auth.Session {
+		session, err := sessionManager.CreateSession(user.ID, user.Username, "")
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+		if _, err := authorizer.CacheMemberships(ctx, session.ID, user); err != nil {
+			t.Fatalf("Failed to cache memberships: %v", err)
+		}
+		return session
+	}
+	owner, _ := userRepo.GetByID(ctx, "owner-id")
+	alice, _ := userRepo.GetByID(ctx, "alice-id")
+	ownerSession := login(owner)
+	aliceSession := login(alice)
+
+	if w := send(http.MethodGet, "/api/templates/acme-private", aliceSession.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected a member to see the organization template, got %d", w.Code)
+	}
+
+	// A role change takes effect as soon as the caller refreshes
+	if w := send(http.MethodPut, "/api/organizations/acme/members/alice", ownerSession.ID, `{"role": "member"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 changing the role, got %d: %s", w.Code, w.Body.String())
+	}
+	w := send(http.MethodPost, "/api/auth/refresh-memberships", aliceSession.ID, "")
+	var refreshed struct {
+		Roles map[string]string `json:"roles"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &refreshed); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected the refreshed roles, got %d: %s", w.Code, w.Body.String())
+	}
+	if refreshed.Roles["org-acme"] != models.RoleMember {
+		t.Errorf("Expected the new member role, got %v", refreshed.Roles)
+	}
+
+	if w := send(http.MethodDelete, "/api/organizations/acme/members/alice", ownerSession.ID, ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 removing the member, got %d: %s", w.Code, w.Body.String())
+	}
+	if user, _ := userRepo.GetByID(ctx, "alice-id"); user.MembershipVersion != 2 {
+		t.Errorf("Expected each change to bump the membership version, got %d", user.MembershipVersion)
+	}
+
+	// Within the TTL the cached role still applies
+	now = now.Add(30 * time.Second)
+	if w := send(http.MethodGet, "/api/templates/acme-private", aliceSession.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the cached role to be used within the TTL, got %d", w.Code)
+	}
+
+	// Once it expires the version check sees the removal
+	now = now.Add(time.Minute)
+	if w := send(http.MethodGet, "/api/templates/acme-private", aliceSession.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected the removed member to lose access, got %d", w.Code)
+	}
+
+	// The owner's membership cannot be changed
+	if w := send(http.MethodDelete, "/api/organizations/acme/members/owner", ownerSession.ID, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 removing the owner, got %d", w.Code)
+	}
+	if w := send(http.MethodPut, "/api/organizations/acme/members/owner", ownerSession.ID, `{"role": "owner"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for the owner role, got %d", w.Code)
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"dotfiles-api/internal/avatar"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// identiconMaxAge is how long clients cache a generated identicon, kept
+// short so the real avatar shows up soon after its host recovers
+const identiconMaxAge = time.Hour
+
+// ConfigureAvatarProxy sets where proxied avatars are cached, for how long,
+// and which hosts and sizes are fetched
+func (h *UserHandler) ConfigureAvatarProxy(config avatar.Config) {
+	h.avatars = avatar.NewProxy(config)
+}
+
+// GetAvatar serves a user's avatar from the server-side cache, fetching it
+// from the stored avatar URL when the cache is stale. Users without an
+// avatar, or whose avatar cannot be fetched, get an identicon generated
+// from their ID.
+func (h *UserHandler) GetAvatar(c *gin.Context) {
+	user, err := h.userRepo.GetByID(c.Request.Context(), c.Param("userID"))
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "failed to get user", err)
+		return
+	}
+	if user == nil || user.IsDeleted() {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("user")})
+		return
+	}
+
+	if user.AvatarURL != "" {
+		image, err := h.avatars.Get(c.Request.Context(), user.ID, user.AvatarURL)
+		if err != nil {
+			log.Printf("Avatar for user %s: %v", user.ID, err)
+		}
+		if image != nil {
+			serveAvatar(c, image.Data, image.ContentType, image.ETag, h.avatars.TTL())
+			return
+		}
+	}
+
+	data := avatar.Identicon(user.ID)
+	serveAvatar(c, data, "image/png", avatar.ETag(data), identiconMaxAge)
+}
+
+// serveAvatar writes image bytes with caching headers, answering a matching
+// If-None-Match with 304 Not Modified
+func serveAvatar(c *gin.Context, data []byte, contentType, etag string, maxAge time.Duration) {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
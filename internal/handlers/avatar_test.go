@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/avatar"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetAvatar(t *testing.T) {
+	imageBytes := []byte("\x89PNG\r\n\x1a\nfake-image-data")
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/alice.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(imageBytes)
+		case "/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(bytes.Repeat([]byte("x"), 4096))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+	host, _ := url.Parse(upstream.URL)
+
+	userRepo := memory.NewUserRepository()
+	for _, user := range []*models.User{
+		{ID: "alice-id", Username: "alice", Email: "alice@example.com", AvatarURL: upstream.URL + "/alice.png"},
+		{ID: "bob-id", Username: "bob", Email: "bob@example.com", AvatarURL: upstream.URL + "/gone.png"},
+		{ID: "carol-id", Username: "carol", Email: "carol@example.com", AvatarURL: upstream.URL + "/huge.png"},
+	} {
+		if err := userRepo.Create(context.Background(), user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	h := NewUserHandler(userRepo, memory.NewTemplateRepository(), memory.NewReviewRepository(), memory.NewOrganizationRepository())
+	h.ConfigureAvatarProxy(avatar.Config{
+		CacheDir:     t.TempDir(),
+		TTL:          24 * time.Hour,
+		MaxBytes:     1024,
+		AllowedHosts: []string{host.Host},
+	})
+	r := gin.New()
+	r.GET("/api/avatars/:userID", h.GetAvatar)
+
+	get := func(userID, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/avatars/"+userID, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("alice-id", "")
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), imageBytes) || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("Expected alice's avatar, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=86400" {
+		t.Errorf("Expected a day-long Cache-Control, got %q", got)
+	}
+	etag := w.Header().Get("ETag")
+	if w := get("alice-id", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected 304 for a matching ETag, got %d", w.Code)
+	}
+
+	// Upstream 404s and oversize images fall back to the user's identicon
+	for _, userID := range []string{"bob-id", "carol-id"} {
+		w := get(userID, "")
+		if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), avatar.Identicon(userID)) {
+			t.Errorf("Expected the identicon for %s, got %d", userID, w.Code)
+		}
+		if got := w.Header().Get("Cache-Control"); got != "public, max-age=3600" {
+			t.Errorf("Expected identicons to be cached briefly, got %q", got)
+		}
+	}
+
+	if w := get("nobody", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown user, got %d", w.Code)
+	}
+}
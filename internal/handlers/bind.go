@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+var registerJSONFieldNames sync.Once
+
+// bindJSON decodes and validates the request body into obj. On failure it
+// writes a 400 response naming the offending fields and returns false.
+// Fields obj does not have are ignored unless the client asks for strict
+// decoding (see strictJSONRequested).
+func bindJSON(c *gin.Context, obj interface{}) bool {
+	registerJSONFieldNames.Do(useJSONFieldNames)
+
+	var err error
+	if strictJSONRequested(c) {
+		if err = decodeStrictJSON(c.Request.Body, obj); err == nil {
+			err = binding.Validator.ValidateStruct(obj)
+		}
+	} else {
+		err = c.ShouldBindJSON(obj)
+	}
+	if err == nil {
+		return true
+	}
+
+	appErr := bindError(err)
+	c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+	return false
+}
+
+// strictJSONRequested reports whether the client asked for request bodies
+// with unknown fields to be rejected, with ?strict=true or a strict=true
+// Content-Type parameter (application/json; strict=true), so typos such as
+// "brew" for "brews" are caught instead of silently dropped
+func strictJSONRequested(c *gin.Context) bool {
+	if strict, err := strconv.ParseBool(c.Query("strict")); err == nil && strict {
+		return true
+	}
+
+	_, params, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil {
+		return false
+	}
+	strict, err := strconv.ParseBool(params["strict"])
+	return err == nil && strict
+}
+
+// decodeStrictJSON decodes body into obj, failing on fields obj does not
+// have
+func decodeStrictJSON(body io.Reader, obj interface{}) error {
+	if body == nil {
+		return io.EOF
+	}
+
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(obj)
+}
+
+// unknownJSONField returns the field a strict decode rejected. encoding/json
+// only reports it in the error text.
+func unknownJSONField(err error) (string, bool) {
+	field, found := strings.CutPrefix(err.Error(), `json: unknown field "`)
+	if !found {
+		return "", false
+	}
+	return strings.TrimSuffix(field, `"`), true
+}
+
+// useJSONFieldNames makes validation errors report fields by their JSON
+// names rather than Go struct field names
+func useJSONFieldNames() {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// validateStruct runs the binding rules on obj, for bodies that were not
+// decoded by bindJSON
+func validateStruct(obj interface{}) *errors.AppError {
+	registerJSONFieldNames.Do(useJSONFieldNames)
+
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return bindError(err)
+	}
+	return nil
+}
+
+// bindError translates a binding failure into a validation error with a
+// message per field where the failure can be pinned to one, or a 413 when
+// the body ran past the middleware.MaxBodySize limit
+func bindError(err error) *errors.AppError {
+	var tooLarge *http.MaxBytesError
+	if stderrors.As(err, &tooLarge) {
+		return errors.NewPayloadTooLargeError(tooLarge.Limit)
+	}
+
+	var validationErrs validator.ValidationErrors
+	if stderrors.As(err, &validationErrs) {
+		fields := make(map[string]string, len(validationErrs))
+		for _, fieldErr := range validationErrs {
+			fields[fieldPath(fieldErr)] = fieldMessage(fieldErr)
+		}
+		return errors.NewFieldValidationError("Request validation failed", fields)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if stderrors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return errors.NewFieldValidationError("Invalid request format", map[string]string{
+			field: "must be " + jsonTypeName(typeErr.Type),
+		})
+	}
+
+	if field, ok := unknownJSONField(err); ok {
+		return errors.NewFieldValidationError("Unknown field in request", map[string]string{
+			field: "is not a known field",
+		})
+	}
+
+	if stderrors.Is(err, io.EOF) {
+		return errors.NewValidationError("Request body is required")
+	}
+
+	return errors.NewValidationError("Invalid JSON format")
+}
+
+// fieldPath returns the dotted JSON path of a failed field, without the
+// name of the request struct itself
+func fieldPath(fieldErr validator.FieldError) string {
+	_, path, found := strings.Cut(fieldErr.Namespace(), ".")
+	if !found {
+		return fieldErr.Field()
+	}
+	return path
+}
+
+// fieldMessage describes a failed validation rule in plain words
+func fieldMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+	case "min", "gte":
+		return "must be at least " + sizeLimit(fieldErr)
+	case "max", "lte":
+		return "must be at most " + sizeLimit(fieldErr)
+	case "len":
+		return "must be exactly " + sizeLimit(fieldErr)
+	default:
+		return fmt.Sprintf("failed the %q rule", fieldErr.Tag())
+	}
+}
+
+// sizeLimit phrases a min/max/len parameter for the kind of field it limits
+func sizeLimit(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	switch fieldErr.Kind() {
+	case reflect.String:
+		return param + " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return param + " items"
+	default:
+		return param
+	}
+}
+
+// jsonTypeName names the JSON type a Go type decodes from
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bindTestRequest struct {
+	Title    string   `json:"title" binding:"required"`
+	Rating   int      `json:"rating" binding:"required,min=1,max=5"`
+	Email    string   `json:"email" binding:"omitempty,email"`
+	Tags     []string `json:"tags" binding:"max=2"`
+	Metadata struct {
+		Name string `json:"name" binding:"required,min=3"`
+	} `json:"metadata"`
+}
+
+func TestBindJSONReportsFieldErrors(t *testing.T) {
+	r := gin.New()
+	r.POST("/bind", func(c *gin.Context) {
+		var req bindTestRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name    string
+		body    string
+		message string
+		fields  map[string]interface{}
+	}{
+		{
+			name:    "validation rules",
+			body:    `{"rating": 9, "email": "nope", "tags": ["a", "b", "c"], "metadata": {"name": "ab"}}`,
+			message: "Request validation failed",
+			fields: map[string]interface{}{
+				"title":         "is required",
+				"rating":        "must be at most 5",
+				"email":         "must be a valid email address",
+				"tags":          "must be at most 2 items",
+				"metadata.name": "must be at least 3 characters long",
+			},
+		},
+		{
+			name:    "wrong type",
+			body:    `{"title": "x", "rating": "five"}`,
+			message: "Invalid request format",
+			fields:  map[string]interface{}{"rating": "must be an integer"},
+		},
+		{
+			name:    "empty body",
+			body:    ``,
+			message: "Request body is required",
+		},
+		{
+			name:    "malformed JSON",
+			body:    `{"title": `,
+			message: "Invalid JSON format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/bind", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+
+			appErr := decodeBody(t, w)["error"].(map[string]interface{})
+			if appErr["message"] != tt.message {
+				t.Errorf("Expected message %q, got %v", tt.message, appErr["message"])
+			}
+			fields, _ := appErr["fields"].(map[string]interface{})
+			if len(tt.fields) > 0 || fields != nil {
+				if !reflect.DeepEqual(fields, tt.fields) {
+					t.Errorf("Expected fields %v, got %v", tt.fields, fields)
+				}
+			}
+		})
+	}
+}
+
+func TestBindJSONStrictMode(t *testing.T) {
+	r := gin.New()
+	r.POST("/bind", func(c *gin.Context) {
+		var req bindTestRequest
+		if !bindJSON(c, &req) {
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	const typo = `{"title": "x", "rating": 3, "metadata": {"name": "abc"}, "tag": ["a"]}`
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		body        string
+		want        int
+		fields      map[string]interface{}
+	}{
+		{"lenient by default", "/bind", "application/json", typo, http.StatusNoContent, nil},
+		{"strict query parameter", "/bind?strict=true", "application/json", typo, http.StatusBadRequest, map[string]interface{}{"tag": "is not a known field"}},
+		{"strict content type", "/bind", "application/json; strict=true", typo, http.StatusBadRequest, map[string]interface{}{"tag": "is not a known field"}},
+		{"strict=false", "/bind?strict=false", "application/json", typo, http.StatusNoContent, nil},
+		{"nested unknown field", "/bind?strict=1", "application/json", `{"title": "x", "rating": 3, "metadata": {"name": "abc", "nmae": "abc"}}`, http.StatusBadRequest, map[string]interface{}{"nmae": "is not a known field"}},
+		{"strict and valid", "/bind?strict=true", "application/json", `{"title": "x", "rating": 3, "metadata": {"name": "abc"}}`, http.StatusNoContent, nil},
+		{"strict still validates", "/bind?strict=true", "application/json", `{"rating": 3, "metadata": {"name": "abc"}}`, http.StatusBadRequest, map[string]interface{}{"title": "is required"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			if tt.fields != nil {
+				fields := decodeBody(t, w)["error"].(map[string]interface{})["fields"]
+				if !reflect.DeepEqual(fields, tt.fields) {
+					t.Errorf("Expected fields %v, got %v", tt.fields, fields)
+				}
+			}
+		})
+	}
+}
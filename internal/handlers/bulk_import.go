@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// BulkImportTemplates creates up to dto.MaxBulkImportTemplates templates at
+// once for the signed-in caller. Every entry is validated before anything is
+// saved, and either all of them are created or none are.
+func (h *TemplateHandler) BulkImportTemplates(c *gin.Context) {
+	var req dto.BulkImportTemplatesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(err.StatusCode, gin.H{"error": err})
+		return
+	}
+
+	// The request's own binding does not descend into the entries, so the
+	// required metadata fields are checked here along with the rest
+	failures := make(map[string]string)
+	for i := range req.Templates {
+		if message := bulkImportEntryError(&req.Templates[i]); message != "" {
+			failures[strconv.Itoa(i)] = message
+		}
+	}
+	if len(failures) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  errors.NewValidationError(fmt.Sprintf("%d of %d templates failed validation", len(failures), len(req.Templates))),
+			"errors": failures,
+		})
+		return
+	}
+
+	for i := range req.Templates {
+		if !h.requireOrganizationMember(c, req.Templates[i].OrganizationID) {
+			return
+		}
+	}
+
+	// Content limits apply to the templates as they would be saved
+	author := c.GetString("username")
+	templates := make([]*models.StoredTemplate, len(req.Templates))
+	for i := range req.Templates {
+		templates[i] = h.newStoredTemplate(&req.Templates[i], author)
+		if appErr := h.limits.CheckTemplate(&templates[i].Template); appErr != nil {
+			failures[strconv.Itoa(i)] = contentLimitMessage(appErr)
+		}
+	}
+	if len(failures) > 0 {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":  errors.NewContentTooLargeError(fmt.Sprintf("%d of %d templates exceed content limits", len(failures), len(req.Templates)), nil),
+			"errors": failures,
+		})
+		return
+	}
+
+	created := make([]*models.StoredTemplate, 0, len(templates))
+	for i, template := range templates {
+		if err := h.templateRepo.Create(c.Request.Context(), template); err != nil {
+			h.rollbackBulkImport(c, created)
+			respondInternalError(c, fmt.Sprintf("failed to create template %d", i), err)
+			return
+		}
+		created = append(created, template)
+	}
+
+	legacy := legacyCompatRequested(c)
+	response := make([]dto.TemplateResponse, len(created))
+	for i, template := range created {
+		response[i] = toTemplateResponse(template)
+		response[i].Warnings = req.Templates[i].Warnings()
+		if legacy {
+			response[i].WithLegacyAliases()
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"templates": response,
+		"count":     len(response),
+	})
+}
+
+// bulkImportEntryError describes everything wrong with one entry of a bulk
+// import, or returns "" when it is valid
+func bulkImportEntryError(req *dto.CreateTemplateRequest) string {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		appErr := bindError(err)
+		if len(appErr.Fields) == 0 {
+			return appErr.Message
+		}
+
+		fields := make([]string, 0, len(appErr.Fields))
+		for field, message := range appErr.Fields {
+			fields = append(fields, field+" "+message)
+		}
+		sort.Strings(fields)
+		return strings.Join(fields, "; ")
+	}
+
+	if err := req.Validate(); err != nil {
+		return err.Message
+	}
+	return ""
+}
+
+// contentLimitMessage describes a content limit error in one line, for the
+// per-entry errors of a bulk import
+func contentLimitMessage(appErr *errors.AppError) string {
+	for field, problem := range appErr.Fields {
+		return fmt.Sprintf("%s: %s %s", appErr.Message, field, problem)
+	}
+	return fmt.Sprintf("%s: %s", appErr.Message, appErr.Details)
+}
+
+// rollbackBulkImport deletes the templates a failed bulk import already
+// created, so the import leaves nothing behind
+func (h *TemplateHandler) rollbackBulkImport(c *gin.Context, created []*models.StoredTemplate) {
+	for _, template := range created {
+		if err := h.templateRepo.Delete(c.Request.Context(), template.ID); err != nil {
+			log.Printf("Failed to roll back bulk-imported template %s: %v", template.ID, err)
+		}
+	}
+}
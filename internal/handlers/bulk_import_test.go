@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bulkTemplate renders one Create Template body for a bulk import
+func bulkTemplate(name, version string) string {
+	return fmt.Sprintf(`{"brews": ["git"], "public": true, "metadata": {"name": %q, "description": "Imported from another platform", "author": "ignored", "version": %q}}`, name, version)
+}
+
+func bulkImport(r *gin.Engine, username string, templates ...string) *httptest.ResponseRecorder {
+	body := `{"templates": [` + strings.Join(templates, ",") + `]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/templates/bulk-import", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if username != "" {
+		req.Header.Set("X-Test-User", username+"-id")
+		req.Header.Set("X-Test-Username", username)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func newBulkImportRouter(repo repository.TemplateRepository) *gin.Engine {
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/templates/bulk-import", newTestTemplateHandler(repo).BulkImportTemplates)
+	return r
+}
+
+func countAuthoredTemplates(t *testing.T, repo repository.TemplateRepository, author string) int {
+	t.Helper()
+	count, err := repo.Count(context.Background(), repository.TemplateFilters{Author: author})
+	if err != nil {
+		t.Fatalf("Failed to count templates: %v", err)
+	}
+	return count
+}
+
+func TestBulkImportTemplates(t *testing.T) {
+	repo := memory.NewTemplateRepository()
+	r := newBulkImportRouter(repo)
+
+	w := bulkImport(r, "alice", bulkTemplate("Zsh Setup", "1.0.0"), bulkTemplate("Neovim Setup", "2.0.0"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Templates []struct {
+			ID       string `json:"id"`
+			Metadata struct {
+				Name   string `json:"name"`
+				Author string `json:"author"`
+			} `json:"metadata"`
+		} `json:"templates"`
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Count != 2 || len(response.Templates) != 2 {
+		t.Fatalf("Expected two templates, got %+v", response)
+	}
+	for i, name := range []string{"Zsh Setup", "Neovim Setup"} {
+		template := response.Templates[i]
+		if template.ID == "" || template.Metadata.Name != name || template.Metadata.Author != "alice" {
+			t.Errorf("Template %d: expected %q attributed to alice, got %+v", i, name, template)
+		}
+	}
+	if got := countAuthoredTemplates(t, repo, "alice"); got != 2 {
+		t.Errorf("Expected 2 stored templates, got %d", got)
+	}
+}
+
+func TestBulkImportTemplatesValidatesEveryEntryFirst(t *testing.T) {
+	repo := memory.NewTemplateRepository()
+	r := newBulkImportRouter(repo)
+
+	w := bulkImport(r, "alice",
+		bulkTemplate("No", "1.0.0"),
+		bulkTemplate("Valid Setup", "1.0.0"),
+		`{"metadata": {"name": "Missing Version", "description": "Imported from another platform", "author": "x"}}`,
+	)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response struct {
+		Errors map[string]string `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Errors) != 2 {
+		t.Fatalf("Expected errors for entries 0 and 2, got %v", response.Errors)
+	}
+	if !strings.Contains(response.Errors["0"], "template name") {
+		t.Errorf("Expected entry 0 to fail on its name, got %q", response.Errors["0"])
+	}
+	if response.Errors["2"] != "metadata.version is required" {
+		t.Errorf("Expected entry 2 to fail on its version, got %q", response.Errors["2"])
+	}
+	if got := countAuthoredTemplates(t, repo, "alice"); got != 0 {
+		t.Errorf("Expected nothing to be saved, got %d templates", got)
+	}
+}
+
+func TestBulkImportTemplatesLimits(t *testing.T) {
+	r := newBulkImportRouter(memory.NewTemplateRepository())
+
+	if w := bulkImport(r, "alice"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an empty import, got %d", w.Code)
+	}
+
+	templates := make([]string, 21)
+	for i := range templates {
+		templates[i] = bulkTemplate(fmt.Sprintf("Template %d", i), "1.0.0")
+	}
+	w := bulkImport(r, "alice", templates...)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "at most 20") {
+		t.Errorf("Expected status 400 for 21 templates, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestBulkImportTemplatesContentLimits(t *testing.T) {
+	repo := memory.NewTemplateRepository()
+	h := newTestTemplateHandler(repo)
+	h.ConfigureContentLimits(dto.ContentLimits{MaxListEntries: 1})
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/templates/bulk-import", h.BulkImportTemplates)
+
+	tooMany := `{"brews": ["git", "go"], "metadata": {"name": "Too Many", "description": "Imported from another platform", "author": "ignored", "version": "1.0.0"}}`
+	w := bulkImport(r, "alice", bulkTemplate("Fine", "1.0.0"), tooMany)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected status 413, got %d: %s", w.Code, w.Body.String())
+	}
+	errs, _ := decodeBody(t, w)["errors"].(map[string]interface{})
+	if len(errs) != 1 || errs["1"] == nil {
+		t.Errorf("Expected only the second template to fail, got %v", errs)
+	}
+	if got := countAuthoredTemplates(t, repo, "alice"); got != 0 {
+		t.Errorf("Expected nothing to be created, got %d", got)
+	}
+}
+
+// failingCreateRepository fails every create after the first few
+type failingCreateRepository struct {
+	repository.TemplateRepository
+	remaining int
+}
+
+func (r *failingCreateRepository) Create(ctx context.Context, template *models.StoredTemplate) error {
+	if r.remaining == 0 {
+		return fmt.Errorf("write conflict")
+	}
+	r.remaining--
+	return r.TemplateRepository.Create(ctx, template)
+}
+
+func TestBulkImportTemplatesRollsBackOnFailure(t *testing.T) {
+	repo := &failingCreateRepository{TemplateRepository: memory.NewTemplateRepository(), remaining: 2}
+	r := newBulkImportRouter(repo)
+
+	w := bulkImport(r, "alice", bulkTemplate("First", "1.0.0"), bulkTemplate("Second", "1.0.0"), bulkTemplate("Third", "1.0.0"))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := countAuthoredTemplates(t, repo, "alice"); got != 0 {
+		t.Errorf("Expected the created templates to be rolled back, got %d", got)
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"strings"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Configs uploaded without an account only carry the author string typed
+// by the uploader. Users can claim those whose author is their email: only
+// emails a sign-in provider verified are stored (see
+// auth.ExternalUser.EmailVerified), so matching it is the verification.
+
+// GetClaimableConfigs lists the configs uploaded without an account under
+// the caller's email, for the client to offer claiming after login
+func (h *ConfigHandler) GetClaimableConfigs(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	user, ok := h.loadClaimant(c)
+	if !ok {
+		return
+	}
+
+	configs, err := h.configRepo.GetUnownedByAuthor(c.Request.Context(), user.Email)
+	if err != nil {
+		respondInternalError(c, "Failed to find claimable configs", err)
+		return
+	}
+	if configs == nil {
+		configs = []*models.StoredConfig{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"configs": configs,
+		"total":   len(configs),
+	})
+}
+
+// ClaimConfigs makes the caller the owner of the chosen configs, each of
+// which must still be unowned and authored under the caller's email
+func (h *ConfigHandler) ClaimConfigs(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	var req dto.ClaimConfigsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	user, ok := h.loadClaimant(c)
+	if !ok {
+		return
+	}
+
+	response := dto.ClaimConfigsResponse{
+		Claimed: []string{},
+		Skipped: map[string]string{},
+	}
+	for _, id := range req.ConfigIDs {
+		reason, err := h.claimConfig(c.Request.Context(), id, user)
+		if err != nil {
+			respondInternalError(c, "Failed to claim config", err)
+			return
+		}
+		if reason != "" {
+			response.Skipped[id] = reason
+			continue
+		}
+		response.Claimed = append(response.Claimed, id)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// claimConfig gives one config to user, returning why it could not be
+// claimed if it was not
+func (h *ConfigHandler) claimConfig(ctx context.Context, id string, user *models.User) (string, error) {
+	config, err := h.configRepo.GetByID(ctx, id)
+	if err != nil && !isNotFound(err) {
+		return "", err
+	}
+	if config == nil {
+		return "not found", nil
+	}
+	if config.OwnerID != "" {
+		return "already owned", nil
+	}
+	if !strings.EqualFold(config.Config.Metadata.Author, user.Email) {
+		return "author does not match your email", nil
+	}
+
+	// Someone else may claim it between the check and the update
+	if err := h.configRepo.Claim(ctx, id, user.ID); err != nil {
+		if stderrors.Is(err, repository.ErrNotFound) {
+			return "already owned", nil
+		}
+		return "", err
+	}
+	return "", nil
+}
+
+// loadClaimant loads the signed-in user, who needs an email to claim
+// anything. When it cannot it writes the error response and returns false.
+func (h *ConfigHandler) loadClaimant(c *gin.Context) (*models.User, bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return nil, false
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "Failed to get user", err)
+		return nil, false
+	}
+	if user == nil || user.IsDeleted() {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("User")})
+		return nil, false
+	}
+
+	if user.Email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewBadRequestError("Your account has no verified email to match uploads against"),
+		})
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClaimConfigs(t *testing.T) {
+	ctx := context.Background()
+
+	userRepo := memory.NewUserRepository()
+	for _, user := range []*models.User{
+		{ID: "user-alice", Username: "alice", Email: "alice@example.com"},
+		{ID: "user-bob", Username: "bob", Email: "bob@example.com"},
+		{ID: "user-quiet", Username: "quiet"},
+	} {
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	configRepo := memory.NewConfigRepository()
+	for _, config := range []*models.StoredConfig{
+		{ID: "anon-1", Config: models.ShareableConfig{Metadata: models.ShareMetadata{Author: "Alice@Example.com"}}},
+		{ID: "anon-2", Config: models.ShareableConfig{Metadata: models.ShareMetadata{Author: "alice@example.com"}}},
+		{ID: "anon-bob", Config: models.ShareableConfig{Metadata: models.ShareMetadata{Author: "bob@example.com"}}},
+		{ID: "owned", OwnerID: "user-bob", Config: models.ShareableConfig{Metadata: models.ShareMetadata{Author: "alice@example.com"}}},
+	} {
+		if err := configRepo.Create(ctx, config); err != nil {
+			t.Fatalf("Failed to create config: %v", err)
+		}
+	}
+
+	h := NewConfigHandler(configRepo, userRepo)
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/me/claim", h.GetClaimableConfigs)
+	r.POST("/api/me/claim", h.ClaimConfigs)
+
+	do := func(method, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/me/claim", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "user-alice", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if total := decodeBody(t, w)["total"]; total != float64(2) {
+		t.Errorf("Expected 2 claimable configs, got %v", total)
+	}
+
+	w = do(http.MethodPost, "user-alice", `{"config_ids":["anon-1","anon-2","anon-bob","owned","missing"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	if claimed := body["claimed"]; !reflect.DeepEqual(claimed, []interface{}{"anon-1", "anon-2"}) {
+		t.Errorf("Expected anon-1 and anon-2 to be claimed, got %v", claimed)
+	}
+	wantSkipped := map[string]interface{}{
+		"anon-bob": "author does not match your email",
+		"owned":    "already owned",
+		"missing":  "not found",
+	}
+	if skipped := body["skipped"]; !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Errorf("Expected skipped %v, got %v", wantSkipped, skipped)
+	}
+
+	for id, want := range map[string]string{"anon-1": "user-alice", "anon-2": "user-alice", "anon-bob": "", "owned": "user-bob"} {
+		config, _ := configRepo.GetByID(ctx, id)
+		if config.OwnerID != want {
+			t.Errorf("Expected %s to be owned by %q, got %q", id, want, config.OwnerID)
+		}
+	}
+
+	// Claimed configs are no longer offered, nor claimable twice
+	if total := decodeBody(t, do(http.MethodGet, "user-alice", ""))["total"]; total != float64(0) {
+		t.Errorf("Expected nothing left to claim, got %v", total)
+	}
+	body = decodeBody(t, do(http.MethodPost, "user-alice", `{"config_ids":["anon-1"]}`))
+	if skipped := body["skipped"].(map[string]interface{}); skipped["anon-1"] != "already owned" {
+		t.Errorf("Expected a second claim to be skipped, got %v", body)
+	}
+
+	if w := do(http.MethodGet, "user-quiet", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a user without an email, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "user-alice", `{"config_ids":[]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an empty claim, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 when signed out, got %d", w.Code)
+	}
+}
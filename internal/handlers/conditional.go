@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"dotfiles-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notModifiedSince sets Last-Modified to modified and, when the request's
+// If-Modified-Since is no earlier, writes 304 Not Modified and returns true.
+// A zero modified time, as for an empty list, sets nothing. HTTP dates have
+// whole-second precision, so modified is truncated to the second.
+func notModifiedSince(c *gin.Context, modified time.Time) bool {
+	if modified.IsZero() {
+		return false
+	}
+
+	modified = modified.UTC().Truncate(time.Second)
+	c.Header("Last-Modified", modified.Format(http.TimeFormat))
+
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	if err != nil || modified.After(since) {
+		return false
+	}
+
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// latestTemplateUpdate returns when the most recently updated template was
+// last changed
+func latestTemplateUpdate(templates []*models.StoredTemplate) time.Time {
+	var latest time.Time
+	for _, template := range templates {
+		if template.UpdatedAt.After(latest) {
+			latest = template.UpdatedAt
+		}
+	}
+	return latest
+}
+
+// latestConfigUpdate returns when the most recently updated config was last
+// changed
+func latestConfigUpdate(configs []*models.StoredConfig) time.Time {
+	var latest time.Time
+	for _, config := range configs {
+		if config.UpdatedAt.After(latest) {
+			latest = config.UpdatedAt
+		}
+	}
+	return latest
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func conditionalGet(r *gin.Engine, url, since, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	if since != "" {
+		req.Header.Set("If-Modified-Since", since)
+	}
+	if userID != "" {
+		req.Header.Set("X-Test-User", userID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// checkConditionalList checks that url sets Last-Modified and answers 304
+// only when nothing is newer than If-Modified-Since
+func checkConditionalList(t *testing.T, r *gin.Engine, url string) {
+	t.Helper()
+
+	w := conditionalGet(r, url, "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	lastModified := w.Header().Get("Last-Modified")
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		t.Fatalf("Expected a Last-Modified date, got %q", lastModified)
+	}
+
+	w = conditionalGet(r, url, lastModified, "")
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected an empty 304 when nothing changed, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Last-Modified"); got != lastModified {
+		t.Errorf("Expected the 304 to carry Last-Modified %q, got %q", lastModified, got)
+	}
+
+	earlier := modified.Add(-time.Second).Format(http.TimeFormat)
+	if w := conditionalGet(r, url, earlier, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 when the list changed since, got %d", w.Code)
+	}
+
+	if w := conditionalGet(r, url, "not a date", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected an unparseable If-Modified-Since to be ignored, got %d", w.Code)
+	}
+}
+
+func TestListTemplatesHonorsIfModifiedSince(t *testing.T) {
+	repo := memory.NewTemplateRepository()
+	for _, template := range []*models.StoredTemplate{
+		{ID: "first", Template: models.Template{Public: true}},
+		{ID: "second", Template: models.Template{Public: true}},
+	} {
+		if err := repo.Create(context.Background(), template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+	h := newTestTemplateHandler(repo)
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates", h.ListTemplates)
+
+	checkConditionalList(t, r, "/api/templates")
+
+	// Signed-in responses carry per-user state, so they are always sent
+	w := conditionalGet(r, "/api/templates", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), "user-alice")
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a signed-in caller, got %d", w.Code)
+	}
+	if got := w.Header().Get("Last-Modified"); got != "" {
+		t.Errorf("Expected no Last-Modified for a signed-in caller, got %q", got)
+	}
+}
+
+func TestListConfigsHonorsIfModifiedSince(t *testing.T) {
+	checkConditionalList(t, newConfigTestRouter(t), "/api/configs")
+}
+
+func TestEmptyListHasNoLastModified(t *testing.T) {
+	h := newTestTemplateHandler(memory.NewTemplateRepository())
+	r := gin.New()
+	r.GET("/api/templates", h.ListTemplates)
+
+	w := conditionalGet(r, "/api/templates?author=nobody", time.Now().UTC().Format(http.TimeFormat), "")
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for an empty list, got %d", w.Code)
+	}
+	if got := w.Header().Get("Last-Modified"); got != "" {
+		t.Errorf("Expected no Last-Modified for an empty list, got %q", got)
+	}
+}
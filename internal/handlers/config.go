@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	stderrors "errors"
+	"log"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/middleware"
 	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/pagination"
 	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/search"
+	"dotfiles-api/internal/tags"
 	"dotfiles-api/pkg/errors"
 
 	"github.com/gin-gonic/gin"
@@ -16,16 +21,31 @@ import (
 
 // ConfigHandler handles config-related HTTP requests
 type ConfigHandler struct {
-	configRepo repository.ConfigRepository
+	configRepo  repository.ConfigRepository
+	userRepo    repository.UserRepository
+	tags        *tags.Registry
+	count       cachedCount
+	publicCount cachedCount
+	downloads   downloadEvents
+	limits      dto.ContentLimits
 }
 
 // NewConfigHandler creates a new config handler
-func NewConfigHandler(configRepo repository.ConfigRepository) *ConfigHandler {
+func NewConfigHandler(configRepo repository.ConfigRepository, userRepo repository.UserRepository) *ConfigHandler {
 	return &ConfigHandler{
 		configRepo: configRepo,
+		userRepo:   userRepo,
+		tags:       tags.NewRegistry(),
+		limits:     dto.DefaultContentLimits(),
 	}
 }
 
+// ConfigureTags replaces the tag registry configs' tags are normalized
+// through, so admin-defined synonyms apply to configs too
+func (h *ConfigHandler) ConfigureTags(registry *tags.Registry) {
+	h.tags = registry
+}
+
 // isAvailable checks if the handler is available (has required dependencies)
 func (h *ConfigHandler) isAvailable() bool {
 	return h.configRepo != nil
@@ -46,10 +66,7 @@ func (h *ConfigHandler) UploadConfig(c *gin.Context) {
 	}
 
 	var shareableConfig models.ShareableConfig
-	if err := c.ShouldBindJSON(&shareableConfig); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewValidationError("Invalid JSON format"),
-		})
+	if !bindJSON(c, &shareableConfig) {
 		return
 	}
 
@@ -68,6 +85,17 @@ func (h *ConfigHandler) UploadConfig(c *gin.Context) {
 		return
 	}
 
+	if appErr := dto.ValidateConfigTags(shareableConfig.Metadata.Tags); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+	shareableConfig.Metadata.Tags = h.tags.CanonicalTags(shareableConfig.Metadata.Tags)
+
+	if appErr := h.limits.CheckConfig(&shareableConfig); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
 	// Get user ID from context (if authenticated)
 	userID := ""
 	if uid, exists := c.Get("user_id"); exists {
@@ -75,7 +103,8 @@ func (h *ConfigHandler) UploadConfig(c *gin.Context) {
 	}
 
 	// Set metadata
-	shareableConfig.Metadata.CreatedAt = time.Now()
+	now := time.Now()
+	shareableConfig.Metadata.CreatedAt = now
 	if shareableConfig.Metadata.Version == "" {
 		shareableConfig.Metadata.Version = "1.0.0"
 	}
@@ -85,15 +114,14 @@ func (h *ConfigHandler) UploadConfig(c *gin.Context) {
 		ID:            uuid.New().String(),
 		Config:        shareableConfig,
 		Public:        true, // Default to public, could be made configurable
-		CreatedAt:     time.Now(),
+		CreatedAt:     now,
+		UpdatedAt:     now,
 		DownloadCount: 0,
 		OwnerID:       userID,
 	}
 
 	if err := h.configRepo.Create(c.Request.Context(), storedConfig); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to save config", err),
-		})
+		respondInternalError(c, "Failed to save config", err)
 		return
 	}
 
@@ -104,7 +132,14 @@ func (h *ConfigHandler) UploadConfig(c *gin.Context) {
 	})
 }
 
-// GetConfig handles getting a config by ID
+// canViewConfig reports whether the caller may see a config. Public configs
+// are visible to everyone, private ones only to their owner.
+func canViewConfig(c *gin.Context, config *models.StoredConfig) bool {
+	return config.Public || (config.OwnerID != "" && config.OwnerID == c.GetString("user_id"))
+}
+
+// GetConfig handles getting a config by ID. Private configs are only
+// returned to their owner.
 func (h *ConfigHandler) GetConfig(c *gin.Context) {
 	if !h.isAvailable() {
 		h.handleUnavailable(c)
@@ -121,13 +156,12 @@ func (h *ConfigHandler) GetConfig(c *gin.Context) {
 
 	config, err := h.configRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to retrieve config", err),
-		})
+		respondInternalError(c, "Failed to retrieve config", err)
 		return
 	}
 
-	if config == nil {
+	// Others are not told a private config exists
+	if config == nil || !canViewConfig(c, config) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": errors.NewNotFoundError("Config"),
 		})
@@ -137,7 +171,8 @@ func (h *ConfigHandler) GetConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, config)
 }
 
-// DownloadConfig handles config download
+// DownloadConfig handles config download. Private configs can only be
+// downloaded by their owner.
 func (h *ConfigHandler) DownloadConfig(c *gin.Context) {
 	if !h.isAvailable() {
 		h.handleUnavailable(c)
@@ -154,23 +189,26 @@ func (h *ConfigHandler) DownloadConfig(c *gin.Context) {
 
 	config, err := h.configRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to retrieve config", err),
-		})
+		respondInternalError(c, "Failed to retrieve config", err)
 		return
 	}
 
-	if config == nil {
+	// Others are not told a private config exists
+	if config == nil || !canViewConfig(c, config) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": errors.NewNotFoundError("Config"),
 		})
 		return
 	}
 
-	// Increment download count
-	if err := h.configRepo.IncrementDownloads(c.Request.Context(), id); err != nil {
-		// Log error but don't fail the request
-		// In production, you'd use proper logging
+	// Increment download count; HEAD requests only check the download, so
+	// they are not counted
+	if !middleware.IsHead(c) {
+		if err := h.configRepo.IncrementDownloads(c.Request.Context(), id); err != nil {
+			// Log error but don't fail the request
+			log.Printf("Failed to increment downloads of config %s: %v", id, err)
+		}
+		h.downloads.record(c, models.DownloadResourceConfig, id)
 	}
 
 	// Return the config content
@@ -178,98 +216,450 @@ func (h *ConfigHandler) DownloadConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, config.Config)
 }
 
-// SearchConfigs handles config search
-func (h *ConfigHandler) SearchConfigs(c *gin.Context) {
+// ListConfigs handles browsing configs with pagination, sorting and an owner filter.
+// Anonymous callers only see public configs; private configs are included only
+// when an authenticated user filters by their own username.
+func (h *ConfigHandler) ListConfigs(c *gin.Context) {
 	if !h.isAvailable() {
 		h.handleUnavailable(c)
 		return
 	}
 
-	query := c.Query("q")
-	if query == "" {
+	sortBy := c.DefaultQuery("sort_by", "created_at")
+	if sortBy != "created_at" && sortBy != "download_count" {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewBadRequestError("Search query is required"),
+			"error": errors.NewValidationError("sort_by must be one of created_at, download_count"),
 		})
 		return
 	}
 
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
+	sortOrder := c.DefaultQuery("sort_order", "desc")
+	if sortOrder != "asc" && sortOrder != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("sort_order must be one of asc, desc"),
+		})
+		return
+	}
+
+	dates, appErr := parseDateRange(c)
+	if appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	limit, offset := parsePagination(c)
+
+	filters := repository.ConfigFilters{
+		Limit:     limit,
+		Offset:    offset,
+		SortBy:    sortBy,
+		SortOrder: sortOrder,
+		DateRange: dates,
+		Tags:      parseTagFilter(c, h.tags),
+	}
+
+	// Get user ID from context (if authenticated)
+	userID := ""
+	if uid, exists := c.Get("user_id"); exists {
+		userID = uid.(string)
+	}
+
+	if username := c.Query("owner"); username != "" {
+		if h.userRepo == nil {
+			h.handleUnavailable(c)
+			return
+		}
+
+		owner, err := h.userRepo.GetByUsername(c.Request.Context(), username)
+		if err != nil {
+			var appErr *errors.AppError
+			if stderrors.As(err, &appErr) {
+				c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+				return
+			}
+			respondInternalError(c, "Failed to look up owner", err)
+			return
+		}
+
+		if owner == nil {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": errors.NewNotFoundError("User"),
+			})
+			return
+		}
+
+		filters.OwnerID = owner.ID
+	}
+
+	// Only owners browsing their own configs can see private ones
+	if filters.OwnerID == "" || filters.OwnerID != userID {
+		public := true
+		filters.Public = &public
+	}
+
+	configs, err := h.configRepo.List(c.Request.Context(), filters)
+	if err != nil {
+		respondInternalError(c, "Failed to list configs", err)
+		return
+	}
+	if notModifiedSince(c, latestConfigUpdate(configs)) {
+		return
+	}
+
+	total, err := h.configRepo.Count(c.Request.Context(), filters)
+	if err != nil {
+		respondInternalError(c, "Failed to count configs", err)
+		return
+	}
+
+	if configs == nil {
+		configs = []*models.StoredConfig{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"configs":    configs,
+		"limit":      limit,
+		"offset":     offset,
+		"total":      total,
+		"sort_by":    sortBy,
+		"sort_order": sortOrder,
+		"links":      pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, total),
+	})
+}
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
+// GetMyConfigs handles listing the configs uploaded by the current user,
+// including private ones, newest first
+func (h *ConfigHandler) GetMyConfigs(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
 	}
-	if limit > 100 {
-		limit = 100
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	limit, offset := parsePagination(c)
+
+	configs, err := h.configRepo.GetByOwner(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		respondInternalError(c, "Failed to list configs", err)
+		return
 	}
 
-	// For now, implement a simple search by listing and filtering
-	// In production, you'd want proper text search
-	configs, err := h.configRepo.List(c.Request.Context(), limit*2, offset) // Get more to filter
+	total, err := h.configRepo.Count(c.Request.Context(), repository.ConfigFilters{OwnerID: userID})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to search configs", err),
+		respondInternalError(c, "Failed to count configs", err)
+		return
+	}
+
+	if configs == nil {
+		configs = []*models.StoredConfig{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"configs": configs,
+		"limit":   limit,
+		"offset":  offset,
+		"total":   total,
+		"links":   pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, total),
+	})
+}
+
+// configSearchResult is a config plus the reason it matched a search
+type configSearchResult struct {
+	*models.StoredConfig
+	MatchedFields []string          `json:"matched_fields"`
+	Highlight     *search.Highlight `json:"highlight,omitempty"`
+}
+
+// SearchConfigs handles config search
+func (h *ConfigHandler) SearchConfigs(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	query := c.Query("q")
+	if len(search.Terms(query)) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewBadRequestError("Search query is required"),
 		})
 		return
 	}
 
-	// Simple text search in name and description
-	var filtered []*models.StoredConfig
-	searchTerm := strings.ToLower(query)
+	limit, offset := parsePagination(c)
+
+	// The store matches the query, so pages are counted in matching configs
+	public := true
+	configs, err := h.configRepo.List(c.Request.Context(), repository.ConfigFilters{
+		Public: &public,
+		Query:  query,
+		Tags:   parseTagFilter(c, h.tags),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		respondInternalError(c, "Failed to search configs", err)
+		return
+	}
+
+	// Report which metadata fields matched any query term
+	results := make([]configSearchResult, 0, len(configs))
+	terms := search.Terms(query)
 	for _, config := range configs {
-		if strings.Contains(strings.ToLower(config.Config.Metadata.Name), searchTerm) ||
-			strings.Contains(strings.ToLower(config.Config.Metadata.Description), searchTerm) {
-			filtered = append(filtered, config)
-			if len(filtered) >= limit {
-				break
-			}
-		}
+		matchedFields, highlight := search.Match(metadataSearchFields(config.Config.Metadata), terms, search.DefaultRadius)
+		results = append(results, configSearchResult{
+			StoredConfig:  config,
+			MatchedFields: matchedFields,
+			Highlight:     highlight,
+		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"configs": filtered,
+		"configs": results,
 		"query":   query,
 		"limit":   limit,
 		"offset":  offset,
-		"total":   len(filtered),
+		"total":   len(results),
+		"links":   pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, pagination.PageTotal(offset, limit, len(results))),
 	})
 }
 
-// GetFeaturedConfigs handles getting featured configs
+// GetFeaturedConfigs handles getting featured configs: the most downloaded
+// public configs. With ?window= (e.g. 7d) they are ranked by downloads over
+// that window instead, topped up by lifetime downloads when too few configs
+// were downloaded recently.
 func (h *ConfigHandler) GetFeaturedConfigs(c *gin.Context) {
 	if !h.isAvailable() {
 		h.handleUnavailable(c)
 		return
 	}
 
-	limitStr := c.DefaultQuery("limit", "10")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
+	limit, _ := parsePagination(c)
+	window, windowed, err := parseWindow(c.Query("window"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError(err.Error()),
+		})
+		return
 	}
-	if limit > 100 {
-		limit = 100
+
+	var configs []*models.StoredConfig
+	rankedBy := "downloads"
+	if windowed && h.downloads.repo != nil {
+		configs, err = h.recentlyDownloadedConfigs(c, window, limit)
+		if err != nil {
+			respondInternalError(c, "Failed to get featured configs", err)
+			return
+		}
+		if len(configs) > 0 {
+			rankedBy = "recent_downloads"
+		}
 	}
 
-	// For now, return most downloaded configs as "featured"
-	configs, err := h.configRepo.List(c.Request.Context(), limit, 0)
+	if len(configs) < limit {
+		// For now, return most downloaded public configs as "featured"
+		public := true
+		popular, err := h.configRepo.List(c.Request.Context(), repository.ConfigFilters{
+			Public: &public,
+			Limit:  limit + len(configs),
+			SortBy: "download_count",
+		})
+		if err != nil {
+			respondInternalError(c, "Failed to get featured configs", err)
+			return
+		}
+
+		included := make(map[string]bool, len(configs))
+		for _, config := range configs {
+			included[config.ID] = true
+		}
+		for _, config := range popular {
+			if len(configs) == limit {
+				break
+			}
+			if !included[config.ID] {
+				configs = append(configs, config)
+			}
+		}
+	}
+
+	response := gin.H{
+		"configs":   configs,
+		"limit":     limit,
+		"total":     len(configs),
+		"ranked_by": rankedBy,
+	}
+	if windowed {
+		response["window"] = c.Query("window")
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// recentlyDownloadedConfigs returns up to limit public configs, most
+// downloaded over the last window first
+func (h *ConfigHandler) recentlyDownloadedConfigs(c *gin.Context, window time.Duration, limit int) ([]*models.StoredConfig, error) {
+	// Some of the top configs may since have been made private or deleted
+	top, err := h.downloads.repo.TopSince(c.Request.Context(), models.DownloadResourceConfig, time.Now().Add(-window), 2*limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to get featured configs", err),
+		return nil, err
+	}
+
+	var configs []*models.StoredConfig
+	for _, entry := range top {
+		if len(configs) == limit {
+			break
+		}
+		config, err := h.configRepo.GetByID(c.Request.Context(), entry.ResourceID)
+		if err != nil && !isNotFound(err) {
+			return nil, err
+		}
+		if config != nil && config.Public {
+			configs = append(configs, config)
+		}
+	}
+	return configs, nil
+}
+
+// GetConfigDownloadHistory handles getting a config's downloads per day
+func (h *ConfigHandler) GetConfigDownloadHistory(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	id := c.Param("id")
+	config, err := h.configRepo.GetByID(c.Request.Context(), id)
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "Failed to retrieve config", err)
+		return
+	}
+	// Private configs' histories are their owner's alone, and others are
+	// not told they exist
+	if config == nil || !canViewConfig(c, config) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": errors.NewNotFoundError("Config"),
+		})
+		return
+	}
+
+	h.downloads.respondHistory(c, models.DownloadResourceConfig, config.ID)
+}
+
+// ConfigureContentLimits replaces the limits on how large an uploaded
+// config may be
+func (h *ConfigHandler) ConfigureContentLimits(limits dto.ContentLimits) {
+	h.limits = limits
+}
+
+// ConfigureDownloadEvents records each download as an event, which download
+// histories, recent download stats and windowed featured configs are built
+// from
+func (h *ConfigHandler) ConfigureDownloadEvents(events repository.DownloadEventRepository) {
+	h.downloads = downloadEvents{repo: events}
+}
+
+// TransferOwnership handles handing a config over to another user.
+// Only the current owner may transfer a config.
+func (h *ConfigHandler) TransferOwnership(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewBadRequestError("Config ID is required"),
+		})
+		return
+	}
+
+	// Get user ID from context
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
 		})
 		return
 	}
 
+	var req struct {
+		NewOwnerID string `json:"new_owner_id" binding:"required"`
+	}
+
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	config, err := h.configRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		respondInternalError(c, "Failed to retrieve config", err)
+		return
+	}
+
+	if config == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": errors.NewNotFoundError("Config"),
+		})
+		return
+	}
+
+	// Check if user owns the config
+	if config.OwnerID != userID.(string) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("Cannot transfer config owned by another user"),
+		})
+		return
+	}
+
+	if req.NewOwnerID == config.OwnerID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("New owner must be different from the current owner"),
+		})
+		return
+	}
+
+	if h.userRepo == nil {
+		h.handleUnavailable(c)
+		return
+	}
+
+	// Verify the new owner exists
+	newOwner, err := h.userRepo.GetByID(c.Request.Context(), req.NewOwnerID)
+	if err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
+			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+			return
+		}
+		respondInternalError(c, "Failed to look up new owner", err)
+		return
+	}
+
+	// Deleted accounts cannot receive configs
+	if newOwner == nil || newOwner.IsDeleted() {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": errors.NewNotFoundError("User"),
+		})
+		return
+	}
+
+	config.OwnerID = newOwner.ID
+
+	if err := h.configRepo.Update(c.Request.Context(), config); err != nil {
+		respondInternalError(c, "Failed to transfer config ownership", err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"configs": configs,
-		"limit":   limit,
-		"total":   len(configs),
+		"config":  config,
+		"message": "Config ownership transferred successfully",
 	})
 }
 
@@ -280,13 +670,28 @@ func (h *ConfigHandler) GetStats(c *gin.Context) {
 		return
 	}
 
+	csvExport, ok := wantsCSV(c)
+	if !ok {
+		return
+	}
+
 	stats, err := h.configRepo.GetStats(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to get statistics", err),
-		})
+		respondInternalError(c, "Failed to get statistics", err)
+		return
+	}
+	if stats.DownloadsLast7Days, err = h.downloads.countSince(c, models.DownloadResourceConfig, 7*24*time.Hour); err != nil {
+		respondInternalError(c, "Failed to get statistics", err)
+		return
+	}
+	if stats.DownloadsLast30Days, err = h.downloads.countSince(c, models.DownloadResourceConfig, 30*24*time.Hour); err != nil {
+		respondInternalError(c, "Failed to get statistics", err)
 		return
 	}
 
+	if csvExport {
+		writeCSV(c, "config-stats.csv", statsHeader, statsRows(stats))
+		return
+	}
 	c.JSON(http.StatusOK, stats)
 }
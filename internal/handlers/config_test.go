@@ -0,0 +1,548 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withTestUser stands in for the auth middleware, authenticating the request
+// as the user ID passed in the X-Test-User header and the username passed in
+// X-Test-Username.
+func withTestUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			c.Set("user_id", userID)
+		}
+		if username := c.GetHeader("X-Test-Username"); username != "" {
+			c.Set("username", username)
+		}
+		c.Next()
+	}
+}
+
+func newConfigTestHandler(t *testing.T) *ConfigHandler {
+	t.Helper()
+	ctx := context.Background()
+
+	userRepo := memory.NewUserRepository()
+	for _, user := range []*models.User{
+		{ID: "user-alice", Username: "alice", Email: "alice@example.com"},
+		{ID: "user-bob", Username: "bob", Email: "bob@example.com"},
+	} {
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	configRepo := memory.NewConfigRepository()
+	now := time.Now()
+	for _, config := range []*models.StoredConfig{
+		{ID: "alice-public", OwnerID: "user-alice", Public: true, DownloadCount: 5, CreatedAt: now.Add(-3 * time.Hour)},
+		{ID: "alice-private", OwnerID: "user-alice", Public: false, DownloadCount: 50, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "bob-public", OwnerID: "user-bob", Public: true, DownloadCount: 20, CreatedAt: now.Add(-1 * time.Hour)},
+	} {
+		if err := configRepo.Create(ctx, config); err != nil {
+			t.Fatalf("Failed to create config: %v", err)
+		}
+	}
+
+	return NewConfigHandler(configRepo, userRepo)
+}
+
+func newConfigTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	h := newConfigTestHandler(t)
+
+	r := gin.New()
+	r.GET("/api/configs", withTestUser(), h.ListConfigs)
+	r.GET("/api/configs/owned", withTestUser(), h.GetMyConfigs)
+	r.GET("/api/configs/:id", withTestUser(), h.GetConfig)
+	r.GET("/api/configs/:id/download", withTestUser(), h.DownloadConfig)
+	r.PUT("/api/configs/:id/owner", withTestUser(), h.TransferOwnership)
+	return r
+}
+
+func listConfigIDs(t *testing.T, r *gin.Engine, url, userID string) ([]string, float64) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	if userID != "" {
+		req.Header.Set("X-Test-User", userID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := decodeBody(t, w)
+	var ids []string
+	for _, config := range body["configs"].([]interface{}) {
+		ids = append(ids, config.(map[string]interface{})["id"].(string))
+	}
+	return ids, body["total"].(float64)
+}
+
+func TestListConfigsSortByDownloads(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	ids, total := listConfigIDs(t, r, "/api/configs?sort_by=download_count", "")
+	expected := []string{"bob-public", "alice-public"}
+	if len(ids) != len(expected) || ids[0] != expected[0] || ids[1] != expected[1] {
+		t.Errorf("Expected %v, got %v", expected, ids)
+	}
+	if total != 2 {
+		t.Errorf("Expected total 2, got %v", total)
+	}
+
+	ids, _ = listConfigIDs(t, r, "/api/configs?sort_by=download_count&sort_order=asc", "")
+	if len(ids) != 2 || ids[0] != "alice-public" {
+		t.Errorf("Expected ascending order to start with alice-public, got %v", ids)
+	}
+}
+
+func TestListConfigsDefaultsToNewestFirst(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	ids, _ := listConfigIDs(t, r, "/api/configs", "")
+	if len(ids) != 2 || ids[0] != "bob-public" {
+		t.Errorf("Expected newest public config first, got %v", ids)
+	}
+}
+
+func TestListConfigsOwnerFilter(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	tests := []struct {
+		name      string
+		requester string
+		expected  int
+	}{
+		{name: "owner sees private configs", requester: "user-alice", expected: 2},
+		{name: "other user sees public only", requester: "user-bob", expected: 1},
+		{name: "anonymous sees public only", requester: "", expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, total := listConfigIDs(t, r, "/api/configs?owner=alice", tt.requester)
+			if len(ids) != tt.expected || int(total) != tt.expected {
+				t.Errorf("Expected %d configs, got %v (total %v)", tt.expected, ids, total)
+			}
+			for _, id := range ids {
+				if id == "bob-public" {
+					t.Errorf("Owner filter leaked another user's config: %v", ids)
+				}
+			}
+		})
+	}
+}
+
+func TestListConfigsPagination(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	ids, total := listConfigIDs(t, r, "/api/configs?owner=alice&limit=1&offset=1&sort_by=download_count", "user-alice")
+	if len(ids) != 1 || ids[0] != "alice-public" {
+		t.Errorf("Expected second page to contain alice-public, got %v", ids)
+	}
+	if total != 2 {
+		t.Errorf("Expected total to ignore pagination, got %v", total)
+	}
+}
+
+func TestListConfigsRejectsUnknownSort(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/configs?sort_by=name", nil))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func transferConfig(r *gin.Engine, configID, userID, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, "/api/configs/"+configID+"/owner", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if userID != "" {
+		req.Header.Set("X-Test-User", userID)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestPrivateConfigsOnlyReachTheirOwner(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	get := func(url, userID string) int {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for _, url := range []string{"/api/configs/alice-private", "/api/configs/alice-private/download"} {
+		if code := get(url, ""); code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %s when signed out, got %d", url, code)
+		}
+		if code := get(url, "user-bob"); code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %s as another user, got %d", url, code)
+		}
+		if code := get(url, "user-alice"); code != http.StatusOK {
+			t.Errorf("Expected status 200 for %s as the owner, got %d", url, code)
+		}
+	}
+	if code := get("/api/configs/bob-public/download", ""); code != http.StatusOK {
+		t.Errorf("Expected status 200 downloading a public config, got %d", code)
+	}
+}
+
+func TestTransferOwnership(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	w := transferConfig(r, "alice-private", "user-alice", `{"new_owner_id": "user-bob"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	config := decodeBody(t, w)["config"].(map[string]interface{})
+	if config["owner_id"] != "user-bob" {
+		t.Errorf("Expected owner_id user-bob, got %v", config["owner_id"])
+	}
+
+	// Bob now owns the private config and sees it in his listing
+	ids, _ := listConfigIDs(t, r, "/api/configs?owner=bob", "user-bob")
+	if len(ids) != 2 {
+		t.Errorf("Expected bob to own 2 configs after transfer, got %v", ids)
+	}
+
+	// Alice no longer owns it
+	w = transferConfig(r, "alice-private", "user-alice", `{"new_owner_id": "user-alice"}`)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected previous owner to be forbidden, got %d", w.Code)
+	}
+}
+
+func TestTransferOwnershipErrors(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	tests := []struct {
+		name     string
+		configID string
+		userID   string
+		body     string
+		expected int
+	}{
+		{name: "not the owner", configID: "alice-public", userID: "user-bob", body: `{"new_owner_id": "user-bob"}`, expected: http.StatusForbidden},
+		{name: "unknown new owner", configID: "alice-public", userID: "user-alice", body: `{"new_owner_id": "user-nobody"}`, expected: http.StatusNotFound},
+		{name: "missing new owner", configID: "alice-public", userID: "user-alice", body: `{}`, expected: http.StatusBadRequest},
+		{name: "same owner", configID: "alice-public", userID: "user-alice", body: `{"new_owner_id": "user-alice"}`, expected: http.StatusBadRequest},
+		{name: "unknown config", configID: "missing", userID: "user-alice", body: `{"new_owner_id": "user-bob"}`, expected: http.StatusNotFound},
+		{name: "unauthenticated", configID: "alice-public", userID: "", body: `{"new_owner_id": "user-bob"}`, expected: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := transferConfig(r, tt.configID, tt.userID, tt.body)
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSearchConfigsReportsMatches(t *testing.T) {
+	h := newConfigTestHandler(t)
+	config, _ := h.configRepo.GetByID(context.Background(), "bob-public")
+	config.Config.Metadata = models.ShareMetadata{Name: "Bob's Shell", Description: "zsh with starship", Tags: []string{"shell"}}
+
+	r := gin.New()
+	r.GET("/api/configs/search", h.SearchConfigs)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/configs/search?q=shell", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	configs := decodeBody(t, w)["configs"].([]interface{})
+	if len(configs) != 1 {
+		t.Fatalf("Expected 1 config, got %d", len(configs))
+	}
+
+	result := configs[0].(map[string]interface{})
+	if result["id"] != "bob-public" {
+		t.Errorf("Expected config fields to stay at the top level, got %v", result)
+	}
+	if fields := fmt.Sprint(result["matched_fields"]); fields != "[name tags]" {
+		t.Errorf("Expected matched_fields [name tags], got %s", fields)
+	}
+	if snippet := result["highlight"].(map[string]interface{})["snippet"]; snippet != "Bob&#39;s <mark>Shell</mark>" {
+		t.Errorf("Expected escaped name highlight, got %v", snippet)
+	}
+}
+
+func TestSearchConfigsPaginatesMatches(t *testing.T) {
+	h := newConfigTestHandler(t)
+	ctx := context.Background()
+
+	// Matching configs are interleaved with newer ones that do not match,
+	// so filtering a page after listing it would come up short
+	now := time.Now()
+	var want []string
+	for i := 0; i < 5; i++ {
+		match := &models.StoredConfig{ID: fmt.Sprintf("match-%d", i), Public: true, CreatedAt: now.Add(time.Duration(-2*i) * time.Minute)}
+		match.Config.Metadata = models.ShareMetadata{Name: fmt.Sprintf("Starship setup %d", i)}
+		other := &models.StoredConfig{ID: fmt.Sprintf("other-%d", i), Public: true, CreatedAt: now.Add(time.Duration(-2*i+1) * time.Minute)}
+		other.Config.Metadata = models.ShareMetadata{Name: "Vim setup"}
+		for _, config := range []*models.StoredConfig{match, other} {
+			if err := h.configRepo.Create(ctx, config); err != nil {
+				t.Fatalf("Failed to create config: %v", err)
+			}
+		}
+		want = append(want, match.ID)
+	}
+	private, _ := h.configRepo.GetByID(ctx, "alice-private")
+	private.Config.Metadata = models.ShareMetadata{Name: "Private starship"}
+
+	r := gin.New()
+	r.GET("/api/configs/search", h.SearchConfigs)
+
+	var got []string
+	for offset := 0; offset < 6; offset += 2 {
+		ids, total := listConfigIDs(t, r, fmt.Sprintf("/api/configs/search?q=starship&limit=2&offset=%d", offset), "")
+		if size := min(2, 5-offset); len(ids) != size || total != float64(size) {
+			t.Errorf("Expected %d results at offset %d, got %v (total %v)", size, offset, ids, total)
+		}
+		got = append(got, ids...)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected every public match once, newest first, got %v", got)
+	}
+}
+
+func TestListConfigsDateRange(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	createdAfter := time.Now().Add(-90 * time.Minute).UTC().Format(time.RFC3339)
+	ids, total := listConfigIDs(t, r, "/api/configs?created_after="+createdAfter, "")
+	if len(ids) != 1 || ids[0] != "bob-public" || total != 1 {
+		t.Errorf("Expected only bob-public, got %v (total %v)", ids, total)
+	}
+
+	createdBefore := time.Now().Add(-90 * time.Minute).UTC().Format(time.RFC3339)
+	ids, _ = listConfigIDs(t, r, "/api/configs?created_before="+createdBefore, "")
+	if len(ids) != 1 || ids[0] != "alice-public" {
+		t.Errorf("Expected only alice-public, got %v", ids)
+	}
+}
+
+func TestListConfigsRejectsMalformedDates(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	for _, query := range []string{
+		"created_after=yesterday",
+		"created_before=2024-03-04",
+		"updated_after=2024-03-04T12:00:00",
+		"created_after=2024-03-05T00:00:00Z&created_before=2024-03-04T00:00:00Z",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/configs?"+query, nil))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestListConfigsPaginationLinks(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/configs?owner=alice&limit=1&offset=1", nil)
+	req.Header.Set("X-Test-User", "user-alice")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	links := decodeBody(t, w)["links"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"first": "/api/configs?owner=alice&offset=0&limit=1",
+		"prev":  "/api/configs?owner=alice&offset=0&limit=1",
+		"next":  nil,
+		"last":  "/api/configs?owner=alice&offset=1&limit=1",
+	}
+	for key, value := range expected {
+		if links[key] != value {
+			t.Errorf("Expected %s link %v, got %v", key, value, links[key])
+		}
+	}
+}
+
+func TestGetMyConfigs(t *testing.T) {
+	r := newConfigTestRouter(t)
+
+	ids, total := listConfigIDs(t, r, "/api/configs/owned", "user-alice")
+	if len(ids) != 2 || ids[0] != "alice-private" || ids[1] != "alice-public" {
+		t.Errorf("Expected alice's configs newest first, including private, got %v", ids)
+	}
+	if total != 2 {
+		t.Errorf("Expected total 2, got %v", total)
+	}
+
+	ids, total = listConfigIDs(t, r, "/api/configs/owned?limit=1&offset=1", "user-alice")
+	if len(ids) != 1 || ids[0] != "alice-public" || total != 2 {
+		t.Errorf("Expected second page to contain alice-public with total 2, got %v (total %v)", ids, total)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/configs/owned", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for anonymous callers, got %d", w.Code)
+	}
+}
+
+func TestGetConfigCount(t *testing.T) {
+	h := newConfigTestHandler(t)
+	r := gin.New()
+	r.GET("/api/configs/count", h.GetCount)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/configs/count", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=30" {
+		t.Errorf("Expected a 30 second Cache-Control, got %q", got)
+	}
+
+	body := decodeBody(t, w)
+	if body["count"] != float64(3) || body["public_count"] != float64(2) {
+		t.Errorf("Expected 3 configs with 2 public, got %v", body)
+	}
+}
+
+func TestUploadConfigNormalizesTags(t *testing.T) {
+	h := newConfigTestHandler(t)
+	r := gin.New()
+	r.POST("/api/configs/upload", h.UploadConfig)
+
+	upload := func(tags string) *httptest.ResponseRecorder {
+		body := `{"metadata": {"name": "Shell", "author": "alice", "tags": ` + tags + `}}`
+		req := httptest.NewRequest(http.MethodPost, "/api/configs/upload", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := upload(`["Front End", "JS", "javascript", "front_end"]`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	config, _ := h.configRepo.GetByID(context.Background(), decodeBody(t, w)["id"].(string))
+	if got := fmt.Sprint(config.Config.Metadata.Tags); got != "[frontend javascript]" {
+		t.Errorf("Expected canonical, deduplicated tags, got %s", got)
+	}
+
+	for _, tags := range []string{
+		`["shell", " "]`,
+		`["` + strings.Repeat("a", 31) + `"]`,
+		`["1","2","3","4","5","6","7","8","9","10","11"]`,
+	} {
+		if w := upload(tags); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for tags %s, got %d", tags, w.Code)
+		}
+	}
+}
+
+func TestUploadConfigContentLimits(t *testing.T) {
+	h := newConfigTestHandler(t)
+	h.ConfigureContentLimits(dto.ContentLimits{MaxListEntries: 2})
+	r := gin.New()
+	r.POST("/api/configs/upload", h.UploadConfig)
+
+	upload := func(brews string) *httptest.ResponseRecorder {
+		body := `{"brews": ` + brews + `, "metadata": {"name": "Shell", "author": "alice"}}`
+		req := httptest.NewRequest(http.MethodPost, "/api/configs/upload", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := upload(`["git", "go"]`); w.Code != http.StatusCreated {
+		t.Errorf("Expected 201 at the limit, got %d: %s", w.Code, w.Body.String())
+	}
+	w := upload(`["git", "go", "jq"]`)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413 over the limit, got %d: %s", w.Code, w.Body.String())
+	}
+	if fields, _ := decodeBody(t, w)["error"].(map[string]interface{})["fields"].(map[string]interface{}); fields["brews"] == nil {
+		t.Errorf("Expected the error to name brews, got %s", w.Body.String())
+	}
+}
+
+func TestConfigTagFilterMatchesAllTags(t *testing.T) {
+	h := newConfigTestHandler(t)
+	ctx := context.Background()
+	for id, tags := range map[string][]string{
+		"alice-public":  {"Shell", "JavaScript"},
+		"alice-private": {"shell", "javascript"},
+		"bob-public":    {"shell"},
+	} {
+		config, _ := h.configRepo.GetByID(ctx, id)
+		config.Config.Metadata = models.ShareMetadata{Name: id + " setup", Tags: tags}
+	}
+
+	r := gin.New()
+	r.GET("/api/configs", withTestUser(), h.ListConfigs)
+	r.GET("/api/configs/search", h.SearchConfigs)
+	r.GET("/api/configs/tags", h.ListConfigTags)
+
+	// Each tag matches through its synonyms and any casing, and every tag
+	// must match
+	ids, total := listConfigIDs(t, r, "/api/configs?tags=shell&tags=js", "")
+	if fmt.Sprint(ids) != "[alice-public]" || total != 1 {
+		t.Errorf("Expected only alice-public, got %v (total %v)", ids, total)
+	}
+	ids, _ = listConfigIDs(t, r, "/api/configs?tags=shell", "")
+	if fmt.Sprint(ids) != "[bob-public alice-public]" {
+		t.Errorf("Expected both public shell configs, got %v", ids)
+	}
+	ids, _ = listConfigIDs(t, r, "/api/configs/search?q=setup&tags=shell&tags=javascript", "")
+	if !slices.Contains(ids, "alice-public") || slices.Contains(ids, "bob-public") {
+		t.Errorf("Expected search to apply the tag filter, got %v", ids)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/configs/tags", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	counts := map[string]float64{}
+	for _, tag := range decodeBody(t, w)["tags"].([]interface{}) {
+		tag := tag.(map[string]interface{})
+		counts[tag["tag"].(string)] = tag["count"].(float64)
+	}
+	// Private configs are not counted
+	if fmt.Sprint(counts) != "map[javascript:1 shell:2]" {
+		t.Errorf("Expected shell 2 and javascript 1, got %v", counts)
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"dotfiles-api/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// countTTL is how long a public count is reused before the store is asked
+// again
+const countTTL = 30 * time.Second
+
+// orgTemplateCountTTL is how long an organization's template count is reused
+const orgTemplateCountTTL = 60 * time.Second
+
+// cachedCount caches a count, so dashboards polling it do not each cost a
+// count query. A zero ttl means countTTL.
+type cachedCount struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	count     int
+	expiresAt time.Time
+}
+
+// get returns the cached count, calling load once it has expired
+func (cc *cachedCount) get(load func() (int, error)) (int, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if now := time.Now(); !now.Before(cc.expiresAt) {
+		count, err := load()
+		if err != nil {
+			return 0, err
+		}
+		ttl := cc.ttl
+		if ttl == 0 {
+			ttl = countTTL
+		}
+		cc.count = count
+		cc.expiresAt = now.Add(ttl)
+	}
+	return cc.count, nil
+}
+
+// cachedCounts caches one count per key, such as per organization
+type cachedCounts struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	counts map[string]*cachedCount
+}
+
+// get returns the count cached under key, calling load once it has expired
+func (cc *cachedCounts) get(key string, load func() (int, error)) (int, error) {
+	cc.mu.Lock()
+	count, ok := cc.counts[key]
+	if !ok {
+		if cc.counts == nil {
+			cc.counts = make(map[string]*cachedCount)
+		}
+		count = &cachedCount{ttl: cc.ttl}
+		cc.counts[key] = count
+	}
+	cc.mu.Unlock()
+
+	// Loaded outside the map lock, so one slow organization does not hold
+	// up the others
+	return count.get(load)
+}
+
+// setCountCacheControl lets clients reuse a count as long as the server does
+func setCountCacheControl(c *gin.Context) {
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(countTTL.Seconds())))
+}
+
+// CountTemplates returns how many public templates are listed
+func (h *TemplateHandler) CountTemplates(c *gin.Context) {
+	count, err := h.publicCount.get(func() (int, error) {
+		public := true
+		return h.templateRepo.Count(c.Request.Context(), repository.TemplateFilters{Public: &public})
+	})
+	if err != nil {
+		respondInternalError(c, "failed to count templates", err)
+		return
+	}
+
+	setCountCacheControl(c)
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
+
+// GetCount returns how many configs are stored, and how many of them are
+// public
+func (h *ConfigHandler) GetCount(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	count, err := h.count.get(func() (int, error) {
+		return h.configRepo.Count(c.Request.Context(), repository.ConfigFilters{})
+	})
+	if err != nil {
+		respondInternalError(c, "failed to count configs", err)
+		return
+	}
+	publicCount, err := h.publicCount.get(func() (int, error) {
+		public := true
+		return h.configRepo.Count(c.Request.Context(), repository.ConfigFilters{Public: &public})
+	})
+	if err != nil {
+		respondInternalError(c, "failed to count public configs", err)
+		return
+	}
+
+	setCountCacheControl(c)
+	c.JSON(http.StatusOK, gin.H{
+		"count":        count,
+		"public_count": publicCount,
+	})
+}
+
+// GetOrganizationTemplateCount returns how many public, listed templates an
+// organization has, for profile pages that show the number without listing
+// them. Private organizations are only visible to their members.
+func (h *OrganizationHandler) GetOrganizationTemplateCount(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	org, ok := h.loadOrganization(c)
+	if !ok || !h.requireOrganizationVisible(c, org) {
+		return
+	}
+
+	count, err := h.templateCounts.get(org.ID, func() (int, error) {
+		// Only templates anyone who can see the organization may open are
+		// counted, so one cached count serves every viewer
+		public := true
+		return h.templateRepo.Count(c.Request.Context(), repository.TemplateFilters{OrganizationID: org.ID, Public: &public})
+	})
+	if err != nil {
+		respondInternalError(c, "failed to count organization templates", err)
+		return
+	}
+
+	// Shared caches may only keep counts anyone can see
+	scope := "public"
+	if !org.Public {
+		scope = "private"
+	}
+	c.Header("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, int(orgTemplateCountTTL.Seconds())))
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
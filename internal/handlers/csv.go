@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"dotfiles-api/internal/middleware"
+	"dotfiles-api/internal/pagination"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// csvContentType is sent with ?format=csv exports
+const csvContentType = "text/csv; charset=utf-8"
+
+// wantsCSV reads ?format=, which may be json (the default) or csv. Any other
+// value is answered with 400 and ok is false.
+func wantsCSV(c *gin.Context) (csv bool, ok bool) {
+	switch format := c.Query("format"); format {
+	case "", "json":
+		return false, true
+	case "csv":
+		return true, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError(fmt.Sprintf("Unsupported format %q; use json or csv", format)),
+		})
+		return false, false
+	}
+}
+
+// parseListPagination is parsePagination for lists that can be exported as
+// CSV, where a page may hold up to pagination.MaxExportLimit rows
+func parseListPagination(c *gin.Context, csv bool) (limit, offset int) {
+	if csv {
+		return parsePaginationUpTo(c, pagination.MaxExportLimit)
+	}
+	return parsePagination(c)
+}
+
+// writeCSV sends header and rows as a CSV attachment named filename. Fields
+// holding commas, quotes or newlines are quoted by encoding/csv, fields that
+// a spreadsheet would run as a formula are neutralized by csvSafeRow, and
+// rows are written to the response as they are encoded.
+func writeCSV(c *gin.Context, filename string, header []string, rows [][]string) {
+	c.Header("Content-Type", csvContentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	if middleware.IsHead(c) {
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	// Write errors are sticky, so Error also reports one from the header
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(header)
+	for _, row := range rows {
+		if err := w.Write(csvSafeRow(row)); err != nil {
+			break
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil && c.Request.Context().Err() == nil {
+		log.Printf("CSV export of %s failed: %v", c.Request.URL.Path, err)
+	}
+}
+
+// csvSafeRow prefixes fields starting with =, +, -, @, a tab or a carriage
+// return with a single quote, so user-supplied text such as a template name
+// cannot become a formula when the export is opened in a spreadsheet
+func csvSafeRow(row []string) []string {
+	safe := make([]string, len(row))
+	for i, field := range row {
+		if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+			field = "'" + field
+		}
+		safe[i] = field
+	}
+	return safe
+}
+
+// statsRows flattens a stats response into key,value rows, one per JSON
+// field in declaration order
+func statsRows(stats interface{}) [][]string {
+	value := reflect.Indirect(reflect.ValueOf(stats))
+	rows := make([][]string, 0, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		key, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		rows = append(rows, []string{key, fmt.Sprint(value.Field(i).Interface())})
+	}
+	return rows
+}
+
+// statsHeader heads the key,value rows of a stats export
+var statsHeader = []string{"key", "value"}
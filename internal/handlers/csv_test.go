@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readCSV requests url and parses the CSV response back into records
+func readCSV(t *testing.T, r *gin.Engine, url string) [][]string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != csvContentType {
+		t.Errorf("Expected Content-Type %q, got %q", csvContentType, got)
+	}
+	if got := w.Header().Get("Content-Disposition"); !strings.HasPrefix(got, "attachment; filename=") {
+		t.Errorf("Expected an attachment filename, got %q", got)
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v\n%s", err, w.Body.String())
+	}
+	return records
+}
+
+func TestListTemplatesCSV(t *testing.T) {
+	ctx := context.Background()
+	templateRepo := memory.NewTemplateRepository()
+	reviewRepo := memory.NewReviewRepository()
+
+	template := &models.StoredTemplate{
+		ID: "quoted",
+		Template: models.Template{
+			Public: true,
+			Metadata: models.ShareMetadata{
+				Name:   `Dev, "Pro" Setup`,
+				Author: "alice",
+				Tags:   []string{"go", "rust"},
+			},
+		},
+		Downloads: 12,
+	}
+	if err := templateRepo.Create(ctx, template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	for i, rating := range []int{4, 5} {
+		review := &models.Review{ID: string(rune('a' + i)), TemplateID: "quoted", UserID: string(rune('a' + i)), Rating: rating}
+		if err := reviewRepo.Create(ctx, review); err != nil {
+			t.Fatalf("Failed to create review: %v", err)
+		}
+	}
+
+	h := NewTemplateHandler(templateRepo, memory.NewTagRepository(), memory.NewUserRepository(), reviewRepo, tags.NewRegistry(), NewAuthorizer(memory.NewOrganizationRepository()))
+	r := gin.New()
+	r.GET("/api/templates", h.ListTemplates)
+
+	records := readCSV(t, r, "/api/templates?format=csv&author=alice")
+	if len(records) != 2 {
+		t.Fatalf("Expected a header and one row, got %v", records)
+	}
+	if !reflect.DeepEqual(records[0], templateCSVHeader) {
+		t.Errorf("Expected header %v, got %v", templateCSVHeader, records[0])
+	}
+	want := []string{"quoted", `Dev, "Pro" Setup`, "alice", "12", "4.50", "go|rust", template.CreatedAt.Format("2006-01-02T15:04:05Z"), "public"}
+	if !reflect.DeepEqual(records[1], want) {
+		t.Errorf("Expected row %v, got %v", want, records[1])
+	}
+
+	// CSV pages may be larger than JSON ones
+	records = readCSV(t, r, "/api/templates?format=csv&limit=500")
+	if total, _ := templateRepo.Count(ctx, repository.TemplateFilters{}); len(records)-1 != total {
+		t.Errorf("Expected all %d templates in one CSV page, got %d rows", total, len(records)-1)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates?format=xml", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown format, got %d", w.Code)
+	}
+}
+
+func TestListUsersCSV(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+	user := &models.User{ID: "alice-id", Username: "alice", Name: "Alice\nSmith", Email: "alice@example.com", Company: "Acme, Inc."}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	user.CreatedAt = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	r := gin.New()
+	r.GET("/api/admin/users", NewUserHandler(userRepo, memory.NewTemplateRepository(), memory.NewReviewRepository(), memory.NewOrganizationRepository()).ListUsers)
+
+	records := readCSV(t, r, "/api/admin/users?format=csv")
+	want := [][]string{
+		userCSVHeader,
+		{"alice-id", "alice", "Alice\nSmith", "alice@example.com", "Acme, Inc.", "", "2024-01-02T03:04:05Z"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("Expected %v, got %v", want, records)
+	}
+}
+
+func TestTemplateStatsCSV(t *testing.T) {
+	h := newTestTemplateHandler(memory.NewTemplateRepository())
+	r := gin.New()
+	r.GET("/api/templates/stats", h.GetTemplateStats)
+
+	records := readCSV(t, r, "/api/templates/stats?format=csv")
+	var keys []string
+	for _, record := range records[1:] {
+		keys = append(keys, record[0])
+	}
+	if !reflect.DeepEqual(records[0], statsHeader) || !reflect.DeepEqual(keys, []string{"total_templates", "featured_templates", "total_downloads", "categories"}) {
+		t.Errorf("Expected key,value rows in field order, got %v", records)
+	}
+}
+
+func TestCSVNeutralizesFormulas(t *testing.T) {
+	r := gin.New()
+	r.GET("/export", func(c *gin.Context) {
+		writeCSV(c, "export.csv", []string{"value"}, [][]string{
+			{"=HYPERLINK(\"http://evil.example\")"},
+			{"+1"},
+			{"-2+3"},
+			{"@SUM(A1:A2)"},
+			{"\tcmd"},
+			{"\rcmd"},
+			{"plain = text"},
+		})
+	})
+
+	var got []string
+	for _, record := range readCSV(t, r, "/export")[1:] {
+		got = append(got, record[0])
+	}
+	want := []string{"'=HYPERLINK(\"http://evil.example\")", "'+1", "'-2+3", "'@SUM(A1:A2)", "'\tcmd", "'\rcmd", "plain = text"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
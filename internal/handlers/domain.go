@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// domainChallengePrefix is prepended to a custom domain to name the TXT
+	// record that proves ownership of it
+	domainChallengePrefix = "_dotfiles-challenge."
+	// domainChallengeValuePrefix is prepended to the challenge token in the
+	// TXT record value
+	domainChallengeValuePrefix = "dotfiles-verification="
+	// domainOrgKey is the context key holding the ID of the organization
+	// whose verified custom domain the request arrived on
+	domainOrgKey = "domain_org_id"
+	// customDomainTTL is how long a host's organization is reused before
+	// the store is asked again
+	customDomainTTL = 30 * time.Second
+	// maxCachedDomains bounds the host cache, since the Host header is
+	// chosen by the client; the cache starts over once it is full
+	maxCachedDomains = 1000
+)
+
+// domainCache remembers which organization, if any, owns each host, so
+// requests do not each cost a lookup. Hosts without a verified domain are
+// cached too, as most traffic arrives on those.
+type domainCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedDomain
+}
+
+type cachedDomain struct {
+	orgID     string
+	expiresAt time.Time
+}
+
+// get returns the cached organization ID for host, and whether it was found
+func (dc *domainCache) get(host string, now time.Time) (string, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	entry, ok := dc.entries[host]
+	if !ok || !now.Before(entry.expiresAt) {
+		return "", false
+	}
+	return entry.orgID, true
+}
+
+func (dc *domainCache) set(host, orgID string, now time.Time) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	if dc.entries == nil || len(dc.entries) >= maxCachedDomains {
+		dc.entries = make(map[string]cachedDomain)
+	}
+	dc.entries[host] = cachedDomain{orgID: orgID, expiresAt: now.Add(customDomainTTL)}
+}
+
+// reset drops every cached host, so a domain change takes effect at once on
+// this instance
+func (dc *domainCache) reset() {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+
+	dc.entries = nil
+}
+
+// ConfigurePrimaryHosts sets the hosts the API itself is served on. Requests
+// on them skip the custom domain lookup.
+func (h *OrganizationHandler) ConfigurePrimaryHosts(hosts []string) {
+	h.primaryHosts = make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		if host = dto.NormalizeDomain(host); host != "" {
+			h.primaryHosts[host] = true
+		}
+	}
+}
+
+// CustomDomain is middleware that maps requests arriving on a verified custom
+// domain to the organization owning it, so default listings can be scoped to
+// that organization. Requests on any other host pass through unchanged.
+func (h *OrganizationHandler) CustomDomain() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.isAvailable() {
+			c.Next()
+			return
+		}
+
+		host := dto.NormalizeDomain(c.Request.Host)
+		if host == "" || h.primaryHosts[host] {
+			c.Next()
+			return
+		}
+
+		if orgID := h.domainOrganization(c, host); orgID != "" {
+			c.Set(domainOrgKey, orgID)
+		}
+
+		c.Next()
+	}
+}
+
+// domainOrganization returns the ID of the organization whose verified
+// custom domain is host, or "" for none. A failed lookup is logged and
+// treated as none, so a store hiccup leaves listings unscoped rather than
+// failing every request.
+func (h *OrganizationHandler) domainOrganization(c *gin.Context, host string) string {
+	now := time.Now()
+	if orgID, ok := h.domains.get(host, now); ok {
+		return orgID
+	}
+
+	org, err := h.orgRepo.GetByCustomDomain(c.Request.Context(), host)
+	if err != nil {
+		log.Printf("Failed to resolve custom domain %s: %v", host, err)
+		return ""
+	}
+
+	orgID := ""
+	if org != nil && org.DomainVerified() {
+		orgID = org.ID
+	}
+	h.domains.set(host, orgID, now)
+	return orgID
+}
+
+// SetCustomDomain handles assigning a custom domain to an organization (site
+// admins only). The domain stays inactive until the organization proves
+// ownership through VerifyCustomDomain.
+func (h *OrganizationHandler) SetCustomDomain(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	var req dto.SetCustomDomainRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if appErr := req.Validate(); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return
+	}
+
+	token := ""
+	if req.Domain != "" {
+		var err error
+		if token, err = generateDomainToken(); err != nil {
+			respondInternalError(c, "Failed to generate domain challenge", err)
+			return
+		}
+	}
+
+	if err := h.orgRepo.SetCustomDomain(c.Request.Context(), org.ID, req.Domain, token); err != nil {
+		if stderrors.Is(err, repository.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": errors.NewConflictError("Domain is already used by another organization"),
+			})
+			return
+		}
+		respondInternalError(c, "Failed to update custom domain", err)
+		return
+	}
+
+	h.domains.reset()
+
+	org.CustomDomain = req.Domain
+	org.DomainToken = token
+	org.DomainVerifiedAt = nil
+
+	c.JSON(http.StatusOK, toCustomDomainResponse(org))
+}
+
+// GetCustomDomain handles reporting an organization's custom domain and the
+// TXT record that verifies it (organization owners and admins, site admins)
+func (h *OrganizationHandler) GetCustomDomain(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	org, ok := h.loadDomainManagedOrganization(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, toCustomDomainResponse(org))
+}
+
+// VerifyCustomDomain handles checking the TXT-record challenge for an
+// organization's custom domain and activating the domain when it matches
+// (organization owners and admins, site admins)
+func (h *OrganizationHandler) VerifyCustomDomain(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	org, ok := h.loadDomainManagedOrganization(c)
+	if !ok {
+		return
+	}
+
+	if org.DomainVerified() {
+		c.JSON(http.StatusOK, toCustomDomainResponse(org))
+		return
+	}
+
+	challenge := domainChallenge(org)
+	records, err := h.lookupTXT(c.Request.Context(), challenge.Name)
+	if err != nil || !slices.Contains(records, challenge.Value) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("TXT record " + challenge.Name + " does not contain the expected challenge value"),
+		})
+		return
+	}
+
+	if err := h.orgRepo.VerifyCustomDomain(c.Request.Context(), org.ID, org.CustomDomain); err != nil {
+		if stderrors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": errors.NewConflictError("Custom domain changed during verification, please retry"),
+			})
+			return
+		}
+		respondInternalError(c, "Failed to verify custom domain", err)
+		return
+	}
+	h.domains.reset()
+
+	org, ok = h.loadOrganization(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, toCustomDomainResponse(org))
+}
+
+// loadDomainManagedOrganization loads the :slug organization, checking that
+// it has a custom domain and that the caller may manage it
+func (h *OrganizationHandler) loadDomainManagedOrganization(c *gin.Context) (*models.Organization, bool) {
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return nil, false
+	}
+
+	if !c.GetBool("is_admin") {
+		role, err := h.memberRole(c.Request.Context(), org, c.GetString("user_id"))
+		if err != nil {
+			respondInternalError(c, "Failed to get organization member", err)
+			return nil, false
+		}
+		if role != models.RoleOwner && role != models.RoleAdmin {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": errors.NewForbiddenError("Only organization owners and admins can manage the custom domain"),
+			})
+			return nil, false
+		}
+	}
+
+	if org.CustomDomain == "" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": errors.NewNotFoundError("Custom domain"),
+		})
+		return nil, false
+	}
+
+	return org, true
+}
+
+// toCustomDomainResponse describes an organization's custom domain, including
+// the challenge record until the domain is verified
+func toCustomDomainResponse(org *models.Organization) dto.CustomDomainResponse {
+	response := dto.CustomDomainResponse{
+		Domain:   org.CustomDomain,
+		Verified: org.DomainVerified(),
+	}
+
+	if response.Verified {
+		response.VerifiedAt = org.DomainVerifiedAt.Format("2006-01-02T15:04:05Z")
+	} else if org.CustomDomain != "" {
+		challenge := domainChallenge(org)
+		response.Challenge = &challenge
+	}
+
+	return response
+}
+
+// domainChallenge returns the TXT record the organization must publish to
+// prove it controls its custom domain
+func domainChallenge(org *models.Organization) dto.DomainChallengeResponse {
+	return dto.DomainChallengeResponse{
+		Type:  "TXT",
+		Name:  domainChallengePrefix + org.CustomDomain,
+		Value: domainChallengeValuePrefix + org.DomainToken,
+	}
+}
+
+// generateDomainToken generates a random custom domain challenge token
+func generateDomainToken() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
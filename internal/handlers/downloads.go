@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dotfiles-api/internal/compat"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultHistoryDays is how many days a download history covers by default
+const defaultHistoryDays = 30
+
+// unknownPlatform counts downloads whose User-Agent named no platform
+const unknownPlatform = "unknown"
+
+// downloadEvents records and reads back individual downloads for the
+// template and config handlers, so both count them the same way. The zero
+// value records nothing and reports no recent downloads.
+type downloadEvents struct {
+	repo repository.DownloadEventRepository
+}
+
+// record stores a download of the resource, with the platform named in the
+// User-Agent. Events only feed statistics, so failing to store one is
+// logged rather than failing the download.
+func (d downloadEvents) record(c *gin.Context, resourceType, resourceID string) {
+	if d.repo == nil {
+		return
+	}
+
+	event := &models.DownloadEvent{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		At:           time.Now(),
+		Platform:     compat.ParsePlatform(c.GetHeader("User-Agent")),
+	}
+	if err := d.repo.Record(c.Request.Context(), event); err != nil {
+		log.Printf("Failed to record download event for %s %s: %v", resourceType, resourceID, err)
+	}
+}
+
+// countSince counts downloads of every resource of the type over the last
+// window, or 0 when events are not recorded
+func (d downloadEvents) countSince(c *gin.Context, resourceType string, window time.Duration) (int, error) {
+	if d.repo == nil {
+		return 0, nil
+	}
+	return d.repo.CountSince(c.Request.Context(), resourceType, time.Now().Add(-window))
+}
+
+// respondHistory responds with the resource's downloads per UTC day over
+// the last ?days= days (default 30), oldest first and including days
+// without downloads
+func (d downloadEvents) respondHistory(c *gin.Context, resourceType, resourceID string) {
+	days, start, ok := parseHistoryDays(c)
+	if !ok {
+		return
+	}
+
+	counts := map[string]int{}
+	if d.repo != nil {
+		var err error
+		counts, err = d.repo.CountByDay(c.Request.Context(), resourceType, resourceID, start)
+		if err != nil {
+			respondInternalError(c, "failed to get download history", err)
+			return
+		}
+	}
+
+	history := make([]models.DailyDownloads, days)
+	total := 0
+	for i := range history {
+		date := start.AddDate(0, 0, i).Format("2006-01-02")
+		history[i] = models.DailyDownloads{Date: date, Downloads: counts[date]}
+		total += counts[date]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":      resourceID,
+		"days":    days,
+		"total":   total,
+		"history": history,
+	})
+}
+
+// respondPlatforms responds with the resource's downloads per platform over
+// the last ?days= days (default 30). Downloads whose User-Agent named no
+// platform are counted as "unknown".
+func (d downloadEvents) respondPlatforms(c *gin.Context, resourceType, resourceID string) {
+	days, start, ok := parseHistoryDays(c)
+	if !ok {
+		return
+	}
+
+	counts := map[string]int{}
+	if d.repo != nil {
+		var err error
+		counts, err = d.repo.CountByPlatform(c.Request.Context(), resourceType, resourceID, start)
+		if err != nil {
+			respondInternalError(c, "failed to get downloads by platform", err)
+			return
+		}
+	}
+
+	platforms := make(map[string]int, len(counts))
+	total := 0
+	for platform, downloads := range counts {
+		if platform == "" {
+			platform = unknownPlatform
+		}
+		platforms[platform] += downloads
+		total += downloads
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":        resourceID,
+		"days":      days,
+		"total":     total,
+		"platforms": platforms,
+	})
+}
+
+// parseHistoryDays reads ?days= (default 30, at most as long as events are
+// kept) and returns it with the start of the first UTC day it covers. On an
+// invalid value it responds with 400 and returns false.
+func parseHistoryDays(c *gin.Context) (int, time.Time, bool) {
+	days := defaultHistoryDays
+	maxDays := int(repository.DownloadEventRetention / (24 * time.Hour))
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxDays {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": errors.NewValidationError(fmt.Sprintf("days must be between 1 and %d", maxDays)),
+			})
+			return 0, time.Time{}, false
+		}
+		days = parsed
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	return days, today.AddDate(0, 0, 1-days), true
+}
+
+// parseWindow reads a recent-downloads window such as "7d" or "12h". It
+// reports false for an empty value and an error for one that is malformed
+// or longer than events are kept.
+func parseWindow(raw string) (time.Duration, bool, error) {
+	if raw == "" {
+		return 0, false, nil
+	}
+
+	var window time.Duration
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid window %q", raw)
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid window %q", raw)
+		}
+		window = parsed
+	}
+
+	if window <= 0 || window > repository.DownloadEventRetention {
+		return 0, false, fmt.Errorf("window must be positive and at most %dd", int(repository.DownloadEventRetention/(24*time.Hour)))
+	}
+	return window, true, nil
+}
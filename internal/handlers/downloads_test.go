@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newDownloadsTestRouter serves templates and configs sharing one download
+// event store, with a template and a config both stored under "shared"
+func newDownloadsTestRouter(t *testing.T) (*gin.Engine, *memory.DownloadEventRepository) {
+	t.Helper()
+	ctx := context.Background()
+
+	templateRepo := memory.NewTemplateRepository()
+	if err := templateRepo.Create(ctx, &models.StoredTemplate{ID: "shared", Template: models.Template{Public: true}}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	configRepo := memory.NewConfigRepository()
+	for _, config := range []*models.StoredConfig{
+		{ID: "shared", Public: true, DownloadCount: 1},
+		{ID: "lifetime-favorite", Public: true, DownloadCount: 100},
+		{ID: "trending", Public: true, DownloadCount: 5},
+		{ID: "private", OwnerID: "owner-id", DownloadCount: 50},
+	} {
+		if err := configRepo.Create(ctx, config); err != nil {
+			t.Fatalf("Failed to create config: %v", err)
+		}
+	}
+
+	events := memory.NewDownloadEventRepository()
+	templateHandler := newTestTemplateHandler(templateRepo)
+	templateHandler.ConfigureDownloadEvents(events)
+	configHandler := NewConfigHandler(configRepo, memory.NewUserRepository())
+	configHandler.ConfigureDownloadEvents(events)
+
+	r := gin.New()
+	r.GET("/api/templates/:id/download", templateHandler.DownloadTemplate)
+	r.GET("/api/templates/:id/downloads/history", templateHandler.GetTemplateDownloadHistory)
+	r.GET("/api/configs/featured", configHandler.GetFeaturedConfigs)
+	r.GET("/api/configs/stats", configHandler.GetStats)
+	r.GET("/api/configs/:id/download", configHandler.DownloadConfig)
+	r.GET("/api/configs/:id/downloads/history", withTestUser(), configHandler.GetConfigDownloadHistory)
+	return r, events
+}
+
+func getJSON(t *testing.T, r *gin.Engine, url string) map[string]interface{} {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 from %s, got %d: %s", url, w.Code, w.Body.String())
+	}
+	return decodeBody(t, w)
+}
+
+func TestDownloadHistoryKeepsResourceTypesApart(t *testing.T) {
+	r, _ := newDownloadsTestRouter(t)
+
+	for i := 0; i < 3; i++ {
+		getJSON(t, r, "/api/templates/shared/download")
+	}
+	getJSON(t, r, "/api/configs/shared/download")
+	// HEAD requests are not downloads
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodHead, "/api/configs/shared/download", nil))
+
+	if total := getJSON(t, r, "/api/configs/shared/downloads/history")["total"]; total != float64(1) {
+		t.Errorf("Expected 1 config download, got %v", total)
+	}
+	if total := getJSON(t, r, "/api/templates/shared/downloads/history")["total"]; total != float64(3) {
+		t.Errorf("Expected 3 template downloads, got %v", total)
+	}
+
+	stats := getJSON(t, r, "/api/configs/stats")
+	if stats["downloads_last_7_days"] != float64(1) || stats["downloads_last_30_days"] != float64(1) {
+		t.Errorf("Expected only the config download in config stats, got %v", stats)
+	}
+}
+
+func TestConfigDownloadHistoryBuckets(t *testing.T) {
+	r, events := newDownloadsTestRouter(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	for _, at := range []time.Time{now, now, now.AddDate(0, 0, -2), now.AddDate(0, 0, -10)} {
+		if err := events.Record(ctx, &models.DownloadEvent{ResourceType: models.DownloadResourceConfig, ResourceID: "shared", At: at}); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	body := getJSON(t, r, "/api/configs/shared/downloads/history?days=3")
+	var counts []float64
+	for _, day := range body["history"].([]interface{}) {
+		counts = append(counts, day.(map[string]interface{})["downloads"].(float64))
+	}
+	// Oldest first, with empty days kept and older events left out
+	if !reflect.DeepEqual(counts, []float64{1, 0, 2}) || body["total"] != float64(3) {
+		t.Errorf("Expected [1 0 2] totalling 3, got %v totalling %v", counts, body["total"])
+	}
+	if days := body["history"].([]interface{}); days[2].(map[string]interface{})["date"] != now.UTC().Format("2006-01-02") {
+		t.Errorf("Expected the last bucket to be today, got %v", days[2])
+	}
+
+	for _, url := range []string{"/api/configs/shared/downloads/history?days=0", "/api/configs/shared/downloads/history?days=91"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", url, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/configs/missing/downloads/history", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown config, got %d", w.Code)
+	}
+}
+
+func TestPrivateConfigDownloadHistoryIsOwnerOnly(t *testing.T) {
+	r, _ := newDownloadsTestRouter(t)
+
+	history := func(userID string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/configs/private/downloads/history", nil)
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := history(""); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an anonymous caller, got %d", code)
+	}
+	if code := history("stranger-id"); code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another user, got %d", code)
+	}
+	if code := history("owner-id"); code != http.StatusOK {
+		t.Errorf("Expected 200 for the owner, got %d", code)
+	}
+}
+
+func TestFeaturedConfigsWindow(t *testing.T) {
+	r, events := newDownloadsTestRouter(t)
+
+	featured := func(url string) ([]string, interface{}) {
+		t.Helper()
+		body := getJSON(t, r, url)
+		var ids []string
+		for _, config := range body["configs"].([]interface{}) {
+			ids = append(ids, config.(map[string]interface{})["id"].(string))
+		}
+		return ids, body["ranked_by"]
+	}
+
+	// Without events a window falls back to lifetime downloads
+	ids, rankedBy := featured("/api/configs/featured?window=7d&limit=2")
+	if !reflect.DeepEqual(ids, []string{"lifetime-favorite", "trending"}) || rankedBy != "downloads" {
+		t.Errorf("Expected lifetime ranking, got %v by %v", ids, rankedBy)
+	}
+
+	ctx := context.Background()
+	for _, event := range []*models.DownloadEvent{
+		{ResourceType: models.DownloadResourceConfig, ResourceID: "trending"},
+		{ResourceType: models.DownloadResourceConfig, ResourceID: "trending"},
+		{ResourceType: models.DownloadResourceConfig, ResourceID: "private"},
+		{ResourceType: models.DownloadResourceConfig, ResourceID: "private"},
+		{ResourceType: models.DownloadResourceConfig, ResourceID: "private"},
+		// Template downloads never rank configs
+		{ResourceType: models.DownloadResourceTemplate, ResourceID: "shared"},
+		{ResourceType: models.DownloadResourceTemplate, ResourceID: "shared"},
+		{ResourceType: models.DownloadResourceTemplate, ResourceID: "shared"},
+		{ResourceType: models.DownloadResourceConfig, ResourceID: "lifetime-favorite", At: time.Now().AddDate(0, 0, -30)},
+	} {
+		if err := events.Record(ctx, event); err != nil {
+			t.Fatalf("Failed to record event: %v", err)
+		}
+	}
+
+	// Recent downloads first, private configs left out, topped up by
+	// lifetime downloads
+	ids, rankedBy = featured("/api/configs/featured?window=7d&limit=2")
+	if !reflect.DeepEqual(ids, []string{"trending", "lifetime-favorite"}) || rankedBy != "recent_downloads" {
+		t.Errorf("Expected trending first, got %v by %v", ids, rankedBy)
+	}
+
+	// Without a window the ranking is unchanged
+	if ids, _ := featured("/api/configs/featured?limit=2"); !reflect.DeepEqual(ids, []string{"lifetime-favorite", "trending"}) {
+		t.Errorf("Expected lifetime ranking without a window, got %v", ids)
+	}
+
+	for _, window := range []string{"soon", "0d", "365d"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/configs/featured?window="+window, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for window %q, got %d", window, w.Code)
+		}
+	}
+}
+
+func TestTemplatePlatformAnalytics(t *testing.T) {
+	ctx := context.Background()
+	templateRepo := memory.NewTemplateRepository()
+	template := &models.StoredTemplate{ID: "dev", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Author: "alice"}}}
+	if err := templateRepo.Create(ctx, template); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	h := newTestTemplateHandler(templateRepo)
+	h.ConfigureDownloadEvents(memory.NewDownloadEventRepository())
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates/:id/download", h.DownloadTemplate)
+	r.GET("/api/templates/:id/analytics/platforms", h.GetTemplatePlatformAnalytics)
+
+	for _, userAgent := range []string{
+		"dotfiles-cli/0.5.0 (Darwin/arm64)",
+		"dotfiles-cli/0.5.0 (Darwin/arm64)",
+		"dotfiles-cli/0.5.0 (Linux/x86_64)",
+		"curl/8.4.0",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/templates/dev/download", nil)
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 downloading, got %d", w.Code)
+		}
+	}
+
+	w := sendAs(r, http.MethodGet, "/api/templates/dev/analytics/platforms", "alice", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	want := map[string]interface{}{"mac_arm64": 2.0, "linux_x86": 1.0, "unknown": 1.0}
+	if !reflect.DeepEqual(body["platforms"], want) || body["total"] != 4.0 {
+		t.Errorf("Expected %v of 4 downloads, got %v of %v", want, body["platforms"], body["total"])
+	}
+
+	if w := sendAs(r, http.MethodGet, "/api/templates/dev/analytics/platforms", "bob", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for someone else, got %d", w.Code)
+	}
+}
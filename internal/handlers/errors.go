@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"strconv"
+
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// storageRetryAfter is the Retry-After hint sent while storage is unavailable
+const storageRetryAfter = 5
+
+// respondInternalError writes the response for an unexpected failure. Storage
+// outages answer 503 with a Retry-After header so clients back off and retry;
+// anything else is a 500.
+func respondInternalError(c *gin.Context, message string, err error) {
+	if stderrors.Is(err, repository.ErrStorageUnavailable) {
+		c.Header("Retry-After", strconv.Itoa(storageRetryAfter))
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": errors.NewUnavailableError("storage is temporarily unavailable, please retry"),
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error": errors.NewInternalError(message, err),
+	})
+}
+
+// isNotFound reports whether a lookup failed because the record does not
+// exist; stores report that either as repository.ErrNotFound or as a
+// NOT_FOUND AppError
+func isNotFound(err error) bool {
+	var appErr *errors.AppError
+	return stderrors.Is(err, repository.ErrNotFound) ||
+		(stderrors.As(err, &appErr) && appErr.Code == errors.ErrCodeNotFound)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/homebrew"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultBandwidthMbps is the connection speed download times are
+// estimated for unless the caller passes ?mbps=
+const defaultBandwidthMbps = 25
+
+// estimateTimeout bounds how long an estimate waits on Homebrew; packages
+// not looked up in time are reported as unknown
+const estimateTimeout = 15 * time.Second
+
+// ConfigureHomebrew sets where package sizes for install estimates are
+// looked up and how long they are cached
+func (h *TemplateHandler) ConfigureHomebrew(config homebrew.Config) {
+	h.homebrew = homebrew.NewClient(config)
+}
+
+// GetTemplateEstimate returns the approximate download size of installing a
+// template's brews and casks, with its extends chain resolved, and how long
+// the downloads take at ?mbps= (default 25). It follows the download
+// visibility rules but does not count as a download.
+func (h *TemplateHandler) GetTemplateEstimate(c *gin.Context) {
+	mbps := float64(defaultBandwidthMbps)
+	if raw := c.Query("mbps"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed <= 0 || math.IsInf(parsed, 0) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": errors.NewValidationError("mbps must be a positive number"),
+			})
+			return
+		}
+		mbps = parsed
+	}
+
+	template, ok := h.loadVisibleTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	resolved, _, ok := h.resolveChain(c, template)
+	if !ok {
+		return
+	}
+
+	packages := make([]homebrew.Package, 0, len(resolved.Brews)+len(resolved.Casks))
+	for _, name := range resolved.Brews {
+		packages = append(packages, homebrew.Package{Name: name, Kind: homebrew.Formula})
+	}
+	for _, name := range resolved.Casks {
+		packages = append(packages, homebrew.Package{Name: name, Kind: homebrew.Cask})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), estimateTimeout)
+	defer cancel()
+
+	response := dto.TemplateEstimateResponse{
+		TemplateID:    template.ID,
+		PackageCount:  len(packages),
+		BandwidthMbps: mbps,
+		Packages:      make([]dto.PackageEstimate, 0, len(packages)),
+	}
+	for _, result := range h.homebrew.Sizes(ctx, packages) {
+		estimate := dto.PackageEstimate{Name: result.Name, Type: "brew"}
+		if result.Kind == homebrew.Cask {
+			estimate.Type = "cask"
+		}
+
+		if result.Err != nil {
+			if !stderrors.Is(result.Err, homebrew.ErrUnknownPackage) {
+				log.Printf("Size of %s %s: %v", result.Kind, result.Name, result.Err)
+			}
+			estimate.Unknown = true
+			response.UnknownCount++
+		} else {
+			size := result.Size
+			estimate.SizeBytes = &size
+			response.TotalSizeBytes += size
+		}
+		response.Packages = append(response.Packages, estimate)
+	}
+
+	response.TotalSize = formatSize(response.TotalSizeBytes)
+	seconds := float64(response.TotalSizeBytes*8) / (mbps * 1e6)
+	response.EstimatedDownloadSeconds = int(math.Ceil(seconds))
+
+	c.JSON(http.StatusOK, response)
+}
+
+// formatSize renders a byte count in decimal units, as Homebrew and most
+// download pages do
+func formatSize(bytes int64) string {
+	const unit = 1000
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value, exp := float64(bytes)/unit, 0
+	for value >= unit && exp < 3 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "kMGT"[exp])
+}
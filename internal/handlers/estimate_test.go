@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/homebrew"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetTemplateEstimate(t *testing.T) {
+	var brew *httptest.Server
+	brew = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/formula/git.json":
+			fmt.Fprintf(w, `{"bottle":{"stable":{"files":{"all":{"url":"%s/files/git"}}}}}`, brew.URL)
+		case "/cask/firefox.json":
+			fmt.Fprintf(w, `{"url":"%s/files/firefox"}`, brew.URL)
+		case "/files/git":
+			w.Header().Set("Content-Length", "5000000")
+		case "/files/firefox":
+			w.Header().Set("Content-Length", "120000000")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer brew.Close()
+
+	ctx := context.Background()
+	repo := memory.NewTemplateRepository()
+	for _, template := range []*models.StoredTemplate{
+		{ID: "base", Template: models.Template{Public: true, Brews: []string{"git"}}},
+		{ID: "leaf", Template: models.Template{Public: true, Extends: "base", Casks: []string{"firefox", "not-a-cask"}}},
+	} {
+		if err := repo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := newTestTemplateHandler(repo)
+	h.homebrew = homebrew.NewClient(homebrew.Config{APIURL: brew.URL})
+	r := gin.New()
+	r.GET("/api/templates/:id/estimate", h.GetTemplateEstimate)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	w := get("/api/templates/leaf/estimate?mbps=100")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var estimate dto.TemplateEstimateResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &estimate); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if estimate.PackageCount != 3 || estimate.UnknownCount != 1 {
+		t.Errorf("Expected 3 packages with 1 unknown, got %d and %d", estimate.PackageCount, estimate.UnknownCount)
+	}
+	if estimate.TotalSizeBytes != 125000000 || estimate.TotalSize != "125.0 MB" {
+		t.Errorf("Expected 125 MB in total, got %d (%s)", estimate.TotalSizeBytes, estimate.TotalSize)
+	}
+	if estimate.EstimatedDownloadSeconds != 10 {
+		t.Errorf("Expected 10 seconds at 100 Mbps, got %d", estimate.EstimatedDownloadSeconds)
+	}
+	unknown := estimate.Packages[2]
+	if unknown.Name != "not-a-cask" || unknown.Type != "cask" || !unknown.Unknown || unknown.SizeBytes != nil {
+		t.Errorf("Expected not-a-cask to be marked unknown, got %+v", unknown)
+	}
+	if git := estimate.Packages[0]; git.Name != "git" || git.Type != "brew" || git.SizeBytes == nil || *git.SizeBytes != 5000000 {
+		t.Errorf("Expected the inherited git brew, got %+v", git)
+	}
+
+	if w := get("/api/templates/leaf/estimate?mbps=0"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a zero bandwidth, got %d", w.Code)
+	}
+	if w := get("/api/templates/missing/estimate"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown template, got %d", w.Code)
+	}
+}
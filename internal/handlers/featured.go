@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// featuredFallbackLimit is how many of an organization's most downloaded
+// templates are featured when it has pinned none
+const featuredFallbackLimit = 5
+
+// GetFeaturedTemplates returns the templates an organization features: its
+// pinned templates in order, or else its most downloaded ones. Only public,
+// listed templates are featured. Private organizations are only visible to
+// their members.
+func (h *OrganizationHandler) GetFeaturedTemplates(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	org, ok := h.loadOrganization(c)
+	if !ok || !h.requireOrganizationVisible(c, org) {
+		return
+	}
+
+	templates, err := h.pinnedTemplates(c.Request.Context(), org, org.PinnedTemplateIDs)
+	if err != nil {
+		respondInternalError(c, "Failed to get pinned templates", err)
+		return
+	}
+
+	if len(templates) == 0 {
+		public := true
+		templates, err = h.templateRepo.List(c.Request.Context(), repository.TemplateFilters{
+			OrganizationID: org.ID,
+			Public:         &public,
+			Sort:           []repository.SortKey{{Field: "downloads", Desc: true}},
+			Limit:          featuredFallbackLimit,
+		})
+		if err != nil {
+			respondInternalError(c, "Failed to list organization templates", err)
+			return
+		}
+	}
+
+	// Pinning templates updates the organization, which changes the list too
+	modified := latestTemplateUpdate(templates)
+	if org.UpdatedAt.After(modified) {
+		modified = org.UpdatedAt
+	}
+	if notModifiedSince(c, modified) {
+		return
+	}
+
+	response := make([]dto.TemplateResponse, 0, len(templates))
+	for _, template := range templates {
+		response = append(response, toTemplateResponse(template))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SetFeaturedTemplates replaces an organization's pinned templates
+// (organization owners and admins, and site admins). Each must be a public
+// template of the organization.
+func (h *OrganizationHandler) SetFeaturedTemplates(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	var req dto.SetPinnedTemplatesRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if appErr := req.Validate(); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return
+	}
+	if !h.requireOrganizationManager(c, org, "Only organization owners and admins can pin templates") {
+		return
+	}
+
+	templates, err := h.pinnedTemplates(c.Request.Context(), org, req.TemplateIDs)
+	if err != nil {
+		respondInternalError(c, "Failed to get templates", err)
+		return
+	}
+	if len(templates) != len(req.TemplateIDs) {
+		found := make(map[string]bool, len(templates))
+		for _, template := range templates {
+			found[template.ID] = true
+		}
+		fields := make(map[string]string)
+		for i, id := range req.TemplateIDs {
+			if !found[id] {
+				fields[fmt.Sprintf("template_ids.%d", i)] = "must be a public template of the organization"
+			}
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewFieldValidationError("Request validation failed", fields),
+		})
+		return
+	}
+
+	if err := h.orgRepo.SetPinnedTemplates(c.Request.Context(), org.ID, req.TemplateIDs); err != nil {
+		respondInternalError(c, "Failed to pin templates", err)
+		return
+	}
+
+	response := make([]dto.TemplateResponse, 0, len(templates))
+	for _, template := range templates {
+		response = append(response, toTemplateResponse(template))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// pinnedTemplates loads the given templates in order, skipping any that no
+// longer exist, were made private or unlisted, or left the organization
+func (h *OrganizationHandler) pinnedTemplates(ctx context.Context, org *models.Organization, ids []string) ([]*models.StoredTemplate, error) {
+	var templates []*models.StoredTemplate
+	for _, id := range ids {
+		template, err := h.templateRepo.GetByID(ctx, id)
+		if err != nil && !isNotFound(err) {
+			return nil, err
+		}
+		if template == nil || !template.Template.Public || template.Unlisted || template.Template.OrganizationID != org.ID {
+			continue
+		}
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
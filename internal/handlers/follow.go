@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/pagination"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FollowUser makes the caller follow the user named in the path. Following
+// someone already followed is not an error.
+func (h *UserHandler) FollowUser(c *gin.Context) {
+	followee, ok := h.loadNamedUser(c)
+	if !ok {
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if followee.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewBadRequestError("you cannot follow yourself"),
+		})
+		return
+	}
+
+	if err := h.userRepo.Follow(c.Request.Context(), userID, followee.ID); err != nil {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("user")})
+			return
+		}
+		respondInternalError(c, "failed to follow user", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User followed successfully"})
+}
+
+// UnfollowUser makes the caller stop following the user named in the path
+func (h *UserHandler) UnfollowUser(c *gin.Context) {
+	followee, ok := h.loadNamedUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.userRepo.Unfollow(c.Request.Context(), c.GetString("user_id"), followee.ID); err != nil {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("follow")})
+			return
+		}
+		respondInternalError(c, "failed to unfollow user", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unfollowed successfully"})
+}
+
+// GetFollowing lists the users the named user follows, most recently
+// followed first
+func (h *UserHandler) GetFollowing(c *gin.Context) {
+	h.listFollows(c, "following", h.userRepo.GetFollowing)
+}
+
+// GetFollowers lists the users following the named user, most recent first
+func (h *UserHandler) GetFollowers(c *gin.Context) {
+	h.listFollows(c, "followers", h.userRepo.GetFollowers)
+}
+
+func (h *UserHandler) listFollows(c *gin.Context, key string, list func(ctx context.Context, userID string, limit, offset int) ([]*models.User, error)) {
+	user, ok := h.loadNamedUser(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	users, err := list(c.Request.Context(), user.ID, limit, offset)
+	if err != nil {
+		respondInternalError(c, "failed to list "+key, err)
+		return
+	}
+
+	response := make([]dto.UserSummaryResponse, len(users))
+	for i, user := range users {
+		response[i] = dto.UserSummaryResponse{
+			ID:        user.ID,
+			Username:  user.Username,
+			Name:      user.Name,
+			AvatarURL: user.AvatarURL,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		key:      response,
+		"limit":  limit,
+		"offset": offset,
+		"links":  pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, pagination.PageTotal(offset, limit, len(response))),
+	})
+}
+
+// loadNamedUser fetches the user named by the :username parameter, writing
+// a 404 when there is none
+func (h *UserHandler) loadNamedUser(c *gin.Context) (*models.User, bool) {
+	user, err := h.userRepo.GetByUsername(c.Request.Context(), c.Param("username"))
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "failed to get user", err)
+		return nil, false
+	}
+	if user == nil || user.IsDeleted() {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("user")})
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFollowing(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		if err := userRepo.Create(ctx, &models.User{ID: name + "-id", Username: name, Email: name + "@example.com"}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	h := NewUserHandler(userRepo, memory.NewTemplateRepository(), memory.NewReviewRepository(), memory.NewOrganizationRepository())
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/users/:username/following", h.GetFollowing)
+	r.GET("/api/users/:username/followers", h.GetFollowers)
+	r.POST("/api/users/:username/follow", h.FollowUser)
+	r.DELETE("/api/users/:username/follow", h.UnfollowUser)
+
+	follow := func(follower, followee string) {
+		t.Helper()
+		if w := sendAs(r, http.MethodPost, "/api/users/"+followee+"/follow", follower, ""); w.Code != http.StatusOK {
+			t.Fatalf("Expected %s to follow %s, got %d: %s", follower, followee, w.Code, w.Body.String())
+		}
+	}
+	usernames := func(url, key string) []string {
+		t.Helper()
+		w := sendAs(r, http.MethodGet, url, "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 from %s, got %d: %s", url, w.Code, w.Body.String())
+		}
+		var names []string
+		for _, user := range decodeBody(t, w)[key].([]interface{}) {
+			names = append(names, user.(map[string]interface{})["username"].(string))
+		}
+		return names
+	}
+
+	follow("alice", "bob")
+	follow("alice", "carol")
+	follow("alice", "dave")
+	follow("alice", "bob") // following again keeps bob's place
+	follow("bob", "carol")
+
+	if got := usernames("/api/users/alice/following", "following"); len(got) != 3 || got[0] != "dave" || got[1] != "carol" || got[2] != "bob" {
+		t.Errorf("Expected alice to follow dave, carol, bob, got %v", got)
+	}
+	if got := usernames("/api/users/alice/following?limit=1&offset=1", "following"); len(got) != 1 || got[0] != "carol" {
+		t.Errorf("Expected the second page of one to be carol, got %v", got)
+	}
+	if got := usernames("/api/users/carol/followers", "followers"); len(got) != 2 || got[0] != "bob" || got[1] != "alice" {
+		t.Errorf("Expected carol to be followed by bob, alice, got %v", got)
+	}
+
+	if w := sendAs(r, http.MethodDelete, "/api/users/carol/follow", "alice", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected alice to unfollow carol, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := userRepo.Delete(ctx, "dave-id"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	if got := usernames("/api/users/alice/following", "following"); len(got) != 1 || got[0] != "bob" {
+		t.Errorf("Expected alice to follow only bob once carol is unfollowed and dave deleted, got %v", got)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		user   string
+		want   int
+	}{
+		{"follow yourself", http.MethodPost, "/api/users/alice/follow", "alice", http.StatusBadRequest},
+		{"follow unknown user", http.MethodPost, "/api/users/nobody/follow", "alice", http.StatusNotFound},
+		{"unfollow someone not followed", http.MethodDelete, "/api/users/carol/follow", "alice", http.StatusNotFound},
+		{"following of unknown user", http.MethodGet, "/api/users/nobody/following", "", http.StatusNotFound},
+		{"followers of unknown user", http.MethodGet, "/api/users/nobody/followers", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := sendAs(r, tt.method, tt.url, tt.user, ""); w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
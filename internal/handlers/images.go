@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"dotfiles-api/internal/images"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImageCaptionLength bounds image captions, in characters
+const maxImageCaptionLength = 200
+
+// ConfigureImages sets where uploaded template images are stored. Without a
+// store, uploads answer 503.
+func (h *TemplateHandler) ConfigureImages(store *images.Store) {
+	h.images = store
+}
+
+// UploadTemplateImage adds a screenshot to a template from the "image" file
+// of a multipart form, with an optional "caption". The type is taken from the
+// file's content rather than its name. Author only.
+func (h *TemplateHandler) UploadTemplateImage(c *gin.Context) {
+	if h.images == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": errors.NewUnavailableError("Template images are not available"),
+		})
+		return
+	}
+
+	template, ok := h.loadImageTemplate(c)
+	if !ok {
+		return
+	}
+
+	config := h.images.Config()
+	if len(template.Images) >= config.MaxPerTemplate {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError(fmt.Sprintf("a template can have at most %d images", config.MaxPerTemplate)),
+		})
+		return
+	}
+
+	caption := strings.TrimSpace(c.PostForm("caption"))
+	if utf8.RuneCountInString(caption) > maxImageCaptionLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewFieldValidationError("Request validation failed", map[string]string{
+				"caption": fmt.Sprintf("must be at most %d characters", maxImageCaptionLength),
+			}),
+		})
+		return
+	}
+
+	header, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewBadRequestError("an image file is required in the \"image\" form field"),
+		})
+		return
+	}
+	if header.Size > config.MaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": errors.NewPayloadTooLargeError(config.MaxBytes),
+		})
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		respondInternalError(c, "failed to read image", err)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, config.MaxBytes+1))
+	if err != nil {
+		respondInternalError(c, "failed to read image", err)
+		return
+	}
+
+	stored, err := h.images.Save(template.ID, data)
+	if err != nil {
+		switch {
+		case stderrors.Is(err, images.ErrTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errors.NewPayloadTooLargeError(config.MaxBytes)})
+		case stderrors.Is(err, images.ErrUnsupported):
+			appErr := errors.NewUnsupportedMediaTypeError("image must be a PNG, JPEG or WebP file")
+			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		case stderrors.Is(err, images.ErrInvalid):
+			c.JSON(http.StatusBadRequest, gin.H{"error": errors.NewValidationError("image could not be read")})
+		default:
+			respondInternalError(c, "failed to store image", err)
+		}
+		return
+	}
+
+	// Names come from the content, so the same file uploaded twice would
+	// share one stored copy
+	for _, image := range template.Images {
+		if image.ID == stored.Name {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": errors.NewConflictError("template already has this image"),
+			})
+			return
+		}
+	}
+
+	image := models.TemplateImage{
+		ID:          stored.Name,
+		URL:         stored.URL,
+		ContentType: stored.ContentType,
+		Width:       stored.Width,
+		Height:      stored.Height,
+		Size:        stored.Size,
+		Caption:     caption,
+		UploadedAt:  time.Now(),
+	}
+	template.Images = append(template.Images, image)
+	if err := h.templateRepo.Update(c.Request.Context(), template); err != nil {
+		h.removeImage(template.ID, image.ID)
+		respondInternalError(c, "failed to update template", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"image":  image,
+		"images": template.Images,
+	})
+}
+
+// DeleteTemplateImage removes one of a template's screenshots. Author only.
+func (h *TemplateHandler) DeleteTemplateImage(c *gin.Context) {
+	template, ok := h.loadImageTemplate(c)
+	if !ok {
+		return
+	}
+
+	imageID := c.Param("imageId")
+	index := -1
+	for i, image := range template.Images {
+		if image.ID == imageID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("image")})
+		return
+	}
+
+	template.Images = append(template.Images[:index:index], template.Images[index+1:]...)
+	if err := h.templateRepo.Update(c.Request.Context(), template); err != nil {
+		respondInternalError(c, "failed to update template", err)
+		return
+	}
+	h.removeImage(template.ID, imageID)
+
+	c.JSON(http.StatusOK, gin.H{"images": template.Images})
+}
+
+// loadImageTemplate loads the template whose images are being changed and
+// checks that the caller is its author
+func (h *TemplateHandler) loadImageTemplate(c *gin.Context) (*models.StoredTemplate, bool) {
+	template, ok := h.loadTemplate(c, c.Param("id"))
+	if !ok {
+		return nil, false
+	}
+
+	if template.Template.Metadata.Author != c.GetString("username") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("only the template author can change its images"),
+		})
+		return nil, false
+	}
+	return template, true
+}
+
+// removeImage deletes an image file no longer referenced by its template,
+// logging failures since the template itself is already consistent
+func (h *TemplateHandler) removeImage(templateID, name string) {
+	if h.images == nil {
+		return
+	}
+	if err := h.images.Remove(templateID, name); err != nil {
+		log.Printf("Failed to remove image %s of template %s: %v", name, templateID, err)
+	}
+}
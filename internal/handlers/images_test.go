@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"dotfiles-api/internal/images"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testPNG encodes a blank PNG of the given size
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestTemplateImages(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTemplateRepository()
+	if err := repo.Create(ctx, &models.StoredTemplate{
+		ID:       "theme",
+		Template: models.Template{Public: true, Metadata: models.ShareMetadata{Author: "alice"}},
+	}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	config := images.DefaultConfig()
+	config.Dir = t.TempDir()
+	h := newTestTemplateHandler(repo)
+	h.ConfigureImages(images.NewStore(config))
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/templates/:id/images", h.UploadTemplateImage)
+	r.DELETE("/api/templates/:id/images/:imageId", h.DeleteTemplateImage)
+	r.DELETE("/api/templates/:id", h.DeleteTemplate)
+
+	upload := func(username, filename string, data []byte, caption string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("image", filename)
+		part.Write(data)
+		form.WriteField("caption", caption)
+		form.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/templates/theme/images", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("X-Test-Username", username)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	remove := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, url, nil)
+		req.Header.Set("X-Test-Username", "alice")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The type comes from the content, not the file name
+	if w := upload("alice", "screenshot.png", []byte("<svg onload=alert(1)>"), ""); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status 415 for a disguised SVG, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := upload("bob", "screenshot.png", testPNG(t, 4, 3), ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another user, got %d", w.Code)
+	}
+
+	w := upload("alice", "screenshot.dat", testPNG(t, 4, 3), "Dark theme")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Image models.TemplateImage `json:"image"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	first := created.Image
+	if first.ContentType != images.PNG || first.Width != 4 || first.Height != 3 || first.Caption != "Dark theme" {
+		t.Errorf("Expected a 4x3 PNG with its caption, got %+v", first)
+	}
+
+	if w := upload("alice", "again.png", testPNG(t, 4, 3), ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for the same image twice, got %d", w.Code)
+	}
+	for _, width := range []int{5, 6} {
+		if w := upload("alice", "more.png", testPNG(t, width, 3), ""); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	if w := upload("alice", "fourth.png", testPNG(t, 7, 3), ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 past 3 images, got %d", w.Code)
+	}
+
+	stored, _ := repo.GetByID(ctx, "theme")
+	if len(stored.Images) != 3 || stored.Images[0].ID != first.ID {
+		t.Fatalf("Expected 3 images in upload order, got %+v", stored.Images)
+	}
+	files := func() []os.DirEntry {
+		dirs, _ := os.ReadDir(config.Dir)
+		if len(dirs) == 0 {
+			return nil
+		}
+		entries, _ := os.ReadDir(filepath.Join(config.Dir, dirs[0].Name()))
+		return entries
+	}
+	if got := len(files()); got != 3 {
+		t.Errorf("Expected 3 stored files, got %d", got)
+	}
+
+	if w := remove("/api/templates/theme/images/" + first.ID); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 removing an image, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := len(files()); got != 2 {
+		t.Errorf("Expected the removed image's file to be deleted, got %d files", got)
+	}
+	if w := remove("/api/templates/theme/images/" + first.ID); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a removed image, got %d", w.Code)
+	}
+
+	// Deleting the template removes the rest of its files
+	if w := remove("/api/templates/theme"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 deleting the template, got %d: %s", w.Code, w.Body.String())
+	}
+	if entries, _ := os.ReadDir(config.Dir); len(entries) != 0 {
+		t.Errorf("Expected no files left after deleting the template, got %v", entries)
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InviteSender delivers an organization invite to the invited email
+type InviteSender interface {
+	SendInvite(ctx context.Context, org *models.Organization, invite *models.OrganizationInvite) error
+}
+
+// LogInviteSender writes invites to the server log. It is the default until
+// an email delivery channel is configured.
+type LogInviteSender struct{}
+
+// SendInvite logs the invite that would be emailed
+func (LogInviteSender) SendInvite(ctx context.Context, org *models.Organization, invite *models.OrganizationInvite) error {
+	log.Printf("Invite to %s for %s expires %s", org.Slug, invite.Email, invite.ExpiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// ResendInvite handles regenerating a pending invite's token, extending its
+// expiry and sending it again (organization owners and admins)
+func (h *OrganizationHandler) ResendInvite(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return
+	}
+
+	if _, ok := h.requireInviteManager(c, org); !ok {
+		return
+	}
+
+	token, err := generateInviteToken(h.tokenBytes)
+	if err != nil {
+		respondInternalError(c, "Failed to generate invite token", err)
+		return
+	}
+
+	invite, err := h.orgRepo.RenewInvite(c.Request.Context(), org.ID, c.Param("id"), token, time.Now().Add(inviteTTL))
+	if err != nil {
+		if stderrors.Is(err, repository.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": errors.NewConflictError("Another invite is already pending for this email"),
+			})
+			return
+		}
+		respondMembershipError(c, err, "Failed to renew invite")
+		return
+	}
+	// Only the hash is stored, so this response is the one chance to see it
+	invite.Token = token
+
+	if err := h.inviteSender.SendInvite(c.Request.Context(), org, invite); err != nil {
+		respondInternalError(c, "Failed to send invite", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invite":  invite,
+		"message": "Invite resent successfully",
+	})
+}
+
+// requireInviteManager checks that the caller is an owner or admin of the
+// organization, responding 403 otherwise. It returns the caller's role.
+func (h *OrganizationHandler) requireInviteManager(c *gin.Context, org *models.Organization) (string, bool) {
+	role, err := h.memberRole(c.Request.Context(), org, c.GetString("user_id"))
+	if err != nil {
+		respondInternalError(c, "Failed to get organization member", err)
+		return "", false
+	}
+
+	if role != models.RoleOwner && role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("Only organization owners and admins can invite members"),
+		})
+		return "", false
+	}
+
+	return role, true
+}
+
+// requireInviteRecipient checks that the invite named by the :token
+// parameter was sent to the user's email, so a leaked token cannot be
+// redeemed by someone else. Responds 404 for unknown tokens and 403 for a
+// different email.
+func (h *OrganizationHandler) requireInviteRecipient(c *gin.Context, userID string) bool {
+	invite, err := h.orgRepo.GetInvite(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		respondInternalError(c, "Failed to get invite", err)
+		return false
+	}
+	if invite == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("Invite")})
+		return false
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "Failed to get user", err)
+		return false
+	}
+	if user == nil || user.IsDeleted() || !strings.EqualFold(strings.TrimSpace(user.Email), invite.Email) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("This invite was sent to a different email address"),
+		})
+		return false
+	}
+
+	return true
+}
+
+// respondInvitePending reports a conflict with an email's active invite,
+// including when that invite expires so the caller can decide to resend it
+func (h *OrganizationHandler) respondInvitePending(c *gin.Context, org *models.Organization, email string) {
+	response := gin.H{
+		"error": errors.NewConflictError("An invite is already pending for this email"),
+	}
+
+	active, err := h.orgRepo.GetActiveInvite(c.Request.Context(), org.ID, email)
+	if err != nil {
+		respondInternalError(c, "Failed to get invite", err)
+		return
+	}
+	if active != nil {
+		response["invite_id"] = active.ID
+		response["expires_at"] = active.ExpiresAt
+	}
+
+	c.JSON(http.StatusConflict, response)
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"log"
+	"net/http"
+	"time"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/pagination"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// JoinRequestNotifier tells users how their request to join an
+// organization was resolved
+type JoinRequestNotifier interface {
+	NotifyJoinRequestResolved(ctx context.Context, org *models.Organization, request *models.OrganizationJoinRequest) error
+}
+
+// LogJoinRequestNotifier writes resolved join requests to the server log. It
+// is the default until a delivery channel such as email is configured.
+type LogJoinRequestNotifier struct{}
+
+// NotifyJoinRequestResolved logs the resolution a requester would be sent
+func (LogJoinRequestNotifier) NotifyJoinRequestResolved(ctx context.Context, org *models.Organization, request *models.OrganizationJoinRequest) error {
+	log.Printf("Join request %s by %s to %s %s", request.ID, request.Username, org.Slug, request.Status)
+	return nil
+}
+
+// CreateJoinRequest handles a user asking to join a public organization that
+// accepts join requests. A user may hold one pending request per
+// organization.
+func (h *OrganizationHandler) CreateJoinRequest(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
+	}
+
+	// The message is optional, so an empty body is fine
+	var req dto.CreateJoinRequestRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+	if appErr := req.Validate(); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return
+	}
+	if !org.Public {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("Organization")})
+		return
+	}
+	if !org.AllowJoinRequests {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("This organization does not accept join requests"),
+		})
+		return
+	}
+
+	role, err := h.memberRole(c.Request.Context(), org, userID)
+	if err != nil {
+		respondInternalError(c, "Failed to get organization member", err)
+		return
+	}
+	if role != "" {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": errors.NewConflictError("You are already a member of this organization"),
+		})
+		return
+	}
+
+	request := &models.OrganizationJoinRequest{
+		ID:             uuid.New().String(),
+		OrganizationID: org.ID,
+		UserID:         userID,
+		Username:       c.GetString("username"),
+		Message:        req.Message,
+	}
+	if err := h.orgRepo.CreateJoinRequest(c.Request.Context(), request); err != nil {
+		if stderrors.Is(err, repository.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": errors.NewConflictError("You already have a pending request to join this organization"),
+			})
+			return
+		}
+		respondInternalError(c, "Failed to create join request", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"join_request": request,
+		"message":      "Join request sent",
+	})
+}
+
+// GetJoinRequests handles listing an organization's join requests, pending
+// ones unless ?status says otherwise (organization owners and admins)
+func (h *OrganizationHandler) GetJoinRequests(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	status := c.DefaultQuery("status", models.JoinRequestPending)
+	if status != models.JoinRequestPending && status != models.JoinRequestApproved && status != models.JoinRequestRejected {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("status must be one of pending, approved, rejected"),
+		})
+		return
+	}
+
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return
+	}
+	if !h.requireOrganizationManager(c, org, "Only organization owners and admins can view join requests") {
+		return
+	}
+
+	limit, offset := parsePagination(c)
+	requests, err := h.orgRepo.ListJoinRequests(c.Request.Context(), org.ID, status, limit, offset)
+	if err != nil {
+		respondInternalError(c, "Failed to list join requests", err)
+		return
+	}
+	total, err := h.orgRepo.CountJoinRequests(c.Request.Context(), org.ID, status)
+	if err != nil {
+		respondInternalError(c, "Failed to count join requests", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"join_requests": requests,
+		"limit":         limit,
+		"offset":        offset,
+		"total":         total,
+		"links":         pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, total),
+	})
+}
+
+// ApproveJoinRequest handles approving a pending join request, adding the
+// requester as a member and notifying them (organization owners and admins)
+func (h *OrganizationHandler) ApproveJoinRequest(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	org, request, ok := h.loadPendingJoinRequest(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	member := &models.OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         request.UserID,
+		Role:           models.RoleMember,
+	}
+	// A requester who joined some other way meanwhile, such as through an
+	// invite, still has their request approved
+	if err := h.orgRepo.AddMember(ctx, member); err != nil && !stderrors.Is(err, repository.ErrAlreadyExists) {
+		respondMembershipError(c, err, "Failed to add member")
+		return
+	}
+	if err := h.membershipsChanged(ctx, request.UserID); err != nil {
+		log.Printf("Failed to record membership change for user %s: %v", request.UserID, err)
+	}
+
+	h.resolveJoinRequest(c, org, request, models.JoinRequestApproved, "")
+}
+
+// RejectJoinRequest handles rejecting a pending join request with an
+// optional reason, notifying the requester (organization owners and admins)
+func (h *OrganizationHandler) RejectJoinRequest(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	// The reason is optional, so an empty body is fine
+	var req dto.RejectJoinRequestRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+	if appErr := req.Validate(); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	org, request, ok := h.loadPendingJoinRequest(c)
+	if !ok {
+		return
+	}
+
+	h.resolveJoinRequest(c, org, request, models.JoinRequestRejected, req.Reason)
+}
+
+// SetJoinRequests handles turning an organization's join requests on or off
+// (organization owners and admins)
+func (h *OrganizationHandler) SetJoinRequests(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	var req dto.SetJoinRequestsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return
+	}
+	if !h.requireOrganizationManager(c, org, "Only organization owners and admins can change join requests") {
+		return
+	}
+
+	if err := h.orgRepo.SetAllowJoinRequests(c.Request.Context(), org.ID, *req.AllowJoinRequests); err != nil {
+		respondInternalError(c, "Failed to update join requests", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"slug":                org.Slug,
+		"allow_join_requests": *req.AllowJoinRequests,
+		"message":             "Join requests updated successfully",
+	})
+}
+
+// loadPendingJoinRequest loads the organization and the join request named
+// by the :id parameter, checking that the caller manages the organization
+// and that the request is still pending
+func (h *OrganizationHandler) loadPendingJoinRequest(c *gin.Context) (*models.Organization, *models.OrganizationJoinRequest, bool) {
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return nil, nil, false
+	}
+	if !h.requireOrganizationManager(c, org, "Only organization owners and admins can resolve join requests") {
+		return nil, nil, false
+	}
+
+	request, err := h.orgRepo.GetJoinRequest(c.Request.Context(), org.ID, c.Param("id"))
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "Failed to get join request", err)
+		return nil, nil, false
+	}
+	if request == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("Join request")})
+		return nil, nil, false
+	}
+	if request.Status != models.JoinRequestPending {
+		respondJoinRequestResolved(c)
+		return nil, nil, false
+	}
+
+	return org, request, true
+}
+
+// resolveJoinRequest records the resolution and notifies the requester. The
+// request stays resolved even if the notification fails to send.
+func (h *OrganizationHandler) resolveJoinRequest(c *gin.Context, org *models.Organization, request *models.OrganizationJoinRequest, status, reason string) {
+	ctx := c.Request.Context()
+	now := time.Now()
+	resolvedBy := c.GetString("username")
+
+	resolved, err := h.orgRepo.ResolveJoinRequest(ctx, org.ID, request.ID, status, resolvedBy, reason, now)
+	if err != nil {
+		respondInternalError(c, "Failed to resolve join request", err)
+		return
+	}
+	if !resolved {
+		respondJoinRequestResolved(c)
+		return
+	}
+
+	request.Status = status
+	request.ResolvedBy = resolvedBy
+	request.ResolvedAt = &now
+	request.Reason = reason
+
+	if err := h.joinRequestNotifier.NotifyJoinRequestResolved(ctx, org, request); err != nil {
+		log.Printf("Failed to notify %s of join request %s: %v", request.Username, request.ID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"join_request": request,
+		"message":      "Join request " + status,
+	})
+}
+
+func respondJoinRequestResolved(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error": errors.NewConflictError("Join request is already resolved"),
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"dotfiles-api/internal/integrity"
+	"dotfiles-api/internal/reconcile"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceHandler runs site maintenance jobs on demand for site admins
+type MaintenanceHandler struct {
+	sweeper    *integrity.Sweeper
+	reconciler *reconcile.Reconciler
+}
+
+func NewMaintenanceHandler(sweeper *integrity.Sweeper, reconciler *reconcile.Reconciler) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		sweeper:    sweeper,
+		reconciler: reconciler,
+	}
+}
+
+// RunIntegritySweep finds reviews, favorites and organization memberships
+// pointing at templates or users that no longer exist, and removes them
+// unless ?dry_run=true
+func (h *MaintenanceHandler) RunIntegritySweep(c *gin.Context) {
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.sweeper.Run(c.Request.Context(), dryRun)
+	if report == nil {
+		respondInternalError(c, "Failed to run integrity sweep", err)
+		return
+	}
+	if err != nil {
+		// The scan finished, so the counts are still worth returning
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  errors.NewInternalError("Some orphaned records could not be removed", err),
+			"report": report,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// ReconcileCounters recounts denormalized counters, such as organization
+// member counts, from their records and corrects those that drifted unless
+// ?dry_run=true
+func (h *MaintenanceHandler) ReconcileCounters(c *gin.Context) {
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.reconciler.Run(c.Request.Context(), dryRun)
+	if err != nil {
+		respondInternalError(c, "Failed to reconcile counters", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// parseDryRun reads the dry_run query parameter, writing a 400 response and
+// returning false when it is not a boolean
+func parseDryRun(c *gin.Context) (bool, bool) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("dry_run must be true or false"),
+		})
+		return false, false
+	}
+	return dryRun, true
+}
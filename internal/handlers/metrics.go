@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"dotfiles-api/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricsContentType is the Prometheus text exposition format
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsHandler serves operational metrics for scraping
+type MetricsHandler struct {
+	sessions *auth.SessionManager
+	now      func() time.Time
+}
+
+func NewMetricsHandler(sessions *auth.SessionManager) *MetricsHandler {
+	return &MetricsHandler{
+		sessions: sessions,
+		now:      time.Now,
+	}
+}
+
+// GetMetrics reports session counts and ages in the Prometheus text format.
+// The age histogram describes the sessions active at scrape time, so its
+// buckets can shrink as sessions expire.
+func (h *MetricsHandler) GetMetrics(c *gin.Context) {
+	stats := h.sessions.Stats(h.now())
+
+	var b strings.Builder
+	b.WriteString("# HELP dotfiles_sessions_active Unexpired sessions.\n")
+	b.WriteString("# TYPE dotfiles_sessions_active gauge\n")
+	fmt.Fprintf(&b, "dotfiles_sessions_active %d\n", stats.Active)
+
+	b.WriteString("# HELP dotfiles_session_age_seconds Time since the active sessions were created.\n")
+	b.WriteString("# TYPE dotfiles_session_age_seconds histogram\n")
+	for i, bound := range auth.SessionAgeBuckets {
+		fmt.Fprintf(&b, "dotfiles_session_age_seconds_bucket{le=%q} %d\n", formatSeconds(bound), stats.AgeCounts[i])
+	}
+	fmt.Fprintf(&b, "dotfiles_session_age_seconds_bucket{le=\"+Inf\"} %d\n", stats.Active)
+	fmt.Fprintf(&b, "dotfiles_session_age_seconds_sum %s\n", formatSeconds(stats.AgeSum))
+	fmt.Fprintf(&b, "dotfiles_session_age_seconds_count %d\n", stats.Active)
+
+	b.WriteString("# HELP dotfiles_sessions_pruned_total Expired sessions removed.\n")
+	b.WriteString("# TYPE dotfiles_sessions_pruned_total counter\n")
+	fmt.Fprintf(&b, "dotfiles_sessions_pruned_total %d\n", stats.Pruned)
+
+	b.WriteString("# HELP dotfiles_sessions_evicted_total Unexpired sessions removed to stay within the session limit.\n")
+	b.WriteString("# TYPE dotfiles_sessions_evicted_total counter\n")
+	fmt.Fprintf(&b, "dotfiles_sessions_evicted_total %d\n", stats.Evicted)
+
+	c.Data(http.StatusOK, metricsContentType, []byte(b.String()))
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetMetrics(t *testing.T) {
+	sessionManager := auth.NewSessionManager(24 * time.Hour)
+	for _, user := range []string{"alice", "bob"} {
+		if _, err := sessionManager.CreateSession(user+"-id", user, user+"@example.com"); err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+
+	h := NewMetricsHandler(sessionManager)
+	// Two hours on, both sessions are past the first bucket but unexpired
+	h.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	r := gin.New()
+	r.GET("/metrics", h.GetMetrics)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != metricsContentType {
+		t.Errorf("Expected Content-Type %q, got %q", metricsContentType, got)
+	}
+
+	body := w.Body.String()
+	for _, line := range []string{
+		"dotfiles_sessions_active 2",
+		`dotfiles_session_age_seconds_bucket{le="3600"} 0`,
+		`dotfiles_session_age_seconds_bucket{le="21600"} 2`,
+		`dotfiles_session_age_seconds_bucket{le="+Inf"} 2`,
+		"dotfiles_session_age_seconds_count 2",
+		"dotfiles_sessions_pruned_total 0",
+		"dotfiles_sessions_evicted_total 0",
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected %q in:\n%s", line, body)
+		}
+	}
+
+	// A day later both sessions have expired and no longer count
+	h.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(w.Body.String(), "dotfiles_sessions_active 0\n") {
+		t.Errorf("Expected expired sessions to be left out, got:\n%s", w.Body.String())
+	}
+}
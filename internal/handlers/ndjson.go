@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"dotfiles-api/internal/middleware"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ndjsonContentType is the Accept value that switches list endpoints to
+// streaming one JSON object per line
+const ndjsonContentType = "application/x-ndjson"
+
+// wantsNDJSON reports whether the caller asked for a streamed NDJSON list
+func wantsNDJSON(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), ndjsonContentType)
+}
+
+// streamNDJSON writes each value iterate emits as one JSON line, flushing as
+// it goes so nothing is buffered beyond the current item. The status is
+// already sent when iterate fails, so the failure is reported as a final
+// {"error": ...} line instead.
+func streamNDJSON(c *gin.Context, iterate func(emit func(interface{}) error) error) {
+	c.Header("Content-Type", ndjsonContentType)
+	c.Status(http.StatusOK)
+
+	// The length of a stream is not known up front, so HEAD requests get
+	// the headers without running it
+	if middleware.IsHead(c) {
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		encoder := json.NewEncoder(w)
+		err := iterate(func(value interface{}) error {
+			if err := encoder.Encode(value); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+			return nil
+		})
+		if err != nil && c.Request.Context().Err() == nil {
+			log.Printf("NDJSON stream of %s failed: %v", c.Request.URL.Path, err)
+			_ = encoder.Encode(gin.H{"error": errors.NewInternalError("stream interrupted", err)})
+		}
+		return false
+	})
+}
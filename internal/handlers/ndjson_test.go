@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readNDJSON requests url with the NDJSON Accept header and decodes every
+// line of the streamed response
+func readNDJSON(t *testing.T, url string) []map[string]interface{} {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Expected an NDJSON content type, got %q", got)
+	}
+
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("Failed to decode line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Failed to read stream: %v", err)
+	}
+	return lines
+}
+
+func TestListTemplatesStreamsNDJSON(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTemplateRepository()
+
+	// More templates than the largest page, so paging would cut the export
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 150; i++ {
+		template := &models.StoredTemplate{
+			ID:       fmt.Sprintf("template-%03d", i),
+			Template: models.Template{Public: i%2 == 0, Metadata: models.ShareMetadata{Name: fmt.Sprintf("Template %d", i), Author: "exporter"}},
+		}
+		if err := repo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+		template.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+	}
+
+	// The author exports their private templates too
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("username", "exporter") })
+	r.GET("/api/templates", newTestTemplateHandler(repo).ListTemplates)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	lines := readNDJSON(t, server.URL+"/api/templates?author=exporter&limit=5&offset=3&sort_order=asc")
+	if len(lines) != 150 {
+		t.Fatalf("Expected every template regardless of limit and offset, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if want := fmt.Sprintf("template-%03d", i); line["id"] != want {
+			t.Fatalf("Line %d: expected %s in created_at order, got %v", i, want, line["id"])
+		}
+	}
+
+	lines = readNDJSON(t, server.URL+"/api/templates?author=exporter&public=true&fields=metadata.name")
+	if len(lines) != 75 {
+		t.Fatalf("Expected the filters to apply, got %d templates", len(lines))
+	}
+	if len(lines[0]) != 2 || lines[0]["metadata"] == nil {
+		t.Errorf("Expected only id and the selected fields, got %v", lines[0])
+	}
+}
+
+// failingIterateRepository fails an export after its first template
+type failingIterateRepository struct {
+	repository.TemplateRepository
+}
+
+func (r failingIterateRepository) Iterate(ctx context.Context, filters repository.TemplateFilters, fn func(*models.StoredTemplate) error) error {
+	if err := fn(&models.StoredTemplate{ID: "first"}); err != nil {
+		return err
+	}
+	return fmt.Errorf("cursor lost")
+}
+
+func TestListTemplatesStreamReportsFailure(t *testing.T) {
+	r := gin.New()
+	r.GET("/api/templates", newTestTemplateHandler(failingIterateRepository{memory.NewTemplateRepository()}).ListTemplates)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	lines := readNDJSON(t, server.URL+"/api/templates")
+	if len(lines) != 2 || lines[0]["id"] != "first" {
+		t.Fatalf("Expected the first template and an error line, got %v", lines)
+	}
+	appErr, ok := lines[1]["error"].(map[string]interface{})
+	if !ok || appErr["code"] != "INTERNAL_ERROR" {
+		t.Errorf("Expected an internal error line, got %v", lines[1])
+	}
+}
+
+func TestListUsersStreamsNDJSON(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+
+	for i, username := range []string{"alice", "bob", "carol"} {
+		user := &models.User{ID: username + "-id", Username: username, Email: username + "@example.com"}
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		user.CreatedAt = time.Date(2024, 1, 1+i, 0, 0, 0, 0, time.UTC)
+	}
+	if err := userRepo.Delete(ctx, "bob-id"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/api/admin/users", NewUserHandler(userRepo, memory.NewTemplateRepository(), memory.NewReviewRepository(), memory.NewOrganizationRepository()).ListUsers)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	lines := readNDJSON(t, server.URL+"/api/admin/users?limit=1")
+	if len(lines) != 2 {
+		t.Fatalf("Expected the two active users, got %v", lines)
+	}
+	if lines[0]["username"] != "carol" || lines[1]["username"] != "alice" || lines[1]["email"] != "alice@example.com" {
+		t.Errorf("Expected active users newest first with emails, got %v", lines)
+	}
+}
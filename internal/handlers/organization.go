@@ -1,12 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+	"log"
+	"net"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
+	"dotfiles-api/internal/dto"
 	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/pagination"
 	"dotfiles-api/internal/repository"
 	"dotfiles-api/pkg/errors"
 
@@ -16,13 +23,30 @@ import (
 
 // OrganizationHandler handles organization-related HTTP requests
 type OrganizationHandler struct {
-	orgRepo repository.OrganizationRepository
+	orgRepo             repository.OrganizationRepository
+	userRepo            repository.UserRepository
+	templateRepo        repository.TemplateRepository
+	inviteSender        InviteSender
+	joinRequestNotifier JoinRequestNotifier
+	tokenBytes          int
+	lookupTXT           func(ctx context.Context, name string) ([]string, error)
+	frontendURL         string
+	templateCounts      cachedCounts
+	primaryHosts        map[string]bool
+	domains             domainCache
 }
 
 // NewOrganizationHandler creates a new organization handler
-func NewOrganizationHandler(orgRepo repository.OrganizationRepository) *OrganizationHandler {
+func NewOrganizationHandler(orgRepo repository.OrganizationRepository, userRepo repository.UserRepository, templateRepo repository.TemplateRepository) *OrganizationHandler {
 	return &OrganizationHandler{
-		orgRepo: orgRepo,
+		orgRepo:             orgRepo,
+		userRepo:            userRepo,
+		templateRepo:        templateRepo,
+		inviteSender:        LogInviteSender{},
+		joinRequestNotifier: LogJoinRequestNotifier{},
+		tokenBytes:          defaultInviteTokenBytes,
+		lookupTXT:           net.DefaultResolver.LookupTXT,
+		templateCounts:      cachedCounts{ttl: orgTemplateCountTTL},
 	}
 }
 
@@ -55,17 +79,15 @@ func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
 	}
 
 	var req struct {
-		Name        string `json:"name" binding:"required"`
-		Slug        string `json:"slug" binding:"required"`
-		Description string `json:"description"`
-		Website     string `json:"website"`
-		Public      bool   `json:"public"`
+		Name              string `json:"name" binding:"required"`
+		Slug              string `json:"slug" binding:"required"`
+		Description       string `json:"description"`
+		Website           string `json:"website"`
+		Public            bool   `json:"public"`
+		AllowJoinRequests bool   `json:"allow_join_requests"`
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewValidationError("Invalid request format"),
-		})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -81,9 +103,7 @@ func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
 	// Check if slug already exists
 	existing, err := h.orgRepo.GetBySlug(c.Request.Context(), req.Slug)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to check existing organization", err),
-		})
+		respondInternalError(c, "Failed to check existing organization", err)
 		return
 	}
 
@@ -95,24 +115,45 @@ func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
 	}
 
 	org := &models.Organization{
-		ID:          uuid.New().String(),
-		Name:        req.Name,
-		Slug:        req.Slug,
-		Description: req.Description,
-		Website:     req.Website,
-		Public:      req.Public,
-		OwnerID:     userID.(string),
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:                uuid.New().String(),
+		Name:              req.Name,
+		Slug:              req.Slug,
+		Description:       req.Description,
+		Website:           req.Website,
+		Public:            req.Public,
+		AllowJoinRequests: req.AllowJoinRequests,
+		OwnerID:           userID.(string),
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
 	}
 
+	// The store enforces slug uniqueness too, catching a concurrent request
+	// that passed the check above
 	if err := h.orgRepo.Create(c.Request.Context(), org); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to create organization", err),
-		})
+		if stderrors.Is(err, repository.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": errors.NewConflictError("Organization slug already exists"),
+			})
+			return
+		}
+		respondInternalError(c, "Failed to create organization", err)
 		return
 	}
 
+	owner := &models.OrganizationMember{
+		OrganizationID: org.ID,
+		UserID:         org.OwnerID,
+		Role:           models.RoleOwner,
+	}
+	if err := h.orgRepo.AddMember(c.Request.Context(), owner); err != nil {
+		respondInternalError(c, "Failed to add organization owner", err)
+		return
+	}
+	if err := h.membershipsChanged(c.Request.Context(), owner.UserID); err != nil {
+		log.Printf("Failed to record membership change for user %s: %v", owner.UserID, err)
+	}
+	org.MemberCount = 1
+
 	c.JSON(http.StatusCreated, gin.H{
 		"organization": org,
 		"message":      "Organization created successfully",
@@ -126,27 +167,11 @@ func (h *OrganizationHandler) GetOrganizations(c *gin.Context) {
 		return
 	}
 
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
-	}
+	limit, offset := parsePagination(c)
 
 	orgs, err := h.orgRepo.List(c.Request.Context(), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to get organizations", err),
-		})
+		respondInternalError(c, "Failed to get organizations", err)
 		return
 	}
 
@@ -154,6 +179,7 @@ func (h *OrganizationHandler) GetOrganizations(c *gin.Context) {
 		"organizations": orgs,
 		"limit":         limit,
 		"offset":        offset,
+		"links":         pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, pagination.PageTotal(offset, limit, len(orgs))),
 	})
 }
 
@@ -174,20 +200,169 @@ func (h *OrganizationHandler) GetOrganizationBySlug(c *gin.Context) {
 
 	org, err := h.orgRepo.GetBySlug(c.Request.Context(), slug)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to get organization", err),
+		respondInternalError(c, "Failed to get organization", err)
+		return
+	}
+
+	if org == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": errors.NewNotFoundError("Organization"),
 		})
 		return
 	}
 
+	response := toOrganizationResponse(org)
+
+	// Seat usage is only visible to site admins and organization admins
+	canSeeSeats := c.GetBool("is_admin")
+	if !canSeeSeats {
+		if userID, exists := c.Get("user_id"); exists {
+			role, err := h.memberRole(c.Request.Context(), org, userID.(string))
+			if err != nil {
+				respondInternalError(c, "Failed to get organization member", err)
+				return
+			}
+			canSeeSeats = role == models.RoleOwner || role == models.RoleAdmin
+		}
+	}
+
+	if canSeeSeats {
+		pending, err := h.orgRepo.CountPendingInvites(c.Request.Context(), org.ID)
+		if err != nil {
+			respondInternalError(c, "Failed to count pending invites", err)
+			return
+		}
+		seatsUsed := org.MemberCount + pending
+		seatsTotal := org.MaxMembers
+		response.SeatsUsed = &seatsUsed
+		response.SeatsTotal = &seatsTotal
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// toOrganizationResponse maps an organization to its public response shape
+func toOrganizationResponse(org *models.Organization) dto.OrganizationResponse {
+	response := dto.OrganizationResponse{
+		ID:                org.ID,
+		Name:              org.Name,
+		Slug:              org.Slug,
+		Description:       org.Description,
+		Website:           org.Website,
+		OwnerID:           org.OwnerID,
+		Public:            org.Public,
+		CreatedAt:         org.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:         org.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		MemberCount:       org.MemberCount,
+		PinnedTemplateIDs: org.PinnedTemplateIDs,
+		AllowJoinRequests: org.AllowJoinRequests,
+	}
+
+	if org.DomainVerified() {
+		response.CustomDomain = org.CustomDomain
+	}
+
+	return response
+}
+
+// memberRole returns the user's role in the organization, or "" if the user
+// is not a member. The organization owner is always treated as an owner.
+func (h *OrganizationHandler) memberRole(ctx context.Context, org *models.Organization, userID string) (string, error) {
+	if org.OwnerID == userID {
+		return models.RoleOwner, nil
+	}
+
+	member, err := h.orgRepo.GetMember(ctx, org.ID, userID)
+	if err != nil || member == nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// loadOrganization fetches the organization named by the :slug parameter,
+// writing a 404 or 500 response and returning false when it cannot
+func (h *OrganizationHandler) loadOrganization(c *gin.Context) (*models.Organization, bool) {
+	org, err := h.orgRepo.GetBySlug(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		respondInternalError(c, "Failed to get organization", err)
+		return nil, false
+	}
+
 	if org == nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": errors.NewNotFoundError("Organization"),
 		})
+		return nil, false
+	}
+
+	return org, true
+}
+
+// requireOrganizationVisible writes a 404 and returns false when org is
+// private and the caller is neither a member nor a site admin
+func (h *OrganizationHandler) requireOrganizationVisible(c *gin.Context, org *models.Organization) bool {
+	if org.Public || c.GetBool("is_admin") {
+		return true
+	}
+
+	role, err := h.memberRole(c.Request.Context(), org, c.GetString("user_id"))
+	if err != nil {
+		respondInternalError(c, "Failed to get organization member", err)
+		return false
+	}
+	if role == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("Organization")})
+		return false
+	}
+	return true
+}
+
+// respondMembershipError maps repository membership errors to API errors
+func respondMembershipError(c *gin.Context, err error, fallback string) {
+	switch {
+	case stderrors.Is(err, repository.ErrOrganizationFull):
+		c.JSON(http.StatusForbidden, gin.H{"error": errors.NewForbiddenError("organization is full")})
+	case stderrors.Is(err, repository.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, gin.H{"error": errors.NewConflictError("User is already a member of this organization")})
+	case stderrors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("Invite")})
+	default:
+		respondInternalError(c, fallback, err)
+	}
+}
+
+// SetMaxMembers handles changing an organization's member limit (site admins only)
+func (h *OrganizationHandler) SetMaxMembers(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	var req dto.SetMaxMembersRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if appErr := req.Validate(); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
 		return
 	}
 
-	c.JSON(http.StatusOK, org)
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return
+	}
+
+	if err := h.orgRepo.SetMaxMembers(c.Request.Context(), org.ID, *req.MaxMembers); err != nil {
+		respondInternalError(c, "Failed to update member limit", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"slug":        org.Slug,
+		"max_members": *req.MaxMembers,
+		"message":     "Member limit updated successfully",
+	})
 }
 
 // UpdateOrganization handles updating an organization
@@ -227,27 +402,336 @@ func (h *OrganizationHandler) InviteMember(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "not implemented yet"})
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
+	}
+
+	var req dto.InviteUserRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if appErr := req.Validate(); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return
+	}
+
+	inviterRole, ok := h.requireInviteManager(c, org)
+	if !ok {
+		return
+	}
+
+	if req.Role == models.RoleAdmin && inviterRole != models.RoleOwner {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("Only organization owners can invite admins"),
+		})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	member, err := h.isMemberEmail(c.Request.Context(), org, email)
+	if err != nil {
+		respondInternalError(c, "Failed to check organization membership", err)
+		return
+	}
+	if member {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": errors.NewConflictError("User is already a member of this organization"),
+		})
+		return
+	}
+
+	token, err := generateInviteToken(h.tokenBytes)
+	if err != nil {
+		respondInternalError(c, "Failed to generate invite token", err)
+		return
+	}
+
+	invite := &models.OrganizationInvite{
+		ID:             uuid.New().String(),
+		OrganizationID: org.ID,
+		Email:          email,
+		Role:           req.Role,
+		Token:          token,
+		InvitedBy:      userID.(string),
+		ExpiresAt:      time.Now().Add(inviteTTL),
+	}
+
+	if err := h.orgRepo.CreateInvite(c.Request.Context(), invite); err != nil {
+		if stderrors.Is(err, repository.ErrAlreadyExists) {
+			h.respondInvitePending(c, org, email)
+			return
+		}
+		respondMembershipError(c, err, "Failed to create invite")
+		return
+	}
+
+	// The invite stands even if delivery fails; it can be resent
+	if err := h.inviteSender.SendInvite(c.Request.Context(), org, invite); err != nil {
+		log.Printf("Failed to send invite %s: %v", invite.ID, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"invite":  invite,
+		"message": "Invite created successfully",
+	})
 }
 
-// RemoveMember handles removing a member from organization
+// isMemberEmail reports whether the email belongs to a user who is already
+// a member of the organization
+func (h *OrganizationHandler) isMemberEmail(ctx context.Context, org *models.Organization, email string) (bool, error) {
+	if h.userRepo == nil {
+		return false, nil
+	}
+
+	user, err := h.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		// Stores disagree on whether an unknown email is an error
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if user == nil {
+		return false, nil
+	}
+
+	return h.orgRepo.IsMember(ctx, org.ID, user.ID)
+}
+
+// inviteTTL is how long an organization invite stays valid
+const inviteTTL = 7 * 24 * time.Hour
+
+const (
+	// defaultInviteTokenBytes is how much randomness an invite token
+	// carries unless configured otherwise
+	defaultInviteTokenBytes = 32
+	// minInviteTokenBytes keeps configured tokens too long to guess
+	minInviteTokenBytes = 16
+)
+
+// ConfigureInviteTokens sets how many random bytes new invite tokens carry,
+// never fewer than minInviteTokenBytes
+func (h *OrganizationHandler) ConfigureInviteTokens(tokenBytes int) {
+	if tokenBytes < minInviteTokenBytes {
+		tokenBytes = minInviteTokenBytes
+	}
+	h.tokenBytes = tokenBytes
+}
+
+// generateInviteToken generates a cryptographically secure, hex-encoded
+// invite token from n random bytes
+func generateInviteToken(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// RemoveMember handles removing a member from an organization. Owners and
+// admins may remove members, only owners may remove admins, and any member
+// may remove themselves; the organization owner cannot be removed.
 func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
 	if !h.isAvailable() {
 		h.handleUnavailable(c)
 		return
 	}
 
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "not implemented yet"})
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return
+	}
+
+	member, ok := h.loadTargetMember(c, org)
+	if !ok {
+		return
+	}
+
+	if member.UserID != c.GetString("user_id") && !h.requireOrganizationManager(c, org, "Only organization owners and admins can remove members") {
+		return
+	}
+
+	// Admins may only leave on their own; removing one is the owner's call
+	if member.UserID != c.GetString("user_id") && member.Role == models.RoleAdmin &&
+		!h.requireOrganizationOwner(c, org, "Only organization owners can remove admins") {
+		return
+	}
+
+	if err := h.orgRepo.RemoveMember(c.Request.Context(), org.ID, member.UserID); err != nil {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("Member")})
+			return
+		}
+		respondInternalError(c, "Failed to remove member", err)
+		return
+	}
+
+	// Cached roles must not outlive the membership
+	if err := h.membershipsChanged(c.Request.Context(), member.UserID); err != nil {
+		respondInternalError(c, "Failed to record membership change", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member removed successfully",
+	})
 }
 
-// UpdateMemberRole handles updating member role
+// UpdateMemberRole handles changing a member's role (organization owners and
+// admins; only owners may grant or revoke the admin role). The owner's role
+// cannot be changed, and ownership cannot be handed over this way.
 func (h *OrganizationHandler) UpdateMemberRole(c *gin.Context) {
 	if !h.isAvailable() {
 		h.handleUnavailable(c)
 		return
 	}
 
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "not implemented yet"})
+	var req dto.UpdateMemberRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if appErr := req.Validate(); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+	if req.Role == models.RoleOwner {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("role must be admin or member; ownership cannot be transferred"),
+		})
+		return
+	}
+
+	org, ok := h.loadOrganization(c)
+	if !ok {
+		return
+	}
+
+	if !h.requireOrganizationManager(c, org, "Only organization owners and admins can change member roles") {
+		return
+	}
+
+	member, ok := h.loadTargetMember(c, org)
+	if !ok {
+		return
+	}
+
+	// Like inviting admins, granting or revoking the admin role is the
+	// owner's call
+	if (req.Role == models.RoleAdmin || member.Role == models.RoleAdmin) &&
+		!h.requireOrganizationOwner(c, org, "Only organization owners can grant or revoke the admin role") {
+		return
+	}
+
+	if err := h.orgRepo.UpdateMemberRole(c.Request.Context(), org.ID, member.UserID, req.Role); err != nil {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("Member")})
+			return
+		}
+		respondInternalError(c, "Failed to update member role", err)
+		return
+	}
+
+	if err := h.membershipsChanged(c.Request.Context(), member.UserID); err != nil {
+		respondInternalError(c, "Failed to record membership change", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member role updated successfully",
+		"role":    req.Role,
+	})
+}
+
+// loadTargetMember fetches the membership of the user named by the
+// :username parameter, writing a 404 when there is none. The organization
+// owner's membership is refused with a 403, as it cannot be changed.
+func (h *OrganizationHandler) loadTargetMember(c *gin.Context, org *models.Organization) (*models.OrganizationMember, bool) {
+	user, err := h.userRepo.GetByUsername(c.Request.Context(), c.Param("username"))
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "Failed to get user", err)
+		return nil, false
+	}
+
+	var member *models.OrganizationMember
+	if user != nil {
+		member, err = h.orgRepo.GetMember(c.Request.Context(), org.ID, user.ID)
+		if err != nil && !isNotFound(err) {
+			respondInternalError(c, "Failed to get organization member", err)
+			return nil, false
+		}
+	}
+	if member == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": errors.NewNotFoundError("Member"),
+		})
+		return nil, false
+	}
+
+	if member.UserID == org.OwnerID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("The organization owner's membership cannot be changed"),
+		})
+		return nil, false
+	}
+
+	return member, true
+}
+
+// requireOrganizationManager checks that the caller is a site admin or an
+// owner or admin of the organization, responding 403 with message otherwise
+func (h *OrganizationHandler) requireOrganizationManager(c *gin.Context, org *models.Organization, message string) bool {
+	if c.GetBool("is_admin") {
+		return true
+	}
+
+	role, err := h.memberRole(c.Request.Context(), org, c.GetString("user_id"))
+	if err != nil {
+		respondInternalError(c, "Failed to get organization member", err)
+		return false
+	}
+
+	if role != models.RoleOwner && role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError(message),
+		})
+		return false
+	}
+
+	return true
+}
+
+// requireOrganizationOwner checks that the caller owns the organization or
+// is a site admin, writing a 403 with message when they are not
+func (h *OrganizationHandler) requireOrganizationOwner(c *gin.Context, org *models.Organization, message string) bool {
+	if c.GetBool("is_admin") || org.OwnerID == c.GetString("user_id") {
+		return true
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": errors.NewForbiddenError(message),
+	})
+	return false
+}
+
+// membershipsChanged bumps the user's membership version so organization
+// roles cached in their sessions are reloaded. Users that no longer exist
+// have nothing to invalidate.
+func (h *OrganizationHandler) membershipsChanged(ctx context.Context, userID string) error {
+	if err := h.userRepo.BumpMembershipVersion(ctx, userID); err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
 }
 
 // GetOrganizationInvites handles getting organization invites
@@ -267,5 +751,28 @@ func (h *OrganizationHandler) AcceptInvite(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusNotImplemented, gin.H{"error": "not implemented yet"})
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
+	}
+
+	if !h.requireInviteRecipient(c, userID.(string)) {
+		return
+	}
+
+	if err := h.orgRepo.AcceptInvite(c.Request.Context(), c.Param("token"), userID.(string)); err != nil {
+		respondMembershipError(c, err, "Failed to accept invite")
+		return
+	}
+
+	if err := h.membershipsChanged(c.Request.Context(), userID.(string)); err != nil {
+		log.Printf("Failed to record membership change for user %s: %v", userID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Invite accepted successfully",
+	})
 }
@@ -0,0 +1,931 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCustomDomainVerificationScopesListing(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := memory.NewOrganizationRepository()
+	templateRepo := memory.NewTemplateRepository()
+
+	for _, org := range []*models.Organization{
+		{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "owner-id"},
+		{ID: "org-other", Name: "Other", Slug: "other", OwnerID: "other-id"},
+	} {
+		if err := orgRepo.Create(ctx, org); err != nil {
+			t.Fatalf("Failed to create organization: %v", err)
+		}
+	}
+
+	for _, template := range []*models.StoredTemplate{
+		{ID: "acme-public", Template: models.Template{Public: true, OrganizationID: "org-acme"}},
+		{ID: "acme-private", Template: models.Template{OrganizationID: "org-acme"}},
+		{ID: "community", Template: models.Template{Public: true}},
+	} {
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	txtRecords := map[string][]string{}
+	orgHandler := NewOrganizationHandler(orgRepo, memory.NewUserRepository(), templateRepo)
+	orgHandler.lookupTXT = func(ctx context.Context, name string) ([]string, error) {
+		records, ok := txtRecords[name]
+		if !ok {
+			return nil, fmt.Errorf("no such host")
+		}
+		return records, nil
+	}
+	templateHandler := NewTemplateHandler(templateRepo, memory.NewTagRepository(), memory.NewUserRepository(), memory.NewReviewRepository(), tags.NewRegistry(), NewAuthorizer(orgRepo))
+
+	r := gin.New()
+	r.Use(withTestUser(), func(c *gin.Context) {
+		c.Set("is_admin", c.GetHeader("X-Test-Admin") == "true")
+	}, orgHandler.CustomDomain())
+	r.PUT("/api/admin/organizations/:slug/domain", orgHandler.SetCustomDomain)
+	r.GET("/api/organizations/:slug/domain", orgHandler.GetCustomDomain)
+	r.POST("/api/organizations/:slug/domain/verify", orgHandler.VerifyCustomDomain)
+	r.GET("/api/templates", templateHandler.ListTemplates)
+
+	send := func(method, url, host, userID string, admin bool, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if host != "" {
+			req.Host = host
+		}
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+		}
+		if admin {
+			req.Header.Set("X-Test-Admin", "true")
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPut, "/api/admin/organizations/acme/domain", "", "admin-id", true, `{"domain": "Dotfiles.Acme.Example."}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	challenge := decodeBody(t, w)["challenge"].(map[string]interface{})
+	if challenge["name"] != "_dotfiles-challenge.dotfiles.acme.example" {
+		t.Errorf("Expected the challenge on the normalized domain, got %v", challenge["name"])
+	}
+
+	// Another organization cannot claim the same domain
+	w = send(http.MethodPut, "/api/admin/organizations/other/domain", "", "admin-id", true, `{"domain": "dotfiles.acme.example"}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a taken domain, got %d", w.Code)
+	}
+
+	w = send(http.MethodPut, "/api/admin/organizations/other/domain", "", "admin-id", true, `{"domain": "not a domain"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid domain, got %d", w.Code)
+	}
+
+	if w := send(http.MethodGet, "/api/organizations/acme/domain", "", "stranger-id", false, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a non-member, got %d", w.Code)
+	}
+
+	// Unverified domains do not scope listings
+	w = send(http.MethodGet, "/api/templates", "dotfiles.acme.example", "", false, "")
+	if ids := templateIDs(t, w); !slices.Contains(ids, "community") {
+		t.Errorf("Expected an unscoped listing before verification, got %v", ids)
+	}
+
+	// Verification fails until the TXT record is published
+	if w := send(http.MethodPost, "/api/organizations/acme/domain/verify", "", "owner-id", false, ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 without the TXT record, got %d: %s", w.Code, w.Body.String())
+	}
+
+	txtRecords[challenge["name"].(string)] = []string{"unrelated", challenge["value"].(string)}
+	w = send(http.MethodPost, "/api/organizations/acme/domain/verify", "", "owner-id", false, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := decodeBody(t, w); body["verified"] != true || body["challenge"] != nil {
+		t.Errorf("Expected a verified domain without a challenge, got %v", body)
+	}
+
+	w = send(http.MethodGet, "/api/templates", "dotfiles.acme.example:443", "", false, "")
+	if ids := templateIDs(t, w); !reflect.DeepEqual(ids, []string{"acme-public"}) {
+		t.Errorf("Expected only the organization's public templates, got %v", ids)
+	}
+
+	w = send(http.MethodGet, "/api/templates?public=false", "dotfiles.acme.example", "", false, "")
+	if ids := templateIDs(t, w); !reflect.DeepEqual(ids, []string{"acme-public"}) {
+		t.Errorf("Expected private templates to stay hidden on the custom domain, got %v", ids)
+	}
+
+	w = send(http.MethodGet, "/api/templates", "api.example.com", "", false, "")
+	if ids := templateIDs(t, w); !slices.Contains(ids, "community") {
+		t.Errorf("Expected other hosts to stay unscoped, got %v", ids)
+	}
+}
+
+// countingDomainRepository counts custom domain lookups, failing them while
+// err is set
+type countingDomainRepository struct {
+	repository.OrganizationRepository
+	lookups int
+	err     error
+}
+
+func (r *countingDomainRepository) GetByCustomDomain(ctx context.Context, domain string) (*models.Organization, error) {
+	r.lookups++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.OrganizationRepository.GetByCustomDomain(ctx, domain)
+}
+
+func TestCustomDomainLookupsAreCached(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := &countingDomainRepository{OrganizationRepository: memory.NewOrganizationRepository()}
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "owner-id"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	if err := orgRepo.SetCustomDomain(ctx, "org-acme", "dotfiles.acme.example", "token"); err != nil {
+		t.Fatalf("Failed to set custom domain: %v", err)
+	}
+	if err := orgRepo.VerifyCustomDomain(ctx, "org-acme", "dotfiles.acme.example"); err != nil {
+		t.Fatalf("Failed to verify custom domain: %v", err)
+	}
+
+	handler := NewOrganizationHandler(orgRepo, memory.NewUserRepository(), memory.NewTemplateRepository())
+	handler.ConfigurePrimaryHosts([]string{"API.example.com:443"})
+
+	r := gin.New()
+	r.Use(handler.CustomDomain())
+	r.GET("/api/scope", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(domainOrgKey))
+	})
+
+	send := func(host string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/scope", nil)
+		req.Host = host
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The API's own host never costs a lookup
+	if w := send("api.example.com"); w.Code != http.StatusOK || w.Body.String() != "" || orgRepo.lookups != 0 {
+		t.Errorf("Expected the primary host to skip the lookup, got %d %q after %d lookups", w.Code, w.Body.String(), orgRepo.lookups)
+	}
+
+	// Both the custom domain and unknown hosts are looked up once
+	for i := 0; i < 3; i++ {
+		if w := send("dotfiles.acme.example"); w.Body.String() != "org-acme" {
+			t.Errorf("Expected the custom domain to scope to org-acme, got %q", w.Body.String())
+		}
+		if w := send("unknown.example"); w.Body.String() != "" {
+			t.Errorf("Expected an unknown host to stay unscoped, got %q", w.Body.String())
+		}
+	}
+	if orgRepo.lookups != 2 {
+		t.Errorf("Expected 2 lookups, got %d", orgRepo.lookups)
+	}
+
+	// A failed lookup serves the request unscoped and is not cached
+	orgRepo.err = fmt.Errorf("connection refused")
+	if w := send("other.example"); w.Code != http.StatusOK || w.Body.String() != "" {
+		t.Errorf("Expected an unscoped 200 when the lookup fails, got %d %q", w.Code, w.Body.String())
+	}
+	orgRepo.err = nil
+	send("other.example")
+	if orgRepo.lookups != 4 {
+		t.Errorf("Expected the failed lookup to be retried, got %d lookups", orgRepo.lookups)
+	}
+}
+
+// recordingInviteSender remembers the tokens of every invite sent
+type recordingInviteSender struct {
+	tokens []string
+}
+
+func (s *recordingInviteSender) SendInvite(ctx context.Context, org *models.Organization, invite *models.OrganizationInvite) error {
+	s.tokens = append(s.tokens, invite.Token)
+	return nil
+}
+
+func TestInviteConflictsAndResend(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := memory.NewOrganizationRepository()
+	userRepo := memory.NewUserRepository()
+
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "owner-id"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	if err := userRepo.Create(ctx, &models.User{ID: "member-id", Username: "member", Email: "member@example.com"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if err := orgRepo.AddMember(ctx, &models.OrganizationMember{OrganizationID: "org-acme", UserID: "member-id", Role: models.RoleMember}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	sender := &recordingInviteSender{}
+	handler := NewOrganizationHandler(orgRepo, userRepo, memory.NewTemplateRepository())
+	handler.inviteSender = sender
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/organizations/:slug/members", handler.InviteMember)
+	r.POST("/api/organizations/:slug/invites/:id/resend", handler.ResendInvite)
+
+	send := func(url, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	invite := func(email string) *httptest.ResponseRecorder {
+		return send("/api/organizations/acme/members", "owner-id", `{"email": "`+email+`", "role": "member"}`)
+	}
+
+	w := invite("Member@Example.com")
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "already a member") {
+		t.Errorf("Expected 409 already a member, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = invite("new@example.com")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	created := decodeBody(t, w)["invite"].(map[string]interface{})
+
+	w = invite("NEW@example.com")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409 for a pending invite, got %d: %s", w.Code, w.Body.String())
+	}
+	if body := decodeBody(t, w); body["expires_at"] != created["expires_at"] || body["invite_id"] != created["id"] {
+		t.Errorf("Expected the pending invite's ID and expiry, got %v", body)
+	}
+
+	resendURL := fmt.Sprintf("/api/organizations/acme/invites/%s/resend", created["id"])
+	if w := send(resendURL, "member-id", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for a plain member, got %d", w.Code)
+	}
+	if w := send("/api/organizations/acme/invites/missing/resend", "owner-id", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown invite, got %d", w.Code)
+	}
+
+	w = send(resendURL, "owner-id", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	resent := decodeBody(t, w)["invite"].(map[string]interface{})
+	if resent["token"] == created["token"] {
+		t.Error("Expected resending to regenerate the token")
+	}
+
+	want := []string{created["token"].(string), resent["token"].(string)}
+	if !reflect.DeepEqual(sender.tokens, want) {
+		t.Errorf("Expected the invite to be sent on creation and resend, got %v", sender.tokens)
+	}
+}
+
+// slugRaceRepository holds every slug check until all of them have run, so
+// concurrent creates all pass the handler's pre-check
+type slugRaceRepository struct {
+	*memory.OrganizationRepository
+	checks sync.WaitGroup
+}
+
+func (r *slugRaceRepository) GetBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	org, err := r.OrganizationRepository.GetBySlug(ctx, slug)
+	r.checks.Done()
+	r.checks.Wait()
+	return org, err
+}
+
+func TestConcurrentCreateOrganizationWithSameSlug(t *testing.T) {
+	const attempts = 2
+	orgRepo := &slugRaceRepository{OrganizationRepository: memory.NewOrganizationRepository()}
+	orgRepo.checks.Add(attempts)
+	handler := NewOrganizationHandler(orgRepo, memory.NewUserRepository(), memory.NewTemplateRepository())
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/organizations", handler.CreateOrganization)
+
+	codes := make([]int, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/api/organizations", strings.NewReader(`{"name": "Acme", "slug": "Acme"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Test-User", fmt.Sprintf("user-%d", i))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+		default:
+			t.Errorf("Expected status 201 or 409, got %d", code)
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected exactly one organization to be created, got %d (%v)", created, codes)
+	}
+}
+
+func TestInviteTokensAreSingleUse(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := memory.NewOrganizationRepository()
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "owner-id"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+
+	userRepo := memory.NewUserRepository()
+	if err := userRepo.Create(ctx, &models.User{ID: "first-id", Username: "first", Email: "First@Example.com"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	sender := &recordingInviteSender{}
+	handler := NewOrganizationHandler(orgRepo, userRepo, memory.NewTemplateRepository())
+	handler.inviteSender = sender
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/organizations/:slug/members", handler.InviteMember)
+	r.POST("/api/invites/:token/accept", handler.AcceptInvite)
+
+	send := func(url, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 32 random bytes by default, and never fewer than 16 when configured
+	if w := send("/api/organizations/acme/members", "owner-id", `{"email": "first@example.com", "role": "member"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	handler.ConfigureInviteTokens(8)
+	w := send("/api/organizations/acme/members", "owner-id", `{"email": "second@example.com", "role": "member"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(sender.tokens) != 2 || len(sender.tokens[0]) != 64 || len(sender.tokens[1]) != 32 {
+		t.Fatalf("Expected 64 and 32 hex character tokens, got %v", sender.tokens)
+	}
+	if token := decodeBody(t, w)["invite"].(map[string]interface{})["token"]; token != sender.tokens[1] {
+		t.Errorf("Expected the creator to receive the token, got %v", token)
+	}
+
+	// Racing accepts of the same token succeed exactly once
+	const attempts = 10
+	codes := make([]int, attempts)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			codes[i] = send("/api/invites/"+sender.tokens[0]+"/accept", "first-id", "").Code
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	accepted := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			accepted++
+		case http.StatusNotFound:
+		default:
+			t.Errorf("Expected status 200 or 404, got %d", code)
+		}
+	}
+	if accepted != 1 {
+		t.Errorf("Expected exactly one accept to succeed, got %d (%v)", accepted, codes)
+	}
+}
+
+func TestInviteRolesAndRecipients(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := memory.NewOrganizationRepository()
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "owner-id"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	if err := orgRepo.AddMember(ctx, &models.OrganizationMember{OrganizationID: "org-acme", UserID: "admin-id", Role: models.RoleAdmin}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	userRepo := memory.NewUserRepository()
+	for _, user := range []*models.User{
+		{ID: "invitee-id", Username: "invitee", Email: "invitee@example.com"},
+		{ID: "mallory-id", Username: "mallory", Email: "mallory@example.com"},
+	} {
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	sender := &recordingInviteSender{}
+	handler := NewOrganizationHandler(orgRepo, userRepo, memory.NewTemplateRepository())
+	handler.inviteSender = sender
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/organizations/:slug/members", handler.InviteMember)
+	r.POST("/api/invites/:token/accept", handler.AcceptInvite)
+
+	send := func(url, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name   string
+		userID string
+		body   string
+		want   int
+	}{
+		{"owner role", "owner-id", `{"email": "new-owner@example.com", "role": "owner"}`, http.StatusBadRequest},
+		{"admin invites admin", "admin-id", `{"email": "new-admin@example.com", "role": "admin"}`, http.StatusForbidden},
+		{"admin invites member", "admin-id", `{"email": "new-member@example.com", "role": "member"}`, http.StatusCreated},
+		{"owner invites admin", "owner-id", `{"email": "invitee@example.com", "role": "admin"}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		if w := send("/api/organizations/acme/members", tt.userID, tt.body); w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+	if len(sender.tokens) != 2 {
+		t.Fatalf("Expected two invites to be sent, got %d", len(sender.tokens))
+	}
+	token := sender.tokens[1]
+
+	// Only the invited email can redeem the token, and a refused attempt
+	// leaves it unused
+	if w := send("/api/invites/"+token+"/accept", "mallory-id", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another user's invite, got %d", w.Code)
+	}
+	if w := send("/api/invites/"+token+"/accept", "unknown-id", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for an unknown user, got %d", w.Code)
+	}
+	if member, _ := orgRepo.GetMember(ctx, "org-acme", "mallory-id"); member != nil {
+		t.Errorf("Expected the refused user not to join, got %+v", member)
+	}
+
+	if w := send("/api/invites/"+token+"/accept", "invitee-id", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	member, err := orgRepo.GetMember(ctx, "org-acme", "invitee-id")
+	if err != nil || member == nil || member.Role != models.RoleAdmin {
+		t.Errorf("Expected the invitee to join as an admin, got %+v (%v)", member, err)
+	}
+}
+
+func TestOnlyOwnersManageAdmins(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := memory.NewOrganizationRepository()
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "owner-id"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	userRepo := memory.NewUserRepository()
+	for _, username := range []string{"owner", "admin", "other-admin", "member", "site-admin"} {
+		if err := userRepo.Create(ctx, &models.User{ID: username + "-id", Username: username, Email: username + "@example.com"}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	for userID, role := range map[string]string{"admin-id": models.RoleAdmin, "other-admin-id": models.RoleAdmin, "member-id": models.RoleMember} {
+		if err := orgRepo.AddMember(ctx, &models.OrganizationMember{OrganizationID: "org-acme", UserID: userID, Role: role}); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	handler := NewOrganizationHandler(orgRepo, userRepo, memory.NewTemplateRepository())
+	r := gin.New()
+	r.Use(withTestUser(), func(c *gin.Context) {
+		c.Set("is_admin", c.GetString("user_id") == "site-admin-id")
+	})
+	r.PUT("/api/organizations/:slug/members/:username", handler.UpdateMemberRole)
+	r.DELETE("/api/organizations/:slug/members/:username", handler.RemoveMember)
+
+	send := func(method, username, userID, body string) int {
+		req := httptest.NewRequest(method, "/api/organizations/acme/members/"+username, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	role := func(userID string) string {
+		member, _ := orgRepo.GetMember(ctx, "org-acme", userID)
+		if member == nil {
+			return ""
+		}
+		return member.Role
+	}
+
+	// An admin cannot promote anyone, or demote or remove another admin
+	tests := []struct {
+		name     string
+		method   string
+		username string
+		body     string
+	}{
+		{"promote a member", http.MethodPut, "member", `{"role": "admin"}`},
+		{"demote an admin", http.MethodPut, "other-admin", `{"role": "member"}`},
+		{"remove an admin", http.MethodDelete, "other-admin", ""},
+	}
+	for _, tt := range tests {
+		if code := send(tt.method, tt.username, "admin-id", tt.body); code != http.StatusForbidden {
+			t.Errorf("%s: expected status 403 for an admin, got %d", tt.name, code)
+		}
+	}
+	if role("member-id") != models.RoleMember || role("other-admin-id") != models.RoleAdmin {
+		t.Errorf("Expected roles unchanged, got member %q and other admin %q", role("member-id"), role("other-admin-id"))
+	}
+
+	// An admin may still step down
+	if code := send(http.MethodDelete, "admin", "admin-id", ""); code != http.StatusOK {
+		t.Errorf("Expected an admin to leave, got %d", code)
+	}
+
+	// The owner and site admins manage admins
+	if code := send(http.MethodPut, "member", "owner-id", `{"role": "admin"}`); code != http.StatusOK || role("member-id") != models.RoleAdmin {
+		t.Errorf("Expected the owner to promote a member, got %d and role %q", code, role("member-id"))
+	}
+	if code := send(http.MethodPut, "other-admin", "site-admin-id", `{"role": "member"}`); code != http.StatusOK || role("other-admin-id") != models.RoleMember {
+		t.Errorf("Expected a site admin to demote an admin, got %d and role %q", code, role("other-admin-id"))
+	}
+	if code := send(http.MethodDelete, "member", "owner-id", ""); code != http.StatusOK || role("member-id") != "" {
+		t.Errorf("Expected the owner to remove an admin, got %d", code)
+	}
+}
+
+func TestFeaturedTemplates(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := memory.NewOrganizationRepository()
+	templateRepo := memory.NewTemplateRepository()
+
+	for _, org := range []*models.Organization{
+		{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "owner-id", Public: true},
+		{ID: "org-secret", Name: "Secret", Slug: "secret", OwnerID: "owner-id"},
+	} {
+		if err := orgRepo.Create(ctx, org); err != nil {
+			t.Fatalf("Failed to create organization: %v", err)
+		}
+	}
+	for i, template := range []*models.StoredTemplate{
+		{ID: "acme-1", Template: models.Template{Public: true, OrganizationID: "org-acme"}},
+		{ID: "acme-2", Template: models.Template{Public: true, OrganizationID: "org-acme"}},
+		{ID: "acme-3", Template: models.Template{Public: true, OrganizationID: "org-acme"}},
+		{ID: "acme-4", Template: models.Template{Public: true, OrganizationID: "org-acme"}},
+		{ID: "acme-5", Template: models.Template{Public: true, OrganizationID: "org-acme"}},
+		{ID: "acme-6", Template: models.Template{Public: true, OrganizationID: "org-acme"}},
+		{ID: "acme-private", Template: models.Template{OrganizationID: "org-acme"}},
+		{ID: "community", Template: models.Template{Public: true}},
+	} {
+		template.Downloads = (i + 1) * 10
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	handler := NewOrganizationHandler(orgRepo, memory.NewUserRepository(), templateRepo)
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/organizations/:slug/featured-templates", handler.GetFeaturedTemplates)
+	r.PUT("/api/organizations/:slug/featured-templates", handler.SetFeaturedTemplates)
+
+	send := func(method, slug, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/organizations/"+slug+"/featured-templates", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	featured := func(w *httptest.ResponseRecorder) []string {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var response []struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		ids := make([]string, 0, len(response))
+		for _, template := range response {
+			ids = append(ids, template.ID)
+		}
+		return ids
+	}
+
+	// Nothing pinned: the five most downloaded public templates
+	if ids := featured(send(http.MethodGet, "acme", "", "")); !reflect.DeepEqual(ids, []string{"acme-6", "acme-5", "acme-4", "acme-3", "acme-2"}) {
+		t.Errorf("Expected the top downloads, got %v", ids)
+	}
+
+	if w := send(http.MethodPut, "acme", "stranger-id", `{"template_ids":["acme-1"]}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a non-member, got %d", w.Code)
+	}
+	for _, body := range []string{
+		`{"template_ids":["acme-1","acme-private"]}`,
+		`{"template_ids":["community"]}`,
+		`{"template_ids":["acme-1","acme-1"]}`,
+		`{"template_ids":["acme-1","acme-2","acme-3","acme-4","acme-5","acme-6"]}`,
+	} {
+		if w := send(http.MethodPut, "acme", "owner-id", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+
+	if ids := featured(send(http.MethodPut, "acme", "owner-id", `{"template_ids":["acme-2","acme-1"]}`)); !reflect.DeepEqual(ids, []string{"acme-2", "acme-1"}) {
+		t.Errorf("Expected the pinned templates, got %v", ids)
+	}
+	if ids := featured(send(http.MethodGet, "acme", "", "")); !reflect.DeepEqual(ids, []string{"acme-2", "acme-1"}) {
+		t.Errorf("Expected the pinned templates in order, got %v", ids)
+	}
+
+	// A pinned template made private drops out
+	template, _ := templateRepo.GetByID(ctx, "acme-2")
+	template.Template.Public = false
+	if err := templateRepo.Update(ctx, template); err != nil {
+		t.Fatalf("Failed to update template: %v", err)
+	}
+	if ids := featured(send(http.MethodGet, "acme", "", "")); !reflect.DeepEqual(ids, []string{"acme-1"}) {
+		t.Errorf("Expected the private template to be skipped, got %v", ids)
+	}
+
+	if w := send(http.MethodGet, "secret", "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a private organization, got %d", w.Code)
+	}
+	if ids := featured(send(http.MethodGet, "secret", "owner-id", "")); len(ids) != 0 {
+		t.Errorf("Expected no featured templates, got %v", ids)
+	}
+}
+
+func TestOrganizationTemplateCount(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := memory.NewOrganizationRepository()
+	templateRepo := memory.NewTemplateRepository()
+
+	for _, org := range []*models.Organization{
+		{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "owner-id", Public: true},
+		{ID: "org-secret", Name: "Secret", Slug: "secret", OwnerID: "owner-id"},
+	} {
+		if err := orgRepo.Create(ctx, org); err != nil {
+			t.Fatalf("Failed to create organization: %v", err)
+		}
+	}
+	for _, template := range []*models.StoredTemplate{
+		{ID: "acme-1", Template: models.Template{Public: true, OrganizationID: "org-acme"}},
+		{ID: "acme-2", Template: models.Template{Public: true, OrganizationID: "org-acme"}},
+		{ID: "acme-private", Template: models.Template{OrganizationID: "org-acme"}},
+		{ID: "acme-unlisted", Template: models.Template{Public: true, OrganizationID: "org-acme"}, Unlisted: true},
+		{ID: "secret-1", Template: models.Template{Public: true, OrganizationID: "org-secret"}},
+		{ID: "secret-private", Template: models.Template{OrganizationID: "org-secret"}},
+		{ID: "community", Template: models.Template{Public: true}},
+	} {
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	handler := NewOrganizationHandler(orgRepo, memory.NewUserRepository(), templateRepo)
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/organizations/:slug/templates/count", handler.GetOrganizationTemplateCount)
+
+	get := func(slug, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/organizations/"+slug+"/templates/count", nil)
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	count := func(w *httptest.ResponseRecorder) float64 {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		return decodeBody(t, w)["count"].(float64)
+	}
+
+	w := get("acme", "")
+	if got := count(w); got != 2 {
+		t.Errorf("Expected 2 public, listed acme templates, got %v", got)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=60" {
+		t.Errorf("Expected a 60 second public Cache-Control, got %q", got)
+	}
+
+	// The count is cached until it expires
+	if err := templateRepo.Create(ctx, &models.StoredTemplate{ID: "acme-3", Template: models.Template{Public: true, OrganizationID: "org-acme"}}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	if got := count(get("acme", "")); got != 2 {
+		t.Errorf("Expected the cached count 2, got %v", got)
+	}
+	handler.templateCounts.counts["org-acme"].expiresAt = time.Time{}
+	if got := count(get("acme", "")); got != 3 {
+		t.Errorf("Expected 3 after the cache expired, got %v", got)
+	}
+
+	if w := get("secret", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a private organization, got %d", w.Code)
+	}
+	w = get("secret", "owner-id")
+	if got := count(w); got != 1 {
+		t.Errorf("Expected a member to see 1 public secret template, got %v", got)
+	}
+	if got := w.Header().Get("Cache-Control"); got != "private, max-age=60" {
+		t.Errorf("Expected a private Cache-Control, got %q", got)
+	}
+	if w := get("missing", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown organization, got %d", w.Code)
+	}
+}
+
+// recordingJoinRequestNotifier remembers every resolved join request
+type recordingJoinRequestNotifier struct {
+	resolved []models.OrganizationJoinRequest
+}
+
+func (n *recordingJoinRequestNotifier) NotifyJoinRequestResolved(ctx context.Context, org *models.Organization, request *models.OrganizationJoinRequest) error {
+	n.resolved = append(n.resolved, *request)
+	return nil
+}
+
+func TestJoinRequestLifecycle(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := memory.NewOrganizationRepository()
+
+	for _, org := range []*models.Organization{
+		{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "owner-id", Public: true, AllowJoinRequests: true},
+		{ID: "org-closed", Name: "Closed", Slug: "closed", OwnerID: "owner-id", Public: true},
+		{ID: "org-secret", Name: "Secret", Slug: "secret", OwnerID: "owner-id", AllowJoinRequests: true},
+	} {
+		if err := orgRepo.Create(ctx, org); err != nil {
+			t.Fatalf("Failed to create organization: %v", err)
+		}
+	}
+	if err := orgRepo.AddMember(ctx, &models.OrganizationMember{OrganizationID: "org-acme", UserID: "member-id", Role: models.RoleMember}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	notifier := &recordingJoinRequestNotifier{}
+	handler := NewOrganizationHandler(orgRepo, memory.NewUserRepository(), memory.NewTemplateRepository())
+	handler.joinRequestNotifier = notifier
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/organizations/:slug/join-requests", handler.CreateJoinRequest)
+	r.GET("/api/organizations/:slug/join-requests", handler.GetJoinRequests)
+	r.PUT("/api/organizations/:slug/join-requests/settings", handler.SetJoinRequests)
+	r.POST("/api/organizations/:slug/join-requests/:id/approve", handler.ApproveJoinRequest)
+	r.POST("/api/organizations/:slug/join-requests/:id/reject", handler.RejectJoinRequest)
+
+	send := func(method, url, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+			req.Header.Set("X-Test-Username", strings.TrimSuffix(userID, "-id"))
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	requestID := func(w *httptest.ResponseRecorder) string {
+		t.Helper()
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+		return decodeBody(t, w)["join_request"].(map[string]interface{})["id"].(string)
+	}
+
+	aliceID := requestID(send(http.MethodPost, "/api/organizations/acme/join-requests", "alice-id", `{"message":"I maintain our dotfiles"}`))
+	bobID := requestID(send(http.MethodPost, "/api/organizations/acme/join-requests", "bob-id", ""))
+
+	for _, tc := range []struct {
+		name, slug, userID string
+		want               int
+	}{
+		{"duplicate", "acme", "alice-id", http.StatusConflict},
+		{"member", "acme", "member-id", http.StatusConflict},
+		{"owner", "acme", "owner-id", http.StatusConflict},
+		{"join requests off", "closed", "alice-id", http.StatusForbidden},
+		{"private organization", "secret", "alice-id", http.StatusNotFound},
+	} {
+		if w := send(http.MethodPost, "/api/organizations/"+tc.slug+"/join-requests", tc.userID, ""); w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, w.Code)
+		}
+	}
+	long := `{"message":"` + strings.Repeat("x", 501) + `"}`
+	if w := send(http.MethodPost, "/api/organizations/acme/join-requests", "carol-id", long); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a long message, got %d", w.Code)
+	}
+
+	if w := send(http.MethodGet, "/api/organizations/acme/join-requests", "member-id", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 listing as a plain member, got %d", w.Code)
+	}
+	w := send(http.MethodGet, "/api/organizations/acme/join-requests?limit=1", "owner-id", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 listing requests, got %d: %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	if total := body["total"].(float64); total != 2 {
+		t.Errorf("Expected 2 pending requests, got %v", total)
+	}
+	if page := body["join_requests"].([]interface{}); len(page) != 1 || page[0].(map[string]interface{})["id"] != aliceID {
+		t.Errorf("Expected the oldest request first, got %v", page)
+	}
+
+	if w := send(http.MethodPost, "/api/organizations/acme/join-requests/"+aliceID+"/approve", "member-id", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 approving as a plain member, got %d", w.Code)
+	}
+	if w := send(http.MethodPost, "/api/organizations/closed/join-requests/"+aliceID+"/approve", "owner-id", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 approving through another organization, got %d", w.Code)
+	}
+	if w := send(http.MethodPost, "/api/organizations/acme/join-requests/"+aliceID+"/approve", "owner-id", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 approving, got %d: %s", w.Code, w.Body.String())
+	}
+	member, err := orgRepo.GetMember(ctx, "org-acme", "alice-id")
+	if err != nil || member == nil || member.Role != models.RoleMember {
+		t.Errorf("Expected alice to be a member, got %+v, %v", member, err)
+	}
+
+	if w := send(http.MethodPost, "/api/organizations/acme/join-requests/"+bobID+"/reject", "owner-id", `{"reason":"Employees only"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 rejecting, got %d: %s", w.Code, w.Body.String())
+	}
+	if ok, _ := orgRepo.IsMember(ctx, "org-acme", "bob-id"); ok {
+		t.Error("Expected bob not to be a member")
+	}
+	for _, action := range []string{"approve", "reject"} {
+		if w := send(http.MethodPost, "/api/organizations/acme/join-requests/"+bobID+"/"+action, "owner-id", ""); w.Code != http.StatusConflict {
+			t.Errorf("Expected 409 to %s a resolved request, got %d", action, w.Code)
+		}
+	}
+
+	if len(notifier.resolved) != 2 ||
+		notifier.resolved[0].UserID != "alice-id" || notifier.resolved[0].Status != models.JoinRequestApproved ||
+		notifier.resolved[1].UserID != "bob-id" || notifier.resolved[1].Status != models.JoinRequestRejected || notifier.resolved[1].Reason != "Employees only" {
+		t.Errorf("Expected both requesters to be notified, got %+v", notifier.resolved)
+	}
+
+	w = send(http.MethodGet, "/api/organizations/acme/join-requests?status=rejected", "owner-id", "")
+	if page := decodeBody(t, w)["join_requests"].([]interface{}); len(page) != 1 || page[0].(map[string]interface{})["reason"] != "Employees only" {
+		t.Errorf("Expected the rejected request with its reason, got %v", page)
+	}
+
+	// Rejected requesters may ask again; turning requests off stops them
+	requestID(send(http.MethodPost, "/api/organizations/acme/join-requests", "bob-id", ""))
+	if w := send(http.MethodPut, "/api/organizations/acme/join-requests/settings", "owner-id", `{"allow_join_requests":false}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 turning requests off, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPost, "/api/organizations/acme/join-requests", "carol-id", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 once requests are off, got %d", w.Code)
+	}
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// expandRequested reports whether the comma-separated ?expand= parameter
+// names the given relation
+func expandRequested(c *gin.Context, relation string) bool {
+	for _, value := range c.QueryArray("expand") {
+		for _, name := range strings.Split(value, ",") {
+			if strings.TrimSpace(name) == relation {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ownerIndex holds the owners of a page of templates, loaded with one
+// lookup per repository rather than one per template
+type ownerIndex struct {
+	users map[string]*models.User
+	orgs  map[string]*models.Organization
+}
+
+// loadOwners resolves the organizations and authors owning templates when
+// the caller asked for ?expand=owner. It returns nil otherwise, so responses
+// leave the owner out.
+func (h *TemplateHandler) loadOwners(c *gin.Context, templates ...*models.StoredTemplate) (*ownerIndex, error) {
+	if !expandRequested(c, "owner") {
+		return nil, nil
+	}
+
+	var usernames, orgIDs []string
+	for _, template := range templates {
+		if template.Template.OrganizationID != "" {
+			orgIDs = append(orgIDs, template.Template.OrganizationID)
+		} else if template.Template.Metadata.Author != "" {
+			usernames = append(usernames, template.Template.Metadata.Author)
+		}
+	}
+
+	return h.resolveOwners(c.Request.Context(), dedupe(usernames), dedupe(orgIDs))
+}
+
+func (h *TemplateHandler) resolveOwners(ctx context.Context, usernames, orgIDs []string) (*ownerIndex, error) {
+	index := &ownerIndex{
+		users: make(map[string]*models.User),
+		orgs:  make(map[string]*models.Organization),
+	}
+
+	if h.userRepo != nil && len(usernames) > 0 {
+		users, err := h.userRepo.GetByUsernames(ctx, usernames)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			index.users[user.Username] = user
+		}
+	}
+
+	if h.authorizer != nil && h.authorizer.orgRepo != nil && len(orgIDs) > 0 {
+		orgs, err := h.authorizer.orgRepo.GetByIDs(ctx, orgIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range orgs {
+			index.orgs[org.ID] = org
+		}
+	}
+
+	return index, nil
+}
+
+// apply sets the owner of a template response. Templates whose owner no
+// longer exists keep only their raw organization_id or author.
+func (o *ownerIndex) apply(template *models.StoredTemplate, response *dto.TemplateResponse) {
+	if o == nil {
+		return
+	}
+
+	if template.Template.OrganizationID != "" {
+		if org, ok := o.orgs[template.Template.OrganizationID]; ok {
+			response.Owner = &dto.TemplateOwnerResponse{
+				Type: dto.OwnerTypeOrganization,
+				ID:   org.ID,
+				Name: org.Name,
+				Slug: org.Slug,
+			}
+		}
+		return
+	}
+
+	if user, ok := o.users[template.Template.Metadata.Author]; ok {
+		response.Owner = &dto.TemplateOwnerResponse{
+			Type:           dto.OwnerTypeUser,
+			ID:             user.ID,
+			Username:       user.Username,
+			AvatarURL:      user.AvatarURL,
+			AvatarProxyURL: dto.AvatarProxyPath(user.ID),
+			Name:           user.Name,
+		}
+	}
+}
+
+// dedupe drops repeated values, keeping the first occurrence
+func dedupe(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := values[:0]
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
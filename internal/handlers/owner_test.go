@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+
+	"github.com/gin-gonic/gin"
+)
+
+// countingUserRepository counts batched username lookups
+type countingUserRepository struct {
+	repository.UserRepository
+	lookups int
+}
+
+func (r *countingUserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]*models.User, error) {
+	r.lookups++
+	return r.UserRepository.GetByUsernames(ctx, usernames)
+}
+
+func TestExpandTemplateOwner(t *testing.T) {
+	ctx := context.Background()
+	templateRepo := memory.NewTemplateRepository()
+	orgRepo := memory.NewOrganizationRepository()
+	userRepo := &countingUserRepository{UserRepository: memory.NewUserRepository()}
+
+	if err := userRepo.Create(ctx, &models.User{ID: "alice-id", Username: "alice", AvatarURL: "https://avatars.example.com/alice"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org-acme", Name: "Acme Corp", Slug: "acme", OwnerID: "alice-id"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+
+	for _, template := range []*models.StoredTemplate{
+		{ID: "alice-1", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Name: "Alice One", Author: "alice", Tags: []string{"owned"}}}},
+		{ID: "alice-2", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Name: "Alice Two", Author: "alice", Tags: []string{"owned"}}}},
+		{ID: "acme", Template: models.Template{Public: true, OrganizationID: "org-acme", Metadata: models.ShareMetadata{Name: "Acme Setup", Author: "alice", Tags: []string{"owned"}}}},
+		{ID: "ghost", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Name: "Ghost Setup", Author: "ghost", Tags: []string{"owned"}}}},
+	} {
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := NewTemplateHandler(templateRepo, memory.NewTagRepository(), userRepo, memory.NewReviewRepository(), tags.NewRegistry(), NewAuthorizer(orgRepo))
+	r := gin.New()
+	r.GET("/api/templates", h.ListTemplates)
+	r.GET("/api/templates/:id", h.GetTemplate)
+
+	get := func(url string) map[string]interface{} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", url, w.Code, w.Body.String())
+		}
+		return decodeBody(t, w)
+	}
+	owners := func(body map[string]interface{}) map[string]interface{} {
+		result := make(map[string]interface{})
+		for _, entry := range body["templates"].([]interface{}) {
+			template := entry.(map[string]interface{})
+			result[template["id"].(string)] = template["owner"]
+		}
+		return result
+	}
+
+	for id, owner := range owners(get("/api/templates?tags=owned")) {
+		if owner != nil {
+			t.Errorf("Expected no owner for %s without expand, got %v", id, owner)
+		}
+	}
+	if userRepo.lookups != 0 {
+		t.Errorf("Expected no owner lookups without expand, got %d", userRepo.lookups)
+	}
+
+	byID := owners(get("/api/templates?tags=owned&expand=owner"))
+	if len(byID) != 4 {
+		t.Fatalf("Expected the four owned templates, got %v", byID)
+	}
+	for _, id := range []string{"alice-1", "alice-2"} {
+		owner, _ := byID[id].(map[string]interface{})
+		if owner["type"] != "user" || owner["id"] != "alice-id" || owner["username"] != "alice" || owner["avatar_url"] != "https://avatars.example.com/alice" {
+			t.Errorf("Expected alice as the owner of %s, got %v", id, byID[id])
+		}
+	}
+	if owner, _ := byID["acme"].(map[string]interface{}); owner["type"] != "organization" || owner["name"] != "Acme Corp" || owner["slug"] != "acme" {
+		t.Errorf("Expected Acme Corp as the owner of the organization template, got %v", byID["acme"])
+	}
+	if byID["ghost"] != nil {
+		t.Errorf("Expected no owner for an unknown author, got %v", byID["ghost"])
+	}
+	if userRepo.lookups != 1 {
+		t.Errorf("Expected one batched user lookup for the page, got %d", userRepo.lookups)
+	}
+
+	template := get("/api/templates/acme?expand=owner")
+	if owner, _ := template["owner"].(map[string]interface{}); owner["slug"] != "acme" || template["organization_id"] != "org-acme" {
+		t.Errorf("Expected the owner alongside the raw organization_id, got %v", template)
+	}
+
+	// The owner can be selected like any other field
+	body := get("/api/templates?tags=owned&expand=owner&fields=owner")
+	for _, entry := range body["templates"].([]interface{}) {
+		template := entry.(map[string]interface{})
+		if template["id"] == "alice-1" {
+			if owner, _ := template["owner"].(map[string]interface{}); owner["username"] != "alice" || len(template) != 2 {
+				t.Errorf("Expected only id and owner, got %v", template)
+			}
+		}
+	}
+}
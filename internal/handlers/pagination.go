@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"strconv"
+
+	"dotfiles-api/internal/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parsePagination reads ?limit= and ?offset= for a paginated list. Each
+// adjustment to what was requested is reported in a Warning header, so a
+// clamped page is never a surprise.
+func parsePagination(c *gin.Context) (limit, offset int) {
+	return parsePaginationUpTo(c, pagination.MaxLimit)
+}
+
+// parsePaginationUpTo is parsePagination allowing pages of up to maxLimit
+func parsePaginationUpTo(c *gin.Context, maxLimit int) (limit, offset int) {
+	params := pagination.ParseParamsUpTo(c.Request.URL.Query(), maxLimit)
+	for _, warning := range params.Warnings {
+		// 299 is the "miscellaneous persistent warning" code
+		c.Writer.Header().Add("Warning", "299 - "+strconv.Quote(warning))
+	}
+	return params.Limit, params.Offset
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"time"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/mergepatch"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mergePatchContentType is the media type of RFC 7386 JSON Merge Patches
+const mergePatchContentType = "application/merge-patch+json"
+
+// protectedPatchFields are the template fields only the server sets. They
+// are listed both where clients see them on responses and where they are
+// stored in the template document.
+var protectedPatchFields = map[string]bool{
+	"id":                  true,
+	"author_id":           true,
+	"downloads":           true,
+	"featured":            true,
+	"created_at":          true,
+	"updated_at":          true,
+	"images":              true,
+	"metadata.author":     true,
+	"metadata.created_at": true,
+	"metadata.updated_at": true,
+}
+
+// PatchTemplate applies a JSON Merge Patch to a template. The patch is
+// merged into the stored template document, and the result is validated
+// and normalized like a new template before it replaces the stored one.
+// Author only.
+func (h *TemplateHandler) PatchTemplate(c *gin.Context) {
+	if c.ContentType() != mergePatchContentType {
+		appErr := errors.NewUnsupportedMediaTypeError("Content-Type must be " + mergePatchContentType)
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	template, ok := h.loadTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if template.Template.Metadata.Author != c.GetString("username") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("only the template author can update it"),
+		})
+		return
+	}
+
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		appErr := bindError(err)
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("patch must be a JSON object"),
+		})
+		return
+	}
+
+	if protected := patchedProtectedFields(fields); len(protected) > 0 {
+		appErr := errors.NewFieldValidationError("Protected fields cannot be patched", protected)
+		appErr.StatusCode = http.StatusUnprocessableEntity
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	original, err := json.Marshal(template.Template)
+	if err != nil {
+		respondInternalError(c, "failed to encode template", err)
+		return
+	}
+	merged, err := mergepatch.Apply(original, patch)
+	if err != nil {
+		respondInternalError(c, "failed to apply patch", err)
+		return
+	}
+
+	// Check the result against the rules for new templates
+	var req dto.CreateTemplateRequest
+	if err := json.Unmarshal(merged, &req); err != nil {
+		appErr := bindError(err)
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+	if appErr := validateStruct(&req); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+	if appErr := req.ValidateUpdate(template.Template.Metadata.Version); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	var patched models.Template
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		appErr := bindError(err)
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	// Patches that change the contents without choosing a version get the
+	// next patch version, as GitHub syncs do
+	previous := template.Template
+	patched.Metadata.Tags = h.tags.CanonicalTags(patched.Metadata.Tags)
+	patched.Metadata.Version = dto.CanonicalVersion(patched.Metadata.Version)
+	if patched.Metadata.Version == previous.Metadata.Version {
+		patched.Metadata.Version = nextPatchVersion(previous.Metadata.Version)
+	}
+	patched.Metadata.UpdatedAt = time.Now()
+	if patched.OrganizationID != previous.OrganizationID && !h.requireOrganizationMember(c, patched.OrganizationID) {
+		return
+	}
+	if !h.checkContentLimits(c, &patched) {
+		return
+	}
+	template.Template = patched
+
+	if err := h.templateRepo.Update(c.Request.Context(), template); err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
+			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+			return
+		}
+		respondInternalError(c, "failed to update template", err)
+		return
+	}
+
+	response := toTemplateResponse(template)
+	response.Warnings = req.Warnings()
+	c.JSON(http.StatusOK, response)
+}
+
+// patchedProtectedFields maps each protected field a patch touches,
+// top-level or under metadata, to why it was refused
+func patchedProtectedFields(patch map[string]interface{}) map[string]string {
+	protected := make(map[string]string)
+	refuse := func(path string) {
+		if protectedPatchFields[path] {
+			protected[path] = "is set by the server and cannot be patched"
+		}
+	}
+
+	for name, value := range patch {
+		refuse(name)
+		if metadata, ok := value.(map[string]interface{}); ok && name == "metadata" {
+			for child := range metadata {
+				refuse("metadata." + child)
+			}
+		}
+	}
+	return protected
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPatchTemplate(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTemplateRepository()
+	if err := repo.Create(ctx, &models.StoredTemplate{ID: "dev", Downloads: 7, Template: models.Template{
+		Brews:  []string{"git", "go"},
+		Casks:  []string{"iterm2"},
+		Public: true,
+		Metadata: models.ShareMetadata{
+			Name:        "Dev",
+			Description: "Developer tools",
+			Author:      "alice",
+			Version:     "1.0.0",
+			Tags:        []string{"go"},
+		},
+	}}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.Use(withTestUser())
+	r.PATCH("/api/templates/:id", h.PatchTemplate)
+
+	patch := func(id, username, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/templates/"+id, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-Test-Username", username)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch("dev", "alice", mergePatchContentType, `{"brews":["git"],"casks":null,"metadata":{"description":"Just git, nothing else","tags":["Go","CLI"]}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	stored, err := repo.GetByID(ctx, "dev")
+	if err != nil {
+		t.Fatalf("Failed to get template: %v", err)
+	}
+	got := stored.Template
+	if !reflect.DeepEqual(got.Brews, []string{"git"}) || len(got.Casks) != 0 {
+		t.Errorf("Expected brews replaced and casks removed, got %v and %v", got.Brews, got.Casks)
+	}
+	if got.Metadata.Name != "Dev" || got.Metadata.Description != "Just git, nothing else" || got.Metadata.Author != "alice" || !got.Public {
+		t.Errorf("Expected unpatched fields to be kept, got %+v", got)
+	}
+	if !reflect.DeepEqual(got.Metadata.Tags, []string{"golang", "cli"}) {
+		t.Errorf("Expected normalized tags, got %v", got.Metadata.Tags)
+	}
+	if got.Metadata.Version != "1.0.1" {
+		t.Errorf("Expected the patch version to be bumped, got %s", got.Metadata.Version)
+	}
+	if stored.Downloads != 7 {
+		t.Errorf("Expected downloads to be kept, got %d", stored.Downloads)
+	}
+
+	// An explicit version is kept as sent
+	if w := patch("dev", "alice", mergePatchContentType, `{"metadata":{"version":"2.0.0"}}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if stored, _ := repo.GetByID(ctx, "dev"); stored.Template.Metadata.Version != "2.0.0" {
+		t.Errorf("Expected version 2.0.0, got %s", stored.Template.Metadata.Version)
+	}
+
+	w = patch("dev", "alice", mergePatchContentType, `{"featured":true,"downloads":100,"metadata":{"author":"mallory","name":"Fine"}}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d: %s", w.Code, w.Body.String())
+	}
+	fields := decodeBody(t, w)["error"].(map[string]interface{})["fields"].(map[string]interface{})
+	if len(fields) != 3 || fields["featured"] == nil || fields["downloads"] == nil || fields["metadata.author"] == nil {
+		t.Errorf("Expected the protected fields to be listed, got %v", fields)
+	}
+
+	// Hooks can only be set by patching, so the content limits guard them here
+	tooManyHooks := `{"hooks":{"post_install":[` + strings.Repeat(`"echo",`, 50) + `"echo"]}}`
+
+	for _, tt := range []struct {
+		name, id, username, contentType, body string
+		want                                  int
+	}{
+		{"plain JSON", "dev", "alice", "application/json", `{"brews":[]}`, http.StatusUnsupportedMediaType},
+		{"not the author", "dev", "bob", mergePatchContentType, `{"brews":[]}`, http.StatusForbidden},
+		{"missing template", "missing", "alice", mergePatchContentType, `{"brews":[]}`, http.StatusNotFound},
+		{"not an object", "dev", "alice", mergePatchContentType, `["git"]`, http.StatusBadRequest},
+		{"invalid result", "dev", "alice", mergePatchContentType, `{"metadata":{"name":null}}`, http.StatusBadRequest},
+		{"wrong type", "dev", "alice", mergePatchContentType, `{"brews":"git"}`, http.StatusBadRequest},
+		{"too many hook commands", "dev", "alice", mergePatchContentType, tooManyHooks, http.StatusRequestEntityTooLarge},
+	} {
+		if w := patch(tt.id, tt.username, tt.contentType, tt.body); w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d: %s", tt.name, tt.want, w.Code, w.Body.String())
+		}
+	}
+	if stored, _ := repo.GetByID(ctx, "dev"); stored.Template.Metadata.Name != "Dev" || len(stored.Template.Brews) != 1 {
+		t.Errorf("Expected rejected patches to leave the template alone, got %+v", stored.Template)
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/plan"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTemplatePlan returns the ordered steps applying a template runs, with
+// its extends chain resolved, so clients can preview an install. It follows
+// the download visibility rules but does not count as a download.
+func (h *TemplateHandler) GetTemplatePlan(c *gin.Context) {
+	template, ok := h.loadVisibleTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	resolved, chain, ok := h.resolveChain(c, template)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.TemplatePlanResponse{
+		TemplateID: template.ID,
+		Chain:      chain,
+		Steps:      plan.Build(resolved),
+	})
+}
+
+// resolveChain merges a template with its extends chain into what
+// applying it installs, also returning the ancestor IDs, nearest parent
+// first. When the chain cannot be resolved it writes the error response
+// and returns false.
+func (h *TemplateHandler) resolveChain(c *gin.Context, template *models.StoredTemplate) (models.Template, []string, bool) {
+	ancestors, problem, err := h.loadAncestors(c, template.Template.Extends)
+	if err != nil {
+		respondInternalError(c, "failed to resolve template inheritance", err)
+		return models.Template{}, nil, false
+	}
+	if problem != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": errors.NewConflictError("template inheritance cannot be resolved: " + problem.Message),
+		})
+		return models.Template{}, nil, false
+	}
+
+	// Resolve wants the root ancestor first and the template itself last
+	chain := make([]models.Template, 0, len(ancestors)+1)
+	ids := make([]string, 0, len(ancestors))
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		chain = append(chain, ancestors[i].Template)
+	}
+	for _, ancestor := range ancestors {
+		ids = append(ids, ancestor.ID)
+	}
+	chain = append(chain, template.Template)
+
+	return plan.Resolve(chain), ids, true
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetTemplatePlan(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTemplateRepository()
+
+	for _, template := range []*models.StoredTemplate{
+		{ID: "base", Template: models.Template{
+			Public: true,
+			Brews:  []string{"git"},
+			Hooks:  &models.Hooks{PreInstall: []string{"sudo softwareupdate --install-rosetta"}},
+		}},
+		{ID: "leaf", Template: models.Template{
+			Public:         true,
+			Extends:        "base",
+			Brews:          []string{"neovim"},
+			Stow:           []string{"nvim"},
+			PackageConfigs: map[string]models.PackageConfig{"git": {PostInstall: []string{"git lfs install"}}},
+		}},
+		{ID: "private", Template: models.Template{Metadata: models.ShareMetadata{Author: "alice"}}},
+		{ID: "orphan", Template: models.Template{Public: true, Extends: "private"}},
+	} {
+		if err := repo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates/:id/plan", h.GetTemplatePlan)
+
+	get := func(id, username string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/templates/"+id+"/plan", nil)
+		if username != "" {
+			req.Header.Set("X-Test-User", username+"-id")
+			req.Header.Set("X-Test-Username", username)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("leaf", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response dto.TemplatePlanResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Chain) != 1 || response.Chain[0] != "base" {
+		t.Errorf("Expected chain [base], got %v", response.Chain)
+	}
+
+	var got []string
+	for _, step := range response.Steps {
+		got = append(got, step.Kind+" "+step.Name)
+	}
+	want := []string{"hook pre_install", "brew git", "package_post_install git", "brew neovim", "stow nvim"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected steps %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Expected steps %v, got %v", want, got)
+		}
+	}
+	if warnings := response.Steps[0].Warnings; len(warnings) != 1 || warnings[0] != "runs with root privileges" {
+		t.Errorf("Expected the inherited hook to be flagged, got %v", warnings)
+	}
+
+	stored, err := repo.GetByID(ctx, "leaf")
+	if err != nil {
+		t.Fatalf("Failed to get template: %v", err)
+	}
+	if stored.Downloads != 0 {
+		t.Errorf("Expected the plan not to count as a download, got %d", stored.Downloads)
+	}
+
+	if w := get("private", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a private template, got %d", w.Code)
+	}
+	if w := get("private", "alice"); w.Code != http.StatusOK {
+		t.Errorf("Expected the author to see the plan, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := get("orphan", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 when a parent is hidden, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRandomTemplates is the most templates one random pick returns
+const maxRandomTemplates = 5
+
+// GetRandomTemplate returns ?count= (default 1) public templates picked at
+// random, for discovery. Fewer come back when fewer are public.
+func (h *TemplateHandler) GetRandomTemplate(c *gin.Context) {
+	count := 1
+	if raw := c.Query("count"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxRandomTemplates {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": errors.NewValidationError(fmt.Sprintf("count must be between 1 and %d", maxRandomTemplates)),
+			})
+			return
+		}
+		count = parsed
+	}
+
+	public := true
+	templates, err := h.templateRepo.List(c.Request.Context(), repository.TemplateFilters{
+		Public: &public,
+		Limit:  count,
+		Random: true,
+	})
+	if err != nil {
+		respondInternalError(c, "failed to pick random templates", err)
+		return
+	}
+
+	response := make([]dto.TemplateResponse, 0, len(templates))
+	for _, template := range templates {
+		response = append(response, toTemplateResponse(template))
+	}
+
+	// Each request should get a fresh pick
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"templates": response})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetRandomTemplate(t *testing.T) {
+	repo := memory.NewTemplateRepository()
+	ctx := context.Background()
+	for _, template := range []*models.StoredTemplate{
+		{ID: "private", Template: models.Template{Public: false}},
+		{ID: "unlisted", Template: models.Template{Public: true}, Unlisted: true},
+	} {
+		if err := repo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+	public := true
+	listed, err := repo.Count(ctx, repository.TemplateFilters{Public: &public})
+	if err != nil {
+		t.Fatalf("Failed to count templates: %v", err)
+	}
+
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.GET("/api/templates/random", h.GetRandomTemplate)
+
+	tests := []struct {
+		query    string
+		wantCode int
+		wantLen  int
+	}{
+		{"", http.StatusOK, 1},
+		{"?count=3", http.StatusOK, min(3, listed)},
+		{"?count=5", http.StatusOK, min(5, listed)},
+		{"?count=0", http.StatusBadRequest, 0},
+		{"?count=6", http.StatusBadRequest, 0},
+		{"?count=many", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates/random"+tt.query, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Expected Cache-Control no-store, got %q", got)
+			}
+
+			templates := decodeBody(t, w)["templates"].([]interface{})
+			if len(templates) != tt.wantLen {
+				t.Fatalf("Expected %d templates, got %d", tt.wantLen, len(templates))
+			}
+			seen := make(map[string]bool)
+			for _, template := range templates {
+				id := template.(map[string]interface{})["id"].(string)
+				if id == "private" || id == "unlisted" {
+					t.Errorf("Expected only public, listed templates, got %s", id)
+				}
+				if seen[id] {
+					t.Errorf("Expected distinct templates, got %s twice", id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestGetRandomTemplateVaries(t *testing.T) {
+	repo := memory.NewTemplateRepository()
+	for i := 0; i < 5; i++ {
+		template := &models.StoredTemplate{ID: fmt.Sprintf("public-%d", i), Template: models.Template{Public: true}}
+		if err := repo.Create(context.Background(), template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.GET("/api/templates/random", h.GetRandomTemplate)
+
+	picked := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates/random", nil))
+		templates := decodeBody(t, w)["templates"].([]interface{})
+		picked[templates[0].(map[string]interface{})["id"].(string)] = true
+	}
+	if len(picked) < 2 {
+		t.Errorf("Expected different templates across 50 picks, got %v", picked)
+	}
+}
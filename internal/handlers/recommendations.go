@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/tags"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxRecommendations is how many templates GetRecommendations returns
+	maxRecommendations = 10
+	// maxRecommendationTags bounds how many of the favorites' tags are
+	// searched, most common first, since each costs a query
+	maxRecommendationTags = 10
+)
+
+// GetRecommendations suggests public templates sharing tags with the
+// caller's favorites. Candidates are ranked by how common their tags are
+// among the favorites, then by popularity; favorites themselves are left
+// out.
+func (h *UserHandler) GetRecommendations(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	favorites, err := h.userRepo.GetFavorites(ctx, userID)
+	if err != nil {
+		respondInternalError(c, "failed to get favorites", err)
+		return
+	}
+
+	favorited := make(map[string]bool, len(favorites))
+	tagCounts := make(map[string]int)
+	for _, id := range favorites {
+		favorited[id] = true
+
+		template, err := h.templateRepo.GetByID(ctx, id)
+		if err != nil && !isNotFound(err) {
+			respondInternalError(c, "failed to get favorite template", err)
+			return
+		}
+		if template == nil {
+			continue
+		}
+		// Count each tag once per favorite, however it is spelled
+		seen := make(map[string]bool)
+		for _, tag := range template.Template.Metadata.Tags {
+			if normalized := tags.Normalize(tag); normalized != "" && !seen[normalized] {
+				seen[normalized] = true
+				tagCounts[normalized]++
+			}
+		}
+	}
+
+	searched := make([]string, 0, len(tagCounts))
+	for tag := range tagCounts {
+		searched = append(searched, tag)
+	}
+	slices.SortFunc(searched, func(a, b string) int {
+		return cmp.Or(cmp.Compare(tagCounts[b], tagCounts[a]), cmp.Compare(a, b))
+	})
+	if len(searched) > maxRecommendationTags {
+		searched = searched[:maxRecommendationTags]
+	}
+
+	// Tag filters match templates having every tag, so each tag is listed
+	// separately and the results merged
+	scores := make(map[string]int)
+	var candidates []*models.StoredTemplate
+	public := true
+	for _, tag := range searched {
+		templates, err := h.templateRepo.List(ctx, repository.TemplateFilters{
+			Tags:   [][]string{{tag}},
+			Public: &public,
+			Sort:   []repository.SortKey{{Field: "popularity", Desc: true}},
+			// Enough to fill the page even if every favorite matches
+			Limit: maxRecommendations + len(favorites),
+		})
+		if err != nil {
+			respondInternalError(c, "failed to list templates", err)
+			return
+		}
+
+		for _, template := range templates {
+			if favorited[template.ID] {
+				continue
+			}
+			if _, seen := scores[template.ID]; !seen {
+				candidates = append(candidates, template)
+			}
+			scores[template.ID] += tagCounts[tag]
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b *models.StoredTemplate) int {
+		return cmp.Or(
+			cmp.Compare(scores[b.ID], scores[a.ID]),
+			cmp.Compare(b.PopularityScore, a.PopularityScore),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+	if len(candidates) > maxRecommendations {
+		candidates = candidates[:maxRecommendations]
+	}
+
+	response := make([]dto.TemplateResponse, len(candidates))
+	for i, template := range candidates {
+		response[i] = toTemplateResponse(template)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recommendations": response,
+		"tags":            searched,
+	})
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetRecommendations(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+	for _, user := range []*models.User{
+		{ID: "alice-id", Username: "alice", Email: "alice@example.com"},
+		{ID: "bob-id", Username: "bob", Email: "bob@example.com"},
+	} {
+		if err := userRepo.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	templateRepo := memory.NewTemplateRepository()
+	tagged := func(id string, public bool, popularity float64, tags ...string) *models.StoredTemplate {
+		return &models.StoredTemplate{
+			ID:              id,
+			Template:        models.Template{Public: public, Metadata: models.ShareMetadata{Name: id, Tags: tags}},
+			PopularityScore: popularity,
+		}
+	}
+	for _, template := range []*models.StoredTemplate{
+		// Alice's favorites: "terminal" twice, "Fish Shell" once
+		tagged("fav-1", true, 0, "terminal", "Fish Shell"),
+		tagged("fav-2", true, 0, "Terminal"),
+		tagged("both", true, 1, "terminal", "fish-shell"),
+		tagged("terminal-popular", true, 9, "terminal"),
+		tagged("terminal-quiet", true, 2, "terminal"),
+		tagged("fish-only", true, 50, "fish_shell"),
+		tagged("private", false, 100, "terminal"),
+		tagged("unrelated", true, 100, "gardening"),
+	} {
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+	for _, id := range []string{"fav-1", "fav-2", "deleted-favorite"} {
+		if err := userRepo.AddFavorite(ctx, "alice-id", id); err != nil {
+			t.Fatalf("Failed to add favorite: %v", err)
+		}
+	}
+
+	h := NewUserHandler(userRepo, templateRepo, memory.NewReviewRepository(), memory.NewOrganizationRepository())
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/me/recommendations", h.GetRecommendations)
+
+	get := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/me/recommendations", nil)
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("alice-id")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+
+	var ids []string
+	for _, template := range body["recommendations"].([]interface{}) {
+		ids = append(ids, template.(map[string]interface{})["id"].(string))
+	}
+	// Both tags beat terminal (2) alone, which beats fish-shell (1) alone;
+	// ties go to the more popular template
+	want := []string{"both", "terminal-popular", "terminal-quiet", "fish-only"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected %v, got %v", want, ids)
+	}
+	if !reflect.DeepEqual(body["tags"], []interface{}{"terminal", "fish-shell"}) {
+		t.Errorf("Expected the favorites' tags most common first, got %v", body["tags"])
+	}
+
+	// No favorites, no recommendations
+	w = get("bob-id")
+	if recommendations := decodeBody(t, w)["recommendations"].([]interface{}); w.Code != http.StatusOK || len(recommendations) != 0 {
+		t.Errorf("Expected an empty list without favorites, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := get(""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 signed out, got %d", w.Code)
+	}
+}
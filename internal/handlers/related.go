@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigureConfigs lets template endpoints look up legacy configs
+func (h *TemplateHandler) ConfigureConfigs(configRepo repository.ConfigRepository) {
+	h.configRepo = configRepo
+}
+
+// GetRelatedConfigs lists public configs sharing at least one brew or cask
+// with a template, with its extends chain resolved, those sharing the most
+// first. It gives users of the legacy config format a path to templates
+// close to what they already have.
+func (h *TemplateHandler) GetRelatedConfigs(c *gin.Context) {
+	if h.configRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": errors.NewUnavailableError("Configs are not available"),
+		})
+		return
+	}
+
+	limit, _ := parsePagination(c)
+
+	template, ok := h.loadVisibleTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	resolved, _, ok := h.resolveChain(c, template)
+	if !ok {
+		return
+	}
+
+	configs, err := h.configRepo.FindByPackages(c.Request.Context(), resolved.Brews, resolved.Casks, limit)
+	if err != nil {
+		respondInternalError(c, "Failed to find related configs", err)
+		return
+	}
+
+	response := make([]dto.RelatedConfigResponse, 0, len(configs))
+	for _, config := range configs {
+		related := dto.RelatedConfigResponse{
+			Config:      config,
+			SharedBrews: sharedPackages(config.Config.Brews, resolved.Brews),
+			SharedCasks: sharedPackages(config.Config.Casks, resolved.Casks),
+		}
+		related.SharedCount = len(related.SharedBrews) + len(related.SharedCasks)
+		response = append(response, related)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"template_id": template.ID,
+		"configs":     response,
+		"limit":       limit,
+		"total":       len(response),
+	})
+}
+
+// sharedPackages returns the packages of have that are also in want, in
+// have's order and without duplicates
+func sharedPackages(have, want []string) []string {
+	wanted := make(map[string]bool, len(want))
+	for _, name := range want {
+		wanted[name] = true
+	}
+
+	shared := []string{}
+	for _, name := range have {
+		if wanted[name] {
+			shared = append(shared, name)
+			delete(wanted, name)
+		}
+	}
+	return shared
+}
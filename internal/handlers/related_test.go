@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetRelatedConfigs(t *testing.T) {
+	ctx := context.Background()
+	templateRepo := memory.NewTemplateRepository()
+	for _, template := range []*models.StoredTemplate{
+		{ID: "base", Template: models.Template{Public: true, Brews: []string{"git"}}},
+		{ID: "leaf", Template: models.Template{Public: true, Extends: "base", Brews: []string{"jq"}, Casks: []string{"firefox"}}},
+		{ID: "hidden", Template: models.Template{Brews: []string{"git"}}},
+	} {
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	configRepo := memory.NewConfigRepository()
+	for _, config := range []*models.StoredConfig{
+		{ID: "both", Public: true, Config: models.ShareableConfig{BasicConfig: models.BasicConfig{Brews: []string{"git", "jq"}}}},
+		{ID: "popular-git", Public: true, DownloadCount: 50, Config: models.ShareableConfig{BasicConfig: models.BasicConfig{Brews: []string{"git", "git"}}}},
+		{ID: "cask", Public: true, Config: models.ShareableConfig{BasicConfig: models.BasicConfig{Casks: []string{"firefox"}}}},
+		{ID: "private", Config: models.ShareableConfig{BasicConfig: models.BasicConfig{Brews: []string{"git", "jq"}}}},
+		{ID: "unrelated", Public: true, Config: models.ShareableConfig{BasicConfig: models.BasicConfig{Brews: []string{"wget"}}}},
+	} {
+		if err := configRepo.Create(ctx, config); err != nil {
+			t.Fatalf("Failed to create config: %v", err)
+		}
+	}
+
+	h := newTestTemplateHandler(templateRepo)
+	h.ConfigureConfigs(configRepo)
+	r := gin.New()
+	r.GET("/api/templates/:id/related-configs", h.GetRelatedConfigs)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	w := get("/api/templates/leaf/related-configs")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Configs []dto.RelatedConfigResponse `json:"configs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	// Inherited brews count, ties go to the most downloaded
+	var ids []string
+	for _, related := range body.Configs {
+		ids = append(ids, related.Config.ID)
+	}
+	if want := []string{"both", "popular-git", "cask"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("Expected %v, got %v", want, ids)
+	}
+	if first := body.Configs[0]; first.SharedCount != 2 || !reflect.DeepEqual(first.SharedBrews, []string{"git", "jq"}) {
+		t.Errorf("Expected both brews to be shared, got %+v", first)
+	}
+	if second := body.Configs[1]; second.SharedCount != 1 || !reflect.DeepEqual(second.SharedBrews, []string{"git"}) {
+		t.Errorf("Expected duplicate brews to count once, got %+v", second)
+	}
+
+	if w := get("/api/templates/leaf/related-configs?limit=1"); len(decodeBody(t, w)["configs"].([]interface{})) != 1 {
+		t.Errorf("Expected the limit to apply, got %s", w.Body.String())
+	}
+	if w := get("/api/templates/hidden/related-configs"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a private template, got %d", w.Code)
+	}
+}
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/images"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultAutoUnlistThreshold is how many open reports unlist a template
+// pending review unless configured otherwise
+const defaultAutoUnlistThreshold = 5
+
+// ReportNotifier tells reporters how their report was resolved
+type ReportNotifier interface {
+	NotifyReportResolved(ctx context.Context, report *models.TemplateReport) error
+}
+
+// LogReportNotifier writes resolved reports to the server log. It is the
+// default until a delivery channel such as email is configured.
+type LogReportNotifier struct{}
+
+// NotifyReportResolved logs the resolution a reporter would be sent
+func (LogReportNotifier) NotifyReportResolved(ctx context.Context, report *models.TemplateReport) error {
+	log.Printf("Report %s by %s on template %s resolved: %s", report.ID, report.ReporterUsername, report.TemplateID, report.Action)
+	return nil
+}
+
+// ReportHandler handles template reports and their review by site admins
+type ReportHandler struct {
+	reportRepo   repository.ReportRepository
+	templateRepo repository.TemplateRepository
+	auditRepo    repository.AuditRepository
+	authorizer   *Authorizer
+	notifier     ReportNotifier
+	threshold    int
+	images       *images.Store
+}
+
+// NewReportHandler creates a new report handler
+func NewReportHandler(reportRepo repository.ReportRepository, templateRepo repository.TemplateRepository, auditRepo repository.AuditRepository, authorizer *Authorizer) *ReportHandler {
+	return &ReportHandler{
+		reportRepo:   reportRepo,
+		templateRepo: templateRepo,
+		auditRepo:    auditRepo,
+		authorizer:   authorizer,
+		notifier:     LogReportNotifier{},
+		threshold:    defaultAutoUnlistThreshold,
+	}
+}
+
+// ConfigureAutoUnlist sets how many open reports unlist a template pending
+// review. Zero or less turns automatic unlisting off.
+func (h *ReportHandler) ConfigureAutoUnlist(threshold int) {
+	h.threshold = threshold
+}
+
+// ConfigureImages sets where the images of deleted templates are removed
+// from
+func (h *ReportHandler) ConfigureImages(store *images.Store) {
+	h.images = store
+}
+
+// ConfigureNotifier sets how reporters hear about resolved reports
+func (h *ReportHandler) ConfigureNotifier(notifier ReportNotifier) {
+	h.notifier = notifier
+}
+
+// ReportTemplate handles flagging a template for site admins to review. A
+// user may hold one open report per template.
+func (h *ReportHandler) ReportTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
+	}
+
+	template, ok := h.loadReportableTemplate(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ReportTemplateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	report := &models.TemplateReport{
+		ID:               uuid.New().String(),
+		TemplateID:       template.ID,
+		ReporterID:       userID,
+		ReporterUsername: c.GetString("username"),
+		Reason:           req.Reason,
+		Details:          req.Details,
+	}
+	if err := h.reportRepo.Create(c.Request.Context(), report); err != nil {
+		if stderrors.Is(err, repository.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": errors.NewConflictError("You already have an open report on this template"),
+			})
+			return
+		}
+		respondInternalError(c, "Failed to create report", err)
+		return
+	}
+
+	// The report stands even if unlisting fails; the next report retries it
+	if err := h.autoUnlist(c.Request.Context(), template); err != nil {
+		log.Printf("Failed to auto-unlist template %s: %v", template.ID, err)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"report":  report,
+		"message": "Report submitted for review",
+	})
+}
+
+// loadReportableTemplate loads the template named in the path, reporting
+// templates the caller may not see as not found
+func (h *ReportHandler) loadReportableTemplate(c *gin.Context) (*models.StoredTemplate, bool) {
+	template, err := h.templateRepo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "Failed to get template", err)
+		return nil, false
+	}
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("template")})
+		return nil, false
+	}
+
+	visible, err := h.authorizer.CanViewTemplate(c, template)
+	if err != nil {
+		respondInternalError(c, "Failed to check template visibility", err)
+		return nil, false
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("template")})
+		return nil, false
+	}
+
+	return template, true
+}
+
+// autoUnlist hides a template pending review once its open reports reach
+// the threshold
+func (h *ReportHandler) autoUnlist(ctx context.Context, template *models.StoredTemplate) error {
+	if h.threshold <= 0 || template.Unlisted {
+		return nil
+	}
+
+	open, err := h.reportRepo.CountOpen(ctx, template.ID)
+	if err != nil {
+		return err
+	}
+	if open < h.threshold {
+		return nil
+	}
+
+	if err := h.templateRepo.SetUnlisted(ctx, template.ID, models.UnlistReasonReports); err != nil {
+		return err
+	}
+	h.audit(ctx, &models.AuditEntry{
+		Actor:      "system",
+		Action:     "auto_unlist_template",
+		TargetType: "template",
+		TargetID:   template.ID,
+		Details:    fmt.Sprintf("%d open reports reached the threshold of %d", open, h.threshold),
+	})
+	return nil
+}
+
+// ListReports handles listing open reports grouped by template, most
+// reported first (site admins)
+func (h *ReportHandler) ListReports(c *gin.Context) {
+	reports, err := h.reportRepo.ListOpen(c.Request.Context())
+	if err != nil {
+		respondInternalError(c, "Failed to list reports", err)
+		return
+	}
+
+	var groups []*dto.ReportGroupResponse
+	byTemplate := make(map[string]*dto.ReportGroupResponse)
+	for _, report := range reports {
+		group, exists := byTemplate[report.TemplateID]
+		if !exists {
+			group = &dto.ReportGroupResponse{
+				TemplateID: report.TemplateID,
+				Reasons:    make(map[string]int),
+			}
+			byTemplate[report.TemplateID] = group
+			groups = append(groups, group)
+		}
+		group.OpenReports++
+		group.Reasons[report.Reason]++
+		group.Reports = append(group.Reports, report)
+	}
+
+	for _, group := range groups {
+		template, err := h.templateRepo.GetByID(c.Request.Context(), group.TemplateID)
+		if err != nil && !isNotFound(err) {
+			respondInternalError(c, "Failed to get reported template", err)
+			return
+		}
+		if template != nil {
+			group.TemplateName = template.Template.Metadata.Name
+			group.Unlisted = template.Unlisted
+		}
+	}
+
+	// Reports arrive oldest first, so ties keep the longest-waiting template
+	// ahead
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].OpenReports > groups[j].OpenReports
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": groups,
+		"total":     len(groups),
+	})
+}
+
+// ResolveReport handles resolving a report (site admins). The action is
+// applied to the reported template and closes every open report against
+// it, notifying each reporter.
+func (h *ReportHandler) ResolveReport(c *gin.Context) {
+	report, err := h.reportRepo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "Failed to get report", err)
+		return
+	}
+	if report == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("report")})
+		return
+	}
+	if report.Status != models.ReportStatusOpen {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": errors.NewConflictError("Report is already resolved"),
+		})
+		return
+	}
+
+	var req dto.ResolveReportRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.applyResolution(ctx, report.TemplateID, req.Action); err != nil {
+		respondInternalError(c, "Failed to apply report action", err)
+		return
+	}
+
+	admin := c.GetString("username")
+	resolved, err := h.reportRepo.ResolveOpen(ctx, report.TemplateID, req.Action, admin, time.Now())
+	if err != nil {
+		respondInternalError(c, "Failed to resolve reports", err)
+		return
+	}
+
+	// Reports stay resolved even if a notification fails to send
+	for _, closed := range resolved {
+		if err := h.notifier.NotifyReportResolved(ctx, closed); err != nil {
+			log.Printf("Failed to notify reporter of report %s: %v", closed.ID, err)
+		}
+	}
+
+	details := fmt.Sprintf("resolved report %s and closed %d open reports", report.ID, len(resolved))
+	if req.Note != "" {
+		details += ": " + req.Note
+	}
+	h.audit(ctx, &models.AuditEntry{
+		Actor:      admin,
+		Action:     req.Action,
+		TargetType: "template",
+		TargetID:   report.TemplateID,
+		Details:    details,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"template_id": report.TemplateID,
+		"action":      req.Action,
+		"resolved":    len(resolved),
+		"reports":     resolved,
+	})
+}
+
+// applyResolution performs a resolution action on a reported template.
+// Dismissing lists a template again only when reports unlisted it
+// automatically; one a site admin unlisted stays hidden. A template that is
+// already gone needs nothing done.
+func (h *ReportHandler) applyResolution(ctx context.Context, templateID, action string) error {
+	template, err := h.templateRepo.GetByID(ctx, templateID)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	if template == nil {
+		return nil
+	}
+
+	switch action {
+	case models.ReportActionDismiss:
+		if template.Unlisted && template.UnlistedReason == models.UnlistReasonReports {
+			return h.templateRepo.SetUnlisted(ctx, templateID, "")
+		}
+	case models.ReportActionUnlistTemplate:
+		return h.templateRepo.SetUnlisted(ctx, templateID, models.UnlistReasonModeration)
+	case models.ReportActionDeleteTemplate:
+		if err := h.templateRepo.Delete(ctx, templateID); err != nil && !isNotFound(err) {
+			return err
+		}
+		if h.images != nil {
+			if err := h.images.RemoveAll(templateID); err != nil {
+				log.Printf("Failed to remove images of template %s: %v", templateID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// audit records a moderation action. A failed write is logged rather than
+// undoing an action that already happened.
+func (h *ReportHandler) audit(ctx context.Context, entry *models.AuditEntry) {
+	entry.ID = uuid.New().String()
+	entry.CreatedAt = time.Now()
+	log.Printf("Audit: %s %s %s %s: %s", entry.Actor, entry.Action, entry.TargetType, entry.TargetID, entry.Details)
+
+	if h.auditRepo == nil {
+		return
+	}
+	if err := h.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("Failed to write audit entry for %s %s: %v", entry.TargetType, entry.TargetID, err)
+	}
+}
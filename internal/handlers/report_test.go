@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordingReportNotifier remembers the reports it was told about
+type recordingReportNotifier struct {
+	notified []string
+}
+
+func (n *recordingReportNotifier) NotifyReportResolved(ctx context.Context, report *models.TemplateReport) error {
+	n.notified = append(n.notified, report.ReporterUsername+":"+report.Action)
+	return nil
+}
+
+type reportFixture struct {
+	router    *gin.Engine
+	templates *memory.TemplateRepository
+	reports   *memory.ReportRepository
+	audit     *memory.AuditRepository
+	notifier  *recordingReportNotifier
+}
+
+func newReportFixture(t *testing.T, threshold int) *reportFixture {
+	t.Helper()
+
+	f := &reportFixture{
+		templates: memory.NewTemplateRepository(),
+		reports:   memory.NewReportRepository(),
+		audit:     memory.NewAuditRepository(),
+		notifier:  &recordingReportNotifier{},
+	}
+	err := f.templates.Create(context.Background(), &models.StoredTemplate{
+		ID:       "reported",
+		Template: models.Template{Public: true, Metadata: models.ShareMetadata{Name: "Suspicious Setup", Author: "mallory", Tags: []string{"reported"}}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	h := NewReportHandler(f.reports, f.templates, f.audit, NewAuthorizer(memory.NewOrganizationRepository()))
+	h.ConfigureAutoUnlist(threshold)
+	h.ConfigureNotifier(f.notifier)
+
+	f.router = gin.New()
+	f.router.Use(withTestUser())
+	f.router.POST("/api/templates/:id/report", h.ReportTemplate)
+	f.router.GET("/api/admin/reports", h.ListReports)
+	f.router.POST("/api/admin/reports/:id/resolve", h.ResolveReport)
+	return f
+}
+
+func (f *reportFixture) do(method, url, username, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if username != "" {
+		req.Header.Set("X-Test-User", username+"-id")
+		req.Header.Set("X-Test-Username", username)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+// report files a report as username and returns the new report's ID
+func (f *reportFixture) report(t *testing.T, username, reason string) string {
+	t.Helper()
+	w := f.do(http.MethodPost, "/api/templates/reported/report", username, fmt.Sprintf(`{"reason": %q}`, reason))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 reporting as %s, got %d: %s", username, w.Code, w.Body.String())
+	}
+	report := decodeBody(t, w)["report"].(map[string]interface{})
+	return report["id"].(string)
+}
+
+func (f *reportFixture) listed(t *testing.T) bool {
+	t.Helper()
+	templates, err := f.templates.List(context.Background(), repository.TemplateFilters{Author: "mallory"})
+	if err != nil {
+		t.Fatalf("Failed to list templates: %v", err)
+	}
+	return len(templates) == 1
+}
+
+func TestReportTemplate(t *testing.T) {
+	f := newReportFixture(t, 0)
+
+	if w := f.do(http.MethodPost, "/api/templates/reported/report", "", `{"reason": "spam"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 when signed out, got %d", w.Code)
+	}
+	w := f.do(http.MethodPost, "/api/templates/reported/report", "alice", `{"reason": "ugly"}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "must be one of: malicious, spam, broken, other") {
+		t.Errorf("Expected status 400 for an unknown reason, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := f.do(http.MethodPost, "/api/templates/missing/report", "alice", `{"reason": "spam"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown template, got %d", w.Code)
+	}
+
+	f.report(t, "alice", models.ReportReasonMalicious)
+	if w := f.do(http.MethodPost, "/api/templates/reported/report", "alice", `{"reason": "spam"}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for a second open report, got %d: %s", w.Code, w.Body.String())
+	}
+	if !f.listed(t) {
+		t.Error("Expected the template to stay listed with automatic unlisting off")
+	}
+}
+
+func TestReportThresholdUnlistsTemplate(t *testing.T) {
+	f := newReportFixture(t, 3)
+
+	f.report(t, "alice", models.ReportReasonMalicious)
+	f.report(t, "bob", models.ReportReasonSpam)
+	if !f.listed(t) {
+		t.Fatal("Expected the template to stay listed below the threshold")
+	}
+
+	f.report(t, "carol", models.ReportReasonMalicious)
+	if f.listed(t) {
+		t.Fatal("Expected the template to be unlisted at the threshold")
+	}
+	template, err := f.templates.GetByID(context.Background(), "reported")
+	if err != nil || template == nil || !template.Unlisted {
+		t.Fatalf("Expected the template to stay reachable by ID while unlisted, got %v, %v", template, err)
+	}
+
+	entries, _ := f.audit.List(context.Background(), 0, 0)
+	if len(entries) != 1 || entries[0].Action != "auto_unlist_template" || entries[0].TargetID != "reported" {
+		t.Errorf("Expected an audit entry for the automatic unlisting, got %+v", entries)
+	}
+
+	w := f.do(http.MethodGet, "/api/admin/reports", "admin", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	groups := decodeBody(t, w)["templates"].([]interface{})
+	if len(groups) != 1 {
+		t.Fatalf("Expected one reported template, got %v", groups)
+	}
+	group := groups[0].(map[string]interface{})
+	reasons := group["reasons"].(map[string]interface{})
+	if group["open_reports"] != float64(3) || group["template_name"] != "Suspicious Setup" || group["unlisted"] != true || reasons["malicious"] != float64(2) {
+		t.Errorf("Expected three open reports on the unlisted template, got %v", group)
+	}
+}
+
+func TestResolveReport(t *testing.T) {
+	tests := []struct {
+		action     string
+		wantListed bool
+		wantExists bool
+	}{
+		{action: models.ReportActionDismiss, wantListed: true, wantExists: true},
+		{action: models.ReportActionUnlistTemplate, wantListed: false, wantExists: true},
+		{action: models.ReportActionDeleteTemplate, wantListed: false, wantExists: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			// A threshold of two unlists the template before the admin acts
+			f := newReportFixture(t, 2)
+			id := f.report(t, "alice", models.ReportReasonBroken)
+			f.report(t, "bob", models.ReportReasonOther)
+
+			w := f.do(http.MethodPost, "/api/admin/reports/"+id+"/resolve", "admin", fmt.Sprintf(`{"action": %q, "note": "checked the hooks"}`, tt.action))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if resolved := decodeBody(t, w)["resolved"]; resolved != float64(2) {
+				t.Errorf("Expected both reports to be closed, got %v", resolved)
+			}
+
+			if got := f.listed(t); got != tt.wantListed {
+				t.Errorf("Expected listed=%v, got %v", tt.wantListed, got)
+			}
+			template, _ := f.templates.GetByID(context.Background(), "reported")
+			if exists := template != nil; exists != tt.wantExists {
+				t.Errorf("Expected exists=%v, got %v", tt.wantExists, exists)
+			}
+
+			if open, _ := f.reports.CountOpen(context.Background(), "reported"); open != 0 {
+				t.Errorf("Expected no open reports, got %d", open)
+			}
+			if len(f.notifier.notified) != 2 {
+				t.Errorf("Expected both reporters to be notified, got %v", f.notifier.notified)
+			}
+			entries, _ := f.audit.List(context.Background(), 1, 0)
+			if len(entries) != 1 || entries[0].Actor != "admin" || entries[0].Action != tt.action || !strings.Contains(entries[0].Details, "checked the hooks") {
+				t.Errorf("Expected an audit entry for the resolution, got %+v", entries)
+			}
+
+			if w := f.do(http.MethodPost, "/api/admin/reports/"+id+"/resolve", "admin", `{"action": "dismiss"}`); w.Code != http.StatusConflict {
+				t.Errorf("Expected status 409 resolving a closed report, got %d", w.Code)
+			}
+			if tt.wantExists {
+				// Resolved reports no longer block reporting again
+				f.report(t, "alice", models.ReportReasonBroken)
+			}
+		})
+	}
+}
+
+func TestDismissKeepsModeratedTemplatesUnlisted(t *testing.T) {
+	f := newReportFixture(t, 0)
+
+	id := f.report(t, "alice", models.ReportReasonMalicious)
+	if w := f.do(http.MethodPost, "/api/admin/reports/"+id+"/resolve", "admin", `{"action": "unlist_template"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 unlisting, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// A later report against the unlisted template is dismissed
+	id = f.report(t, "bob", models.ReportReasonSpam)
+	if w := f.do(http.MethodPost, "/api/admin/reports/"+id+"/resolve", "admin", `{"action": "dismiss"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 dismissing, got %d: %s", w.Code, w.Body.String())
+	}
+	if f.listed(t) {
+		t.Error("Expected dismissing a report to leave a template an admin unlisted hidden")
+	}
+	template, _ := f.templates.GetByID(context.Background(), "reported")
+	if template == nil || template.UnlistedReason != models.UnlistReasonModeration {
+		t.Errorf("Expected the template to stay unlisted by moderation, got %+v", template)
+	}
+}
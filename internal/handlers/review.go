@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"context"
+	stderrors "errors"
+	"fmt"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/language"
 	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/pagination"
 	"dotfiles-api/internal/repository"
 	"dotfiles-api/pkg/errors"
 
@@ -15,16 +21,28 @@ import (
 
 // ReviewHandler handles review-related HTTP requests
 type ReviewHandler struct {
-	reviewRepo repository.ReviewRepository
+	reviewRepo   repository.ReviewRepository
+	templateRepo repository.TemplateRepository
+	authorizer   *Authorizer
+	commentRules dto.ReviewCommentRules
 }
 
 // NewReviewHandler creates a new review handler
-func NewReviewHandler(reviewRepo repository.ReviewRepository) *ReviewHandler {
+func NewReviewHandler(reviewRepo repository.ReviewRepository, templateRepo repository.TemplateRepository, authorizer *Authorizer) *ReviewHandler {
 	return &ReviewHandler{
-		reviewRepo: reviewRepo,
+		reviewRepo:   reviewRepo,
+		templateRepo: templateRepo,
+		authorizer:   authorizer,
 	}
 }
 
+// ConfigureComments sets the shortest comment a review may have and whether
+// reviews need one at all. Rating-only reviews can still be left through
+// RateTemplate.
+func (h *ReviewHandler) ConfigureComments(minLength int, required bool) {
+	h.commentRules = dto.ReviewCommentRules{MinLength: minLength, Required: required}
+}
+
 // isAvailable checks if the handler is available (has required dependencies)
 func (h *ReviewHandler) isAvailable() bool {
 	return h.reviewRepo != nil
@@ -52,37 +70,176 @@ func (h *ReviewHandler) GetTemplateReviews(c *gin.Context) {
 		return
 	}
 
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
+	languages, ok := parseLanguageFilter(c)
+	if !ok {
+		return
+	}
+	limit, offset := parsePagination(c)
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
+	reviews, err := h.reviewRepo.GetByTemplate(c.Request.Context(), templateID, languages, limit, offset)
+	if err != nil {
+		respondInternalError(c, "Failed to get reviews", err)
+		return
 	}
-	if limit > 100 {
-		limit = 100
+	for _, review := range reviews {
+		review.AvatarProxyURL = dto.AvatarProxyPath(review.UserID)
+		if review.Language == "" {
+			review.Language = language.Undetermined
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reviews": reviews,
+		"limit":   limit,
+		"offset":  offset,
+		"links":   pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, pagination.PageTotal(offset, limit, len(reviews))),
+	})
+}
+
+// GetMyReviews handles listing the reviews the current user wrote, newest
+// first, each with the name of the reviewed template
+func (h *ReviewHandler) GetMyReviews(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
 	}
 
-	reviews, err := h.reviewRepo.GetByTemplate(c.Request.Context(), templateID, limit, offset)
+	limit, offset := parsePagination(c)
+
+	reviews, err := h.reviewRepo.GetByUser(c.Request.Context(), userID.(string), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to get reviews", err),
-		})
+		respondInternalError(c, "Failed to get reviews", err)
 		return
 	}
 
+	// Several reviews rarely share a template, but look each one up once
+	templateNames := make(map[string]string)
+	response := make([]dto.ReviewResponse, len(reviews))
+	for i, review := range reviews {
+		name, seen := templateNames[review.TemplateID]
+		if !seen {
+			if name, err = templateName(c.Request.Context(), h.templateRepo, review.TemplateID); err != nil {
+				respondInternalError(c, "Failed to get reviewed template", err)
+				return
+			}
+			templateNames[review.TemplateID] = name
+		}
+
+		response[i] = toReviewResponse(review, c.GetString("username"))
+		response[i].TemplateName = name
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"reviews": reviews,
+		"reviews": response,
 		"limit":   limit,
 		"offset":  offset,
+		"links":   pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, pagination.PageTotal(offset, limit, len(response))),
 	})
 }
 
+// GetMyReviewForTemplate handles getting the current user's review of a
+// template. Not having reviewed it yet is a normal state for the review
+// form, so it answers 200 with a null review rather than 404.
+func (h *ReviewHandler) GetMyReviewForTemplate(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
+	}
+
+	review, err := h.reviewRepo.GetUserReviewForTemplate(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		respondInternalError(c, "Failed to get review", err)
+		return
+	}
+
+	if review == nil {
+		c.JSON(http.StatusOK, gin.H{"review": nil})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"review": toReviewResponse(review, c.GetString("username"))})
+}
+
+// templateName returns a template's name, or "" when it no longer exists
+func templateName(ctx context.Context, templateRepo repository.TemplateRepository, templateID string) (string, error) {
+	if templateRepo == nil {
+		return "", nil
+	}
+
+	template, err := templateRepo.GetByID(ctx, templateID)
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if template == nil {
+		return "", nil
+	}
+	return template.Template.Metadata.Name, nil
+}
+
+// parseLanguageFilter reads the ?lang= parameters, each a language code or
+// a comma-separated list of them. Unsupported codes are a 400.
+func parseLanguageFilter(c *gin.Context) ([]string, bool) {
+	var languages []string
+	for _, value := range c.QueryArray("lang") {
+		for _, code := range strings.Split(value, ",") {
+			code = strings.ToLower(strings.TrimSpace(code))
+			if code == "" {
+				continue
+			}
+			if !language.IsSupported(code) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": errors.NewValidationError(fmt.Sprintf("lang must be one of %s, got %q",
+						strings.Join(append(language.Supported(), language.Undetermined), ", "), code)),
+				})
+				return nil, false
+			}
+			languages = append(languages, code)
+		}
+	}
+	return languages, true
+}
+
+// toReviewResponse converts a review written by the named user
+func toReviewResponse(review *models.Review, username string) dto.ReviewResponse {
+	response := dto.ReviewResponse{
+		ID:             review.ID,
+		TemplateID:     review.TemplateID,
+		UserID:         review.UserID,
+		Username:       username,
+		AvatarURL:      review.AvatarURL,
+		AvatarProxyURL: dto.AvatarProxyPath(review.UserID),
+		Rating:         review.Rating,
+		Comment:        review.Comment,
+		Language:       review.Language,
+		Helpful:        review.Helpful,
+		CreatedAt:      review.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:      review.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+	if response.Language == "" {
+		response.Language = language.Undetermined
+	}
+	return response
+}
+
 // CreateReview handles creating a new review
 func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	if !h.isAvailable() {
@@ -112,19 +269,18 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 		Comment string `json:"comment"`
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewValidationError("Invalid request format"),
-		})
+	if !bindJSON(c, &req) {
+		return
+	}
+	if appErr := h.commentRules.Check(req.Comment); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
 		return
 	}
 
 	// Check if user already reviewed this template
 	existingReview, err := h.reviewRepo.GetUserReviewForTemplate(c.Request.Context(), userID.(string), templateID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to check existing review", err),
-		})
+		respondInternalError(c, "Failed to check existing review", err)
 		return
 	}
 
@@ -141,17 +297,25 @@ func (h *ReviewHandler) CreateReview(c *gin.Context) {
 		UserID:     userID.(string),
 		Rating:     req.Rating,
 		Comment:    req.Comment,
+		Language:   language.Detect(req.Comment),
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
 
+	// The store enforces one review per user too, catching a concurrent
+	// submit that passed the check above
 	if err := h.reviewRepo.Create(c.Request.Context(), review); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to create review", err),
-		})
+		if stderrors.Is(err, repository.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": errors.NewConflictError("User has already reviewed this template"),
+			})
+			return
+		}
+		respondInternalError(c, "Failed to create review", err)
 		return
 	}
 
+	review.AvatarProxyURL = dto.AvatarProxyPath(review.UserID)
 	c.JSON(http.StatusCreated, gin.H{
 		"review": review,
 		"message": "Review created successfully",
@@ -175,15 +339,100 @@ func (h *ReviewHandler) GetTemplateRating(c *gin.Context) {
 
 	rating, err := h.reviewRepo.CalculateTemplateRating(c.Request.Context(), templateID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to calculate rating", err),
-		})
+		respondInternalError(c, "Failed to calculate rating", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, rating)
 }
 
+// RateTemplate handles a quick rating: it sets the caller's rating of a
+// template, creating a review without a comment if they have none. Any
+// existing comment is kept, and the comment rules do not apply. Templates
+// the caller may not see cannot be rated.
+func (h *ReviewHandler) RateTemplate(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	templateID := c.Param("id")
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
+	}
+
+	var req struct {
+		Rating int `json:"rating" binding:"required,min=1,max=5"`
+	}
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if h.templateRepo != nil {
+		template, err := h.templateRepo.GetByID(c.Request.Context(), templateID)
+		if err != nil && !isNotFound(err) {
+			respondInternalError(c, "Failed to get template", err)
+			return
+		}
+		if template == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("Template")})
+			return
+		}
+
+		// Templates the caller may not see are reported as not found
+		visible, err := h.authorizer.CanViewTemplate(c, template)
+		if err != nil {
+			respondInternalError(c, "Failed to check template visibility", err)
+			return
+		}
+		if !visible {
+			c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("Template")})
+			return
+		}
+	}
+
+	review, err := h.reviewRepo.GetUserReviewForTemplate(c.Request.Context(), userID.(string), templateID)
+	if err != nil {
+		respondInternalError(c, "Failed to check existing review", err)
+		return
+	}
+
+	status := http.StatusOK
+	if review != nil {
+		review.Rating = req.Rating
+		review.UpdatedAt = time.Now()
+		err = h.reviewRepo.Update(c.Request.Context(), review)
+	} else {
+		review = &models.Review{
+			ID:         uuid.New().String(),
+			TemplateID: templateID,
+			UserID:     userID.(string),
+			Rating:     req.Rating,
+			Language:   language.Undetermined,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+		status = http.StatusCreated
+		err = h.reviewRepo.Create(c.Request.Context(), review)
+	}
+	if err != nil {
+		if stderrors.Is(err, repository.ErrAlreadyExists) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": errors.NewConflictError("User has already reviewed this template"),
+			})
+			return
+		}
+		respondInternalError(c, "Failed to save rating", err)
+		return
+	}
+
+	c.JSON(status, gin.H{"review": toReviewResponse(review, c.GetString("username"))})
+}
+
 // UpdateReview handles updating a review
 func (h *ReviewHandler) UpdateReview(c *gin.Context) {
 	if !h.isAvailable() {
@@ -211,9 +460,7 @@ func (h *ReviewHandler) UpdateReview(c *gin.Context) {
 	// Get existing review
 	review, err := h.reviewRepo.GetByID(c.Request.Context(), reviewID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to get review", err),
-		})
+		respondInternalError(c, "Failed to get review", err)
 		return
 	}
 
@@ -232,29 +479,37 @@ func (h *ReviewHandler) UpdateReview(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Rating  int    `json:"rating" binding:"required,min=1,max=5"`
-		Comment string `json:"comment"`
+	// Fields left out of the body keep their current values
+	var req dto.UpdateReviewRequest
+	if !bindJSON(c, &req) {
+		return
 	}
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewValidationError("Invalid request format"),
-		})
+	if appErr := req.Validate(); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
 		return
 	}
+	if req.Comment != nil {
+		if appErr := h.commentRules.Check(*req.Comment); appErr != nil {
+			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+			return
+		}
+	}
 
-	review.Rating = req.Rating
-	review.Comment = req.Comment
+	if req.Rating != nil {
+		review.Rating = *req.Rating
+	}
+	if req.Comment != nil {
+		review.Comment = *req.Comment
+		review.Language = language.Detect(review.Comment)
+	}
 	review.UpdatedAt = time.Now()
 
 	if err := h.reviewRepo.Update(c.Request.Context(), review); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to update review", err),
-		})
+		respondInternalError(c, "Failed to update review", err)
 		return
 	}
 
+	review.AvatarProxyURL = dto.AvatarProxyPath(review.UserID)
 	c.JSON(http.StatusOK, gin.H{
 		"review": review,
 		"message": "Review updated successfully",
@@ -288,9 +543,7 @@ func (h *ReviewHandler) DeleteReview(c *gin.Context) {
 	// Get existing review
 	review, err := h.reviewRepo.GetByID(c.Request.Context(), reviewID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to get review", err),
-		})
+		respondInternalError(c, "Failed to get review", err)
 		return
 	}
 
@@ -310,9 +563,7 @@ func (h *ReviewHandler) DeleteReview(c *gin.Context) {
 	}
 
 	if err := h.reviewRepo.Delete(c.Request.Context(), reviewID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to delete review", err),
-		})
+		respondInternalError(c, "Failed to delete review", err)
 		return
 	}
 
@@ -348,9 +599,7 @@ func (h *ReviewHandler) MarkReviewHelpful(c *gin.Context) {
 	// Check if review exists
 	review, err := h.reviewRepo.GetByID(c.Request.Context(), reviewID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to get review", err),
-		})
+		respondInternalError(c, "Failed to get review", err)
 		return
 	}
 
@@ -362,9 +611,7 @@ func (h *ReviewHandler) MarkReviewHelpful(c *gin.Context) {
 	}
 
 	if err := h.reviewRepo.IncrementHelpful(c.Request.Context(), reviewID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("Failed to mark review as helpful", err),
-		})
+		respondInternalError(c, "Failed to mark review as helpful", err)
 		return
 	}
 
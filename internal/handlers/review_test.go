@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetMyReviews(t *testing.T) {
+	ctx := context.Background()
+	reviewRepo := memory.NewReviewRepository()
+	templateRepo := memory.NewTemplateRepository()
+
+	for id, name := range map[string]string{"node": "Node Setup", "python": "Python Setup"} {
+		if err := templateRepo.Create(ctx, &models.StoredTemplate{
+			ID:       id,
+			Template: models.Template{Metadata: models.ShareMetadata{Name: name}},
+		}); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	for _, review := range []*models.Review{
+		{ID: "old", TemplateID: "node", UserID: "alice", Rating: 4},
+		{ID: "orphan", TemplateID: "deleted", UserID: "alice", Rating: 2},
+		{ID: "other", TemplateID: "node", UserID: "bob", Rating: 5},
+		{ID: "new", TemplateID: "python", UserID: "alice", Rating: 5, Comment: "Even better"},
+	} {
+		if err := reviewRepo.Create(ctx, review); err != nil {
+			t.Fatalf("Failed to create review: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	h := NewReviewHandler(reviewRepo, templateRepo, NewAuthorizer(memory.NewOrganizationRepository()))
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/me/reviews", h.GetMyReviews)
+
+	get := func(url, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+			req.Header.Set("X-Test-Username", userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get("/api/me/reviews", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without a user, got %d", w.Code)
+	}
+
+	w := get("/api/me/reviews", "alice")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	reviews := decodeBody(t, w)["reviews"].([]interface{})
+	if len(reviews) != 3 {
+		t.Fatalf("Expected alice's 3 reviews, got %v", reviews)
+	}
+
+	want := []struct{ id, templateName string }{
+		{"new", "Python Setup"},
+		{"orphan", ""},
+		{"old", "Node Setup"},
+	}
+	for i, expected := range want {
+		review := reviews[i].(map[string]interface{})
+		name, _ := review["template_name"].(string)
+		if review["id"] != expected.id || name != expected.templateName || review["username"] != "alice" {
+			t.Errorf("Review %d: expected %s on %q, got %v", i, expected.id, expected.templateName, review)
+		}
+	}
+
+	w = get("/api/me/reviews?limit=1&offset=1", "alice")
+	reviews = decodeBody(t, w)["reviews"].([]interface{})
+	if len(reviews) != 1 || reviews[0].(map[string]interface{})["id"] != "orphan" {
+		t.Errorf("Expected the second review alone, got %v", reviews)
+	}
+}
+
+func TestGetMyReviewForTemplate(t *testing.T) {
+	reviewRepo := memory.NewReviewRepository()
+	if err := reviewRepo.Create(context.Background(), &models.Review{ID: "mine", TemplateID: "node", UserID: "alice", Rating: 4, Comment: "Solid"}); err != nil {
+		t.Fatalf("Failed to create review: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates/:id/reviews/user", NewReviewHandler(reviewRepo, memory.NewTemplateRepository(), NewAuthorizer(memory.NewOrganizationRepository())).GetMyReviewForTemplate)
+
+	get := func(templateID, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/templates/"+templateID+"/reviews/user", nil)
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+			req.Header.Set("X-Test-Username", userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get("node", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without a user, got %d", w.Code)
+	}
+
+	w := get("node", "alice")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	review, ok := decodeBody(t, w)["review"].(map[string]interface{})
+	if !ok || review["id"] != "mine" || review["rating"] != float64(4) || review["username"] != "alice" {
+		t.Errorf("Expected alice's review, got %v", review)
+	}
+
+	// Not having reviewed yet is not an error
+	for _, tc := range []struct{ templateID, userID string }{{"node", "bob"}, {"python", "alice"}} {
+		w := get(tc.templateID, tc.userID)
+		body := decodeBody(t, w)
+		if w.Code != http.StatusOK || body["review"] != nil {
+			t.Errorf("Expected 200 with a null review for %s on %s, got %d %v", tc.userID, tc.templateID, w.Code, body)
+		}
+		if _, present := body["review"]; !present {
+			t.Errorf("Expected the review key to be present, got %v", body)
+		}
+	}
+}
+
+func TestUpdateReviewPartially(t *testing.T) {
+	reviewRepo := memory.NewReviewRepository()
+	if err := reviewRepo.Create(context.Background(), &models.Review{ID: "mine", TemplateID: "node", UserID: "alice", Rating: 4, Comment: "Solid"}); err != nil {
+		t.Fatalf("Failed to create review: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.PATCH("/api/reviews/:id", NewReviewHandler(reviewRepo, memory.NewTemplateRepository(), NewAuthorizer(memory.NewOrganizationRepository())).UpdateReview)
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/reviews/mine", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", "alice")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	stored := func() *models.Review {
+		review, err := reviewRepo.GetByID(context.Background(), "mine")
+		if err != nil {
+			t.Fatalf("Failed to get review: %v", err)
+		}
+		return review
+	}
+
+	if w := patch(`{"comment": "Even better with the hooks"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for a comment-only update, got %d: %s", w.Code, w.Body.String())
+	}
+	if review := stored(); review.Rating != 4 || review.Comment != "Even better with the hooks" {
+		t.Errorf("Expected the rating to be kept, got %+v", review)
+	}
+
+	if w := patch(`{"rating": 5}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for a rating-only update, got %d: %s", w.Code, w.Body.String())
+	}
+	if review := stored(); review.Rating != 5 || review.Comment != "Even better with the hooks" {
+		t.Errorf("Expected the comment to be kept, got %+v", review)
+	}
+
+	for _, body := range []string{`{}`, `{"rating": 0}`, `{"rating": 6}`} {
+		if w := patch(body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+	if review := stored(); review.Rating != 5 {
+		t.Errorf("Expected rejected updates to change nothing, got %+v", review)
+	}
+}
+
+func TestReviewCommentRules(t *testing.T) {
+	ctx := context.Background()
+	reviewRepo := memory.NewReviewRepository()
+	templateRepo := memory.NewTemplateRepository()
+	if err := templateRepo.Create(ctx, &models.StoredTemplate{ID: "node", Template: models.Template{Public: true}}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	h := NewReviewHandler(reviewRepo, templateRepo, NewAuthorizer(memory.NewOrganizationRepository()))
+	h.ConfigureComments(10, true)
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/templates/:id/reviews", h.CreateReview)
+	r.PUT("/api/templates/:id/rating", h.RateTemplate)
+
+	send := func(method, url, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for body, field := range map[string]string{
+		`{"rating": 5}`:                       "is required",
+		`{"rating": 5, "comment": "   "}`:     "is required",
+		`{"rating": 5, "comment": "Great  "}`: "must be at least 10 characters, got 5",
+	} {
+		w := send(http.MethodPost, "/api/templates/node/reviews", "alice", body)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400 for %s, got %d: %s", body, w.Code, w.Body.String())
+		}
+		fields, _ := decodeBody(t, w)["error"].(map[string]interface{})["fields"].(map[string]interface{})
+		if fields["comment"] != field {
+			t.Errorf("Expected %q on the comment for %s, got %v", field, body, fields)
+		}
+	}
+
+	if w := send(http.MethodPost, "/api/templates/node/reviews", "alice", `{"rating": 4, "comment": "Sensible defaults"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for a long enough comment, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Quick rating skips the comment rules, and keeps an existing comment
+	if w := send(http.MethodPut, "/api/templates/node/rating", "alice", `{"rating": 2}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 re-rating, got %d: %s", w.Code, w.Body.String())
+	}
+	if review, _ := reviewRepo.GetUserReviewForTemplate(ctx, "alice", "node"); review == nil || review.Rating != 2 || review.Comment != "Sensible defaults" {
+		t.Errorf("Expected the new rating with the comment kept, got %+v", review)
+	}
+	if w := send(http.MethodPut, "/api/templates/node/rating", "bob", `{"rating": 5}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201 for a first rating, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(http.MethodPut, "/api/templates/missing/rating", "bob", `{"rating": 5}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown template, got %d", w.Code)
+	}
+}
+
+func TestRateTemplateHidesPrivateTemplates(t *testing.T) {
+	ctx := context.Background()
+	reviewRepo := memory.NewReviewRepository()
+	templateRepo := memory.NewTemplateRepository()
+	err := templateRepo.Create(ctx, &models.StoredTemplate{
+		ID:           "private",
+		Template:     models.Template{Metadata: models.ShareMetadata{Author: "alice"}},
+		AllowedUsers: []string{"carol"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	h := NewReviewHandler(reviewRepo, templateRepo, NewAuthorizer(memory.NewOrganizationRepository()))
+	r := gin.New()
+	r.Use(withTestUser())
+	r.PUT("/api/templates/:id/rating", h.RateTemplate)
+
+	rate := func(username string) int {
+		req := httptest.NewRequest(http.MethodPut, "/api/templates/private/rating", strings.NewReader(`{"rating": 1}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", username+"-id")
+		req.Header.Set("X-Test-Username", username)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := rate("bob"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 rating a private template, got %d", code)
+	}
+	if review, _ := reviewRepo.GetUserReviewForTemplate(ctx, "bob-id", "private"); review != nil {
+		t.Errorf("Expected no rating to be stored, got %+v", review)
+	}
+	if code := rate("carol"); code != http.StatusCreated {
+		t.Errorf("Expected status 201 for a user the template is shared with, got %d", code)
+	}
+}
+
+func TestReviewLanguages(t *testing.T) {
+	reviewRepo := memory.NewReviewRepository()
+	h := NewReviewHandler(reviewRepo, memory.NewTemplateRepository(), NewAuthorizer(memory.NewOrganizationRepository()))
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/templates/:id/reviews", h.CreateReview)
+	r.PATCH("/api/reviews/:id", h.UpdateReview)
+	r.GET("/api/templates/:id/reviews", h.GetTemplateReviews)
+	r.GET("/api/templates/:id/rating", h.GetTemplateRating)
+
+	for user, comment := range map[string]string{
+		"alice": "Great template, works perfectly!",
+		"bob":   "Muy buena plantilla, funciona perfectamente.",
+		"carol": "Tolle Vorlage, funktioniert einwandfrei!",
+		"dave":  "👍",
+	} {
+		w := sendAs(r, http.MethodPost, "/api/templates/node/reviews", user, `{"rating": 5, "comment": "`+comment+`"}`)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	languages := func(url string) map[string]bool {
+		t.Helper()
+		w := sendAs(r, http.MethodGet, url, "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 from %s, got %d: %s", url, w.Code, w.Body.String())
+		}
+		found := make(map[string]bool)
+		for _, review := range decodeBody(t, w)["reviews"].([]interface{}) {
+			found[review.(map[string]interface{})["language"].(string)] = true
+		}
+		return found
+	}
+
+	if got := languages("/api/templates/node/reviews"); len(got) != 4 || !got["en"] || !got["es"] || !got["de"] || !got["und"] {
+		t.Errorf("Expected en, es, de and und reviews, got %v", got)
+	}
+	if got := languages("/api/templates/node/reviews?lang=es&lang=DE"); len(got) != 3 || !got["es"] || !got["de"] || !got["und"] {
+		t.Errorf("Expected es, de and und reviews, got %v", got)
+	}
+	if got := languages("/api/templates/node/reviews?lang=en,es"); len(got) != 3 || got["de"] {
+		t.Errorf("Expected en, es and und reviews, got %v", got)
+	}
+	if w := sendAs(r, http.MethodGet, "/api/templates/node/reviews?lang=xx", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unsupported language, got %d", w.Code)
+	}
+
+	w := sendAs(r, http.MethodGet, "/api/templates/node/rating", "", "")
+	counts := decodeBody(t, w)["languages"].(map[string]interface{})
+	if len(counts) != 4 || counts["en"] != float64(1) || counts["und"] != float64(1) {
+		t.Errorf("Expected one review per language, got %v", counts)
+	}
+
+	// Editing the comment detects its language again
+	review, _ := reviewRepo.GetUserReviewForTemplate(context.Background(), "alice-id", "node")
+	if w := sendAs(r, http.MethodPatch, "/api/reviews/"+review.ID, "alice", `{"comment": "Einfach einzurichten und die Anleitung ist klar."}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if review, _ := reviewRepo.GetByID(context.Background(), review.ID); review.Language != "de" {
+		t.Errorf("Expected the edited review to be German, got %q", review.Language)
+	}
+}
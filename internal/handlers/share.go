@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxShareDescription is the longest description social cards display
+// without truncating it themselves
+const maxShareDescription = 200
+
+// ConfigureShareLinks sets the frontend origin that share metadata links
+// to, such as https://dotfiles.example.com. Empty links to the template's
+// API URL instead.
+func (h *TemplateHandler) ConfigureShareLinks(frontendURL string) {
+	h.frontendURL = strings.TrimSuffix(frontendURL, "/")
+}
+
+// GetShareMetadata returns the Open Graph and Twitter Card fields for
+// sharing a template on social media
+func (h *TemplateHandler) GetShareMetadata(c *gin.Context) {
+	template, ok := h.loadVisibleTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	rating, err := h.reviewRepo.CalculateTemplateRating(c.Request.Context(), template.ID)
+	if err != nil {
+		respondInternalError(c, "failed to get template rating", err)
+		return
+	}
+
+	shareURL := shareLink(c, h.frontendURL, "templates", template.ID)
+	c.JSON(http.StatusOK, renderShareMetadata(template, rating, shareURL))
+}
+
+// shareLink returns the frontend page for a resource, such as
+// https://dotfiles.example.com/templates/{id}, or its API URL when no
+// frontend is configured
+func shareLink(c *gin.Context, frontendURL, collection, id string) string {
+	if frontendURL == "" {
+		return fmt.Sprintf("%s://%s/api/%s/%s", requestScheme(c), c.Request.Host, collection, url.PathEscape(id))
+	}
+	return frontendURL + "/" + collection + "/" + url.PathEscape(id)
+}
+
+// renderShareMetadata builds the card served by GetShareMetadata. The image
+// is a shields.io badge, so no image is generated here.
+func renderShareMetadata(template *models.StoredTemplate, rating *models.TemplateRating, shareURL string) dto.ShareMetadataResponse {
+	summary := "No reviews yet"
+	if rating != nil && rating.TotalRatings > 0 {
+		reviews := "reviews"
+		if rating.TotalRatings == 1 {
+			reviews = "review"
+		}
+		summary = fmt.Sprintf("%.1f out of 5 from %d %s", rating.AverageRating, rating.TotalRatings, reviews)
+	}
+
+	return dto.ShareMetadataResponse{
+		Title:         template.Template.Metadata.Name,
+		Description:   truncateShareDescription(template.Template.Metadata.Description),
+		OGImageURL:    fmt.Sprintf("https://img.shields.io/badge/downloads-%d-blue", template.Downloads),
+		TwitterCard:   "summary",
+		URL:           shareURL,
+		DownloadCount: template.Downloads,
+		RatingSummary: summary,
+	}
+}
+
+// ConfigureShareLinks sets the frontend origin that share metadata links
+// to. Empty links to the organization's API URL instead.
+func (h *OrganizationHandler) ConfigureShareLinks(frontendURL string) {
+	h.frontendURL = strings.TrimSuffix(frontendURL, "/")
+}
+
+// truncateShareDescription collapses whitespace and cuts descriptions longer
+// than maxShareDescription, ending them with an ellipsis
+func truncateShareDescription(description string) string {
+	description = strings.Join(strings.Fields(description), " ")
+	if runes := []rune(description); len(runes) > maxShareDescription {
+		description = strings.TrimSpace(string(runes[:maxShareDescription-1])) + "…"
+	}
+	return description
+}
+
+// GetShareMetadata returns the fields for an organization's social card.
+// Private organizations are not shareable and are reported as not found.
+func (h *OrganizationHandler) GetShareMetadata(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	org, err := h.orgRepo.GetBySlug(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		respondInternalError(c, "Failed to get organization", err)
+		return
+	}
+	if org == nil || !org.Public {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": errors.NewNotFoundError("Organization"),
+		})
+		return
+	}
+
+	public := true
+	templateCount, err := h.templateRepo.Count(c.Request.Context(), repository.TemplateFilters{
+		OrganizationID: org.ID,
+		Public:         &public,
+	})
+	if err != nil {
+		respondInternalError(c, "Failed to count organization templates", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.OrganizationShareResponse{
+		Name:          org.Name,
+		Description:   truncateShareDescription(org.Description),
+		MemberCount:   org.MemberCount,
+		TemplateCount: templateCount,
+		OGImageURL:    fmt.Sprintf("https://img.shields.io/badge/templates-%d-blue", templateCount),
+		URL:           shareLink(c, h.frontendURL, "organizations", org.Slug),
+	})
+}
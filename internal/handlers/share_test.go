@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetShareMetadata(t *testing.T) {
+	ctx := context.Background()
+	templateRepo := memory.NewTemplateRepository()
+	reviewRepo := memory.NewReviewRepository()
+
+	for _, template := range []*models.StoredTemplate{
+		{ID: "node", Downloads: 42, Template: models.Template{
+			Public:   true,
+			Metadata: models.ShareMetadata{Name: "Node Setup", Description: strings.Repeat("Node tooling. ", 20)},
+		}},
+		{ID: "private", Template: models.Template{Metadata: models.ShareMetadata{Name: "Private", Author: "alice"}}},
+	} {
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+	if err := templateRepo.IncrementDownloads(ctx, "node"); err != nil {
+		t.Fatalf("Failed to count download: %v", err)
+	}
+	for userID, rating := range map[string]int{"alice": 5, "bob": 4} {
+		if err := reviewRepo.Create(ctx, &models.Review{TemplateID: "node", UserID: userID, Rating: rating}); err != nil {
+			t.Fatalf("Failed to create review: %v", err)
+		}
+	}
+
+	h := NewTemplateHandler(templateRepo, memory.NewTagRepository(), memory.NewUserRepository(), reviewRepo, tags.NewRegistry(), NewAuthorizer(memory.NewOrganizationRepository()))
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates/:id/share", h.GetShareMetadata)
+
+	get := func(url, username string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if username != "" {
+			req.Header.Set("X-Test-User", username+"-id")
+			req.Header.Set("X-Test-Username", username)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("http://api.example.com/api/templates/node/share", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var card dto.ShareMetadataResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &card); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if card.Title != "Node Setup" || card.TwitterCard != "summary" || card.DownloadCount != 43 {
+		t.Errorf("Unexpected card: %+v", card)
+	}
+	if card.OGImageURL != "https://img.shields.io/badge/downloads-43-blue" {
+		t.Errorf("Expected a downloads badge, got %q", card.OGImageURL)
+	}
+	if card.URL != "http://api.example.com/api/templates/node" {
+		t.Errorf("Expected the API URL without a frontend, got %q", card.URL)
+	}
+	if card.RatingSummary != "4.5 out of 5 from 2 reviews" {
+		t.Errorf("Unexpected rating summary %q", card.RatingSummary)
+	}
+	if n := len([]rune(card.Description)); n != maxShareDescription || !strings.HasSuffix(card.Description, "…") {
+		t.Errorf("Expected the description truncated to %d characters, got %d: %q", maxShareDescription, n, card.Description)
+	}
+
+	h.ConfigureShareLinks("https://dotfiles.example.com/")
+	if err := json.Unmarshal(get("/api/templates/node/share", "").Body.Bytes(), &card); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if card.URL != "https://dotfiles.example.com/templates/node" {
+		t.Errorf("Expected a frontend link, got %q", card.URL)
+	}
+
+	if w := get("/api/templates/private/share", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a private template, got %d", w.Code)
+	}
+
+	w = get("/api/templates/private/share", "alice")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected the author to see the card, got %d: %s", w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &card); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if card.RatingSummary != "No reviews yet" {
+		t.Errorf("Unexpected rating summary %q", card.RatingSummary)
+	}
+}
+
+func TestGetOrganizationShareMetadata(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := memory.NewOrganizationRepository()
+	templateRepo := memory.NewTemplateRepository()
+
+	for _, org := range []*models.Organization{
+		{ID: "org-acme", Slug: "acme", Name: "Acme Corp", Description: "Shared  setups\nfor Acme", Public: true, MemberCount: 3},
+		{ID: "org-secret", Slug: "secret", Name: "Secret"},
+	} {
+		if err := orgRepo.Create(ctx, org); err != nil {
+			t.Fatalf("Failed to create organization: %v", err)
+		}
+	}
+	for _, template := range []*models.StoredTemplate{
+		{ID: "acme-1", Template: models.Template{Public: true, OrganizationID: "org-acme"}},
+		{ID: "acme-2", Template: models.Template{Public: true, OrganizationID: "org-acme"}},
+		{ID: "acme-private", Template: models.Template{OrganizationID: "org-acme"}},
+		{ID: "community", Template: models.Template{Public: true}},
+	} {
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := NewOrganizationHandler(orgRepo, memory.NewUserRepository(), templateRepo)
+	r := gin.New()
+	r.GET("/api/organizations/:slug/share", h.GetShareMetadata)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	w := get("http://api.example.com/api/organizations/acme/share")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var card dto.OrganizationShareResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &card); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := dto.OrganizationShareResponse{
+		Name:          "Acme Corp",
+		Description:   "Shared setups for Acme",
+		MemberCount:   3,
+		TemplateCount: 2,
+		OGImageURL:    "https://img.shields.io/badge/templates-2-blue",
+		URL:           "http://api.example.com/api/organizations/acme",
+	}
+	if card != want {
+		t.Errorf("Expected %+v, got %+v", want, card)
+	}
+
+	h.ConfigureShareLinks("https://dotfiles.example.com")
+	if err := json.Unmarshal(get("/api/organizations/acme/share").Body.Bytes(), &card); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if card.URL != "https://dotfiles.example.com/organizations/acme" {
+		t.Errorf("Expected a frontend link, got %q", card.URL)
+	}
+
+	for _, slug := range []string{"secret", "missing"} {
+		if w := get("/api/organizations/" + slug + "/share"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for %q, got %d", slug, w.Code)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/middleware"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// shareLinkTokenBytes is how much randomness a share link token carries
+const shareLinkTokenBytes = 32
+
+// ConfigureShareLinkStore enables share links, which let authors show a
+// private template to people who cannot otherwise see it
+func (h *TemplateHandler) ConfigureShareLinkStore(links repository.ShareLinkRepository) {
+	h.shareLinks = links
+}
+
+// CreateShareLink creates a link anyone can use to see a template, whatever
+// its visibility (author only). The token is only ever returned in this
+// response.
+func (h *TemplateHandler) CreateShareLink(c *gin.Context) {
+	if !h.requireShareLinks(c) {
+		return
+	}
+
+	var req dto.CreateShareLinkRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	template, ok := h.loadACLTemplate(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	now := time.Now()
+	existing, err := h.shareLinks.ListUsable(ctx, template.ID, now)
+	if err != nil {
+		respondInternalError(c, "failed to list share links", err)
+		return
+	}
+	if len(existing) >= dto.MaxTemplateShareLinks {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError(fmt.Sprintf("a template can have at most %d active share links", dto.MaxTemplateShareLinks)),
+		})
+		return
+	}
+
+	token, err := generateShareLinkToken()
+	if err != nil {
+		respondInternalError(c, "failed to generate share link token", err)
+		return
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = dto.DefaultShareLinkDays
+	}
+	link := &models.TemplateShareLink{
+		TemplateID: template.ID,
+		Token:      token,
+		CreatedBy:  c.GetString("user_id"),
+		CreatedAt:  now,
+		ExpiresAt:  now.AddDate(0, 0, days),
+		MaxUses:    req.MaxUses,
+	}
+	if err := h.shareLinks.Create(ctx, link); err != nil {
+		respondInternalError(c, "failed to create share link", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"share_link": link,
+		"url":        fmt.Sprintf("%s://%s/api/templates/shared/%s", requestScheme(c), c.Request.Host, token),
+	})
+}
+
+// ListShareLinks lists a template's usable share links, newest first,
+// without their tokens (author only)
+func (h *TemplateHandler) ListShareLinks(c *gin.Context) {
+	if !h.requireShareLinks(c) {
+		return
+	}
+
+	template, ok := h.loadACLTemplate(c)
+	if !ok {
+		return
+	}
+
+	links, err := h.shareLinks.ListUsable(c.Request.Context(), template.ID, time.Now())
+	if err != nil {
+		respondInternalError(c, "failed to list share links", err)
+		return
+	}
+	if links == nil {
+		links = []*models.TemplateShareLink{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"share_links": links})
+}
+
+// DeleteShareLink revokes a share link (author only)
+func (h *TemplateHandler) DeleteShareLink(c *gin.Context) {
+	if !h.requireShareLinks(c) {
+		return
+	}
+
+	template, ok := h.loadACLTemplate(c)
+	if !ok {
+		return
+	}
+
+	if err := h.shareLinks.Delete(c.Request.Context(), template.ID, c.Param("linkId")); err != nil {
+		if isNotFound(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("share link")})
+			return
+		}
+		respondInternalError(c, "failed to delete share link", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked successfully"})
+}
+
+// GetSharedTemplate returns the template a share link points at, using up
+// one of the link's uses
+func (h *TemplateHandler) GetSharedTemplate(c *gin.Context) {
+	template, ok := h.useShareLink(c)
+	if !ok {
+		return
+	}
+
+	response := toTemplateResponse(template)
+	if legacyCompatRequested(c) {
+		response.WithLegacyAliases()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DownloadSharedTemplate is DownloadTemplate through a share link. It uses
+// up one of the link's uses but is not counted as a download, so previews
+// do not count towards the template's downloads or trending.
+func (h *TemplateHandler) DownloadSharedTemplate(c *gin.Context) {
+	template, ok := h.useShareLink(c)
+	if !ok {
+		return
+	}
+
+	info := h.downloadInfo(c, template)
+	if legacyCompatRequested(c) {
+		c.JSON(http.StatusOK, dto.NewLegacyTemplate(template.Template, info))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.TemplateDownload{Template: template.Template, DownloadInfo: info})
+}
+
+// useShareLink resolves the share link token in the path to its template.
+// HEAD requests only check the link, so they do not use it up. Unknown and
+// revoked tokens are not found; expired and used-up links are gone.
+func (h *TemplateHandler) useShareLink(c *gin.Context) (*models.StoredTemplate, bool) {
+	if !h.requireShareLinks(c) {
+		return nil, false
+	}
+
+	ctx := c.Request.Context()
+	token := c.Param("token")
+	now := time.Now()
+
+	var link *models.TemplateShareLink
+	var err error
+	if middleware.IsHead(c) {
+		link, err = h.shareLinks.GetByToken(ctx, token)
+		if err == nil && !link.Usable(now) {
+			err = repository.ErrShareLinkUnusable
+		}
+	} else {
+		link, err = h.shareLinks.Use(ctx, token, now)
+	}
+	switch {
+	case isNotFound(err):
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("share link")})
+		return nil, false
+	case stderrors.Is(err, repository.ErrShareLinkUnusable):
+		c.JSON(http.StatusGone, gin.H{
+			"error": errors.NewGoneError("This share link has expired or been used up"),
+		})
+		return nil, false
+	case err != nil:
+		respondInternalError(c, "failed to resolve share link", err)
+		return nil, false
+	}
+
+	return h.loadTemplate(c, link.TemplateID)
+}
+
+func (h *TemplateHandler) requireShareLinks(c *gin.Context) bool {
+	if h.shareLinks == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": errors.NewUnavailableError("Share links are not enabled"),
+		})
+		return false
+	}
+	return true
+}
+
+// generateShareLinkToken returns a random, URL-safe share link token
+func generateShareLinkToken() (string, error) {
+	bytes := make([]byte, shareLinkTokenBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTemplateShareLinks(t *testing.T) {
+	ctx := context.Background()
+	templateRepo := memory.NewTemplateRepository()
+	draft := &models.StoredTemplate{ID: "draft", Template: models.Template{Metadata: models.ShareMetadata{Name: "Draft", Author: "alice"}}}
+	if err := templateRepo.Create(ctx, draft); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	links := memory.NewShareLinkRepository()
+
+	h := newTestTemplateHandler(templateRepo)
+	h.ConfigureShareLinkStore(links)
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates/:id", h.GetTemplate)
+	r.POST("/api/templates/:id/share-links", h.CreateShareLink)
+	r.GET("/api/templates/:id/share-links", h.ListShareLinks)
+	r.DELETE("/api/templates/:id/share-links/:linkId", h.DeleteShareLink)
+	r.GET("/api/templates/shared/:token", h.GetSharedTemplate)
+	r.GET("/api/templates/shared/:token/download", h.DownloadSharedTemplate)
+
+	create := func(username, body string) *httptest.ResponseRecorder {
+		return sendAs(r, http.MethodPost, "/api/templates/draft/share-links", username, body)
+	}
+	newLink := func(body string) (id, token string) {
+		t.Helper()
+		w := create("alice", body)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+		created := decodeBody(t, w)
+		link := created["share_link"].(map[string]interface{})
+		token = link["token"].(string)
+		if !strings.HasSuffix(created["url"].(string), "/api/templates/shared/"+token) {
+			t.Errorf("Expected a URL ending in the token, got %v", created["url"])
+		}
+		return link["id"].(string), token
+	}
+
+	if w := sendAs(r, http.MethodGet, "/api/templates/draft", "", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected the draft to be hidden, got %d", w.Code)
+	}
+
+	// Used up after two uses, across detail and download
+	_, token := newLink(`{"max_uses": 2}`)
+	for _, url := range []string{"/api/templates/shared/" + token, "/api/templates/shared/" + token + "/download"} {
+		if w := sendAs(r, http.MethodGet, url, "", ""); w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 from %s, got %d: %s", url, w.Code, w.Body.String())
+		}
+	}
+	if w := sendAs(r, http.MethodGet, "/api/templates/shared/"+token, "", ""); w.Code != http.StatusGone {
+		t.Errorf("Expected 410 once used up, got %d", w.Code)
+	}
+	if stored, _ := templateRepo.GetByID(ctx, "draft"); stored.Downloads != 0 {
+		t.Errorf("Expected share link downloads not to be counted, got %d", stored.Downloads)
+	}
+
+	// Expired
+	expiredToken := "expired-token"
+	if err := links.Create(ctx, &models.TemplateShareLink{TemplateID: "draft", Token: expiredToken, ExpiresAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatalf("Failed to create share link: %v", err)
+	}
+	if w := sendAs(r, http.MethodGet, "/api/templates/shared/"+expiredToken, "", ""); w.Code != http.StatusGone {
+		t.Errorf("Expected 410 once expired, got %d", w.Code)
+	}
+
+	// Revoked
+	id, token := newLink("")
+	w := sendAs(r, http.MethodGet, "/api/templates/draft/share-links", "alice", "")
+	if listed := decodeBody(t, w)["share_links"].([]interface{}); len(listed) != 1 {
+		t.Errorf("Expected only the usable link to be listed, got %v", listed)
+	}
+	if w := sendAs(r, http.MethodDelete, "/api/templates/draft/share-links/"+id, "alice", ""); w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 revoking the link, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := sendAs(r, http.MethodGet, "/api/templates/shared/"+token, "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 once revoked, got %d", w.Code)
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		body     string
+		want     int
+	}{
+		{"not the author", "bob", "", http.StatusForbidden},
+		{"expiry too long", "alice", `{"expires_in_days": 31}`, http.StatusBadRequest},
+		{"negative uses", "alice", `{"max_uses": -1}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := create(tt.username, tt.body); w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
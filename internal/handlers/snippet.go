@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InstallSnippetConfig sets the hostnames and command names rendered into
+// install snippets, so self-hosted instances point at themselves
+type InstallSnippetConfig struct {
+	// PublicAPIURL is the API origin clients reach, such as
+	// https://api.example.com. Empty uses the host of each request.
+	PublicAPIURL string
+	// CLIName is the command-line client's executable name
+	CLIName string
+}
+
+// DefaultInstallSnippetConfig renders request hosts and the stock CLI name
+func DefaultInstallSnippetConfig() InstallSnippetConfig {
+	return InstallSnippetConfig{CLIName: "dotfiles"}
+}
+
+// ConfigureInstallSnippets replaces the hostnames and CLI name used by
+// GetInstallSnippet
+func (h *TemplateHandler) ConfigureInstallSnippets(config InstallSnippetConfig) {
+	h.snippets = config
+}
+
+// GetInstallSnippet renders copy-paste commands that install a template:
+// a curl one-liner against the download endpoint and the CLI equivalent.
+// ?format=text returns only the curl line as plain text for embedding.
+func (h *TemplateHandler) GetInstallSnippet(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "text" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("format must be json or text"),
+		})
+		return
+	}
+
+	template, ok := h.loadVisibleTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	baseURL := strings.TrimSuffix(h.snippets.PublicAPIURL, "/")
+	if baseURL == "" {
+		baseURL = fmt.Sprintf("%s://%s", requestScheme(c), c.Request.Host)
+	}
+
+	snippet := renderInstallSnippet(template, baseURL, h.snippets.CLIName)
+	if format == "text" {
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(snippet.Curl+"\n"))
+		return
+	}
+
+	c.JSON(http.StatusOK, snippet)
+}
+
+// renderInstallSnippet builds the commands served by GetInstallSnippet.
+// Templates have no slugs yet, so every visibility level is addressed by
+// ID.
+func renderInstallSnippet(template *models.StoredTemplate, baseURL, cliName string) dto.InstallSnippetResponse {
+	downloadURL := baseURL + "/api/templates/" + url.PathEscape(template.ID) + "/download"
+
+	snippet := dto.InstallSnippetResponse{
+		TemplateID:  template.ID,
+		Visibility:  templateVisibility(template),
+		DownloadURL: downloadURL,
+		Curl:        fmt.Sprintf("curl -fsSL %s -o %s", shellQuote(downloadURL), shellQuote(template.ID+".json")),
+		Short:       "curl -fsSL " + shellQuote(downloadURL),
+		CLI:         fmt.Sprintf("%s template install %s", cliName, shellQuote(template.ID)),
+	}
+
+	if hooks := countHookCommands(template.Template); hooks > 0 {
+		snippet.Warning = fmt.Sprintf("# Warning: this template runs %d hook command(s) during install. Review them before installing.", hooks)
+	}
+
+	return snippet
+}
+
+// templateVisibility names who can see a template: everyone, members of
+// its organization, or only its author
+func templateVisibility(template *models.StoredTemplate) string {
+	switch {
+	case template.Template.Public:
+		return "public"
+	case template.Template.OrganizationID != "":
+		return "organization"
+	default:
+		return "private"
+	}
+}
+
+// countHookCommands counts the shell commands a template runs besides
+// installing packages, from its lifecycle hooks and package configs
+func countHookCommands(template models.Template) int {
+	count := 0
+	if hooks := template.Hooks; hooks != nil {
+		count += len(hooks.PreInstall) + len(hooks.PostInstall) +
+			len(hooks.PreSync) + len(hooks.PostSync) +
+			len(hooks.PreStow) + len(hooks.PostStow)
+	}
+	for _, config := range template.PackageConfigs {
+		count += len(config.PreInstall) + len(config.PostInstall)
+	}
+	return count
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newSnippetTestRouter(t *testing.T, config InstallSnippetConfig) *gin.Engine {
+	t.Helper()
+	ctx := context.Background()
+
+	orgRepo := memory.NewOrganizationRepository()
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "owner-id"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	if err := orgRepo.AddMember(ctx, &models.OrganizationMember{OrganizationID: "org-acme", UserID: "member-id", Role: models.RoleMember}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	templateRepo := memory.NewTemplateRepository()
+	for _, template := range []*models.StoredTemplate{
+		{ID: "public-id", Template: models.Template{Public: true}},
+		{ID: "org-id", Template: models.Template{OrganizationID: "org-acme"}},
+		{ID: "private-id", Template: models.Template{Metadata: models.ShareMetadata{Author: "alice"}}},
+		{ID: "hooks-id", Template: models.Template{
+			Public: true,
+			Hooks:  &models.Hooks{PostInstall: []string{"echo done"}, PreStow: []string{"mkdir -p ~/.config"}},
+			PackageConfigs: map[string]models.PackageConfig{
+				"git": {PostInstall: []string{"git config --global init.defaultBranch main"}},
+			},
+		}},
+	} {
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := NewTemplateHandler(templateRepo, memory.NewTagRepository(), memory.NewUserRepository(), memory.NewReviewRepository(), tags.NewRegistry(), NewAuthorizer(orgRepo))
+	h.ConfigureInstallSnippets(config)
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates/:id/install-snippet", h.GetInstallSnippet)
+	return r
+}
+
+func getInstallSnippet(r *gin.Engine, url string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestInstallSnippetSnapshots(t *testing.T) {
+	r := newSnippetTestRouter(t, InstallSnippetConfig{PublicAPIURL: "https://dotfiles.corp.example/", CLIName: "corpdots"})
+
+	tests := []struct {
+		name    string
+		id      string
+		headers map[string]string
+		want    dto.InstallSnippetResponse
+	}{
+		{
+			name: "public",
+			id:   "public-id",
+			want: dto.InstallSnippetResponse{
+				TemplateID:  "public-id",
+				Visibility:  "public",
+				DownloadURL: "https://dotfiles.corp.example/api/templates/public-id/download",
+				Curl:        "curl -fsSL https://dotfiles.corp.example/api/templates/public-id/download -o public-id.json",
+				Short:       "curl -fsSL https://dotfiles.corp.example/api/templates/public-id/download",
+				CLI:         "corpdots template install public-id",
+			},
+		},
+		{
+			name:    "organization",
+			id:      "org-id",
+			headers: map[string]string{"X-Test-User": "member-id"},
+			want: dto.InstallSnippetResponse{
+				TemplateID:  "org-id",
+				Visibility:  "organization",
+				DownloadURL: "https://dotfiles.corp.example/api/templates/org-id/download",
+				Curl:        "curl -fsSL https://dotfiles.corp.example/api/templates/org-id/download -o org-id.json",
+				Short:       "curl -fsSL https://dotfiles.corp.example/api/templates/org-id/download",
+				CLI:         "corpdots template install org-id",
+			},
+		},
+		{
+			name:    "private",
+			id:      "private-id",
+			headers: map[string]string{"X-Test-Username": "alice"},
+			want: dto.InstallSnippetResponse{
+				TemplateID:  "private-id",
+				Visibility:  "private",
+				DownloadURL: "https://dotfiles.corp.example/api/templates/private-id/download",
+				Curl:        "curl -fsSL https://dotfiles.corp.example/api/templates/private-id/download -o private-id.json",
+				Short:       "curl -fsSL https://dotfiles.corp.example/api/templates/private-id/download",
+				CLI:         "corpdots template install private-id",
+			},
+		},
+		{
+			name: "hooks",
+			id:   "hooks-id",
+			want: dto.InstallSnippetResponse{
+				TemplateID:  "hooks-id",
+				Visibility:  "public",
+				DownloadURL: "https://dotfiles.corp.example/api/templates/hooks-id/download",
+				Curl:        "curl -fsSL https://dotfiles.corp.example/api/templates/hooks-id/download -o hooks-id.json",
+				Short:       "curl -fsSL https://dotfiles.corp.example/api/templates/hooks-id/download",
+				CLI:         "corpdots template install hooks-id",
+				Warning:     "# Warning: this template runs 3 hook command(s) during install. Review them before installing.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := getInstallSnippet(r, "/api/templates/"+tt.id+"/install-snippet", tt.headers)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var got dto.InstallSnippetResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	// Private templates stay hidden from everyone else
+	if w := getInstallSnippet(r, "/api/templates/private-id/install-snippet", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a hidden template, got %d", w.Code)
+	}
+}
+
+func TestInstallSnippetTextFormat(t *testing.T) {
+	r := newSnippetTestRouter(t, DefaultInstallSnippetConfig())
+
+	w := getInstallSnippet(r, "http://localhost:8080/api/templates/public-id/install-snippet?format=text", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+		t.Errorf("Expected text/plain content type, got %q", contentType)
+	}
+
+	// Without PUBLIC_API_URL the request's own host is used
+	want := "curl -fsSL http://localhost:8080/api/templates/public-id/download -o public-id.json\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if w := getInstallSnippet(r, "/api/templates/public-id/install-snippet?format=yaml", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown format, got %d", w.Code)
+	}
+}
+
+func TestRenderInstallSnippetQuotesIDs(t *testing.T) {
+	template := &models.StoredTemplate{ID: "it's", Template: models.Template{Public: true}}
+
+	snippet := renderInstallSnippet(template, "https://api.example.com", "dotfiles")
+	if want := "curl -fsSL 'https://api.example.com/api/templates/it%27s/download'"; snippet.Short != want {
+		t.Errorf("Expected %q, got %q", want, snippet.Short)
+	}
+	if want := `dotfiles template install 'it'\''s'`; snippet.CLI != want {
+		t.Errorf("Expected %q, got %q", want, snippet.CLI)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/tags"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SubscriptionHandler handles the tags users follow for the new-template
+// digest
+type SubscriptionHandler struct {
+	subscriptionRepo repository.SubscriptionRepository
+	tags             *tags.Registry
+}
+
+// NewSubscriptionHandler creates a new subscription handler
+func NewSubscriptionHandler(subscriptionRepo repository.SubscriptionRepository, tagRegistry *tags.Registry) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		subscriptionRepo: subscriptionRepo,
+		tags:             tagRegistry,
+	}
+}
+
+// GetTagSubscriptions handles listing the tags the current user follows
+func (h *SubscriptionHandler) GetTagSubscriptions(c *gin.Context) {
+	subscription, err := h.subscriptionRepo.GetByUser(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		respondInternalError(c, "Failed to get tag subscriptions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tagSubscriptionResponse(subscription))
+}
+
+// SubscribeTags handles following tags. Tags are stored in canonical form,
+// so following "js" also brings templates tagged "javascript".
+func (h *SubscriptionHandler) SubscribeTags(c *gin.Context) {
+	var req dto.SubscribeTagsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if appErr := req.Validate(); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	canonical := h.tags.CanonicalTags(req.Tags)
+	if len(canonical) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("at least one tag is required"),
+		})
+		return
+	}
+
+	subscription, err := h.subscriptionRepo.AddTags(c.Request.Context(), c.GetString("user_id"), canonical)
+	if err != nil {
+		respondInternalError(c, "Failed to follow tags", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tagSubscriptionResponse(subscription))
+}
+
+// UnsubscribeTag handles unfollowing a tag, given in any of its forms
+func (h *SubscriptionHandler) UnsubscribeTag(c *gin.Context) {
+	tag := h.tags.Canonical(c.Param("tag"))
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("Tag is required"),
+		})
+		return
+	}
+
+	subscription, err := h.subscriptionRepo.RemoveTags(c.Request.Context(), c.GetString("user_id"), []string{tag})
+	if err != nil {
+		respondInternalError(c, "Failed to unfollow tag", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tagSubscriptionResponse(subscription))
+}
+
+// tagSubscriptionResponse reports the followed tags, with an empty list
+// when the user follows none
+func tagSubscriptionResponse(subscription *models.TagSubscription) gin.H {
+	if subscription == nil {
+		return gin.H{"tags": []string{}}
+	}
+
+	return gin.H{
+		"tags":             subscription.Tags,
+		"last_notified_at": subscription.LastNotifiedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTagSubscriptions(t *testing.T) {
+	h := NewSubscriptionHandler(memory.NewSubscriptionRepository(), tags.NewRegistry())
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/subscriptions/tags", h.GetTagSubscriptions)
+	r.POST("/api/subscriptions/tags", h.SubscribeTags)
+	r.DELETE("/api/subscriptions/tags/:tag", h.UnsubscribeTag)
+
+	send := func(method, url, body string) map[string]interface{} {
+		t.Helper()
+
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", "alice-id")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: expected status 200, got %d: %s", method, url, w.Code, w.Body.String())
+		}
+		return decodeBody(t, w)
+	}
+
+	body := send(http.MethodPost, "/api/subscriptions/tags", `{"tags": ["JS", "k8s", "javascript"]}`)
+	if got := body["tags"]; !reflect.DeepEqual(got, []interface{}{"javascript", "kubernetes"}) {
+		t.Errorf("Expected canonical tags without duplicates, got %v", got)
+	}
+
+	body = send(http.MethodDelete, "/api/subscriptions/tags/kube", "")
+	if got := body["tags"]; !reflect.DeepEqual(got, []interface{}{"javascript"}) {
+		t.Errorf("Expected kubernetes to be unfollowed through its synonym, got %v", got)
+	}
+
+	send(http.MethodDelete, "/api/subscriptions/tags/javascript", "")
+	body = send(http.MethodGet, "/api/subscriptions/tags", "")
+	if got := body["tags"]; !reflect.DeepEqual(got, []interface{}{}) {
+		t.Errorf("Expected no followed tags, got %v", got)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/subscriptions/tags", strings.NewReader(`{"tags": [" "]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a blank tag, got %d", w.Code)
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	stderrors "errors"
+	"net/http"
+	"sort"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/tags"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListTags reports the canonical tags used by public templates, each with its
+// synonyms and a count that merges every synonym
+func (h *TemplateHandler) ListTags(c *gin.Context) {
+	counts, err := h.templateRepo.CountTags(c.Request.Context(), h.tags.CanonicalTags)
+	if err != nil {
+		respondInternalError(c, "failed to count tags", err)
+		return
+	}
+
+	respondTagCounts(c, counts, h.tags.Synonyms())
+}
+
+// ListConfigTags reports the canonical tags used by public configs, like
+// ListTags does for templates
+func (h *ConfigHandler) ListConfigTags(c *gin.Context) {
+	if !h.isAvailable() {
+		h.handleUnavailable(c)
+		return
+	}
+
+	counts, err := h.configRepo.CountTags(c.Request.Context(), h.tags.CanonicalTags)
+	if err != nil {
+		respondInternalError(c, "failed to count tags", err)
+		return
+	}
+
+	respondTagCounts(c, counts, h.tags.Synonyms())
+}
+
+// respondTagCounts lists counted canonical tags with their synonyms, most
+// used first
+func respondTagCounts(c *gin.Context, counts map[string]int, synonyms map[string][]string) {
+
+	response := make([]dto.TagResponse, 0, len(counts))
+	for tag, count := range counts {
+		tagSynonyms := synonyms[tag]
+		if tagSynonyms == nil {
+			tagSynonyms = []string{}
+		}
+		response = append(response, dto.TagResponse{Tag: tag, Synonyms: tagSynonyms, Count: count})
+	}
+
+	// Most used first, ties alphabetically
+	sort.Slice(response, func(i, j int) bool {
+		if response[i].Count != response[j].Count {
+			return response[i].Count > response[j].Count
+		}
+		return response[i].Tag < response[j].Tag
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags":  response,
+		"total": len(response),
+	})
+}
+
+// parseTagFilter reads the tags query parameters into a tag filter, listing
+// every form of each tag so "js" also finds "javascript"
+func parseTagFilter(c *gin.Context, registry *tags.Registry) [][]string {
+	var filter [][]string
+	for _, tag := range c.QueryArray("tags") {
+		if variants := registry.Variants(tag); len(variants) > 0 {
+			filter = append(filter, variants)
+		}
+	}
+	return filter
+}
+
+// SetTagSynonyms replaces the synonyms of a canonical tag. Site admin only.
+func (h *TemplateHandler) SetTagSynonyms(c *gin.Context) {
+	var req dto.SetTagSynonymsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if appErr := req.Validate(); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+
+	// Check before storing so a conflicting table is never persisted
+	if err := h.tags.Check(req.Canonical, req.Synonyms); err != nil {
+		respondTagSynonymError(c, err)
+		return
+	}
+
+	canonical := tags.Normalize(req.Canonical)
+	if err := h.tagRepo.SetSynonyms(c.Request.Context(), canonical, req.Synonyms); err != nil {
+		respondInternalError(c, "failed to save tag synonyms", err)
+		return
+	}
+
+	if err := h.tags.Set(canonical, req.Synonyms); err != nil {
+		respondTagSynonymError(c, err)
+		return
+	}
+
+	synonyms := h.tags.Synonyms()[canonical]
+	if synonyms == nil {
+		synonyms = []string{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"canonical": canonical,
+		"synonyms":  synonyms,
+		"message":   "Tag synonyms updated successfully",
+	})
+}
+
+// BackfillTags rewrites the tags stored on every template to their canonical
+// form. Site admin only.
+func (h *TemplateHandler) BackfillTags(c *gin.Context) {
+	updated, err := h.templateRepo.RewriteTags(c.Request.Context(), h.tags.CanonicalTags)
+	if err != nil {
+		respondInternalError(c, "failed to rewrite template tags", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"updated": updated,
+		"message": "Template tags rewritten to canonical form",
+	})
+}
+
+func respondTagSynonymError(c *gin.Context, err error) {
+	if stderrors.Is(err, tags.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": errors.NewConflictError(err.Error())})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": errors.NewValidationError(err.Error())})
+}
@@ -1,32 +1,73 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"dotfiles-api/internal/auth"
+	"dotfiles-api/internal/brewfile"
+	"dotfiles-api/internal/compat"
 	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/github"
+	"dotfiles-api/internal/homebrew"
+	"dotfiles-api/internal/images"
+	"dotfiles-api/internal/middleware"
 	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/pagination"
 	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/search"
+	"dotfiles-api/internal/tags"
 	"dotfiles-api/pkg/errors"
 )
 
 type TemplateHandler struct {
 	templateRepo repository.TemplateRepository
+	tagRepo      repository.TagRepository
+	userRepo     repository.UserRepository
+	reviewRepo   repository.ReviewRepository
+	tags         *tags.Registry
+	authorizer   *Authorizer
+	github       *github.Client
+	homebrew     *homebrew.Client
+	configRepo   repository.ConfigRepository
+	images       *images.Store
+	snippets     InstallSnippetConfig
+	clientMatrix compat.Matrix
+	frontendURL  string
+	publicCount  cachedCount
+	downloads    downloadEvents
+	shareLinks   repository.ShareLinkRepository
+	limits       dto.ContentLimits
 }
 
-func NewTemplateHandler(templateRepo repository.TemplateRepository) *TemplateHandler {
+func NewTemplateHandler(templateRepo repository.TemplateRepository, tagRepo repository.TagRepository, userRepo repository.UserRepository, reviewRepo repository.ReviewRepository, tagRegistry *tags.Registry, authorizer *Authorizer) *TemplateHandler {
 	return &TemplateHandler{
 		templateRepo: templateRepo,
+		tagRepo:      tagRepo,
+		userRepo:     userRepo,
+		reviewRepo:   reviewRepo,
+		tags:         tagRegistry,
+		authorizer:   authorizer,
+		github:       github.NewClient(""),
+		homebrew:     homebrew.NewClient(homebrew.DefaultConfig()),
+		snippets:     DefaultInstallSnippetConfig(),
+		clientMatrix: compat.DefaultMatrix(),
+		limits:       dto.DefaultContentLimits(),
 	}
 }
 
 func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
 	var req dto.CreateTemplateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewValidationError("invalid request body"),
-		})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -35,13 +76,80 @@ func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
 		return
 	}
 
-	// Create StoredTemplate from request
-	storedTemplate := &models.StoredTemplate{
+	// Templates belong to the signed-in caller. Anonymous templates, only
+	// reachable when anonymous uploads are enabled, get a placeholder author
+	// that no account owns, so they must be public.
+	author := c.GetString("username")
+	if author == "" {
+		if !req.Public || req.OrganizationID != "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": errors.NewUnauthorizedError("Sign in to create private or organization templates"),
+			})
+			return
+		}
+		author = models.AnonymousAuthor
+	}
+
+	if !h.requireOrganizationMember(c, req.OrganizationID) {
+		return
+	}
+
+	storedTemplate := h.newStoredTemplate(&req, author)
+	if !h.checkContentLimits(c, &storedTemplate.Template) {
+		return
+	}
+
+	// Save template to repository
+	if err := h.templateRepo.Create(c.Request.Context(), storedTemplate); err != nil {
+		respondInternalError(c, "failed to create template", err)
+		return
+	}
+
+	// Return created template
+	response := toTemplateResponse(storedTemplate)
+	response.Warnings = req.Warnings()
+
+	if legacyCompatRequested(c) {
+		response.WithLegacyAliases()
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// requireOrganizationMember checks that the caller belongs to the
+// organization a template is being placed in. Organization featured
+// listings and custom domains trust a template's organization_id, so only
+// members may set it.
+func (h *TemplateHandler) requireOrganizationMember(c *gin.Context, orgID string) bool {
+	if orgID == "" {
+		return true
+	}
+
+	role, err := h.authorizer.OrganizationRole(c, orgID)
+	if err != nil {
+		respondInternalError(c, "failed to check organization membership", err)
+		return false
+	}
+	if role == "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("only organization members can add templates to it"),
+		})
+		return false
+	}
+	return true
+}
+
+// newStoredTemplate builds the template a create request describes,
+// attributed to author
+func (h *TemplateHandler) newStoredTemplate(req *dto.CreateTemplateRequest, author string) *models.StoredTemplate {
+	return &models.StoredTemplate{
 		Template: models.Template{
 			Taps:           req.Taps,
 			Brews:          req.Brews,
 			Casks:          req.Casks,
 			Stow:           req.Stow,
+			AptPackages:    req.AptPackages,
+			PipPackages:    req.PipPackages,
 			Extends:        req.Extends,
 			Overrides:      req.Overrides,
 			AddOnly:        req.AddOnly,
@@ -51,49 +159,89 @@ func (h *TemplateHandler) CreateTemplate(c *gin.Context) {
 			Metadata: models.ShareMetadata{
 				Name:        req.Metadata.Name,
 				Description: req.Metadata.Description,
-				Author:      req.Metadata.Author,
-				Version:     req.Metadata.Version,
-				Tags:        req.Metadata.Tags,
+				Author:      author,
+				Version:     dto.CanonicalVersion(req.Metadata.Version),
+				Tags:        h.tags.CanonicalTags(req.Metadata.Tags),
 			},
 		},
 	}
+}
 
-	// Save template to repository
-	if err := h.templateRepo.Create(c.Request.Context(), storedTemplate); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to create template", err),
+// ValidateTemplate dry-runs template creation. It reports every validation
+// problem, package-name issue and inheritance error without saving anything.
+func (h *TemplateHandler) ValidateTemplate(c *gin.Context) {
+	// Bind without the binding tags so missing fields are reported as
+	// problems rather than rejected as a malformed body
+	var req dto.CreateTemplateRequest
+	decoder := json.NewDecoder(c.Request.Body)
+	if strictJSONRequested(c) {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if stderrors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": errors.NewPayloadTooLargeError(tooLarge.Limit),
+			})
+			return
+		}
+		if _, unknown := unknownJSONField(err); unknown {
+			appErr := bindError(err)
+			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewValidationError("invalid request body"),
 		})
 		return
 	}
 
-	// Return created template
-	response := &dto.TemplateResponse{
-		ID:             storedTemplate.ID,
-		Taps:           storedTemplate.Template.Taps,
-		Brews:          storedTemplate.Template.Brews,
-		Casks:          storedTemplate.Template.Casks,
-		Stow:           storedTemplate.Template.Stow,
-		Extends:        storedTemplate.Template.Extends,
-		Overrides:      storedTemplate.Template.Overrides,
-		AddOnly:        storedTemplate.Template.AddOnly,
-		Public:         storedTemplate.Template.Public,
-		Featured:       storedTemplate.Template.Featured,
-		OrganizationID: storedTemplate.Template.OrganizationID,
-		Downloads:      storedTemplate.Downloads,
-		CreatedAt:      storedTemplate.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:      storedTemplate.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-		Metadata: dto.TemplateMetadataResponse{
-			Name:        storedTemplate.Template.Metadata.Name,
-			Description: storedTemplate.Template.Metadata.Description,
-			Author:      storedTemplate.Template.Metadata.Author,
-			Version:     storedTemplate.Template.Metadata.Version,
-			Tags:        storedTemplate.Template.Metadata.Tags,
-			CreatedAt:   storedTemplate.Template.Metadata.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt:   storedTemplate.Template.Metadata.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-		},
+	problems, warnings := req.Lint()
+
+	response := dto.TemplateValidationResponse{
+		Problems: []dto.TemplateProblem{},
+		Warnings: []dto.TemplateProblem{},
 	}
+	response.Problems = append(response.Problems, problems...)
+	response.Warnings = append(response.Warnings, warnings...)
 
-	c.JSON(http.StatusCreated, response)
+	if req.Extends != "" {
+		resolved, problem, err := h.resolveInheritance(c, &req)
+		if err != nil {
+			respondInternalError(c, "failed to resolve template inheritance", err)
+			return
+		}
+
+		if problem != nil {
+			response.Problems = append(response.Problems, *problem)
+		} else {
+			response.Resolved = resolved.ResolvedTemplateResponse
+			for _, override := range req.Overrides {
+				if !resolved.inherits(override) {
+					response.Warnings = append(response.Warnings, dto.TemplateProblem{
+						Field:   "overrides",
+						Message: fmt.Sprintf("override %q does not match any inherited package", override),
+					})
+				}
+			}
+		}
+	}
+
+	response.Valid = len(response.Problems) == 0
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTemplateSchema serves the JSON Schema for template request bodies so
+// editors can validate templates the same way the server does
+func (h *TemplateHandler) GetTemplateSchema(c *gin.Context) {
+	body, err := json.Marshal(dto.TemplateSchema())
+	if err != nil {
+		respondInternalError(c, "failed to encode template schema", err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600")
+	c.Data(http.StatusOK, "application/schema+json", body)
 }
 
 func (h *TemplateHandler) GetTemplate(c *gin.Context) {
@@ -105,42 +253,32 @@ func (h *TemplateHandler) GetTemplate(c *gin.Context) {
 		return
 	}
 
-	template, err := h.templateRepo.GetByID(c.Request.Context(), templateID)
+	template, ok := h.loadVisibleTemplate(c, templateID)
+	if !ok {
+		return
+	}
+
+	viewer, err := h.loadViewerState(c.Request.Context(), c.GetString("user_id"))
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
-			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to get template", err),
-		})
+		respondInternalError(c, "failed to load favorites and reviews", err)
 		return
 	}
 
-	response := &dto.TemplateResponse{
-		ID:             template.ID,
-		Taps:           template.Template.Taps,
-		Brews:          template.Template.Brews,
-		Casks:          template.Template.Casks,
-		Stow:           template.Template.Stow,
-		Extends:        template.Template.Extends,
-		Overrides:      template.Template.Overrides,
-		AddOnly:        template.Template.AddOnly,
-		Public:         template.Template.Public,
-		Featured:       template.Template.Featured,
-		OrganizationID: template.Template.OrganizationID,
-		Downloads:      template.Downloads,
-		CreatedAt:      template.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt:      template.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-		Metadata: dto.TemplateMetadataResponse{
-			Name:        template.Template.Metadata.Name,
-			Description: template.Template.Metadata.Description,
-			Author:      template.Template.Metadata.Author,
-			Version:     template.Template.Metadata.Version,
-			Tags:        template.Template.Metadata.Tags,
-			CreatedAt:   template.Template.Metadata.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt:   template.Template.Metadata.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-		},
+	owners, err := h.loadOwners(c, template)
+	if err != nil {
+		respondInternalError(c, "failed to load template owner", err)
+		return
+	}
+
+	response := toTemplateResponse(template)
+	viewer.apply(&response)
+	owners.apply(template, &response)
+	if !applyInstalledVersion(c, template, &response) {
+		return
+	}
+
+	if legacyCompatRequested(c) {
+		response.WithLegacyAliases()
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -156,10 +294,7 @@ func (h *TemplateHandler) UpdateTemplate(c *gin.Context) {
 	}
 
 	var req dto.UpdateTemplateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewValidationError("invalid request body"),
-		})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -173,27 +308,38 @@ func (h *TemplateHandler) UpdateTemplate(c *gin.Context) {
 	})
 }
 
+// DeleteTemplate deletes a template and its uploaded images. Author only.
 func (h *TemplateHandler) DeleteTemplate(c *gin.Context) {
-	templateID := c.Param("id")
-	if templateID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewBadRequestError("template ID is required"),
+	template, ok := h.loadTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if template.Template.Metadata.Author != c.GetString("username") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("only the template author can delete it"),
 		})
 		return
 	}
 
-	err := h.templateRepo.Delete(c.Request.Context(), templateID)
+	err := h.templateRepo.Delete(c.Request.Context(), template.ID)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
 			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to delete template", err),
-		})
+		respondInternalError(c, "failed to delete template", err)
 		return
 	}
 
+	// The template is gone either way, so leftover files are only logged
+	if h.images != nil {
+		if err := h.images.RemoveAll(template.ID); err != nil {
+			log.Printf("Failed to remove images of template %s: %v", template.ID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Template deleted successfully",
 	})
@@ -203,13 +349,23 @@ func (h *TemplateHandler) ListTemplates(c *gin.Context) {
 	filters := repository.TemplateFilters{
 		Author:         c.Query("author"),
 		OrganizationID: c.Query("organization_id"),
-		SortBy:         c.DefaultQuery("sort_by", "created_at"),
-		SortOrder:      c.DefaultQuery("sort_order", "desc"),
 	}
 
-	if tags := c.QueryArray("tags"); len(tags) > 0 {
-		filters.Tags = tags
+	sortKeys, appErr := parseTemplateSort(c)
+	if appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
+	}
+	filters.Sort = sortKeys
+
+	filters.Tags = parseTagFilter(c, h.tags)
+
+	dates, appErr := parseDateRange(c)
+	if appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return
 	}
+	filters.DateRange = dates
 
 	if featuredStr := c.Query("featured"); featuredStr != "" {
 		if featured, err := strconv.ParseBool(featuredStr); err == nil {
@@ -223,193 +379,315 @@ func (h *TemplateHandler) ListTemplates(c *gin.Context) {
 		}
 	}
 
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
+	visibleTo, err := h.authorizer.TemplateViewer(c)
+	if err != nil {
+		respondInternalError(c, "failed to check template visibility", err)
+		return
 	}
-	if limit > 100 {
-		limit = 100
+	filters.Viewer = visibleTo
+
+	// On an organization's custom domain the default listing shows only
+	// that organization's public templates
+	if orgID := c.GetString(domainOrgKey); orgID != "" && filters.OrganizationID == "" {
+		public := true
+		filters.OrganizationID = orgID
+		filters.Public = &public
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	csvExport, ok := wantsCSV(c)
+	if !ok {
+		return
 	}
 
+	limit, offset := parseListPagination(c, csvExport)
+
 	filters.Limit = limit
 	filters.Offset = offset
 
+	if csvExport {
+		h.exportTemplatesCSV(c, filters)
+		return
+	}
+
+	selection, ok := parseTemplateFields(c)
+	if !ok {
+		return
+	}
+	filters.Fields = storedTemplateFields(selection)
+
+	// Streamed exports cover every matching template, without paging
+	if wantsNDJSON(c) {
+		h.streamTemplates(c, filters, selection)
+		return
+	}
+
 	templates, err := h.templateRepo.List(c.Request.Context(), filters)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to list templates", err),
-		})
+		respondInternalError(c, "failed to list templates", err)
+		return
+	}
+
+	// Signed-in callers also see their own favorites and reviews, which
+	// template update times do not track
+	if c.GetString("user_id") == "" && notModifiedSince(c, latestTemplateUpdate(templates)) {
+		return
+	}
+
+	total, err := h.templateRepo.Count(c.Request.Context(), filters)
+	if err != nil {
+		respondInternalError(c, "failed to count templates", err)
+		return
+	}
+
+	viewer, err := h.loadViewerState(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		respondInternalError(c, "failed to load favorites and reviews", err)
+		return
+	}
+
+	owners, err := h.loadOwners(c, templates...)
+	if err != nil {
+		respondInternalError(c, "failed to load template owners", err)
 		return
 	}
 
 	response := make([]dto.TemplateResponse, len(templates))
 	for i, template := range templates {
-		response[i] = dto.TemplateResponse{
-			ID:             template.ID,
-			Taps:           template.Template.Taps,
-			Brews:          template.Template.Brews,
-			Casks:          template.Template.Casks,
-			Stow:           template.Template.Stow,
-			Extends:        template.Template.Extends,
-			Overrides:      template.Template.Overrides,
-			AddOnly:        template.Template.AddOnly,
-			Public:         template.Template.Public,
-			Featured:       template.Template.Featured,
-			OrganizationID: template.Template.OrganizationID,
-			Downloads:      template.Downloads,
-			CreatedAt:      template.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt:      template.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-			Metadata: dto.TemplateMetadataResponse{
-				Name:        template.Template.Metadata.Name,
-				Description: template.Template.Metadata.Description,
-				Author:      template.Template.Metadata.Author,
-				Version:     template.Template.Metadata.Version,
-				Tags:        template.Template.Metadata.Tags,
-				CreatedAt:   template.Template.Metadata.CreatedAt.Format("2006-01-02T15:04:05Z"),
-				UpdatedAt:   template.Template.Metadata.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-			},
+		response[i] = toTemplateResponse(template)
+		viewer.apply(&response[i])
+		owners.apply(template, &response[i])
+		if legacyCompatRequested(c) {
+			response[i].WithLegacyAliases()
 		}
 	}
 
+	projected, err := projectTemplates(response, selection)
+	if err != nil {
+		respondInternalError(c, "failed to select template fields", err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"templates": response,
+		"templates": projected,
 		"limit":     limit,
 		"offset":    offset,
-		"total":     len(response),
+		"total":     total,
+		"links":     pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, total),
 	})
 }
 
-func (h *TemplateHandler) SearchTemplates(c *gin.Context) {
-	query := c.Query("q")
-	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewBadRequestError("search query is required"),
+// streamTemplates writes every template matching filters as NDJSON, shaped
+// like the entries of a ListTemplates page
+func (h *TemplateHandler) streamTemplates(c *gin.Context, filters repository.TemplateFilters, selection dto.FieldSelection) {
+	viewer, err := h.loadViewerState(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		respondInternalError(c, "failed to load favorites and reviews", err)
+		return
+	}
+	legacy := legacyCompatRequested(c)
+
+	streamNDJSON(c, func(emit func(interface{}) error) error {
+		return h.templateRepo.Iterate(c.Request.Context(), filters, func(template *models.StoredTemplate) error {
+			response := toTemplateResponse(template)
+			viewer.apply(&response)
+			if legacy {
+				response.WithLegacyAliases()
+			}
+			if selection == nil {
+				return emit(response)
+			}
+
+			fields, err := selection.Project(response)
+			if err != nil {
+				return err
+			}
+			return emit(fields)
 		})
+	})
+}
+
+// templateCSVHeader lists the columns of a template catalog export
+var templateCSVHeader = []string{"id", "name", "author", "downloads", "rating", "tags", "created_at", "visibility"}
+
+// exportTemplatesCSV writes a page of the templates matching filters as
+// CSV, one row per template with its tags joined by |
+func (h *TemplateHandler) exportTemplatesCSV(c *gin.Context, filters repository.TemplateFilters) {
+	templates, err := h.templateRepo.List(c.Request.Context(), filters)
+	if err != nil {
+		respondInternalError(c, "failed to list templates", err)
 		return
 	}
 
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
+	rows := make([][]string, 0, len(templates))
+	for _, template := range templates {
+		rating, err := h.reviewRepo.CalculateTemplateRating(c.Request.Context(), template.ID)
+		if err != nil {
+			respondInternalError(c, "failed to get template rating", err)
+			return
+		}
+		// Unrated templates are left blank rather than shown as 0
+		average := ""
+		if rating != nil && rating.TotalRatings > 0 {
+			average = strconv.FormatFloat(rating.AverageRating, 'f', 2, 64)
+		}
+
+		rows = append(rows, []string{
+			template.ID,
+			template.Template.Metadata.Name,
+			template.Template.Metadata.Author,
+			strconv.Itoa(template.Downloads),
+			average,
+			strings.Join(template.Template.Metadata.Tags, "|"),
+			template.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			templateVisibility(template),
+		})
+	}
+
+	writeCSV(c, "templates.csv", templateCSVHeader, rows)
+}
+
+// templateStatuses maps the ?status= values of GetMyTemplates to template
+// visibility. Templates have no review workflow yet, so drafts are the
+// private templates and there is no pending state.
+var templateStatuses = map[string]bool{
+	"draft":     false,
+	"published": true,
+}
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
+// GetMyTemplates lists the caller's own templates, private ones included,
+// newest first
+func (h *TemplateHandler) GetMyTemplates(c *gin.Context) {
+	username := c.GetString("username")
+	if username == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
 	}
-	if limit > 100 {
-		limit = 100
+
+	filters := repository.TemplateFilters{
+		Author:          username,
+		IncludeUnlisted: true,
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	if status := c.Query("status"); status != "" {
+		public, ok := templateStatuses[status]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": errors.NewValidationError("status must be draft or published"),
+			})
+			return
+		}
+		filters.Public = &public
 	}
 
-	templates, err := h.templateRepo.Search(c.Request.Context(), query, limit, offset)
+	limit, offset := parsePagination(c)
+
+	filters.Limit = limit
+	filters.Offset = offset
+
+	templates, err := h.templateRepo.List(c.Request.Context(), filters)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to search templates", err),
-		})
+		respondInternalError(c, "failed to list templates", err)
+		return
+	}
+	total, err := h.templateRepo.Count(c.Request.Context(), filters)
+	if err != nil {
+		respondInternalError(c, "failed to count templates", err)
 		return
 	}
 
 	response := make([]dto.TemplateResponse, len(templates))
 	for i, template := range templates {
-		response[i] = dto.TemplateResponse{
-			ID:             template.ID,
-			Taps:           template.Template.Taps,
-			Brews:          template.Template.Brews,
-			Casks:          template.Template.Casks,
-			Stow:           template.Template.Stow,
-			Extends:        template.Template.Extends,
-			Overrides:      template.Template.Overrides,
-			AddOnly:        template.Template.AddOnly,
-			Public:         template.Template.Public,
-			Featured:       template.Template.Featured,
-			OrganizationID: template.Template.OrganizationID,
-			Downloads:      template.Downloads,
-			CreatedAt:      template.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt:      template.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-			Metadata: dto.TemplateMetadataResponse{
-				Name:        template.Template.Metadata.Name,
-				Description: template.Template.Metadata.Description,
-				Author:      template.Template.Metadata.Author,
-				Version:     template.Template.Metadata.Version,
-				Tags:        template.Template.Metadata.Tags,
-				CreatedAt:   template.Template.Metadata.CreatedAt.Format("2006-01-02T15:04:05Z"),
-				UpdatedAt:   template.Template.Metadata.UpdatedAt.Format("2006-01-02T15:04:05Z"),
-			},
+		response[i] = toTemplateResponse(template)
+		if legacyCompatRequested(c) {
+			response[i].WithLegacyAliases()
 		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"templates": response,
-		"query":     query,
 		"limit":     limit,
 		"offset":    offset,
-		"total":     len(response),
+		"total":     total,
+		"links":     pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, total),
 	})
 }
 
-func (h *TemplateHandler) DownloadTemplate(c *gin.Context) {
-	templateID := c.Param("id")
-	if templateID == "" {
+func (h *TemplateHandler) SearchTemplates(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewBadRequestError("template ID is required"),
+			"error": errors.NewBadRequestError("search query is required"),
 		})
 		return
 	}
 
-	template, err := h.templateRepo.GetByID(c.Request.Context(), templateID)
-	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
-			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to get template", err),
-		})
+	limit, offset := parsePagination(c)
+
+	selection, ok := parseTemplateFields(c)
+	if !ok {
 		return
 	}
 
-	err = h.templateRepo.IncrementDownloads(c.Request.Context(), templateID)
+	visibleTo, err := h.authorizer.TemplateViewer(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to increment download count", err),
-		})
+		respondInternalError(c, "failed to check template visibility", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, template.Template)
-}
+	// Search for the synonyms of any tag in the query too, so "k8s" finds
+	// templates that only say "kubernetes"
+	terms := h.tags.ExpandTerms(search.Terms(query))
 
-func (h *TemplateHandler) GetTemplateStats(c *gin.Context) {
-	stats, err := h.templateRepo.GetStats(c.Request.Context())
+	expanded := strings.Join(terms, " ")
+	templates, err := h.templateRepo.Search(c.Request.Context(), expanded, visibleTo, limit, offset, storedTemplateFields(selection))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to get template stats", err),
-		})
+		respondInternalError(c, "failed to search templates", err)
+		return
+	}
+	total, err := h.templateRepo.CountSearch(c.Request.Context(), expanded, visibleTo)
+	if err != nil {
+		respondInternalError(c, "failed to count matching templates", err)
 		return
 	}
 
-	response := &dto.TemplateStatsResponse{
-		TotalTemplates:    stats.TotalTemplates,
-		FeaturedTemplates: stats.FeaturedTemplates,
-		TotalDownloads:    stats.TotalDownloads,
-		Categories:        stats.Categories,
+	owners, err := h.loadOwners(c, templates...)
+	if err != nil {
+		respondInternalError(c, "failed to load template owners", err)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	response := make([]dto.TemplateResponse, len(templates))
+	for i, template := range templates {
+		matchedFields, highlight := search.Match(metadataSearchFields(template.Template.Metadata), terms, search.DefaultRadius)
+
+		response[i] = toTemplateResponse(template)
+		response[i].MatchedFields = matchedFields
+		response[i].Highlight = highlight
+		owners.apply(template, &response[i])
+		if legacyCompatRequested(c) {
+			response[i].WithLegacyAliases()
+		}
+	}
+
+	projected, err := projectTemplates(response, selection)
+	if err != nil {
+		respondInternalError(c, "failed to select template fields", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": projected,
+		"query":     query,
+		"limit":     limit,
+		"offset":    offset,
+		"total":     total,
+		"links":     pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, total),
+	})
 }
 
-func (h *TemplateHandler) GetTemplateRating(c *gin.Context) {
+func (h *TemplateHandler) DownloadTemplate(c *gin.Context) {
 	templateID := c.Param("id")
 	if templateID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -418,24 +696,845 @@ func (h *TemplateHandler) GetTemplateRating(c *gin.Context) {
 		return
 	}
 
-	rating, err := h.templateRepo.GetRating(c.Request.Context(), templateID)
-	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
-			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to get template rating", err),
-		})
+	template, ok := h.loadVisibleTemplate(c, templateID)
+	if !ok {
 		return
 	}
 
-	response := &dto.TemplateRatingResponse{
-		TemplateID:    rating.TemplateID,
-		AverageRating: rating.AverageRating,
+	// HEAD requests only check the download, so they are not counted
+	if !middleware.IsHead(c) {
+		if err := h.templateRepo.IncrementDownloads(c.Request.Context(), templateID); err != nil {
+			respondInternalError(c, "failed to increment download count", err)
+			return
+		}
+
+		// The history is a convenience, so failing to record it does not
+		// fail the download
+		if userID := c.GetString("user_id"); userID != "" {
+			if err := h.userRepo.RecordDownload(c.Request.Context(), userID, templateID, time.Now()); err != nil {
+				log.Printf("Failed to record download of %s by %s: %v", templateID, userID, err)
+			}
+		}
+		h.downloads.record(c, models.DownloadResourceTemplate, templateID)
+	}
+
+	info := h.downloadInfo(c, template)
+	if legacyCompatRequested(c) {
+		c.JSON(http.StatusOK, dto.NewLegacyTemplate(template.Template, info))
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.TemplateDownload{Template: template.Template, DownloadInfo: info})
+}
+
+// downloadInfo reports the template's schema version and, when the X-Client
+// header names a CLI release too old for it, what that release would drop.
+// Clients that do not identify themselves get no warnings.
+func (h *TemplateHandler) downloadInfo(c *gin.Context, template *models.StoredTemplate) dto.DownloadInfo {
+	schema := template.SchemaVersion
+	if schema == 0 {
+		schema = template.Template.SchemaVersion()
+	}
+
+	info := dto.DownloadInfo{SchemaVersion: schema}
+	if client, ok := compat.ParseClient(c.GetHeader("X-Client")); ok {
+		info.Warnings = h.clientMatrix.Warnings(schema, client)
+	}
+	return info
+}
+
+// GetTemplateDownloadHistory returns a visible template's downloads per day
+func (h *TemplateHandler) GetTemplateDownloadHistory(c *gin.Context) {
+	template, ok := h.loadVisibleTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	h.downloads.respondHistory(c, models.DownloadResourceTemplate, template.ID)
+}
+
+// GetTemplatePlatformAnalytics returns a template's downloads per platform
+// (author only)
+func (h *TemplateHandler) GetTemplatePlatformAnalytics(c *gin.Context) {
+	template, ok := h.loadTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if template.Template.Metadata.Author != c.GetString("username") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("only the template author can see its analytics"),
+		})
+		return
+	}
+
+	h.downloads.respondPlatforms(c, models.DownloadResourceTemplate, template.ID)
+}
+
+// ConfigureDownloadEvents records each download as an event, which download
+// histories are built from
+func (h *TemplateHandler) ConfigureDownloadEvents(events repository.DownloadEventRepository) {
+	h.downloads = downloadEvents{repo: events}
+}
+
+// ConfigureContentLimits replaces the limits on how large a template may
+// be, which apply wherever templates are created or changed
+func (h *TemplateHandler) ConfigureContentLimits(limits dto.ContentLimits) {
+	h.limits = limits
+}
+
+// checkContentLimits checks a template against the content limits before
+// it is saved. When it is over one it writes the error response and
+// returns false.
+func (h *TemplateHandler) checkContentLimits(c *gin.Context, template *models.Template) bool {
+	if appErr := h.limits.CheckTemplate(template); appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return false
+	}
+	return true
+}
+
+// ConfigureClientMatrix replaces the minimum CLI releases used to warn old
+// clients on download
+func (h *TemplateHandler) ConfigureClientMatrix(matrix compat.Matrix) {
+	h.clientMatrix = matrix
+}
+
+// GetDockerSetup renders a Dockerfile snippet that installs the template's
+// packages inside a Linux Homebrew environment, for use in CI images.
+func (h *TemplateHandler) GetDockerSetup(c *gin.Context) {
+	templateID := c.Param("id")
+	if templateID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewBadRequestError("template ID is required"),
+		})
+		return
+	}
+
+	template, ok := h.loadVisibleTemplate(c, templateID)
+	if !ok {
+		return
+	}
+
+	sourceURL := fmt.Sprintf("%s://%s/api/templates/%s", requestScheme(c), c.Request.Host, template.ID)
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(renderDockerSetup(template, sourceURL)))
+}
+
+func (h *TemplateHandler) GetTemplateStats(c *gin.Context) {
+	csvExport, ok := wantsCSV(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.templateRepo.GetStats(c.Request.Context())
+	if err != nil {
+		respondInternalError(c, "failed to get template stats", err)
+		return
+	}
+
+	response := &dto.TemplateStatsResponse{
+		TotalTemplates:    stats.TotalTemplates,
+		FeaturedTemplates: stats.FeaturedTemplates,
+		TotalDownloads:    stats.TotalDownloads,
+		Categories:        stats.Categories,
+	}
+
+	if csvExport {
+		writeCSV(c, "template-stats.csv", statsHeader, statsRows(response))
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *TemplateHandler) GetTemplateRating(c *gin.Context) {
+	templateID := c.Param("id")
+	if templateID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewBadRequestError("template ID is required"),
+		})
+		return
+	}
+
+	rating, err := h.templateRepo.GetRating(c.Request.Context(), templateID)
+	if err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
+			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+			return
+		}
+		respondInternalError(c, "failed to get template rating", err)
+		return
+	}
+
+	response := &dto.TemplateRatingResponse{
+		TemplateID:    rating.TemplateID,
+		AverageRating: rating.AverageRating,
 		TotalRatings:  rating.TotalRatings,
 		Distribution:  rating.Distribution,
 	}
 
 	c.JSON(http.StatusOK, response)
+}
+
+// ImportFromGitHub creates a template from a Brewfile in a GitHub repository,
+// recording the source so it can be synced later
+func (h *TemplateHandler) ImportFromGitHub(c *gin.Context) {
+	var req dto.ImportGitHubTemplateRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(err.StatusCode, gin.H{"error": err})
+		return
+	}
+
+	token := githubToken(c)
+	repo, err := h.github.GetRepository(c.Request.Context(), req.Repo, token)
+	if err != nil {
+		respondGitHubError(c, err)
+		return
+	}
+
+	ref := req.Ref
+	if ref == "" {
+		ref = repo.DefaultBranch
+	}
+
+	packages, ok := h.importBrewfile(c, req.Repo, ref, req.Path, token)
+	if !ok {
+		return
+	}
+
+	name := repo.Name
+	if len(name) < 3 {
+		name = req.Repo
+	}
+
+	description := strings.TrimSpace(repo.Description)
+	if len(description) < 10 {
+		description = fmt.Sprintf("Packages imported from %s on GitHub", req.Repo)
+	}
+	if len(description) > 500 {
+		description = description[:500]
+	}
+
+	storedTemplate := &models.StoredTemplate{
+		Template: models.Template{
+			Taps:   packages.Taps,
+			Brews:  packages.Brews,
+			Casks:  packages.Casks,
+			Stow:   []string{},
+			Public: req.Public,
+			Metadata: models.ShareMetadata{
+				Name:        name,
+				Description: description,
+				Author:      c.GetString("username"),
+				Version:     "1.0.0",
+				Tags:        h.tags.CanonicalTags(req.Tags),
+			},
+			SourceRepo: &models.SourceRepo{
+				Repo:     req.Repo,
+				Ref:      ref,
+				Path:     req.Path,
+				SyncedAt: time.Now(),
+			},
+		},
+	}
+	if !h.checkContentLimits(c, &storedTemplate.Template) {
+		return
+	}
+
+	if err := h.templateRepo.Create(c.Request.Context(), storedTemplate); err != nil {
+		respondInternalError(c, "failed to create template", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toTemplateResponse(storedTemplate))
+}
+
+// SyncFromGitHub re-imports the Brewfile a template came from, replacing its
+// taps, brews and casks and bumping its patch version. Only the author may
+// sync a template.
+func (h *TemplateHandler) SyncFromGitHub(c *gin.Context) {
+	template, ok := h.loadTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if template.Template.Metadata.Author != c.GetString("username") {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": errors.NewForbiddenError("only the template author can sync it"),
+		})
+		return
+	}
+
+	source := template.Template.SourceRepo
+	if source == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewBadRequestError("template was not imported from GitHub"),
+		})
+		return
+	}
+
+	token := githubToken(c)
+	if _, err := h.github.GetRepository(c.Request.Context(), source.Repo, token); err != nil {
+		respondGitHubError(c, err)
+		return
+	}
+
+	packages, ok := h.importBrewfile(c, source.Repo, source.Ref, source.Path, token)
+	if !ok {
+		return
+	}
+
+	synced := template.Template
+	synced.Taps = packages.Taps
+	synced.Brews = packages.Brews
+	synced.Casks = packages.Casks
+	if !h.checkContentLimits(c, &synced) {
+		return
+	}
+
+	now := time.Now()
+	template.Template = synced
+	template.Template.Metadata.Version = nextPatchVersion(template.Template.Metadata.Version)
+	template.Template.Metadata.UpdatedAt = now
+	template.Template.SourceRepo.SyncedAt = now
+
+	if err := h.templateRepo.Update(c.Request.Context(), template); err != nil {
+		respondInternalError(c, "failed to update template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toTemplateResponse(template))
+}
+
+// importBrewfile fetches and parses a Brewfile. When it cannot it writes the
+// error response and returns false.
+func (h *TemplateHandler) importBrewfile(c *gin.Context, repo, ref, path, token string) (*brewfile.Brewfile, bool) {
+	content, err := h.github.GetFile(c.Request.Context(), repo, ref, path, token)
+	if err != nil {
+		respondGitHubError(c, err)
+		return nil, false
+	}
+
+	packages, err := brewfile.Parse(bytes.NewReader(content))
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": errors.NewValidationError(fmt.Sprintf("could not parse %s: %v", path, err)),
+		})
+		return nil, false
+	}
+
+	return packages, true
+}
+
+// githubToken returns the signed-in user's GitHub OAuth token, or "" to fall
+// back to anonymous access
+func githubToken(c *gin.Context) string {
+	value, exists := c.Get("session")
+	if !exists {
+		return ""
+	}
+
+	session, ok := value.(*auth.Session)
+	if !ok {
+		return ""
+	}
+
+	token, _ := session.Data[auth.GitHubTokenKey].(string)
+	return token
+}
+
+// respondGitHubError maps GitHub client failures onto API errors, keeping a
+// missing repository distinct from a missing file
+func respondGitHubError(c *gin.Context, err error) {
+	var rateLimited *github.RateLimitError
+	switch {
+	case stderrors.Is(err, github.ErrRepoNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("GitHub repository")})
+	case stderrors.Is(err, github.ErrFileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("file in GitHub repository")})
+	case stderrors.Is(err, github.ErrFileTooLarge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": errors.NewValidationError("file is too large to import")})
+	case stderrors.As(err, &rateLimited):
+		c.Header("Retry-After", strconv.Itoa(int(rateLimited.RetryAfter().Seconds())))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error": errors.NewRateLimitError("GitHub rate limit exceeded, sign in or try again later"),
+		})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": errors.NewInternalError("failed to reach GitHub", err),
+		})
+	}
+}
+
+// nextPatchVersion increments the patch component of a major.minor.patch
+// version, leaving other version strings unchanged
+func nextPatchVersion(version string) string {
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return version
+	}
+
+	patch, err := strconv.Atoi(parts[2])
+	if err != nil || patch < 0 {
+		return version
+	}
+
+	parts[2] = strconv.Itoa(patch + 1)
+	return strings.Join(parts, ".")
+}
+
+// toTemplateResponse converts a stored template to its API representation.
+// Every handler returning templates goes through it so new fields are only
+// mapped once.
+func toTemplateResponse(template *models.StoredTemplate) dto.TemplateResponse {
+	response := dto.TemplateResponse{
+		ID:             template.ID,
+		Taps:           template.Template.Taps,
+		Brews:          template.Template.Brews,
+		Casks:          template.Template.Casks,
+		Stow:           template.Template.Stow,
+		AptPackages:    template.Template.AptPackages,
+		PipPackages:    template.Template.PipPackages,
+		Extends:        template.Template.Extends,
+		Overrides:      template.Template.Overrides,
+		AddOnly:        template.Template.AddOnly,
+		Public:         template.Template.Public,
+		Featured:       template.Template.Featured,
+		OrganizationID: template.Template.OrganizationID,
+		Hooks:          template.Template.Hooks,
+		PackageConfigs: template.Template.PackageConfigs,
+		Downloads:      template.Downloads,
+		PopularityScore: template.PopularityScore,
+		Unlisted:        template.Unlisted,
+		Images:          template.Images,
+		CreatedAt:       template.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:       template.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		Metadata: dto.TemplateMetadataResponse{
+			Name:        template.Template.Metadata.Name,
+			Description: template.Template.Metadata.Description,
+			Author:      template.Template.Metadata.Author,
+			Version:     template.Template.Metadata.Version,
+			NonSemver:   dto.IsNonSemver(template.Template.Metadata.Version),
+			Tags:        template.Template.Metadata.Tags,
+			CreatedAt:   template.Template.Metadata.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			UpdatedAt:   template.Template.Metadata.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		},
+	}
+
+	if source := template.Template.SourceRepo; source != nil {
+		response.SourceRepo = &dto.SourceRepoResponse{
+			Repo:     source.Repo,
+			Ref:      source.Ref,
+			Path:     source.Path,
+			SyncedAt: source.SyncedAt.Format("2006-01-02T15:04:05Z"),
+		}
+	}
+
+	return response
+}
+
+// derivedTemplateFields maps response fields computed from other template
+// fields to the fields they are computed from, which must then be loaded
+var derivedTemplateFields = map[string][]string{
+	"matched_fields":      {"metadata"},
+	"highlight":           {"metadata"},
+	"addOnly":             {"add_only"},
+	"owner":               {"organization_id", "metadata.author"},
+	"metadata.non_semver": {"metadata.version"},
+}
+
+// parseTemplateFields reads the ?fields= selection. When it names unknown
+// fields it writes the error response and returns false.
+func parseTemplateFields(c *gin.Context) (dto.FieldSelection, bool) {
+	selection, appErr := dto.ParseTemplateFields(c.Query("fields"))
+	if appErr != nil {
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return nil, false
+	}
+	return selection, true
+}
+
+// storedTemplateFields lists the template fields the store has to load to
+// build the selected response fields; nil loads everything
+func storedTemplateFields(selection dto.FieldSelection) []string {
+	if selection == nil {
+		return nil
+	}
+
+	fields := selection.Paths()
+	for derived, sources := range derivedTemplateFields {
+		if selection.HasPath(derived) {
+			fields = append(fields, sources...)
+		}
+	}
+	return fields
+}
+
+// projectTemplates trims template responses to the selected fields, leaving
+// them whole when nothing was selected
+func projectTemplates(responses []dto.TemplateResponse, selection dto.FieldSelection) (interface{}, error) {
+	if selection == nil {
+		return responses, nil
+	}
+
+	projected := make([]map[string]interface{}, len(responses))
+	for i, response := range responses {
+		fields, err := selection.Project(response)
+		if err != nil {
+			return nil, err
+		}
+		projected[i] = fields
+	}
+	return projected, nil
+}
+
+// legacyCompatRequested reports whether the caller opted into the v0 JSON
+// shape via the X-API-Compat header or the compat query parameter.
+func legacyCompatRequested(c *gin.Context) bool {
+	return c.GetHeader("X-API-Compat") == dto.LegacyCompatVersion ||
+		c.Query("compat") == dto.LegacyCompatVersion
+}
+
+// loadTemplate fetches a template by ID. When the template cannot be served it
+// writes the error response and returns false.
+func (h *TemplateHandler) loadTemplate(c *gin.Context, templateID string) (*models.StoredTemplate, bool) {
+	template, err := h.templateRepo.GetByID(c.Request.Context(), templateID)
+	if err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
+			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+			return nil, false
+		}
+		if stderrors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("template")})
+			return nil, false
+		}
+		respondInternalError(c, "failed to get template", err)
+		return nil, false
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("template")})
+		return nil, false
+	}
+
+	return template, true
+}
+
+// loadVisibleTemplate is loadTemplate for endpoints that serve a template's
+// contents. Templates the caller may not see are reported as not found, so
+// guessing an ID does not confirm that a private template exists.
+func (h *TemplateHandler) loadVisibleTemplate(c *gin.Context, templateID string) (*models.StoredTemplate, bool) {
+	template, ok := h.loadTemplate(c, templateID)
+	if !ok {
+		return nil, false
+	}
+
+	visible, err := h.authorizer.CanViewTemplate(c, template)
+	if err != nil {
+		respondInternalError(c, "failed to check template visibility", err)
+		return nil, false
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("template")})
+		return nil, false
+	}
+
+	return template, true
+}
+
+// maxInheritanceDepth bounds how many templates an extends chain may walk
+const maxInheritanceDepth = 10
+
+// resolvedTemplate wraps the resolution response with the inherited packages
+// so overrides can be checked against them
+type resolvedTemplate struct {
+	*dto.ResolvedTemplateResponse
+	inherited map[string]bool
+}
+
+func (r *resolvedTemplate) inherits(name string) bool {
+	return r.inherited[name]
+}
+
+// resolveInheritance walks the extends chain starting at req.Extends and
+// merges packages from the root ancestor down to the request itself. A broken
+// chain is reported as a problem; err is only set for repository failures.
+func (h *TemplateHandler) resolveInheritance(c *gin.Context, req *dto.CreateTemplateRequest) (*resolvedTemplate, *dto.TemplateProblem, error) {
+	ancestors, problem, err := h.loadAncestors(c, req.Extends)
+	if problem != nil || err != nil {
+		return nil, problem, err
+	}
+
+	resolved := &resolvedTemplate{
+		ResolvedTemplateResponse: &dto.ResolvedTemplateResponse{},
+		inherited:                make(map[string]bool),
+	}
+
+	// Chain lists the nearest parent first
+	for _, ancestor := range ancestors {
+		resolved.Chain = append(resolved.Chain, ancestor.ID)
+	}
+
+	// Merge from the root ancestor down so the nearest template comes last
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		tmpl := ancestors[i].Template
+		resolved.Taps = mergePackages(resolved.Taps, tmpl.Taps)
+		resolved.Brews = mergePackages(resolved.Brews, tmpl.Brews)
+		resolved.Casks = mergePackages(resolved.Casks, tmpl.Casks)
+		resolved.Stow = mergePackages(resolved.Stow, tmpl.Stow)
+		resolved.AptPackages = mergePackages(resolved.AptPackages, tmpl.AptPackages)
+		resolved.PipPackages = mergePackages(resolved.PipPackages, tmpl.PipPackages)
+	}
+
+	for _, list := range [][]string{resolved.Taps, resolved.Brews, resolved.Casks, resolved.Stow, resolved.AptPackages, resolved.PipPackages} {
+		for _, name := range list {
+			resolved.inherited[name] = true
+		}
+	}
+
+	resolved.Taps = mergePackages(resolved.Taps, req.Taps)
+	resolved.Brews = mergePackages(resolved.Brews, req.Brews)
+	resolved.Casks = mergePackages(resolved.Casks, req.Casks)
+	resolved.Stow = mergePackages(resolved.Stow, req.Stow)
+	resolved.AptPackages = mergePackages(resolved.AptPackages, req.AptPackages)
+	resolved.PipPackages = mergePackages(resolved.PipPackages, req.PipPackages)
+
+	return resolved, nil, nil
+}
+
+// loadAncestors walks the extends chain starting at extends, returning the
+// nearest parent first. A broken chain is reported as a problem; err is only
+// set for repository failures. Ancestors the caller may not see are treated
+// as missing.
+func (h *TemplateHandler) loadAncestors(c *gin.Context, extends string) ([]*models.StoredTemplate, *dto.TemplateProblem, error) {
+	ctx := c.Request.Context()
+	var ancestors []*models.StoredTemplate
+	visited := make(map[string]bool)
+
+	for id := extends; id != ""; {
+		if visited[id] {
+			return nil, &dto.TemplateProblem{Field: "extends", Message: fmt.Sprintf("inheritance cycle detected at template %q", id)}, nil
+		}
+		if len(ancestors) == maxInheritanceDepth {
+			return nil, &dto.TemplateProblem{Field: "extends", Message: fmt.Sprintf("inheritance chain is deeper than %d templates", maxInheritanceDepth)}, nil
+		}
+		visited[id] = true
+
+		parent, err := h.templateRepo.GetByID(ctx, id)
+		if err != nil {
+			var appErr *errors.AppError
+			if !stderrors.As(err, &appErr) && !stderrors.Is(err, repository.ErrNotFound) {
+				return nil, nil, err
+			}
+			parent = nil
+		}
+		if parent != nil {
+			visible, err := h.authorizer.CanViewTemplate(c, parent)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !visible {
+				parent = nil
+			}
+		}
+		if parent == nil {
+			return nil, &dto.TemplateProblem{Field: "extends", Message: fmt.Sprintf("template %q not found", id)}, nil
+		}
+
+		ancestors = append(ancestors, parent)
+		id = parent.Template.Extends
+	}
+
+	return ancestors, nil, nil
+}
+
+// mergePackages appends the packages from next that base does not already contain
+func mergePackages(base, next []string) []string {
+	merged := append([]string{}, base...)
+	seen := make(map[string]bool, len(merged))
+	for _, name := range merged {
+		seen[name] = true
+	}
+
+	for _, name := range next {
+		if !seen[name] {
+			merged = append(merged, name)
+			seen[name] = true
+		}
+	}
+	return merged
+}
+
+// parseDateRange reads the created_after, created_before and updated_after
+// RFC3339 query parameters shared by the list endpoints
+func parseDateRange(c *gin.Context) (repository.DateRange, *errors.AppError) {
+	var dates repository.DateRange
+
+	params := []struct {
+		name  string
+		bound **time.Time
+	}{
+		{"created_after", &dates.CreatedAfter},
+		{"created_before", &dates.CreatedBefore},
+		{"updated_after", &dates.UpdatedAfter},
+	}
+
+	for _, param := range params {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return dates, errors.NewValidationError(param.name + " must be an RFC3339 timestamp")
+		}
+		*param.bound = &t
+	}
+
+	if dates.CreatedAfter != nil && dates.CreatedBefore != nil && dates.CreatedAfter.After(*dates.CreatedBefore) {
+		return dates, errors.NewValidationError("created_after must not be later than created_before")
+	}
+
+	return dates, nil
+}
+
+// parseTemplateSort reads the sort keys for a template listing from a
+// comma-separated sort=field[:asc|desc] list, each direction defaulting to
+// desc, or else from sort_by, which takes the same list with directions
+// defaulting to sort_order
+func parseTemplateSort(c *gin.Context) ([]repository.SortKey, *errors.AppError) {
+	if raw, ok := c.GetQuery("sort"); ok {
+		return parseSortKeys(raw, "sort", true)
+	}
+
+	order := c.DefaultQuery("sort_order", "desc")
+	if order != "asc" && order != "desc" {
+		return nil, errors.NewValidationError("sort_order must be one of asc, desc")
+	}
+	return parseSortKeys(c.DefaultQuery("sort_by", "created_at"), "sort_by", order == "desc")
+}
+
+// parseSortKeys parses a comma-separated field[:asc|desc] list of template
+// sort keys, named param in errors. Keys without a direction use desc.
+func parseSortKeys(raw, param string, desc bool) ([]repository.SortKey, *errors.AppError) {
+	allowed := strings.Join(repository.TemplateSortFields, ", ")
+
+	var keys []repository.SortKey
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		field, order, hasOrder := strings.Cut(strings.TrimSpace(part), ":")
+		if !slices.Contains(repository.TemplateSortFields, field) {
+			return nil, errors.NewValidationError(param + " fields must be one of " + allowed)
+		}
+		if hasOrder && order != "asc" && order != "desc" {
+			return nil, errors.NewValidationError(param + " directions must be one of asc, desc")
+		}
+		if seen[field] {
+			return nil, errors.NewValidationError(param + " field " + field + " is repeated")
+		}
+		seen[field] = true
+
+		key := repository.SortKey{Field: field, Desc: desc}
+		if hasOrder {
+			key.Desc = order == "desc"
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// metadataSearchFields lists the metadata fields searches match against, in
+// the order matches are reported
+func metadataSearchFields(metadata models.ShareMetadata) []search.Field {
+	return []search.Field{
+		{Name: "name", Text: metadata.Name},
+		{Name: "description", Text: metadata.Description},
+		{Name: "tags", Text: strings.Join(metadata.Tags, ", ")},
+		{Name: "author", Text: metadata.Author},
+	}
+}
+
+// requestScheme returns the scheme the client used, honouring TLS-terminating proxies
+func requestScheme(c *gin.Context) string {
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		return "https"
+	}
+	return "http"
+}
+
+// renderDockerSetup builds the Dockerfile snippet served by GetDockerSetup.
+// Casks are macOS-only and are listed in a comment rather than installed.
+func renderDockerSetup(template *models.StoredTemplate, sourceURL string) string {
+	var b strings.Builder
+
+	// Keep user-supplied metadata on a single comment line
+	oneLine := strings.NewReplacer("\r", " ", "\n", " ")
+	fmt.Fprintf(&b, "# Template: %s\n", oneLine.Replace(template.Template.Metadata.Name))
+	fmt.Fprintf(&b, "# Version: %s\n", oneLine.Replace(template.Template.Metadata.Version))
+	fmt.Fprintf(&b, "# Source: %s\n", sourceURL)
+
+	// Homebrew on Linux needs these packages and must not run as root
+	aptPackages := append([]string{"build-essential", "ca-certificates", "curl", "file", "git", "procps", "sudo"}, template.Template.AptPackages...)
+	if len(template.Template.PipPackages) > 0 {
+		aptPackages = append(aptPackages, "python3-pip")
+	}
+
+	b.WriteString("RUN apt-get update \\\n")
+	b.WriteString("    && apt-get install -y --no-install-recommends " + shellWords(aptPackages) + " \\\n")
+	b.WriteString("    && rm -rf /var/lib/apt/lists/*\n")
+
+	if len(template.Template.PipPackages) > 0 {
+		b.WriteString("RUN pip3 install --no-cache-dir " + shellWords(template.Template.PipPackages) + "\n")
+	}
+
+	if len(template.Template.Brews) > 0 {
+		b.WriteString("RUN useradd -m -s /bin/bash linuxbrew \\\n")
+		b.WriteString("    && echo 'linuxbrew ALL=(ALL) NOPASSWD:ALL' >> /etc/sudoers\n")
+		b.WriteString("USER linuxbrew\n")
+		b.WriteString("RUN NONINTERACTIVE=1 /bin/bash -c \"$(curl -fsSL https://raw.githubusercontent.com/Homebrew/install/HEAD/install.sh)\"\n")
+		b.WriteString("ENV PATH=\"/home/linuxbrew/.linuxbrew/bin:/home/linuxbrew/.linuxbrew/sbin:${PATH}\"\n")
+
+		b.WriteString("RUN brew update")
+		for _, tap := range template.Template.Taps {
+			b.WriteString(" \\\n    && brew tap " + shellQuote(tap))
+		}
+		b.WriteString(" \\\n    && brew install " + shellWords(template.Template.Brews) + "\n")
+		b.WriteString("USER root\n")
+	}
+
+	if len(template.Template.Casks) > 0 {
+		b.WriteString("# Skipped macOS-only casks: " + oneLine.Replace(strings.Join(template.Template.Casks, ", ")) + "\n")
+	}
+
+	return b.String()
+}
+
+// shellWords quotes each word for safe use in a generated shell command
+func shellWords(words []string) string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = shellQuote(word)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote single-quotes a word unless it only contains safe characters
+func shellQuote(word string) string {
+	safe := word != ""
+	for _, r := range word {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./@+=:", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
 }
\ No newline at end of file
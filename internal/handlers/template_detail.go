@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/language"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/pagination"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTemplateDetail returns everything a template page shows in one
+// response: the template as GetTemplate returns it, its rating, a page of
+// its reviews and whether the caller has favorited it. The lookups after
+// the template's visibility check run concurrently.
+func (h *TemplateHandler) GetTemplateDetail(c *gin.Context) {
+	if h.reviewRepo == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": errors.NewUnavailableError("Reviews are not enabled"),
+		})
+		return
+	}
+
+	templateID := c.Param("id")
+	if templateID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": errors.NewBadRequestError("template ID is required"),
+		})
+		return
+	}
+
+	template, ok := h.loadVisibleTemplate(c, templateID)
+	if !ok {
+		return
+	}
+
+	languages, ok := parseLanguageFilter(c)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	limit, offset := parsePagination(c)
+
+	var (
+		wg                  sync.WaitGroup
+		viewer              *viewerState
+		owners              *ownerIndex
+		rating              *models.TemplateRating
+		reviews             []*models.Review
+		viewerErr, ownerErr error
+		ratingErr, listErr  error
+	)
+	wg.Add(4)
+	go func() {
+		defer wg.Done()
+		viewer, viewerErr = h.loadViewerState(ctx, c.GetString("user_id"))
+	}()
+	go func() {
+		defer wg.Done()
+		owners, ownerErr = h.loadOwners(c, template)
+	}()
+	go func() {
+		defer wg.Done()
+		rating, ratingErr = h.reviewRepo.CalculateTemplateRating(ctx, template.ID)
+	}()
+	go func() {
+		defer wg.Done()
+		reviews, listErr = h.reviewRepo.GetByTemplate(ctx, template.ID, languages, limit, offset)
+	}()
+	wg.Wait()
+
+	switch {
+	case viewerErr != nil:
+		respondInternalError(c, "failed to load favorites and reviews", viewerErr)
+		return
+	case ownerErr != nil:
+		respondInternalError(c, "failed to load template owner", ownerErr)
+		return
+	case ratingErr != nil:
+		respondInternalError(c, "failed to calculate rating", ratingErr)
+		return
+	case listErr != nil:
+		respondInternalError(c, "failed to get reviews", listErr)
+		return
+	}
+
+	response := toTemplateResponse(template)
+	viewer.apply(&response)
+	owners.apply(template, &response)
+	if !applyInstalledVersion(c, template, &response) {
+		return
+	}
+	if legacyCompatRequested(c) {
+		response.WithLegacyAliases()
+	}
+
+	if reviews == nil {
+		reviews = []*models.Review{}
+	}
+	for _, review := range reviews {
+		review.AvatarProxyURL = dto.AvatarProxyPath(review.UserID)
+		if review.Language == "" {
+			review.Language = language.Undetermined
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"template":     response,
+		"rating":       rating,
+		"reviews":      reviews,
+		"is_favorited": response.IsFavorited != nil && *response.IsFavorited,
+		"limit":        limit,
+		"offset":       offset,
+		"links":        pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, pagination.PageTotal(offset, limit, len(reviews))),
+	})
+}
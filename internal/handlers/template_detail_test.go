@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetTemplateDetail(t *testing.T) {
+	ctx := context.Background()
+	templateRepo := memory.NewTemplateRepository()
+	for _, template := range []*models.StoredTemplate{
+		{ID: "tpl", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Name: "Detailed"}}},
+		{ID: "hidden", Template: models.Template{Metadata: models.ShareMetadata{Name: "Hidden"}}},
+	} {
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	reviewRepo := memory.NewReviewRepository()
+	for _, review := range []*models.Review{
+		{ID: "review-1", TemplateID: "tpl", UserID: "bob-id", Rating: 5},
+		{ID: "review-2", TemplateID: "tpl", UserID: "carol-id", Rating: 4},
+		{ID: "review-3", TemplateID: "tpl", UserID: "dave-id", Rating: 3},
+	} {
+		if err := reviewRepo.Create(ctx, review); err != nil {
+			t.Fatalf("Failed to create review: %v", err)
+		}
+	}
+
+	userRepo := memory.NewUserRepository()
+	if err := userRepo.Create(ctx, &models.User{ID: "alice-id", Username: "alice", Email: "alice@example.com"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if err := userRepo.AddFavorite(ctx, "alice-id", "tpl"); err != nil {
+		t.Fatalf("Failed to add favorite: %v", err)
+	}
+
+	h := NewTemplateHandler(templateRepo, memory.NewTagRepository(), userRepo, reviewRepo, tags.NewRegistry(), NewAuthorizer(memory.NewOrganizationRepository()))
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates/:id/detail", h.GetTemplateDetail)
+
+	w := sendAs(r, http.MethodGet, "/api/templates/tpl/detail?limit=2", "alice", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	if template := body["template"].(map[string]interface{}); template["id"] != "tpl" {
+		t.Errorf("Expected the template, got %v", template)
+	}
+	if rating := body["rating"].(map[string]interface{}); rating["total_ratings"] != float64(3) || rating["average_rating"] != float64(4) {
+		t.Errorf("Expected a rating of 4 from 3 reviews, got %v", rating)
+	}
+	if reviews := body["reviews"].([]interface{}); len(reviews) != 2 {
+		t.Errorf("Expected the first page of 2 reviews, got %d", len(reviews))
+	}
+	if body["is_favorited"] != true {
+		t.Errorf("Expected alice's favorite to be reported, got %v", body["is_favorited"])
+	}
+
+	w = sendAs(r, http.MethodGet, "/api/templates/tpl/detail?limit=2&offset=2", "", "")
+	body = decodeBody(t, w)
+	if reviews := body["reviews"].([]interface{}); len(reviews) != 1 || body["is_favorited"] != false {
+		t.Errorf("Expected the last review and no favorite for an anonymous caller, got %v", body)
+	}
+
+	for _, url := range []string{"/api/templates/hidden/detail", "/api/templates/missing/detail"} {
+		if w := sendAs(r, http.MethodGet, url, "", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for %s, got %d", url, w.Code)
+		}
+	}
+}
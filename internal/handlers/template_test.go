@@ -0,0 +1,1481 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/github"
+	"dotfiles-api/internal/middleware"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestTemplateHandler wires a template handler to in-memory tag and
+// organization storage
+func newTestTemplateHandler(repo repository.TemplateRepository) *TemplateHandler {
+	return NewTemplateHandler(repo, memory.NewTagRepository(), memory.NewUserRepository(), memory.NewReviewRepository(), tags.NewRegistry(), NewAuthorizer(memory.NewOrganizationRepository()))
+}
+
+func newTemplateTestRouter() *gin.Engine {
+	h := newTestTemplateHandler(memory.NewTemplateRepository())
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/templates", h.CreateTemplate)
+	r.GET("/api/templates", h.ListTemplates)
+	r.GET("/api/templates/:id", h.GetTemplate)
+	r.GET("/api/templates/:id/download", h.DownloadTemplate)
+	return r
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v (%s)", err, w.Body.String())
+	}
+	return body
+}
+
+const createTemplateBody = `{
+	"brews": ["git"],
+	"%s": true,
+	"metadata": {
+		"name": "Compat Template",
+		"description": "Template used to exercise the compat layer",
+		"author": "test-user",
+		"version": "1.0.0"
+	}
+}`
+
+func TestCreateTemplateAcceptsEitherAddOnlyKey(t *testing.T) {
+	for _, key := range []string{"add_only", "addOnly"} {
+		t.Run(key, func(t *testing.T) {
+			r := newTemplateTestRouter()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/templates", strings.NewReader(strings.Replace(createTemplateBody, "%s", key, 1)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Test-User", "test-user-id")
+			req.Header.Set("X-Test-Username", "test-user")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+			}
+
+			body := decodeBody(t, w)
+			if body["add_only"] != true {
+				t.Errorf("Expected add_only true, got %v", body["add_only"])
+			}
+		})
+	}
+}
+
+func TestCreateTemplateAttribution(t *testing.T) {
+	r := newTemplateTestRouter()
+
+	create := func(username, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/templates", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if username != "" {
+			req.Header.Set("X-Test-User", username+"-id")
+			req.Header.Set("X-Test-Username", username)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	body := func(public bool, organizationID string) string {
+		return fmt.Sprintf(`{
+			"brews": ["git"],
+			"public": %t,
+			"organization_id": %q,
+			"metadata": {"name": "Attributed", "description": "Template used to check authors", "author": "someone-else", "version": "1.0.0"}
+		}`, public, organizationID)
+	}
+	author := func(w *httptest.ResponseRecorder) interface{} {
+		return decodeBody(t, w)["metadata"].(map[string]interface{})["author"]
+	}
+
+	w := create("alice", body(false, ""))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := author(w); got != "alice" {
+		t.Errorf("Expected the template attributed to the caller, got %v", got)
+	}
+
+	w = create("", body(true, ""))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := author(w); got != models.AnonymousAuthor {
+		t.Errorf("Expected the anonymous placeholder author, got %v", got)
+	}
+
+	for _, anonymous := range []string{body(false, ""), body(true, "org-acme")} {
+		if w := create("", anonymous); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for an anonymous private or organization template, got %d", w.Code)
+		}
+	}
+}
+
+func TestOrganizationTemplatesRequireMembership(t *testing.T) {
+	ctx := context.Background()
+	orgRepo := memory.NewOrganizationRepository()
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "alice-id", Public: true}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	if err := orgRepo.AddMember(ctx, &models.OrganizationMember{ID: "m1", OrganizationID: "org-acme", UserID: "alice-id", Role: models.RoleOwner}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	repo := memory.NewTemplateRepository()
+	h := NewTemplateHandler(repo, memory.NewTagRepository(), memory.NewUserRepository(), memory.NewReviewRepository(), tags.NewRegistry(), NewAuthorizer(orgRepo))
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/templates", h.CreateTemplate)
+	r.PATCH("/api/templates/:id", h.PatchTemplate)
+
+	send := func(method, url, username, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("X-Test-User", username+"-id")
+		req.Header.Set("X-Test-Username", username)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	create := func(username, organizationID string) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/api/templates", username, "application/json", fmt.Sprintf(`{
+			"brews": ["git"],
+			"public": true,
+			"organization_id": %q,
+			"metadata": {"name": "Team Setup", "description": "Tools the whole team uses", "author": "ignored", "version": "1.0.0"}
+		}`, organizationID))
+	}
+
+	if w := create("mallory", "org-acme"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 creating a template in another organization, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := create("alice", "org-acme"); w.Code != http.StatusCreated {
+		t.Errorf("Expected status 201 for a member, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := create("mallory", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	id := decodeBody(t, w)["id"].(string)
+	if w := send(http.MethodPatch, "/api/templates/"+id, "mallory", mergePatchContentType, `{"organization_id": "org-acme"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 moving a template into another organization, got %d: %s", w.Code, w.Body.String())
+	}
+	if stored, _ := repo.GetByID(ctx, id); stored.Template.OrganizationID != "" {
+		t.Errorf("Expected the refused patch to change nothing, got %q", stored.Template.OrganizationID)
+	}
+}
+
+func TestTemplateCanonicalShape(t *testing.T) {
+	r := newTemplateTestRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates/essential-developer-setup/download", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := decodeBody(t, w)
+	if _, ok := body["add_only"]; !ok {
+		t.Error("Expected canonical add_only key in download response")
+	}
+	if _, ok := body["addOnly"]; ok {
+		t.Error("Did not expect legacy addOnly key without compat mode")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates", nil))
+
+	templates := decodeBody(t, w)["templates"].([]interface{})
+	if len(templates) == 0 {
+		t.Fatal("Expected at least one template")
+	}
+	for _, tmpl := range templates {
+		if _, ok := tmpl.(map[string]interface{})["addOnly"]; ok {
+			t.Error("Did not expect legacy addOnly key without compat mode")
+		}
+	}
+}
+
+func TestTemplateCompatMode(t *testing.T) {
+	r := newTemplateTestRouter()
+
+	requests := map[string]*http.Request{
+		"header": httptest.NewRequest(http.MethodGet, "/api/templates/essential-developer-setup/download", nil),
+		"query":  httptest.NewRequest(http.MethodGet, "/api/templates/essential-developer-setup/download?compat=v0", nil),
+	}
+	requests["header"].Header.Set("X-API-Compat", "v0")
+
+	for name, req := range requests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+
+			body := decodeBody(t, w)
+			if _, ok := body["add_only"]; !ok {
+				t.Error("Expected canonical add_only key in compat mode")
+			}
+			if _, ok := body["addOnly"]; !ok {
+				t.Error("Expected legacy addOnly alias in compat mode")
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates?compat=v0", nil))
+
+	templates := decodeBody(t, w)["templates"].([]interface{})
+	for _, tmpl := range templates {
+		fields := tmpl.(map[string]interface{})
+		if fields["addOnly"] != fields["add_only"] {
+			t.Errorf("Expected addOnly alias to mirror add_only, got %v and %v", fields["addOnly"], fields["add_only"])
+		}
+	}
+}
+
+func TestDownloadWarnsOldClients(t *testing.T) {
+	r := newTemplateTestRouter()
+
+	download := func(client string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodGet, "/api/templates/essential-developer-setup/download", nil)
+		if client != "" {
+			req.Header.Set("X-Client", client)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		return decodeBody(t, w)
+	}
+
+	// The seeded template uses hooks and package configs
+	body := download("dotfiles-cli/0.3.2")
+	if body["schema_version"] != float64(models.SchemaVersionHooks) {
+		t.Errorf("Expected schema version %d, got %v", models.SchemaVersionHooks, body["schema_version"])
+	}
+	want := []interface{}{"your CLI does not support hooks and package configs; upgrade to >= 0.4.0"}
+	if !reflect.DeepEqual(body["warnings"], want) {
+		t.Errorf("Expected %v, got %v", want, body["warnings"])
+	}
+
+	for _, client := range []string{"dotfiles-cli/0.4.0", "", "dotfiles-cli/dev"} {
+		if body := download(client); body["warnings"] != nil {
+			t.Errorf("Expected no warnings for client %q, got %v", client, body["warnings"])
+		}
+	}
+}
+
+func TestGetDockerSetup(t *testing.T) {
+	h := newTestTemplateHandler(memory.NewTemplateRepository())
+	r := gin.New()
+	r.GET("/api/templates/:id/docker-setup", h.GetDockerSetup)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "http://api.example.com/api/templates/essential-developer-setup/docker-setup", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Errorf("Expected text/plain content type, got %q", contentType)
+	}
+
+	body := w.Body.String()
+	for _, expected := range []string{
+		"# Template: Essential Developer Setup",
+		"# Version: 1.0.0",
+		"# Source: http://api.example.com/api/templates/essential-developer-setup",
+		"brew tap homebrew/cask-fonts",
+		"brew install git curl wget",
+		"# Skipped macOS-only casks: visual-studio-code",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, body)
+		}
+	}
+
+	if strings.Contains(body, "pip3 install") {
+		t.Error("Did not expect a pip step for a template without pip packages")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates/missing/docker-setup", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for missing template, got %d", w.Code)
+	}
+}
+
+func TestRenderDockerSetupQuotesPackages(t *testing.T) {
+	tmpl := &models.StoredTemplate{
+		ID: "quoted",
+		Template: models.Template{
+			Brews:       []string{"git", "evil; rm -rf /"},
+			AptPackages: []string{"jq"},
+			PipPackages: []string{"httpie"},
+		},
+	}
+
+	out := renderDockerSetup(tmpl, "http://localhost/api/templates/quoted")
+
+	if !strings.Contains(out, "brew install git 'evil; rm -rf /'") {
+		t.Errorf("Expected unsafe package names to be quoted, got:\n%s", out)
+	}
+	if !strings.Contains(out, " jq python3-pip") {
+		t.Errorf("Expected apt packages and pip prerequisite, got:\n%s", out)
+	}
+	if !strings.Contains(out, "pip3 install --no-cache-dir httpie") {
+		t.Errorf("Expected pip install step, got:\n%s", out)
+	}
+}
+
+func validateTemplate(t *testing.T, r *gin.Engine, body string) map[string]interface{} {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/templates/validate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	return decodeBody(t, w)
+}
+
+func problemFields(body map[string]interface{}, key string) []string {
+	var fields []string
+	for _, problem := range body[key].([]interface{}) {
+		fields = append(fields, problem.(map[string]interface{})["field"].(string))
+	}
+	return fields
+}
+
+func TestValidateTemplateReportsAllProblems(t *testing.T) {
+	repo := memory.NewTemplateRepository()
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.POST("/api/templates/validate", h.ValidateTemplate)
+
+	before, _ := repo.List(context.Background(), repository.TemplateFilters{})
+
+	body := validateTemplate(t, r, `{
+		"brews": ["git", "Not A Package", "git"],
+		"metadata": {"name": "ab", "description": "short", "author": "", "version": "1.0.0"}
+	}`)
+
+	if body["valid"] != false {
+		t.Errorf("Expected valid false, got %v", body["valid"])
+	}
+
+	problems := strings.Join(problemFields(body, "problems"), ",")
+	for _, field := range []string{"metadata.name", "metadata.description", "metadata.author", "brews[1]"} {
+		if !strings.Contains(problems, field) {
+			t.Errorf("Expected problem for %s, got %s", field, problems)
+		}
+	}
+
+	if warnings := problemFields(body, "warnings"); len(warnings) != 1 || warnings[0] != "brews[2]" {
+		t.Errorf("Expected duplicate warning for brews[2], got %v", warnings)
+	}
+
+	// Nothing is persisted
+	after, _ := repo.List(context.Background(), repository.TemplateFilters{})
+	if len(after) != len(before) {
+		t.Errorf("Expected validation not to create templates, got %d before and %d after", len(before), len(after))
+	}
+}
+
+func TestValidateTemplateResolvesInheritance(t *testing.T) {
+	repo := memory.NewTemplateRepository()
+	ctx := context.Background()
+	for _, tmpl := range []*models.StoredTemplate{
+		{ID: "base", Template: models.Template{Public: true, Brews: []string{"git", "curl"}}},
+		{ID: "middle", Template: models.Template{Public: true, Extends: "base", Brews: []string{"jq"}, Casks: []string{"ghostty"}}},
+		{ID: "loop-a", Template: models.Template{Public: true, Extends: "loop-b"}},
+		{ID: "loop-b", Template: models.Template{Public: true, Extends: "loop-a"}},
+		{ID: "hidden", Template: models.Template{Brews: []string{"secret-tool"}, Metadata: models.ShareMetadata{Author: "someone-else"}}},
+	} {
+		if err := repo.Create(ctx, tmpl); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.POST("/api/templates/validate", h.ValidateTemplate)
+
+	const template = `{
+		"extends": "%s",
+		"brews": ["git", "tmux"],
+		"overrides": ["curl", "htop"],
+		"metadata": {"name": "Child Template", "description": "Extends the middle template", "author": "tester", "version": "1.0.0"}
+	}`
+
+	body := validateTemplate(t, r, strings.Replace(template, "%s", "middle", 1))
+	if body["valid"] != true {
+		t.Fatalf("Expected valid template, got %v", body)
+	}
+
+	resolved := body["resolved"].(map[string]interface{})
+	if chain := fmt.Sprint(resolved["chain"]); chain != "[middle base]" {
+		t.Errorf("Expected chain [middle base], got %s", chain)
+	}
+	if brews := fmt.Sprint(resolved["brews"]); brews != "[git curl jq tmux]" {
+		t.Errorf("Expected merged brews [git curl jq tmux], got %s", brews)
+	}
+
+	if warnings := body["warnings"].([]interface{}); len(warnings) != 1 || !strings.Contains(fmt.Sprint(warnings[0]), "htop") {
+		t.Errorf("Expected a warning for the unmatched htop override, got %v", warnings)
+	}
+
+	// Extending someone else's private template looks the same as extending
+	// a missing one
+	for _, extends := range []string{"missing", "loop-a", "hidden"} {
+		body = validateTemplate(t, r, strings.Replace(template, "%s", extends, 1))
+		if body["valid"] != false || body["resolved"] != nil {
+			t.Errorf("Expected unresolvable extends %q to be invalid, got %v", extends, body)
+		}
+		if fields := problemFields(body, "problems"); len(fields) != 1 || fields[0] != "extends" {
+			t.Errorf("Expected an extends problem for %q, got %v", extends, fields)
+		}
+	}
+}
+
+func TestValidateTemplateRejectsMalformedJSON(t *testing.T) {
+	h := newTestTemplateHandler(memory.NewTemplateRepository())
+	r := gin.New()
+	r.POST("/api/templates/validate", h.ValidateTemplate)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/templates/validate", strings.NewReader(`{"brews": [`)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestSearchTemplatesReportsMatches(t *testing.T) {
+	repo := memory.NewTemplateRepository()
+	if err := repo.Create(context.Background(), &models.StoredTemplate{
+		ID: "terminal",
+		Template: models.Template{
+			Public: true,
+			Metadata: models.ShareMetadata{
+				Name:        "Terminal Setup",
+				Description: "Neovim <b>and</b> tmux for the terminal",
+				Author:      "octocat",
+				Tags:        []string{"editor"},
+			},
+		},
+	}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.GET("/api/templates/search", h.SearchTemplates)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates/search?q=neovim+tmux", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result map[string]interface{}
+	for _, tmpl := range decodeBody(t, w)["templates"].([]interface{}) {
+		if tmpl.(map[string]interface{})["id"] == "terminal" {
+			result = tmpl.(map[string]interface{})
+		}
+	}
+	if result == nil {
+		t.Fatal("Expected the terminal template to match a multi-word query")
+	}
+
+	if fields := fmt.Sprint(result["matched_fields"]); fields != "[description]" {
+		t.Errorf("Expected matched_fields [description], got %s", fields)
+	}
+
+	highlight := result["highlight"].(map[string]interface{})
+	expected := "<mark>Neovim</mark> &lt;b&gt;and&lt;/b&gt; <mark>tmux</mark> for the terminal"
+	if highlight["field"] != "description" || highlight["snippet"] != expected {
+		t.Errorf("Expected escaped description highlight %q, got %v", expected, highlight)
+	}
+}
+
+func TestToTemplateResponseMapsEveryField(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	synced := created.Add(time.Hour)
+
+	response := toTemplateResponse(&models.StoredTemplate{
+		ID: "full",
+		Template: models.Template{
+			Taps:           []string{"homebrew/cask-fonts"},
+			Brews:          []string{"git"},
+			Casks:          []string{"iterm2"},
+			Stow:           []string{"zsh"},
+			AptPackages:    []string{"curl"},
+			PipPackages:    []string{"black"},
+			Extends:        "base",
+			Overrides:      []string{"vim"},
+			Public:         true,
+			Featured:       true,
+			OrganizationID: "org-1",
+			Hooks:          &models.Hooks{PostInstall: []string{"echo done"}},
+			PackageConfigs: map[string]models.PackageConfig{
+				"git": {PostInstall: []string{"git config --global init.defaultBranch main"}},
+			},
+			SourceRepo: &models.SourceRepo{Repo: "octocat/dotfiles", Ref: "main", Path: "Brewfile", SyncedAt: synced},
+			Metadata: models.ShareMetadata{
+				Name:        "Full Template",
+				Description: "Template with every field set",
+				Author:      "octocat",
+				Version:     "1.2.3",
+				Tags:        []string{"macos"},
+				CreatedAt:   created,
+				UpdatedAt:   created,
+			},
+		},
+		CreatedAt:       created,
+		UpdatedAt:       synced,
+		Downloads:       7,
+		PopularityScore: 12.5,
+	})
+
+	if response.ID != "full" || response.Downloads != 7 || response.OrganizationID != "org-1" || response.PopularityScore != 12.5 {
+		t.Errorf("Expected identity fields to be copied, got %+v", response)
+	}
+	if fmt.Sprint(response.Taps, response.Brews, response.Casks, response.Stow, response.AptPackages, response.PipPackages) !=
+		"[homebrew/cask-fonts] [git] [iterm2] [zsh] [curl] [black]" {
+		t.Errorf("Expected package lists to be copied, got %+v", response)
+	}
+	if response.Extends != "base" || fmt.Sprint(response.Overrides) != "[vim]" || !response.Public || !response.Featured {
+		t.Errorf("Expected inheritance and visibility fields to be copied, got %+v", response)
+	}
+	if response.Hooks == nil || fmt.Sprint(response.Hooks.PostInstall) != "[echo done]" {
+		t.Errorf("Expected hooks to be copied, got %+v", response.Hooks)
+	}
+	if len(response.PackageConfigs["git"].PostInstall) != 1 {
+		t.Errorf("Expected package configs to be copied, got %+v", response.PackageConfigs)
+	}
+	if response.SourceRepo == nil || response.SourceRepo.SyncedAt != "2024-01-02T04:04:05Z" {
+		t.Errorf("Expected source repo to be copied, got %+v", response.SourceRepo)
+	}
+	if response.CreatedAt != "2024-01-02T03:04:05Z" || response.UpdatedAt != "2024-01-02T04:04:05Z" {
+		t.Errorf("Expected formatted timestamps, got %s and %s", response.CreatedAt, response.UpdatedAt)
+	}
+	if response.Metadata.Name != "Full Template" || response.Metadata.Version != "1.2.3" ||
+		response.Metadata.CreatedAt != "2024-01-02T03:04:05Z" || fmt.Sprint(response.Metadata.Tags) != "[macos]" {
+		t.Errorf("Expected metadata to be copied, got %+v", response.Metadata)
+	}
+	if response.LegacyAddOnly != nil || response.MatchedFields != nil || response.Highlight != nil {
+		t.Errorf("Expected endpoint-specific fields to be left unset, got %+v", response)
+	}
+}
+
+// fakeGitHub serves the GitHub endpoints used by template imports
+type fakeGitHub struct {
+	brewfile    string
+	rateLimited bool
+	tokens      []string
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.tokens = append(f.tokens, r.Header.Get("Authorization"))
+
+	if f.rateLimited {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(time.Now().Add(time.Minute).Unix()))
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/repos/octocat/dotfiles":
+		fmt.Fprint(w, `{"name": "dotfiles", "full_name": "octocat/dotfiles", "description": "My macOS development setup", "default_branch": "main"}`)
+	case "/repos/octocat/dotfiles/contents/Brewfile":
+		if r.URL.Query().Get("ref") != "main" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, f.brewfile)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newGitHubTestRouter(t *testing.T, fake *fakeGitHub) (*gin.Engine, repository.TemplateRepository) {
+	t.Helper()
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	repo := memory.NewTemplateRepository()
+	h := newTestTemplateHandler(repo)
+	h.github = github.NewClient(server.URL)
+
+	r := gin.New()
+	r.POST("/api/templates/from-github", withTestUser(), h.ImportFromGitHub)
+	r.POST("/api/templates/:id/sync-github", withTestUser(), h.SyncFromGitHub)
+	return r, repo
+}
+
+func postAsUser(r *gin.Engine, url, username, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-Username", username)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestImportFromGitHub(t *testing.T) {
+	fake := &fakeGitHub{brewfile: "tap \"homebrew/bundle\"\nbrew \"git\"\ncask \"iterm2\"\n"}
+	r, repo := newGitHubTestRouter(t, fake)
+
+	w := postAsUser(r, "/api/templates/from-github", "octocat", `{"repo": "octocat/dotfiles"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := decodeBody(t, w)
+	metadata := body["metadata"].(map[string]interface{})
+	if metadata["name"] != "dotfiles" || metadata["author"] != "octocat" || metadata["description"] != "My macOS development setup" {
+		t.Errorf("Expected metadata pre-filled from the repository, got %v", metadata)
+	}
+	if brews := body["brews"].([]interface{}); len(brews) != 1 || brews[0] != "git" {
+		t.Errorf("Expected brews [git], got %v", brews)
+	}
+
+	source := body["source_repo"].(map[string]interface{})
+	if source["repo"] != "octocat/dotfiles" || source["ref"] != "main" || source["path"] != "Brewfile" {
+		t.Errorf("Expected source repo to be recorded, got %v", source)
+	}
+
+	// Anonymous requests send no token
+	for _, token := range fake.tokens {
+		if token != "" {
+			t.Errorf("Expected anonymous GitHub requests, got Authorization %q", token)
+		}
+	}
+
+	// Syncing picks up the new Brewfile and bumps the version
+	id := body["id"].(string)
+	fake.brewfile = "brew \"git\"\nbrew \"neovim\"\n"
+
+	if w := postAsUser(r, "/api/templates/"+id+"/sync-github", "someone-else", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for non-author sync, got %d", w.Code)
+	}
+
+	w = postAsUser(r, "/api/templates/"+id+"/sync-github", "octocat", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	stored, _ := repo.GetByID(context.Background(), id)
+	if len(stored.Template.Brews) != 2 || len(stored.Template.Casks) != 0 {
+		t.Errorf("Expected synced packages, got brews %v casks %v", stored.Template.Brews, stored.Template.Casks)
+	}
+	if stored.Template.Metadata.Version != "1.0.1" {
+		t.Errorf("Expected version 1.0.1 after sync, got %s", stored.Template.Metadata.Version)
+	}
+}
+
+func TestImportFromGitHubErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		fake     *fakeGitHub
+		body     string
+		expected int
+		message  string
+	}{
+		{
+			name:     "repository not found",
+			fake:     &fakeGitHub{},
+			body:     `{"repo": "octocat/missing"}`,
+			expected: http.StatusNotFound,
+			message:  "GitHub repository not found",
+		},
+		{
+			name:     "file not found",
+			fake:     &fakeGitHub{},
+			body:     `{"repo": "octocat/dotfiles", "path": "brew/Brewfile"}`,
+			expected: http.StatusNotFound,
+			message:  "file in GitHub repository not found",
+		},
+		{
+			name:     "parse failure",
+			fake:     &fakeGitHub{brewfile: "brew \"git\"\nnpm \"left-pad\"\n"},
+			body:     `{"repo": "octocat/dotfiles"}`,
+			expected: http.StatusUnprocessableEntity,
+			message:  `could not parse Brewfile: line 2: unknown entry "npm"`,
+		},
+		{
+			name:     "rate limited",
+			fake:     &fakeGitHub{rateLimited: true},
+			body:     `{"repo": "octocat/dotfiles"}`,
+			expected: http.StatusTooManyRequests,
+		},
+		{
+			name:     "invalid repo",
+			fake:     &fakeGitHub{},
+			body:     `{"repo": "https://github.com/octocat/dotfiles"}`,
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newGitHubTestRouter(t, tt.fake)
+
+			w := postAsUser(r, "/api/templates/from-github", "octocat", tt.body)
+			if w.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, w.Code, w.Body.String())
+			}
+
+			if tt.message != "" {
+				appErr := decodeBody(t, w)["error"].(map[string]interface{})
+				if appErr["message"] != tt.message {
+					t.Errorf("Expected message %q, got %q", tt.message, appErr["message"])
+				}
+			}
+			if tt.expected == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+				t.Error("Expected a Retry-After header when rate limited")
+			}
+		})
+	}
+}
+
+// unavailableTemplateRepo fails every list as if MongoDB could not be reached
+type unavailableTemplateRepo struct {
+	repository.TemplateRepository
+}
+
+func (unavailableTemplateRepo) List(ctx context.Context, filters repository.TemplateFilters) ([]*models.StoredTemplate, error) {
+	return nil, fmt.Errorf("%w: server selection error", repository.ErrStorageUnavailable)
+}
+
+func TestStorageUnavailableReturns503(t *testing.T) {
+	h := newTestTemplateHandler(unavailableTemplateRepo{})
+	r := gin.New()
+	r.GET("/api/templates", h.ListTemplates)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header")
+	}
+}
+
+func newTagTestRouter(t *testing.T) (*gin.Engine, repository.TemplateRepository) {
+	t.Helper()
+
+	repo := memory.NewTemplateRepository()
+	for _, template := range []*models.StoredTemplate{
+		{ID: "node", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Name: "Node", Tags: []string{"JS", "javascript"}}}},
+		{ID: "react", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Name: "React", Tags: []string{"JavaScript", "Front End"}}}},
+		{ID: "cluster", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Name: "Cluster", Tags: []string{"k8s"}}}},
+		{ID: "secret", Template: models.Template{Metadata: models.ShareMetadata{Name: "Secret", Tags: []string{"js"}}}},
+	} {
+		if err := repo.Create(context.Background(), template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.GET("/api/templates", h.ListTemplates)
+	r.GET("/api/templates/search", h.SearchTemplates)
+	r.GET("/api/templates/tags", h.ListTags)
+	r.PUT("/api/admin/tags/synonyms", h.SetTagSynonyms)
+	r.POST("/api/admin/tags/backfill", h.BackfillTags)
+	return r, repo
+}
+
+func templateIDs(t *testing.T, w *httptest.ResponseRecorder) []string {
+	t.Helper()
+
+	var ids []string
+	for _, tmpl := range decodeBody(t, w)["templates"].([]interface{}) {
+		ids = append(ids, tmpl.(map[string]interface{})["id"].(string))
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestListTemplatesSortsByPopularity(t *testing.T) {
+	r, repo := newTagTestRouter(t)
+	ctx := context.Background()
+
+	for id, score := range map[string]float64{"node": 2, "react": 9.5, "cluster": 4} {
+		if err := repo.SetPopularityScore(ctx, id, score); err != nil {
+			t.Fatalf("Failed to set popularity: %v", err)
+		}
+	}
+
+	for query, want := range map[string]string{
+		"?public=true&tags=js&sort_by=popularity":                "[react node]",
+		"?public=true&tags=js&sort_by=popularity&sort_order=asc": "[node react]",
+		"?sort_by=popularity&limit=2":                            "[react cluster]",
+		"?sort_by=popularity&limit=1&offset=1":                   "[cluster]",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates"+query, nil))
+
+		var ids []string
+		for _, tmpl := range decodeBody(t, w)["templates"].([]interface{}) {
+			ids = append(ids, tmpl.(map[string]interface{})["id"].(string))
+		}
+		if fmt.Sprint(ids) != want {
+			t.Errorf("%s: expected %s, got %v", query, want, ids)
+		}
+	}
+}
+
+func TestListTemplatesWarnsWhenPageIsClamped(t *testing.T) {
+	r := newTemplateTestRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates?limit=500", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := decodeBody(t, w)["limit"]; got != float64(100) {
+		t.Errorf("Expected the limit to be clamped to 100, got %v", got)
+	}
+	if got := w.Header().Get("Warning"); !strings.HasPrefix(got, "299 - ") || !strings.Contains(got, "limit 500 exceeds the maximum of 100") {
+		t.Errorf("Expected a Warning header about the clamped limit, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates?limit=50", nil))
+	if got := w.Header().Values("Warning"); len(got) != 0 {
+		t.Errorf("Expected no Warning header for an in-range limit, got %v", got)
+	}
+}
+
+func TestListTemplatesSortsByMultipleKeys(t *testing.T) {
+	repo := memory.NewTemplateRepository()
+	// Drop the sample template so only these are listed
+	if err := repo.Delete(context.Background(), "essential-developer-setup"); err != nil {
+		t.Fatalf("Failed to delete sample template: %v", err)
+	}
+	for _, template := range []*models.StoredTemplate{
+		{ID: "plain-popular", Downloads: 50, Template: models.Template{Public: true}},
+		{ID: "featured-quiet", Downloads: 5, Template: models.Template{Public: true, Featured: true}},
+		{ID: "featured-popular", Downloads: 40, Template: models.Template{Public: true, Featured: true}},
+		{ID: "plain-quiet", Downloads: 1, Template: models.Template{Public: true}},
+	} {
+		if err := repo.Create(context.Background(), template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.GET("/api/templates", h.ListTemplates)
+
+	for query, want := range map[string]string{
+		"?sort=featured:desc,downloads:desc":              "[featured-popular featured-quiet plain-popular plain-quiet]",
+		"?sort=featured,downloads:asc":                    "[featured-quiet featured-popular plain-quiet plain-popular]",
+		"?sort=downloads&sort_by=created_at":              "[plain-popular featured-popular featured-quiet plain-quiet]",
+		"?sort=featured:desc,downloads&limit=2":           "[featured-popular featured-quiet]",
+		"?sort_by=featured:desc,downloads:desc":           "[featured-popular featured-quiet plain-popular plain-quiet]",
+		"?sort_by=featured,downloads&sort_order=asc":      "[plain-quiet plain-popular featured-quiet featured-popular]",
+		"?sort_by=featured:desc,downloads&sort_order=asc": "[featured-quiet featured-popular plain-quiet plain-popular]",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates"+query, nil))
+
+		var ids []string
+		for _, tmpl := range decodeBody(t, w)["templates"].([]interface{}) {
+			ids = append(ids, tmpl.(map[string]interface{})["id"].(string))
+		}
+		if fmt.Sprint(ids) != want {
+			t.Errorf("%s: expected %s, got %v", query, want, ids)
+		}
+	}
+
+	for _, query := range []string{
+		"?sort=name",
+		"?sort=downloads:sideways",
+		"?sort=downloads,downloads:asc",
+		"?sort=",
+		"?sort_by=name",
+		"?sort_by=featured:sideways",
+		"?sort_by=featured,downloads,featured",
+		"?sort_order=up",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, w.Code)
+		}
+	}
+}
+
+func TestTemplateListingsSelectFields(t *testing.T) {
+	r, _ := newTagTestRouter(t)
+
+	for _, url := range []string{
+		"/api/templates?public=true&tags=k8s&fields=metadata.name,downloads",
+		"/api/templates/search?q=cluster&fields=metadata.name,downloads",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d: %s", url, w.Code, w.Body.String())
+		}
+
+		templates := decodeBody(t, w)["templates"].([]interface{})
+		if len(templates) != 1 {
+			t.Fatalf("%s: expected 1 template, got %v", url, templates)
+		}
+		want := map[string]interface{}{
+			"id":        "cluster",
+			"downloads": float64(0),
+			"metadata":  map[string]interface{}{"name": "Cluster"},
+		}
+		if got := templates[0]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", url, want, got)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates?fields=name,brews", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for an unknown field, got %d", w.Code)
+	}
+	appErr := decodeBody(t, w)["error"].(map[string]interface{})
+	if details, _ := appErr["details"].(string); !strings.Contains(details, "metadata.name") {
+		t.Errorf("Expected the valid fields to be listed, got %v", appErr)
+	}
+}
+
+func TestListTemplatesMatchesTagSynonyms(t *testing.T) {
+	r, _ := newTagTestRouter(t)
+
+	for _, tag := range []string{"js", "JavaScript", "ecmascript"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates?public=true&tags="+tag, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if ids := fmt.Sprint(templateIDs(t, w)); ids != "[node react]" {
+			t.Errorf("tags=%s: expected [node react], got %s", tag, ids)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates?tags=js&tags=front-end", nil))
+	if ids := fmt.Sprint(templateIDs(t, w)); ids != "[react]" {
+		t.Errorf("Expected only react to have both tags, got %s", ids)
+	}
+}
+
+func TestSearchTemplatesMatchesTagSynonyms(t *testing.T) {
+	r, _ := newTagTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates/search?q=kubernetes", nil))
+	if ids := fmt.Sprint(templateIDs(t, w)); ids != "[cluster]" {
+		t.Errorf("Expected kubernetes to find the k8s template, got %s", ids)
+	}
+}
+
+func TestListTagsMergesSynonymCounts(t *testing.T) {
+	r, _ := newTagTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates/tags", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	found := make(map[string]map[string]interface{})
+	for _, tag := range decodeBody(t, w)["tags"].([]interface{}) {
+		entry := tag.(map[string]interface{})
+		found[entry["tag"].(string)] = entry
+	}
+
+	// node counts once despite carrying both forms; the private template is
+	// not counted
+	javascript := found["javascript"]
+	if javascript == nil || javascript["count"] != float64(2) {
+		t.Fatalf("Expected javascript with a merged count of 2, got %v", javascript)
+	}
+	if synonyms := fmt.Sprint(javascript["synonyms"]); synonyms != "[ecmascript js]" {
+		t.Errorf("Expected javascript synonyms [ecmascript js], got %s", synonyms)
+	}
+	if found["frontend"] == nil || found["kubernetes"] == nil {
+		t.Errorf("Expected frontend and kubernetes to be reported canonically, got %v", found)
+	}
+	for _, raw := range []string{"js", "JS", "JavaScript", "k8s", "front-end"} {
+		if found[raw] != nil {
+			t.Errorf("Expected %q to be folded into its canonical tag", raw)
+		}
+	}
+}
+
+func TestSetTagSynonyms(t *testing.T) {
+	r, _ := newTagTestRouter(t)
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/admin/tags/synonyms", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := put(`{"canonical": "node", "synonyms": ["js"]}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a synonym owned by javascript, got %d", w.Code)
+	}
+
+	if w := put(`{"canonical": "Container Orchestration", "synonyms": ["k8s"]}`); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 while k8s belongs to kubernetes, got %d", w.Code)
+	}
+
+	w := put(`{"canonical": "Cloud Native", "synonyms": ["CNCF", "cloud_native_apps"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	if body["canonical"] != "cloud-native" || fmt.Sprint(body["synonyms"]) != "[cloud-native-apps cncf]" {
+		t.Errorf("Expected normalized canonical and synonyms, got %v", body)
+	}
+
+	// New synonyms apply to filtering straight away
+	put(`{"canonical": "javascript", "synonyms": ["js", "ecmascript", "node"]}`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates?public=true&tags=node", nil))
+	if ids := fmt.Sprint(templateIDs(t, w)); ids != "[node react]" {
+		t.Errorf("Expected the new node synonym to match javascript templates, got %s", ids)
+	}
+}
+
+func TestBackfillTags(t *testing.T) {
+	r, repo := newTagTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/admin/tags/backfill", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The seeded sample template is already canonical
+	if updated := decodeBody(t, w)["updated"]; updated != float64(4) {
+		t.Errorf("Expected 4 templates to be rewritten, got %v", updated)
+	}
+
+	react, _ := repo.GetByID(context.Background(), "react")
+	if tags := fmt.Sprint(react.Template.Metadata.Tags); tags != "[javascript frontend]" {
+		t.Errorf("Expected canonical tags, got %s", tags)
+	}
+}
+
+func TestPrivateTemplateVisibility(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTemplateRepository()
+	orgRepo := memory.NewOrganizationRepository()
+
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org-1", Name: "Acme", Slug: "acme"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	if err := orgRepo.AddMember(ctx, &models.OrganizationMember{ID: "m-1", OrganizationID: "org-1", UserID: "member-id", Role: models.RoleMember}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	for _, template := range []*models.StoredTemplate{
+		{ID: "private", Template: models.Template{Brews: []string{"git"}, Metadata: models.ShareMetadata{Author: "alice"}}},
+		{ID: "org-private", Template: models.Template{Brews: []string{"git"}, OrganizationID: "org-1", Metadata: models.ShareMetadata{Author: "alice"}}},
+		{ID: "public", Template: models.Template{Public: true, Brews: []string{"git"}, Metadata: models.ShareMetadata{Author: "alice"}}},
+	} {
+		if err := repo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := NewTemplateHandler(repo, memory.NewTagRepository(), memory.NewUserRepository(), memory.NewReviewRepository(), tags.NewRegistry(), NewAuthorizer(orgRepo))
+	r := gin.New()
+	r.Use(withTestUser(), func(c *gin.Context) {
+		c.Set("is_admin", c.GetHeader("X-Test-Admin") == "true")
+	})
+	r.GET("/api/templates/:id", h.GetTemplate)
+	r.GET("/api/templates/:id/download", h.DownloadTemplate)
+	r.GET("/api/templates/:id/docker-setup", h.GetDockerSetup)
+
+	tests := []struct {
+		name     string
+		id       string
+		userID   string
+		username string
+		admin    bool
+		expected int
+	}{
+		{name: "anonymous private", id: "private", expected: http.StatusNotFound},
+		{name: "other user private", id: "private", userID: "bob-id", username: "bob", expected: http.StatusNotFound},
+		{name: "author private", id: "private", userID: "alice-id", username: "alice", expected: http.StatusOK},
+		{name: "site admin private", id: "private", userID: "admin-id", username: "root", admin: true, expected: http.StatusOK},
+		{name: "anonymous org", id: "org-private", expected: http.StatusNotFound},
+		{name: "non-member org", id: "org-private", userID: "bob-id", username: "bob", expected: http.StatusNotFound},
+		{name: "member org", id: "org-private", userID: "member-id", username: "carol", expected: http.StatusOK},
+		{name: "anonymous public", id: "public", expected: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, path := range []string{"", "/download", "/docker-setup"} {
+				req := httptest.NewRequest(http.MethodGet, "/api/templates/"+tt.id+path, nil)
+				req.Header.Set("X-Test-User", tt.userID)
+				req.Header.Set("X-Test-Username", tt.username)
+				if tt.admin {
+					req.Header.Set("X-Test-Admin", "true")
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				if w.Code != tt.expected {
+					t.Errorf("GET %s: expected status %d, got %d", req.URL.Path, tt.expected, w.Code)
+				}
+			}
+		})
+	}
+
+	// A hidden template's downloads are not counted
+	private, _ := repo.GetByID(ctx, "private")
+	if private.Downloads != 2 {
+		t.Errorf("Expected only the author and admin downloads to count, got %d", private.Downloads)
+	}
+}
+
+// nilTemplateRepo reports missing templates as (nil, nil), like the MongoDB
+// repository
+type nilTemplateRepo struct {
+	repository.TemplateRepository
+}
+
+func (nilTemplateRepo) GetByID(ctx context.Context, id string) (*models.StoredTemplate, error) {
+	return nil, nil
+}
+
+func TestGetTemplateMissingInEitherBackend(t *testing.T) {
+	for name, repo := range map[string]repository.TemplateRepository{
+		"memory": memory.NewTemplateRepository(),
+		"mongo":  nilTemplateRepo{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := newTestTemplateHandler(repo)
+			r := gin.New()
+			r.GET("/api/templates/:id", h.GetTemplate)
+			r.GET("/api/templates/:id/download", h.DownloadTemplate)
+
+			for _, path := range []string{"/api/templates/missing", "/api/templates/missing/download"} {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+				if w.Code != http.StatusNotFound {
+					t.Errorf("GET %s: expected status 404, got %d", path, w.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateBodiesOverTheLimit(t *testing.T) {
+	handler := newTestTemplateHandler(memory.NewTemplateRepository())
+
+	r := gin.New()
+	r.POST("/api/templates", middleware.MaxBodySize(256), handler.CreateTemplate)
+	r.POST("/api/templates/validate", middleware.MaxBodySize(256), handler.ValidateTemplate)
+
+	body := `{"metadata": {"name": "big", "description": "` + strings.Repeat("x", 512) + `"}}`
+	for _, url := range []string{"/api/templates", "/api/templates/validate"} {
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		// Stream the body so the limit is hit while decoding
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("%s: expected status 413, got %d: %s", url, w.Code, w.Body.String())
+		}
+		if code := decodeBody(t, w)["error"].(map[string]interface{})["code"]; code != "PAYLOAD_TOO_LARGE" {
+			t.Errorf("%s: expected PAYLOAD_TOO_LARGE, got %v", url, code)
+		}
+	}
+}
+
+func TestTemplatesCarryCallerFavoriteAndReviewState(t *testing.T) {
+	ctx := context.Background()
+	templateRepo := memory.NewTemplateRepository()
+	userRepo := memory.NewUserRepository()
+	reviewRepo := memory.NewReviewRepository()
+
+	if err := templateRepo.Create(ctx, &models.StoredTemplate{ID: "reviewed", Template: models.Template{
+		Public:   true,
+		Metadata: models.ShareMetadata{Name: "Reviewed", Tags: []string{"viewer-state"}},
+	}}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	if err := templateRepo.Create(ctx, &models.StoredTemplate{ID: "favorited", Template: models.Template{
+		Public:   true,
+		Metadata: models.ShareMetadata{Name: "Favorited", Tags: []string{"viewer-state"}},
+	}}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	if err := userRepo.Create(ctx, &models.User{ID: "alice-id", Username: "alice", Email: "alice@example.com"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if err := userRepo.AddFavorite(ctx, "alice-id", "favorited"); err != nil {
+		t.Fatalf("Failed to add favorite: %v", err)
+	}
+	if err := reviewRepo.Create(ctx, &models.Review{TemplateID: "reviewed", UserID: "alice-id", Rating: 4}); err != nil {
+		t.Fatalf("Failed to create review: %v", err)
+	}
+
+	h := NewTemplateHandler(templateRepo, memory.NewTagRepository(), userRepo, reviewRepo, tags.NewRegistry(), NewAuthorizer(memory.NewOrganizationRepository()))
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates", h.ListTemplates)
+	r.GET("/api/templates/:id", h.GetTemplate)
+
+	get := func(url, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", url, w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	state := func(template map[string]interface{}) [2]interface{} {
+		return [2]interface{}{template["is_favorited"], template["is_reviewed"]}
+	}
+
+	list := map[string][2]interface{}{}
+	for _, item := range decodeBody(t, get("/api/templates?tags=viewer-state", "alice-id"))["templates"].([]interface{}) {
+		template := item.(map[string]interface{})
+		list[template["id"].(string)] = state(template)
+	}
+	want := map[string][2]interface{}{
+		"favorited": {true, false},
+		"reviewed":  {false, true},
+	}
+	if !reflect.DeepEqual(list, want) {
+		t.Errorf("Expected %v, got %v", want, list)
+	}
+
+	if got := state(decodeBody(t, get("/api/templates/favorited", "alice-id"))); got != [2]interface{}{true, false} {
+		t.Errorf("Expected a favorited, unreviewed template, got %v", got)
+	}
+
+	// Anonymous callers get neither field
+	body := decodeBody(t, get("/api/templates/favorited", ""))
+	if _, ok := body["is_favorited"]; ok {
+		t.Errorf("Expected no is_favorited for anonymous requests, got %v", body)
+	}
+	for _, item := range decodeBody(t, get("/api/templates?tags=viewer-state", ""))["templates"].([]interface{}) {
+		if _, ok := item.(map[string]interface{})["is_reviewed"]; ok {
+			t.Errorf("Expected no is_reviewed for anonymous requests, got %v", item)
+		}
+	}
+}
+
+func TestTemplateListsCountEveryMatch(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTemplateRepository()
+	for i := 1; i <= 3; i++ {
+		if err := repo.Create(ctx, &models.StoredTemplate{
+			ID: fmt.Sprintf("dana-%d", i),
+			Template: models.Template{Public: true, Metadata: models.ShareMetadata{
+				Name:        fmt.Sprintf("Dana Setup %d", i),
+				Description: "Zephyr shell prompt and friends",
+				Author:      "dana",
+			}},
+		}); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates", h.ListTemplates)
+	r.GET("/api/templates/search", h.SearchTemplates)
+	r.GET("/api/users/me/templates", h.GetMyTemplates)
+
+	for url, last := range map[string]string{
+		"/api/templates?author=dana&limit=2":     "/api/templates?author=dana&offset=2&limit=2",
+		"/api/templates/search?q=zephyr&limit=2": "/api/templates/search?q=zephyr&offset=2&limit=2",
+		"/api/users/me/templates?limit=2":        "/api/users/me/templates?offset=2&limit=2",
+	} {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("X-Test-User", "dana-id")
+		req.Header.Set("X-Test-Username", "dana")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d: %s", url, w.Code, w.Body.String())
+		}
+
+		// A full first page still knows where the last one starts
+		body := decodeBody(t, w)
+		links := body["links"].(map[string]interface{})
+		if body["total"] != float64(3) || links["last"] != last {
+			t.Errorf("Expected %s to count 3 templates with last link %s, got total %v and %v", url, last, body["total"], links)
+		}
+	}
+}
+
+func TestGetMyTemplatesShowsDraftsOnlyToTheirOwner(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTemplateRepository()
+
+	for _, template := range []*models.StoredTemplate{
+		{ID: "alice-draft", Template: models.Template{Metadata: models.ShareMetadata{Name: "Draft", Author: "alice"}}},
+		{ID: "alice-published", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Name: "Published", Author: "alice"}}},
+		{ID: "bob-draft", Template: models.Template{Metadata: models.ShareMetadata{Name: "Bob's draft", Author: "bob"}}},
+	} {
+		if err := repo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/users/me/templates", h.GetMyTemplates)
+
+	get := func(url, username string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if username != "" {
+			req.Header.Set("X-Test-User", username+"-id")
+			req.Header.Set("X-Test-Username", username)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		url      string
+		username string
+		want     []string
+	}{
+		{"/api/users/me/templates", "alice", []string{"alice-draft", "alice-published"}},
+		{"/api/users/me/templates?status=draft", "alice", []string{"alice-draft"}},
+		{"/api/users/me/templates?status=published", "alice", []string{"alice-published"}},
+		{"/api/users/me/templates?status=draft", "bob", []string{"bob-draft"}},
+		{"/api/users/me/templates", "carol", nil},
+	}
+	for _, tt := range tests {
+		w := get(tt.url, tt.username)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s as %s: expected status 200, got %d: %s", tt.url, tt.username, w.Code, w.Body.String())
+		}
+		if got := templateIDs(t, w); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s as %s: expected %v, got %v", tt.url, tt.username, tt.want, got)
+		}
+	}
+
+	if w := get("/api/users/me/templates?status=pending", "alice"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unsupported status, got %d", w.Code)
+	}
+	if w := get("/api/users/me/templates", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without a user, got %d", w.Code)
+	}
+}
+
+func TestCountTemplatesCountsPublicTemplates(t *testing.T) {
+	repo := memory.NewTemplateRepository()
+	ctx := context.Background()
+	public := true
+	want, err := repo.Count(ctx, repository.TemplateFilters{Public: &public})
+	if err != nil {
+		t.Fatalf("Failed to count templates: %v", err)
+	}
+	for _, template := range []*models.StoredTemplate{
+		{ID: "shared", Template: models.Template{Public: true}},
+		{ID: "private", Template: models.Template{}},
+	} {
+		if err := repo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+	want++
+
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.GET("/api/templates/count", h.CountTemplates)
+
+	count := func() float64 {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/templates/count", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if got := w.Header().Get("Cache-Control"); got != "public, max-age=30" {
+			t.Errorf("Expected a 30 second Cache-Control, got %q", got)
+		}
+		return decodeBody(t, w)["count"].(float64)
+	}
+
+	if got := count(); got != float64(want) {
+		t.Errorf("Expected %d public templates, got %v", want, got)
+	}
+
+	// New templates show up once the cached count expires
+	if err := repo.Create(ctx, &models.StoredTemplate{ID: "another", Template: models.Template{Public: true}}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+	if got := count(); got != float64(want) {
+		t.Errorf("Expected the cached count %d, got %v", want, got)
+	}
+	h.publicCount.expiresAt = time.Time{}
+	if got := count(); got != float64(want+1) {
+		t.Errorf("Expected %d after the cache expired, got %v", want+1, got)
+	}
+}
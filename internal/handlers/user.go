@@ -1,31 +1,42 @@
 package handlers
 
 import (
+	"context"
+	stderrors "errors"
+	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"dotfiles-api/internal/avatar"
 	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/pagination"
 	"dotfiles-api/internal/repository"
 	"dotfiles-api/pkg/errors"
 )
 
 type UserHandler struct {
-	userRepo repository.UserRepository
+	userRepo     repository.UserRepository
+	templateRepo repository.TemplateRepository
+	reviewRepo   repository.ReviewRepository
+	orgRepo      repository.OrganizationRepository
+	avatars      *avatar.Proxy
+	apiKeys      repository.APIKeyRepository
 }
 
-func NewUserHandler(userRepo repository.UserRepository) *UserHandler {
+func NewUserHandler(userRepo repository.UserRepository, templateRepo repository.TemplateRepository, reviewRepo repository.ReviewRepository, orgRepo repository.OrganizationRepository) *UserHandler {
 	return &UserHandler{
-		userRepo: userRepo,
+		userRepo:     userRepo,
+		templateRepo: templateRepo,
+		reviewRepo:   reviewRepo,
+		orgRepo:      orgRepo,
+		avatars:      avatar.NewProxy(avatar.DefaultConfig()),
 	}
 }
 
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var req dto.CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewValidationError("invalid request body"),
-		})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -50,28 +61,28 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 
 	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
 			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to get user", err),
-		})
+		respondInternalError(c, "failed to get user", err)
 		return
 	}
 
 	response := &dto.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Name:      user.Name,
-		Email:     user.Email,
-		AvatarURL: user.AvatarURL,
-		Bio:       user.Bio,
-		Location:  user.Location,
-		Website:   user.Website,
-		Company:   user.Company,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:             user.ID,
+		Username:       user.Username,
+		Name:           user.Name,
+		Email:          user.Email,
+		AvatarURL:      user.AvatarURL,
+		AvatarProxyURL: dto.AvatarProxyPath(user.ID),
+		Bio:            user.Bio,
+		Location:       user.Location,
+		Website:        user.Website,
+		Company:        user.Company,
+		CreatedAt:      user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:      user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -88,28 +99,28 @@ func (h *UserHandler) GetUserByUsername(c *gin.Context) {
 
 	user, err := h.userRepo.GetByUsername(c.Request.Context(), username)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
 			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to get user", err),
-		})
+		respondInternalError(c, "failed to get user", err)
 		return
 	}
 
 	response := &dto.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Name:      user.Name,
-		Email:     user.Email,
-		AvatarURL: user.AvatarURL,
-		Bio:       user.Bio,
-		Location:  user.Location,
-		Website:   user.Website,
-		Company:   user.Company,
-		CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-		UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+		ID:             user.ID,
+		Username:       user.Username,
+		Name:           user.Name,
+		Email:          user.Email,
+		AvatarURL:      user.AvatarURL,
+		AvatarProxyURL: dto.AvatarProxyPath(user.ID),
+		Bio:            user.Bio,
+		Location:       user.Location,
+		Website:        user.Website,
+		Company:        user.Company,
+		CreatedAt:      user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:      user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -125,10 +136,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	}
 
 	var req dto.UpdateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": errors.NewValidationError("invalid request body"),
-		})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -153,60 +161,118 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 
 	err := h.userRepo.Delete(c.Request.Context(), userID)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
 			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to delete user", err),
-		})
+		respondInternalError(c, "failed to delete user", err)
 		return
 	}
 
+	// The account is already gone, and the auth middleware refuses keys of
+	// deleted users, so a failure here only leaves dead keys behind
+	if h.apiKeys != nil {
+		if err := h.apiKeys.DeleteByUser(c.Request.Context(), userID); err != nil {
+			log.Printf("Failed to revoke API keys of deleted user %s: %v", userID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User deleted successfully",
 	})
 }
 
+// userCSVHeader lists the columns of a user export
+var userCSVHeader = []string{"id", "username", "name", "email", "company", "location", "created_at"}
+
+// ListUsers lists active users for site admins, newest first. Send
+// Accept: application/x-ndjson to stream all of them instead of a page.
 func (h *UserHandler) ListUsers(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "10")
-	offsetStr := c.DefaultQuery("offset", "0")
+	csvExport, ok := wantsCSV(c)
+	if !ok {
+		return
+	}
+	limit, offset := parseListPagination(c, csvExport)
+
+	// Streamed exports cover every user, without paging
+	if !csvExport && wantsNDJSON(c) {
+		streamNDJSON(c, func(emit func(interface{}) error) error {
+			return h.userRepo.Iterate(c.Request.Context(), func(user *models.User) error {
+				return emit(toUserResponse(user))
+			})
+		})
+		return
+	}
 
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10
+	users, err := h.userRepo.List(c.Request.Context(), limit, offset)
+	if err != nil {
+		respondInternalError(c, "failed to list users", err)
+		return
+	}
+
+	if csvExport {
+		rows := make([][]string, len(users))
+		for i, user := range users {
+			rows[i] = []string{user.ID, user.Username, user.Name, user.Email, user.Company, user.Location, user.CreatedAt.Format("2006-01-02T15:04:05Z")}
+		}
+		writeCSV(c, "users.csv", userCSVHeader, rows)
+		return
 	}
-	if limit > 100 {
-		limit = 100
+
+	response := make([]dto.UserResponse, len(users))
+	for i, user := range users {
+		response[i] = toUserResponse(user)
 	}
 
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
+	c.JSON(http.StatusOK, gin.H{
+		"users":  response,
+		"limit":  limit,
+		"offset": offset,
+		"total":  len(response),
+		"links":  pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, pagination.PageTotal(offset, limit, len(response))),
+	})
+}
+
+// toUserResponse maps a user to the full response shown to site admins
+func toUserResponse(user *models.User) dto.UserResponse {
+	return dto.UserResponse{
+		ID:             user.ID,
+		Username:       user.Username,
+		Name:           user.Name,
+		Email:          user.Email,
+		AvatarURL:      user.AvatarURL,
+		AvatarProxyURL: dto.AvatarProxyPath(user.ID),
+		Bio:            user.Bio,
+		Location:       user.Location,
+		Website:        user.Website,
+		Company:        user.Company,
+		CreatedAt:      user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt:      user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
+}
 
-	users, err := h.userRepo.List(c.Request.Context(), limit, offset)
+// GetDeletedUsers lists soft-deleted users for site admins
+func (h *UserHandler) GetDeletedUsers(c *gin.Context) {
+	limit, offset := parsePagination(c)
+
+	users, err := h.userRepo.GetDeletedUsers(c.Request.Context(), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to list users", err),
-		})
+		respondInternalError(c, "failed to list deleted users", err)
 		return
 	}
 
 	response := make([]dto.UserResponse, len(users))
 	for i, user := range users {
 		response[i] = dto.UserResponse{
-			ID:        user.ID,
-			Username:  user.Username,
-			Name:      user.Name,
-			Email:     user.Email,
-			AvatarURL: user.AvatarURL,
-			Bio:       user.Bio,
-			Location:  user.Location,
-			Website:   user.Website,
-			Company:   user.Company,
-			CreatedAt: user.CreatedAt.Format("2006-01-02T15:04:05Z"),
-			UpdatedAt: user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			ID:             user.ID,
+			Username:       user.Username,
+			Name:           user.Name,
+			AvatarURL:      user.AvatarURL,
+			AvatarProxyURL: dto.AvatarProxyPath(user.ID),
+			CreatedAt:      user.CreatedAt.Format("2006-01-02T15:04:05Z"),
+			UpdatedAt:      user.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+			DeletedAt:      user.DeletedAt.Format("2006-01-02T15:04:05Z"),
 		}
 	}
 
@@ -215,6 +281,7 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		"limit":  limit,
 		"offset": offset,
 		"total":  len(response),
+		"links":  pagination.BuildPaginationLinks(pagination.BaseURL(c.Request.URL), offset, limit, pagination.PageTotal(offset, limit, len(response))),
 	})
 }
 
@@ -231,13 +298,12 @@ func (h *UserHandler) AddFavorite(c *gin.Context) {
 
 	err := h.userRepo.AddFavorite(c.Request.Context(), userID, templateID)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
 			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to add favorite", err),
-		})
+		respondInternalError(c, "failed to add favorite", err)
 		return
 	}
 
@@ -259,13 +325,12 @@ func (h *UserHandler) RemoveFavorite(c *gin.Context) {
 
 	err := h.userRepo.RemoveFavorite(c.Request.Context(), userID, templateID)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
 			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to remove favorite", err),
-		})
+		respondInternalError(c, "failed to remove favorite", err)
 		return
 	}
 
@@ -285,13 +350,12 @@ func (h *UserHandler) GetFavorites(c *gin.Context) {
 
 	favorites, err := h.userRepo.GetFavorites(c.Request.Context(), userID)
 	if err != nil {
-		if appErr, ok := err.(*errors.AppError); ok {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
 			c.JSON(appErr.StatusCode, gin.H{"error": appErr})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": errors.NewInternalError("failed to get favorites", err),
-		})
+		respondInternalError(c, "failed to get favorites", err)
 		return
 	}
 
@@ -300,6 +364,113 @@ func (h *UserHandler) GetFavorites(c *gin.Context) {
 	})
 }
 
+// GetMe returns the signed-in user's full profile with counts of their
+// templates, favorites, organizations and reviews, so a client can set up
+// a session from one call
+func (h *UserHandler) GetMe(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.userRepo.GetByID(ctx, userID)
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "failed to get user", err)
+		return
+	}
+	if user == nil || user.IsDeleted() {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("user")})
+		return
+	}
+
+	stats, err := h.userStats(ctx, user)
+	if err != nil {
+		respondInternalError(c, "failed to count user activity", err)
+		return
+	}
+
+	response := toUserResponse(user)
+	c.JSON(http.StatusOK, dto.MeResponse{
+		User:  &response,
+		Stats: stats,
+	})
+}
+
+// userStats counts a user's templates, unlisted ones included, along with
+// their favorites, organizations and reviews
+func (h *UserHandler) userStats(ctx context.Context, user *models.User) (*dto.UserStatsResponse, error) {
+	templates, err := h.templateRepo.Count(ctx, repository.TemplateFilters{Author: user.Username, IncludeUnlisted: true})
+	if err != nil {
+		return nil, err
+	}
+	orgs, err := h.orgRepo.GetUserOrganizations(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := h.reviewRepo.CountByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.UserStatsResponse{
+		TemplateCount:     templates,
+		ReviewCount:       reviews,
+		FavoriteCount:     len(user.Favorites),
+		OrganizationCount: len(orgs),
+	}, nil
+}
+
+// GetDownloadHistory lists the templates the caller recently downloaded,
+// newest first, with their current names
+func (h *UserHandler) GetDownloadHistory(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errors.NewUnauthorizedError("Authentication required"),
+		})
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil && !isNotFound(err) {
+		respondInternalError(c, "failed to get user", err)
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errors.NewNotFoundError("user")})
+		return
+	}
+
+	// The same template is often downloaded repeatedly; look each up once
+	templateNames := make(map[string]string)
+	history := make([]dto.DownloadHistoryResponse, len(user.DownloadHistory))
+	for i, item := range user.DownloadHistory {
+		name, seen := templateNames[item.TemplateID]
+		if !seen {
+			if name, err = templateName(c.Request.Context(), h.templateRepo, item.TemplateID); err != nil {
+				respondInternalError(c, "failed to get downloaded template", err)
+				return
+			}
+			templateNames[item.TemplateID] = name
+		}
+
+		history[i] = dto.DownloadHistoryResponse{
+			TemplateID:   item.TemplateID,
+			TemplateName: name,
+			DownloadedAt: item.DownloadedAt.Format("2006-01-02T15:04:05Z"),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"downloads": history,
+		"total":     len(history),
+	})
+}
+
 func (h *UserHandler) GetUserOrganizations(c *gin.Context) {
 	username := c.Param("username")
 	if username == "" {
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+	"dotfiles-api/internal/tags"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDownloadHistory(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+	templateRepo := memory.NewTemplateRepository()
+
+	if err := userRepo.Create(ctx, &models.User{ID: "alice-id", Username: "alice", Email: "alice@example.com"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if err := templateRepo.Create(ctx, &models.StoredTemplate{ID: "doomed", Template: models.Template{
+		Public:   true,
+		Metadata: models.ShareMetadata{Name: "Doomed"},
+	}}); err != nil {
+		t.Fatalf("Failed to create template: %v", err)
+	}
+
+	templateHandler := NewTemplateHandler(templateRepo, memory.NewTagRepository(), userRepo, memory.NewReviewRepository(), tags.NewRegistry(), NewAuthorizer(memory.NewOrganizationRepository()))
+	userHandler := NewUserHandler(userRepo, templateRepo, memory.NewReviewRepository(), memory.NewOrganizationRepository())
+
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/templates/:id/download", templateHandler.DownloadTemplate)
+	r.GET("/api/me/download-history", userHandler.GetDownloadHistory)
+
+	get := func(url, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if userID != "" {
+			req.Header.Set("X-Test-User", userID)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, download := range []struct{ id, userID string }{
+		{"doomed", "alice-id"},
+		{"essential-developer-setup", "alice-id"},
+		{"essential-developer-setup", ""}, // anonymous downloads are not recorded
+		{"essential-developer-setup", "alice-id"},
+	} {
+		if w := get("/api/templates/"+download.id+"/download", download.userID); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 downloading %s, got %d: %s", download.id, w.Code, w.Body.String())
+		}
+	}
+
+	if err := templateRepo.Delete(ctx, "doomed"); err != nil {
+		t.Fatalf("Failed to delete template: %v", err)
+	}
+
+	w := get("/api/me/download-history", "alice-id")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var got [][2]interface{}
+	for _, item := range decodeBody(t, w)["downloads"].([]interface{}) {
+		entry := item.(map[string]interface{})
+		got = append(got, [2]interface{}{entry["template_id"], entry["template_name"]})
+	}
+	want := [][2]interface{}{
+		{"essential-developer-setup", "Essential Developer Setup"},
+		{"essential-developer-setup", "Essential Developer Setup"},
+		{"doomed", nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if w := get("/api/me/download-history", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without a user, got %d", w.Code)
+	}
+}
+
+func TestGetMe(t *testing.T) {
+	ctx := context.Background()
+	userRepo := memory.NewUserRepository()
+	templateRepo := memory.NewTemplateRepository()
+	reviewRepo := memory.NewReviewRepository()
+	orgRepo := memory.NewOrganizationRepository()
+
+	if err := userRepo.Create(ctx, &models.User{ID: "alice-id", Username: "alice", Email: "alice@example.com", Favorites: []string{"a", "b"}}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	for _, template := range []*models.StoredTemplate{
+		{ID: "shared", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Author: "alice"}}},
+		{ID: "draft", Template: models.Template{Metadata: models.ShareMetadata{Author: "alice"}}},
+		{ID: "other", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Author: "bob"}}},
+	} {
+		if err := templateRepo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+	if err := reviewRepo.Create(ctx, &models.Review{ID: "review", TemplateID: "other", UserID: "alice-id", Rating: 4}); err != nil {
+		t.Fatalf("Failed to create review: %v", err)
+	}
+	if err := orgRepo.Create(ctx, &models.Organization{ID: "org", Slug: "acme"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	if err := orgRepo.AddMember(ctx, &models.OrganizationMember{OrganizationID: "org", UserID: "alice-id", Role: "member"}); err != nil {
+		t.Fatalf("Failed to add member: %v", err)
+	}
+
+	h := NewUserHandler(userRepo, templateRepo, reviewRepo, orgRepo)
+	r := gin.New()
+	r.Use(withTestUser())
+	r.GET("/api/me", h.GetMe)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("X-Test-User", "alice-id")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	body := decodeBody(t, w)
+	user := body["user"].(map[string]interface{})
+	if user["username"] != "alice" || user["email"] != "alice@example.com" || user["avatar_proxy_url"] != "/api/avatars/alice-id" {
+		t.Errorf("Unexpected user %v", user)
+	}
+	want := map[string]interface{}{
+		"template_count":     float64(2),
+		"review_count":       float64(1),
+		"favorite_count":     float64(2),
+		"organization_count": float64(1),
+	}
+	if stats := body["stats"]; !reflect.DeepEqual(stats, want) {
+		t.Errorf("Expected stats %v, got %v", want, stats)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/me", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 when signed out, got %d", w.Code)
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"dotfiles-api/internal/dto"
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/semver"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLatestVersion returns a template's current version so clients can check
+// for upgrades without downloading the template
+func (h *TemplateHandler) GetLatestVersion(c *gin.Context) {
+	template, ok := h.loadVisibleTemplate(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.TemplateVersionResponse{
+		TemplateID: template.ID,
+		Version:    template.Template.Metadata.Version,
+		NonSemver:  dto.IsNonSemver(template.Template.Metadata.Version),
+		UpdatedAt:  template.UpdatedAt.Format("2006-01-02T15:04:05Z"),
+	})
+}
+
+// applyInstalledVersion sets IsOutdated when the caller passes the version
+// it has installed as ?installed_version=. Templates with a grandfathered
+// non-semver version cannot be compared and are left unflagged. When the
+// installed version is invalid it writes the error response and returns
+// false.
+func applyInstalledVersion(c *gin.Context, template *models.StoredTemplate, response *dto.TemplateResponse) bool {
+	raw, ok := c.GetQuery("installed_version")
+	if !ok {
+		return true
+	}
+
+	installed, err := semver.Parse(raw)
+	if err != nil {
+		appErr := errors.NewValidationError("installed_version must be a semantic version such as 1.2.0")
+		appErr.Details = err.Error()
+		c.JSON(appErr.StatusCode, gin.H{"error": appErr})
+		return false
+	}
+
+	current, err := semver.Parse(template.Template.Metadata.Version)
+	if err != nil {
+		return true
+	}
+
+	outdated := semver.Less(installed, current)
+	response.IsOutdated = &outdated
+	return true
+}
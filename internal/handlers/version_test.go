@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTemplateVersions(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTemplateRepository()
+	for id, version := range map[string]string{"dev": "1.2.0", "legacy": "latest"} {
+		if err := repo.Create(ctx, &models.StoredTemplate{ID: id, Template: models.Template{
+			Brews:  []string{"git"},
+			Public: true,
+			Metadata: models.ShareMetadata{
+				Name:        id,
+				Description: "Template used to check versions",
+				Author:      "alice",
+				Version:     version,
+			},
+		}}); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	h := newTestTemplateHandler(repo)
+	r := gin.New()
+	r.Use(withTestUser())
+	r.POST("/api/templates", h.CreateTemplate)
+	r.GET("/api/templates/:id", h.GetTemplate)
+	r.PATCH("/api/templates/:id", h.PatchTemplate)
+	r.GET("/api/templates/:id/versions/latest", h.GetLatestVersion)
+
+	send := func(method, path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("X-Test-User", "alice-id")
+		req.Header.Set("X-Test-Username", "alice")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	metadata := func(w *httptest.ResponseRecorder) map[string]interface{} {
+		return decodeBody(t, w)["metadata"].(map[string]interface{})
+	}
+	create := func(version string) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/api/templates", "application/json", `{
+			"brews": ["git"],
+			"metadata": {"name": "Versioned", "description": "Template used to check versions", "author": "alice", "version": "`+version+`"}
+		}`)
+	}
+
+	w := create("v1.3.0")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := metadata(w)["version"]; got != "1.3.0" {
+		t.Errorf("Expected the leading v to be dropped, got %v", got)
+	}
+	for _, version := range []string{"latest", "v1", "1.0"} {
+		if w := create(version); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for version %q, got %d", version, w.Code)
+		}
+	}
+
+	if got := metadata(send(http.MethodGet, "/api/templates/legacy", "", ""))["non_semver"]; got != true {
+		t.Errorf("Expected the grandfathered version to be flagged, got %v", got)
+	}
+	if _, ok := metadata(send(http.MethodGet, "/api/templates/dev", "", ""))["non_semver"]; ok {
+		t.Error("Expected semver versions to be left unflagged")
+	}
+
+	for installed, want := range map[string]interface{}{
+		"1.1.0":      true,
+		"1.2.0-rc.1": true,
+		"v1.2.0":     false,
+		"1.2.0+b.7":  false,
+		"2.0.0":      false,
+	} {
+		w := send(http.MethodGet, "/api/templates/dev?installed_version="+url.QueryEscape(installed), "", "")
+		if got := decodeBody(t, w)["is_outdated"]; got != want {
+			t.Errorf("Expected is_outdated %v for installed %s, got %v", want, installed, got)
+		}
+	}
+	if w := send(http.MethodGet, "/api/templates/dev?installed_version=latest", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid installed version, got %d", w.Code)
+	}
+	if _, ok := decodeBody(t, send(http.MethodGet, "/api/templates/legacy?installed_version=1.0.0", "", ""))["is_outdated"]; ok {
+		t.Error("Expected no is_outdated for a non-semver template")
+	}
+
+	w = send(http.MethodGet, "/api/templates/dev/versions/latest", "", "")
+	if body := decodeBody(t, w); w.Code != http.StatusOK || body["version"] != "1.2.0" || body["template_id"] != "dev" {
+		t.Errorf("Expected the current version, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Grandfathered versions survive edits until the author replaces them
+	if w := send(http.MethodPatch, "/api/templates/legacy", mergePatchContentType, `{"brews":["git","jq"]}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 patching a grandfathered template, got %d: %s", w.Code, w.Body.String())
+	}
+	if stored, _ := repo.GetByID(ctx, "legacy"); stored.Template.Metadata.Version != "latest" {
+		t.Errorf("Expected the grandfathered version to be kept, got %s", stored.Template.Metadata.Version)
+	}
+	if w := send(http.MethodPatch, "/api/templates/legacy", mergePatchContentType, `{"metadata":{"version":"v2"}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a new non-semver version, got %d", w.Code)
+	}
+	if w := send(http.MethodPatch, "/api/templates/legacy", mergePatchContentType, `{"metadata":{"version":"v2.0.0"}}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if stored, _ := repo.GetByID(ctx, "legacy"); stored.Template.Metadata.Version != "2.0.0" {
+		t.Errorf("Expected the normalized version, got %s", stored.Template.Metadata.Version)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"context"
+
+	"dotfiles-api/internal/dto"
+)
+
+// viewerPageSize is how many of the caller's reviews are loaded at a time
+const viewerPageSize = 100
+
+// viewerState records which templates the authenticated caller has
+// favorited and reviewed, loaded once per request
+type viewerState struct {
+	favorited map[string]bool
+	reviewed  map[string]bool
+}
+
+// loadViewerState loads the caller's favorites and reviewed templates. It
+// returns nil for anonymous requests, so responses leave the fields out.
+func (h *TemplateHandler) loadViewerState(ctx context.Context, userID string) (*viewerState, error) {
+	if userID == "" {
+		return nil, nil
+	}
+
+	state := &viewerState{
+		favorited: make(map[string]bool),
+		reviewed:  make(map[string]bool),
+	}
+
+	if h.userRepo != nil {
+		favorites, err := h.userRepo.GetFavorites(ctx, userID)
+		if err != nil && !isNotFound(err) {
+			return nil, err
+		}
+		for _, templateID := range favorites {
+			state.favorited[templateID] = true
+		}
+	}
+
+	if h.reviewRepo != nil {
+		for offset := 0; ; offset += viewerPageSize {
+			reviews, err := h.reviewRepo.GetByUser(ctx, userID, viewerPageSize, offset)
+			if err != nil {
+				return nil, err
+			}
+			for _, review := range reviews {
+				state.reviewed[review.TemplateID] = true
+			}
+			if len(reviews) < viewerPageSize {
+				break
+			}
+		}
+	}
+
+	return state, nil
+}
+
+// apply sets is_favorited and is_reviewed on a template response
+func (v *viewerState) apply(response *dto.TemplateResponse) {
+	if v == nil {
+		return
+	}
+
+	favorited := v.favorited[response.ID]
+	reviewed := v.reviewed[response.ID]
+	response.IsFavorited = &favorited
+	response.IsReviewed = &reviewed
+}
@@ -0,0 +1,255 @@
+// Package homebrew looks up approximate download sizes of Homebrew formulae
+// and casks. Package metadata comes from the formulae.brew.sh API and sizes
+// from the Content-Length of the bottle or cask download, so no package is
+// ever downloaded. Results are cached in memory.
+package homebrew
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAPIURL is the public Homebrew formulae API
+const DefaultAPIURL = "https://formulae.brew.sh/api"
+
+// ErrUnknownPackage is returned when Homebrew does not know a package or
+// its download size
+var ErrUnknownPackage = errors.New("unknown package")
+
+// Kind says whether a package is a formula (brew) or a cask
+type Kind string
+
+const (
+	Formula Kind = "formula"
+	Cask    Kind = "cask"
+)
+
+// ghcrToken is the anonymous bearer token Homebrew itself sends to read
+// bottles from the GitHub Container Registry
+const ghcrToken = "QQ=="
+
+// Config tunes the client
+type Config struct {
+	// APIURL is the formulae API base URL
+	APIURL string
+	// BottleTag picks the platform whose bottle sizes are reported, falling
+	// back to the platform-independent "all" bottle
+	BottleTag string
+	// CacheTTL is how long looked-up sizes, and unknown packages, are kept
+	CacheTTL time.Duration
+	// Concurrency bounds the lookups Sizes runs at once
+	Concurrency int
+}
+
+// DefaultConfig returns the settings used unless configured otherwise
+func DefaultConfig() Config {
+	return Config{
+		APIURL:      DefaultAPIURL,
+		BottleTag:   "arm64_sonoma",
+		CacheTTL:    24 * time.Hour,
+		Concurrency: 8,
+	}
+}
+
+// Package names a formula or cask
+type Package struct {
+	Name string
+	Kind Kind
+}
+
+// Result is the looked-up size of a package. Err is ErrUnknownPackage, or
+// the lookup failure, when Size is not known.
+type Result struct {
+	Package
+	Size int64
+	Err  error
+}
+
+type cacheEntry struct {
+	size    int64
+	err     error
+	expires time.Time
+}
+
+// Client looks up package sizes
+type Client struct {
+	config     Config
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	cache map[Package]cacheEntry
+}
+
+// NewClient creates a client. Zero config fields take their defaults.
+func NewClient(config Config) *Client {
+	defaults := DefaultConfig()
+	if config.APIURL == "" {
+		config.APIURL = defaults.APIURL
+	}
+	if config.BottleTag == "" {
+		config.BottleTag = defaults.BottleTag
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = defaults.CacheTTL
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaults.Concurrency
+	}
+	config.APIURL = strings.TrimRight(config.APIURL, "/")
+
+	return &Client{
+		config:     config,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		cache:      make(map[Package]cacheEntry),
+	}
+}
+
+// Size returns the approximate download size of a package in bytes
+func (c *Client) Size(ctx context.Context, pkg Package) (int64, error) {
+	c.mu.Lock()
+	entry, ok := c.cache[pkg]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.size, entry.err
+	}
+
+	size, err := c.lookup(ctx, pkg)
+	// Only answers are cached; failed lookups are retried next time
+	if err == nil || errors.Is(err, ErrUnknownPackage) {
+		c.mu.Lock()
+		c.cache[pkg] = cacheEntry{size: size, err: err, expires: time.Now().Add(c.config.CacheTTL)}
+		c.mu.Unlock()
+	}
+	return size, err
+}
+
+// Sizes looks up several packages concurrently, returning results in the
+// order given
+func (c *Client) Sizes(ctx context.Context, packages []Package) []Result {
+	results := make([]Result, len(packages))
+	sem := make(chan struct{}, c.config.Concurrency)
+	var wg sync.WaitGroup
+	for i, pkg := range packages {
+		wg.Add(1)
+		go func(i int, pkg Package) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			size, err := c.Size(ctx, pkg)
+			results[i] = Result{Package: pkg, Size: size, Err: err}
+		}(i, pkg)
+	}
+	wg.Wait()
+	return results
+}
+
+func (c *Client) lookup(ctx context.Context, pkg Package) (int64, error) {
+	// The API only covers the core taps, so tap formulae such as
+	// "user/tap/formula" are unknown
+	if pkg.Name == "" || strings.Contains(pkg.Name, "/") {
+		return 0, ErrUnknownPackage
+	}
+
+	switch pkg.Kind {
+	case Formula:
+		var formula struct {
+			Bottle struct {
+				Stable struct {
+					Files map[string]struct {
+						URL string `json:"url"`
+					} `json:"files"`
+				} `json:"stable"`
+			} `json:"bottle"`
+		}
+		if err := c.getJSON(ctx, "/formula/"+url.PathEscape(pkg.Name)+".json", &formula); err != nil {
+			return 0, err
+		}
+
+		files := formula.Bottle.Stable.Files
+		file, ok := files[c.config.BottleTag]
+		if !ok {
+			file, ok = files["all"]
+		}
+		if !ok && len(files) > 0 {
+			// Any bottle is a better estimate than none
+			tags := make([]string, 0, len(files))
+			for tag := range files {
+				tags = append(tags, tag)
+			}
+			sort.Strings(tags)
+			file, ok = files[tags[0]], true
+		}
+		if !ok || file.URL == "" {
+			// Formulae without bottles are built from source
+			return 0, ErrUnknownPackage
+		}
+		return c.contentLength(ctx, file.URL, ghcrToken)
+
+	case Cask:
+		var cask struct {
+			URL string `json:"url"`
+		}
+		if err := c.getJSON(ctx, "/cask/"+url.PathEscape(pkg.Name)+".json", &cask); err != nil {
+			return 0, err
+		}
+		if cask.URL == "" {
+			return 0, ErrUnknownPackage
+		}
+		return c.contentLength(ctx, cask.URL, "")
+	}
+
+	return 0, ErrUnknownPackage
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.APIURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrUnknownPackage
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("homebrew API returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// contentLength asks the download host for the size of a file without
+// downloading it
+func (c *Client) contentLength(ctx context.Context, rawURL, token string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return 0, ErrUnknownPackage
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.ContentLength <= 0 {
+		return 0, ErrUnknownPackage
+	}
+	return resp.ContentLength, nil
+}
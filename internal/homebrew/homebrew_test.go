@@ -0,0 +1,88 @@
+package homebrew
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func newFakeAPI(t *testing.T, requests *int32) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		switch r.URL.Path {
+		case "/formula/git.json":
+			fmt.Fprintf(w, `{"bottle":{"stable":{"files":{
+				"arm64_sonoma":{"url":"%[1]s/bottles/git-arm"},
+				"x86_64_linux":{"url":"%[1]s/bottles/git-linux"}}}}}`, server.URL)
+		case "/formula/ca-certificates.json":
+			fmt.Fprintf(w, `{"bottle":{"stable":{"files":{"all":{"url":"%s/bottles/certs"}}}}}`, server.URL)
+		case "/formula/from-source.json":
+			fmt.Fprint(w, `{"bottle":{}}`)
+		case "/cask/firefox.json":
+			fmt.Fprintf(w, `{"url":"%s/downloads/firefox.dmg"}`, server.URL)
+		case "/bottles/git-arm":
+			if r.Method != http.MethodHead || r.Header.Get("Authorization") != "Bearer "+ghcrToken {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Length", "9000000")
+		case "/bottles/certs":
+			w.Header().Set("Content-Length", "130000")
+		case "/downloads/firefox.dmg":
+			w.Header().Set("Content-Length", "140000000")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSizes(t *testing.T) {
+	var requests int32
+	server := newFakeAPI(t, &requests)
+	client := NewClient(Config{APIURL: server.URL})
+
+	packages := []Package{
+		{Name: "git", Kind: Formula},
+		{Name: "ca-certificates", Kind: Formula},
+		{Name: "firefox", Kind: Cask},
+		{Name: "from-source", Kind: Formula},
+		{Name: "missing", Kind: Cask},
+		{Name: "someone/tap/tool", Kind: Formula},
+	}
+	want := []struct {
+		size    int64
+		unknown bool
+	}{
+		{9000000, false},
+		{130000, false},
+		{140000000, false},
+		{0, true},
+		{0, true},
+		{0, true},
+	}
+
+	results := client.Sizes(context.Background(), packages)
+	for i, result := range results {
+		if result.Package != packages[i] {
+			t.Errorf("Expected result %d for %v, got %v", i, packages[i], result.Package)
+		}
+		if result.Size != want[i].size || errors.Is(result.Err, ErrUnknownPackage) != want[i].unknown {
+			t.Errorf("%s: got size %d, err %v", packages[i].Name, result.Size, result.Err)
+		}
+	}
+
+	// Known and unknown answers are both cached
+	before := atomic.LoadInt32(&requests)
+	client.Sizes(context.Background(), packages)
+	if after := atomic.LoadInt32(&requests); after != before {
+		t.Errorf("Expected cached lookups, got %d more requests", after-before)
+	}
+}
@@ -0,0 +1,59 @@
+// Package hookscan flags hook and package config commands that can damage
+// the machine a template is applied to.
+package hookscan
+
+import "regexp"
+
+// rule pairs a pattern with the warning reported when a command matches it
+type rule struct {
+	pattern *regexp.Regexp
+	warning string
+}
+
+// rules are checked in order, so warnings come out in a stable order
+var rules = []rule{
+	{regexp.MustCompile(`\brm\s+((-\w+|--[\w-]+)\s+)*(-\w*[rR]\w*|--recursive)\s+((-\w+|--[\w-]+)\s+)*"?(/|~|\$HOME)/?\*?"?(\s|;|&|\||$)`),
+		"recursively deletes the root or home directory"},
+	{regexp.MustCompile(`\b(curl|wget)\b[^|;&]*\|\s*(sudo\s+)?(ba|z|da|k)?sh\b`),
+		"pipes a download straight into a shell"},
+	{regexp.MustCompile(`\bbase64\s+(-d|--decode|-D)\b[^|;&]*\|\s*(sudo\s+)?(ba|z|da|k)?sh\b`),
+		"runs obfuscated base64 code"},
+	{regexp.MustCompile(`(^|[;&|]\s*|\s)sudo\s`),
+		"runs with root privileges"},
+	{regexp.MustCompile(`\bchmod\s+(-R\s+)?(0?777|a\+rwx)\b`),
+		"makes files writable by every user"},
+	{regexp.MustCompile(`\bmkfs(\.\w+)?\b|\bdd\b[^;&|]*\bof=/dev/|>\s*/dev/(sd|disk|nvme)`),
+		"writes to a raw disk"},
+	{regexp.MustCompile(`:\s*\(\s*\)\s*\{[^}]*:\s*\|\s*:`),
+		"is a fork bomb"},
+	{regexp.MustCompile(`>\s*/etc/`),
+		"overwrites system configuration in /etc"},
+}
+
+// Scan returns a warning for each dangerous pattern in command, or nil when
+// none match
+func Scan(command string) []string {
+	var warnings []string
+	for _, r := range rules {
+		if r.pattern.MatchString(command) {
+			warnings = append(warnings, r.warning)
+		}
+	}
+	return warnings
+}
+
+// ScanAll returns the warnings for every command without duplicates, in the
+// order they were first found
+func ScanAll(commands []string) []string {
+	var warnings []string
+	seen := make(map[string]bool)
+	for _, command := range commands {
+		for _, warning := range Scan(command) {
+			if !seen[warning] {
+				seen[warning] = true
+				warnings = append(warnings, warning)
+			}
+		}
+	}
+	return warnings
+}
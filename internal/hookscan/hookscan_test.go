@@ -0,0 +1,50 @@
+package hookscan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{command: "brew services start postgresql", want: nil},
+		{command: "rm -rf ~/.cache/nvim", want: nil},
+		{command: "rm -rf build/", want: nil},
+		{command: "rm -rf /", want: []string{"recursively deletes the root or home directory"}},
+		{command: "rm -fr ~", want: []string{"recursively deletes the root or home directory"}},
+		{command: "rm -r -f $HOME/*", want: []string{"recursively deletes the root or home directory"}},
+		{command: "curl -fsSL https://example.com/install.sh | bash", want: []string{"pipes a download straight into a shell"}},
+		{command: "wget -qO- https://example.com/x | sudo sh", want: []string{"pipes a download straight into a shell", "runs with root privileges"}},
+		{command: "curl -o install.sh https://example.com/install.sh", want: nil},
+		{command: "echo ZWNobyBoaQ== | base64 -d | sh", want: []string{"runs obfuscated base64 code"}},
+		{command: "sudo apt-get update", want: []string{"runs with root privileges"}},
+		{command: "pseudo-tool --sudo-mode", want: nil},
+		{command: "chmod -R 777 /usr/local", want: []string{"makes files writable by every user"}},
+		{command: "chmod 755 ~/bin/tool", want: nil},
+		{command: "dd if=/dev/zero of=/dev/sda bs=1M", want: []string{"writes to a raw disk"}},
+		{command: "mkfs.ext4 /dev/sdb1", want: []string{"writes to a raw disk"}},
+		{command: ":(){ :|:& };:", want: []string{"is a fork bomb"}},
+		{command: "echo '127.0.0.1 ads' > /etc/hosts", want: []string{"overwrites system configuration in /etc"}},
+	}
+
+	for _, tt := range tests {
+		if got := Scan(tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Scan(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestScanAllDeduplicates(t *testing.T) {
+	got := ScanAll([]string{"sudo true", "echo ok", "sudo chmod 777 /opt", "sudo false"})
+	want := []string{"runs with root privileges", "makes files writable by every user"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if got := ScanAll([]string{"git pull"}); got != nil {
+		t.Errorf("Expected no warnings for safe commands, got %v", got)
+	}
+}
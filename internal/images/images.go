@@ -0,0 +1,222 @@
+// Package images stores the screenshots uploaded for templates. Uploads are
+// identified by their magic bytes rather than their file name, and saved on
+// disk under content-hashed names, so a stored file can be served directly
+// as a static file.
+package images
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"image"
+	_ "image/jpeg" // register the decoders image.DecodeConfig needs
+	_ "image/png"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrTooLarge    = errors.New("image too large")
+	ErrUnsupported = errors.New("image type not supported")
+	ErrInvalid     = errors.New("image could not be read")
+)
+
+// Content types of the accepted formats
+const (
+	PNG  = "image/png"
+	JPEG = "image/jpeg"
+	WebP = "image/webp"
+)
+
+var extensions = map[string]string{
+	PNG:  ".png",
+	JPEG: ".jpg",
+	WebP: ".webp",
+}
+
+// Config tunes the store
+type Config struct {
+	// Dir holds the stored images, one subdirectory per template
+	Dir string
+	// URLPath is the path Dir is served at
+	URLPath string
+	// MaxBytes bounds the size of each image
+	MaxBytes int64
+	// MaxPerTemplate bounds how many images a template may have
+	MaxPerTemplate int
+}
+
+// DefaultConfig stores up to three images of 2MB per template
+func DefaultConfig() Config {
+	return Config{
+		Dir:            filepath.Join("static", "templates"),
+		URLPath:        "/static/templates",
+		MaxBytes:       2 << 20,
+		MaxPerTemplate: 3,
+	}
+}
+
+// Image describes a stored image
+type Image struct {
+	// Name is the file name, unique to the image's content
+	Name        string
+	URL         string
+	ContentType string
+	Width       int
+	Height      int
+	Size        int64
+}
+
+// Store saves and removes template images
+type Store struct {
+	config Config
+}
+
+// NewStore creates a store
+func NewStore(config Config) *Store {
+	config.URLPath = strings.TrimRight(config.URLPath, "/")
+	return &Store{config: config}
+}
+
+// Config returns the store's settings
+func (s *Store) Config() Config {
+	return s.config
+}
+
+// Save checks that data is a PNG, JPEG or WebP image within MaxBytes and
+// stores it for the template
+func (s *Store) Save(templateID string, data []byte) (*Image, error) {
+	if int64(len(data)) > s.config.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	contentType := Sniff(data)
+	if contentType == "" {
+		return nil, ErrUnsupported
+	}
+	width, height, err := dimensions(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:16]) + extensions[contentType]
+	dir := s.dir(templateID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, name), data); err != nil {
+		return nil, err
+	}
+
+	return &Image{
+		Name:        name,
+		URL:         path.Join(s.config.URLPath, filepath.Base(dir), name),
+		ContentType: contentType,
+		Width:       width,
+		Height:      height,
+		Size:        int64(len(data)),
+	}, nil
+}
+
+// Remove deletes one of a template's images. Missing files are ignored.
+func (s *Store) Remove(templateID, name string) error {
+	err := os.Remove(filepath.Join(s.dir(templateID), filepath.Base(name)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// RemoveAll deletes every image of a template
+func (s *Store) RemoveAll(templateID string) error {
+	return os.RemoveAll(s.dir(templateID))
+}
+
+// dir returns where a template's images are kept. Template IDs are hashed
+// so they can never name a path outside Dir.
+func (s *Store) dir(templateID string) string {
+	sum := sha256.Sum256([]byte(templateID))
+	return filepath.Join(s.config.Dir, hex.EncodeToString(sum[:16]))
+}
+
+// Sniff returns the content type of a PNG, JPEG or WebP image from its
+// magic bytes, or "" for anything else
+func Sniff(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return PNG
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return JPEG
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return WebP
+	}
+	return ""
+}
+
+func dimensions(data []byte, contentType string) (int, int, error) {
+	if contentType == WebP {
+		return webpDimensions(data)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, ErrInvalid
+	}
+	return config.Width, config.Height, nil
+}
+
+// webpDimensions reads the canvas size from the first chunk of a WebP
+// file, which the standard library cannot decode
+func webpDimensions(data []byte) (int, int, error) {
+	if len(data) < 30 {
+		return 0, 0, ErrInvalid
+	}
+
+	chunk := data[12:16]
+	payload := data[20:]
+	switch string(chunk) {
+	case "VP8 ":
+		// Lossy: a frame tag, start code and 14-bit dimensions
+		if !bytes.Equal(payload[3:6], []byte{0x9d, 0x01, 0x2a}) {
+			return 0, 0, ErrInvalid
+		}
+		width := int(binary.LittleEndian.Uint16(payload[6:8]) & 0x3fff)
+		height := int(binary.LittleEndian.Uint16(payload[8:10]) & 0x3fff)
+		return width, height, nil
+	case "VP8L":
+		// Lossless: a signature byte, then 14-bit dimensions minus one
+		if payload[0] != 0x2f {
+			return 0, 0, ErrInvalid
+		}
+		bits := binary.LittleEndian.Uint32(payload[1:5])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, nil
+	case "VP8X":
+		// Extended: 24-bit canvas dimensions minus one
+		width := int(payload[4]) | int(payload[5])<<8 | int(payload[6])<<16
+		height := int(payload[7]) | int(payload[8])<<8 | int(payload[9])<<16
+		return width + 1, height + 1, nil
+	}
+	return 0, 0, ErrInvalid
+}
+
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".image-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
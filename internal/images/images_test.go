@@ -0,0 +1,50 @@
+package images
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// webpHeader builds the RIFF header and first chunk of a WebP file
+func webpHeader(chunk string, payload []byte) []byte {
+	data := []byte("RIFF\x00\x00\x00\x00WEBP" + chunk)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(payload)))
+	return append(data, payload...)
+}
+
+func TestSniffAndDimensions(t *testing.T) {
+	lossless := make([]byte, 10)
+	lossless[0] = 0x2f
+	binary.LittleEndian.PutUint32(lossless[1:], uint32(640-1)|uint32(480-1)<<14)
+
+	extended := make([]byte, 10)
+	extended[4], extended[5] = 0x7f, 0x07 // 1920 - 1
+	extended[7], extended[8] = 0x37, 0x04 // 1080 - 1
+
+	tests := []struct {
+		name          string
+		data          []byte
+		contentType   string
+		width, height int
+	}{
+		{name: "lossless webp", data: webpHeader("VP8L", lossless), contentType: WebP, width: 640, height: 480},
+		{name: "extended webp", data: webpHeader("VP8X", extended), contentType: WebP, width: 1920, height: 1080},
+		{name: "gif", data: []byte("GIF89a\x01\x00\x01\x00"), contentType: ""},
+		{name: "svg", data: []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"), contentType: ""},
+	}
+
+	for _, tt := range tests {
+		contentType := Sniff(tt.data)
+		if contentType != tt.contentType {
+			t.Errorf("%s: expected content type %q, got %q", tt.name, tt.contentType, contentType)
+			continue
+		}
+		if contentType == "" {
+			continue
+		}
+		width, height, err := dimensions(tt.data, contentType)
+		if err != nil || width != tt.width || height != tt.height {
+			t.Errorf("%s: expected %dx%d, got %dx%d (%v)", tt.name, tt.width, tt.height, width, height, err)
+		}
+	}
+}
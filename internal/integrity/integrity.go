@@ -0,0 +1,290 @@
+// Package integrity finds and removes records left pointing at templates
+// and users that no longer exist.
+package integrity
+
+import (
+	"context"
+	stderrors "errors"
+	"log"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+)
+
+// pageSize is how many records the sweep loads at a time
+const pageSize = 100
+
+// Report counts the orphaned records a sweep found, per category. Unless
+// DryRun is set they were also removed, apart from OrphanedOwners.
+type Report struct {
+	DryRun bool `json:"dry_run"`
+	// OrphanedReviews are reviews of templates that no longer exist
+	OrphanedReviews int `json:"orphaned_reviews"`
+	// OrphanedFavorites are favorites of templates that no longer exist
+	OrphanedFavorites int `json:"orphaned_favorites"`
+	// OrphanedMembers are organization memberships of missing or deleted
+	// users
+	OrphanedMembers int `json:"orphaned_members"`
+	// OrphanedOwners are owner memberships of missing or deleted users.
+	// They are only reported: removing them would leave the organization
+	// without an owner, so ownership has to be transferred by hand.
+	OrphanedOwners int `json:"orphaned_owners"`
+}
+
+// Sweeper scans the stores for orphaned records
+type Sweeper struct {
+	templates repository.TemplateRepository
+	reviews   repository.ReviewRepository
+	users     repository.UserRepository
+	orgs      repository.OrganizationRepository
+}
+
+// New creates a sweeper
+func New(
+	templates repository.TemplateRepository,
+	reviews repository.ReviewRepository,
+	users repository.UserRepository,
+	orgs repository.OrganizationRepository,
+) *Sweeper {
+	return &Sweeper{
+		templates: templates,
+		reviews:   reviews,
+		users:     users,
+		orgs:      orgs,
+	}
+}
+
+// Start sweeps and removes orphans every interval until ctx is done
+func (s *Sweeper) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Run(ctx, false)
+			if err != nil {
+				log.Printf("Integrity sweep failed: %v", err)
+			}
+			if report != nil {
+				log.Printf("Integrity sweep removed %d reviews, %d favorites and %d memberships; %d owner memberships need attention",
+					report.OrphanedReviews, report.OrphanedFavorites, report.OrphanedMembers, report.OrphanedOwners)
+			}
+		}
+	}
+}
+
+// Run scans every review, favorite and organization membership, paging
+// through each store, and removes the orphans unless dryRun is set. Each
+// category is removed only after it has been fully scanned so removals do
+// not shift later pages. The report is returned even when some removals
+// fail.
+func (s *Sweeper) Run(ctx context.Context, dryRun bool) (*Report, error) {
+	sweep := &sweep{
+		Sweeper:        s,
+		report:         &Report{DryRun: dryRun},
+		knownTemplates: make(map[string]bool),
+		knownUsers:     make(map[string]bool),
+	}
+
+	for _, step := range []func(context.Context) error{
+		sweep.sweepReviews,
+		sweep.sweepFavorites,
+		sweep.sweepMembers,
+	} {
+		if err := step(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return sweep.report, stderrors.Join(sweep.errs...)
+}
+
+// sweep holds the state of one Run, caching existence checks so each
+// template and user is looked up once
+type sweep struct {
+	*Sweeper
+	report         *Report
+	knownTemplates map[string]bool
+	knownUsers     map[string]bool
+	errs           []error
+}
+
+func (s *sweep) sweepReviews(ctx context.Context) error {
+	var orphans []string
+
+	for offset := 0; ; offset += pageSize {
+		reviews, err := s.reviews.List(ctx, pageSize, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, review := range reviews {
+			exists, err := s.templateExists(ctx, review.TemplateID)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				orphans = append(orphans, review.ID)
+			}
+		}
+
+		if len(reviews) < pageSize {
+			break
+		}
+	}
+
+	s.report.OrphanedReviews = len(orphans)
+	if s.report.DryRun {
+		return nil
+	}
+
+	for _, id := range orphans {
+		if err := s.reviews.Delete(ctx, id); err != nil && !isNotFound(err) {
+			s.errs = append(s.errs, err)
+		}
+	}
+	return nil
+}
+
+// favorite is one user's favorite of a template
+type favorite struct {
+	userID     string
+	templateID string
+}
+
+func (s *sweep) sweepFavorites(ctx context.Context) error {
+	var orphans []favorite
+
+	for offset := 0; ; offset += pageSize {
+		users, err := s.users.List(ctx, pageSize, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			favorites, err := s.users.GetFavorites(ctx, user.ID)
+			if err != nil {
+				return err
+			}
+
+			for _, templateID := range favorites {
+				exists, err := s.templateExists(ctx, templateID)
+				if err != nil {
+					return err
+				}
+				if !exists {
+					orphans = append(orphans, favorite{userID: user.ID, templateID: templateID})
+				}
+			}
+		}
+
+		if len(users) < pageSize {
+			break
+		}
+	}
+
+	s.report.OrphanedFavorites = len(orphans)
+	if s.report.DryRun {
+		return nil
+	}
+
+	for _, orphan := range orphans {
+		if err := s.users.RemoveFavorite(ctx, orphan.userID, orphan.templateID); err != nil && !isNotFound(err) {
+			s.errs = append(s.errs, err)
+		}
+	}
+	return nil
+}
+
+func (s *sweep) sweepMembers(ctx context.Context) error {
+	var orphans []*models.OrganizationMember
+
+	for offset := 0; ; offset += pageSize {
+		members, err := s.orgs.ListMembers(ctx, pageSize, offset)
+		if err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			exists, err := s.userExists(ctx, member.UserID)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+
+			if member.Role == models.RoleOwner {
+				s.report.OrphanedOwners++
+				continue
+			}
+			orphans = append(orphans, member)
+		}
+
+		if len(members) < pageSize {
+			break
+		}
+	}
+
+	s.report.OrphanedMembers = len(orphans)
+	if s.report.DryRun {
+		return nil
+	}
+
+	for _, member := range orphans {
+		if err := s.orgs.RemoveMember(ctx, member.OrganizationID, member.UserID); err != nil && !isNotFound(err) {
+			s.errs = append(s.errs, err)
+			continue
+		}
+		// A soft-deleted user's sessions may still cache the role
+		if err := s.users.BumpMembershipVersion(ctx, member.UserID); err != nil && !isNotFound(err) {
+			s.errs = append(s.errs, err)
+		}
+	}
+	return nil
+}
+
+// templateExists reports whether a template is still stored
+func (s *sweep) templateExists(ctx context.Context, id string) (bool, error) {
+	if exists, checked := s.knownTemplates[id]; checked {
+		return exists, nil
+	}
+
+	template, err := s.templates.GetByID(ctx, id)
+	if err != nil && !isNotFound(err) {
+		return false, err
+	}
+
+	exists := err == nil && template != nil
+	s.knownTemplates[id] = exists
+	return exists, nil
+}
+
+// userExists reports whether a user is stored and not soft-deleted
+func (s *sweep) userExists(ctx context.Context, id string) (bool, error) {
+	if exists, checked := s.knownUsers[id]; checked {
+		return exists, nil
+	}
+
+	user, err := s.users.GetByID(ctx, id)
+	if err != nil && !isNotFound(err) {
+		return false, err
+	}
+
+	exists := err == nil && user != nil && !user.IsDeleted()
+	s.knownUsers[id] = exists
+	return exists, nil
+}
+
+// isNotFound reports whether a lookup failed because the record does not
+// exist; some stores report that as an error rather than a nil result
+func isNotFound(err error) bool {
+	var appErr *errors.AppError
+	return stderrors.Is(err, repository.ErrNotFound) ||
+		(stderrors.As(err, &appErr) && appErr.Code == errors.ErrCodeNotFound)
+}
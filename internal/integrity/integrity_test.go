@@ -0,0 +1,177 @@
+package integrity
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+)
+
+type integrityFixture struct {
+	sweeper   *Sweeper
+	templates *memory.TemplateRepository
+	reviews   *memory.ReviewRepository
+	users     *memory.UserRepository
+	orgs      *memory.OrganizationRepository
+}
+
+// newIntegrityFixture seeds orphans of every kind: reviews spanning more
+// than one page, some of a deleted template; favorites of that template;
+// and memberships, including an owner's, of deleted and missing users
+func newIntegrityFixture(t *testing.T) *integrityFixture {
+	t.Helper()
+	ctx := context.Background()
+
+	f := &integrityFixture{
+		templates: memory.NewTemplateRepository(),
+		reviews:   memory.NewReviewRepository(),
+		users:     memory.NewUserRepository(),
+		orgs:      memory.NewOrganizationRepository(),
+	}
+	f.sweeper = New(f.templates, f.reviews, f.users, f.orgs)
+
+	for _, id := range []string{"kept", "doomed"} {
+		if err := f.templates.Create(ctx, &models.StoredTemplate{ID: id, Template: models.Template{Public: true}}); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	for i := 0; i < pageSize+20; i++ {
+		templateID := "kept"
+		if i%10 == 0 {
+			templateID = "doomed"
+		}
+		review := &models.Review{ID: fmt.Sprintf("review-%03d", i), TemplateID: templateID, UserID: fmt.Sprintf("user-%03d", i), Rating: 5}
+		if err := f.reviews.Create(ctx, review); err != nil {
+			t.Fatalf("Failed to create review: %v", err)
+		}
+	}
+
+	for _, user := range []*models.User{
+		{ID: "alice-id", Username: "alice", Email: "alice@example.com"},
+		{ID: "bob-id", Username: "bob", Email: "bob@example.com"},
+		{ID: "gone-id", Username: "gone", Email: "gone@example.com"},
+	} {
+		if err := f.users.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	for _, favorite := range []struct{ userID, templateID string }{
+		{"alice-id", "kept"},
+		{"alice-id", "doomed"},
+		{"bob-id", "doomed"},
+		{"bob-id", "never-existed"},
+	} {
+		if err := f.users.AddFavorite(ctx, favorite.userID, favorite.templateID); err != nil {
+			t.Fatalf("Failed to add favorite: %v", err)
+		}
+	}
+
+	if err := f.orgs.Create(ctx, &models.Organization{ID: "org-acme", Name: "Acme", Slug: "acme", OwnerID: "ghost-id"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+	for _, member := range []*models.OrganizationMember{
+		{ID: "member-1", UserID: "ghost-id", Role: models.RoleOwner},
+		{ID: "member-2", UserID: "alice-id", Role: models.RoleAdmin},
+		{ID: "member-3", UserID: "gone-id", Role: models.RoleMember},
+		{ID: "member-4", UserID: "missing-id", Role: models.RoleMember},
+	} {
+		member.OrganizationID = "org-acme"
+		if err := f.orgs.AddMember(ctx, member); err != nil {
+			t.Fatalf("Failed to add member: %v", err)
+		}
+	}
+
+	if err := f.templates.Delete(ctx, "doomed"); err != nil {
+		t.Fatalf("Failed to delete template: %v", err)
+	}
+	if err := f.users.Delete(ctx, "gone-id"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	return f
+}
+
+func (f *integrityFixture) snapshot(t *testing.T) (reviews int, favorites map[string][]string, members []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	all, err := f.reviews.List(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to list reviews: %v", err)
+	}
+
+	favorites = make(map[string][]string)
+	for _, userID := range []string{"alice-id", "bob-id"} {
+		favorites[userID], _ = f.users.GetFavorites(ctx, userID)
+	}
+
+	orgMembers, err := f.orgs.ListMembers(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to list members: %v", err)
+	}
+	for _, member := range orgMembers {
+		members = append(members, member.ID)
+	}
+
+	return len(all), favorites, members
+}
+
+func TestSweepDryRunOnlyReports(t *testing.T) {
+	f := newIntegrityFixture(t)
+
+	report, err := f.sweeper.Run(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Failed to run sweep: %v", err)
+	}
+
+	want := &Report{DryRun: true, OrphanedReviews: 12, OrphanedFavorites: 3, OrphanedMembers: 2, OrphanedOwners: 1}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Expected %+v, got %+v", want, report)
+	}
+
+	reviews, favorites, members := f.snapshot(t)
+	if reviews != pageSize+20 || len(favorites["bob-id"]) != 2 || len(members) != 4 {
+		t.Errorf("Expected a dry run to change nothing, got %d reviews, favorites %v, members %v", reviews, favorites, members)
+	}
+}
+
+func TestSweepRemovesOrphans(t *testing.T) {
+	f := newIntegrityFixture(t)
+	ctx := context.Background()
+
+	report, err := f.sweeper.Run(ctx, false)
+	if err != nil {
+		t.Fatalf("Failed to run sweep: %v", err)
+	}
+
+	want := &Report{OrphanedReviews: 12, OrphanedFavorites: 3, OrphanedMembers: 2, OrphanedOwners: 1}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("Expected %+v, got %+v", want, report)
+	}
+
+	reviews, favorites, members := f.snapshot(t)
+	if reviews != pageSize+20-12 {
+		t.Errorf("Expected %d reviews left, got %d", pageSize+20-12, reviews)
+	}
+	wantFavorites := map[string][]string{"alice-id": {"kept"}, "bob-id": {}}
+	if !reflect.DeepEqual(favorites, wantFavorites) {
+		t.Errorf("Expected favorites %v, got %v", wantFavorites, favorites)
+	}
+	// The ownerless organization keeps its owner row for a manual transfer
+	if wantMembers := []string{"member-1", "member-2"}; !reflect.DeepEqual(members, wantMembers) {
+		t.Errorf("Expected members %v, got %v", wantMembers, members)
+	}
+
+	// A second sweep finds only the owner row it leaves alone
+	report, err = f.sweeper.Run(ctx, false)
+	if err != nil {
+		t.Fatalf("Failed to run sweep: %v", err)
+	}
+	if want := (&Report{OrphanedOwners: 1}); !reflect.DeepEqual(report, want) {
+		t.Errorf("Expected %+v on the second sweep, got %+v", want, report)
+	}
+}
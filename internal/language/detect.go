@@ -0,0 +1,131 @@
+// Package language guesses the language of short user-written text, such
+// as review comments, so listings can be filtered to languages a reader
+// knows.
+package language
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Undetermined is the ISO 639-2 code stored when the language of a text
+// cannot be told, such as for empty, very short or unfamiliar text
+const Undetermined = "und"
+
+// minTrigrams is how many trigrams a text needs before it is classified.
+// A single three-letter word is too little to go on.
+const minTrigrams = 4
+
+// minKnownShare is the share of a text's trigrams the winning language must
+// have seen in its sample. Text mostly made of trigrams no language uses,
+// such as keyboard mashing or another language entirely, is undetermined.
+const minKnownShare = 0.5
+
+// profile is the trigram counts of one language's sample text
+type profile struct {
+	code   string
+	counts map[string]int
+	total  int
+}
+
+var profiles = buildProfiles()
+
+func buildProfiles() []profile {
+	codes := make([]string, 0, len(samples))
+	for code := range samples {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	built := make([]profile, len(codes))
+	for i, code := range codes {
+		p := profile{code: code, counts: make(map[string]int)}
+		for _, trigram := range trigrams(samples[code]) {
+			p.counts[trigram]++
+			p.total++
+		}
+		built[i] = p
+	}
+	return built
+}
+
+// Supported returns the codes Detect can return other than Undetermined,
+// in sorted order
+func Supported() []string {
+	codes := make([]string, len(profiles))
+	for i, p := range profiles {
+		codes[i] = p.code
+	}
+	return codes
+}
+
+// IsSupported reports whether code is Undetermined or a code Detect returns
+func IsSupported(code string) bool {
+	if code == Undetermined {
+		return true
+	}
+	for _, p := range profiles {
+		if p.code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect returns the ISO 639-1 code of the language text is most likely
+// written in, or Undetermined. Each language is scored by how likely its
+// sample text makes the trigrams of text, so the result only depends on
+// text and is the same on every call.
+func Detect(text string) string {
+	grams := trigrams(text)
+	if len(grams) < minTrigrams {
+		return Undetermined
+	}
+
+	best, bestScore, bestKnown := "", math.Inf(-1), 0
+	tied := false
+	for _, p := range profiles {
+		score, known := 0.0, 0
+		// Add-one smoothing, so trigrams missing from a sample count against
+		// the language without ruling it out
+		vocabulary := float64(p.total + len(p.counts))
+		for _, gram := range grams {
+			count := p.counts[gram]
+			if count > 0 {
+				known++
+			}
+			score += math.Log(float64(count+1) / vocabulary)
+		}
+
+		switch {
+		case score > bestScore:
+			best, bestScore, bestKnown, tied = p.code, score, known, false
+		case score == bestScore:
+			tied = true
+		}
+	}
+
+	if tied || float64(bestKnown) < minKnownShare*float64(len(grams)) {
+		return Undetermined
+	}
+	return best
+}
+
+// trigrams splits text into lowercase words of letters and returns the
+// three-letter sequences of each, padded with a space on either side so
+// word beginnings and endings count
+func trigrams(text string) []string {
+	var grams []string
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			grams = append(grams, string(runes[i:i+3]))
+		}
+	}
+	return grams
+}
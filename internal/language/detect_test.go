@@ -0,0 +1,64 @@
+package language
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		// English
+		{"Great template, works perfectly!", "en"},
+		{"Easy to set up and the docs are clear.", "en"},
+		{"Not bad, but the hooks were broken for me.", "en"},
+		{"Exactly what I needed for my new laptop", "en"},
+		// Spanish
+		{"Muy buena plantilla, funciona perfectamente.", "es"},
+		{"Fácil de instalar y las instrucciones son claras.", "es"},
+		{"No está mal, pero los ganchos no me funcionaron.", "es"},
+		{"Justo lo que necesitaba para mi nuevo portátil", "es"},
+		// German
+		{"Tolle Vorlage, funktioniert einwandfrei!", "de"},
+		{"Einfach einzurichten und die Anleitung ist klar.", "de"},
+		{"Nicht schlecht, aber die Hooks waren bei mir kaputt.", "de"},
+		{"Genau das, was ich für meinen neuen Laptop brauchte", "de"},
+		// French
+		{"Très bon modèle, fonctionne parfaitement.", "fr"},
+		{"Facile à installer et la documentation est claire.", "fr"},
+		// Nothing to go on
+		{"", Undetermined},
+		{"👍👍👍", Undetermined},
+		{"ok", Undetermined},
+		{"10/10", Undetermined},
+		{"qxz vkjw zzqp", Undetermined},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Detect(tt.text); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDetectIsDeterministic(t *testing.T) {
+	text := "Sehr gut, danke!"
+	want := Detect(text)
+	for i := 0; i < 50; i++ {
+		if got := Detect(text); got != want {
+			t.Fatalf("Expected %q on every call, got %q", want, got)
+		}
+	}
+}
+
+func TestIsSupported(t *testing.T) {
+	for _, code := range append(Supported(), Undetermined) {
+		if !IsSupported(code) {
+			t.Errorf("Expected %q to be supported", code)
+		}
+	}
+	if IsSupported("xx") || IsSupported("EN") {
+		t.Error("Expected unknown and uppercase codes to be unsupported")
+	}
+}
@@ -0,0 +1,68 @@
+package language
+
+// samples is the text each language's trigram profile is built from, keyed
+// by ISO 639-1 code. They are everyday prose in the register of a review,
+// made up of the language's most common words, and are kept about the same
+// length so no language is favoured for having more text.
+var samples = map[string]string{
+	"en": `This is a great template and it works really well on my machine.
+I was looking for something simple that would set up my terminal, and this
+did everything I needed. The install was quick, the instructions were clear
+and there is nothing here that I would want to change. It would be nice if
+the author could add a few more packages for people who write code every
+day, but that is not a problem. I have been using it for two weeks now and
+I think it is the best one I have found. Thank you for sharing your work
+with us. Some of the hooks did not run the first time, so you should check
+them before you sync. Overall they are very good and easy to use, which is
+what most of us want from these things. Highly recommended for anyone who
+is new to this and wants to get started without reading all of the docs.
+With just one command you get the shell, the editor and your favourite
+tools, which saves a lot of time when you move to another computer.`,
+
+	"es": `Esta plantilla es muy buena y funciona perfectamente en mi equipo.
+Estaba buscando algo sencillo para configurar la terminal y esto hizo todo
+lo que necesitaba. La instalación fue rápida, las instrucciones son claras
+y no hay nada aquí que me gustaría cambiar. Sería bueno que el autor pudiera
+añadir algunos paquetes más para las personas que escriben código todos los
+días, pero no es un problema. La estoy usando desde hace dos semanas y creo
+que es la mejor que he encontrado. Gracias por compartir tu trabajo con
+nosotros. Algunos de los ganchos no se ejecutaron la primera vez, así que
+conviene revisarlos antes de sincronizar. En general son muy buenos y
+fáciles de usar, que es lo que la mayoría de nosotros queremos de estas
+cosas. Muy recomendable para cualquiera que sea nuevo en esto y quiera
+empezar sin leer toda la documentación. Con un solo comando tienes el
+intérprete, el editor y tus herramientas favoritas, lo cual ahorra mucho
+tiempo cuando te cambias a otro ordenador. ¡Excelente trabajo, de verdad!`,
+
+	"de": `Diese Vorlage ist sehr gut und funktioniert wirklich gut auf meinem
+Rechner. Ich habe nach etwas Einfachem gesucht, um mein Terminal
+einzurichten, und das hier hat alles gemacht, was ich brauchte. Die
+Installation war schnell, die Anleitung ist klar und es gibt nichts, was
+ich ändern würde. Es wäre schön, wenn der Autor noch ein paar Pakete für
+Leute hinzufügen könnte, die jeden Tag Code schreiben, aber das ist kein
+Problem. Ich benutze sie jetzt seit zwei Wochen und finde, dass sie die
+beste ist, die ich gefunden habe. Danke, dass du deine Arbeit mit uns
+teilst. Einige der Hooks sind beim ersten Mal nicht gelaufen, also solltest
+du sie vor dem Synchronisieren prüfen. Insgesamt sind sie sehr gut und
+einfach zu benutzen, was die meisten von uns von solchen Dingen wollen.
+Sehr empfehlenswert für alle, die neu dabei sind und anfangen möchten, ohne
+die ganze Dokumentation zu lesen. Mit nur einem Befehl bekommst du die
+Shell, den Editor und deine Lieblingswerkzeuge, was viel Zeit spart, wenn
+man zu einem anderen Computer wechselt. Wirklich großartige Arbeit!`,
+
+	"fr": `Ce modèle est très bien et il fonctionne vraiment bien sur ma machine.
+Je cherchais quelque chose de simple pour configurer mon terminal, et
+celui-ci a fait tout ce dont j'avais besoin. L'installation était rapide,
+les instructions sont claires et il n'y a rien que je voudrais changer. Ce
+serait bien que l'auteur puisse ajouter quelques paquets de plus pour les
+personnes qui écrivent du code tous les jours, mais ce n'est pas un
+problème. Je l'utilise depuis deux semaines et je pense que c'est le
+meilleur que j'ai trouvé. Merci de partager votre travail avec nous.
+Certains des crochets ne se sont pas lancés la première fois, donc il vaut
+mieux les vérifier avant de synchroniser. Dans l'ensemble ils sont très bons
+et faciles à utiliser, ce que la plupart d'entre nous attendent de ces
+choses. Je le recommande à tous ceux qui débutent et qui veulent commencer
+sans lire toute la documentation. Avec une seule commande vous avez le
+shell, l'éditeur et vos outils préférés, ce qui fait gagner beaucoup de
+temps quand on change d'ordinateur.`,
+}
@@ -0,0 +1,60 @@
+// Package mergepatch applies JSON Merge Patches (RFC 7386): a patch object
+// sets each of its members on the target, recursing into objects, and a null
+// member removes the field. Anything other than an object, arrays included,
+// replaces the target value wholesale.
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Apply merges the JSON document patch into the JSON document target
+func Apply(target, patch []byte) ([]byte, error) {
+	var targetValue interface{}
+	if len(bytes.TrimSpace(target)) > 0 {
+		if err := decode(target, &targetValue); err != nil {
+			return nil, err
+		}
+	}
+
+	var patchValue interface{}
+	if err := decode(patch, &patchValue); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(Merge(targetValue, patchValue))
+}
+
+// Merge applies a decoded patch to a decoded target, following the
+// MergePatch pseudocode in RFC 7386 section 2. target is not modified.
+func Merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	result := make(map[string]interface{})
+	if targetObject, ok := target.(map[string]interface{}); ok {
+		for name, value := range targetObject {
+			result[name] = value
+		}
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(result, name)
+			continue
+		}
+		result[name] = Merge(result[name], value)
+	}
+	return result
+}
+
+// decode keeps numbers as json.Number so large integers survive the round
+// trip unchanged
+func decode(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
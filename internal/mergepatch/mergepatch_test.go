@@ -0,0 +1,86 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	// The examples from RFC 7386 appendix A, plus a nested document
+	tests := []struct {
+		target, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{
+			`{"metadata":{"name":"Dev","tags":["go","js"]},"brews":["git"],"public":true}`,
+			`{"metadata":{"tags":["rust"]},"brews":null,"public":false}`,
+			`{"metadata":{"name":"Dev","tags":["rust"]},"public":false}`,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Apply([]byte(tt.target), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("Apply(%s, %s) failed: %v", tt.target, tt.patch, err)
+			continue
+		}
+		if !jsonEqual(t, got, []byte(tt.want)) {
+			t.Errorf("Apply(%s, %s) = %s, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApplyLeavesTargetUnchanged(t *testing.T) {
+	target := map[string]interface{}{"a": map[string]interface{}{"b": "c"}}
+	Merge(target, map[string]interface{}{"a": map[string]interface{}{"b": nil}})
+
+	if !reflect.DeepEqual(target, map[string]interface{}{"a": map[string]interface{}{"b": "c"}}) {
+		t.Errorf("Expected the target to be left alone, got %v", target)
+	}
+}
+
+func TestApplyKeepsLargeNumbers(t *testing.T) {
+	got, err := Apply([]byte(`{"downloads":9007199254740993}`), []byte(`{"name":"x"}`))
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if want := `{"downloads":9007199254740993,"name":"x"}`; string(got) != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestApplyRejectsInvalidJSON(t *testing.T) {
+	if _, err := Apply([]byte(`{"a":1}`), []byte(`{"a":`)); err == nil {
+		t.Error("Expected an error for a malformed patch")
+	}
+	if _, err := Apply([]byte(`{"a":`), []byte(`{"a":1}`)); err == nil {
+		t.Error("Expected an error for a malformed target")
+	}
+}
+
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var av, bv interface{}
+	if err := json.Unmarshal(a, &av); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &bv); err != nil {
+		t.Fatalf("Invalid JSON %s: %v", b, err)
+	}
+	return reflect.DeepEqual(av, bv)
+}
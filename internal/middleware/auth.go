@@ -6,23 +6,43 @@ import (
 	"github.com/gin-gonic/gin"
 	"dotfiles-api/pkg/errors"
 	"dotfiles-api/internal/auth"
+	"dotfiles-api/internal/repository"
 )
 
-// AuthMiddleware holds the session manager
+// AuthMiddleware holds the session manager, the configured site admins and,
+// when API keys are enabled, the repositories to check them against
 type AuthMiddleware struct {
 	sessionManager *auth.SessionManager
+	admins         map[string]bool
+	apiKeys        repository.APIKeyRepository
+	users          repository.UserRepository
 }
 
-// NewAuthMiddleware creates a new auth middleware
-func NewAuthMiddleware(sessionManager *auth.SessionManager) *AuthMiddleware {
+// NewAuthMiddleware creates a new auth middleware. adminUsers lists the
+// usernames granted site admin rights.
+func NewAuthMiddleware(sessionManager *auth.SessionManager, adminUsers []string) *AuthMiddleware {
+	admins := make(map[string]bool, len(adminUsers))
+	for _, username := range adminUsers {
+		admins[username] = true
+	}
+
 	return &AuthMiddleware{
 		sessionManager: sessionManager,
+		admins:         admins,
 	}
 }
 
-// RequireAuth middleware that requires authentication
+// RequireAuth middleware that requires authentication, by session cookie or
+// API key
 func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if am.useAPIKey(c) {
+			if !c.IsAborted() {
+				c.Next()
+			}
+			return
+		}
+
 		session, exists := am.sessionManager.GetSessionFromContext(c)
 		if !exists {
 			c.JSON(http.StatusUnauthorized, gin.H{
@@ -37,24 +57,71 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		c.Set("username", session.Username)
 		c.Set("email", session.Email)
 		c.Set("session", session)
+		c.Set("is_admin", am.admins[session.Username])
+		am.setOrgMemberships(c, session)
 		c.Next()
 	}
 }
 
-// OptionalAuth middleware that optionally sets user info if authenticated
+// RequireAdmin middleware that requires an authenticated site admin.
+// It must run after RequireAuth.
+func (am *AuthMiddleware) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("is_admin") {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": errors.NewForbiddenError("site admin privileges required"),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// OptionalAuth middleware that optionally sets user info if authenticated.
+// A request with an invalid API key is still rejected.
 func (am *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if am.useAPIKey(c) {
+			if !c.IsAborted() {
+				c.Next()
+			}
+			return
+		}
+
 		session, exists := am.sessionManager.GetSessionFromContext(c)
 		if exists {
 			c.Set("user_id", session.UserID)
 			c.Set("username", session.Username)
 			c.Set("email", session.Email)
 			c.Set("session", session)
+			c.Set("is_admin", am.admins[session.Username])
+			am.setOrgMemberships(c, session)
 		}
 		c.Next()
 	}
 }
 
+// setOrgMemberships copies the organization roles cached in the session, if
+// any, into the request context
+func (am *AuthMiddleware) setOrgMemberships(c *gin.Context, session *auth.Session) {
+	if memberships, ok := am.sessionManager.GetData(session.ID, auth.OrgMembershipsKey); ok {
+		c.Set(auth.OrgMembershipsKey, memberships)
+	}
+}
+
+// OrgMemberships returns the caller's organization roles as cached in their
+// session. They may be stale; see auth.OrgMemberships.
+func OrgMemberships(c *gin.Context) (*auth.OrgMemberships, bool) {
+	value, ok := c.Get(auth.OrgMembershipsKey)
+	if !ok {
+		return nil, false
+	}
+	memberships, ok := value.(*auth.OrgMemberships)
+	return memberships, ok && memberships != nil
+}
+
 func RequireRole(role string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("user_role")
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxBodySize caps request bodies at limit bytes. Requests declaring a
+// larger Content-Length are rejected with 413 up front; other bodies are
+// cut off at the limit, and handlers report the read error as 413.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error": errors.NewPayloadTooLargeError(limit),
+			})
+			c.Abort()
+			return
+		}
+
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+
+		c.Next()
+	}
+}
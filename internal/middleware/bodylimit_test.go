@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		body        string
+		chunked     bool
+		expected    int
+		handlerRuns bool
+	}{
+		{name: "within limit", body: `{"name": "ok"}`, expected: http.StatusOK, handlerRuns: true},
+		{name: "declared over limit", body: strings.Repeat("x", 65), expected: http.StatusRequestEntityTooLarge},
+		{name: "streamed over limit", body: strings.Repeat("x", 65), chunked: true, expected: http.StatusRequestEntityTooLarge, handlerRuns: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handlerRan := false
+			r := gin.New()
+			r.POST("/api/templates", MaxBodySize(64), func(c *gin.Context) {
+				handlerRan = true
+				var tooLarge *http.MaxBytesError
+				if _, err := io.ReadAll(c.Request.Body); errors.As(err, &tooLarge) {
+					c.Status(http.StatusRequestEntityTooLarge)
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/templates", strings.NewReader(tt.body))
+			if tt.chunked {
+				// An unknown length skips the up-front check
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d", tt.expected, w.Code)
+			}
+			if handlerRan != tt.handlerRuns {
+				t.Errorf("Expected handler to run: %v, ran: %v", tt.handlerRuns, handlerRan)
+			}
+		})
+	}
+}
@@ -2,11 +2,36 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
+
+	"dotfiles-api/internal/config"
 
 	"github.com/gin-gonic/gin"
 )
 
-func CORS(allowedOrigins []string) gin.HandlerFunc {
+// CORS returns a middleware applying the allowed origins and the methods,
+// headers and max-age from cfg. Empty settings fall back to config.DefaultCORSConfig.
+func CORS(allowedOrigins []string, cfg config.CORSConfig) gin.HandlerFunc {
+	defaults := config.DefaultCORSConfig()
+	if len(cfg.AllowedMethods) == 0 {
+		cfg.AllowedMethods = defaults.AllowedMethods
+	}
+	if len(cfg.AllowedHeaders) == 0 {
+		cfg.AllowedHeaders = defaults.AllowedHeaders
+	}
+	if len(cfg.ExposedHeaders) == 0 {
+		cfg.ExposedHeaders = defaults.ExposedHeaders
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = defaults.MaxAge
+	}
+
+	allowMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
 
@@ -24,10 +49,10 @@ func CORS(allowedOrigins []string) gin.HandlerFunc {
 			c.Header("Access-Control-Allow-Credentials", "false")
 		}
 
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-		c.Header("Access-Control-Expose-Headers", "Content-Length")
-		c.Header("Access-Control-Max-Age", "86400")
+		c.Header("Access-Control-Allow-Methods", allowMethods)
+		c.Header("Access-Control-Allow-Headers", allowHeaders)
+		c.Header("Access-Control-Expose-Headers", exposeHeaders)
+		c.Header("Access-Control-Max-Age", maxAge)
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(http.StatusNoContent)
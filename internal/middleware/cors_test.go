@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func preflight(t *testing.T, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(handler)
+	r.GET("/api/templates", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/templates", nil)
+	req.Header.Set("Origin", "https://dotfiles.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PATCH")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSPreflightUsesConfiguredValues(t *testing.T) {
+	w := preflight(t, CORS([]string{"*"}, config.CORSConfig{
+		AllowedMethods: []string{"GET", "PATCH"},
+		AllowedHeaders: []string{"Content-Type", "X-Custom-Header"},
+		ExposedHeaders: []string{"X-Total-Count"},
+		MaxAge:         10 * time.Minute,
+	}))
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+
+	expected := map[string]string{
+		"Access-Control-Allow-Methods":  "GET, PATCH",
+		"Access-Control-Allow-Headers":  "Content-Type, X-Custom-Header",
+		"Access-Control-Expose-Headers": "X-Total-Count",
+		"Access-Control-Max-Age":        "600",
+	}
+	for header, value := range expected {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("Expected %s %q, got %q", header, value, got)
+		}
+	}
+}
+
+func TestCORSPreflightDefaults(t *testing.T) {
+	w := preflight(t, CORS([]string{"*"}, config.CORSConfig{}))
+
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, PUT, PATCH, DELETE, OPTIONS" {
+		t.Errorf("Expected default methods, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "86400" {
+		t.Errorf("Expected default max-age 86400, got %q", got)
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireFeature rejects requests with 503 when the named feature is turned
+// off in config.FeatureConfig
+func RequireFeature(enabled bool, feature string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": errors.NewUnavailableError(feature + " are disabled on this server"),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type headRequestKey struct{}
+
+// ServeHeadAsGet answers HEAD requests with the GET route for the same path,
+// so every GET endpoint supports HEAD. The handler runs as for GET, but its
+// body is discarded and only counted, to report an accurate Content-Length.
+// Handlers with side effects, or that stream long bodies, check IsHead.
+func ServeHeadAsGet(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		get := r.Clone(context.WithValue(r.Context(), headRequestKey{}, true))
+		get.Method = http.MethodGet
+
+		head := &headWriter{ResponseWriter: w}
+		next.ServeHTTP(head, get)
+		head.finish()
+	})
+}
+
+// IsHead reports whether the request is a HEAD request being served by a GET
+// handler, which should then skip work that only affects the body
+func IsHead(c *gin.Context) bool {
+	head, _ := c.Request.Context().Value(headRequestKey{}).(bool)
+	return head
+}
+
+// headWriter holds back the status until the handler is done and drops the
+// body, counting its length
+type headWriter struct {
+	http.ResponseWriter
+	status int
+	length int64
+}
+
+func (w *headWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *headWriter) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	w.length += int64(len(data))
+	return len(data), nil
+}
+
+// Flush is a no-op: nothing is sent before the handler is done
+func (w *headWriter) Flush() {}
+
+// CloseNotify lets streaming handlers watch for the client going away
+func (w *headWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// finish sends the status and headers. A Content-Length the handler set is
+// kept; otherwise it is the length of the discarded body, unless the handler
+// wrote none, as when it skipped a stream.
+func (w *headWriter) finish() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.length > 0 && w.Header().Get("Content-Length") == "" {
+		w.Header().Set("Content-Length", strconv.FormatInt(w.length, 10))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
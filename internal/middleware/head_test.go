@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestServeHeadAsGet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	downloads := 0
+	r := gin.New()
+	r.GET("/api/templates/:id/download", func(c *gin.Context) {
+		if !IsHead(c) {
+			downloads++
+		}
+		c.JSON(http.StatusOK, gin.H{"brews": []string{"git", "neovim"}})
+	})
+	handler := ServeHeadAsGet(r)
+
+	get := httptest.NewRecorder()
+	handler.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/api/templates/abc/download", nil))
+
+	head := httptest.NewRecorder()
+	handler.ServeHTTP(head, httptest.NewRequest(http.MethodHead, "/api/templates/abc/download", nil))
+	if head.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", head.Code)
+	}
+	if head.Body.Len() != 0 {
+		t.Errorf("Expected no body, got %q", head.Body.String())
+	}
+	if want := strconv.Itoa(get.Body.Len()); head.Header().Get("Content-Length") != want {
+		t.Errorf("Expected Content-Length %s matching the GET body, got %q", want, head.Header().Get("Content-Length"))
+	}
+	if head.Header().Get("Content-Type") != get.Header().Get("Content-Type") {
+		t.Errorf("Expected the GET content type, got %q", head.Header().Get("Content-Type"))
+	}
+	if downloads != 1 {
+		t.Errorf("Expected only the GET to count, got %d downloads", downloads)
+	}
+}
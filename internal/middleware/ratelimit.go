@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -14,6 +16,8 @@ type RateLimiter struct {
 	mutex   sync.RWMutex
 	limit   int
 	window  time.Duration
+	exempt  map[string]bool
+	now     func() time.Time
 }
 
 type Client struct {
@@ -23,21 +27,64 @@ type Client struct {
 }
 
 func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
-	rl := &RateLimiter{
+	rl := newRateLimiter(limit, window, time.Now)
+
+	go rl.cleanup()
+	return rl
+}
+
+// newRateLimiter builds a limiter reading the time from now, without the
+// cleanup loop, so tests can step through windows
+func newRateLimiter(limit int, window time.Duration, now func() time.Time) *RateLimiter {
+	return &RateLimiter{
 		clients: make(map[string]*Client),
 		limit:   limit,
 		window:  window,
+		exempt:  make(map[string]bool),
+		now:     now,
 	}
+}
 
-	go rl.cleanup()
-	return rl
+// Exempt lets requests for the given paths, such as health checks, through
+// without counting them or adding rate limit headers
+func (rl *RateLimiter) Exempt(paths ...string) {
+	for _, path := range paths {
+		rl.exempt[path] = true
+	}
 }
 
+// Middleware limits requests per client IP. Responses carry the draft
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, and a
+// 429 also carries Retry-After. The headers are set before the handler runs,
+// since headers added after it has written the response are dropped.
 func (rl *RateLimiter) Middleware() gin.HandlerFunc {
+	return rl.MiddlewareFor(func(c *gin.Context) (string, bool) {
+		return c.ClientIP(), true
+	})
+}
+
+// MiddlewareFor limits requests per the key keyOf returns, such as an API
+// key ID. Requests for which keyOf returns false are not limited by rl.
+func (rl *RateLimiter) MiddlewareFor(keyOf func(c *gin.Context) (string, bool)) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
+		if rl.exempt[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
 
-		if !rl.allow(clientIP) {
+		key, ok := keyOf(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		allowed := rl.allow(key)
+
+		count, resetTime := rl.Snapshot(key)
+		reset := rl.secondsUntil(resetTime)
+		rl.setHeaders(c, rl.limit-count, reset)
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(reset))
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error": errors.NewRateLimitError("rate limit exceeded"),
 			})
@@ -49,25 +96,86 @@ func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 	}
 }
 
-func (rl *RateLimiter) allow(clientIP string) bool {
+// OnlyWrites runs limiter for POST, PUT, PATCH and DELETE requests and skips
+// it for reads
+func OnlyWrites(limiter gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			limiter(c)
+		default:
+			c.Next()
+		}
+	}
+}
+
+// Snapshot returns how many requests the client has made in its current
+// window and when that window ends. A client with no open window reports
+// zero requests and a window starting now.
+func (rl *RateLimiter) Snapshot(key string) (int, time.Time) {
+	now := rl.now()
+
 	rl.mutex.RLock()
-	client, exists := rl.clients[clientIP]
+	client, exists := rl.clients[key]
 	rl.mutex.RUnlock()
 
+	if !exists {
+		return 0, now.Add(rl.window)
+	}
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if now.After(client.resetTime) {
+		return 0, now.Add(rl.window)
+	}
+	return client.count, client.resetTime
+}
+
+// setHeaders reports this limiter's quota unless a limiter that ran earlier
+// already reported fewer remaining requests, so clients see the limit they
+// will hit first
+func (rl *RateLimiter) setHeaders(c *gin.Context, remaining, reset int) {
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	if previous := c.Writer.Header().Get("RateLimit-Remaining"); previous != "" {
+		if n, err := strconv.Atoi(previous); err == nil && n <= remaining {
+			return
+		}
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(rl.limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(reset))
+}
+
+// secondsUntil rounds up, so clients never retry before the window ends
+func (rl *RateLimiter) secondsUntil(t time.Time) int {
+	seconds := math.Ceil(t.Sub(rl.now()).Seconds())
+	if seconds < 0 {
+		return 0
+	}
+	return int(seconds)
+}
+
+func (rl *RateLimiter) allow(clientIP string) bool {
+	rl.mutex.Lock()
+	client, exists := rl.clients[clientIP]
 	if !exists {
 		client = &Client{
 			count:     0,
-			resetTime: time.Now().Add(rl.window),
+			resetTime: rl.now().Add(rl.window),
 		}
-		rl.mutex.Lock()
 		rl.clients[clientIP] = client
-		rl.mutex.Unlock()
 	}
+	rl.mutex.Unlock()
 
 	client.mutex.Lock()
 	defer client.mutex.Unlock()
 
-	now := time.Now()
+	now := rl.now()
 	if now.After(client.resetTime) {
 		client.count = 0
 		client.resetTime = now.Add(rl.window)
@@ -88,7 +196,7 @@ func (rl *RateLimiter) cleanup() {
 	for {
 		select {
 		case <-ticker.C:
-			now := time.Now()
+			now := rl.now()
 			rl.mutex.Lock()
 			for ip, client := range rl.clients {
 				client.mutex.Lock()
@@ -100,4 +208,4 @@ func (rl *RateLimiter) cleanup() {
 			rl.mutex.Unlock()
 		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,245 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeClock is a settable time source for stepping through rate limit
+// windows
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func TestRateLimiterHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := newRateLimiter(3, time.Minute, clock.Now)
+	limiter.Exempt("/health")
+
+	r := gin.New()
+	r.Use(limiter.Middleware())
+	r.GET("/api/templates", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	steps := []struct {
+		advance   time.Duration
+		code      int
+		remaining string
+		reset     string
+	}{
+		{code: http.StatusOK, remaining: "2", reset: "60"},
+		{advance: 10 * time.Second, code: http.StatusOK, remaining: "1", reset: "50"},
+		{advance: 500 * time.Millisecond, code: http.StatusOK, remaining: "0", reset: "50"},
+		{advance: 20 * time.Second, code: http.StatusTooManyRequests, remaining: "0", reset: "30"},
+		// The window ends 60s after the first request
+		{advance: 30 * time.Second, code: http.StatusOK, remaining: "2", reset: "60"},
+	}
+
+	for i, step := range steps {
+		clock.now = clock.now.Add(step.advance)
+		w := get("/api/templates")
+
+		if w.Code != step.code {
+			t.Fatalf("Request %d: expected status %d, got %d", i+1, step.code, w.Code)
+		}
+		if got := w.Header().Get("RateLimit-Limit"); got != "3" {
+			t.Errorf("Request %d: expected RateLimit-Limit 3, got %q", i+1, got)
+		}
+		if got := w.Header().Get("RateLimit-Remaining"); got != step.remaining {
+			t.Errorf("Request %d: expected RateLimit-Remaining %s, got %q", i+1, step.remaining, got)
+		}
+		if got := w.Header().Get("RateLimit-Reset"); got != step.reset {
+			t.Errorf("Request %d: expected RateLimit-Reset %s, got %q", i+1, step.reset, got)
+		}
+
+		wantRetry := ""
+		if step.code == http.StatusTooManyRequests {
+			wantRetry = step.reset
+		}
+		if got := w.Header().Get("Retry-After"); got != wantRetry {
+			t.Errorf("Request %d: expected Retry-After %q, got %q", i+1, wantRetry, got)
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		w := get("/health")
+		if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("Expected exempt paths to pass without headers, got %d %v", w.Code, w.Header())
+		}
+	}
+	if count, _ := limiter.Snapshot("192.0.2.1"); count != 1 {
+		t.Errorf("Expected exempt requests not to be counted, got %d", count)
+	}
+}
+
+func TestRateLimiterSnapshot(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	limiter := newRateLimiter(5, time.Minute, clock.Now)
+
+	count, reset := limiter.Snapshot("unknown")
+	if count != 0 || !reset.Equal(clock.now.Add(time.Minute)) {
+		t.Errorf("Expected an empty window for an unknown client, got %d, %v", count, reset)
+	}
+
+	limiter.allow("client")
+	limiter.allow("client")
+	start := clock.now
+
+	clock.now = clock.now.Add(30 * time.Second)
+	count, reset = limiter.Snapshot("client")
+	if count != 2 || !reset.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected 2 requests resetting at %v, got %d, %v", start.Add(time.Minute), count, reset)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	if count, _ := limiter.Snapshot("client"); count != 0 {
+		t.Errorf("Expected the count to reset after the window, got %d", count)
+	}
+}
+
+func TestOnlyWritesAndStackedLimiters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	global := newRateLimiter(10, time.Hour, clock.Now)
+	writes := newRateLimiter(2, time.Minute, clock.Now)
+
+	r := gin.New()
+	r.Use(global.Middleware(), OnlyWrites(writes.Middleware()))
+	r.GET("/api/templates", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.POST("/api/templates", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	send := func(method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/templates", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodGet)
+	if w.Header().Get("RateLimit-Limit") != "10" || w.Header().Get("RateLimit-Remaining") != "9" {
+		t.Errorf("Expected reads to report the global limit, got %v", w.Header())
+	}
+	if count, _ := writes.Snapshot("192.0.2.1"); count != 0 {
+		t.Errorf("Expected reads to skip the write limiter, got %d", count)
+	}
+
+	w = send(http.MethodPost)
+	if w.Code != http.StatusCreated || w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != "1" {
+		t.Errorf("Expected writes to report the tighter write limit, got %d %v", w.Code, w.Header())
+	}
+
+	send(http.MethodPost)
+	if w := send(http.MethodPost); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the write limit to reject the third write, got %d", w.Code)
+	}
+	if w := send(http.MethodGet); w.Code != http.StatusOK {
+		t.Errorf("Expected reads to keep working once writes are limited, got %d", w.Code)
+	}
+}
+
+func TestRateLimiterTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tt := range []struct {
+		name    string
+		proxies []string
+		want    []int
+	}{
+		// Clients behind a trusted proxy get a limit each
+		{name: "trusted", proxies: []string{"10.0.0.0/8"}, want: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+		// Otherwise they all share the proxy's
+		{name: "untrusted", proxies: nil, want: []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+			limiter := newRateLimiter(1, time.Minute, clock.Now)
+
+			r := gin.New()
+			if err := r.SetTrustedProxies(tt.proxies); err != nil {
+				t.Fatalf("Failed to set trusted proxies: %v", err)
+			}
+			r.Use(limiter.Middleware())
+			r.GET("/api/templates", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			for i, client := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.1"} {
+				req := httptest.NewRequest(http.MethodGet, "/api/templates", nil)
+				req.RemoteAddr = "10.1.2.3:1234"
+				req.Header.Set("X-Forwarded-For", client)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				if w.Code != tt.want[i] {
+					t.Errorf("Request %d from %s: expected status %d, got %d", i+1, client, tt.want[i], w.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterPerAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	perIP := newRateLimiter(1, time.Minute, clock.Now)
+	perKey := newRateLimiter(2, time.Minute, clock.Now)
+
+	r := gin.New()
+	// Stands in for APIKeyAuth
+	r.Use(func(c *gin.Context) {
+		if key := c.GetHeader("X-Test-Key"); key != "" {
+			c.Set(APIKeyIDKey, key)
+		}
+		c.Next()
+	})
+	r.Use(perIP.MiddlewareFor(ByClientIP), perKey.MiddlewareFor(ByAPIKey))
+	r.GET("/api/templates", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	get := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/templates", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if key != "" {
+			req.Header.Set("X-Test-Key", key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Each key has its own quota, apart from the IP's
+	want := []struct {
+		key  string
+		code int
+	}{
+		{"", http.StatusOK},
+		{"", http.StatusTooManyRequests},
+		{"key-1", http.StatusOK},
+		{"key-1", http.StatusOK},
+		{"key-1", http.StatusTooManyRequests},
+		{"key-2", http.StatusOK},
+	}
+	for i, step := range want {
+		if got := get(step.key); got != step.code {
+			t.Errorf("Request %d (key %q): expected %d, got %d", i+1, step.key, step.code, got)
+		}
+	}
+}
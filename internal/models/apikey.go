@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// APIKey is a long-lived credential a user creates for scripts and CI. Only
+// a hash of the key is stored; the key itself is shown once, on creation.
+type APIKey struct {
+	ID        string     `json:"id" bson:"_id"`
+	UserID    string     `json:"user_id" bson:"user_id"`
+	Name      string     `json:"name" bson:"name"`
+	KeyHash   string     `json:"-" bson:"key_hash"`
+	LastUsed  *time.Time `json:"last_used,omitempty" bson:"last_used,omitempty"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+}
+
+// Expired reports whether the key has passed its expiry at now
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
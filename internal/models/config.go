@@ -22,6 +22,7 @@ type StoredConfig struct {
 	Config        ShareableConfig `json:"config" bson:"config"`
 	Public        bool            `json:"public" bson:"public"`
 	CreatedAt     time.Time       `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at" bson:"updated_at"`
 	DownloadCount int             `json:"download_count" bson:"download_count"`
 	OwnerID       string          `json:"owner_id" bson:"owner_id"`
 }
@@ -31,4 +32,7 @@ type ConfigStats struct {
 	TotalConfigs   int `json:"total_configs"`
 	PublicConfigs  int `json:"public_configs"`
 	TotalDownloads int `json:"total_downloads"`
-}
\ No newline at end of file
+	// Downloads recorded over the last week and month
+	DownloadsLast7Days  int `json:"downloads_last_7_days"`
+	DownloadsLast30Days int `json:"downloads_last_30_days"`
+}
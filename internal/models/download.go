@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Kinds of resource a download event can record
+const (
+	DownloadResourceTemplate = "template"
+	DownloadResourceConfig   = "config"
+)
+
+// Platforms a download event can record, parsed from the downloader's
+// User-Agent
+const (
+	PlatformMacARM64   = "mac_arm64"
+	PlatformMacX86     = "mac_x86"
+	PlatformLinuxARM64 = "linux_arm64"
+	PlatformLinuxX86   = "linux_x86"
+)
+
+// DownloadEvent records a single download of a template or config. Lifetime
+// counts live on the resource itself; events allow counting recent windows.
+type DownloadEvent struct {
+	ID           string    `json:"id" bson:"_id"`
+	ResourceType string    `json:"resource_type" bson:"resource_type"`
+	ResourceID   string    `json:"resource_id" bson:"resource_id"`
+	At           time.Time `json:"at" bson:"at"`
+	// Platform is one of the Platform constants, or empty when the
+	// User-Agent did not name one
+	Platform string `json:"platform,omitempty" bson:"platform,omitempty"`
+}
+
+// ResourceDownloads counts the download events of one resource
+type ResourceDownloads struct {
+	ResourceID string `json:"resource_id" bson:"_id"`
+	Downloads  int    `json:"downloads" bson:"downloads"`
+}
+
+// DailyDownloads counts the downloads of one resource on a UTC day
+type DailyDownloads struct {
+	Date      string `json:"date"`
+	Downloads int    `json:"downloads"`
+}
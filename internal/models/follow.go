@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Follow records that one user follows another
+type Follow struct {
+	ID         string    `json:"id" bson:"_id"`
+	FollowerID string    `json:"follower_id" bson:"follower_id"`
+	FolloweeID string    `json:"followee_id" bson:"followee_id"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+}
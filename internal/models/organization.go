@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
 
 // Organization represents an organization that can own templates
 type Organization struct {
@@ -14,6 +18,28 @@ type Organization struct {
 	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
 	MemberCount int       `json:"member_count" bson:"member_count"`
+	MaxMembers  int       `json:"max_members" bson:"max_members"` // 0 means unlimited
+
+	// CustomDomain is set by a site admin and only routes to the
+	// organization once DomainVerifiedAt is set by the TXT-record challenge
+	CustomDomain     string     `json:"custom_domain,omitempty" bson:"custom_domain,omitempty"`
+	DomainToken      string     `json:"-" bson:"domain_token,omitempty"`
+	DomainVerifiedAt *time.Time `json:"domain_verified_at,omitempty" bson:"domain_verified_at,omitempty"`
+
+	// PinnedTemplateIDs are the templates the organization features, in
+	// display order
+	PinnedTemplateIDs []string `json:"pinned_template_ids,omitempty" bson:"pinned_template_ids,omitempty"`
+
+	// AllowJoinRequests lets users ask to join a public organization
+	// without an invite. Off by default so closed organizations are not
+	// flooded with requests.
+	AllowJoinRequests bool `json:"allow_join_requests" bson:"allow_join_requests,omitempty"`
+}
+
+// DomainVerified reports whether the organization's custom domain passed the
+// ownership challenge
+func (o *Organization) DomainVerified() bool {
+	return o.CustomDomain != "" && o.DomainVerifiedAt != nil
 }
 
 // OrganizationMember represents a user's membership in an organization
@@ -25,19 +51,53 @@ type OrganizationMember struct {
 	JoinedAt       time.Time `json:"joined_at" bson:"joined_at"`
 }
 
-// OrganizationInvite represents an invitation to join an organization
+// OrganizationInvite represents an invitation to join an organization. Only
+// the token's hash is stored; the token itself is handed out once, when the
+// invite is created or resent.
 type OrganizationInvite struct {
 	ID             string    `json:"id" bson:"_id"`
 	OrganizationID string    `json:"organization_id" bson:"organization_id"`
 	Email          string    `json:"email" bson:"email"`
 	Role           string    `json:"role" bson:"role"`
-	Token          string    `json:"token" bson:"token"`
+	Token          string    `json:"token,omitempty" bson:"-"`
+	TokenHash      string    `json:"-" bson:"token_hash"`
 	InvitedBy      string    `json:"invited_by" bson:"invited_by"`
 	CreatedAt      time.Time `json:"created_at" bson:"created_at"`
 	ExpiresAt      time.Time `json:"expires_at" bson:"expires_at"`
 	AcceptedAt     *time.Time `json:"accepted_at,omitempty" bson:"accepted_at,omitempty"`
 }
 
+// Join request statuses. A user may hold one pending request per
+// organization.
+const (
+	JoinRequestPending  = "pending"
+	JoinRequestApproved = "approved"
+	JoinRequestRejected = "rejected"
+)
+
+// OrganizationJoinRequest is a user's request to join a public organization,
+// for its owners and admins to approve or reject
+type OrganizationJoinRequest struct {
+	ID             string     `json:"id" bson:"_id"`
+	OrganizationID string     `json:"organization_id" bson:"organization_id"`
+	UserID         string     `json:"user_id" bson:"user_id"`
+	Username       string     `json:"username" bson:"username"`
+	Message        string     `json:"message,omitempty" bson:"message,omitempty"`
+	Status         string     `json:"status" bson:"status"`
+	CreatedAt      time.Time  `json:"created_at" bson:"created_at"`
+	ResolvedBy     string     `json:"resolved_by,omitempty" bson:"resolved_by,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty" bson:"resolved_at,omitempty"`
+	// Reason is the optional explanation given with a rejection
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"`
+}
+
+// HashInviteToken returns the hex SHA-256 of an invite token, which is what
+// stores look invites up by
+func HashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // OrganizationRole constants
 const (
 	RoleOwner  = "owner"
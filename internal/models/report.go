@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Report reasons
+const (
+	ReportReasonMalicious = "malicious"
+	ReportReasonSpam      = "spam"
+	ReportReasonBroken    = "broken"
+	ReportReasonOther     = "other"
+)
+
+// Report statuses. A user may hold one open report per template.
+const (
+	ReportStatusOpen     = "open"
+	ReportStatusResolved = "resolved"
+)
+
+// Actions a site admin resolves a template's reports with
+const (
+	ReportActionDismiss        = "dismiss"
+	ReportActionUnlistTemplate = "unlist_template"
+	ReportActionDeleteTemplate = "delete_template"
+)
+
+// TemplateReport is a user's flag on a template for site admins to review
+type TemplateReport struct {
+	ID               string     `json:"id" bson:"_id"`
+	TemplateID       string     `json:"template_id" bson:"template_id"`
+	ReporterID       string     `json:"reporter_id" bson:"reporter_id"`
+	ReporterUsername string     `json:"reporter_username" bson:"reporter_username"`
+	Reason           string     `json:"reason" bson:"reason"`
+	Details          string     `json:"details,omitempty" bson:"details,omitempty"`
+	Status           string     `json:"status" bson:"status"`
+	CreatedAt        time.Time  `json:"created_at" bson:"created_at"`
+	Action           string     `json:"action,omitempty" bson:"action,omitempty"`
+	ResolvedBy       string     `json:"resolved_by,omitempty" bson:"resolved_by,omitempty"`
+	ResolvedAt       *time.Time `json:"resolved_at,omitempty" bson:"resolved_at,omitempty"`
+}
+
+// AuditEntry records an action a site admin took
+type AuditEntry struct {
+	ID         string    `json:"id" bson:"_id"`
+	Actor      string    `json:"actor" bson:"actor"`
+	Action     string    `json:"action" bson:"action"`
+	TargetType string    `json:"target_type" bson:"target_type"`
+	TargetID   string    `json:"target_id" bson:"target_id"`
+	Details    string    `json:"details,omitempty" bson:"details,omitempty"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+}
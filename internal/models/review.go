@@ -14,6 +14,13 @@ type Review struct {
 	Helpful    int       `json:"helpful" bson:"helpful"` // helpful votes count
 	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at" bson:"updated_at"`
+
+	// Language is the ISO 639-1 code detected from the comment, or "und"
+	// when it cannot be told. Reviews written before detection have none.
+	Language string `json:"language" bson:"language,omitempty"`
+
+	// AvatarProxyURL is filled in for responses and never stored
+	AvatarProxyURL string `json:"avatar_proxy_url,omitempty" bson:"-"`
 }
 
 // IsValidRating checks if the rating is within valid range (1-5)
@@ -2,6 +2,10 @@ package models
 
 import "time"
 
+// AnonymousAuthor is the author of templates created without signing in.
+// The parentheses keep it from matching any GitHub username.
+const AnonymousAuthor = "(anonymous)"
+
 // ShareMetadata contains metadata for shareable configs and templates
 type ShareMetadata struct {
 	Name        string    `json:"name"`
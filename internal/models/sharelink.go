@@ -0,0 +1,36 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// TemplateShareLink lets anyone holding its token see a template, whatever
+// its visibility, until the link expires or runs out of uses. Only the
+// token's hash is stored; the token itself is handed out once, when the
+// link is created.
+type TemplateShareLink struct {
+	ID         string    `json:"id" bson:"_id"`
+	TemplateID string    `json:"template_id" bson:"template_id"`
+	Token      string    `json:"token,omitempty" bson:"-"`
+	TokenHash  string    `json:"-" bson:"token_hash"`
+	CreatedBy  string    `json:"created_by" bson:"created_by"`
+	CreatedAt  time.Time `json:"created_at" bson:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at" bson:"expires_at"`
+	// MaxUses bounds how many times the link resolves; 0 is unlimited
+	MaxUses int `json:"max_uses,omitempty" bson:"max_uses"`
+	Uses    int `json:"uses" bson:"uses"`
+}
+
+// Usable reports whether the link is unexpired and has uses left at now
+func (l *TemplateShareLink) Usable(now time.Time) bool {
+	return now.Before(l.ExpiresAt) && (l.MaxUses == 0 || l.Uses < l.MaxUses)
+}
+
+// HashShareLinkToken returns the hex SHA-256 of a share link token, which is
+// what stores look links up by
+func HashShareLinkToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TagSubscription holds the canonical tags a user follows. The digest sends
+// the user public templates created after LastNotifiedAt that carry any of
+// the tags.
+type TagSubscription struct {
+	UserID         string    `json:"user_id" bson:"_id"`
+	Tags           []string  `json:"tags" bson:"tags"`
+	CreatedAt      time.Time `json:"created_at" bson:"created_at"`
+	LastNotifiedAt time.Time `json:"last_notified_at" bson:"last_notified_at"`
+}
@@ -18,21 +18,52 @@ type Hooks struct {
 	PostStow    []string `json:"post_stow,omitempty" bson:"post_stow,omitempty"`
 }
 
+// Template schema versions, by the newest features a template uses. CLIs
+// that predate a schema silently drop the fields it added.
+const (
+	// SchemaVersionPlain templates use only package lists
+	SchemaVersionPlain = 1
+	// SchemaVersionHooks templates use hooks or package_configs
+	SchemaVersionHooks = 2
+	// SchemaVersionPins is reserved for requirements and package version
+	// pins, which templates cannot carry yet
+	SchemaVersionPins = 3
+)
+
 // Template represents a dotfiles template
 type Template struct {
 	Taps           []string                 `json:"taps" bson:"taps"`
 	Brews          []string                 `json:"brews" bson:"brews"`
 	Casks          []string                 `json:"casks" bson:"casks"`
 	Stow           []string                 `json:"stow" bson:"stow"`
+	AptPackages    []string                 `json:"apt_packages,omitempty" bson:"apt_packages,omitempty"`
+	PipPackages    []string                 `json:"pip_packages,omitempty" bson:"pip_packages,omitempty"`
 	Metadata       ShareMetadata            `json:"metadata" bson:"metadata"`
 	Extends        string                   `json:"extends,omitempty" bson:"extends"`
 	Overrides      []string                 `json:"overrides,omitempty" bson:"overrides"`
-	AddOnly        bool                     `json:"addOnly" bson:"add_only"`
+	AddOnly        bool                     `json:"add_only" bson:"add_only"`
 	Public         bool                     `json:"public" bson:"public"`
 	Featured       bool                     `json:"featured" bson:"featured"`
 	OrganizationID string                   `json:"organization_id,omitempty" bson:"organization_id,omitempty"`
 	Hooks          *Hooks                   `json:"hooks,omitempty" bson:"hooks,omitempty"`
 	PackageConfigs map[string]PackageConfig `json:"package_configs,omitempty" bson:"package_configs,omitempty"`
+	SourceRepo     *SourceRepo              `json:"source_repo,omitempty" bson:"source_repo,omitempty"`
+}
+
+// SchemaVersion returns the oldest schema that can represent the template
+func (t Template) SchemaVersion() int {
+	if t.Hooks != nil || len(t.PackageConfigs) > 0 {
+		return SchemaVersionHooks
+	}
+	return SchemaVersionPlain
+}
+
+// SourceRepo records the GitHub file a template was imported from
+type SourceRepo struct {
+	Repo     string    `json:"repo" bson:"repo"`
+	Ref      string    `json:"ref" bson:"ref"`
+	Path     string    `json:"path" bson:"path"`
+	SyncedAt time.Time `json:"synced_at" bson:"synced_at"`
 }
 
 // TemplateMetadata contains template metadata
@@ -46,6 +77,13 @@ type TemplateMetadata struct {
 	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
 }
 
+// Reasons a template is unlisted. Dismissing reports only lists a template
+// again when reports unlisted it.
+const (
+	UnlistReasonReports    = "reports"
+	UnlistReasonModeration = "moderation"
+)
+
 // StoredTemplate represents a template stored in the database
 type StoredTemplate struct {
 	ID        string    `json:"id" bson:"_id"`
@@ -53,6 +91,37 @@ type StoredTemplate struct {
 	CreatedAt time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
 	Downloads int       `json:"downloads" bson:"downloads"`
+	// SchemaVersion is set from Template.SchemaVersion whenever the
+	// template is saved
+	SchemaVersion int `json:"schema_version" bson:"schema_version"`
+	// PopularityScore is recomputed periodically from downloads, ratings,
+	// favorites and age; see the popularity package
+	PopularityScore float64 `json:"popularity_score" bson:"popularity_score"`
+	// Unlisted templates are hidden from listings, search and featured
+	// templates while site admins review reports against them, but stay
+	// reachable by ID
+	Unlisted bool `json:"unlisted" bson:"unlisted,omitempty"`
+	// UnlistedReason records why an unlisted template was hidden, one of
+	// the UnlistReason constants
+	UnlistedReason string `json:"unlisted_reason,omitempty" bson:"unlisted_reason,omitempty"`
+	// Images are the template's screenshots, in display order
+	Images []TemplateImage `json:"images,omitempty" bson:"images,omitempty"`
+	// AllowedUsers lists the usernames a private template is shared with
+	// besides its author and organization
+	AllowedUsers []string `json:"allowed_users,omitempty" bson:"allowed_users,omitempty"`
+}
+
+// TemplateImage is a screenshot uploaded for a template. ID is the stored
+// file's name, which is derived from the image's content.
+type TemplateImage struct {
+	ID          string    `json:"id" bson:"id"`
+	URL         string    `json:"url" bson:"url"`
+	ContentType string    `json:"content_type" bson:"content_type"`
+	Width       int       `json:"width" bson:"width"`
+	Height      int       `json:"height" bson:"height"`
+	Size        int64     `json:"size" bson:"size"`
+	Caption     string    `json:"caption,omitempty" bson:"caption,omitempty"`
+	UploadedAt  time.Time `json:"uploaded_at" bson:"uploaded_at"`
 }
 
 // TemplateStats contains template statistics
@@ -69,4 +138,5 @@ type TemplateRating struct {
 	AverageRating  float64            `json:"average_rating"`
 	TotalRatings   int                `json:"total_ratings"`
 	Distribution   map[string]int     `json:"distribution"` // rating -> count
+	Languages      map[string]int     `json:"languages"`    // review language -> count
 }
\ No newline at end of file
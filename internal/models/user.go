@@ -4,20 +4,89 @@ import "time"
 
 // User represents a system user
 type User struct {
-	ID          string    `json:"id" bson:"_id"`
-	GitHubID    int       `json:"github_id" bson:"github_id"`
-	Username    string    `json:"username" bson:"username"`
-	Name        string    `json:"name" bson:"name"`
-	Email       string    `json:"email" bson:"email"`
-	AvatarURL   string    `json:"avatar_url" bson:"avatar_url"`
-	Bio         string    `json:"bio" bson:"bio"`
-	Location    string    `json:"location" bson:"location"`
-	Website     string    `json:"website" bson:"website"`
-	Company     string    `json:"company" bson:"company"`
-	CreatedAt   time.Time `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" bson:"updated_at"`
-	Favorites   []string  `json:"favorites" bson:"favorites"`
-	Collections []string  `json:"collections" bson:"collections"`
+	ID          string     `json:"id" bson:"_id"`
+	GitHubID    int        `json:"github_id" bson:"github_id"`
+	Username    string     `json:"username" bson:"username"`
+	Name        string     `json:"name" bson:"name"`
+	Email       string     `json:"email" bson:"email"`
+	AvatarURL   string     `json:"avatar_url" bson:"avatar_url"`
+	Bio         string     `json:"bio" bson:"bio"`
+	Location    string     `json:"location" bson:"location"`
+	Website     string     `json:"website" bson:"website"`
+	Company     string     `json:"company" bson:"company"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" bson:"updated_at"`
+	Favorites   []string   `json:"favorites" bson:"favorites"`
+	Collections []string   `json:"collections" bson:"collections"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	// DownloadHistory lists the templates the user downloaded, newest
+	// first, capped at MaxDownloadHistory
+	DownloadHistory []DownloadHistoryItem `json:"download_history,omitempty" bson:"download_history,omitempty"`
+	// UsernameLower is Username lowercased, for case-insensitive lookups
+	UsernameLower string `json:"-" bson:"username_lower,omitempty"`
+	// Identities lists the OAuth provider accounts the user signs in with.
+	// GitHubID mirrors the GitHub identity, if any.
+	Identities []Identity `json:"identities,omitempty" bson:"identities,omitempty"`
+	// MembershipVersion changes whenever the user's organization
+	// memberships do, so sessions caching their roles can tell they are stale
+	MembershipVersion int64 `json:"-" bson:"membership_version,omitempty"`
+}
+
+// Identity links a user to an account at an OAuth provider
+type Identity struct {
+	Provider   string `json:"provider" bson:"provider"`
+	ExternalID string `json:"external_id" bson:"external_id"`
+}
+
+// HasIdentity reports whether the user is linked to the provider account
+func (u *User) HasIdentity(provider, externalID string) bool {
+	for _, identity := range u.Identities {
+		if identity.Provider == provider && identity.ExternalID == externalID {
+			return true
+		}
+	}
+	return false
+}
+
+// IdentityFor returns the user's identity at a provider, if linked
+func (u *User) IdentityFor(provider string) (Identity, bool) {
+	for _, identity := range u.Identities {
+		if identity.Provider == provider {
+			return identity, true
+		}
+	}
+	return Identity{}, false
+}
+
+// MaxDownloadHistory caps how many downloads User.DownloadHistory keeps
+const MaxDownloadHistory = 50
+
+// DownloadHistoryItem records one template download
+type DownloadHistoryItem struct {
+	TemplateID   string    `json:"template_id" bson:"template_id"`
+	DownloadedAt time.Time `json:"downloaded_at" bson:"downloaded_at"`
+}
+
+// RecordDownload prepends a download to the user's history, dropping the
+// oldest entries past MaxDownloadHistory
+func (u *User) RecordDownload(templateID string, at time.Time) {
+	history := make([]DownloadHistoryItem, 0, MaxDownloadHistory)
+	history = append(history, DownloadHistoryItem{TemplateID: templateID, DownloadedAt: at})
+	history = append(history, u.DownloadHistory...)
+	if len(history) > MaxDownloadHistory {
+		history = history[:MaxDownloadHistory]
+	}
+	u.DownloadHistory = history
+}
+
+// IsDeleted reports whether the user has been soft-deleted
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != nil
+}
+
+// DeletedUsername is the placeholder username given to a soft-deleted user
+func DeletedUsername(id string) string {
+	return "deleted-" + id
 }
 
 // UserProfile represents a user's public profile information
@@ -0,0 +1,97 @@
+// Package pagination builds the JSON:API style links returned with every
+// paginated list.
+package pagination
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// UnknownTotal is passed as the total when a handler cannot count every
+// matching item. Links then omit last and always offer next.
+const UnknownTotal = -1
+
+// PaginationLinks holds the URLs of the neighbouring pages. Prev, Next and
+// Last are null when there is no such page.
+type PaginationLinks struct {
+	First string  `json:"first"`
+	Prev  *string `json:"prev"`
+	Next  *string `json:"next"`
+	Last  *string `json:"last"`
+}
+
+// BuildPaginationLinks returns the links for the page at offset. base is the
+// request path plus any other query parameters, as returned by BaseURL.
+func BuildPaginationLinks(base string, offset, limit, total int) PaginationLinks {
+	if limit <= 0 {
+		limit = 1
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	links := PaginationLinks{
+		First: pageURL(base, 0, limit),
+	}
+
+	if offset > 0 {
+		prev := offset - limit
+		if prev < 0 {
+			prev = 0
+		}
+		links.Prev = stringPtr(pageURL(base, prev, limit))
+	}
+
+	if total == UnknownTotal {
+		links.Next = stringPtr(pageURL(base, offset+limit, limit))
+		return links
+	}
+
+	if offset+limit < total {
+		links.Next = stringPtr(pageURL(base, offset+limit, limit))
+	}
+
+	last := 0
+	if total > 0 {
+		last = (total - 1) / limit * limit
+	}
+	links.Last = stringPtr(pageURL(base, last, limit))
+
+	return links
+}
+
+// PageTotal works out the total from a page of count items when the
+// repository cannot count matches. A short page is the last one, so the total
+// is exact; a full page may have more after it.
+func PageTotal(offset, limit, count int) int {
+	if count < limit {
+		return offset + count
+	}
+	return UnknownTotal
+}
+
+// BaseURL returns the path and query of u without the offset and limit
+// parameters, ready to pass to BuildPaginationLinks
+func BaseURL(u *url.URL) string {
+	query := u.Query()
+	query.Del("offset")
+	query.Del("limit")
+
+	if encoded := query.Encode(); encoded != "" {
+		return u.Path + "?" + encoded
+	}
+	return u.Path
+}
+
+func pageURL(base string, offset, limit int) string {
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	return base + separator + "offset=" + strconv.Itoa(offset) + "&limit=" + strconv.Itoa(limit)
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
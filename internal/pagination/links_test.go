@@ -0,0 +1,117 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+)
+
+func deref(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
+
+func TestBuildPaginationLinks(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		limit  int
+		total  int
+		prev   string
+		next   string
+		last   string
+	}{
+		{
+			name:  "first page",
+			limit: 10, total: 100,
+			prev: "<nil>",
+			next: "/api/templates?offset=10&limit=10",
+			last: "/api/templates?offset=90&limit=10",
+		},
+		{
+			name:   "middle page",
+			offset: 40, limit: 10, total: 100,
+			prev: "/api/templates?offset=30&limit=10",
+			next: "/api/templates?offset=50&limit=10",
+			last: "/api/templates?offset=90&limit=10",
+		},
+		{
+			name:   "last page",
+			offset: 90, limit: 10, total: 100,
+			prev: "/api/templates?offset=80&limit=10",
+			next: "<nil>",
+			last: "/api/templates?offset=90&limit=10",
+		},
+		{
+			name:   "partial last page",
+			offset: 0, limit: 10, total: 95,
+			prev: "<nil>",
+			next: "/api/templates?offset=10&limit=10",
+			last: "/api/templates?offset=90&limit=10",
+		},
+		{
+			name:   "offset not aligned to limit",
+			offset: 5, limit: 10, total: 100,
+			prev: "/api/templates?offset=0&limit=10",
+			next: "/api/templates?offset=15&limit=10",
+			last: "/api/templates?offset=90&limit=10",
+		},
+		{
+			name:  "empty",
+			limit: 10, total: 0,
+			prev: "<nil>",
+			next: "<nil>",
+			last: "/api/templates?offset=0&limit=10",
+		},
+		{
+			name:   "unknown total",
+			offset: 10, limit: 10, total: UnknownTotal,
+			prev: "/api/templates?offset=0&limit=10",
+			next: "/api/templates?offset=20&limit=10",
+			last: "<nil>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := BuildPaginationLinks("/api/templates", tt.offset, tt.limit, tt.total)
+
+			if links.First != "/api/templates?offset=0&limit=10" {
+				t.Errorf("Expected first %q, got %q", "/api/templates?offset=0&limit=10", links.First)
+			}
+			if got := deref(links.Prev); got != tt.prev {
+				t.Errorf("Expected prev %q, got %q", tt.prev, got)
+			}
+			if got := deref(links.Next); got != tt.next {
+				t.Errorf("Expected next %q, got %q", tt.next, got)
+			}
+			if got := deref(links.Last); got != tt.last {
+				t.Errorf("Expected last %q, got %q", tt.last, got)
+			}
+		})
+	}
+}
+
+func TestBaseURLKeepsFilters(t *testing.T) {
+	u, _ := url.Parse("/api/configs?owner=alice&offset=20&limit=5&sort_by=download_count")
+
+	base := BaseURL(u)
+	if base != "/api/configs?owner=alice&sort_by=download_count" {
+		t.Fatalf("Expected filters without paging params, got %q", base)
+	}
+
+	links := BuildPaginationLinks(base, 20, 5, 30)
+	if want := "/api/configs?owner=alice&sort_by=download_count&offset=25&limit=5"; deref(links.Next) != want {
+		t.Errorf("Expected next %q, got %q", want, deref(links.Next))
+	}
+}
+
+func TestPageTotal(t *testing.T) {
+	if got := PageTotal(20, 10, 4); got != 24 {
+		t.Errorf("Expected a short page to give an exact total of 24, got %d", got)
+	}
+	if got := PageTotal(20, 10, 10); got != UnknownTotal {
+		t.Errorf("Expected a full page to give UnknownTotal, got %d", got)
+	}
+}
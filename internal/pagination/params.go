@@ -0,0 +1,66 @@
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// DefaultLimit is the page size used when the request gives none
+	DefaultLimit = 10
+	// MaxLimit is the largest page size served
+	MaxLimit = 100
+	// MaxExportLimit is the largest page size served as a CSV export
+	MaxExportLimit = 1000
+)
+
+// Params is the page a request asked for, after adjustment. Warnings says
+// why the limit or offset differs from what was requested, so the change is
+// not silent.
+type Params struct {
+	Limit    int
+	Offset   int
+	Warnings []string
+}
+
+// Clamped reports whether the requested limit or offset was adjusted
+func (p Params) Clamped() bool {
+	return len(p.Warnings) > 0
+}
+
+// ParseParams reads the limit and offset query parameters. Missing values
+// take their defaults quietly; invalid or out of range ones are replaced and
+// produce a warning.
+func ParseParams(query url.Values) Params {
+	return ParseParamsUpTo(query, MaxLimit)
+}
+
+// ParseParamsUpTo is ParseParams with maxLimit in place of MaxLimit
+func ParseParamsUpTo(query url.Values, maxLimit int) Params {
+	params := Params{Limit: DefaultLimit}
+
+	if raw, ok := query["limit"]; ok && len(raw) > 0 {
+		limit, err := strconv.Atoi(raw[0])
+		switch {
+		case err != nil || limit <= 0:
+			params.Warnings = append(params.Warnings, fmt.Sprintf("limit %q is not a positive integer; using %d", raw[0], DefaultLimit))
+		case limit > maxLimit:
+			params.Limit = maxLimit
+			params.Warnings = append(params.Warnings, fmt.Sprintf("limit %d exceeds the maximum of %d; using %d", limit, maxLimit, maxLimit))
+		default:
+			params.Limit = limit
+		}
+	}
+
+	if raw, ok := query["offset"]; ok && len(raw) > 0 {
+		offset, err := strconv.Atoi(raw[0])
+		if err != nil || offset < 0 {
+			params.Warnings = append(params.Warnings, fmt.Sprintf("offset %q is not a non-negative integer; using 0", raw[0]))
+		} else {
+			params.Offset = offset
+		}
+	}
+
+	return params
+}
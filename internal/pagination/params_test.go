@@ -0,0 +1,50 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseParams(t *testing.T) {
+	tests := []struct {
+		query    string
+		limit    int
+		offset   int
+		warnings int
+	}{
+		{query: "", limit: 10},
+		{query: "limit=25&offset=50", limit: 25, offset: 50},
+		{query: "limit=100", limit: 100},
+		{query: "limit=500", limit: 100, warnings: 1},
+		{query: "limit=0", limit: 10, warnings: 1},
+		{query: "limit=ten&offset=-5", limit: 10, warnings: 2},
+		{query: "offset=abc", limit: 10, warnings: 1},
+	}
+
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %v", tt.query, err)
+		}
+
+		params := ParseParams(query)
+		if params.Limit != tt.limit || params.Offset != tt.offset {
+			t.Errorf("%q: expected limit %d offset %d, got %d and %d", tt.query, tt.limit, tt.offset, params.Limit, params.Offset)
+		}
+		if len(params.Warnings) != tt.warnings || params.Clamped() != (tt.warnings > 0) {
+			t.Errorf("%q: expected %d warnings, got %v", tt.query, tt.warnings, params.Warnings)
+		}
+	}
+}
+
+func TestParseParamsUpTo(t *testing.T) {
+	query, _ := url.ParseQuery("limit=500")
+	if params := ParseParamsUpTo(query, MaxExportLimit); params.Limit != 500 || params.Clamped() {
+		t.Errorf("Expected limit 500 within the export maximum, got %+v", params)
+	}
+
+	query, _ = url.ParseQuery("limit=5000")
+	if params := ParseParamsUpTo(query, MaxExportLimit); params.Limit != MaxExportLimit || !params.Clamped() {
+		t.Errorf("Expected limit lowered to %d, got %+v", MaxExportLimit, params)
+	}
+}
@@ -0,0 +1,166 @@
+// Package plan lists, in order, every step applying a template runs, so
+// clients can preview an install before making any changes.
+package plan
+
+import (
+	"dotfiles-api/internal/hookscan"
+	"dotfiles-api/internal/models"
+)
+
+// Phases a step belongs to, in the order they run
+const (
+	PhasePreInstall  = "pre_install"
+	PhaseInstall     = "install"
+	PhasePostInstall = "post_install"
+	PhasePreStow     = "pre_stow"
+	PhaseStow        = "stow"
+	PhasePostStow    = "post_stow"
+)
+
+// Kinds of step
+const (
+	KindHook               = "hook"
+	KindTap                = "tap"
+	KindBrew               = "brew"
+	KindCask               = "cask"
+	KindApt                = "apt"
+	KindPip                = "pip"
+	KindStow               = "stow"
+	KindPackagePreInstall  = "package_pre_install"
+	KindPackagePostInstall = "package_post_install"
+)
+
+// Step is one thing the CLI does. Hook steps carry the commands they run;
+// package and stow steps are named after the package.
+type Step struct {
+	Phase    string   `json:"phase"`
+	Kind     string   `json:"kind"`
+	Name     string   `json:"name"`
+	Commands []string `json:"commands"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Build returns the steps applying template runs, in order: pre_install
+// hooks, taps, brews, casks, apt and pip packages, post_install hooks,
+// pre_stow hooks, stow packages and post_stow hooks. A brew or cask with a
+// package config is wrapped in its pre and post install commands. Sync hooks
+// do not run on install and are left out.
+func Build(template models.Template) []Step {
+	hooks := template.Hooks
+	if hooks == nil {
+		hooks = &models.Hooks{}
+	}
+
+	steps := []Step{}
+	steps = appendHook(steps, PhasePreInstall, hooks.PreInstall)
+	steps = appendPackages(steps, KindTap, template.Taps, nil)
+	steps = appendPackages(steps, KindBrew, template.Brews, template.PackageConfigs)
+	steps = appendPackages(steps, KindCask, template.Casks, template.PackageConfigs)
+	steps = appendPackages(steps, KindApt, template.AptPackages, nil)
+	steps = appendPackages(steps, KindPip, template.PipPackages, nil)
+	steps = appendHook(steps, PhasePostInstall, hooks.PostInstall)
+	steps = appendHook(steps, PhasePreStow, hooks.PreStow)
+	for _, name := range template.Stow {
+		steps = append(steps, Step{Phase: PhaseStow, Kind: KindStow, Name: name, Commands: []string{}})
+	}
+	steps = appendHook(steps, PhasePostStow, hooks.PostStow)
+
+	return steps
+}
+
+// appendHook adds a step running the phase's hook commands, if it has any
+func appendHook(steps []Step, phase string, commands []string) []Step {
+	if len(commands) == 0 {
+		return steps
+	}
+	return append(steps, commandStep(phase, KindHook, phase, commands))
+}
+
+// appendPackages adds an install step for each package, between the
+// package's own pre and post install commands when configs has them
+func appendPackages(steps []Step, kind string, names []string, configs map[string]models.PackageConfig) []Step {
+	for _, name := range names {
+		config := configs[name]
+		if len(config.PreInstall) > 0 {
+			steps = append(steps, commandStep(PhaseInstall, KindPackagePreInstall, name, config.PreInstall))
+		}
+		steps = append(steps, Step{Phase: PhaseInstall, Kind: kind, Name: name, Commands: []string{}})
+		if len(config.PostInstall) > 0 {
+			steps = append(steps, commandStep(PhaseInstall, KindPackagePostInstall, name, config.PostInstall))
+		}
+	}
+	return steps
+}
+
+func commandStep(phase, kind, name string, commands []string) Step {
+	return Step{
+		Phase:    phase,
+		Kind:     kind,
+		Name:     name,
+		Commands: append([]string(nil), commands...),
+		Warnings: hookscan.ScanAll(commands),
+	}
+}
+
+// Resolve flattens an extends chain, given from the root ancestor down to the
+// template itself, into the template that is applied. Packages are merged
+// without duplicates, hooks run from the root down, and the nearest
+// template's config wins for each package.
+func Resolve(chain []models.Template) models.Template {
+	var resolved models.Template
+	if len(chain) == 0 {
+		return resolved
+	}
+	resolved = chain[len(chain)-1]
+	resolved.Taps, resolved.Brews, resolved.Casks = nil, nil, nil
+	resolved.Stow, resolved.AptPackages, resolved.PipPackages = nil, nil, nil
+	resolved.Hooks, resolved.PackageConfigs = nil, nil
+
+	var hooks models.Hooks
+	hasHooks := false
+	for _, tmpl := range chain {
+		resolved.Taps = merge(resolved.Taps, tmpl.Taps)
+		resolved.Brews = merge(resolved.Brews, tmpl.Brews)
+		resolved.Casks = merge(resolved.Casks, tmpl.Casks)
+		resolved.Stow = merge(resolved.Stow, tmpl.Stow)
+		resolved.AptPackages = merge(resolved.AptPackages, tmpl.AptPackages)
+		resolved.PipPackages = merge(resolved.PipPackages, tmpl.PipPackages)
+
+		if tmpl.Hooks != nil {
+			hasHooks = true
+			hooks.PreInstall = append(hooks.PreInstall, tmpl.Hooks.PreInstall...)
+			hooks.PostInstall = append(hooks.PostInstall, tmpl.Hooks.PostInstall...)
+			hooks.PreSync = append(hooks.PreSync, tmpl.Hooks.PreSync...)
+			hooks.PostSync = append(hooks.PostSync, tmpl.Hooks.PostSync...)
+			hooks.PreStow = append(hooks.PreStow, tmpl.Hooks.PreStow...)
+			hooks.PostStow = append(hooks.PostStow, tmpl.Hooks.PostStow...)
+		}
+
+		for name, config := range tmpl.PackageConfigs {
+			if resolved.PackageConfigs == nil {
+				resolved.PackageConfigs = make(map[string]models.PackageConfig)
+			}
+			resolved.PackageConfigs[name] = config
+		}
+	}
+	if hasHooks {
+		resolved.Hooks = &hooks
+	}
+
+	return resolved
+}
+
+// merge appends the names from next that base does not already contain
+func merge(base, next []string) []string {
+	seen := make(map[string]bool, len(base))
+	for _, name := range base {
+		seen[name] = true
+	}
+	for _, name := range next {
+		if !seen[name] {
+			seen[name] = true
+			base = append(base, name)
+		}
+	}
+	return base
+}
@@ -0,0 +1,180 @@
+package plan
+
+import (
+	"reflect"
+	"testing"
+
+	"dotfiles-api/internal/models"
+)
+
+// summary renders steps as "phase kind name" for compact ordering checks
+func summary(steps []Step) []string {
+	out := make([]string, len(steps))
+	for i, step := range steps {
+		out[i] = step.Phase + " " + step.Kind + " " + step.Name
+	}
+	return out
+}
+
+func TestBuildOrder(t *testing.T) {
+	template := models.Template{
+		Taps:        []string{"homebrew/cask-fonts"},
+		Brews:       []string{"git", "postgresql@16"},
+		Casks:       []string{"iterm2"},
+		AptPackages: []string{"curl"},
+		PipPackages: []string{"black"},
+		Stow:        []string{"zsh", "nvim"},
+		Hooks: &models.Hooks{
+			PreInstall:  []string{"xcode-select --install"},
+			PostInstall: []string{"brew cleanup"},
+			PreSync:     []string{"git pull"},
+			PostSync:    []string{"echo synced"},
+			PreStow:     []string{"mkdir -p ~/.config"},
+			PostStow:    []string{"exec zsh"},
+		},
+		PackageConfigs: map[string]models.PackageConfig{
+			"postgresql@16": {PreInstall: []string{"echo pg"}, PostInstall: []string{"brew services start postgresql@16"}},
+			"iterm2":        {PostInstall: []string{"defaults write com.googlecode.iterm2 PromptOnQuit -bool false"}},
+			"unused":        {PreInstall: []string{"echo never"}},
+		},
+	}
+
+	want := []string{
+		"pre_install hook pre_install",
+		"install tap homebrew/cask-fonts",
+		"install brew git",
+		"install package_pre_install postgresql@16",
+		"install brew postgresql@16",
+		"install package_post_install postgresql@16",
+		"install cask iterm2",
+		"install package_post_install iterm2",
+		"install apt curl",
+		"install pip black",
+		"post_install hook post_install",
+		"pre_stow hook pre_stow",
+		"stow stow zsh",
+		"stow stow nvim",
+		"post_stow hook post_stow",
+	}
+
+	steps := Build(template)
+	if got := summary(steps); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Unexpected plan:\n got %v\nwant %v", got, want)
+	}
+
+	if got := steps[5].Commands; !reflect.DeepEqual(got, []string{"brew services start postgresql@16"}) {
+		t.Errorf("Expected the package's post install commands, got %v", got)
+	}
+	if steps[2].Commands == nil || len(steps[2].Commands) != 0 {
+		t.Errorf("Expected package steps to carry an empty command list, got %#v", steps[2].Commands)
+	}
+}
+
+func TestBuildSkipsEmptyHooks(t *testing.T) {
+	steps := Build(models.Template{
+		Brews: []string{"git"},
+		Stow:  []string{"git"},
+		Hooks: &models.Hooks{PostStow: []string{}},
+	})
+
+	want := []string{"install brew git", "stow stow git"}
+	if got := summary(steps); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if steps := Build(models.Template{}); steps == nil || len(steps) != 0 {
+		t.Errorf("Expected an empty, non-nil plan for an empty template, got %#v", steps)
+	}
+}
+
+func TestBuildKeepsHookCommandOrder(t *testing.T) {
+	steps := Build(models.Template{Hooks: &models.Hooks{PreInstall: []string{"first", "second", "third"}}})
+	if len(steps) != 1 || !reflect.DeepEqual(steps[0].Commands, []string{"first", "second", "third"}) {
+		t.Errorf("Expected one hook step with commands in order, got %+v", steps)
+	}
+}
+
+func TestBuildWarnings(t *testing.T) {
+	steps := Build(models.Template{
+		Brews: []string{"nvm"},
+		Hooks: &models.Hooks{
+			PreInstall:  []string{"curl -fsSL https://example.com/install.sh | bash", "sudo true"},
+			PostInstall: []string{"brew cleanup"},
+		},
+		PackageConfigs: map[string]models.PackageConfig{
+			"nvm": {PostInstall: []string{"chmod 777 ~/.nvm"}},
+		},
+	})
+
+	wants := map[string][]string{
+		"pre_install hook pre_install":     {"pipes a download straight into a shell", "runs with root privileges"},
+		"install brew nvm":                 nil,
+		"install package_post_install nvm": {"makes files writable by every user"},
+		"post_install hook post_install":   nil,
+	}
+	for i, key := range summary(steps) {
+		want, ok := wants[key]
+		if !ok {
+			t.Errorf("Unexpected step %q", key)
+			continue
+		}
+		if !reflect.DeepEqual(steps[i].Warnings, want) {
+			t.Errorf("Step %q: expected warnings %v, got %v", key, want, steps[i].Warnings)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	root := models.Template{
+		Brews: []string{"git", "curl"},
+		Stow:  []string{"git"},
+		Hooks: &models.Hooks{PreInstall: []string{"root pre"}, PostStow: []string{"root post stow"}},
+		PackageConfigs: map[string]models.PackageConfig{
+			"git":  {PostInstall: []string{"git config --global init.defaultBranch main"}},
+			"curl": {PreInstall: []string{"echo root curl"}},
+		},
+		Metadata: models.ShareMetadata{Name: "Base"},
+	}
+	middle := models.Template{
+		Brews: []string{"curl", "neovim"},
+		Casks: []string{"iterm2"},
+	}
+	leaf := models.Template{
+		Brews: []string{"ripgrep", "git"},
+		Stow:  []string{"nvim"},
+		Hooks: &models.Hooks{PreInstall: []string{"leaf pre"}},
+		PackageConfigs: map[string]models.PackageConfig{
+			"curl": {PreInstall: []string{"echo leaf curl"}},
+		},
+		Metadata: models.ShareMetadata{Name: "Leaf"},
+		Extends:  "middle",
+	}
+
+	resolved := Resolve([]models.Template{root, middle, leaf})
+
+	if want := []string{"git", "curl", "neovim", "ripgrep"}; !reflect.DeepEqual(resolved.Brews, want) {
+		t.Errorf("Expected brews %v, got %v", want, resolved.Brews)
+	}
+	if want := []string{"git", "nvim"}; !reflect.DeepEqual(resolved.Stow, want) {
+		t.Errorf("Expected stow %v, got %v", want, resolved.Stow)
+	}
+	if want := []string{"root pre", "leaf pre"}; !reflect.DeepEqual(resolved.Hooks.PreInstall, want) {
+		t.Errorf("Expected hooks from the root down, got %v", resolved.Hooks.PreInstall)
+	}
+	if want := []string{"echo leaf curl"}; !reflect.DeepEqual(resolved.PackageConfigs["curl"].PreInstall, want) {
+		t.Errorf("Expected the nearest config to win, got %v", resolved.PackageConfigs["curl"].PreInstall)
+	}
+	if len(resolved.PackageConfigs["git"].PostInstall) != 1 {
+		t.Errorf("Expected inherited configs to be kept, got %v", resolved.PackageConfigs)
+	}
+	if resolved.Metadata.Name != "Leaf" || resolved.Extends != "middle" {
+		t.Errorf("Expected the leaf's own fields, got %+v", resolved.Metadata)
+	}
+	if len(root.Hooks.PreInstall) != 1 || len(root.PackageConfigs) != 2 {
+		t.Error("Expected Resolve to leave the chain unchanged")
+	}
+
+	if resolved := Resolve([]models.Template{middle}); resolved.Hooks != nil || resolved.PackageConfigs != nil {
+		t.Errorf("Expected no hooks or configs when no template has them, got %+v", resolved)
+	}
+}
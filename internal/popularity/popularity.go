@@ -0,0 +1,184 @@
+// Package popularity ranks templates by a composite score of downloads,
+// ratings and favorites that fades as templates age.
+package popularity
+
+import (
+	"context"
+	stderrors "errors"
+	"log"
+	"math"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+)
+
+// userPageSize is how many users are read at a time when counting favorites
+const userPageSize = 500
+
+// Weights are the coefficients of the popularity score:
+//
+//	score = (Downloads*downloads + Rating*averageRating*ratingCount + Favorites*favorites)
+//	        * 0.5^(age/HalfLife)
+//
+// A zero HalfLife disables the recency decay.
+type Weights struct {
+	Downloads float64
+	Rating    float64
+	Favorites float64
+	HalfLife  time.Duration
+}
+
+// DefaultWeights counts a five-star rating as five downloads and a favorite
+// as three, and halves a template's score every 90 days
+func DefaultWeights() Weights {
+	return Weights{
+		Downloads: 1,
+		Rating:    1,
+		Favorites: 3,
+		HalfLife:  90 * 24 * time.Hour,
+	}
+}
+
+// Signals are the inputs to a template's popularity score
+type Signals struct {
+	Downloads     int
+	AverageRating float64
+	RatingCount   int
+	Favorites     int
+	CreatedAt     time.Time
+}
+
+// Score combines the signals using the weights, decayed by age at now
+func (w Weights) Score(s Signals, now time.Time) float64 {
+	score := w.Downloads*float64(s.Downloads) +
+		w.Rating*s.AverageRating*float64(s.RatingCount) +
+		w.Favorites*float64(s.Favorites)
+
+	if w.HalfLife > 0 {
+		if age := now.Sub(s.CreatedAt); age > 0 {
+			score *= math.Pow(0.5, float64(age)/float64(w.HalfLife))
+		}
+	}
+
+	return score
+}
+
+// Updater recomputes and stores the popularity score of every template
+type Updater struct {
+	templates repository.TemplateRepository
+	reviews   repository.ReviewRepository
+	users     repository.UserRepository
+	weights   Weights
+	now       func() time.Time
+}
+
+// NewUpdater creates a popularity updater
+func NewUpdater(
+	templates repository.TemplateRepository,
+	reviews repository.ReviewRepository,
+	users repository.UserRepository,
+	weights Weights,
+) *Updater {
+	return &Updater{
+		templates: templates,
+		reviews:   reviews,
+		users:     users,
+		weights:   weights,
+		now:       time.Now,
+	}
+}
+
+// Start scores templates immediately and then every interval until ctx is
+// done. Scores decay with age, so they are refreshed even when no signal
+// changes.
+func (u *Updater) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := u.Run(ctx); err != nil {
+			log.Printf("Popularity update failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run rescores every template and returns how many scores were stored
+func (u *Updater) Run(ctx context.Context) (int, error) {
+	favorites, err := u.countFavorites(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	templates, err := u.templates.List(ctx, repository.TemplateFilters{})
+	if err != nil {
+		return 0, err
+	}
+
+	now := u.now()
+	updated := 0
+	var errs []error
+
+	for _, template := range templates {
+		signals := Signals{
+			Downloads: template.Downloads,
+			Favorites: favorites[template.ID],
+			CreatedAt: template.CreatedAt,
+		}
+
+		rating, err := u.reviews.CalculateTemplateRating(ctx, template.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if rating != nil {
+			signals.AverageRating = rating.AverageRating
+			signals.RatingCount = rating.TotalRatings
+		}
+
+		if err := u.templates.SetPopularityScore(ctx, template.ID, u.weights.Score(signals, now)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		updated++
+	}
+
+	return updated, stderrors.Join(errs...)
+}
+
+// countFavorites counts how many active users favorited each template
+func (u *Updater) countFavorites(ctx context.Context) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	for offset := 0; ; offset += userPageSize {
+		users, err := u.users.List(ctx, userPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range users {
+			countUserFavorites(counts, user)
+		}
+
+		if len(users) < userPageSize {
+			return counts, nil
+		}
+	}
+}
+
+// countUserFavorites adds one user's favorites, counting each template once
+func countUserFavorites(counts map[string]int, user *models.User) {
+	seen := make(map[string]bool, len(user.Favorites))
+	for _, templateID := range user.Favorites {
+		if !seen[templateID] {
+			seen[templateID] = true
+			counts[templateID]++
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package popularity
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/repository/memory"
+)
+
+func TestScoreWeightsSignalsAndDecays(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	weights := Weights{Downloads: 1, Rating: 2, Favorites: 3, HalfLife: 10 * 24 * time.Hour}
+	signals := Signals{Downloads: 10, AverageRating: 4.5, RatingCount: 2, Favorites: 1, CreatedAt: now}
+
+	// 10*1 + 4.5*2*2 + 1*3
+	if got := weights.Score(signals, now); got != 31 {
+		t.Errorf("Expected a fresh template to score 31, got %v", got)
+	}
+
+	signals.CreatedAt = now.Add(-20 * 24 * time.Hour)
+	if got := weights.Score(signals, now); math.Abs(got-31.0/4) > 1e-9 {
+		t.Errorf("Expected two half-lives to quarter the score, got %v", got)
+	}
+
+	weights.HalfLife = 0
+	if got := weights.Score(signals, now); got != 31 {
+		t.Errorf("Expected no decay without a half-life, got %v", got)
+	}
+}
+
+func TestUpdaterStoresScores(t *testing.T) {
+	ctx := context.Background()
+	templates := memory.NewTemplateRepository()
+	reviews := memory.NewReviewRepository()
+	users := memory.NewUserRepository()
+
+	for _, id := range []string{"popular", "ignored"} {
+		if err := templates.Create(ctx, &models.StoredTemplate{ID: id}); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if err := templates.IncrementDownloads(ctx, "popular"); err != nil {
+			t.Fatalf("Failed to count download: %v", err)
+		}
+	}
+
+	for userID, rating := range map[string]int{"alice": 5, "bob": 3} {
+		if err := reviews.Create(ctx, &models.Review{TemplateID: "popular", UserID: userID, Rating: rating}); err != nil {
+			t.Fatalf("Failed to create review: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for _, user := range []*models.User{
+		{ID: "alice", Username: "alice", Email: "alice@example.com", Favorites: []string{"popular", "popular"}},
+		{ID: "bob", Username: "bob", Email: "bob@example.com", Favorites: []string{"popular"}},
+	} {
+		if err := users.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	all, err := templates.List(ctx, repository.TemplateFilters{})
+	if err != nil {
+		t.Fatalf("Failed to list templates: %v", err)
+	}
+
+	updater := NewUpdater(templates, reviews, users, Weights{Downloads: 1, Rating: 1, Favorites: 3})
+	updated, err := updater.Run(ctx)
+	if err != nil {
+		t.Fatalf("Failed to update scores: %v", err)
+	}
+	if updated != len(all) {
+		t.Errorf("Expected %d scores stored, got %d", len(all), updated)
+	}
+
+	// 3 downloads + 4 average * 2 ratings + 2 favoriting users * 3
+	popular, _ := templates.GetByID(ctx, "popular")
+	if popular.PopularityScore != 17 {
+		t.Errorf("Expected a score of 17, got %v", popular.PopularityScore)
+	}
+
+	ignored, _ := templates.GetByID(ctx, "ignored")
+	if ignored.PopularityScore != 0 {
+		t.Errorf("Expected a template without signals to score 0, got %v", ignored.PopularityScore)
+	}
+}
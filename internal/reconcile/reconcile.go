@@ -0,0 +1,117 @@
+// Package reconcile recomputes denormalized counters from the records they
+// count and corrects the stored values that have drifted, as they can when
+// a write fails partway.
+//
+// Organization member counts are the only counters stored alongside their
+// records. Favorite counts and rating aggregates are computed from users
+// and reviews when read, so they cannot drift, and template downloads have
+// no record of their own to be recounted from.
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"dotfiles-api/internal/repository"
+)
+
+// pageSize is how many organizations are loaded at a time
+const pageSize = 100
+
+// Report counts what a run checked and found. Unless DryRun is set the
+// drifted counts were also corrected.
+type Report struct {
+	DryRun bool `json:"dry_run"`
+	// Organizations is how many organizations were checked
+	Organizations int `json:"organizations"`
+	// MemberCountsOff counts organizations whose stored member_count did
+	// not match their memberships
+	MemberCountsOff int `json:"member_counts_off"`
+	// MemberCountsSkipped counts drifted member counts left alone because
+	// members joined or left while they were being recounted. The next run
+	// picks them up.
+	MemberCountsSkipped int `json:"member_counts_skipped"`
+}
+
+// Reconciler recounts the stored counters
+type Reconciler struct {
+	orgs repository.OrganizationRepository
+}
+
+// New creates a reconciler
+func New(orgs repository.OrganizationRepository) *Reconciler {
+	return &Reconciler{orgs: orgs}
+}
+
+// Start reconciles every interval until ctx is done. An interval of 0 or
+// less leaves reconciliation to be run on demand.
+func (r *Reconciler) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := r.Run(ctx, false)
+			if err != nil {
+				log.Printf("Counter reconciliation failed: %v", err)
+				continue
+			}
+			log.Printf("Counter reconciliation checked %d organizations and corrected %d member counts; %d changed while being recounted",
+				report.Organizations, report.MemberCountsOff-report.MemberCountsSkipped, report.MemberCountsSkipped)
+		}
+	}
+}
+
+// Run recounts every organization's members, paging through the
+// organizations, and corrects the stored counts that are off unless dryRun
+// is set
+func (r *Reconciler) Run(ctx context.Context, dryRun bool) (*Report, error) {
+	report := &Report{DryRun: dryRun}
+
+	for offset := 0; ; offset += pageSize {
+		orgs, err := r.orgs.ListAll(ctx, pageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, org := range orgs {
+			report.Organizations++
+
+			members, err := r.orgs.GetMembers(ctx, org.ID)
+			if err != nil {
+				return nil, err
+			}
+			if len(members) == org.MemberCount {
+				continue
+			}
+
+			report.MemberCountsOff++
+			if dryRun {
+				continue
+			}
+			corrected, err := r.orgs.SetMemberCount(ctx, org.ID, org.MemberCount, len(members))
+			// A deleted organization has nothing left to correct
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+			if !corrected {
+				report.MemberCountsSkipped++
+			}
+		}
+
+		if len(orgs) < pageSize {
+			break
+		}
+	}
+
+	return report, nil
+}
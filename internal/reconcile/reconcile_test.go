@@ -0,0 +1,128 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository/memory"
+)
+
+// newOrgs seeds organizations, more than a page of them, with members.
+// org-000 and the private org-001 have drifted counts; the rest are right.
+func newOrgs(t *testing.T) *memory.OrganizationRepository {
+	t.Helper()
+	ctx := context.Background()
+	orgs := memory.NewOrganizationRepository()
+
+	for i := 0; i < pageSize+5; i++ {
+		id := fmt.Sprintf("org-%03d", i)
+		org := &models.Organization{ID: id, Name: id, Slug: id, OwnerID: "owner-id", Public: i != 1}
+		switch i {
+		case 0:
+			org.MemberCount = 5
+		case 1:
+			org.MemberCount = -1
+		}
+		if err := orgs.Create(ctx, org); err != nil {
+			t.Fatalf("Failed to create organization: %v", err)
+		}
+
+		for _, userID := range []string{"owner-id", "member-id"} {
+			member := &models.OrganizationMember{ID: id + "-" + userID, OrganizationID: id, UserID: userID, Role: models.RoleMember}
+			if err := orgs.AddMember(ctx, member); err != nil {
+				t.Fatalf("Failed to add member: %v", err)
+			}
+		}
+	}
+	return orgs
+}
+
+func memberCount(t *testing.T, orgs *memory.OrganizationRepository, id string) int {
+	t.Helper()
+	org, err := orgs.GetByID(context.Background(), id)
+	if err != nil || org == nil {
+		t.Fatalf("Failed to get organization %s: %v", id, err)
+	}
+	return org.MemberCount
+}
+
+func TestRunCorrectsMemberCounts(t *testing.T) {
+	orgs := newOrgs(t)
+	reconciler := New(orgs)
+
+	report, err := reconciler.Run(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if report.Organizations != pageSize+5 || report.MemberCountsOff != 2 {
+		t.Errorf("Expected 2 of %d counts off, got %+v", pageSize+5, report)
+	}
+	if got := memberCount(t, orgs, "org-000"); got != 7 {
+		t.Errorf("Expected the dry run to leave the count at 7, got %d", got)
+	}
+
+	report, err = reconciler.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.MemberCountsOff != 2 || report.MemberCountsSkipped != 0 {
+		t.Errorf("Expected 2 counts corrected, got %+v", report)
+	}
+	for _, id := range []string{"org-000", "org-001", "org-002"} {
+		if got := memberCount(t, orgs, id); got != 2 {
+			t.Errorf("Expected %s to have 2 members, got %d", id, got)
+		}
+	}
+
+	report, err = reconciler.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Second run failed: %v", err)
+	}
+	if report.MemberCountsOff != 0 {
+		t.Errorf("Expected nothing left to correct, got %+v", report)
+	}
+}
+
+// joiningOrgs adds a member to an organization while it is being recounted
+type joiningOrgs struct {
+	*memory.OrganizationRepository
+	joined bool
+}
+
+func (r *joiningOrgs) GetMembers(ctx context.Context, orgID string) ([]*models.OrganizationMember, error) {
+	members, err := r.OrganizationRepository.GetMembers(ctx, orgID)
+	if err == nil && orgID == "org-000" && !r.joined {
+		r.joined = true
+		err = r.AddMember(ctx, &models.OrganizationMember{ID: "late", OrganizationID: orgID, UserID: "late-id", Role: models.RoleMember})
+	}
+	return members, err
+}
+
+func TestRunSkipsCountsChangedWhileRecounting(t *testing.T) {
+	orgs := newOrgs(t)
+	reconciler := New(&joiningOrgs{OrganizationRepository: orgs})
+
+	report, err := reconciler.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.MemberCountsOff != 2 || report.MemberCountsSkipped != 1 {
+		t.Errorf("Expected one of 2 corrections skipped, got %+v", report)
+	}
+	if got := memberCount(t, orgs, "org-000"); got != 8 {
+		t.Errorf("Expected the count to keep the late join, got %d", got)
+	}
+
+	report, err = reconciler.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Second run failed: %v", err)
+	}
+	if report.MemberCountsOff != 1 || report.MemberCountsSkipped != 0 {
+		t.Errorf("Expected the skipped count corrected next time, got %+v", report)
+	}
+	if got := memberCount(t, orgs, "org-000"); got != 3 {
+		t.Errorf("Expected 3 members, got %d", got)
+	}
+}
@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"time"
 
 	"dotfiles-api/internal/models"
 )
@@ -11,20 +14,67 @@ import (
 var (
 	ErrNotFound      = errors.New("resource not found")
 	ErrAlreadyExists = errors.New("resource already exists")
+
+	// ErrOrganizationFull is returned when adding a member or invite would
+	// exceed the organization's MaxMembers limit
+	ErrOrganizationFull = errors.New("organization is full")
+
+	// ErrStorageUnavailable is returned when the backing store cannot be
+	// reached, e.g. while a replica set elects a new primary. Callers should
+	// retry later.
+	ErrStorageUnavailable = errors.New("storage unavailable")
+
+	// ErrStoreFull is returned when an in-memory store has reached its
+	// configured entry limit. It wraps ErrStorageUnavailable, so callers
+	// treat it as a temporary outage.
+	ErrStoreFull = fmt.Errorf("%w: store is full", ErrStorageUnavailable)
+
+	// ErrShareLinkUnusable is returned when a share link exists but has
+	// expired or used up its uses
+	ErrShareLinkUnusable = errors.New("share link expired or used up")
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id string) (*models.User, error)
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	// GetByUsernames looks up several users at once, ignoring deleted users.
+	// Unknown usernames are left out rather than reported as errors.
+	GetByUsernames(ctx context.Context, usernames []string) ([]*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	GetByGitHubID(ctx context.Context, githubID int) (*models.User, error)
+	GetByIdentity(ctx context.Context, provider, externalID string) (*models.User, error)
+	// AddIdentity links a provider account to the user. It returns
+	// ErrAlreadyExists if another user is linked to that account.
+	AddIdentity(ctx context.Context, userID string, identity models.Identity) error
 	Update(ctx context.Context, user *models.User) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, limit, offset int) ([]*models.User, error)
+	// Iterate calls fn for every user that is not deleted, newest first,
+	// without loading them all at once. It stops at the first error fn
+	// returns.
+	Iterate(ctx context.Context, fn func(*models.User) error) error
+	GetDeletedUsers(ctx context.Context, limit, offset int) ([]*models.User, error)
 	AddFavorite(ctx context.Context, userID, templateID string) error
 	RemoveFavorite(ctx context.Context, userID, templateID string) error
 	GetFavorites(ctx context.Context, userID string) ([]string, error)
+	// RecordDownload adds a template download to the user's history; see
+	// models.User.RecordDownload
+	RecordDownload(ctx context.Context, userID, templateID string, at time.Time) error
+	// BumpMembershipVersion records that the user's organization
+	// memberships changed; see models.User.MembershipVersion
+	BumpMembershipVersion(ctx context.Context, userID string) error
+	// Follow records that followerID follows followeeID. Following a user
+	// again is not an error and keeps the original follow time.
+	Follow(ctx context.Context, followerID, followeeID string) error
+	// Unfollow removes a follow, returning ErrNotFound if there was none
+	Unfollow(ctx context.Context, followerID, followeeID string) error
+	// GetFollowing returns the users userID follows, most recently followed
+	// first, ignoring deleted users
+	GetFollowing(ctx context.Context, userID string, limit, offset int) ([]*models.User, error)
+	// GetFollowers returns the users following userID, most recent follower
+	// first, ignoring deleted users
+	GetFollowers(ctx context.Context, userID string, limit, offset int) ([]*models.User, error)
 }
 
 type TemplateRepository interface {
@@ -33,73 +83,384 @@ type TemplateRepository interface {
 	Update(ctx context.Context, template *models.StoredTemplate) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, filters TemplateFilters) ([]*models.StoredTemplate, error)
-	Search(ctx context.Context, query string, limit, offset int) ([]*models.StoredTemplate, error)
+	// Count counts the templates matching filters, ignoring pagination,
+	// sorting and Fields
+	Count(ctx context.Context, filters TemplateFilters) (int, error)
+	// Iterate calls fn for every template matching filters, in the order
+	// List would return them but ignoring Limit and Offset, without loading
+	// them all at once. It stops at the first error fn returns.
+	Iterate(ctx context.Context, filters TemplateFilters, fn func(*models.StoredTemplate) error) error
+	// Search matches templates against query. viewer and fields work like
+	// TemplateFilters.Viewer and TemplateFilters.Fields.
+	Search(ctx context.Context, query string, viewer *TemplateViewer, limit, offset int, fields []string) ([]*models.StoredTemplate, error)
+	// CountSearch counts the templates Search matches, ignoring pagination
+	CountSearch(ctx context.Context, query string, viewer *TemplateViewer) (int, error)
 	GetByAuthor(ctx context.Context, authorID string, limit, offset int) ([]*models.StoredTemplate, error)
 	GetByOrganization(ctx context.Context, orgID string, limit, offset int) ([]*models.StoredTemplate, error)
+	// CountByOrganization counts the templates GetByOrganization pages
+	// through, without loading them
+	CountByOrganization(ctx context.Context, orgID string) (int64, error)
 	GetFeatured(ctx context.Context, limit int) ([]*models.StoredTemplate, error)
 	IncrementDownloads(ctx context.Context, id string) error
+	// SetPopularityScore stores a template's precomputed popularity score
+	SetPopularityScore(ctx context.Context, id string, score float64) error
+	// SetUnlisted hides a template from listings, search and featured
+	// templates for the given reason, or lists it again when reason is empty
+	SetUnlisted(ctx context.Context, id string, reason string) error
 	GetStats(ctx context.Context) (*models.TemplateStats, error)
 	GetRating(ctx context.Context, templateID string) (*models.TemplateRating, error)
+
+	// CountTags counts public templates per tag after mapping each
+	// template's tags through canonicalize, so a template tagged both "js"
+	// and "javascript" counts once
+	CountTags(ctx context.Context, canonicalize func([]string) []string) (map[string]int, error)
+
+	// RewriteTags replaces every template's tags with canonicalize(tags),
+	// returning how many templates changed
+	RewriteTags(ctx context.Context, canonicalize func([]string) []string) (int, error)
+}
+
+// TagRepository stores the tag synonyms added by site admins on top of the
+// built-in table
+type TagRepository interface {
+	GetSynonyms(ctx context.Context) (map[string][]string, error)
+	SetSynonyms(ctx context.Context, canonical string, synonyms []string) error
+}
+
+// SubscriptionRepository stores the tags each user follows, one subscription
+// per user
+type SubscriptionRepository interface {
+	// GetByUser returns the user's subscription, or nil when they follow no
+	// tags
+	GetByUser(ctx context.Context, userID string) (*models.TagSubscription, error)
+	// AddTags follows the tags, creating the subscription with
+	// LastNotifiedAt set to now so older templates are not sent
+	AddTags(ctx context.Context, userID string, tags []string) (*models.TagSubscription, error)
+	// RemoveTags unfollows the tags, deleting the subscription and returning
+	// nil once no tags are left
+	RemoveTags(ctx context.Context, userID string, tags []string) (*models.TagSubscription, error)
+	List(ctx context.Context) ([]*models.TagSubscription, error)
+	MarkNotified(ctx context.Context, userID string, at time.Time) error
 }
 
 type OrganizationRepository interface {
+	// Create stores an organization. Returns ErrAlreadyExists when the slug
+	// is taken.
 	Create(ctx context.Context, org *models.Organization) error
 	GetByID(ctx context.Context, id string) (*models.Organization, error)
+	// GetByIDs looks up several organizations at once. Unknown IDs are left
+	// out rather than reported as errors.
+	GetByIDs(ctx context.Context, ids []string) ([]*models.Organization, error)
 	GetBySlug(ctx context.Context, slug string) (*models.Organization, error)
 	Update(ctx context.Context, org *models.Organization) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, limit, offset int) ([]*models.Organization, error)
+	// ListAll pages through every organization, public or not, ordered by
+	// ID
+	ListAll(ctx context.Context, limit, offset int) ([]*models.Organization, error)
 	Search(ctx context.Context, query string, limit, offset int) ([]*models.Organization, error)
 	GetByOwner(ctx context.Context, ownerID string) ([]*models.Organization, error)
 	GetUserOrganizations(ctx context.Context, userID string) ([]*models.Organization, error)
+	// GetUserMemberships returns the user's membership in every
+	// organization they belong to
+	GetUserMemberships(ctx context.Context, userID string) ([]*models.OrganizationMember, error)
+	SetMaxMembers(ctx context.Context, orgID string, maxMembers int) error
+	// SetPinnedTemplates replaces the templates the organization features
+	SetPinnedTemplates(ctx context.Context, orgID string, templateIDs []string) error
+	// SetMemberCount corrects the stored member count from one value to
+	// another, reporting false without changing it if the count is no
+	// longer from, as when a member joined or left in the meantime
+	SetMemberCount(ctx context.Context, orgID string, from, to int) (bool, error)
+
+	// GetByCustomDomain returns the organization a custom domain is assigned
+	// to, verified or not, or nil when no organization uses it
+	GetByCustomDomain(ctx context.Context, domain string) (*models.Organization, error)
+	// SetCustomDomain assigns a domain and its challenge token, clearing any
+	// earlier verification. An empty domain removes it. Returns
+	// ErrAlreadyExists when another organization uses the domain.
+	SetCustomDomain(ctx context.Context, orgID, domain, token string) error
+	// VerifyCustomDomain marks the organization's domain verified, provided
+	// it is still the given domain
+	VerifyCustomDomain(ctx context.Context, orgID, domain string) error
 
 	AddMember(ctx context.Context, member *models.OrganizationMember) error
 	RemoveMember(ctx context.Context, orgID, userID string) error
 	UpdateMemberRole(ctx context.Context, orgID, userID, role string) error
 	GetMembers(ctx context.Context, orgID string) ([]*models.OrganizationMember, error)
+	// ListMembers pages through the memberships of every organization,
+	// ordered by membership ID
+	ListMembers(ctx context.Context, limit, offset int) ([]*models.OrganizationMember, error)
 	GetMember(ctx context.Context, orgID, userID string) (*models.OrganizationMember, error)
 	IsMember(ctx context.Context, orgID, userID string) (bool, error)
 
+	// CreateInvite stores an invite. Returns ErrAlreadyExists when the email
+	// already has an active invite to the organization.
 	CreateInvite(ctx context.Context, invite *models.OrganizationInvite) error
 	GetInvite(ctx context.Context, token string) (*models.OrganizationInvite, error)
+	// GetActiveInvite returns the unexpired, unaccepted invite for an email
+	// in an organization, or nil when there is none
+	GetActiveInvite(ctx context.Context, orgID, email string) (*models.OrganizationInvite, error)
+	// RenewInvite replaces an unaccepted invite's token and expiry. Returns
+	// ErrNotFound when the organization has no such unaccepted invite and
+	// ErrAlreadyExists when reviving an expired invite would duplicate
+	// another active invite for the same email.
+	RenewInvite(ctx context.Context, orgID, id, token string, expiresAt time.Time) (*models.OrganizationInvite, error)
 	GetInvitesByOrganization(ctx context.Context, orgID string) ([]*models.OrganizationInvite, error)
+	CountPendingInvites(ctx context.Context, orgID string) (int, error)
 	AcceptInvite(ctx context.Context, token string, userID string) error
 	DeleteInvite(ctx context.Context, id string) error
 	CleanupExpiredInvites(ctx context.Context) error
+
+	// SetAllowJoinRequests turns the organization's join requests on or off
+	SetAllowJoinRequests(ctx context.Context, orgID string, allow bool) error
+	// CreateJoinRequest stores a pending join request. Returns
+	// ErrAlreadyExists when the user already has a pending request to the
+	// organization.
+	CreateJoinRequest(ctx context.Context, request *models.OrganizationJoinRequest) error
+	// GetJoinRequest returns one of the organization's join requests, or
+	// ErrNotFound
+	GetJoinRequest(ctx context.Context, orgID, id string) (*models.OrganizationJoinRequest, error)
+	// ListJoinRequests pages through the organization's join requests with
+	// the given status, oldest first
+	ListJoinRequests(ctx context.Context, orgID, status string, limit, offset int) ([]*models.OrganizationJoinRequest, error)
+	CountJoinRequests(ctx context.Context, orgID, status string) (int, error)
+	// ResolveJoinRequest approves or rejects a pending join request,
+	// reporting false without changing it if it is no longer pending
+	ResolveJoinRequest(ctx context.Context, orgID, id, status, resolvedBy, reason string, at time.Time) (bool, error)
 }
 
 type ReviewRepository interface {
+	// Create stores a review. Returns ErrAlreadyExists when the user has
+	// already reviewed the template.
 	Create(ctx context.Context, review *models.Review) error
 	GetByID(ctx context.Context, id string) (*models.Review, error)
 	Update(ctx context.Context, review *models.Review) error
 	Delete(ctx context.Context, id string) error
-	GetByTemplate(ctx context.Context, templateID string, limit, offset int) ([]*models.Review, error)
+	// GetByTemplate pages through a template's reviews, newest first. With
+	// languages set, only reviews in one of those languages are returned,
+	// along with reviews whose language is undetermined or was never detected.
+	GetByTemplate(ctx context.Context, templateID string, languages []string, limit, offset int) ([]*models.Review, error)
 	GetByUser(ctx context.Context, userID string, limit, offset int) ([]*models.Review, error)
+	// CountByUser counts the reviews the user has written
+	CountByUser(ctx context.Context, userID string) (int, error)
+	// List pages through every review, ordered by ID
+	List(ctx context.Context, limit, offset int) ([]*models.Review, error)
 	GetUserReviewForTemplate(ctx context.Context, userID, templateID string) (*models.Review, error)
 	IncrementHelpful(ctx context.Context, id string) error
+	// CalculateTemplateRating summarizes a template's ratings, with review
+	// counts per rating and per review language
 	CalculateTemplateRating(ctx context.Context, templateID string) (*models.TemplateRating, error)
 }
 
+// ReportRepository stores user reports against templates
+type ReportRepository interface {
+	// Create stores an open report. Returns ErrAlreadyExists when the
+	// reporter already has an open report on the template.
+	Create(ctx context.Context, report *models.TemplateReport) error
+	GetByID(ctx context.Context, id string) (*models.TemplateReport, error)
+	// ListOpen returns every open report, oldest first
+	ListOpen(ctx context.Context) ([]*models.TemplateReport, error)
+	CountOpen(ctx context.Context, templateID string) (int, error)
+	// ResolveOpen closes every open report on the template with the given
+	// action, returning the reports it closed
+	ResolveOpen(ctx context.Context, templateID, action, resolvedBy string, at time.Time) ([]*models.TemplateReport, error)
+}
+
+// AuditRepository stores the audit log of site admin actions
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) error
+	// List pages through the audit log, newest first
+	List(ctx context.Context, limit, offset int) ([]*models.AuditEntry, error)
+}
+
+// ShareLinkRepository stores template share links by the hash of their
+// token
+type ShareLinkRepository interface {
+	// Create stores a link, hashing its Token into TokenHash
+	Create(ctx context.Context, link *models.TemplateShareLink) error
+	// GetByToken returns the link with the given token, usable or not, or
+	// ErrNotFound
+	GetByToken(ctx context.Context, token string) (*models.TemplateShareLink, error)
+	// Use atomically counts one use of the link with the given token and
+	// returns it. Returns ErrNotFound for an unknown token and
+	// ErrShareLinkUnusable when the link is expired or used up at now.
+	Use(ctx context.Context, token string, now time.Time) (*models.TemplateShareLink, error)
+	// ListUsable lists a template's links usable at now, newest first
+	ListUsable(ctx context.Context, templateID string, now time.Time) ([]*models.TemplateShareLink, error)
+	// Delete revokes one of a template's links. Returns ErrNotFound when
+	// the template has no link with that ID.
+	Delete(ctx context.Context, templateID, id string) error
+}
+
+// APIKeyRepository stores users' API keys by the hash of the key
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	// GetByHash returns the key with the given hash, or ErrNotFound
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	// ListByUser lists a user's keys, newest first
+	ListByUser(ctx context.Context, userID string) ([]*models.APIKey, error)
+	// Delete removes one of a user's keys. Returns ErrNotFound when the
+	// user has no key with that ID.
+	Delete(ctx context.Context, userID, id string) error
+	// DeleteByUser removes all of a user's keys
+	DeleteByUser(ctx context.Context, userID string) error
+	// TouchLastUsed records that the key was used at at
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+// DownloadEventRetention is how long download events are kept
+const DownloadEventRetention = 90 * 24 * time.Hour
+
+// DownloadEventRepository stores individual template and config downloads,
+// kept apart by resource type, for counting them over recent windows
+type DownloadEventRepository interface {
+	Record(ctx context.Context, event *models.DownloadEvent) error
+	// CountByDay counts a resource's downloads at or after since per UTC
+	// day, keyed by date (2006-01-02). Days without downloads are left out.
+	CountByDay(ctx context.Context, resourceType, resourceID string, since time.Time) (map[string]int, error)
+	// CountByPlatform counts a resource's downloads at or after since per
+	// platform. Downloads with no known platform are counted under "".
+	CountByPlatform(ctx context.Context, resourceType, resourceID string, since time.Time) (map[string]int, error)
+	// CountSince counts the downloads of every resource of the type at or
+	// after since
+	CountSince(ctx context.Context, resourceType string, since time.Time) (int, error)
+	// TopSince lists the resources of the type downloaded most at or after
+	// since, most downloads first
+	TopSince(ctx context.Context, resourceType string, since time.Time, limit int) ([]models.ResourceDownloads, error)
+}
+
 type ConfigRepository interface {
 	Create(ctx context.Context, config *models.StoredConfig) error
 	GetByID(ctx context.Context, id string) (*models.StoredConfig, error)
 	Update(ctx context.Context, config *models.StoredConfig) error
 	Delete(ctx context.Context, id string) error
-	List(ctx context.Context, limit, offset int) ([]*models.StoredConfig, error)
+	List(ctx context.Context, filters ConfigFilters) ([]*models.StoredConfig, error)
+	Count(ctx context.Context, filters ConfigFilters) (int, error)
+	// GetByOwner lists a user's configs, public and private, newest first
+	GetByOwner(ctx context.Context, ownerID string, limit, offset int) ([]*models.StoredConfig, error)
 	GetStats(ctx context.Context) (*models.ConfigStats, error)
 	IncrementDownloads(ctx context.Context, id string) error
+	// GetUnownedByAuthor lists configs uploaded without an account whose
+	// metadata author matches author, ignoring case, newest first
+	GetUnownedByAuthor(ctx context.Context, author string) ([]*models.StoredConfig, error)
+	// Claim gives an unowned config to ownerID. Returns ErrNotFound when
+	// the config does not exist or already has an owner.
+	Claim(ctx context.Context, id, ownerID string) error
+	// CountTags counts public configs per tag after mapping each config's
+	// tags through canonicalize, like TemplateRepository.CountTags
+	CountTags(ctx context.Context, canonicalize func([]string) []string) (map[string]int, error)
+	// FindByPackages lists public configs sharing at least one of the brews
+	// or casks, those sharing the most first, then the most downloaded
+	FindByPackages(ctx context.Context, brews, casks []string, limit int) ([]*models.StoredConfig, error)
 }
 
 type TemplateFilters struct {
-	Author         string
-	Tags           []string
+	Author string
+	// Tags holds one entry per requested tag, listing the normalized forms
+	// (see tags.Normalize) a stored tag may take to satisfy it
+	Tags           [][]string
 	Featured       *bool
 	Public         *bool
 	OrganizationID string
 	Limit          int
 	Offset         int
-	SortBy         string
-	SortOrder      string
+	// Sort orders results by each key in turn, ties on one key falling
+	// through to the next. Empty sorts by DefaultTemplateSort.
+	Sort []SortKey
+	// Fields lists the template JSON paths (e.g. "metadata.name") the caller
+	// needs. Stores may skip loading other fields; empty loads everything.
+	Fields []string
+	// IncludeUnlisted also matches templates unlisted pending report review,
+	// for listings of the caller's own templates
+	IncludeUnlisted bool
+	// Random returns up to Limit matches picked at random, in no particular
+	// order, instead of a sorted page. Sort and Offset are ignored.
+	Random bool
+	// Viewer limits results to the templates this caller may see. Nil
+	// matches templates whatever their visibility, for site admins and
+	// listings that are already scoped, such as the caller's own templates.
+	Viewer *TemplateViewer
+	DateRange
+}
+
+// TemplateViewer is who a template listing is for. They see public
+// templates, and private ones they wrote, that are shared with them or that
+// belong to one of their organizations. The zero value is an anonymous
+// caller, who only sees public templates.
+type TemplateViewer struct {
+	Username        string
+	OrganizationIDs []string
+}
+
+// CanView reports whether the viewer may see a template, by the same rules
+// as the handlers' visibility check
+func (v *TemplateViewer) CanView(template *models.StoredTemplate) bool {
+	if v == nil || template.Template.Public {
+		return true
+	}
+	if v.Username != "" && (template.Template.Metadata.Author == v.Username || slices.Contains(template.AllowedUsers, v.Username)) {
+		return true
+	}
+	return template.Template.OrganizationID != "" && slices.Contains(v.OrganizationIDs, template.Template.OrganizationID)
+}
+
+type ConfigFilters struct {
+	OwnerID string
+	Public  *bool
+	// Tags works like TemplateFilters.Tags: a config must carry a form of
+	// every requested tag
+	Tags [][]string
+	// Query keeps configs whose name, description, tags or author contain
+	// any of its search.Terms, ignoring case
+	Query     string
+	Limit     int
+	Offset    int
+	SortBy    string
+	SortOrder string
+	DateRange
+}
+
+// SortKey orders results by one field
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// TemplateSortFields are the fields templates can be sorted by
+var TemplateSortFields = []string{"created_at", "updated_at", "downloads", "popularity", "featured"}
+
+// DefaultTemplateSort lists the newest templates first
+var DefaultTemplateSort = []SortKey{{Field: "created_at", Desc: true}}
+
+// TemplateSort returns the keys templates are sorted by
+func (f TemplateFilters) TemplateSort() []SortKey {
+	if len(f.Sort) == 0 {
+		return DefaultTemplateSort
+	}
+	return f.Sort
+}
+
+// DateRange restricts results by creation and update time. Bounds are
+// inclusive and a nil bound is not applied.
+type DateRange struct {
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+}
+
+// Contains reports whether a record with the given timestamps falls inside
+// the range
+func (d DateRange) Contains(createdAt, updatedAt time.Time) bool {
+	if d.CreatedAfter != nil && createdAt.Before(*d.CreatedAfter) {
+		return false
+	}
+	if d.CreatedBefore != nil && createdAt.After(*d.CreatedBefore) {
+		return false
+	}
+	if d.UpdatedAfter != nil && updatedAt.Before(*d.UpdatedAfter) {
+		return false
+	}
+	return true
 }
 
 type Repositories struct {
@@ -108,4 +469,11 @@ type Repositories struct {
 	Organizations OrganizationRepository
 	Reviews       ReviewRepository
 	Configs       ConfigRepository
-}
\ No newline at end of file
+	Tags          TagRepository
+	Subscriptions SubscriptionRepository
+	Reports       ReportRepository
+	Audit         AuditRepository
+	Downloads     DownloadEventRepository
+	APIKeys       APIKeyRepository
+	ShareLinks    ShareLinkRepository
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+)
+
+type APIKeyRepository struct {
+	keys map[string]*models.APIKey
+	mu   sync.RWMutex
+}
+
+func NewAPIKeyRepository() *APIKeyRepository {
+	return &APIKeyRepository{
+		keys: make(map[string]*models.APIKey),
+	}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key.ID == "" {
+		key.ID = fmt.Sprintf("apikey-%d", time.Now().UnixNano())
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+
+	stored := *key
+	r.keys[key.ID] = &stored
+	return nil
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			result := *key
+			return &result, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.APIKey
+	for _, key := range r.keys {
+		if key.UserID == userID {
+			stored := *key
+			result = append(result, &stored)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.After(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (r *APIKeyRepository) Delete(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, exists := r.keys[id]
+	if !exists || key.UserID != userID {
+		return repository.ErrNotFound
+	}
+
+	delete(r.keys, id)
+	return nil
+}
+
+func (r *APIKeyRepository) DeleteByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, key := range r.keys {
+		if key.UserID == userID {
+			delete(r.keys, id)
+		}
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	key.LastUsed = &at
+	return nil
+}
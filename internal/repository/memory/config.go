@@ -0,0 +1,324 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/search"
+)
+
+type ConfigRepository struct {
+	configs map[string]*models.StoredConfig
+	mu      sync.RWMutex
+	limit   entryLimit
+}
+
+func NewConfigRepository() *ConfigRepository {
+	return &ConfigRepository{
+		configs: make(map[string]*models.StoredConfig),
+	}
+}
+
+// SetMaxEntries caps how many configs the store holds; Create fails with
+// repository.ErrStoreFull once it is full. Zero means unlimited.
+func (r *ConfigRepository) SetMaxEntries(max int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limit = entryLimit{max: max, kind: "config"}
+}
+
+func (r *ConfigRepository) Create(ctx context.Context, config *models.StoredConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if config.ID == "" {
+		config.ID = fmt.Sprintf("config-%d", time.Now().UnixNano())
+	}
+	if _, exists := r.configs[config.ID]; !exists {
+		if err := r.limit.check(len(r.configs)); err != nil {
+			return err
+		}
+	}
+
+	if config.CreatedAt.IsZero() {
+		config.CreatedAt = time.Now()
+	}
+	if config.UpdatedAt.IsZero() {
+		config.UpdatedAt = config.CreatedAt
+	}
+
+	r.configs[config.ID] = config
+	return nil
+}
+
+func (r *ConfigRepository) GetByID(ctx context.Context, id string) (*models.StoredConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	config, exists := r.configs[id]
+	if !exists {
+		return nil, nil // Handlers treat a nil config as not found
+	}
+
+	return config, nil
+}
+
+func (r *ConfigRepository) Update(ctx context.Context, config *models.StoredConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.configs[config.ID]; !exists {
+		return repository.ErrNotFound
+	}
+
+	config.UpdatedAt = time.Now()
+	r.configs[config.ID] = config
+	return nil
+}
+
+func (r *ConfigRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.configs[id]; !exists {
+		return repository.ErrNotFound
+	}
+
+	delete(r.configs, id)
+	return nil
+}
+
+func (r *ConfigRepository) List(ctx context.Context, filters repository.ConfigFilters) ([]*models.StoredConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := r.filter(filters)
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if filters.SortOrder == "asc" {
+			a, b = b, a
+		}
+
+		switch filters.SortBy {
+		case "download_count":
+			if a.DownloadCount != b.DownloadCount {
+				return a.DownloadCount > b.DownloadCount
+			}
+		default:
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.After(b.CreatedAt)
+			}
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	// Apply limit and offset
+	if filters.Offset > 0 && filters.Offset < len(result) {
+		result = result[filters.Offset:]
+	} else if filters.Offset >= len(result) {
+		result = []*models.StoredConfig{}
+	}
+
+	if filters.Limit > 0 && filters.Limit < len(result) {
+		result = result[:filters.Limit]
+	}
+
+	return result, nil
+}
+
+func (r *ConfigRepository) Count(ctx context.Context, filters repository.ConfigFilters) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.filter(filters)), nil
+}
+
+func (r *ConfigRepository) GetByOwner(ctx context.Context, ownerID string, limit, offset int) ([]*models.StoredConfig, error) {
+	return r.List(ctx, repository.ConfigFilters{
+		OwnerID: ownerID,
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
+
+// filter returns the configs matching the filters, ignoring pagination.
+// Callers must hold the read lock.
+func (r *ConfigRepository) filter(filters repository.ConfigFilters) []*models.StoredConfig {
+	result := make([]*models.StoredConfig, 0, len(r.configs))
+	terms := search.Terms(filters.Query)
+
+	for _, config := range r.configs {
+		if filters.OwnerID != "" && config.OwnerID != filters.OwnerID {
+			continue
+		}
+
+		if filters.Public != nil && config.Public != *filters.Public {
+			continue
+		}
+
+		if !filters.DateRange.Contains(config.CreatedAt, config.UpdatedAt) {
+			continue
+		}
+
+		if len(filters.Tags) > 0 && !hasAllTags(config.Config.Metadata.Tags, filters.Tags) {
+			continue
+		}
+
+		if len(terms) > 0 && !matchesConfigQuery(config.Config.Metadata, terms) {
+			continue
+		}
+
+		result = append(result, config)
+	}
+
+	return result
+}
+
+// matchesConfigQuery reports whether any term appears in the config's name,
+// description, tags or author
+func matchesConfigQuery(metadata models.ShareMetadata, terms []string) bool {
+	return search.Contains(metadata.Name, terms) ||
+		search.Contains(metadata.Description, terms) ||
+		search.Contains(strings.Join(metadata.Tags, ", "), terms) ||
+		search.Contains(metadata.Author, terms)
+}
+
+func (r *ConfigRepository) GetStats(ctx context.Context) (*models.ConfigStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := &models.ConfigStats{
+		TotalConfigs: len(r.configs),
+	}
+
+	for _, config := range r.configs {
+		if config.Public {
+			stats.PublicConfigs++
+		}
+		stats.TotalDownloads += config.DownloadCount
+	}
+
+	return stats, nil
+}
+
+func (r *ConfigRepository) CountTags(ctx context.Context, canonicalize func([]string) []string) (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, config := range r.configs {
+		if !config.Public {
+			continue
+		}
+		for _, tag := range canonicalize(config.Config.Metadata.Tags) {
+			counts[tag]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *ConfigRepository) IncrementDownloads(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config, exists := r.configs[id]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	config.DownloadCount++
+	return nil
+}
+
+func (r *ConfigRepository) GetUnownedByAuthor(ctx context.Context, author string) ([]*models.StoredConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.StoredConfig
+	for _, config := range r.configs {
+		if config.OwnerID == "" && strings.EqualFold(config.Config.Metadata.Author, author) {
+			result = append(result, config)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.After(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (r *ConfigRepository) Claim(ctx context.Context, id, ownerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config, exists := r.configs[id]
+	if !exists || config.OwnerID != "" {
+		return repository.ErrNotFound
+	}
+
+	config.OwnerID = ownerID
+	config.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *ConfigRepository) FindByPackages(ctx context.Context, brews, casks []string, limit int) ([]*models.StoredConfig, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	shared := make(map[string]int)
+	result := []*models.StoredConfig{}
+	for _, config := range r.configs {
+		if !config.Public {
+			continue
+		}
+		count := countShared(config.Config.Brews, brews) + countShared(config.Config.Casks, casks)
+		if count == 0 {
+			continue
+		}
+		shared[config.ID] = count
+		result = append(result, config)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if shared[a.ID] != shared[b.ID] {
+			return shared[a.ID] > shared[b.ID]
+		}
+		if a.DownloadCount != b.DownloadCount {
+			return a.DownloadCount > b.DownloadCount
+		}
+		return a.ID < b.ID
+	})
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// countShared counts the distinct packages found in both lists
+func countShared(have, want []string) int {
+	wanted := make(map[string]bool, len(want))
+	for _, name := range want {
+		wanted[name] = true
+	}
+
+	count := 0
+	for _, name := range have {
+		if wanted[name] {
+			count++
+			delete(wanted, name)
+		}
+	}
+	return count
+}
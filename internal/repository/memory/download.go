@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+)
+
+type DownloadEventRepository struct {
+	events []*models.DownloadEvent
+	mu     sync.RWMutex
+}
+
+func NewDownloadEventRepository() *DownloadEventRepository {
+	return &DownloadEventRepository{}
+}
+
+func (r *DownloadEventRepository) Record(ctx context.Context, event *models.DownloadEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID == "" {
+		event.ID = fmt.Sprintf("download-%d", time.Now().UnixNano())
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	// Events arrive roughly in order, so expired ones sit at the front
+	cutoff := time.Now().Add(-repository.DownloadEventRetention)
+	expired := 0
+	for expired < len(r.events) && r.events[expired].At.Before(cutoff) {
+		expired++
+	}
+	r.events = r.events[expired:]
+
+	stored := *event
+	r.events = append(r.events, &stored)
+	return nil
+}
+
+func (r *DownloadEventRepository) CountByDay(ctx context.Context, resourceType, resourceID string, since time.Time) (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, event := range r.events {
+		if event.ResourceType == resourceType && event.ResourceID == resourceID && !event.At.Before(since) {
+			counts[event.At.UTC().Format("2006-01-02")]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *DownloadEventRepository) CountByPlatform(ctx context.Context, resourceType, resourceID string, since time.Time) (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, event := range r.events {
+		if event.ResourceType == resourceType && event.ResourceID == resourceID && !event.At.Before(since) {
+			counts[event.Platform]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *DownloadEventRepository) CountSince(ctx context.Context, resourceType string, since time.Time) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, event := range r.events {
+		if event.ResourceType == resourceType && !event.At.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *DownloadEventRepository) TopSince(ctx context.Context, resourceType string, since time.Time, limit int) ([]models.ResourceDownloads, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, event := range r.events {
+		if event.ResourceType == resourceType && !event.At.Before(since) {
+			counts[event.ResourceID]++
+		}
+	}
+
+	result := make([]models.ResourceDownloads, 0, len(counts))
+	for id, downloads := range counts {
+		result = append(result, models.ResourceDownloads{ResourceID: id, Downloads: downloads})
+	}
+	slices.SortFunc(result, func(a, b models.ResourceDownloads) int {
+		return cmp.Or(cmp.Compare(b.Downloads, a.Downloads), cmp.Compare(a.ResourceID, b.ResourceID))
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/pkg/errors"
+)
+
+func (r *UserRepository) Follow(ctx context.Context, followerID, followeeID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, id := range []string{followerID, followeeID} {
+		if _, exists := r.users[id]; !exists {
+			return errors.NewNotFoundError("user")
+		}
+	}
+
+	followees := r.following[followerID]
+	if followees == nil {
+		followees = make(map[string]time.Time)
+		r.following[followerID] = followees
+	}
+	if _, exists := followees[followeeID]; !exists {
+		followees[followeeID] = time.Now()
+	}
+	return nil
+}
+
+func (r *UserRepository) Unfollow(ctx context.Context, followerID, followeeID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.following[followerID][followeeID]; !exists {
+		return repository.ErrNotFound
+	}
+	delete(r.following[followerID], followeeID)
+	return nil
+}
+
+func (r *UserRepository) GetFollowing(ctx context.Context, userID string, limit, offset int) ([]*models.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.followedUsers(r.following[userID], limit, offset), nil
+}
+
+func (r *UserRepository) GetFollowers(ctx context.Context, userID string, limit, offset int) ([]*models.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	followers := make(map[string]time.Time)
+	for followerID, followees := range r.following {
+		if at, ok := followees[userID]; ok {
+			followers[followerID] = at
+		}
+	}
+	return r.followedUsers(followers, limit, offset), nil
+}
+
+// followedUsers pages through the users in follows, which maps user ID to
+// follow time, most recent follow first. Deleted users are left out.
+func (r *UserRepository) followedUsers(follows map[string]time.Time, limit, offset int) []*models.User {
+	users := make([]*models.User, 0, len(follows))
+	for id := range follows {
+		if user, exists := r.users[id]; exists && !user.IsDeleted() {
+			users = append(users, user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		a, b := follows[users[i].ID], follows[users[j].ID]
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return users[i].ID < users[j].ID
+	})
+
+	return paginateUsers(users, limit, offset)
+}
@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"log"
+
+	"dotfiles-api/internal/repository"
+)
+
+// entryLimit caps how many entries a store holds, so a server running
+// without MongoDB cannot be made to grow without bound. The zero value is
+// unlimited.
+type entryLimit struct {
+	max  int
+	kind string
+}
+
+// check returns repository.ErrStoreFull if a store holding count entries
+// has no room for another. Stores reject new entries rather than evict old
+// ones, since evicting would silently lose user data.
+func (l entryLimit) check(count int) error {
+	if l.max <= 0 || count < l.max {
+		return nil
+	}
+	log.Printf("Rejected new %s: in-memory store is at its limit of %d", l.kind, l.max)
+	return repository.ErrStoreFull
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+)
+
+func TestFullStoreRejectsNewEntries(t *testing.T) {
+	ctx := context.Background()
+	repo := NewConfigRepository()
+	repo.SetMaxEntries(2)
+
+	for _, id := range []string{"a", "b"} {
+		if err := repo.Create(ctx, &models.StoredConfig{ID: id}); err != nil {
+			t.Fatalf("Failed to create config %s: %v", id, err)
+		}
+	}
+
+	err := repo.Create(ctx, &models.StoredConfig{ID: "c"})
+	if !errors.Is(err, repository.ErrStoreFull) || !errors.Is(err, repository.ErrStorageUnavailable) {
+		t.Fatalf("Expected ErrStoreFull wrapping ErrStorageUnavailable, got %v", err)
+	}
+	// Existing entries are kept, and can still be replaced
+	if _, err := repo.GetByID(ctx, "a"); err != nil {
+		t.Errorf("Expected the existing config to be kept, got %v", err)
+	}
+	if err := repo.Create(ctx, &models.StoredConfig{ID: "a"}); err != nil {
+		t.Errorf("Expected replacing an entry to succeed when full, got %v", err)
+	}
+
+	if err := repo.Delete(ctx, "b"); err != nil {
+		t.Fatalf("Failed to delete config: %v", err)
+	}
+	if err := repo.Create(ctx, &models.StoredConfig{ID: "c"}); err != nil {
+		t.Errorf("Expected room after a delete, got %v", err)
+	}
+}
+
+func TestFullUserStoreRejectsNewUsers(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository()
+	repo.SetMaxEntries(1)
+
+	if err := repo.Create(ctx, &models.User{Username: "alice", Email: "alice@example.com"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	err := repo.Create(ctx, &models.User{Username: "bob", Email: "bob@example.com"})
+	if !errors.Is(err, repository.ErrStoreFull) {
+		t.Errorf("Expected ErrStoreFull, got %v", err)
+	}
+}
@@ -0,0 +1,805 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+)
+
+type OrganizationRepository struct {
+	orgs         map[string]*models.Organization
+	members      map[string]map[string]*models.OrganizationMember // orgID -> userID -> member
+	invites      map[string]*models.OrganizationInvite            // token hash -> invite
+	joinRequests map[string]*models.OrganizationJoinRequest       // ID -> request
+	mu           sync.RWMutex
+}
+
+func NewOrganizationRepository() *OrganizationRepository {
+	return &OrganizationRepository{
+		orgs:         make(map[string]*models.Organization),
+		members:      make(map[string]map[string]*models.OrganizationMember),
+		invites:      make(map[string]*models.OrganizationInvite),
+		joinRequests: make(map[string]*models.OrganizationJoinRequest),
+	}
+}
+
+func (r *OrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if org.ID == "" {
+		org.ID = fmt.Sprintf("org-%d", time.Now().UnixNano())
+	}
+
+	for _, existing := range r.orgs {
+		if existing.Slug == org.Slug {
+			return repository.ErrAlreadyExists
+		}
+	}
+
+	org.CreatedAt = time.Now()
+	org.UpdatedAt = time.Now()
+
+	copied := *org
+	r.orgs[org.ID] = &copied
+	return nil
+}
+
+func (r *OrganizationRepository) GetByID(ctx context.Context, id string) (*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	org, exists := r.orgs[id]
+	if !exists {
+		return nil, nil // Handlers treat a nil organization as not found
+	}
+
+	copied := *org
+	return &copied, nil
+}
+
+func (r *OrganizationRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.Organization
+	for _, id := range ids {
+		if org, exists := r.orgs[id]; exists {
+			copied := *org
+			result = append(result, &copied)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *OrganizationRepository) GetBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, org := range r.orgs {
+		if org.Slug == slug {
+			copied := *org
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *OrganizationRepository) Update(ctx context.Context, org *models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.orgs[org.ID]; !exists {
+		return repository.ErrNotFound
+	}
+
+	org.UpdatedAt = time.Now()
+
+	copied := *org
+	r.orgs[org.ID] = &copied
+	return nil
+}
+
+func (r *OrganizationRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.orgs[id]; !exists {
+		return repository.ErrNotFound
+	}
+
+	delete(r.orgs, id)
+	delete(r.members, id)
+	for token, invite := range r.invites {
+		if invite.OrganizationID == id {
+			delete(r.invites, token)
+		}
+	}
+	return nil
+}
+
+func (r *OrganizationRepository) List(ctx context.Context, limit, offset int) ([]*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.Organization
+	for _, org := range r.orgs {
+		if org.Public {
+			result = append(result, org)
+		}
+	}
+
+	return paginateOrganizations(result, limit, offset), nil
+}
+
+func (r *OrganizationRepository) ListAll(ctx context.Context, limit, offset int) ([]*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.Organization
+	for _, org := range r.orgs {
+		copied := *org
+		result = append(result, &copied)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	if offset >= len(result) {
+		return []*models.Organization{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+func (r *OrganizationRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	query = strings.ToLower(query)
+
+	var result []*models.Organization
+	for _, org := range r.orgs {
+		if !org.Public {
+			continue
+		}
+		if strings.Contains(strings.ToLower(org.Name), query) ||
+			strings.Contains(strings.ToLower(org.Description), query) {
+			result = append(result, org)
+		}
+	}
+
+	return paginateOrganizations(result, limit, offset), nil
+}
+
+func (r *OrganizationRepository) GetByOwner(ctx context.Context, ownerID string) ([]*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.Organization
+	for _, org := range r.orgs {
+		if org.OwnerID == ownerID {
+			copied := *org
+			result = append(result, &copied)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *OrganizationRepository) GetUserOrganizations(ctx context.Context, userID string) ([]*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []*models.Organization{}
+	for orgID, members := range r.members {
+		if _, isMember := members[userID]; !isMember {
+			continue
+		}
+		if org, exists := r.orgs[orgID]; exists {
+			copied := *org
+			result = append(result, &copied)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *OrganizationRepository) GetUserMemberships(ctx context.Context, userID string) ([]*models.OrganizationMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := []*models.OrganizationMember{}
+	for _, members := range r.members {
+		if member, isMember := members[userID]; isMember {
+			copied := *member
+			result = append(result, &copied)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *OrganizationRepository) SetMaxMembers(ctx context.Context, orgID string, maxMembers int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, exists := r.orgs[orgID]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	org.MaxMembers = maxMembers
+	org.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *OrganizationRepository) SetPinnedTemplates(ctx context.Context, orgID string, templateIDs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, exists := r.orgs[orgID]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	org.PinnedTemplateIDs = append([]string(nil), templateIDs...)
+	org.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *OrganizationRepository) SetMemberCount(ctx context.Context, orgID string, from, to int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, exists := r.orgs[orgID]
+	if !exists {
+		return false, repository.ErrNotFound
+	}
+	if org.MemberCount != from {
+		return false, nil
+	}
+
+	org.MemberCount = to
+	return true, nil
+}
+
+func (r *OrganizationRepository) GetByCustomDomain(ctx context.Context, domain string) (*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if domain == "" {
+		return nil, nil
+	}
+
+	for _, org := range r.orgs {
+		if org.CustomDomain == domain {
+			copied := *org
+			return &copied, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *OrganizationRepository) SetCustomDomain(ctx context.Context, orgID, domain, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, exists := r.orgs[orgID]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	if domain != "" {
+		for id, other := range r.orgs {
+			if id != orgID && other.CustomDomain == domain {
+				return repository.ErrAlreadyExists
+			}
+		}
+	}
+
+	org.CustomDomain = domain
+	org.DomainToken = token
+	org.DomainVerifiedAt = nil
+	org.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *OrganizationRepository) VerifyCustomDomain(ctx context.Context, orgID, domain string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, exists := r.orgs[orgID]
+	if !exists || domain == "" || org.CustomDomain != domain {
+		return repository.ErrNotFound
+	}
+
+	now := time.Now()
+	org.DomainVerifiedAt = &now
+	org.UpdatedAt = now
+	return nil
+}
+
+// paginateOrganizations sorts newest first and applies limit and offset,
+// returning copies so callers cannot mutate stored organizations
+func paginateOrganizations(orgs []*models.Organization, limit, offset int) []*models.Organization {
+	sort.Slice(orgs, func(i, j int) bool {
+		return orgs[i].CreatedAt.After(orgs[j].CreatedAt)
+	})
+
+	if offset >= len(orgs) {
+		return []*models.Organization{}
+	}
+	orgs = orgs[offset:]
+
+	if limit > 0 && limit < len(orgs) {
+		orgs = orgs[:limit]
+	}
+
+	result := make([]*models.Organization, len(orgs))
+	for i, org := range orgs {
+		copied := *org
+		result[i] = &copied
+	}
+	return result
+}
+
+// AddMember adds a member if members plus pending invites leave room under
+// MaxMembers
+func (r *OrganizationRepository) AddMember(ctx context.Context, member *models.OrganizationMember) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, exists := r.orgs[member.OrganizationID]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	if _, isMember := r.members[org.ID][member.UserID]; isMember {
+		return repository.ErrAlreadyExists
+	}
+
+	if org.MaxMembers > 0 && org.MemberCount+r.pendingInvites(org.ID) >= org.MaxMembers {
+		return repository.ErrOrganizationFull
+	}
+
+	if member.ID == "" {
+		member.ID = fmt.Sprintf("member-%d", time.Now().UnixNano())
+	}
+	member.JoinedAt = time.Now()
+
+	r.addMember(org, member)
+	return nil
+}
+
+// addMember stores the member and bumps the count. Callers must hold the lock.
+func (r *OrganizationRepository) addMember(org *models.Organization, member *models.OrganizationMember) {
+	if r.members[org.ID] == nil {
+		r.members[org.ID] = make(map[string]*models.OrganizationMember)
+	}
+	r.members[org.ID][member.UserID] = member
+	org.MemberCount++
+}
+
+func (r *OrganizationRepository) ListMembers(ctx context.Context, limit, offset int) ([]*models.OrganizationMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.OrganizationMember
+	for _, members := range r.members {
+		for _, member := range members {
+			copied := *member
+			result = append(result, &copied)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	if offset >= len(result) {
+		return []*models.OrganizationMember{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, isMember := r.members[orgID][userID]; !isMember {
+		return repository.ErrNotFound
+	}
+
+	delete(r.members[orgID], userID)
+	if org, exists := r.orgs[orgID]; exists {
+		org.MemberCount--
+	}
+	return nil
+}
+
+func (r *OrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	member, isMember := r.members[orgID][userID]
+	if !isMember {
+		return repository.ErrNotFound
+	}
+
+	member.Role = role
+	return nil
+}
+
+func (r *OrganizationRepository) GetMembers(ctx context.Context, orgID string) ([]*models.OrganizationMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.OrganizationMember
+	for _, member := range r.members[orgID] {
+		copied := *member
+		result = append(result, &copied)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].JoinedAt.Before(result[j].JoinedAt)
+	})
+
+	return result, nil
+}
+
+func (r *OrganizationRepository) GetMember(ctx context.Context, orgID, userID string) (*models.OrganizationMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	member, isMember := r.members[orgID][userID]
+	if !isMember {
+		return nil, nil
+	}
+
+	copied := *member
+	return &copied, nil
+}
+
+func (r *OrganizationRepository) IsMember(ctx context.Context, orgID, userID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, isMember := r.members[orgID][userID]
+	return isMember, nil
+}
+
+// CreateInvite stores an invite. An email may only hold one active invite
+// per organization, and pending invites hold a seat, so the invite is
+// refused once members plus pending invites reach MaxMembers.
+func (r *OrganizationRepository) CreateInvite(ctx context.Context, invite *models.OrganizationInvite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, exists := r.orgs[invite.OrganizationID]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	if r.activeInvite(org.ID, invite.Email) != nil {
+		return repository.ErrAlreadyExists
+	}
+
+	if org.MaxMembers > 0 && org.MemberCount+r.pendingInvites(org.ID) >= org.MaxMembers {
+		return repository.ErrOrganizationFull
+	}
+
+	if invite.ID == "" {
+		invite.ID = fmt.Sprintf("invite-%d", time.Now().UnixNano())
+	}
+	invite.CreatedAt = time.Now()
+	invite.TokenHash = models.HashInviteToken(invite.Token)
+
+	// Keep only the hash, like the MongoDB store
+	stored := *invite
+	stored.Token = ""
+	r.invites[stored.TokenHash] = &stored
+	return nil
+}
+
+func (r *OrganizationRepository) GetInvite(ctx context.Context, token string) (*models.OrganizationInvite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invite, exists := r.invites[models.HashInviteToken(token)]
+	if !exists {
+		return nil, nil
+	}
+
+	copied := *invite
+	return &copied, nil
+}
+
+func (r *OrganizationRepository) GetActiveInvite(ctx context.Context, orgID, email string) (*models.OrganizationInvite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invite := r.activeInvite(orgID, email)
+	if invite == nil {
+		return nil, nil
+	}
+
+	copied := *invite
+	return &copied, nil
+}
+
+// activeInvite finds the unexpired, unaccepted invite for an email. Callers
+// must hold the lock.
+func (r *OrganizationRepository) activeInvite(orgID, email string) *models.OrganizationInvite {
+	now := time.Now()
+	for _, invite := range r.invites {
+		if invite.OrganizationID == orgID && invite.Email == email &&
+			invite.AcceptedAt == nil && invite.ExpiresAt.After(now) {
+			return invite
+		}
+	}
+	return nil
+}
+
+// RenewInvite swaps an unaccepted invite's token and pushes out its expiry
+func (r *OrganizationRepository) RenewInvite(ctx context.Context, orgID, id, token string, expiresAt time.Time) (*models.OrganizationInvite, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var invite *models.OrganizationInvite
+	for _, candidate := range r.invites {
+		if candidate.ID == id && candidate.OrganizationID == orgID && candidate.AcceptedAt == nil {
+			invite = candidate
+			break
+		}
+	}
+	if invite == nil {
+		return nil, repository.ErrNotFound
+	}
+
+	if active := r.activeInvite(orgID, invite.Email); active != nil && active != invite {
+		return nil, repository.ErrAlreadyExists
+	}
+
+	delete(r.invites, invite.TokenHash)
+	invite.TokenHash = models.HashInviteToken(token)
+	invite.ExpiresAt = expiresAt
+	r.invites[invite.TokenHash] = invite
+
+	copied := *invite
+	return &copied, nil
+}
+
+func (r *OrganizationRepository) GetInvitesByOrganization(ctx context.Context, orgID string) ([]*models.OrganizationInvite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.OrganizationInvite
+	for _, invite := range r.invites {
+		if invite.OrganizationID == orgID {
+			copied := *invite
+			result = append(result, &copied)
+		}
+	}
+
+	return result, nil
+}
+
+func (r *OrganizationRepository) CountPendingInvites(ctx context.Context, orgID string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.pendingInvites(orgID), nil
+}
+
+// pendingInvites counts unexpired, unaccepted invites. Callers must hold the lock.
+func (r *OrganizationRepository) pendingInvites(orgID string) int {
+	now := time.Now()
+	count := 0
+	for _, invite := range r.invites {
+		if invite.OrganizationID == orgID && invite.AcceptedAt == nil && invite.ExpiresAt.After(now) {
+			count++
+		}
+	}
+	return count
+}
+
+// AcceptInvite claims a pending invite and adds the user with the invite's
+// role. The invite already holds a seat, so it only needs MemberCount to be
+// below MaxMembers.
+func (r *OrganizationRepository) AcceptInvite(ctx context.Context, token string, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	invite, exists := r.invites[models.HashInviteToken(token)]
+	if !exists || invite.AcceptedAt != nil || !invite.ExpiresAt.After(now) {
+		return repository.ErrNotFound
+	}
+
+	org, exists := r.orgs[invite.OrganizationID]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	if _, isMember := r.members[org.ID][userID]; isMember {
+		return repository.ErrAlreadyExists
+	}
+
+	if org.MaxMembers > 0 && org.MemberCount >= org.MaxMembers {
+		return repository.ErrOrganizationFull
+	}
+
+	invite.AcceptedAt = &now
+	r.addMember(org, &models.OrganizationMember{
+		ID:             fmt.Sprintf("member-%d", now.UnixNano()),
+		OrganizationID: org.ID,
+		UserID:         userID,
+		Role:           invite.Role,
+		JoinedAt:       now,
+	})
+	return nil
+}
+
+func (r *OrganizationRepository) DeleteInvite(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for token, invite := range r.invites {
+		if invite.ID == id {
+			delete(r.invites, token)
+			return nil
+		}
+	}
+
+	return repository.ErrNotFound
+}
+
+func (r *OrganizationRepository) CleanupExpiredInvites(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for token, invite := range r.invites {
+		if invite.AcceptedAt == nil && invite.ExpiresAt.Before(now) {
+			delete(r.invites, token)
+		}
+	}
+	return nil
+}
+
+func (r *OrganizationRepository) SetAllowJoinRequests(ctx context.Context, orgID string, allow bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	org, exists := r.orgs[orgID]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	org.AllowJoinRequests = allow
+	org.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *OrganizationRepository) CreateJoinRequest(ctx context.Context, request *models.OrganizationJoinRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Checked under the lock so concurrent requests cannot both pass
+	for _, existing := range r.joinRequests {
+		if existing.Status == models.JoinRequestPending &&
+			existing.OrganizationID == request.OrganizationID &&
+			existing.UserID == request.UserID {
+			return repository.ErrAlreadyExists
+		}
+	}
+
+	if request.ID == "" {
+		request.ID = fmt.Sprintf("join-request-%d", time.Now().UnixNano())
+	}
+	request.Status = models.JoinRequestPending
+	request.CreatedAt = time.Now()
+
+	stored := *request
+	r.joinRequests[request.ID] = &stored
+	return nil
+}
+
+func (r *OrganizationRepository) GetJoinRequest(ctx context.Context, orgID, id string) (*models.OrganizationJoinRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	request, exists := r.joinRequests[id]
+	if !exists || request.OrganizationID != orgID {
+		return nil, repository.ErrNotFound
+	}
+
+	result := *request
+	return &result, nil
+}
+
+func (r *OrganizationRepository) ListJoinRequests(ctx context.Context, orgID, status string, limit, offset int) ([]*models.OrganizationJoinRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := r.joinRequestsWith(orgID, status)
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	if offset >= len(result) {
+		return []*models.OrganizationJoinRequest{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+func (r *OrganizationRepository) CountJoinRequests(ctx context.Context, orgID, status string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.joinRequestsWith(orgID, status)), nil
+}
+
+// joinRequestsWith copies the organization's join requests with the status.
+// Callers must hold the lock.
+func (r *OrganizationRepository) joinRequestsWith(orgID, status string) []*models.OrganizationJoinRequest {
+	var result []*models.OrganizationJoinRequest
+	for _, request := range r.joinRequests {
+		if request.OrganizationID == orgID && request.Status == status {
+			copied := *request
+			result = append(result, &copied)
+		}
+	}
+	return result
+}
+
+func (r *OrganizationRepository) ResolveJoinRequest(ctx context.Context, orgID, id, status, resolvedBy, reason string, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	request, exists := r.joinRequests[id]
+	if !exists || request.OrganizationID != orgID {
+		return false, repository.ErrNotFound
+	}
+	if request.Status != models.JoinRequestPending {
+		return false, nil
+	}
+
+	resolvedAt := at
+	request.Status = status
+	request.ResolvedBy = resolvedBy
+	request.ResolvedAt = &resolvedAt
+	request.Reason = reason
+	return true, nil
+}
@@ -0,0 +1,320 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+)
+
+func newLimitedOrganization(t *testing.T, repo *OrganizationRepository, maxMembers int) *models.Organization {
+	t.Helper()
+	ctx := context.Background()
+
+	org := &models.Organization{ID: "org-1", Name: "Acme", Slug: "acme", OwnerID: "owner", MaxMembers: maxMembers}
+	if err := repo.Create(ctx, org); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+
+	if err := repo.AddMember(ctx, &models.OrganizationMember{OrganizationID: org.ID, UserID: "owner", Role: models.RoleOwner}); err != nil {
+		t.Fatalf("Failed to add owner: %v", err)
+	}
+
+	return org
+}
+
+func createInvite(t *testing.T, repo *OrganizationRepository, orgID, token string) error {
+	t.Helper()
+
+	return repo.CreateInvite(context.Background(), &models.OrganizationInvite{
+		OrganizationID: orgID,
+		Email:          token + "@example.com",
+		Role:           models.RoleMember,
+		Token:          token,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	})
+}
+
+func TestPendingInvitesCountTowardLimit(t *testing.T) {
+	repo := NewOrganizationRepository()
+	ctx := context.Background()
+	org := newLimitedOrganization(t, repo, 2)
+
+	if err := createInvite(t, repo, org.ID, "invite-a"); err != nil {
+		t.Fatalf("Failed to create invite: %v", err)
+	}
+
+	if err := createInvite(t, repo, org.ID, "invite-b"); !errors.Is(err, repository.ErrOrganizationFull) {
+		t.Errorf("Expected ErrOrganizationFull for invite, got %v", err)
+	}
+
+	err := repo.AddMember(ctx, &models.OrganizationMember{OrganizationID: org.ID, UserID: "direct", Role: models.RoleMember})
+	if !errors.Is(err, repository.ErrOrganizationFull) {
+		t.Errorf("Expected ErrOrganizationFull for direct add, got %v", err)
+	}
+
+	// The pending invite already holds its seat
+	if err := repo.AcceptInvite(ctx, "invite-a", "user-a"); err != nil {
+		t.Fatalf("Failed to accept invite: %v", err)
+	}
+
+	if err := repo.AcceptInvite(ctx, "invite-a", "user-b"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected accepted invite to be unusable, got %v", err)
+	}
+
+	stored, _ := repo.GetByID(ctx, org.ID)
+	if stored.MemberCount != 2 {
+		t.Errorf("Expected 2 members, got %d", stored.MemberCount)
+	}
+}
+
+func TestUnlimitedOrganization(t *testing.T) {
+	repo := NewOrganizationRepository()
+	ctx := context.Background()
+	org := newLimitedOrganization(t, repo, 0)
+
+	for _, userID := range []string{"a", "b", "c"} {
+		if err := repo.AddMember(ctx, &models.OrganizationMember{OrganizationID: org.ID, UserID: userID, Role: models.RoleMember}); err != nil {
+			t.Fatalf("Expected unlimited organization to accept %s, got %v", userID, err)
+		}
+	}
+
+	err := repo.AddMember(ctx, &models.OrganizationMember{OrganizationID: org.ID, UserID: "a", Role: models.RoleMember})
+	if !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists for duplicate member, got %v", err)
+	}
+}
+
+func TestConcurrentAcceptAtLimit(t *testing.T) {
+	repo := NewOrganizationRepository()
+	ctx := context.Background()
+	org := newLimitedOrganization(t, repo, 3)
+
+	for _, token := range []string{"invite-a", "invite-b"} {
+		if err := createInvite(t, repo, org.ID, token); err != nil {
+			t.Fatalf("Failed to create invite: %v", err)
+		}
+	}
+
+	// Lower the limit so only one of the two outstanding invites fits
+	if err := repo.SetMaxMembers(ctx, org.ID, 2); err != nil {
+		t.Fatalf("Failed to set max members: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	results := make([]error, 2)
+	for i, token := range []string{"invite-a", "invite-b"} {
+		wg.Add(1)
+		go func(i int, token string) {
+			defer wg.Done()
+			<-start
+			results[i] = repo.AcceptInvite(ctx, token, "user-"+token)
+		}(i, token)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded, full := 0, 0
+	for _, err := range results {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, repository.ErrOrganizationFull):
+			full++
+		default:
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+
+	if succeeded != 1 || full != 1 {
+		t.Errorf("Expected one accept and one ErrOrganizationFull, got %d and %d", succeeded, full)
+	}
+
+	stored, _ := repo.GetByID(ctx, org.ID)
+	if stored.MemberCount != 2 {
+		t.Errorf("Expected member count to stop at 2, got %d", stored.MemberCount)
+	}
+
+	members, _ := repo.GetMembers(ctx, org.ID)
+	if len(members) != 2 {
+		t.Errorf("Expected 2 members, got %d", len(members))
+	}
+}
+
+func TestConcurrentDoubleAccept(t *testing.T) {
+	repo := NewOrganizationRepository()
+	ctx := context.Background()
+	org := newLimitedOrganization(t, repo, 0)
+
+	if err := createInvite(t, repo, org.ID, "invite-a"); err != nil {
+		t.Fatalf("Failed to create invite: %v", err)
+	}
+
+	// Only the hash is kept
+	invites, _ := repo.GetInvitesByOrganization(ctx, org.ID)
+	if len(invites) != 1 || invites[0].Token != "" || invites[0].TokenHash != models.HashInviteToken("invite-a") {
+		t.Fatalf("Expected one invite stored by its token hash, got %+v", invites)
+	}
+
+	const attempts = 10
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	results := make([]error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i] = repo.AcceptInvite(ctx, "invite-a", fmt.Sprintf("user-%d", i))
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range results {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, repository.ErrNotFound):
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("Expected exactly one accept to succeed, got %d", succeeded)
+	}
+
+	members, _ := repo.GetMembers(ctx, org.ID)
+	if len(members) != 2 {
+		t.Errorf("Expected the owner and one new member, got %d members", len(members))
+	}
+}
+
+func TestCustomDomainIsUniqueAndReverifiedOnChange(t *testing.T) {
+	repo := NewOrganizationRepository()
+	ctx := context.Background()
+
+	for _, org := range []*models.Organization{
+		{ID: "org-a", Name: "Acme", Slug: "acme"},
+		{ID: "org-b", Name: "Other", Slug: "other"},
+	} {
+		if err := repo.Create(ctx, org); err != nil {
+			t.Fatalf("Failed to create organization: %v", err)
+		}
+	}
+
+	if err := repo.SetCustomDomain(ctx, "org-a", "acme.example", "token-1"); err != nil {
+		t.Fatalf("Failed to set custom domain: %v", err)
+	}
+	if err := repo.SetCustomDomain(ctx, "org-b", "acme.example", "token-2"); !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists for a taken domain, got %v", err)
+	}
+
+	// Verification only applies to the domain that was challenged
+	if err := repo.VerifyCustomDomain(ctx, "org-a", "old.example"); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a stale domain, got %v", err)
+	}
+	if err := repo.VerifyCustomDomain(ctx, "org-a", "acme.example"); err != nil {
+		t.Fatalf("Failed to verify custom domain: %v", err)
+	}
+
+	org, err := repo.GetByCustomDomain(ctx, "acme.example")
+	if err != nil || org == nil || org.ID != "org-a" || !org.DomainVerified() {
+		t.Fatalf("Expected org-a with a verified domain, got %+v (%v)", org, err)
+	}
+
+	// Changing the domain drops the verification
+	if err := repo.SetCustomDomain(ctx, "org-a", "dotfiles.acme.example", "token-3"); err != nil {
+		t.Fatalf("Failed to change custom domain: %v", err)
+	}
+	org, _ = repo.GetByID(ctx, "org-a")
+	if org.DomainVerified() || org.DomainToken != "token-3" {
+		t.Errorf("Expected an unverified domain with the new token, got %+v", org)
+	}
+
+	// The released domain can be claimed by another organization
+	if err := repo.SetCustomDomain(ctx, "org-b", "acme.example", "token-4"); err != nil {
+		t.Errorf("Expected the released domain to be available, got %v", err)
+	}
+}
+
+func TestOneActiveInvitePerEmail(t *testing.T) {
+	repo := NewOrganizationRepository()
+	ctx := context.Background()
+	org := newLimitedOrganization(t, repo, 0)
+	if err := repo.Create(ctx, &models.Organization{ID: "org-2", Name: "Other", Slug: "other", OwnerID: "owner"}); err != nil {
+		t.Fatalf("Failed to create organization: %v", err)
+	}
+
+	invite := func(orgID, email, token string, expiresAt time.Time) error {
+		return repo.CreateInvite(ctx, &models.OrganizationInvite{
+			OrganizationID: orgID,
+			Email:          email,
+			Role:           models.RoleMember,
+			Token:          token,
+			ExpiresAt:      expiresAt,
+		})
+	}
+	later := time.Now().Add(time.Hour)
+
+	if err := invite(org.ID, "dev@example.com", "first", later); err != nil {
+		t.Fatalf("Failed to create invite: %v", err)
+	}
+	if err := invite(org.ID, "dev@example.com", "second", later); !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists for a second active invite, got %v", err)
+	}
+	if err := invite("org-2", "dev@example.com", "elsewhere", later); err != nil {
+		t.Errorf("Expected the same email to be invitable to another organization, got %v", err)
+	}
+
+	active, err := repo.GetActiveInvite(ctx, org.ID, "dev@example.com")
+	if err != nil || active == nil || active.TokenHash != models.HashInviteToken("first") {
+		t.Fatalf("Expected the first invite to be active, got %v (%v)", active, err)
+	}
+
+	// Renewing swaps the token and pushes out the expiry
+	renewed, err := repo.RenewInvite(ctx, org.ID, active.ID, "renewed", later.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to renew invite: %v", err)
+	}
+	if renewed.TokenHash != models.HashInviteToken("renewed") || !renewed.ExpiresAt.After(later) {
+		t.Errorf("Expected a new token and later expiry, got %v", renewed)
+	}
+	if old, _ := repo.GetInvite(ctx, "first"); old != nil {
+		t.Error("Expected the old token to stop working")
+	}
+
+	if _, err := repo.RenewInvite(ctx, "org-2", active.ID, "stolen", later); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound renewing another organization's invite, got %v", err)
+	}
+
+	// Expired invites no longer block a new one, and cannot be revived
+	// alongside it
+	if err := invite(org.ID, "late@example.com", "expired", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("Failed to create expired invite: %v", err)
+	}
+	expired, _ := repo.GetInvite(ctx, "expired")
+	if err := invite(org.ID, "late@example.com", "fresh", later); err != nil {
+		t.Fatalf("Expected an expired invite not to block a new one, got %v", err)
+	}
+	if _, err := repo.RenewInvite(ctx, org.ID, expired.ID, "revived", later); !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists reviving a superseded invite, got %v", err)
+	}
+
+	// Accepted invites are neither active nor renewable
+	if err := repo.AcceptInvite(ctx, "renewed", "dev"); err != nil {
+		t.Fatalf("Failed to accept invite: %v", err)
+	}
+	if _, err := repo.RenewInvite(ctx, org.ID, renewed.ID, "again", later); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound renewing an accepted invite, got %v", err)
+	}
+	if active, _ := repo.GetActiveInvite(ctx, org.ID, "dev@example.com"); active != nil {
+		t.Errorf("Expected no active invite after acceptance, got %v", active)
+	}
+}
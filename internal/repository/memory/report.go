@@ -0,0 +1,161 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+)
+
+type ReportRepository struct {
+	reports map[string]*models.TemplateReport
+	mu      sync.RWMutex
+}
+
+func NewReportRepository() *ReportRepository {
+	return &ReportRepository{
+		reports: make(map[string]*models.TemplateReport),
+	}
+}
+
+func (r *ReportRepository) Create(ctx context.Context, report *models.TemplateReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Checked under the lock so concurrent reports cannot both pass
+	for _, existing := range r.reports {
+		if existing.Status == models.ReportStatusOpen &&
+			existing.ReporterID == report.ReporterID &&
+			existing.TemplateID == report.TemplateID {
+			return repository.ErrAlreadyExists
+		}
+	}
+
+	if report.ID == "" {
+		report.ID = fmt.Sprintf("report-%d", time.Now().UnixNano())
+	}
+	report.Status = models.ReportStatusOpen
+	report.CreatedAt = time.Now()
+
+	stored := *report
+	r.reports[report.ID] = &stored
+	return nil
+}
+
+func (r *ReportRepository) GetByID(ctx context.Context, id string) (*models.TemplateReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	report, exists := r.reports[id]
+	if !exists {
+		return nil, repository.ErrNotFound
+	}
+
+	result := *report
+	return &result, nil
+}
+
+func (r *ReportRepository) ListOpen(ctx context.Context) ([]*models.TemplateReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.TemplateReport
+	for _, report := range r.reports {
+		if report.Status == models.ReportStatusOpen {
+			open := *report
+			result = append(result, &open)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (r *ReportRepository) CountOpen(ctx context.Context, templateID string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, report := range r.reports {
+		if report.Status == models.ReportStatusOpen && report.TemplateID == templateID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *ReportRepository) ResolveOpen(ctx context.Context, templateID, action, resolvedBy string, at time.Time) ([]*models.TemplateReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var resolved []*models.TemplateReport
+	for _, report := range r.reports {
+		if report.Status != models.ReportStatusOpen || report.TemplateID != templateID {
+			continue
+		}
+
+		resolvedAt := at
+		report.Status = models.ReportStatusResolved
+		report.Action = action
+		report.ResolvedBy = resolvedBy
+		report.ResolvedAt = &resolvedAt
+
+		result := *report
+		resolved = append(resolved, &result)
+	}
+
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].ID < resolved[j].ID
+	})
+	return resolved, nil
+}
+
+type AuditRepository struct {
+	entries []*models.AuditEntry
+	mu      sync.RWMutex
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = fmt.Sprintf("audit-%d", time.Now().UnixNano())
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	stored := *entry
+	r.entries = append(r.entries, &stored)
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, limit, offset int) ([]*models.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Entries are appended in order, so walk them backwards for newest first
+	var result []*models.AuditEntry
+	for i := len(r.entries) - 1 - offset; i >= 0; i-- {
+		if limit > 0 && len(result) == limit {
+			break
+		}
+		entry := *r.entries[i]
+		result = append(result, &entry)
+	}
+	return result, nil
+}
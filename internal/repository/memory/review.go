@@ -3,9 +3,11 @@ package memory
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
+	"dotfiles-api/internal/language"
 	"dotfiles-api/internal/models"
 	"dotfiles-api/internal/repository"
 )
@@ -13,6 +15,7 @@ import (
 type ReviewRepository struct {
 	reviews map[string]*models.Review
 	mu      sync.RWMutex
+	limit   entryLimit
 }
 
 func NewReviewRepository() *ReviewRepository {
@@ -21,13 +24,33 @@ func NewReviewRepository() *ReviewRepository {
 	}
 }
 
+// SetMaxEntries caps how many reviews the store holds; Create fails with
+// repository.ErrStoreFull once it is full. Zero means unlimited.
+func (r *ReviewRepository) SetMaxEntries(max int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limit = entryLimit{max: max, kind: "review"}
+}
+
 func (r *ReviewRepository) Create(ctx context.Context, review *models.Review) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	// Checked under the lock so concurrent submits cannot both pass
+	for _, existing := range r.reviews {
+		if existing.UserID == review.UserID && existing.TemplateID == review.TemplateID {
+			return repository.ErrAlreadyExists
+		}
+	}
+
 	if review.ID == "" {
 		review.ID = fmt.Sprintf("review-%d", time.Now().UnixNano())
 	}
+	if _, exists := r.reviews[review.ID]; !exists {
+		if err := r.limit.check(len(r.reviews)); err != nil {
+			return err
+		}
+	}
 
 	review.CreatedAt = time.Now()
 	review.UpdatedAt = time.Now()
@@ -73,18 +96,20 @@ func (r *ReviewRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (r *ReviewRepository) GetByTemplate(ctx context.Context, templateID string, limit, offset int) ([]*models.Review, error) {
+func (r *ReviewRepository) GetByTemplate(ctx context.Context, templateID string, languages []string, limit, offset int) ([]*models.Review, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	var result []*models.Review
 
 	for _, review := range r.reviews {
-		if review.TemplateID == templateID {
+		if review.TemplateID == templateID && inLanguages(review, languages) {
 			result = append(result, review)
 		}
 	}
 
+	sortNewestFirst(result)
+
 	// Apply offset and limit
 	if offset > 0 && offset < len(result) {
 		result = result[offset:]
@@ -111,6 +136,8 @@ func (r *ReviewRepository) GetByUser(ctx context.Context, userID string, limit,
 		}
 	}
 
+	sortNewestFirst(result)
+
 	// Apply offset and limit
 	if offset > 0 && offset < len(result) {
 		result = result[offset:]
@@ -125,6 +152,44 @@ func (r *ReviewRepository) GetByUser(ctx context.Context, userID string, limit,
 	return result, nil
 }
 
+func (r *ReviewRepository) CountByUser(ctx context.Context, userID string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, review := range r.reviews {
+		if review.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *ReviewRepository) List(ctx context.Context, limit, offset int) ([]*models.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*models.Review, 0, len(r.reviews))
+	for _, review := range r.reviews {
+		result = append(result, review)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	if offset >= len(result) {
+		return []*models.Review{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
 func (r *ReviewRepository) GetUserReviewForTemplate(ctx context.Context, userID, templateID string) (*models.Review, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -158,6 +223,7 @@ func (r *ReviewRepository) CalculateTemplateRating(ctx context.Context, template
 	rating := &models.TemplateRating{
 		TemplateID:   templateID,
 		Distribution: make(map[string]int),
+		Languages:    make(map[string]int),
 	}
 
 	var totalRating int
@@ -168,6 +234,7 @@ func (r *ReviewRepository) CalculateTemplateRating(ctx context.Context, template
 			totalRating += review.Rating
 			count++
 			rating.Distribution[fmt.Sprintf("%d", review.Rating)]++
+			rating.Languages[reviewLanguage(review)]++
 		}
 	}
 
@@ -178,3 +245,42 @@ func (r *ReviewRepository) CalculateTemplateRating(ctx context.Context, template
 
 	return rating, nil
 }
+
+// inLanguages reports whether a review is in one of languages. Every
+// review matches when languages is empty, and reviews of undetermined
+// language always match.
+func inLanguages(review *models.Review, languages []string) bool {
+	if len(languages) == 0 {
+		return true
+	}
+	code := reviewLanguage(review)
+	if code == language.Undetermined {
+		return true
+	}
+	for _, l := range languages {
+		if l == code {
+			return true
+		}
+	}
+	return false
+}
+
+// reviewLanguage is a review's language, counting reviews written before
+// detection as undetermined
+func reviewLanguage(review *models.Review) string {
+	if review.Language == "" {
+		return language.Undetermined
+	}
+	return review.Language
+}
+
+// sortNewestFirst orders reviews newest first, breaking ties by ID so pages
+// stay stable
+func sortNewestFirst(reviews []*models.Review) {
+	sort.Slice(reviews, func(i, j int) bool {
+		if !reviews[i].CreatedAt.Equal(reviews[j].CreatedAt) {
+			return reviews[i].CreatedAt.After(reviews[j].CreatedAt)
+		}
+		return reviews[i].ID > reviews[j].ID
+	})
+}
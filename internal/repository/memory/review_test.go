@@ -2,9 +2,16 @@ package memory
 
 import (
 	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 
+	"dotfiles-api/internal/language"
 	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
 )
 
 func TestCreateReview(t *testing.T) {
@@ -90,7 +97,7 @@ func TestGetByTemplate(t *testing.T) {
 		t.Logf("Created review %d: ID=%s, TemplateID=%s", i, r.ID, r.TemplateID)
 	}
 
-	templateReviews, err := repo.GetByTemplate(ctx, templateID, 10, 0)
+	templateReviews, err := repo.GetByTemplate(ctx, templateID, nil, 10, 0)
 	if err != nil {
 		t.Fatalf("Failed to get template reviews: %v", err)
 	}
@@ -172,7 +179,7 @@ func TestCalculateTemplateRating(t *testing.T) {
 	}
 
 	// Verify all reviews were created
-	allReviews, _ := repo.GetByTemplate(ctx, templateID, 100, 0)
+	allReviews, _ := repo.GetByTemplate(ctx, templateID, nil, 100, 0)
 	t.Logf("Total reviews found for template: %d", len(allReviews))
 	for i, r := range allReviews {
 		t.Logf("  Review %d: ID=%s, Rating=%d", i, r.ID, r.Rating)
@@ -303,3 +310,119 @@ func TestDeleteReview(t *testing.T) {
 
 	t.Logf("✓ Review deleted successfully")
 }
+
+func TestConcurrentCreateKeepsOneReviewPerUser(t *testing.T) {
+	repo := NewReviewRepository()
+	ctx := context.Background()
+
+	const submits = 20
+	errs := make([]error, submits)
+	var wg sync.WaitGroup
+	for i := 0; i < submits; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = repo.Create(ctx, &models.Review{TemplateID: "template-1", UserID: "user-1", Rating: 5})
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, repository.ErrAlreadyExists):
+			t.Errorf("Expected ErrAlreadyExists, got %v", err)
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected exactly one create to succeed, got %d", created)
+	}
+
+	reviews, err := repo.GetByTemplate(ctx, "template-1", nil, 0, 0)
+	if err != nil {
+		t.Fatalf("Failed to get template reviews: %v", err)
+	}
+	if len(reviews) != 1 {
+		t.Errorf("Expected exactly one review, got %d", len(reviews))
+	}
+}
+
+func TestGetByTemplatePagesNewestFirst(t *testing.T) {
+	repo := NewReviewRepository()
+	ctx := context.Background()
+
+	createdAt := time.Now()
+	for _, id := range []string{"b", "a", "c", "d"} {
+		if err := repo.Create(ctx, &models.Review{ID: id, TemplateID: "template-1", UserID: "user-" + id}); err != nil {
+			t.Fatalf("Failed to create review: %v", err)
+		}
+	}
+	// Give every review the same timestamp so only the tiebreak orders them
+	for _, review := range repo.reviews {
+		review.CreatedAt = createdAt
+	}
+	repo.reviews["d"].CreatedAt = createdAt.Add(-time.Second)
+
+	var ids []string
+	for offset := 0; offset < 4; offset += 2 {
+		page, err := repo.GetByTemplate(ctx, "template-1", nil, 2, offset)
+		if err != nil {
+			t.Fatalf("Failed to get template reviews: %v", err)
+		}
+		for _, review := range page {
+			ids = append(ids, review.ID)
+		}
+	}
+
+	if want := []string{"c", "b", "a", "d"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected %v, got %v", want, ids)
+	}
+}
+
+func TestGetByTemplateFiltersByLanguage(t *testing.T) {
+	repo := NewReviewRepository()
+	ctx := context.Background()
+
+	for _, review := range []*models.Review{
+		{ID: "en", TemplateID: "template-1", UserID: "user-1", Rating: 5, Language: "en"},
+		{ID: "es", TemplateID: "template-1", UserID: "user-2", Rating: 4, Language: "es"},
+		{ID: "de", TemplateID: "template-1", UserID: "user-3", Rating: 4, Language: "de"},
+		{ID: "und", TemplateID: "template-1", UserID: "user-4", Rating: 3, Language: language.Undetermined},
+		{ID: "legacy", TemplateID: "template-1", UserID: "user-5", Rating: 3},
+	} {
+		if err := repo.Create(ctx, review); err != nil {
+			t.Fatalf("Failed to create review: %v", err)
+		}
+	}
+
+	ids := func(languages ...string) []string {
+		reviews, err := repo.GetByTemplate(ctx, "template-1", languages, 0, 0)
+		if err != nil {
+			t.Fatalf("Failed to get reviews: %v", err)
+		}
+		var got []string
+		for _, review := range reviews {
+			got = append(got, review.ID)
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	if got, want := ids("en", "de"), []string{"de", "en", "legacy", "und"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if got := ids(); len(got) != 5 {
+		t.Errorf("Expected every review without a filter, got %v", got)
+	}
+
+	rating, err := repo.CalculateTemplateRating(ctx, "template-1")
+	if err != nil {
+		t.Fatalf("Failed to calculate rating: %v", err)
+	}
+	want := map[string]int{"en": 1, "es": 1, "de": 1, language.Undetermined: 2}
+	if !reflect.DeepEqual(rating.Languages, want) {
+		t.Errorf("Expected languages %v, got %v", want, rating.Languages)
+	}
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+)
+
+type ShareLinkRepository struct {
+	links map[string]*models.TemplateShareLink
+	mu    sync.RWMutex
+}
+
+func NewShareLinkRepository() *ShareLinkRepository {
+	return &ShareLinkRepository{
+		links: make(map[string]*models.TemplateShareLink),
+	}
+}
+
+func (r *ShareLinkRepository) Create(ctx context.Context, link *models.TemplateShareLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if link.ID == "" {
+		link.ID = fmt.Sprintf("sharelink-%d", time.Now().UnixNano())
+	}
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = time.Now()
+	}
+	link.TokenHash = models.HashShareLinkToken(link.Token)
+
+	stored := *link
+	stored.Token = ""
+	r.links[link.ID] = &stored
+	return nil
+}
+
+func (r *ShareLinkRepository) GetByToken(ctx context.Context, token string) (*models.TemplateShareLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	link := r.findByToken(token)
+	if link == nil {
+		return nil, repository.ErrNotFound
+	}
+	result := *link
+	return &result, nil
+}
+
+func (r *ShareLinkRepository) Use(ctx context.Context, token string, now time.Time) (*models.TemplateShareLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link := r.findByToken(token)
+	if link == nil {
+		return nil, repository.ErrNotFound
+	}
+	if !link.Usable(now) {
+		return nil, repository.ErrShareLinkUnusable
+	}
+
+	link.Uses++
+	result := *link
+	return &result, nil
+}
+
+func (r *ShareLinkRepository) ListUsable(ctx context.Context, templateID string, now time.Time) ([]*models.TemplateShareLink, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*models.TemplateShareLink
+	for _, link := range r.links {
+		if link.TemplateID == templateID && link.Usable(now) {
+			stored := *link
+			result = append(result, &stored)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].CreatedAt.After(result[j].CreatedAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+func (r *ShareLinkRepository) Delete(ctx context.Context, templateID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link, exists := r.links[id]
+	if !exists || link.TemplateID != templateID {
+		return repository.ErrNotFound
+	}
+
+	delete(r.links, id)
+	return nil
+}
+
+// findByToken returns the stored link for token. Callers hold the lock.
+func (r *ShareLinkRepository) findByToken(token string) *models.TemplateShareLink {
+	hash := models.HashShareLinkToken(token)
+	for _, link := range r.links {
+		if link.TokenHash == hash {
+			return link
+		}
+	}
+	return nil
+}
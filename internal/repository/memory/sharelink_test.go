@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+)
+
+func TestShareLinkUseIsBounded(t *testing.T) {
+	repo := NewShareLinkRepository()
+	ctx := context.Background()
+	now := time.Now()
+
+	link := &models.TemplateShareLink{TemplateID: "template-1", Token: "secret", ExpiresAt: now.Add(time.Hour), MaxUses: 3}
+	if err := repo.Create(ctx, link); err != nil {
+		t.Fatalf("Failed to create share link: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	used, refused := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Use(ctx, "secret", now)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				used++
+			case errors.Is(err, repository.ErrShareLinkUnusable):
+				refused++
+			default:
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if used != 3 || refused != 7 {
+		t.Errorf("Expected 3 uses and 7 refusals, got %d and %d", used, refused)
+	}
+	if _, err := repo.Use(ctx, "other", now); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown token, got %v", err)
+	}
+	if links, _ := repo.ListUsable(ctx, "template-1", now); len(links) != 0 {
+		t.Errorf("Expected a used-up link not to be listed, got %v", links)
+	}
+}
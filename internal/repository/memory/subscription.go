@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+)
+
+type SubscriptionRepository struct {
+	subscriptions map[string]*models.TagSubscription // userID -> subscription
+	mu            sync.RWMutex
+}
+
+func NewSubscriptionRepository() *SubscriptionRepository {
+	return &SubscriptionRepository{
+		subscriptions: make(map[string]*models.TagSubscription),
+	}
+}
+
+func (r *SubscriptionRepository) GetByUser(ctx context.Context, userID string) (*models.TagSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscription, exists := r.subscriptions[userID]
+	if !exists {
+		return nil, nil
+	}
+
+	return copySubscription(subscription), nil
+}
+
+func (r *SubscriptionRepository) AddTags(ctx context.Context, userID string, tags []string) (*models.TagSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription, exists := r.subscriptions[userID]
+	if !exists {
+		now := time.Now()
+		subscription = &models.TagSubscription{
+			UserID:         userID,
+			CreatedAt:      now,
+			LastNotifiedAt: now,
+		}
+		r.subscriptions[userID] = subscription
+	}
+
+	for _, tag := range tags {
+		if !slices.Contains(subscription.Tags, tag) {
+			subscription.Tags = append(subscription.Tags, tag)
+		}
+	}
+
+	return copySubscription(subscription), nil
+}
+
+func (r *SubscriptionRepository) RemoveTags(ctx context.Context, userID string, tags []string) (*models.TagSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription, exists := r.subscriptions[userID]
+	if !exists {
+		return nil, nil
+	}
+
+	subscription.Tags = slices.DeleteFunc(subscription.Tags, func(tag string) bool {
+		return slices.Contains(tags, tag)
+	})
+
+	if len(subscription.Tags) == 0 {
+		delete(r.subscriptions, userID)
+		return nil, nil
+	}
+
+	return copySubscription(subscription), nil
+}
+
+func (r *SubscriptionRepository) List(ctx context.Context) ([]*models.TagSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*models.TagSubscription, 0, len(r.subscriptions))
+	for _, subscription := range r.subscriptions {
+		result = append(result, copySubscription(subscription))
+	}
+	return result, nil
+}
+
+func (r *SubscriptionRepository) MarkNotified(ctx context.Context, userID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscription, exists := r.subscriptions[userID]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	subscription.LastNotifiedAt = at
+	return nil
+}
+
+// copySubscription returns a copy callers can modify without touching the
+// stored subscription
+func copySubscription(subscription *models.TagSubscription) *models.TagSubscription {
+	copied := *subscription
+	copied.Tags = append([]string(nil), subscription.Tags...)
+	return &copied
+}
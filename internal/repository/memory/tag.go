@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+	"sync"
+)
+
+type TagRepository struct {
+	synonyms map[string][]string
+	mu       sync.RWMutex
+}
+
+func NewTagRepository() *TagRepository {
+	return &TagRepository{
+		synonyms: make(map[string][]string),
+	}
+}
+
+func (r *TagRepository) GetSynonyms(ctx context.Context) (map[string][]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	table := make(map[string][]string, len(r.synonyms))
+	for canonical, synonyms := range r.synonyms {
+		table[canonical] = append([]string(nil), synonyms...)
+	}
+	return table, nil
+}
+
+func (r *TagRepository) SetSynonyms(ctx context.Context, canonical string, synonyms []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.synonyms[canonical] = append([]string(nil), synonyms...)
+	return nil
+}
@@ -1,19 +1,26 @@
 package memory
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"dotfiles-api/internal/models"
 	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/search"
+	"dotfiles-api/internal/tags"
 )
 
 type TemplateRepository struct {
 	templates map[string]*models.StoredTemplate
 	mu        sync.RWMutex
+	limit     entryLimit
 }
 
 func NewTemplateRepository() *TemplateRepository {
@@ -108,10 +115,19 @@ func (r *TemplateRepository) initSampleTemplates() {
 	}
 
 	for _, template := range sampleTemplates {
+		template.SchemaVersion = template.Template.SchemaVersion()
 		r.templates[template.ID] = template
 	}
 }
 
+// SetMaxEntries caps how many templates the store holds; Create fails with
+// repository.ErrStoreFull once it is full. Zero means unlimited.
+func (r *TemplateRepository) SetMaxEntries(max int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limit = entryLimit{max: max, kind: "template"}
+}
+
 func (r *TemplateRepository) Create(ctx context.Context, template *models.StoredTemplate) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -119,9 +135,15 @@ func (r *TemplateRepository) Create(ctx context.Context, template *models.Stored
 	if template.ID == "" {
 		template.ID = fmt.Sprintf("template-%d", time.Now().UnixNano())
 	}
+	if _, exists := r.templates[template.ID]; !exists {
+		if err := r.limit.check(len(r.templates)); err != nil {
+			return err
+		}
+	}
 
 	template.CreatedAt = time.Now()
 	template.UpdatedAt = time.Now()
+	template.SchemaVersion = template.Template.SchemaVersion()
 
 	r.templates[template.ID] = template
 	return nil
@@ -148,6 +170,7 @@ func (r *TemplateRepository) Update(ctx context.Context, template *models.Stored
 	}
 
 	template.UpdatedAt = time.Now()
+	template.SchemaVersion = template.Template.SchemaVersion()
 	r.templates[template.ID] = template
 	return nil
 }
@@ -168,14 +191,78 @@ func (r *TemplateRepository) List(ctx context.Context, filters repository.Templa
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	result := r.filter(filters)
+
+	if filters.Random {
+		rand.Shuffle(len(result), func(i, j int) {
+			result[i], result[j] = result[j], result[i]
+		})
+		if filters.Limit > 0 && filters.Limit < len(result) {
+			result = result[:filters.Limit]
+		}
+		return result, nil
+	}
+
+	sortTemplates(result, filters.TemplateSort())
+
+	// Apply limit and offset
+	if filters.Offset > 0 && filters.Offset < len(result) {
+		result = result[filters.Offset:]
+	} else if filters.Offset >= len(result) {
+		result = []*models.StoredTemplate{}
+	}
+
+	if filters.Limit > 0 && filters.Limit < len(result) {
+		result = result[:filters.Limit]
+	}
+
+	return result, nil
+}
+
+func (r *TemplateRepository) Count(ctx context.Context, filters repository.TemplateFilters) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.filter(filters)), nil
+}
+
+func (r *TemplateRepository) Iterate(ctx context.Context, filters repository.TemplateFilters, fn func(*models.StoredTemplate) error) error {
+	r.mu.RLock()
+	result := r.filter(filters)
+	sortTemplates(result, filters.TemplateSort())
+	r.mu.RUnlock()
+
+	// fn runs without the lock, so a slow consumer does not block writers
+	for _, template := range result {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(template); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filter returns the templates matching the filters, ignoring pagination
+// and sorting. Callers must hold the read lock.
+func (r *TemplateRepository) filter(filters repository.TemplateFilters) []*models.StoredTemplate {
 	var result []*models.StoredTemplate
 
 	for _, template := range r.templates {
 		// Apply filters
+		if template.Unlisted && !filters.IncludeUnlisted {
+			continue
+		}
+
 		if filters.Public != nil && template.Template.Public != *filters.Public {
 			continue
 		}
 
+		if !filters.Viewer.CanView(template) {
+			continue
+		}
+
 		if filters.Featured != nil && template.Template.Featured != *filters.Featured {
 			continue
 		}
@@ -188,58 +275,93 @@ func (r *TemplateRepository) List(ctx context.Context, filters repository.Templa
 			continue
 		}
 
-		if len(filters.Tags) > 0 {
-			hasAllTags := true
-			for _, filterTag := range filters.Tags {
-				found := false
-				for _, templateTag := range template.Template.Metadata.Tags {
-					if templateTag == filterTag {
-						found = true
-						break
-					}
-				}
-				if !found {
-					hasAllTags = false
-					break
-				}
+		if !filters.DateRange.Contains(template.CreatedAt, template.UpdatedAt) {
+			continue
+		}
+
+		if len(filters.Tags) > 0 && !hasAllTags(template.Template.Metadata.Tags, filters.Tags) {
+			continue
+		}
+
+		result = append(result, template)
+	}
+
+	return result
+}
+
+// sortTemplates orders templates by each key in turn, keeping the input
+// order of templates that tie on every key
+func sortTemplates(templates []*models.StoredTemplate, keys []repository.SortKey) {
+	sort.SliceStable(templates, func(i, j int) bool {
+		for _, key := range keys {
+			c := compareTemplates(templates[i], templates[j], key.Field)
+			if key.Desc {
+				c = -c
 			}
-			if !hasAllTags {
-				continue
+			if c != 0 {
+				return c < 0
 			}
 		}
+		return false
+	})
+}
 
-		result = append(result, template)
+// compareTemplates compares two templates on one sort field
+func compareTemplates(a, b *models.StoredTemplate, field string) int {
+	switch field {
+	case "updated_at":
+		return a.UpdatedAt.Compare(b.UpdatedAt)
+	case "downloads":
+		return cmp.Compare(a.Downloads, b.Downloads)
+	case "popularity":
+		return cmp.Compare(a.PopularityScore, b.PopularityScore)
+	case "featured":
+		return cmp.Compare(boolRank(a.Template.Featured), boolRank(b.Template.Featured))
+	default:
+		return a.CreatedAt.Compare(b.CreatedAt)
 	}
+}
 
-	// Apply limit and offset
-	if filters.Offset > 0 && filters.Offset < len(result) {
-		result = result[filters.Offset:]
-	} else if filters.Offset >= len(result) {
-		result = []*models.StoredTemplate{}
+func boolRank(b bool) int {
+	if b {
+		return 1
 	}
+	return 0
+}
 
-	if filters.Limit > 0 && filters.Limit < len(result) {
-		result = result[:filters.Limit]
+// hasAllTags reports whether, for every requested tag, one of the template's
+// tags normalizes to one of the requested tag's forms
+func hasAllTags(templateTags []string, requested [][]string) bool {
+	normalized := make(map[string]bool, len(templateTags))
+	for _, tag := range templateTags {
+		normalized[tags.Normalize(tag)] = true
 	}
 
-	return result, nil
+	for _, variants := range requested {
+		found := false
+		for _, variant := range variants {
+			if normalized[variant] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
 }
 
-func (r *TemplateRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.StoredTemplate, error) {
+func (r *TemplateRepository) Search(ctx context.Context, query string, viewer *repository.TemplateViewer, limit, offset int, fields []string) ([]*models.StoredTemplate, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	var result []*models.StoredTemplate
-	lowerQuery := strings.ToLower(query)
+	result := r.search(query, viewer)
 
-	for _, template := range r.templates {
-		// Simple search in name and description
-		if strings.Contains(strings.ToLower(template.Template.Metadata.Name), lowerQuery) ||
-			strings.Contains(strings.ToLower(template.Template.Metadata.Description), lowerQuery) ||
-			strings.Contains(strings.ToLower(template.Template.Metadata.Author), lowerQuery) {
-			result = append(result, template)
-		}
-	}
+	// Map iteration order is random; keep pages stable
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
 
 	// Apply offset and limit
 	if offset > 0 && offset < len(result) {
@@ -255,24 +377,69 @@ func (r *TemplateRepository) Search(ctx context.Context, query string, limit, of
 	return result, nil
 }
 
+func (r *TemplateRepository) CountSearch(ctx context.Context, query string, viewer *repository.TemplateViewer) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.search(query, viewer)), nil
+}
+
+// search returns the templates matching any term of query in the name,
+// description, tags or author, in no particular order. Callers must hold
+// the read lock.
+func (r *TemplateRepository) search(query string, viewer *repository.TemplateViewer) []*models.StoredTemplate {
+	var result []*models.StoredTemplate
+	terms := search.Terms(query)
+
+	for _, template := range r.templates {
+		if template.Unlisted || !viewer.CanView(template) {
+			continue
+		}
+
+		metadata := template.Template.Metadata
+		if search.Contains(metadata.Name, terms) ||
+			search.Contains(metadata.Description, terms) ||
+			search.Contains(strings.Join(metadata.Tags, ", "), terms) ||
+			search.Contains(metadata.Author, terms) {
+			result = append(result, template)
+		}
+	}
+	return result
+}
+
 func (r *TemplateRepository) GetByAuthor(ctx context.Context, authorID string, limit, offset int) ([]*models.StoredTemplate, error) {
 	filters := repository.TemplateFilters{
-		Author: authorID,
-		Limit:  limit,
-		Offset: offset,
+		Author:          authorID,
+		Limit:           limit,
+		Offset:          offset,
+		IncludeUnlisted: true,
 	}
 	return r.List(ctx, filters)
 }
 
 func (r *TemplateRepository) GetByOrganization(ctx context.Context, orgID string, limit, offset int) ([]*models.StoredTemplate, error) {
 	filters := repository.TemplateFilters{
-		OrganizationID: orgID,
-		Limit:          limit,
-		Offset:         offset,
+		OrganizationID:  orgID,
+		Limit:           limit,
+		Offset:          offset,
+		IncludeUnlisted: true,
 	}
 	return r.List(ctx, filters)
 }
 
+func (r *TemplateRepository) CountByOrganization(ctx context.Context, orgID string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, template := range r.templates {
+		if template.Template.OrganizationID == orgID {
+			count++
+		}
+	}
+	return count, nil
+}
+
 func (r *TemplateRepository) GetFeatured(ctx context.Context, limit int) ([]*models.StoredTemplate, error) {
 	featured := true
 	filters := repository.TemplateFilters{
@@ -295,6 +462,33 @@ func (r *TemplateRepository) IncrementDownloads(ctx context.Context, id string)
 	return nil
 }
 
+func (r *TemplateRepository) SetPopularityScore(ctx context.Context, id string, score float64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template, exists := r.templates[id]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	template.PopularityScore = score
+	return nil
+}
+
+func (r *TemplateRepository) SetUnlisted(ctx context.Context, id string, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template, exists := r.templates[id]
+	if !exists {
+		return repository.ErrNotFound
+	}
+
+	template.Unlisted = reason != ""
+	template.UnlistedReason = reason
+	return nil
+}
+
 func (r *TemplateRepository) GetStats(ctx context.Context) (*models.TemplateStats, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -330,5 +524,40 @@ func (r *TemplateRepository) GetRating(ctx context.Context, templateID string) (
 		AverageRating:  0,
 		TotalRatings:   0,
 		Distribution:   make(map[string]int),
+		Languages:      make(map[string]int),
 	}, nil
 }
+
+func (r *TemplateRepository) CountTags(ctx context.Context, canonicalize func([]string) []string) (map[string]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, template := range r.templates {
+		if !template.Template.Public {
+			continue
+		}
+		for _, tag := range canonicalize(template.Template.Metadata.Tags) {
+			counts[tag]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *TemplateRepository) RewriteTags(ctx context.Context, canonicalize func([]string) []string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed := 0
+	for _, template := range r.templates {
+		rewritten := canonicalize(template.Template.Metadata.Tags)
+		if slices.Equal(rewritten, template.Template.Metadata.Tags) {
+			continue
+		}
+
+		template.Template.Metadata.Tags = rewritten
+		template.UpdatedAt = time.Now()
+		changed++
+	}
+	return changed, nil
+}
@@ -2,6 +2,8 @@ package memory
 
 import (
 	"context"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -243,3 +245,134 @@ func TestDeleteTemplate(t *testing.T) {
 
 	t.Logf("✓ Template deleted successfully")
 }
+
+func TestListTemplatesDateRange(t *testing.T) {
+	repo := NewTemplateRepository()
+	ctx := context.Background()
+
+	// Drop the seeded templates so only the ones below are counted
+	seeded, _ := repo.List(ctx, repository.TemplateFilters{})
+	for _, template := range seeded {
+		if err := repo.Delete(ctx, template.ID); err != nil {
+			t.Fatalf("Failed to delete seeded template: %v", err)
+		}
+	}
+
+	base := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"monday", "tuesday", "wednesday"} {
+		template := &models.StoredTemplate{ID: id}
+		if err := repo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+		template.CreatedAt = base.Add(time.Duration(i) * 24 * time.Hour)
+		template.UpdatedAt = template.CreatedAt.Add(time.Hour)
+	}
+
+	at := func(offset time.Duration) *time.Time {
+		t := base.Add(offset)
+		return &t
+	}
+
+	tests := []struct {
+		name     string
+		dates    repository.DateRange
+		expected int
+	}{
+		{name: "no bounds", dates: repository.DateRange{}, expected: 3},
+		{name: "created_after is inclusive", dates: repository.DateRange{CreatedAfter: at(24 * time.Hour)}, expected: 2},
+		{name: "created_before is inclusive", dates: repository.DateRange{CreatedBefore: at(24 * time.Hour)}, expected: 2},
+		{name: "single instant", dates: repository.DateRange{CreatedAfter: at(24 * time.Hour), CreatedBefore: at(24 * time.Hour)}, expected: 1},
+		{name: "just past the last template", dates: repository.DateRange{CreatedAfter: at(48*time.Hour + time.Second)}, expected: 0},
+		{name: "updated_after", dates: repository.DateRange{UpdatedAfter: at(49 * time.Hour)}, expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates, err := repo.List(ctx, repository.TemplateFilters{DateRange: tt.dates})
+			if err != nil {
+				t.Fatalf("Failed to list templates: %v", err)
+			}
+			if len(templates) != tt.expected {
+				t.Errorf("Expected %d templates, got %d", tt.expected, len(templates))
+			}
+		})
+	}
+}
+
+// The sample data must match the MongoDB seed (seedDefaultTemplate) so the
+// memory backend behaves like production
+func TestSampleTemplateIncludesHooks(t *testing.T) {
+	repo := NewTemplateRepository()
+
+	template, err := repo.GetByID(context.Background(), "essential-developer-setup")
+	if err != nil {
+		t.Fatalf("Failed to get sample template: %v", err)
+	}
+
+	hooks := template.Template.Hooks
+	if hooks == nil || len(hooks.PreInstall) == 0 || len(hooks.PostInstall) == 0 || len(hooks.PreStow) == 0 || len(hooks.PostStow) == 0 {
+		t.Errorf("Expected install and stow hooks on the sample template, got %+v", hooks)
+	}
+
+	for _, pkg := range []string{"starship", "zoxide", "fzf", "neovim", "tmux"} {
+		if len(template.Template.PackageConfigs[pkg].PostInstall) == 0 {
+			t.Errorf("Expected post-install steps for %s", pkg)
+		}
+	}
+}
+
+func TestListAndSearchApplyViewer(t *testing.T) {
+	repo := NewTemplateRepository()
+	ctx := context.Background()
+
+	for _, template := range []*models.StoredTemplate{
+		{ID: "open", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Name: "Visibility open", Author: "alice"}}},
+		{ID: "mine", Template: models.Template{Metadata: models.ShareMetadata{Name: "Visibility mine", Author: "alice"}}},
+		{ID: "shared", AllowedUsers: []string{"bob"}, Template: models.Template{Metadata: models.ShareMetadata{Name: "Visibility shared", Author: "alice"}}},
+		{ID: "org", Template: models.Template{OrganizationID: "acme", Metadata: models.ShareMetadata{Name: "Visibility org", Author: "alice"}}},
+	} {
+		if err := repo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		viewer *repository.TemplateViewer
+		want   []string
+	}{
+		{"anonymous", &repository.TemplateViewer{}, []string{"open"}},
+		{"author", &repository.TemplateViewer{Username: "alice"}, []string{"mine", "open", "org", "shared"}},
+		{"shared with", &repository.TemplateViewer{Username: "bob"}, []string{"open", "shared"}},
+		{"organization member", &repository.TemplateViewer{Username: "carol", OrganizationIDs: []string{"acme"}}, []string{"open", "org"}},
+		{"unscoped", nil, []string{"mine", "open", "org", "shared"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listed, err := repo.List(ctx, repository.TemplateFilters{Author: "alice", Viewer: tt.viewer})
+			if err != nil {
+				t.Fatalf("Failed to list templates: %v", err)
+			}
+			if got := sortedTemplateIDs(listed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected List to return %v, got %v", tt.want, got)
+			}
+
+			found, err := repo.Search(ctx, "visibility", tt.viewer, 0, 0, nil)
+			if err != nil {
+				t.Fatalf("Failed to search templates: %v", err)
+			}
+			if got := sortedTemplateIDs(found); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected Search to return %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func sortedTemplateIDs(templates []*models.StoredTemplate) []string {
+	ids := make([]string, len(templates))
+	for i, template := range templates {
+		ids[i] = template.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -2,42 +2,75 @@ package memory
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
 	"dotfiles-api/pkg/errors"
 )
 
 type UserRepository struct {
 	users     map[string]*models.User
 	favorites map[string][]string
+	// following maps follower ID to followee ID to when they followed
+	following map[string]map[string]time.Time
 	mutex     sync.RWMutex
+	limit     entryLimit
 }
 
 func NewUserRepository() *UserRepository {
 	return &UserRepository{
 		users:     make(map[string]*models.User),
 		favorites: make(map[string][]string),
+		following: make(map[string]map[string]time.Time),
 	}
 }
 
+// SetMaxEntries caps how many users the store holds; Create fails with
+// repository.ErrStoreFull once it is full. Zero means unlimited.
+func (r *UserRepository) SetMaxEntries(max int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.limit = entryLimit{max: max, kind: "user"}
+}
+
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	if user.ID == "" {
+		user.ID = fmt.Sprintf("user-%d", time.Now().UnixNano())
+	}
 	if _, exists := r.users[user.ID]; exists {
 		return errors.NewConflictError("user already exists")
 	}
 
 	for _, existingUser := range r.users {
+		// Mirrors the unique github_id index in the mongo store
+		if user.GitHubID > 0 && existingUser.GitHubID == user.GitHubID {
+			return repository.ErrAlreadyExists
+		}
+		for _, identity := range user.Identities {
+			if existingUser.HasIdentity(identity.Provider, identity.ExternalID) {
+				return repository.ErrAlreadyExists
+			}
+		}
+		if existingUser.IsDeleted() {
+			continue
+		}
 		if existingUser.Username == user.Username {
 			return errors.NewConflictError("username already taken")
 		}
-		if existingUser.Email == user.Email {
+		if user.Email != "" && existingUser.Email == user.Email {
 			return errors.NewConflictError("email already taken")
 		}
 	}
+	if err := r.limit.check(len(r.users)); err != nil {
+		return err
+	}
 
 	r.users[user.ID] = user
 	return nil
@@ -60,7 +93,7 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*m
 	defer r.mutex.RUnlock()
 
 	for _, user := range r.users {
-		if user.Username == username {
+		if user.Username == username && !user.IsDeleted() {
 			return user, nil
 		}
 	}
@@ -68,6 +101,25 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*m
 	return nil, errors.NewNotFoundError("user")
 }
 
+func (r *UserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]*models.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	wanted := make(map[string]bool, len(usernames))
+	for _, username := range usernames {
+		wanted[username] = true
+	}
+
+	var result []*models.User
+	for _, user := range r.users {
+		if wanted[user.Username] && !user.IsDeleted() {
+			result = append(result, user)
+		}
+	}
+
+	return result, nil
+}
+
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
@@ -94,6 +146,40 @@ func (r *UserRepository) GetByGitHubID(ctx context.Context, githubID int) (*mode
 	return nil, errors.NewNotFoundError("user")
 }
 
+func (r *UserRepository) GetByIdentity(ctx context.Context, provider, externalID string) (*models.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, user := range r.users {
+		if user.HasIdentity(provider, externalID) {
+			return user, nil
+		}
+	}
+
+	return nil, errors.NewNotFoundError("user")
+}
+
+func (r *UserRepository) AddIdentity(ctx context.Context, userID string, identity models.Identity) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, exists := r.users[userID]
+	if !exists {
+		return errors.NewNotFoundError("user")
+	}
+
+	for _, existingUser := range r.users {
+		if existingUser.ID != userID && existingUser.HasIdentity(identity.Provider, identity.ExternalID) {
+			return repository.ErrAlreadyExists
+		}
+	}
+
+	if !user.HasIdentity(identity.Provider, identity.ExternalID) {
+		user.Identities = append(user.Identities, identity)
+	}
+	return nil
+}
+
 func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
@@ -103,11 +189,11 @@ func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	}
 
 	for id, existingUser := range r.users {
-		if id != user.ID {
+		if id != user.ID && !existingUser.IsDeleted() {
 			if existingUser.Username == user.Username {
 				return errors.NewConflictError("username already taken")
 			}
-			if existingUser.Email == user.Email {
+			if user.Email != "" && existingUser.Email == user.Email {
 				return errors.NewConflictError("email already taken")
 			}
 		}
@@ -121,11 +207,20 @@ func (r *UserRepository) Delete(ctx context.Context, id string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	if _, exists := r.users[id]; !exists {
+	user, exists := r.users[id]
+	if !exists || user.IsDeleted() {
 		return errors.NewNotFoundError("user")
 	}
 
-	delete(r.users, id)
+	// Keep the record so reviews still resolve, but drop personal details
+	now := time.Now()
+	user.DeletedAt = &now
+	user.UpdatedAt = now
+	user.Email = ""
+	user.Username = models.DeletedUsername(id)
+	user.Bio = ""
+	user.Location = ""
+
 	delete(r.favorites, id)
 	return nil
 }
@@ -136,16 +231,66 @@ func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*models
 
 	users := make([]*models.User, 0, len(r.users))
 	for _, user := range r.users {
-		users = append(users, user)
+		if !user.IsDeleted() {
+			users = append(users, user)
+		}
 	}
 
 	sort.Slice(users, func(i, j int) bool {
 		return users[i].CreatedAt.After(users[j].CreatedAt)
 	})
 
+	return paginateUsers(users, limit, offset), nil
+}
+
+func (r *UserRepository) Iterate(ctx context.Context, fn func(*models.User) error) error {
+	r.mutex.RLock()
+	users := make([]*models.User, 0, len(r.users))
+	for _, user := range r.users {
+		if !user.IsDeleted() {
+			users = append(users, user)
+		}
+	}
+	r.mutex.RUnlock()
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.After(users[j].CreatedAt)
+	})
+
+	// fn runs without the lock, so a slow consumer does not block writers
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *UserRepository) GetDeletedUsers(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	users := make([]*models.User, 0)
+	for _, user := range r.users {
+		if user.IsDeleted() {
+			users = append(users, user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].DeletedAt.After(*users[j].DeletedAt)
+	})
+
+	return paginateUsers(users, limit, offset), nil
+}
+
+func paginateUsers(users []*models.User, limit, offset int) []*models.User {
 	start := offset
 	if start > len(users) {
-		return []*models.User{}, nil
+		return []*models.User{}
 	}
 
 	end := start + limit
@@ -153,7 +298,7 @@ func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*models
 		end = len(users)
 	}
 
-	return users[start:end], nil
+	return users[start:end]
 }
 
 func (r *UserRepository) AddFavorite(ctx context.Context, userID, templateID string) error {
@@ -210,4 +355,29 @@ func (r *UserRepository) GetFavorites(ctx context.Context, userID string) ([]str
 	result := make([]string, len(favorites))
 	copy(result, favorites)
 	return result, nil
-}
\ No newline at end of file
+}
+func (r *UserRepository) RecordDownload(ctx context.Context, userID, templateID string, at time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, exists := r.users[userID]
+	if !exists {
+		return errors.NewNotFoundError("user")
+	}
+
+	user.RecordDownload(templateID, at)
+	return nil
+}
+
+func (r *UserRepository) BumpMembershipVersion(ctx context.Context, userID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, exists := r.users[userID]
+	if !exists {
+		return errors.NewNotFoundError("user")
+	}
+
+	user.MembershipVersion++
+	return nil
+}
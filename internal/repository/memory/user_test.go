@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+)
+
+func TestDeleteUserAnonymizes(t *testing.T) {
+	repo := NewUserRepository()
+	ctx := context.Background()
+
+	user := &models.User{
+		ID:       "user-1",
+		Username: "octocat",
+		Name:     "Octo Cat",
+		Email:    "octocat@example.com",
+		Bio:      "Loves dotfiles",
+		Location: "San Francisco",
+	}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	if err := repo.Delete(ctx, "user-1"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	// The record is kept so reviews can still resolve it
+	deleted, err := repo.GetByID(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("Expected deleted user to remain retrievable by ID, got %v", err)
+	}
+
+	if !deleted.IsDeleted() {
+		t.Error("Expected DeletedAt to be set")
+	}
+	if deleted.Username != "deleted-user-1" {
+		t.Errorf("Expected username deleted-user-1, got %s", deleted.Username)
+	}
+	if deleted.Email != "" || deleted.Bio != "" || deleted.Location != "" {
+		t.Errorf("Expected personal details to be cleared, got %+v", deleted)
+	}
+
+	if _, err := repo.GetByUsername(ctx, "octocat"); err == nil {
+		t.Error("Expected old username lookup to fail")
+	}
+	if _, err := repo.GetByUsername(ctx, "deleted-user-1"); err == nil {
+		t.Error("Expected deleted users to be hidden from username lookups")
+	}
+
+	if err := repo.Delete(ctx, "user-1"); err == nil {
+		t.Error("Expected deleting an already deleted user to fail")
+	}
+}
+
+func TestDeletedUsersListing(t *testing.T) {
+	repo := NewUserRepository()
+	ctx := context.Background()
+
+	for _, user := range []*models.User{
+		{ID: "user-1", Username: "alice", Email: "alice@example.com"},
+		{ID: "user-2", Username: "bob", Email: "bob@example.com"},
+	} {
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	if err := repo.Delete(ctx, "user-1"); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+
+	users, _ := repo.List(ctx, 10, 0)
+	if len(users) != 1 || users[0].ID != "user-2" {
+		t.Errorf("Expected only the active user in List, got %v", users)
+	}
+
+	deleted, _ := repo.GetDeletedUsers(ctx, 10, 0)
+	if len(deleted) != 1 || deleted[0].ID != "user-1" {
+		t.Errorf("Expected only the deleted user in GetDeletedUsers, got %v", deleted)
+	}
+
+	// A new account may reuse the freed username and the cleared email
+	if err := repo.Create(ctx, &models.User{ID: "user-3", Username: "alice", Email: ""}); err != nil {
+		t.Errorf("Expected freed username to be reusable, got %v", err)
+	}
+}
+
+func TestCreateUserRejectsDuplicateGitHubID(t *testing.T) {
+	repo := NewUserRepository()
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, &models.User{ID: "user-1", GitHubID: 42, Username: "octocat", Email: "octocat@example.com"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	err := repo.Create(ctx, &models.User{ID: "user-2", GitHubID: 42, Username: "octocat-2", Email: "other@example.com"})
+	if !errors.Is(err, repository.ErrAlreadyExists) {
+		t.Errorf("Expected ErrAlreadyExists for a duplicate GitHub ID, got %v", err)
+	}
+
+	// Users not linked to GitHub do not collide
+	for _, user := range []*models.User{
+		{ID: "user-3", Username: "local-1", Email: "local-1@example.com"},
+		{ID: "user-4", Username: "local-2", Email: "local-2@example.com"},
+	} {
+		if err := repo.Create(ctx, user); err != nil {
+			t.Errorf("Expected users without a GitHub ID to be created, got %v", err)
+		}
+	}
+}
+
+func TestRecordDownloadKeepsNewestFirstUpToTheCap(t *testing.T) {
+	repo := NewUserRepository()
+	ctx := context.Background()
+
+	if err := repo.Create(ctx, &models.User{ID: "user-1", Username: "octocat", Email: "octocat@example.com"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	start := time.Now()
+	for i := 0; i < models.MaxDownloadHistory+5; i++ {
+		if err := repo.RecordDownload(ctx, "user-1", fmt.Sprintf("template-%d", i), start.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatalf("Failed to record download: %v", err)
+		}
+	}
+
+	user, _ := repo.GetByID(ctx, "user-1")
+	history := user.DownloadHistory
+	if len(history) != models.MaxDownloadHistory {
+		t.Fatalf("Expected %d entries, got %d", models.MaxDownloadHistory, len(history))
+	}
+	if first, last := history[0].TemplateID, history[len(history)-1].TemplateID; first != "template-54" || last != "template-5" {
+		t.Errorf("Expected newest first with the oldest dropped, got %s..%s", first, last)
+	}
+
+	if err := repo.RecordDownload(ctx, "missing", "template-1", start); err == nil {
+		t.Error("Expected an error for an unknown user")
+	}
+}
@@ -0,0 +1,132 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// APIKeyRepository implements the APIKeyRepository interface using MongoDB
+type APIKeyRepository struct {
+	client     *Client
+	collection *mongo.Collection
+	reads      *RetryingCollection
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(client *Client) *APIKeyRepository {
+	return &APIKeyRepository{
+		client:     client,
+		collection: client.Collection("api_keys"),
+		reads:      client.ReadCollection("api_keys"),
+	}
+}
+
+// EnsureIndexes creates the indexes the api_keys collection relies on:
+// keys are looked up by hash on every request that uses one
+func (r *APIKeyRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key_hash", Value: 1}},
+			Options: options.Index().SetName("key_hash_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("user_created_at"),
+		},
+	})
+	return err
+}
+
+// Create stores a new API key
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	if key.ID == "" {
+		key.ID = primitive.NewObjectID().Hex()
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, key)
+	return err
+}
+
+// GetByHash retrieves the API key with the given hash
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	var key models.APIKey
+	err := r.reads.FindOne(ctx, bson.M{"key_hash": keyHash}).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByUser retrieves a user's API keys, newest first
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID string) ([]*models.APIKey, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}})
+	cursor, err := r.reads.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var keys []*models.APIKey
+	if err = cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Delete removes one of a user's API keys
+func (r *APIKeyRepository) Delete(ctx context.Context, userID, id string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteByUser removes all of a user's API keys
+func (r *APIKeyRepository) DeleteByUser(ctx context.Context, userID string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// TouchLastUsed records when an API key was last used
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used": at}})
+	return err
+}
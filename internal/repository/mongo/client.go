@@ -2,23 +2,56 @@ package mongo
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // Client wraps MongoDB client and database
 type Client struct {
 	client   *mongo.Client
 	database *mongo.Database
+	options  ClientOptions
+	readPref *readpref.ReadPref
+	logger   *slog.Logger
 }
 
-// NewClient creates a new MongoDB client
-func NewClient(mongoURI, dbName string) (*Client, error) {
+// ClientOptions controls how repositories split reads and writes. Reads
+// (get, list, search, stats) use ReadPreference and ReadTimeout; writes
+// always go to the primary and use WriteTimeout. A zero timeout leaves the
+// caller's context untouched.
+type ClientOptions struct {
+	ReadPreference string
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+}
+
+// NewClient creates a new MongoDB client, logging its connection lifecycle
+// to logger
+func NewClient(mongoURI, dbName string, opts ClientOptions, logger *slog.Logger) (*Client, error) {
+	logger = logger.With("component", "mongo", "database", dbName)
+
+	readPref := readpref.Primary()
+	if opts.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(opts.ReadPreference)
+		if err != nil {
+			return nil, fmt.Errorf("invalid read preference %q: %w", opts.ReadPreference, err)
+		}
+		if readPref, err = readpref.New(mode); err != nil {
+			return nil, fmt.Errorf("invalid read preference %q: %w", opts.ReadPreference, err)
+		}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	logger.Debug("connecting to MongoDB", "read_preference", readPref.Mode().String())
+	started := time.Now()
+
 	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
 	if err != nil {
 		return nil, err
@@ -29,24 +62,61 @@ func NewClient(mongoURI, dbName string) (*Client, error) {
 		return nil, err
 	}
 
+	logger.Info("connected to MongoDB", "duration", time.Since(started))
+
 	database := client.Database(dbName)
 	return &Client{
 		client:   client,
 		database: database,
+		options:  opts,
+		readPref: readPref,
+		logger:   logger,
 	}, nil
 }
 
 // Close closes the MongoDB connection
 func (c *Client) Close(ctx context.Context) error {
-	return c.client.Disconnect(ctx)
+	if err := c.client.Disconnect(ctx); err != nil {
+		c.logger.Error("failed to disconnect from MongoDB", "error", err)
+		return err
+	}
+	c.logger.Info("disconnected from MongoDB")
+	return nil
 }
 
-// Collection returns a MongoDB collection
+// Collection returns a MongoDB collection for writes, which always go to
+// the primary
 func (c *Client) Collection(name string) *mongo.Collection {
 	return c.database.Collection(name)
 }
 
+// ReadCollection returns a MongoDB collection that reads with the configured
+// read preference, e.g. to offload catalog reads to secondaries, and retries
+// reads that fail while the cluster recovers
+func (c *Client) ReadCollection(name string) *RetryingCollection {
+	return &RetryingCollection{
+		collection: c.database.Collection(name, options.Collection().SetReadPreference(c.readPref)),
+	}
+}
+
+// ReadContext bounds a read operation by the configured read timeout
+func (c *Client) ReadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, c.options.ReadTimeout)
+}
+
+// WriteContext bounds a write operation by the configured write timeout
+func (c *Client) WriteContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withTimeout(ctx, c.options.WriteTimeout)
+}
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 // Database returns the MongoDB database
 func (c *Client) Database() *mongo.Database {
 	return c.database
-}
\ No newline at end of file
+}
@@ -2,35 +2,50 @@ package mongo
 
 import (
 	"context"
+	"regexp"
+	"time"
 
 	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/search"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // ConfigRepository implements the ConfigRepository interface using MongoDB
 type ConfigRepository struct {
+	client     *Client
 	collection *mongo.Collection
+	reads      *RetryingCollection
 }
 
 // NewConfigRepository creates a new config repository
 func NewConfigRepository(client *Client) *ConfigRepository {
 	return &ConfigRepository{
+		client:     client,
 		collection: client.Collection("configs"),
+		reads:      client.ReadCollection("configs"),
 	}
 }
 
 // Create stores a new config
 func (r *ConfigRepository) Create(ctx context.Context, config *models.StoredConfig) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.collection.InsertOne(ctx, config)
 	return err
 }
 
 // GetByID retrieves a config by ID
 func (r *ConfigRepository) GetByID(ctx context.Context, id string) (*models.StoredConfig, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	var config models.StoredConfig
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&config)
+	err := r.reads.FindOne(ctx, bson.M{"_id": id}).Decode(&config)
 	if err != nil {
 		return nil, err
 	}
@@ -39,25 +54,69 @@ func (r *ConfigRepository) GetByID(ctx context.Context, id string) (*models.Stor
 
 // Update updates an existing config
 func (r *ConfigRepository) Update(ctx context.Context, config *models.StoredConfig) error {
-	// Note: UpdatedAt field doesn't exist in current StoredConfig model
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	config.UpdatedAt = time.Now()
 	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": config.ID}, config)
 	return err
 }
 
 // Delete removes a config
 func (r *ConfigRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
 
-// List retrieves configs with pagination
-func (r *ConfigRepository) List(ctx context.Context, limit, offset int) ([]*models.StoredConfig, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{},
-		&options.FindOptions{
-			Limit: int64ptr(limit),
-			Skip:  int64ptr(offset),
-		},
-	)
+// List retrieves configs matching the filters with sorting and pagination
+func (r *ConfigRepository) List(ctx context.Context, filters repository.ConfigFilters) ([]*models.StoredConfig, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	// Sort options
+	sortBy := "created_at"
+	if filters.SortBy != "" {
+		sortBy = filters.SortBy
+	}
+	sortOrder := -1 // desc
+	if filters.SortOrder == "asc" {
+		sortOrder = 1
+	}
+
+	opts := &options.FindOptions{
+		Sort:  bson.D{{Key: sortBy, Value: sortOrder}, {Key: "_id", Value: 1}},
+		Limit: int64ptr(filters.Limit),
+		Skip:  int64ptr(filters.Offset),
+	}
+
+	cursor, err := r.reads.Find(ctx, configFilter(filters), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var configs []*models.StoredConfig
+	if err = cursor.All(ctx, &configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// GetByOwner retrieves a user's configs, public and private, newest first
+func (r *ConfigRepository) GetByOwner(ctx context.Context, ownerID string, limit, offset int) ([]*models.StoredConfig, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	opts := &options.FindOptions{
+		Sort:  bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}},
+		Limit: int64ptr(limit),
+		Skip:  int64ptr(offset),
+	}
+
+	cursor, err := r.reads.Find(ctx, bson.M{"owner_id": ownerID}, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -70,14 +129,77 @@ func (r *ConfigRepository) List(ctx context.Context, limit, offset int) ([]*mode
 	return configs, nil
 }
 
+// Count returns the number of configs matching the filters, ignoring pagination
+func (r *ConfigRepository) Count(ctx context.Context, filters repository.ConfigFilters) (int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	count, err := r.reads.CountDocuments(ctx, configFilter(filters))
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// configFilter builds the query document shared by List and Count
+func configFilter(filters repository.ConfigFilters) bson.M {
+	filter := bson.M{}
+	if filters.OwnerID != "" {
+		filter["owner_id"] = filters.OwnerID
+	}
+	if filters.Public != nil {
+		filter["public"] = *filters.Public
+	}
+	if len(filters.Tags) > 0 {
+		filter["$and"] = allTagsFilter("config.metadata.tags", filters.Tags)
+	}
+	if terms := search.Terms(filters.Query); len(terms) > 0 {
+		filter["$or"] = configQueryFilter(terms)
+	}
+	applyDateRange(filter, filters.DateRange)
+	return filter
+}
+
+// configQueryFilter matches configs whose name, description, tags or author
+// contain any of the terms, ignoring case, like the memory store
+func configQueryFilter(terms []string) bson.A {
+	clauses := bson.A{}
+	for _, field := range []string{"config.metadata.name", "config.metadata.description", "config.metadata.tags", "config.metadata.author"} {
+		for _, term := range terms {
+			clauses = append(clauses, bson.M{field: primitive.Regex{Pattern: regexp.QuoteMeta(term), Options: "i"}})
+		}
+	}
+	return clauses
+}
+
+// applyDateRange adds inclusive created_at/updated_at bounds to a query
+func applyDateRange(filter bson.M, dates repository.DateRange) {
+	created := bson.M{}
+	if dates.CreatedAfter != nil {
+		created["$gte"] = *dates.CreatedAfter
+	}
+	if dates.CreatedBefore != nil {
+		created["$lte"] = *dates.CreatedBefore
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+	if dates.UpdatedAfter != nil {
+		filter["updated_at"] = bson.M{"$gte": *dates.UpdatedAfter}
+	}
+}
+
 // GetStats returns config statistics
 func (r *ConfigRepository) GetStats(ctx context.Context) (*models.ConfigStats, error) {
-	total, err := r.collection.CountDocuments(ctx, bson.M{})
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	total, err := r.reads.CountDocuments(ctx, bson.M{})
 	if err != nil {
 		return nil, err
 	}
 
-	public, err := r.collection.CountDocuments(ctx, bson.M{"public": true})
+	public, err := r.reads.CountDocuments(ctx, bson.M{"public": true})
 	if err != nil {
 		return nil, err
 	}
@@ -90,7 +212,7 @@ func (r *ConfigRepository) GetStats(ctx context.Context) (*models.ConfigStats, e
 		}},
 	}
 
-	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	cursor, err := r.reads.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -112,8 +234,36 @@ func (r *ConfigRepository) GetStats(ctx context.Context) (*models.ConfigStats, e
 	}, nil
 }
 
+// CountTags counts public configs per canonical tag
+func (r *ConfigRepository) CountTags(ctx context.Context, canonicalize func([]string) []string) (map[string]int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{"config.metadata.tags": 1})
+	cursor, err := r.reads.Find(ctx, bson.M{"public": true}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[string]int)
+	for cursor.Next(ctx) {
+		var config models.StoredConfig
+		if err := cursor.Decode(&config); err != nil {
+			return nil, err
+		}
+		for _, tag := range canonicalize(config.Config.Metadata.Tags) {
+			counts[tag]++
+		}
+	}
+	return counts, cursor.Err()
+}
+
 // IncrementDownloads increments the download count for a config
 func (r *ConfigRepository) IncrementDownloads(ctx context.Context, id string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": id},
@@ -122,6 +272,112 @@ func (r *ConfigRepository) IncrementDownloads(ctx context.Context, id string) er
 	return err
 }
 
+// GetUnownedByAuthor lists configs uploaded without an account whose
+// metadata author matches author, ignoring case, newest first
+func (r *ConfigRepository) GetUnownedByAuthor(ctx context.Context, author string) ([]*models.StoredConfig, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"owner_id":               "",
+		"config.metadata.author": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(author) + "$", Options: "i"},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.reads.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var configs []*models.StoredConfig
+	if err = cursor.All(ctx, &configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// Claim gives an unowned config to ownerID. The owner check is part of the
+// update, so two users claiming at once cannot both succeed.
+func (r *ConfigRepository) Claim(ctx context.Context, id, ownerID string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "owner_id": ""},
+		bson.M{"$set": bson.M{"owner_id": ownerID, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// configBrewsField and configCasksField are where a config's packages are
+// stored. ShareableConfig embeds BasicConfig without a bson inline tag, so
+// the lists sit in a "basicconfig" subdocument.
+const (
+	configBrewsField = "config.basicconfig.brews"
+	configCasksField = "config.basicconfig.casks"
+)
+
+// FindByPackages lists public configs sharing packages with the given
+// lists, ranked by how many they share
+func (r *ConfigRepository) FindByPackages(ctx context.Context, brews, casks []string, limit int) ([]*models.StoredConfig, error) {
+	if len(brews) == 0 && len(casks) == 0 {
+		return []*models.StoredConfig{}, nil
+	}
+
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	var matches bson.A
+	if len(brews) > 0 {
+		matches = append(matches, bson.M{configBrewsField: bson.M{"$in": brews}})
+	}
+	if len(casks) > 0 {
+		matches = append(matches, bson.M{configCasksField: bson.M{"$in": casks}})
+	}
+
+	shared := func(field string, names []string) bson.M {
+		if names == nil {
+			names = []string{}
+		}
+		return bson.M{"$size": bson.M{"$setIntersection": bson.A{
+			bson.M{"$ifNull": bson.A{"$" + field, bson.A{}}},
+			names,
+		}}}
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"public": true, "$or": matches}},
+		bson.M{"$addFields": bson.M{"shared_packages": bson.M{"$add": bson.A{
+			shared(configBrewsField, brews),
+			shared(configCasksField, casks),
+		}}}},
+		bson.M{"$sort": bson.D{{Key: "shared_packages", Value: -1}, {Key: "download_count", Value: -1}, {Key: "_id", Value: 1}}},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
+	pipeline = append(pipeline, bson.M{"$project": bson.M{"shared_packages": 0}})
+
+	cursor, err := r.reads.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	configs := []*models.StoredConfig{}
+	if err = cursor.All(ctx, &configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
 func int64ptr(i int) *int64 {
 	val := int64(i)
 	return &val
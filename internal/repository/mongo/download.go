@@ -0,0 +1,189 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DownloadEventRepository implements the DownloadEventRepository interface
+// using MongoDB
+type DownloadEventRepository struct {
+	client     *Client
+	collection *mongo.Collection
+	reads      *RetryingCollection
+}
+
+// NewDownloadEventRepository creates a new download event repository
+func NewDownloadEventRepository(client *Client) *DownloadEventRepository {
+	return &DownloadEventRepository{
+		client:     client,
+		collection: client.Collection("download_events"),
+		reads:      client.ReadCollection("download_events"),
+	}
+}
+
+// EnsureIndexes creates the indexes the download_events collection relies
+// on. Events expire through a TTL index once past the retention window.
+func (r *DownloadEventRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "resource_type", Value: 1}, {Key: "resource_id", Value: 1}, {Key: "at", Value: 1}},
+			Options: options.Index().SetName("resource_at"),
+		},
+		{
+			Keys:    bson.D{{Key: "resource_type", Value: 1}, {Key: "at", Value: 1}},
+			Options: options.Index().SetName("resource_type_at"),
+		},
+		{
+			Keys: bson.D{{Key: "at", Value: 1}},
+			Options: options.Index().
+				SetName("at_ttl").
+				SetExpireAfterSeconds(int32(repository.DownloadEventRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+// Record stores a download event
+func (r *DownloadEventRepository) Record(ctx context.Context, event *models.DownloadEvent) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	if event.ID == "" {
+		event.ID = primitive.NewObjectID().Hex()
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, event)
+	return err
+}
+
+// CountByDay counts a resource's downloads per UTC day since since
+func (r *DownloadEventRepository) CountByDay(ctx context.Context, resourceType, resourceID string, since time.Time) (map[string]int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"resource_type": resourceType,
+			"resource_id":   resourceID,
+			"at":            bson.M{"$gte": since},
+		}},
+		{"$group": bson.M{
+			"_id":       bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$at"}},
+			"downloads": bson.M{"$sum": 1},
+		}},
+	}
+
+	cursor, err := r.reads.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var days []struct {
+		Date      string `bson:"_id"`
+		Downloads int    `bson:"downloads"`
+	}
+	if err := cursor.All(ctx, &days); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(days))
+	for _, day := range days {
+		counts[day.Date] = day.Downloads
+	}
+	return counts, nil
+}
+
+// CountByPlatform counts a resource's downloads per platform since since
+func (r *DownloadEventRepository) CountByPlatform(ctx context.Context, resourceType, resourceID string, since time.Time) (map[string]int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"resource_type": resourceType,
+			"resource_id":   resourceID,
+			"at":            bson.M{"$gte": since},
+		}},
+		{"$group": bson.M{
+			"_id":       bson.M{"$ifNull": bson.A{"$platform", ""}},
+			"downloads": bson.M{"$sum": 1},
+		}},
+	}
+
+	cursor, err := r.reads.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var platforms []struct {
+		Platform  string `bson:"_id"`
+		Downloads int    `bson:"downloads"`
+	}
+	if err := cursor.All(ctx, &platforms); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(platforms))
+	for _, platform := range platforms {
+		counts[platform.Platform] = platform.Downloads
+	}
+	return counts, nil
+}
+
+// CountSince counts the downloads of every resource of the type since since
+func (r *DownloadEventRepository) CountSince(ctx context.Context, resourceType string, since time.Time) (int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	count, err := r.reads.CountDocuments(ctx, bson.M{
+		"resource_type": resourceType,
+		"at":            bson.M{"$gte": since},
+	})
+	return int(count), err
+}
+
+// TopSince lists the resources of the type downloaded most since since
+func (r *DownloadEventRepository) TopSince(ctx context.Context, resourceType string, since time.Time, limit int) ([]models.ResourceDownloads, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"resource_type": resourceType,
+			"at":            bson.M{"$gte": since},
+		}},
+		{"$group": bson.M{"_id": "$resource_id", "downloads": bson.M{"$sum": 1}}},
+		{"$sort": bson.D{{Key: "downloads", Value: -1}, {Key: "_id", Value: 1}}},
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
+
+	cursor, err := r.reads.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	result := []models.ResourceDownloads{}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
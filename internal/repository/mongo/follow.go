@@ -0,0 +1,96 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Follow records that followerID follows followeeID. Following again keeps
+// the original follow time.
+func (r *UserRepository) Follow(ctx context.Context, followerID, followeeID string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.follows.UpdateOne(
+		ctx,
+		bson.M{"follower_id": followerID, "followee_id": followeeID},
+		bson.M{"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID().Hex(),
+			"created_at": time.Now(),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	// Two concurrent upserts can race on the unique index; either way the
+	// follow exists
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+// Unfollow removes a follow
+func (r *UserRepository) Unfollow(ctx context.Context, followerID, followeeID string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.follows.DeleteOne(ctx, bson.M{"follower_id": followerID, "followee_id": followeeID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// GetFollowing returns the users userID follows, most recently followed first
+func (r *UserRepository) GetFollowing(ctx context.Context, userID string, limit, offset int) ([]*models.User, error) {
+	return r.followedUsers(ctx, "follower_id", userID, "followee_id", limit, offset)
+}
+
+// GetFollowers returns the users following userID, most recent first
+func (r *UserRepository) GetFollowers(ctx context.Context, userID string, limit, offset int) ([]*models.User, error) {
+	return r.followedUsers(ctx, "followee_id", userID, "follower_id", limit, offset)
+}
+
+// followedUsers pages through the follows whose field is userID, joining
+// the user on the other side of each. Deleted users are left out.
+func (r *UserRepository) followedUsers(ctx context.Context, field, userID, other string, limit, offset int) ([]*models.User, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{field: userID}},
+		{"$sort": bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}}},
+		{"$lookup": bson.M{
+			"from":         "users",
+			"localField":   other,
+			"foreignField": "_id",
+			"as":           "user",
+		}},
+		{"$unwind": "$user"},
+		{"$match": bson.M{"user.deleted_at": nil}},
+		{"$skip": offset},
+		{"$limit": limit},
+		{"$replaceRoot": bson.M{"newRoot": "$user"}},
+	}
+
+	cursor, err := r.followReads.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
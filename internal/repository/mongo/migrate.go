@@ -0,0 +1,195 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// migrationsCollection records the name of every applied migration
+const migrationsCollection = "schema_migrations"
+
+// defaultMigrationBatchSize is how many documents a backfill updates per
+// round trip
+const defaultMigrationBatchSize = 500
+
+// Migration is a named, one-off data change. Up must be safe to run again
+// if a previous run stopped partway, since a migration is only recorded as
+// applied once Up returns.
+type Migration struct {
+	Name string
+	Up   func(ctx context.Context, m *Migrator) error
+}
+
+// Migrations lists every schema migration in the order they apply. Append
+// new migrations to the end and never rename or reorder applied ones.
+func Migrations() []Migration {
+	return []Migration{
+		{Name: "0001_users_username_lower", Up: addUsernameLower},
+		{Name: "0002_organization_invites_hash_tokens", Up: hashInviteTokens},
+	}
+}
+
+// appliedMigration is a schema_migrations document
+type appliedMigration struct {
+	Name      string    `bson:"_id"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// Migrator applies pending migrations and records them in
+// schema_migrations
+type Migrator struct {
+	client     *Client
+	migrations []Migration
+	batchSize  int
+	logger     *slog.Logger
+}
+
+// NewMigrator creates a migrator for the given migrations, usually
+// Migrations()
+func NewMigrator(client *Client, migrations []Migration) *Migrator {
+	return &Migrator{
+		client:     client,
+		migrations: migrations,
+		batchSize:  defaultMigrationBatchSize,
+		logger:     client.logger.With("component", "migrations"),
+	}
+}
+
+// SetBatchSize changes how many documents Backfill updates per round trip
+func (m *Migrator) SetBatchSize(size int) {
+	if size > 0 {
+		m.batchSize = size
+	}
+}
+
+// Run applies every migration not yet recorded in schema_migrations, in
+// order, stopping at the first failure
+func (m *Migrator) Run(ctx context.Context) error {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	for _, migration := range m.migrations {
+		if applied[migration.Name] {
+			continue
+		}
+
+		m.logger.Info("applying migration", "migration", migration.Name)
+		started := time.Now()
+		if err := migration.Up(ctx, m); err != nil {
+			return fmt.Errorf("migration %s failed: %w", migration.Name, err)
+		}
+
+		_, err := m.client.Collection(migrationsCollection).InsertOne(ctx, appliedMigration{
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		})
+		// Another instance finished the same migration first
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+		}
+		m.logger.Info("applied migration", "migration", migration.Name, "duration", time.Since(started))
+	}
+	return nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[string]bool, error) {
+	cursor, err := m.client.Collection(migrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	applied := make(map[string]bool)
+	for cursor.Next(ctx) {
+		var record appliedMigration
+		if err := cursor.Decode(&record); err != nil {
+			return nil, err
+		}
+		applied[record.Name] = true
+	}
+	return applied, cursor.Err()
+}
+
+// Backfill walks the documents in collection matching filter in _id order,
+// batchSize at a time, and applies the update fn returns for each one.
+// A nil update leaves the document alone. Progress is logged after every
+// batch.
+func (m *Migrator) Backfill(ctx context.Context, collection string, filter bson.M, fn func(bson.M) bson.M) error {
+	coll := m.client.Collection(collection)
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(m.batchSize))
+
+	var lastID interface{}
+	updated := 0
+	for {
+		batchFilter := bson.M{}
+		for key, value := range filter {
+			batchFilter[key] = value
+		}
+		if lastID != nil {
+			batchFilter["_id"] = bson.M{"$gt": lastID}
+		}
+
+		cursor, err := coll.Find(ctx, batchFilter, opts)
+		if err != nil {
+			return err
+		}
+		var docs []bson.M
+		if err := cursor.All(ctx, &docs); err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+
+		var writes []mongo.WriteModel
+		for _, doc := range docs {
+			if update := fn(doc); update != nil {
+				writes = append(writes, mongo.NewUpdateOneModel().
+					SetFilter(bson.M{"_id": doc["_id"]}).
+					SetUpdate(update))
+			}
+		}
+		if len(writes) > 0 {
+			if _, err := coll.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+				return err
+			}
+		}
+
+		updated += len(writes)
+		lastID = docs[len(docs)-1]["_id"]
+		m.logger.Info("backfill progress", "collection", collection, "updated", updated)
+	}
+}
+
+// addUsernameLower stores a lowercased copy of every username so lookups
+// can ignore case
+func addUsernameLower(ctx context.Context, m *Migrator) error {
+	filter := bson.M{"username_lower": bson.M{"$exists": false}}
+	return m.Backfill(ctx, "users", filter, func(doc bson.M) bson.M {
+		username, _ := doc["username"].(string)
+		return bson.M{"$set": bson.M{"username_lower": strings.ToLower(username)}}
+	})
+}
+
+// hashInviteTokens replaces the plaintext token stored on older invites with
+// its hash
+func hashInviteTokens(ctx context.Context, m *Migrator) error {
+	filter := bson.M{"token": bson.M{"$exists": true}}
+	return m.Backfill(ctx, "organization_invites", filter, func(doc bson.M) bson.M {
+		token, _ := doc["token"].(string)
+		return bson.M{
+			"$set":   bson.M{"token_hash": models.HashInviteToken(token)},
+			"$unset": bson.M{"token": ""},
+		}
+	})
+}
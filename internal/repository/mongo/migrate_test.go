@@ -0,0 +1,115 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// newIntegrationClient connects to the MongoDB at MONGODB_URI using a
+// throwaway database, skipping the test when MONGODB_URI is unset
+func newIntegrationClient(t *testing.T) *Client {
+	t.Helper()
+
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		t.Skip("MONGODB_URI not set; skipping MongoDB integration test")
+	}
+
+	dbName := fmt.Sprintf("dotfiles_test_%d", time.Now().UnixNano())
+	client, err := NewClient(uri, dbName, ClientOptions{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		_ = client.Database().Drop(ctx)
+		_ = client.Close(ctx)
+	})
+	return client
+}
+
+func TestMigratorAppliesUsernameLowerOnce(t *testing.T) {
+	client := newIntegrationClient(t)
+	ctx := context.Background()
+	users := client.Collection("users")
+
+	// More users than one batch, stored the way they were before the field
+	// existed
+	for i := 0; i < 7; i++ {
+		_, err := users.InsertOne(ctx, bson.M{"_id": fmt.Sprintf("user-%02d", i), "username": fmt.Sprintf("OctoCat%d", i)})
+		if err != nil {
+			t.Fatalf("Failed to insert user: %v", err)
+		}
+	}
+
+	runs := 0
+	migrations := Migrations()
+	up := migrations[0].Up
+	migrations[0].Up = func(ctx context.Context, m *Migrator) error {
+		runs++
+		return up(ctx, m)
+	}
+
+	for i := 0; i < 2; i++ {
+		migrator := NewMigrator(client, migrations)
+		migrator.SetBatchSize(3)
+		if err := migrator.Run(ctx); err != nil {
+			t.Fatalf("Run %d failed: %v", i+1, err)
+		}
+	}
+
+	if runs != 1 {
+		t.Errorf("Expected the migration to run once, ran %d times", runs)
+	}
+	recorded, err := client.Collection(migrationsCollection).CountDocuments(ctx, bson.M{"_id": "0001_users_username_lower"})
+	if err != nil {
+		t.Fatalf("Failed to count applied migrations: %v", err)
+	}
+	if recorded != 1 {
+		t.Errorf("Expected one schema_migrations record, got %d", recorded)
+	}
+
+	for i := 0; i < 7; i++ {
+		var user bson.M
+		if err := users.FindOne(ctx, bson.M{"_id": fmt.Sprintf("user-%02d", i)}).Decode(&user); err != nil {
+			t.Fatalf("Failed to read user: %v", err)
+		}
+		if want := fmt.Sprintf("octocat%d", i); user["username_lower"] != want {
+			t.Errorf("Expected username_lower %q, got %v", want, user["username_lower"])
+		}
+	}
+}
+
+func TestUsernameLowerMigrationIsIdempotent(t *testing.T) {
+	client := newIntegrationClient(t)
+	ctx := context.Background()
+	users := client.Collection("users")
+
+	if _, err := users.InsertOne(ctx, bson.M{"_id": "user-1", "username": "OctoCat"}); err != nil {
+		t.Fatalf("Failed to insert user: %v", err)
+	}
+
+	// Running the migration body again, e.g. after a crash before it was
+	// recorded, must leave the data unchanged
+	migrator := NewMigrator(client, Migrations())
+	for i := 0; i < 2; i++ {
+		if err := addUsernameLower(ctx, migrator); err != nil {
+			t.Fatalf("Run %d failed: %v", i+1, err)
+		}
+	}
+
+	var user bson.M
+	if err := users.FindOne(ctx, bson.M{"_id": "user-1"}).Decode(&user); err != nil {
+		t.Fatalf("Failed to read user: %v", err)
+	}
+	if user["username_lower"] != "octocat" || user["username"] != "OctoCat" {
+		t.Errorf("Expected the username to be kept with its lowercase copy, got %v", user)
+	}
+}
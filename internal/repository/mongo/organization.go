@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -13,22 +14,37 @@ import (
 
 // OrganizationRepository implements the OrganizationRepository interface using MongoDB
 type OrganizationRepository struct {
-	orgCollection     *mongo.Collection
-	memberCollection  *mongo.Collection
-	inviteCollection  *mongo.Collection
+	client                *Client
+	orgCollection         *mongo.Collection
+	memberCollection      *mongo.Collection
+	inviteCollection      *mongo.Collection
+	joinRequestCollection *mongo.Collection
+	orgReads              *RetryingCollection
+	memberReads           *RetryingCollection
+	inviteReads           *RetryingCollection
+	joinRequestReads      *RetryingCollection
 }
 
 // NewOrganizationRepository creates a new organization repository
 func NewOrganizationRepository(client *Client) *OrganizationRepository {
 	return &OrganizationRepository{
-		orgCollection:     client.Collection("organizations"),
-		memberCollection:  client.Collection("organization_members"),
-		inviteCollection:  client.Collection("organization_invites"),
+		client:                client,
+		orgCollection:         client.Collection("organizations"),
+		memberCollection:      client.Collection("organization_members"),
+		inviteCollection:      client.Collection("organization_invites"),
+		joinRequestCollection: client.Collection("organization_join_requests"),
+		orgReads:              client.ReadCollection("organizations"),
+		memberReads:           client.ReadCollection("organization_members"),
+		inviteReads:           client.ReadCollection("organization_invites"),
+		joinRequestReads:      client.ReadCollection("organization_join_requests"),
 	}
 }
 
 // Create stores a new organization
 func (r *OrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	if org.ID == "" {
 		org.ID = primitive.NewObjectID().Hex()
 	}
@@ -36,26 +52,100 @@ func (r *OrganizationRepository) Create(ctx context.Context, org *models.Organiz
 	org.UpdatedAt = time.Now()
 
 	_, err := r.orgCollection.InsertOne(ctx, org)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+// EnsureIndexes creates the indexes the organization collections rely on.
+// The unique slug index stops two concurrent requests from creating the
+// same slug twice, invites are looked up by their token hash, and a partial
+// unique index allows one pending join request per user and organization.
+func (r *OrganizationRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.orgCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetName("slug_unique").SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.inviteCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "token_hash", Value: 1}},
+		Options: options.Index().
+			SetName("token_hash_unique").
+			SetUnique(true).
+			// Invites from before tokens were hashed lack the field until
+			// the 0002 migration runs
+			SetPartialFilterExpression(bson.M{"token_hash": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.joinRequestCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "organization_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().
+				SetName("pending_join_request_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": models.JoinRequestPending}),
+		},
+		{
+			Keys:    bson.D{{Key: "organization_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("organization_status_created_at"),
+		},
+	})
 	return err
 }
 
 // GetByID retrieves an organization by ID
 func (r *OrganizationRepository) GetByID(ctx context.Context, id string) (*models.Organization, error) {
-	var org models.Organization
-	err := r.orgCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&org)
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	return findOrganization(ctx, r.orgReads, bson.M{"_id": id})
+}
+
+// GetByIDs retrieves the organizations with any of the given IDs
+func (r *OrganizationRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Organization, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	cursor, err := r.orgReads.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, nil
-		}
 		return nil, err
 	}
-	return &org, nil
+	defer cursor.Close(ctx)
+
+	var orgs []*models.Organization
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
 }
 
 // GetBySlug retrieves an organization by slug
 func (r *OrganizationRepository) GetBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	return findOrganization(ctx, r.orgReads, bson.M{"slug": slug})
+}
+
+// findOrganization looks up a single organization on the given collection
+// handle, so write paths can read from the primary
+func findOrganization(ctx context.Context, orgs reader, filter bson.M) (*models.Organization, error) {
 	var org models.Organization
-	err := r.orgCollection.FindOne(ctx, bson.M{"slug": slug}).Decode(&org)
+	err := orgs.FindOne(ctx, filter).Decode(&org)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -67,6 +157,9 @@ func (r *OrganizationRepository) GetBySlug(ctx context.Context, slug string) (*m
 
 // Update updates an existing organization
 func (r *OrganizationRepository) Update(ctx context.Context, org *models.Organization) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	org.UpdatedAt = time.Now()
 	_, err := r.orgCollection.ReplaceOne(ctx, bson.M{"_id": org.ID}, org)
 	return err
@@ -74,6 +167,9 @@ func (r *OrganizationRepository) Update(ctx context.Context, org *models.Organiz
 
 // Delete removes an organization
 func (r *OrganizationRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	// Also cleanup members and invites
 	_, err := r.memberCollection.DeleteMany(ctx, bson.M{"organization_id": id})
 	if err != nil {
@@ -91,13 +187,40 @@ func (r *OrganizationRepository) Delete(ctx context.Context, id string) error {
 
 // List retrieves organizations with pagination
 func (r *OrganizationRepository) List(ctx context.Context, limit, offset int) ([]*models.Organization, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	opts := &options.FindOptions{
 		Sort:  bson.D{{Key: "created_at", Value: -1}},
 		Limit: int64ptr(limit),
 		Skip:  int64ptr(offset),
 	}
 
-	cursor, err := r.orgCollection.Find(ctx, bson.M{"public": true}, opts)
+	cursor, err := r.orgReads.Find(ctx, bson.M{"public": true}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var orgs []*models.Organization
+	if err = cursor.All(ctx, &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// ListAll pages through every organization, public or not, ordered by ID
+func (r *OrganizationRepository) ListAll(ctx context.Context, limit, offset int) ([]*models.Organization, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	opts := &options.FindOptions{
+		Sort:  bson.D{{Key: "_id", Value: 1}},
+		Limit: int64ptr(limit),
+		Skip:  int64ptr(offset),
+	}
+
+	cursor, err := r.orgReads.Find(ctx, bson.M{}, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -112,6 +235,9 @@ func (r *OrganizationRepository) List(ctx context.Context, limit, offset int) ([
 
 // Search searches organizations by query
 func (r *OrganizationRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.Organization, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	filter := bson.M{
 		"public": true,
 		"$or": []bson.M{
@@ -126,7 +252,7 @@ func (r *OrganizationRepository) Search(ctx context.Context, query string, limit
 		Skip:  int64ptr(offset),
 	}
 
-	cursor, err := r.orgCollection.Find(ctx, filter, opts)
+	cursor, err := r.orgReads.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -141,9 +267,12 @@ func (r *OrganizationRepository) Search(ctx context.Context, query string, limit
 
 // GetByOwner retrieves organizations owned by a user
 func (r *OrganizationRepository) GetByOwner(ctx context.Context, ownerID string) ([]*models.Organization, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	filter := bson.M{"owner_id": ownerID}
 
-	cursor, err := r.orgCollection.Find(ctx, filter)
+	cursor, err := r.orgReads.Find(ctx, filter)
 	if err != nil {
 		return nil, err
 	}
@@ -158,8 +287,11 @@ func (r *OrganizationRepository) GetByOwner(ctx context.Context, ownerID string)
 
 // GetUserOrganizations retrieves organizations where user is a member
 func (r *OrganizationRepository) GetUserOrganizations(ctx context.Context, userID string) ([]*models.Organization, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	// Find all organization IDs where user is a member
-	cursor, err := r.memberCollection.Find(ctx, bson.M{"user_id": userID})
+	cursor, err := r.memberReads.Find(ctx, bson.M{"user_id": userID})
 	if err != nil {
 		return nil, err
 	}
@@ -181,7 +313,7 @@ func (r *OrganizationRepository) GetUserOrganizations(ctx context.Context, userI
 	}
 
 	// Find organizations
-	cursor, err = r.orgCollection.Find(ctx, bson.M{"_id": bson.M{"$in": orgIDs}})
+	cursor, err = r.orgReads.Find(ctx, bson.M{"_id": bson.M{"$in": orgIDs}})
 	if err != nil {
 		return nil, err
 	}
@@ -194,29 +326,260 @@ func (r *OrganizationRepository) GetUserOrganizations(ctx context.Context, userI
 	return orgs, nil
 }
 
-// AddMember adds a member to an organization
+// GetUserMemberships retrieves the user's memberships. They are read from
+// the primary because sessions cache the result.
+func (r *OrganizationRepository) GetUserMemberships(ctx context.Context, userID string) ([]*models.OrganizationMember, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	cursor, err := r.memberCollection.Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	members := []*models.OrganizationMember{}
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// SetMaxMembers updates the member limit without touching member_count
+func (r *OrganizationRepository) SetMaxMembers(ctx context.Context, orgID string, maxMembers int) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.orgCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": orgID},
+		bson.M{"$set": bson.M{
+			"max_members": maxMembers,
+			"updated_at":  time.Now(),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// SetPinnedTemplates replaces the templates the organization features
+func (r *OrganizationRepository) SetPinnedTemplates(ctx context.Context, orgID string, templateIDs []string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.orgCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": orgID},
+		bson.M{"$set": bson.M{
+			"pinned_template_ids": templateIDs,
+			"updated_at":          time.Now(),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// SetMemberCount sets member_count only while it still holds the value the
+// caller corrected, so concurrent joins and departures are not lost
+func (r *OrganizationRepository) SetMemberCount(ctx context.Context, orgID string, from, to int) (bool, error) {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.orgCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": orgID, "member_count": from},
+		bson.M{"$set": bson.M{"member_count": to}},
+	)
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount == 0 {
+		count, err := r.orgCollection.CountDocuments(ctx, bson.M{"_id": orgID})
+		if err != nil {
+			return false, err
+		}
+		if count == 0 {
+			return false, repository.ErrNotFound
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// GetByCustomDomain retrieves the organization a custom domain is assigned to
+func (r *OrganizationRepository) GetByCustomDomain(ctx context.Context, domain string) (*models.Organization, error) {
+	if domain == "" {
+		return nil, nil
+	}
+
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	return findOrganization(ctx, r.orgReads, bson.M{"custom_domain": domain})
+}
+
+// SetCustomDomain assigns a custom domain and resets its verification
+func (r *OrganizationRepository) SetCustomDomain(ctx context.Context, orgID, domain, token string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	update := bson.M{
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"custom_domain": "", "domain_token": "", "domain_verified_at": ""},
+	}
+
+	if domain != "" {
+		// Check on the primary so a domain assigned moments ago is seen
+		owner, err := findOrganization(ctx, r.orgCollection, bson.M{
+			"custom_domain": domain,
+			"_id":           bson.M{"$ne": orgID},
+		})
+		if err != nil {
+			return err
+		}
+		if owner != nil {
+			return repository.ErrAlreadyExists
+		}
+
+		update = bson.M{
+			"$set": bson.M{
+				"custom_domain": domain,
+				"domain_token":  token,
+				"updated_at":    time.Now(),
+			},
+			"$unset": bson.M{"domain_verified_at": ""},
+		}
+	}
+
+	result, err := r.orgCollection.UpdateOne(ctx, bson.M{"_id": orgID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// VerifyCustomDomain marks the custom domain verified if it has not been
+// changed since the challenge was read
+func (r *OrganizationRepository) VerifyCustomDomain(ctx context.Context, orgID, domain string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	now := time.Now()
+	result, err := r.orgCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": orgID, "custom_domain": domain},
+		bson.M{"$set": bson.M{
+			"domain_verified_at": now,
+			"updated_at":         now,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// AddMember adds a member to an organization. The member count is only
+// incremented while members plus pending invites stay below MaxMembers,
+// so concurrent adds cannot overshoot the limit.
 func (r *OrganizationRepository) AddMember(ctx context.Context, member *models.OrganizationMember) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	// Reads on write paths go to the primary so checks see the latest state
+	exists, err := isMember(ctx, r.memberCollection, member.OrganizationID, member.UserID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return repository.ErrAlreadyExists
+	}
+
+	pending, err := countPendingInvites(ctx, r.inviteCollection, member.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	if err := r.reserveSeat(ctx, member.OrganizationID, pending); err != nil {
+		return err
+	}
+
 	if member.ID == "" {
 		member.ID = primitive.NewObjectID().Hex()
 	}
 	member.JoinedAt = time.Now()
 
-	_, err := r.memberCollection.InsertOne(ctx, member)
-	if err != nil {
+	if _, err := r.memberCollection.InsertOne(ctx, member); err != nil {
+		r.releaseSeat(ctx, member.OrganizationID)
 		return err
 	}
+	return nil
+}
 
-	// Update member count
-	_, err = r.orgCollection.UpdateOne(
+// reserveSeat increments member_count only if the organization has room for
+// one more member on top of the given number of reserved seats
+func (r *OrganizationRepository) reserveSeat(ctx context.Context, orgID string, reserved int) error {
+	result, err := r.orgCollection.UpdateOne(
 		ctx,
-		bson.M{"_id": member.OrganizationID},
+		bson.M{
+			"_id": orgID,
+			"$or": []bson.M{
+				{"max_members": bson.M{"$exists": false}},
+				{"max_members": bson.M{"$lte": 0}},
+				{"$expr": bson.M{"$lt": bson.A{
+					bson.M{"$add": bson.A{"$member_count", reserved}},
+					"$max_members",
+				}}},
+			},
+		},
 		bson.M{"$inc": bson.M{"member_count": 1}},
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		count, err := r.orgCollection.CountDocuments(ctx, bson.M{"_id": orgID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return repository.ErrNotFound
+		}
+		return repository.ErrOrganizationFull
+	}
+	return nil
+}
+
+// releaseSeat undoes a reserveSeat after a failed write
+func (r *OrganizationRepository) releaseSeat(ctx context.Context, orgID string) {
+	r.orgCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": orgID},
+		bson.M{"$inc": bson.M{"member_count": -1}},
+	)
 }
 
 // RemoveMember removes a member from an organization
 func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID, userID string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.memberCollection.DeleteOne(ctx, bson.M{
 		"organization_id": orgID,
 		"user_id":         userID,
@@ -236,6 +599,9 @@ func (r *OrganizationRepository) RemoveMember(ctx context.Context, orgID, userID
 
 // UpdateMemberRole updates a member's role
 func (r *OrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID, role string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.memberCollection.UpdateOne(
 		ctx,
 		bson.M{
@@ -249,7 +615,35 @@ func (r *OrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, us
 
 // GetMembers retrieves all members of an organization
 func (r *OrganizationRepository) GetMembers(ctx context.Context, orgID string) ([]*models.OrganizationMember, error) {
-	cursor, err := r.memberCollection.Find(ctx, bson.M{"organization_id": orgID})
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	cursor, err := r.memberReads.Find(ctx, bson.M{"organization_id": orgID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var members []*models.OrganizationMember
+	if err = cursor.All(ctx, &members); err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ListMembers pages through the memberships of every organization,
+// ordered by membership ID
+func (r *OrganizationRepository) ListMembers(ctx context.Context, limit, offset int) ([]*models.OrganizationMember, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	opts := &options.FindOptions{
+		Sort:  bson.D{{Key: "_id", Value: 1}},
+		Limit: int64ptr(limit),
+		Skip:  int64ptr(offset),
+	}
+
+	cursor, err := r.memberReads.Find(ctx, bson.M{}, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -264,8 +658,11 @@ func (r *OrganizationRepository) GetMembers(ctx context.Context, orgID string) (
 
 // GetMember retrieves a specific member
 func (r *OrganizationRepository) GetMember(ctx context.Context, orgID, userID string) (*models.OrganizationMember, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	var member models.OrganizationMember
-	err := r.memberCollection.FindOne(ctx, bson.M{
+	err := r.memberReads.FindOne(ctx, bson.M{
 		"organization_id": orgID,
 		"user_id":         userID,
 	}).Decode(&member)
@@ -280,28 +677,99 @@ func (r *OrganizationRepository) GetMember(ctx context.Context, orgID, userID st
 
 // IsMember checks if a user is a member of an organization
 func (r *OrganizationRepository) IsMember(ctx context.Context, orgID, userID string) (bool, error) {
-	count, err := r.memberCollection.CountDocuments(ctx, bson.M{
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	return isMember(ctx, r.memberReads, orgID, userID)
+}
+
+func isMember(ctx context.Context, members reader, orgID, userID string) (bool, error) {
+	count, err := members.CountDocuments(ctx, bson.M{
 		"organization_id": orgID,
 		"user_id":         userID,
 	})
 	return count > 0, err
 }
 
-// CreateInvite creates an organization invite
+// CreateInvite creates an organization invite. An email may only hold one
+// active invite per organization, and pending invites count toward
+// MaxMembers, so an invite is refused once the organization is full.
 func (r *OrganizationRepository) CreateInvite(ctx context.Context, invite *models.OrganizationInvite) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	org, err := findOrganization(ctx, r.orgCollection, bson.M{"_id": invite.OrganizationID})
+	if err != nil {
+		return err
+	}
+	if org == nil {
+		return repository.ErrNotFound
+	}
+
+	active, err := findActiveInvite(ctx, r.inviteCollection, invite.OrganizationID, invite.Email)
+	if err != nil {
+		return err
+	}
+	if active != nil {
+		return repository.ErrAlreadyExists
+	}
+
+	if org.MaxMembers > 0 {
+		pending, err := countPendingInvites(ctx, r.inviteCollection, invite.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if org.MemberCount+pending >= org.MaxMembers {
+			return repository.ErrOrganizationFull
+		}
+	}
+
 	if invite.ID == "" {
 		invite.ID = primitive.NewObjectID().Hex()
 	}
 	invite.CreatedAt = time.Now()
+	invite.TokenHash = models.HashInviteToken(invite.Token)
 
-	_, err := r.inviteCollection.InsertOne(ctx, invite)
+	_, err = r.inviteCollection.InsertOne(ctx, invite)
 	return err
 }
 
 // GetInvite retrieves an invite by token
 func (r *OrganizationRepository) GetInvite(ctx context.Context, token string) (*models.OrganizationInvite, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	return findInvite(ctx, r.inviteReads, token)
+}
+
+func findInvite(ctx context.Context, invites reader, token string) (*models.OrganizationInvite, error) {
+	var invite models.OrganizationInvite
+	err := invites.FindOne(ctx, bson.M{"token_hash": models.HashInviteToken(token)}).Decode(&invite)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// GetActiveInvite retrieves the unexpired, unaccepted invite for an email
+func (r *OrganizationRepository) GetActiveInvite(ctx context.Context, orgID, email string) (*models.OrganizationInvite, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	return findActiveInvite(ctx, r.inviteReads, orgID, email)
+}
+
+func findActiveInvite(ctx context.Context, invites reader, orgID, email string) (*models.OrganizationInvite, error) {
 	var invite models.OrganizationInvite
-	err := r.inviteCollection.FindOne(ctx, bson.M{"token": token}).Decode(&invite)
+	err := invites.FindOne(ctx, bson.M{
+		"organization_id": orgID,
+		"email":           email,
+		"accepted_at":     nil,
+		"expires_at":      bson.M{"$gt": time.Now()},
+	}).Decode(&invite)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -311,9 +779,54 @@ func (r *OrganizationRepository) GetInvite(ctx context.Context, token string) (*
 	return &invite, nil
 }
 
+// RenewInvite swaps an unaccepted invite's token and pushes out its expiry
+func (r *OrganizationRepository) RenewInvite(ctx context.Context, orgID, id, token string, expiresAt time.Time) (*models.OrganizationInvite, error) {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":             id,
+		"organization_id": orgID,
+		"accepted_at":     nil,
+	}
+
+	var invite models.OrganizationInvite
+	if err := r.inviteCollection.FindOne(ctx, filter).Decode(&invite); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+
+	active, err := findActiveInvite(ctx, r.inviteCollection, orgID, invite.Email)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil && active.ID != invite.ID {
+		return nil, repository.ErrAlreadyExists
+	}
+
+	err = r.inviteCollection.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$set": bson.M{"token_hash": models.HashInviteToken(token), "expires_at": expiresAt}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&invite)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
 // GetInvitesByOrganization retrieves all invites for an organization
 func (r *OrganizationRepository) GetInvitesByOrganization(ctx context.Context, orgID string) ([]*models.OrganizationInvite, error) {
-	cursor, err := r.inviteCollection.Find(ctx, bson.M{"organization_id": orgID})
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	cursor, err := r.inviteReads.Find(ctx, bson.M{"organization_id": orgID})
 	if err != nil {
 		return nil, err
 	}
@@ -326,28 +839,236 @@ func (r *OrganizationRepository) GetInvitesByOrganization(ctx context.Context, o
 	return invites, nil
 }
 
-// AcceptInvite marks an invite as accepted
+// CountPendingInvites counts unexpired invites that have not been accepted
+func (r *OrganizationRepository) CountPendingInvites(ctx context.Context, orgID string) (int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	return countPendingInvites(ctx, r.inviteReads, orgID)
+}
+
+func countPendingInvites(ctx context.Context, invites reader, orgID string) (int, error) {
+	count, err := invites.CountDocuments(ctx, bson.M{
+		"organization_id": orgID,
+		"accepted_at":     nil,
+		"expires_at":      bson.M{"$gt": time.Now()},
+	})
+	return int(count), err
+}
+
+// AcceptInvite claims a pending invite and adds the user as a member with
+// the invite's role. The invite's seat is already counted, so accepting only
+// requires member_count to be below MaxMembers.
 func (r *OrganizationRepository) AcceptInvite(ctx context.Context, token string, userID string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	invite, err := findInvite(ctx, r.inviteCollection, token)
+	if err != nil {
+		return err
+	}
+	if invite == nil {
+		return repository.ErrNotFound
+	}
+
+	exists, err := isMember(ctx, r.memberCollection, invite.OrganizationID, userID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return repository.ErrAlreadyExists
+	}
+
+	// Claim the invite so it cannot be accepted twice. The claim is a single
+	// conditional update, so of two concurrent accepts only one matches.
 	now := time.Now()
-	_, err := r.inviteCollection.UpdateOne(
+	claimed, err := r.inviteCollection.UpdateOne(
 		ctx,
-		bson.M{"token": token},
+		bson.M{
+			"_id":         invite.ID,
+			"accepted_at": nil,
+			"expires_at":  bson.M{"$gt": now},
+		},
 		bson.M{"$set": bson.M{"accepted_at": &now}},
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if claimed.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+
+	unclaim := func() {
+		r.inviteCollection.UpdateOne(
+			ctx,
+			bson.M{"_id": invite.ID},
+			bson.M{"$unset": bson.M{"accepted_at": ""}},
+		)
+	}
+
+	if err := r.reserveSeat(ctx, invite.OrganizationID, 0); err != nil {
+		unclaim()
+		return err
+	}
+
+	member := &models.OrganizationMember{
+		ID:             primitive.NewObjectID().Hex(),
+		OrganizationID: invite.OrganizationID,
+		UserID:         userID,
+		Role:           invite.Role,
+		JoinedAt:       now,
+	}
+	if _, err := r.memberCollection.InsertOne(ctx, member); err != nil {
+		r.releaseSeat(ctx, invite.OrganizationID)
+		unclaim()
+		return err
+	}
+	return nil
 }
 
 // DeleteInvite removes an invite
 func (r *OrganizationRepository) DeleteInvite(ctx context.Context, id string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.inviteCollection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
 
 // CleanupExpiredInvites removes expired invites
 func (r *OrganizationRepository) CleanupExpiredInvites(ctx context.Context) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.inviteCollection.DeleteMany(ctx, bson.M{
 		"expires_at": bson.M{"$lt": time.Now()},
 		"accepted_at": nil,
 	})
 	return err
-}
\ No newline at end of file
+}
+
+// SetAllowJoinRequests turns an organization's join requests on or off
+func (r *OrganizationRepository) SetAllowJoinRequests(ctx context.Context, orgID string, allow bool) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.orgCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": orgID},
+		bson.M{"$set": bson.M{
+			"allow_join_requests": allow,
+			"updated_at":          time.Now(),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// CreateJoinRequest stores a new pending join request
+func (r *OrganizationRepository) CreateJoinRequest(ctx context.Context, request *models.OrganizationJoinRequest) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	if request.ID == "" {
+		request.ID = primitive.NewObjectID().Hex()
+	}
+	request.Status = models.JoinRequestPending
+	request.CreatedAt = time.Now()
+
+	_, err := r.joinRequestCollection.InsertOne(ctx, request)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+// GetJoinRequest retrieves one of an organization's join requests
+func (r *OrganizationRepository) GetJoinRequest(ctx context.Context, orgID, id string) (*models.OrganizationJoinRequest, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	var request models.OrganizationJoinRequest
+	err := r.joinRequestReads.FindOne(ctx, bson.M{"_id": id, "organization_id": orgID}).Decode(&request)
+	if err == mongo.ErrNoDocuments {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// ListJoinRequests pages through an organization's join requests with the
+// given status, oldest first
+func (r *OrganizationRepository) ListJoinRequests(ctx context.Context, orgID, status string, limit, offset int) ([]*models.OrganizationJoinRequest, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	opts := &options.FindOptions{
+		Sort:  bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+		Limit: int64ptr(limit),
+		Skip:  int64ptr(offset),
+	}
+
+	cursor, err := r.joinRequestReads.Find(ctx, bson.M{"organization_id": orgID, "status": status}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	requests := []*models.OrganizationJoinRequest{}
+	if err = cursor.All(ctx, &requests); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// CountJoinRequests counts an organization's join requests with the given
+// status
+func (r *OrganizationRepository) CountJoinRequests(ctx context.Context, orgID, status string) (int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	count, err := r.joinRequestReads.CountDocuments(ctx, bson.M{"organization_id": orgID, "status": status})
+	return int(count), err
+}
+
+// ResolveJoinRequest approves or rejects a join request that is still
+// pending, so a request resolved concurrently is resolved only once
+func (r *OrganizationRepository) ResolveJoinRequest(ctx context.Context, orgID, id, status, resolvedBy, reason string, at time.Time) (bool, error) {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	set := bson.M{
+		"status":      status,
+		"resolved_by": resolvedBy,
+		"resolved_at": at,
+	}
+	if reason != "" {
+		set["reason"] = reason
+	}
+
+	result, err := r.joinRequestCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "organization_id": orgID, "status": models.JoinRequestPending},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount == 0 {
+		count, err := r.joinRequestCollection.CountDocuments(ctx, bson.M{"_id": id, "organization_id": orgID})
+		if err != nil {
+			return false, err
+		}
+		if count == 0 {
+			return false, repository.ErrNotFound
+		}
+		return false, nil
+	}
+	return true, nil
+}
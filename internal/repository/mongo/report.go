@@ -0,0 +1,215 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReportRepository implements the ReportRepository interface using MongoDB
+type ReportRepository struct {
+	client     *Client
+	collection *mongo.Collection
+	reads      *RetryingCollection
+}
+
+// NewReportRepository creates a new report repository
+func NewReportRepository(client *Client) *ReportRepository {
+	return &ReportRepository{
+		client:     client,
+		collection: client.Collection("reports"),
+		reads:      client.ReadCollection("reports"),
+	}
+}
+
+// EnsureIndexes creates the indexes the reports collection relies on. The
+// partial unique index allows one open report per user and template while
+// keeping any number of resolved ones.
+func (r *ReportRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "template_id", Value: 1}, {Key: "reporter_id", Value: 1}},
+			Options: options.Index().
+				SetName("open_report_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": models.ReportStatusOpen}),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("status_created_at"),
+		},
+	})
+	return err
+}
+
+// Create stores a new open report
+func (r *ReportRepository) Create(ctx context.Context, report *models.TemplateReport) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	if report.ID == "" {
+		report.ID = primitive.NewObjectID().Hex()
+	}
+	report.Status = models.ReportStatusOpen
+	report.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, report)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+// GetByID retrieves a report by ID
+func (r *ReportRepository) GetByID(ctx context.Context, id string) (*models.TemplateReport, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	var report models.TemplateReport
+	err := r.reads.FindOne(ctx, bson.M{"_id": id}).Decode(&report)
+	if err == mongo.ErrNoDocuments {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ListOpen returns every open report, oldest first
+func (r *ReportRepository) ListOpen(ctx context.Context) ([]*models.TemplateReport, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.reads.Find(ctx, bson.M{"status": models.ReportStatusOpen}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reports []*models.TemplateReport
+	if err = cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// CountOpen counts the open reports on a template
+func (r *ReportRepository) CountOpen(ctx context.Context, templateID string) (int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	count, err := r.reads.CountDocuments(ctx, bson.M{"template_id": templateID, "status": models.ReportStatusOpen})
+	return int(count), err
+}
+
+// ResolveOpen closes every open report on a template, returning the reports
+// it closed
+func (r *ReportRepository) ResolveOpen(ctx context.Context, templateID, action, resolvedBy string, at time.Time) ([]*models.TemplateReport, error) {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	cursor, err := r.collection.Find(ctx, bson.M{"template_id": templateID, "status": models.ReportStatusOpen})
+	if err != nil {
+		return nil, err
+	}
+	var open []*models.TemplateReport
+	if err := cursor.All(ctx, &open); err != nil {
+		return nil, err
+	}
+
+	// Claim each report individually, so a report resolved concurrently is
+	// returned by only one caller
+	var resolved []*models.TemplateReport
+	for _, report := range open {
+		result, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": report.ID, "status": models.ReportStatusOpen},
+			bson.M{"$set": bson.M{
+				"status":      models.ReportStatusResolved,
+				"action":      action,
+				"resolved_by": resolvedBy,
+				"resolved_at": at,
+			}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+
+		resolvedAt := at
+		report.Status = models.ReportStatusResolved
+		report.Action = action
+		report.ResolvedBy = resolvedBy
+		report.ResolvedAt = &resolvedAt
+		resolved = append(resolved, report)
+	}
+	return resolved, nil
+}
+
+// AuditRepository implements the AuditRepository interface using MongoDB
+type AuditRepository struct {
+	client     *Client
+	collection *mongo.Collection
+	reads      *RetryingCollection
+}
+
+// NewAuditRepository creates a new audit log repository
+func NewAuditRepository(client *Client) *AuditRepository {
+	return &AuditRepository{
+		client:     client,
+		collection: client.Collection("audit_log"),
+		reads:      client.ReadCollection("audit_log"),
+	}
+}
+
+// Create appends an entry to the audit log
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	if entry.ID == "" {
+		entry.ID = primitive.NewObjectID().Hex()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// List pages through the audit log, newest first
+func (r *AuditRepository) List(ctx context.Context, limit, offset int) ([]*models.AuditEntry, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	opts := &options.FindOptions{
+		Sort:  bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+		Limit: int64ptr(limit),
+		Skip:  int64ptr(offset),
+	}
+
+	cursor, err := r.reads.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.AuditEntry
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
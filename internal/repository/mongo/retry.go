@@ -0,0 +1,151 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"dotfiles-api/internal/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// maxReadRetries is how many times a failed read is retried
+const maxReadRetries = 2
+
+// readRetryBackoff is the wait before the first retry; later retries wait
+// proportionally longer
+var readRetryBackoff = 100 * time.Millisecond
+
+// Server error codes returned while a replica set is changing primary
+var transientErrorCodes = []int{
+	91,    // ShutdownInProgress
+	189,   // PrimarySteppedDown
+	10107, // NotWritablePrimary ("not master")
+	11600, // InterruptedAtShutdown
+	11602, // InterruptedDueToReplStateChange
+	13435, // NotPrimaryNoSecondaryOk
+	13436, // NotPrimaryOrSecondary ("node is recovering")
+}
+
+// reader is the read side shared by *mongo.Collection and
+// RetryingCollection, so helpers can read from the primary on write paths
+type reader interface {
+	FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
+	CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error)
+}
+
+// RetryingCollection wraps a collection used for reads, retrying idempotent
+// operations that fail while the cluster recovers. It deliberately exposes
+// no write methods: writes are never retried.
+type RetryingCollection struct {
+	collection *mongo.Collection
+}
+
+// FindOne retries finding a single document. A persistent failure is
+// reported by the returned result's Decode and Err methods.
+func (rc *RetryingCollection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	var result *mongo.SingleResult
+	err := retryRead(ctx, func() error {
+		result = rc.collection.FindOne(ctx, filter, opts...)
+		return result.Err()
+	})
+
+	if errors.Is(err, repository.ErrStorageUnavailable) {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	return result
+}
+
+// Find retries starting a query. Errors while iterating the cursor are not
+// retried.
+func (rc *RetryingCollection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	var cursor *mongo.Cursor
+	err := retryRead(ctx, func() (err error) {
+		cursor, err = rc.collection.Find(ctx, filter, opts...)
+		return err
+	})
+	return cursor, err
+}
+
+// CountDocuments retries counting matching documents
+func (rc *RetryingCollection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	var count int64
+	err := retryRead(ctx, func() (err error) {
+		count, err = rc.collection.CountDocuments(ctx, filter, opts...)
+		return err
+	})
+	return count, err
+}
+
+// Aggregate retries running a read-only pipeline
+func (rc *RetryingCollection) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	var cursor *mongo.Cursor
+	err := retryRead(ctx, func() (err error) {
+		cursor, err = rc.collection.Aggregate(ctx, pipeline, opts...)
+		return err
+	})
+	return cursor, err
+}
+
+// retryRead runs op, retrying transient failures with a short backoff. When
+// the failures persist it returns an error wrapping
+// repository.ErrStorageUnavailable; other errors are returned unchanged.
+func retryRead(ctx context.Context, op func() error) error {
+	var err error
+	for attempt := 0; attempt <= maxReadRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return storageUnavailable(err)
+			case <-time.After(time.Duration(attempt) * readRetryBackoff):
+			}
+		}
+
+		err = op()
+		if !isTransient(err) {
+			return err
+		}
+	}
+
+	return storageUnavailable(err)
+}
+
+func storageUnavailable(err error) error {
+	return fmt.Errorf("%w: %v", repository.ErrStorageUnavailable, err)
+}
+
+// isTransient reports whether err is a connectivity failure that may clear
+// once the driver reconnects or a new primary is elected
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, mongo.ErrNoDocuments) {
+		return false
+	}
+
+	if mongo.IsNetworkError(err) {
+		return true
+	}
+
+	var selectionErr topology.ServerSelectionError
+	if errors.As(err, &selectionErr) {
+		return true
+	}
+
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) {
+		for _, code := range transientErrorCodes {
+			if serverErr.HasErrorCode(code) {
+				return true
+			}
+		}
+		return serverErr.HasErrorMessage("not master") || serverErr.HasErrorMessage("node is recovering")
+	}
+
+	message := err.Error()
+	return strings.Contains(message, "not master") || strings.Contains(message, "node is recovering")
+}
@@ -0,0 +1,116 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"dotfiles-api/internal/repository"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{name: "nil", err: nil, transient: false},
+		{name: "no documents", err: mongo.ErrNoDocuments, transient: false},
+		{name: "network error", err: mongo.CommandError{Labels: []string{"NetworkError"}}, transient: true},
+		{name: "not master", err: mongo.CommandError{Code: 10107, Message: "not master"}, transient: true},
+		{name: "node is recovering", err: mongo.CommandError{Code: 13436, Message: "node is recovering"}, transient: true},
+		{name: "primary stepped down", err: mongo.CommandError{Code: 189}, transient: true},
+		{name: "recovering message only", err: mongo.CommandError{Message: "node is recovering"}, transient: true},
+		{name: "server selection", err: topology.ServerSelectionError{Wrapped: errors.New("context deadline exceeded")}, transient: true},
+		{name: "wrapped network error", err: fmt.Errorf("find: %w", mongo.CommandError{Labels: []string{"NetworkError"}}), transient: true},
+		{name: "duplicate key", err: mongo.WriteException{WriteErrors: []mongo.WriteError{{Code: 11000}}}, transient: false},
+		{name: "bad query", err: mongo.CommandError{Code: 2, Message: "unknown operator: $foo"}, transient: false},
+		{name: "plain error", err: errors.New("decode failed"), transient: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransient(tt.err); got != tt.transient {
+				t.Errorf("Expected transient %v, got %v", tt.transient, got)
+			}
+		})
+	}
+}
+
+func withFastRetries(t *testing.T) {
+	t.Helper()
+	backoff := readRetryBackoff
+	readRetryBackoff = time.Millisecond
+	t.Cleanup(func() { readRetryBackoff = backoff })
+}
+
+func TestRetryReadRecovers(t *testing.T) {
+	withFastRetries(t)
+
+	calls := 0
+	err := retryRead(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return mongo.CommandError{Code: 10107, Message: "not master"}
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("Expected the third attempt to succeed, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", calls)
+	}
+}
+
+func TestRetryReadGivesUp(t *testing.T) {
+	withFastRetries(t)
+
+	calls := 0
+	err := retryRead(context.Background(), func() error {
+		calls++
+		return mongo.CommandError{Labels: []string{"NetworkError"}}
+	})
+
+	if !errors.Is(err, repository.ErrStorageUnavailable) {
+		t.Errorf("Expected ErrStorageUnavailable, got %v", err)
+	}
+	if calls != maxReadRetries+1 {
+		t.Errorf("Expected %d attempts, got %d", maxReadRetries+1, calls)
+	}
+}
+
+func TestRetryReadDoesNotRetryOtherErrors(t *testing.T) {
+	withFastRetries(t)
+
+	calls := 0
+	err := retryRead(context.Background(), func() error {
+		calls++
+		return mongo.ErrNoDocuments
+	})
+
+	if err != mongo.ErrNoDocuments || calls != 1 {
+		t.Errorf("Expected ErrNoDocuments after one attempt, got %v after %d", err, calls)
+	}
+}
+
+func TestRetryingCollectionHasNoWrites(t *testing.T) {
+	collectionType := reflect.TypeOf(&RetryingCollection{})
+
+	for i := 0; i < collectionType.NumMethod(); i++ {
+		name := collectionType.Method(i).Name
+		for _, write := range []string{"Insert", "Update", "Replace", "Delete", "BulkWrite", "FindOneAnd"} {
+			if strings.HasPrefix(name, write) {
+				t.Errorf("Expected RetryingCollection to expose reads only, found %s", name)
+			}
+		}
+	}
+}
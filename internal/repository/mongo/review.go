@@ -4,7 +4,9 @@ import (
 	"context"
 	"time"
 
+	"dotfiles-api/internal/language"
 	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -13,18 +15,25 @@ import (
 
 // ReviewRepository implements the ReviewRepository interface using MongoDB
 type ReviewRepository struct {
+	client     *Client
 	collection *mongo.Collection
+	reads      *RetryingCollection
 }
 
 // NewReviewRepository creates a new review repository
 func NewReviewRepository(client *Client) *ReviewRepository {
 	return &ReviewRepository{
+		client:     client,
 		collection: client.Collection("reviews"),
+		reads:      client.ReadCollection("reviews"),
 	}
 }
 
 // Create stores a new review
 func (r *ReviewRepository) Create(ctx context.Context, review *models.Review) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	if review.ID == "" {
 		review.ID = primitive.NewObjectID().Hex()
 	}
@@ -32,13 +41,33 @@ func (r *ReviewRepository) Create(ctx context.Context, review *models.Review) er
 	review.UpdatedAt = time.Now()
 
 	_, err := r.collection.InsertOne(ctx, review)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+// EnsureIndexes creates the indexes the review collection relies on. The
+// unique user_id and template_id index stops concurrent submits from
+// reviewing the same template twice.
+func (r *ReviewRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "template_id", Value: 1}},
+		Options: options.Index().SetName("user_template_unique").SetUnique(true),
+	})
 	return err
 }
 
 // GetByID retrieves a review by ID
 func (r *ReviewRepository) GetByID(ctx context.Context, id string) (*models.Review, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	var review models.Review
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&review)
+	err := r.reads.FindOne(ctx, bson.M{"_id": id}).Decode(&review)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -50,6 +79,9 @@ func (r *ReviewRepository) GetByID(ctx context.Context, id string) (*models.Revi
 
 // Update updates an existing review
 func (r *ReviewRepository) Update(ctx context.Context, review *models.Review) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	review.UpdatedAt = time.Now()
 	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": review.ID}, review)
 	return err
@@ -57,19 +89,40 @@ func (r *ReviewRepository) Update(ctx context.Context, review *models.Review) er
 
 // Delete removes a review
 func (r *ReviewRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
 
+// newestReviewsFirst orders reviews newest first, breaking ties by ID so
+// pages stay stable
+var newestReviewsFirst = bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+
 // GetByTemplate retrieves reviews for a template
-func (r *ReviewRepository) GetByTemplate(ctx context.Context, templateID string, limit, offset int) ([]*models.Review, error) {
+func (r *ReviewRepository) GetByTemplate(ctx context.Context, templateID string, languages []string, limit, offset int) ([]*models.Review, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	opts := &options.FindOptions{
-		Sort:  bson.D{{Key: "created_at", Value: -1}},
+		Sort:  newestReviewsFirst,
 		Limit: int64ptr(limit),
 		Skip:  int64ptr(offset),
 	}
 
-	cursor, err := r.collection.Find(ctx, bson.M{"template_id": templateID}, opts)
+	filter := bson.M{"template_id": templateID}
+	if len(languages) > 0 {
+		// Reviews stored before detection have no language and, like
+		// undetermined ones, are always included
+		codes := bson.A{language.Undetermined, "", nil}
+		for _, code := range languages {
+			codes = append(codes, code)
+		}
+		filter["language"] = bson.M{"$in": codes}
+	}
+
+	cursor, err := r.reads.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -84,13 +137,52 @@ func (r *ReviewRepository) GetByTemplate(ctx context.Context, templateID string,
 
 // GetByUser retrieves reviews by a user
 func (r *ReviewRepository) GetByUser(ctx context.Context, userID string, limit, offset int) ([]*models.Review, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	opts := &options.FindOptions{
+		Sort:  newestReviewsFirst,
+		Limit: int64ptr(limit),
+		Skip:  int64ptr(offset),
+	}
+
+	cursor, err := r.reads.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var reviews []*models.Review
+	if err = cursor.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// CountByUser counts the reviews the user has written
+func (r *ReviewRepository) CountByUser(ctx context.Context, userID string) (int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	count, err := r.reads.CountDocuments(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// List pages through every review, ordered by ID
+func (r *ReviewRepository) List(ctx context.Context, limit, offset int) ([]*models.Review, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	opts := &options.FindOptions{
-		Sort:  bson.D{{Key: "created_at", Value: -1}},
+		Sort:  bson.D{{Key: "_id", Value: 1}},
 		Limit: int64ptr(limit),
 		Skip:  int64ptr(offset),
 	}
 
-	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	cursor, err := r.reads.Find(ctx, bson.M{}, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -105,8 +197,11 @@ func (r *ReviewRepository) GetByUser(ctx context.Context, userID string, limit,
 
 // GetUserReviewForTemplate retrieves a user's review for a specific template
 func (r *ReviewRepository) GetUserReviewForTemplate(ctx context.Context, userID, templateID string) (*models.Review, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	var review models.Review
-	err := r.collection.FindOne(ctx, bson.M{
+	err := r.reads.FindOne(ctx, bson.M{
 		"user_id":     userID,
 		"template_id": templateID,
 	}).Decode(&review)
@@ -121,6 +216,9 @@ func (r *ReviewRepository) GetUserReviewForTemplate(ctx context.Context, userID,
 
 // IncrementHelpful increments the helpful count for a review
 func (r *ReviewRepository) IncrementHelpful(ctx context.Context, id string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": id},
@@ -131,6 +229,9 @@ func (r *ReviewRepository) IncrementHelpful(ctx context.Context, id string) erro
 
 // CalculateTemplateRating calculates the rating information for a template
 func (r *ReviewRepository) CalculateTemplateRating(ctx context.Context, templateID string) (*models.TemplateRating, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	// Aggregate pipeline to calculate rating statistics
 	pipeline := []bson.M{
 		{"$match": bson.M{"template_id": templateID}},
@@ -139,19 +240,21 @@ func (r *ReviewRepository) CalculateTemplateRating(ctx context.Context, template
 			"avg_rating": bson.M{"$avg": "$rating"},
 			"total_ratings": bson.M{"$sum": 1},
 			"ratings": bson.M{"$push": "$rating"},
+			"languages": bson.M{"$push": bson.M{"$ifNull": bson.A{"$language", language.Undetermined}}},
 		}},
 	}
 
-	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	cursor, err := r.reads.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
 	var result struct {
-		AvgRating    float64 `bson:"avg_rating"`
-		TotalRatings int     `bson:"total_ratings"`
-		Ratings      []int   `bson:"ratings"`
+		AvgRating    float64  `bson:"avg_rating"`
+		TotalRatings int      `bson:"total_ratings"`
+		Ratings      []int    `bson:"ratings"`
+		Languages    []string `bson:"languages"`
 	}
 
 	if !cursor.Next(ctx) {
@@ -161,6 +264,7 @@ func (r *ReviewRepository) CalculateTemplateRating(ctx context.Context, template
 			AverageRating:  0.0,
 			TotalRatings:   0,
 			Distribution:   make(map[string]int),
+			Languages:      make(map[string]int),
 		}, nil
 	}
 
@@ -175,10 +279,19 @@ func (r *ReviewRepository) CalculateTemplateRating(ctx context.Context, template
 		distribution[key]++
 	}
 
+	languages := make(map[string]int)
+	for _, code := range result.Languages {
+		if code == "" {
+			code = language.Undetermined
+		}
+		languages[code]++
+	}
+
 	return &models.TemplateRating{
 		TemplateID:     templateID,
 		AverageRating:  result.AvgRating,
 		TotalRatings:   result.TotalRatings,
 		Distribution:   distribution,
+		Languages:      languages,
 	}, nil
 }
\ No newline at end of file
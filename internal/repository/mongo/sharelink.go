@@ -0,0 +1,166 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// expiredShareLinkRetention is how long expired share links are kept, so
+// their tokens answer "expired" rather than "not found" for a while
+const expiredShareLinkRetention = 7 * 24 * time.Hour
+
+// ShareLinkRepository implements the ShareLinkRepository interface using
+// MongoDB
+type ShareLinkRepository struct {
+	client     *Client
+	collection *mongo.Collection
+	reads      *RetryingCollection
+}
+
+// NewShareLinkRepository creates a new share link repository
+func NewShareLinkRepository(client *Client) *ShareLinkRepository {
+	return &ShareLinkRepository{
+		client:     client,
+		collection: client.Collection("template_share_links"),
+		reads:      client.ReadCollection("template_share_links"),
+	}
+}
+
+// EnsureIndexes creates the indexes the template_share_links collection
+// relies on, including the TTL index that removes expired links
+func (r *ShareLinkRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "token_hash", Value: 1}},
+			Options: options.Index().SetName("token_hash_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "template_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("template_created_at"),
+		},
+		{
+			Keys: bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().
+				SetName("expires_at_ttl").
+				SetExpireAfterSeconds(int32(expiredShareLinkRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+// Create stores a new share link
+func (r *ShareLinkRepository) Create(ctx context.Context, link *models.TemplateShareLink) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	if link.ID == "" {
+		link.ID = primitive.NewObjectID().Hex()
+	}
+	if link.CreatedAt.IsZero() {
+		link.CreatedAt = time.Now()
+	}
+	link.TokenHash = models.HashShareLinkToken(link.Token)
+
+	_, err := r.collection.InsertOne(ctx, link)
+	return err
+}
+
+// GetByToken retrieves a share link by its token
+func (r *ShareLinkRepository) GetByToken(ctx context.Context, token string) (*models.TemplateShareLink, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	var link models.TemplateShareLink
+	err := r.reads.FindOne(ctx, bson.M{"token_hash": models.HashShareLinkToken(token)}).Decode(&link)
+	if err == mongo.ErrNoDocuments {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// Use counts one use of a share link, in the same update that checks it is
+// still usable, so concurrent requests cannot exceed MaxUses
+func (r *ShareLinkRepository) Use(ctx context.Context, token string, now time.Time) (*models.TemplateShareLink, error) {
+	writeCtx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	filter := usableShareLinks(bson.M{"token_hash": models.HashShareLinkToken(token)}, now)
+
+	var link models.TemplateShareLink
+	err := r.collection.FindOneAndUpdate(
+		writeCtx,
+		filter,
+		bson.M{"$inc": bson.M{"uses": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&link)
+	if err == mongo.ErrNoDocuments {
+		// Tell a link that is no longer usable from one that never existed
+		if _, err := r.GetByToken(ctx, token); err != nil {
+			return nil, err
+		}
+		return nil, repository.ErrShareLinkUnusable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// ListUsable retrieves a template's usable share links, newest first
+func (r *ShareLinkRepository) ListUsable(ctx context.Context, templateID string, now time.Time) ([]*models.TemplateShareLink, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	filter := usableShareLinks(bson.M{"template_id": templateID}, now)
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}})
+	cursor, err := r.reads.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var links []*models.TemplateShareLink
+	if err = cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// usableShareLinks narrows filter to links unexpired at now with uses left,
+// matching TemplateShareLink.Usable
+func usableShareLinks(filter bson.M, now time.Time) bson.M {
+	filter["expires_at"] = bson.M{"$gt": now}
+	filter["$or"] = bson.A{
+		bson.M{"max_uses": 0},
+		bson.M{"$expr": bson.M{"$lt": bson.A{"$uses", "$max_uses"}}},
+	}
+	return filter
+}
+
+// Delete revokes one of a template's share links
+func (r *ShareLinkRepository) Delete(ctx context.Context, templateID, id string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "template_id": templateID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
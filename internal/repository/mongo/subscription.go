@@ -0,0 +1,132 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SubscriptionRepository implements the SubscriptionRepository interface
+// using MongoDB. Subscriptions are keyed by user ID.
+type SubscriptionRepository struct {
+	client     *Client
+	collection *mongo.Collection
+	reads      *RetryingCollection
+}
+
+// NewSubscriptionRepository creates a new subscription repository
+func NewSubscriptionRepository(client *Client) *SubscriptionRepository {
+	return &SubscriptionRepository{
+		client:     client,
+		collection: client.Collection("tag_subscriptions"),
+		reads:      client.ReadCollection("tag_subscriptions"),
+	}
+}
+
+// GetByUser retrieves a user's tag subscription
+func (r *SubscriptionRepository) GetByUser(ctx context.Context, userID string) (*models.TagSubscription, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	var subscription models.TagSubscription
+	err := r.reads.FindOne(ctx, bson.M{"_id": userID}).Decode(&subscription)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// AddTags follows tags, creating the subscription on first use
+func (r *SubscriptionRepository) AddTags(ctx context.Context, userID string, tags []string) (*models.TagSubscription, error) {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	now := time.Now()
+	var subscription models.TagSubscription
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{
+			"$addToSet":    bson.M{"tags": bson.M{"$each": tags}},
+			"$setOnInsert": bson.M{"created_at": now, "last_notified_at": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&subscription)
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// RemoveTags unfollows tags, deleting the subscription once it is empty
+func (r *SubscriptionRepository) RemoveTags(ctx context.Context, userID string, tags []string) (*models.TagSubscription, error) {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	var subscription models.TagSubscription
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$pullAll": bson.M{"tags": tags}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&subscription)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(subscription.Tags) > 0 {
+		return &subscription, nil
+	}
+
+	// Only delete while still empty, in case tags were added meanwhile
+	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": userID, "tags": bson.M{"$size": 0}})
+	return nil, err
+}
+
+// List retrieves every tag subscription
+func (r *SubscriptionRepository) List(ctx context.Context) ([]*models.TagSubscription, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	cursor, err := r.reads.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var subscriptions []*models.TagSubscription
+	if err = cursor.All(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// MarkNotified records when the user was last sent a digest
+func (r *SubscriptionRepository) MarkNotified(ctx context.Context, userID string, at time.Time) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"last_notified_at": at}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
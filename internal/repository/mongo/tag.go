@@ -0,0 +1,74 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TagRepository implements the TagRepository interface using MongoDB. Each
+// document holds the synonyms of one canonical tag.
+type TagRepository struct {
+	client     *Client
+	collection *mongo.Collection
+	reads      *RetryingCollection
+}
+
+// tagSynonymsDocument is the stored form of one canonical tag's synonyms
+type tagSynonymsDocument struct {
+	Canonical string   `bson:"_id"`
+	Synonyms  []string `bson:"synonyms"`
+}
+
+// NewTagRepository creates a new tag repository
+func NewTagRepository(client *Client) *TagRepository {
+	return &TagRepository{
+		client:     client,
+		collection: client.Collection("tag_synonyms"),
+		reads:      client.ReadCollection("tag_synonyms"),
+	}
+}
+
+// GetSynonyms returns the stored synonym table keyed by canonical tag
+func (r *TagRepository) GetSynonyms(ctx context.Context) (map[string][]string, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	cursor, err := r.reads.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var documents []tagSynonymsDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+
+	table := make(map[string][]string, len(documents))
+	for _, document := range documents {
+		table[document.Canonical] = document.Synonyms
+	}
+	return table, nil
+}
+
+// SetSynonyms stores the synonyms of a canonical tag, replacing any stored
+// before. An empty list is kept so it can override a built-in entry.
+func (r *TagRepository) SetSynonyms(ctx context.Context, canonical string, synonyms []string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	if synonyms == nil {
+		synonyms = []string{}
+	}
+
+	_, err := r.collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": canonical},
+		tagSynonymsDocument{Canonical: canonical, Synonyms: synonyms},
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
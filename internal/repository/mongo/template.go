@@ -2,10 +2,14 @@ package mongo
 
 import (
 	"context"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 
 	"dotfiles-api/internal/models"
 	"dotfiles-api/internal/repository"
+	"dotfiles-api/internal/tags"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -14,13 +18,17 @@ import (
 
 // TemplateRepository implements the TemplateRepository interface using MongoDB
 type TemplateRepository struct {
+	client     *Client
 	collection *mongo.Collection
+	reads      *RetryingCollection
 }
 
 // NewTemplateRepository creates a new template repository
 func NewTemplateRepository(client *Client) *TemplateRepository {
 	repo := &TemplateRepository{
+		client:     client,
 		collection: client.Collection("templates"),
+		reads:      client.ReadCollection("templates"),
 	}
 
 	// Seed with default template if collection is empty
@@ -116,17 +124,23 @@ func (r *TemplateRepository) seedDefaultTemplate() {
 		UpdatedAt: now,
 	}
 
+	defaultTemplate.SchemaVersion = defaultTemplate.Template.SchemaVersion()
+
 	// Insert the default template
 	_, _ = r.collection.InsertOne(ctx, defaultTemplate)
 }
 
 // Create stores a new template
 func (r *TemplateRepository) Create(ctx context.Context, template *models.StoredTemplate) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	if template.ID == "" {
 		template.ID = primitive.NewObjectID().Hex()
 	}
 	template.CreatedAt = time.Now()
 	template.UpdatedAt = time.Now()
+	template.SchemaVersion = template.Template.SchemaVersion()
 
 	_, err := r.collection.InsertOne(ctx, template)
 	return err
@@ -134,8 +148,11 @@ func (r *TemplateRepository) Create(ctx context.Context, template *models.Stored
 
 // GetByID retrieves a template by ID
 func (r *TemplateRepository) GetByID(ctx context.Context, id string) (*models.StoredTemplate, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	var template models.StoredTemplate
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&template)
+	err := r.reads.FindOne(ctx, bson.M{"_id": id}).Decode(&template)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -147,22 +164,122 @@ func (r *TemplateRepository) GetByID(ctx context.Context, id string) (*models.St
 
 // Update updates an existing template
 func (r *TemplateRepository) Update(ctx context.Context, template *models.StoredTemplate) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	template.UpdatedAt = time.Now()
+	template.SchemaVersion = template.Template.SchemaVersion()
 	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": template.ID}, template)
 	return err
 }
 
 // Delete removes a template
 func (r *TemplateRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
 
 // List retrieves templates with filters
 func (r *TemplateRepository) List(ctx context.Context, filters repository.TemplateFilters) ([]*models.StoredTemplate, error) {
-	filter := bson.M{}
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	filter := templateFilter(filters)
+	if filters.Random {
+		return r.sample(ctx, filter, filters)
+	}
+
+	opts := &options.FindOptions{
+		Sort:  templateSort(filters.TemplateSort()),
+		Limit: int64ptr(filters.Limit),
+		Skip:  int64ptr(filters.Offset),
+	}
+	if projection := templateProjection(filters.Fields); projection != nil {
+		opts.Projection = projection
+	}
+
+	cursor, err := r.reads.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var templates []*models.StoredTemplate
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// sample picks up to filters.Limit of the templates matching filter at
+// random with $sample
+func (r *TemplateRepository) sample(ctx context.Context, filter bson.M, filters repository.TemplateFilters) ([]*models.StoredTemplate, error) {
+	pipeline := []bson.M{
+		{"$match": filter},
+		{"$sample": bson.M{"size": filters.Limit}},
+	}
+	if projection := templateProjection(filters.Fields); projection != nil {
+		pipeline = append(pipeline, bson.M{"$project": projection})
+	}
+
+	cursor, err := r.reads.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var templates []*models.StoredTemplate
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
 
-	// Apply filters
+// Count counts the templates matching filters
+func (r *TemplateRepository) Count(ctx context.Context, filters repository.TemplateFilters) (int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	count, err := r.reads.CountDocuments(ctx, templateFilter(filters))
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// Iterate streams the templates matching filters from a cursor. It is not
+// bounded by the read timeout, since exports can take longer; cancel ctx to
+// stop it.
+func (r *TemplateRepository) Iterate(ctx context.Context, filters repository.TemplateFilters, fn func(*models.StoredTemplate) error) error {
+	opts := options.Find().SetSort(templateSort(filters.TemplateSort()))
+	if projection := templateProjection(filters.Fields); projection != nil {
+		opts.SetProjection(projection)
+	}
+
+	cursor, err := r.reads.Find(ctx, templateFilter(filters), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var template models.StoredTemplate
+		if err := cursor.Decode(&template); err != nil {
+			return err
+		}
+		if err := fn(&template); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// templateFilter builds the query document shared by List and Count
+func templateFilter(filters repository.TemplateFilters) bson.M {
+	filter := bson.M{}
 	if filters.Author != "" {
 		filter["template.metadata.author"] = filters.Author
 	}
@@ -175,52 +292,170 @@ func (r *TemplateRepository) List(ctx context.Context, filters repository.Templa
 	if filters.Public != nil {
 		filter["template.public"] = *filters.Public
 	}
+	if !filters.IncludeUnlisted {
+		filter["unlisted"] = bson.M{"$ne": true}
+	}
+	applyViewer(filter, filters.Viewer)
 	if len(filters.Tags) > 0 {
-		filter["template.metadata.tags"] = bson.M{"$in": filters.Tags}
+		filter["$and"] = allTagsFilter("template.metadata.tags", filters.Tags)
+	}
+	applyDateRange(filter, filters.DateRange)
+	return filter
+}
+
+// applyViewer limits filter to the templates viewer may see, as
+// TemplateViewer.CanView does. A nil viewer sees everything.
+func applyViewer(filter bson.M, viewer *repository.TemplateViewer) {
+	if viewer == nil {
+		return
 	}
 
-	// Sort options
-	sortBy := "created_at"
-	if filters.SortBy != "" {
-		sortBy = filters.SortBy
+	visible := bson.A{bson.M{"template.public": true}}
+	if viewer.Username != "" {
+		visible = append(visible,
+			bson.M{"template.metadata.author": viewer.Username},
+			bson.M{"allowed_users": viewer.Username},
+		)
 	}
-	sortOrder := -1 // desc
-	if filters.SortOrder == "asc" {
-		sortOrder = 1
+	if len(viewer.OrganizationIDs) > 0 {
+		visible = append(visible, bson.M{"template.organization_id": bson.M{"$in": viewer.OrganizationIDs}})
 	}
+	filter["$or"] = visible
+}
 
-	opts := &options.FindOptions{
-		Sort:  bson.D{{Key: sortBy, Value: sortOrder}},
-		Limit: int64ptr(filters.Limit),
-		Skip:  int64ptr(filters.Offset),
+// templateProjection turns template JSON paths into a projection on the
+// stored document, so excluded package lists are never fetched. It returns
+// nil, loading whole documents, when no path has a stored counterpart.
+func templateProjection(fields []string) bson.M {
+	projection := bson.M{}
+	for _, field := range fields {
+		if path, ok := storedTemplatePath(field); ok {
+			projection[path] = 1
+		}
 	}
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
-	if err != nil {
-		return nil, err
+	if len(projection) == 0 {
+		return nil
 	}
-	defer cursor.Close(ctx)
+	return projection
+}
 
-	var templates []*models.StoredTemplate
-	if err = cursor.All(ctx, &templates); err != nil {
-		return nil, err
+// storedTemplatePath maps a template JSON path to its BSON path. Top-level
+// names are looked up on StoredTemplate and then on the embedded Template
+// document, mirroring how responses flatten the two.
+func storedTemplatePath(field string) (string, bool) {
+	segments := strings.Split(field, ".")
+	stored := reflect.TypeOf(models.StoredTemplate{})
+
+	if path, ok := bsonPath(stored, segments); ok {
+		return path, true
 	}
-	return templates, nil
+
+	inner, _ := stored.FieldByName("Template")
+	if path, ok := bsonPath(inner.Type, segments); ok {
+		return bsonName(inner) + "." + path, true
+	}
+	return "", false
 }
 
-// Search searches templates by query
-func (r *TemplateRepository) Search(ctx context.Context, query string, limit, offset int) ([]*models.StoredTemplate, error) {
-	filter := bson.M{
-		"$text": bson.M{"$search": query},
+// bsonPath follows JSON field names through nested structs and returns the
+// matching BSON path
+func bsonPath(t reflect.Type, segments []string) (string, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return "", false
 	}
 
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name != segments[0] {
+			continue
+		}
+
+		if len(segments) == 1 {
+			return bsonName(field), true
+		}
+		if rest, ok := bsonPath(field.Type, segments[1:]); ok {
+			return bsonName(field) + "." + rest, true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// bsonName is the key the driver stores a field under: its bson tag, or
+// the lowercased field name when untagged
+func bsonName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("bson"), ","); name != "" {
+		return name
+	}
+	return strings.ToLower(field.Name)
+}
+
+// templateSortFields maps sort keys that differ from the stored field
+// name
+var templateSortFields = map[string]string{
+	"popularity": "popularity_score",
+	"featured":   "template.featured",
+}
+
+// templateSort builds a sort document applying keys in order
+func templateSort(keys []repository.SortKey) bson.D {
+	sort := make(bson.D, 0, len(keys))
+	for _, key := range keys {
+		field := key.Field
+		if stored, ok := templateSortFields[field]; ok {
+			field = stored
+		}
+		order := 1
+		if key.Desc {
+			order = -1
+		}
+		sort = append(sort, bson.E{Key: field, Value: order})
+	}
+	return sort
+}
+
+// tagPatterns matches stored tags in any casing or separator style against
+// the requested tag forms
+func tagPatterns(variants []string) []primitive.Regex {
+	patterns := make([]primitive.Regex, 0, len(variants))
+	for _, variant := range variants {
+		patterns = append(patterns, primitive.Regex{Pattern: tags.Pattern(variant), Options: "i"})
+	}
+	return patterns
+}
+
+// allTagsFilter matches documents whose tags at field include a form of
+// every requested tag
+func allTagsFilter(field string, requested [][]string) bson.A {
+	clauses := make(bson.A, 0, len(requested))
+	for _, variants := range requested {
+		clauses = append(clauses, bson.M{field: bson.M{"$in": tagPatterns(variants)}})
+	}
+	return clauses
+}
+
+// Search searches templates by query
+func (r *TemplateRepository) Search(ctx context.Context, query string, viewer *repository.TemplateViewer, limit, offset int, fields []string) ([]*models.StoredTemplate, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	filter := searchFilter(query, viewer)
+
 	opts := &options.FindOptions{
 		Sort:  bson.D{{Key: "score", Value: bson.M{"$meta": "textScore"}}},
 		Limit: int64ptr(limit),
 		Skip:  int64ptr(offset),
 	}
+	if projection := templateProjection(fields); projection != nil {
+		opts.Projection = projection
+	}
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	cursor, err := r.reads.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -233,8 +468,34 @@ func (r *TemplateRepository) Search(ctx context.Context, query string, limit, of
 	return templates, nil
 }
 
+// CountSearch counts the templates Search matches
+func (r *TemplateRepository) CountSearch(ctx context.Context, query string, viewer *repository.TemplateViewer) (int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	count, err := r.reads.CountDocuments(ctx, searchFilter(query, viewer))
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// searchFilter matches the listed templates viewer may see whose text
+// index matches query
+func searchFilter(query string, viewer *repository.TemplateViewer) bson.M {
+	filter := bson.M{
+		"$text":    bson.M{"$search": query},
+		"unlisted": bson.M{"$ne": true},
+	}
+	applyViewer(filter, viewer)
+	return filter
+}
+
 // GetByAuthor retrieves templates by author
 func (r *TemplateRepository) GetByAuthor(ctx context.Context, authorID string, limit, offset int) ([]*models.StoredTemplate, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	filter := bson.M{"template.metadata.author": authorID}
 
 	opts := &options.FindOptions{
@@ -243,7 +504,7 @@ func (r *TemplateRepository) GetByAuthor(ctx context.Context, authorID string, l
 		Skip:  int64ptr(offset),
 	}
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	cursor, err := r.reads.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -258,6 +519,9 @@ func (r *TemplateRepository) GetByAuthor(ctx context.Context, authorID string, l
 
 // GetByOrganization retrieves templates by organization
 func (r *TemplateRepository) GetByOrganization(ctx context.Context, orgID string, limit, offset int) ([]*models.StoredTemplate, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	filter := bson.M{"template.organization_id": orgID}
 
 	opts := &options.FindOptions{
@@ -266,7 +530,7 @@ func (r *TemplateRepository) GetByOrganization(ctx context.Context, orgID string
 		Skip:  int64ptr(offset),
 	}
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	cursor, err := r.reads.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -279,16 +543,27 @@ func (r *TemplateRepository) GetByOrganization(ctx context.Context, orgID string
 	return templates, nil
 }
 
+// CountByOrganization counts an organization's templates
+func (r *TemplateRepository) CountByOrganization(ctx context.Context, orgID string) (int64, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	return r.reads.CountDocuments(ctx, bson.M{"template.organization_id": orgID})
+}
+
 // GetFeatured retrieves featured templates
 func (r *TemplateRepository) GetFeatured(ctx context.Context, limit int) ([]*models.StoredTemplate, error) {
-	filter := bson.M{"template.featured": true, "template.public": true}
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	filter := bson.M{"template.featured": true, "template.public": true, "unlisted": bson.M{"$ne": true}}
 
 	opts := &options.FindOptions{
 		Sort:  bson.D{{Key: "downloads", Value: -1}},
 		Limit: int64ptr(limit),
 	}
 
-	cursor, err := r.collection.Find(ctx, filter, opts)
+	cursor, err := r.reads.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -303,6 +578,9 @@ func (r *TemplateRepository) GetFeatured(ctx context.Context, limit int) ([]*mod
 
 // IncrementDownloads increments the download count for a template
 func (r *TemplateRepository) IncrementDownloads(ctx context.Context, id string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": id},
@@ -311,14 +589,53 @@ func (r *TemplateRepository) IncrementDownloads(ctx context.Context, id string)
 	return err
 }
 
+// SetPopularityScore stores a template's precomputed popularity score
+func (r *TemplateRepository) SetPopularityScore(ctx context.Context, id string, score float64) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"popularity_score": score}},
+	)
+	return err
+}
+
+// SetUnlisted hides a template from listings, search and featured
+// templates for the given reason, or lists it again when reason is empty
+func (r *TemplateRepository) SetUnlisted(ctx context.Context, id string, reason string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"unlisted": true, "unlisted_reason": reason}}
+	if reason == "" {
+		update = bson.M{
+			"$set":   bson.M{"unlisted": false},
+			"$unset": bson.M{"unlisted_reason": ""},
+		}
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
 // GetStats returns template statistics
 func (r *TemplateRepository) GetStats(ctx context.Context) (*models.TemplateStats, error) {
-	total, err := r.collection.CountDocuments(ctx, bson.M{})
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	total, err := r.reads.CountDocuments(ctx, bson.M{})
 	if err != nil {
 		return nil, err
 	}
 
-	featured, err := r.collection.CountDocuments(ctx, bson.M{"template.featured": true})
+	featured, err := r.reads.CountDocuments(ctx, bson.M{"template.featured": true})
 	if err != nil {
 		return nil, err
 	}
@@ -331,7 +648,7 @@ func (r *TemplateRepository) GetStats(ctx context.Context) (*models.TemplateStat
 		}},
 	}
 
-	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	cursor, err := r.reads.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -353,7 +670,7 @@ func (r *TemplateRepository) GetStats(ctx context.Context) (*models.TemplateStat
 		{"$count": "categories"},
 	}
 
-	cursor, err = r.collection.Aggregate(ctx, pipeline)
+	cursor, err = r.reads.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
@@ -378,6 +695,9 @@ func (r *TemplateRepository) GetStats(ctx context.Context) (*models.TemplateStat
 
 // GetRating returns template rating information
 func (r *TemplateRepository) GetRating(ctx context.Context, templateID string) (*models.TemplateRating, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	// This would typically come from a reviews collection
 	// For now, return a placeholder
 	return &models.TemplateRating{
@@ -385,5 +705,71 @@ func (r *TemplateRepository) GetRating(ctx context.Context, templateID string) (
 		AverageRating:  0.0,
 		TotalRatings:   0,
 		Distribution:   make(map[string]int),
+		Languages:      make(map[string]int),
 	}, nil
-}
\ No newline at end of file
+}
+
+// CountTags counts public templates per canonical tag
+func (r *TemplateRepository) CountTags(ctx context.Context, canonicalize func([]string) []string) (map[string]int, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{"template.metadata.tags": 1})
+	cursor, err := r.reads.Find(ctx, bson.M{"template.public": true}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[string]int)
+	for cursor.Next(ctx) {
+		var template models.StoredTemplate
+		if err := cursor.Decode(&template); err != nil {
+			return nil, err
+		}
+		for _, tag := range canonicalize(template.Template.Metadata.Tags) {
+			counts[tag]++
+		}
+	}
+	return counts, cursor.Err()
+}
+
+// RewriteTags stores the canonical form of every template's tags
+func (r *TemplateRepository) RewriteTags(ctx context.Context, canonicalize func([]string) []string) (int, error) {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	opts := options.Find().SetProjection(bson.M{"template.metadata.tags": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"template.metadata.tags.0": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	changed := 0
+	for cursor.Next(ctx) {
+		var template models.StoredTemplate
+		if err := cursor.Decode(&template); err != nil {
+			return changed, err
+		}
+
+		rewritten := canonicalize(template.Template.Metadata.Tags)
+		if slices.Equal(rewritten, template.Template.Metadata.Tags) {
+			continue
+		}
+
+		_, err := r.collection.UpdateOne(
+			ctx,
+			bson.M{"_id": template.ID},
+			bson.M{"$set": bson.M{
+				"template.metadata.tags": rewritten,
+				"updated_at":             time.Now(),
+			}},
+		)
+		if err != nil {
+			return changed, err
+		}
+		changed++
+	}
+	return changed, cursor.Err()
+}
@@ -0,0 +1,95 @@
+package mongo
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestTemplateFilterAppliesViewer(t *testing.T) {
+	filter := templateFilter(repository.TemplateFilters{Viewer: &repository.TemplateViewer{}})
+	want := bson.A{bson.M{"template.public": true}}
+	if !reflect.DeepEqual(filter["$or"], want) {
+		t.Errorf("Expected anonymous callers to be limited to public templates, got %v", filter["$or"])
+	}
+
+	filter = templateFilter(repository.TemplateFilters{
+		Viewer: &repository.TemplateViewer{Username: "alice", OrganizationIDs: []string{"acme"}},
+	})
+	want = bson.A{
+		bson.M{"template.public": true},
+		bson.M{"template.metadata.author": "alice"},
+		bson.M{"allowed_users": "alice"},
+		bson.M{"template.organization_id": bson.M{"$in": []string{"acme"}}},
+	}
+	if !reflect.DeepEqual(filter["$or"], want) {
+		t.Errorf("Expected %v, got %v", want, filter["$or"])
+	}
+
+	if filter := templateFilter(repository.TemplateFilters{}); filter["$or"] != nil {
+		t.Errorf("Expected no visibility rule without a viewer, got %v", filter["$or"])
+	}
+}
+
+func TestTemplateListAndSearchHidePrivateTemplates(t *testing.T) {
+	client := newIntegrationClient(t)
+	ctx := context.Background()
+	repo := NewTemplateRepository(client)
+
+	_, err := client.Collection("templates").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "template.metadata.name", Value: "text"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create text index: %v", err)
+	}
+
+	for _, template := range []*models.StoredTemplate{
+		{ID: "open", Template: models.Template{Public: true, Metadata: models.ShareMetadata{Name: "Visibility open", Author: "alice"}}},
+		{ID: "mine", Template: models.Template{Metadata: models.ShareMetadata{Name: "Visibility mine", Author: "alice"}}},
+		{ID: "shared", AllowedUsers: []string{"bob"}, Template: models.Template{Metadata: models.ShareMetadata{Name: "Visibility shared", Author: "alice"}}},
+	} {
+		if err := repo.Create(ctx, template); err != nil {
+			t.Fatalf("Failed to create template: %v", err)
+		}
+	}
+
+	ids := func(templates []*models.StoredTemplate) []string {
+		var got []string
+		for _, template := range templates {
+			got = append(got, template.ID)
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	anonymous := &repository.TemplateViewer{}
+	listed, err := repo.List(ctx, repository.TemplateFilters{Author: "alice", Viewer: anonymous, Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to list templates: %v", err)
+	}
+	if got := ids(listed); !reflect.DeepEqual(got, []string{"open"}) {
+		t.Errorf("Expected an anonymous list to hold only the public template, got %v", got)
+	}
+
+	found, err := repo.Search(ctx, "visibility", anonymous, 10, 0, nil)
+	if err != nil {
+		t.Fatalf("Failed to search templates: %v", err)
+	}
+	if got := ids(found); !reflect.DeepEqual(got, []string{"open"}) {
+		t.Errorf("Expected an anonymous search to find only the public template, got %v", got)
+	}
+
+	found, err = repo.Search(ctx, "visibility", &repository.TemplateViewer{Username: "bob"}, 10, 0, nil)
+	if err != nil {
+		t.Fatalf("Failed to search templates: %v", err)
+	}
+	if got := ids(found); !reflect.DeepEqual(got, []string{"open", "shared"}) {
+		t.Errorf("Expected bob to also find the template shared with them, got %v", got)
+	}
+}
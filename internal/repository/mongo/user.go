@@ -2,9 +2,11 @@ package mongo
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"dotfiles-api/internal/models"
+	"dotfiles-api/internal/repository"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -13,32 +15,101 @@ import (
 
 // UserRepository implements the UserRepository interface using MongoDB
 type UserRepository struct {
+	client     *Client
 	collection *mongo.Collection
+	reads      *RetryingCollection
+
+	// follows holds one document per follow, in the following collection
+	follows     *mongo.Collection
+	followReads *RetryingCollection
 }
 
 // NewUserRepository creates a new user repository
 func NewUserRepository(client *Client) *UserRepository {
 	return &UserRepository{
+		client:     client,
 		collection: client.Collection("users"),
+		reads:      client.ReadCollection("users"),
+
+		follows:     client.Collection("following"),
+		followReads: client.ReadCollection("following"),
 	}
 }
 
 // Create stores a new user
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	if user.ID == "" {
 		user.ID = primitive.NewObjectID().Hex()
 	}
+	user.UsernameLower = strings.ToLower(user.Username)
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
 	_, err := r.collection.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+// EnsureIndexes creates the indexes the user collection relies on. The
+// unique github_id and identities indexes stop two concurrent OAuth
+// callbacks from creating the same user twice. The following collection
+// gets a unique index per follower and followee, and indexes for listing
+// either side most recent first.
+func (r *UserRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "github_id", Value: 1}},
+			Options: options.Index().
+				SetName("github_id_unique").
+				SetUnique(true).
+				// Users not linked to GitHub store 0 and must not collide
+				SetPartialFilterExpression(bson.M{"github_id": bson.M{"$gt": 0}}),
+		},
+		{
+			Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.external_id", Value: 1}},
+			Options: options.Index().
+				SetName("identities_unique").
+				SetUnique(true).
+				// Users without identities must not collide on null
+				SetPartialFilterExpression(bson.M{"identities.external_id": bson.M{"$exists": true}}),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.follows.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "follower_id", Value: 1}, {Key: "followee_id", Value: 1}},
+			Options: options.Index().SetName("follow_unique").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "follower_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("following_recent"),
+		},
+		{
+			Keys:    bson.D{{Key: "followee_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("followers_recent"),
+		},
+	})
 	return err
 }
 
 // GetByID retrieves a user by ID
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	var user models.User
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+	err := r.reads.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -48,10 +119,13 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User,
 	return &user, nil
 }
 
-// GetByUsername retrieves a user by username
+// GetByUsername retrieves a user by username, ignoring deleted users
 func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	var user models.User
-	err := r.collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+	err := r.reads.FindOne(ctx, bson.M{"username": username, "deleted_at": nil}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -61,10 +135,36 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*m
 	return &user, nil
 }
 
+// GetByUsernames retrieves the users with any of the given usernames,
+// ignoring deleted users
+func (r *UserRepository) GetByUsernames(ctx context.Context, usernames []string) ([]*models.User, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	cursor, err := r.reads.Find(ctx, bson.M{"username": bson.M{"$in": usernames}, "deleted_at": nil})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // GetByEmail retrieves a user by email
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	var user models.User
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	err := r.reads.FindOne(ctx, bson.M{"email": email}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -76,8 +176,27 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 
 // GetByGitHubID retrieves a user by GitHub ID
 func (r *UserRepository) GetByGitHubID(ctx context.Context, githubID int) (*models.User, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	var user models.User
+	err := r.reads.FindOne(ctx, bson.M{"github_id": githubID}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetByIdentity retrieves the user linked to a provider account
+func (r *UserRepository) GetByIdentity(ctx context.Context, provider, externalID string) (*models.User, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	var user models.User
-	err := r.collection.FindOne(ctx, bson.M{"github_id": githubID}).Decode(&user)
+	err := r.reads.FindOne(ctx, identityFilter(provider, externalID)).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return nil, nil
@@ -87,28 +206,137 @@ func (r *UserRepository) GetByGitHubID(ctx context.Context, githubID int) (*mode
 	return &user, nil
 }
 
+// AddIdentity links a provider account to a user
+func (r *UserRepository) AddIdentity(ctx context.Context, userID string, identity models.Identity) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	filter := identityFilter(identity.Provider, identity.ExternalID)
+	filter["_id"] = bson.M{"$ne": userID}
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return repository.ErrAlreadyExists
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{
+		"$addToSet": bson.M{"identities": identity},
+		"$set":      bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return repository.ErrAlreadyExists
+		}
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// identityFilter matches the user linked to a provider account
+func identityFilter(provider, externalID string) bson.M {
+	return bson.M{"identities": bson.M{"$elemMatch": bson.M{
+		"provider":    provider,
+		"external_id": externalID,
+	}}}
+}
+
 // Update updates an existing user
 func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	user.UsernameLower = strings.ToLower(user.Username)
 	user.UpdatedAt = time.Now()
 	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": user.ID}, user)
 	return err
 }
 
-// Delete removes a user
+// Delete soft-deletes a user. The record is kept so reviews and other
+// references stay valid, but personal details are anonymized.
 func (r *UserRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	now := time.Now()
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "deleted_at": nil},
+		bson.M{"$set": bson.M{
+			"deleted_at": now,
+			"updated_at": now,
+			"email":      "",
+			"username":   models.DeletedUsername(id),
+			"bio":        "",
+			"location":   "",
+		}},
+	)
 	return err
 }
 
 // List retrieves users with pagination
 func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	opts := &options.FindOptions{
 		Sort:  bson.D{{Key: "created_at", Value: -1}},
 		Limit: int64ptr(limit),
 		Skip:  int64ptr(offset),
 	}
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	cursor, err := r.reads.Find(ctx, bson.M{"deleted_at": nil}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	if err = cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Iterate streams users that are not deleted from a cursor, newest first. It
+// is not bounded by the read timeout, since exports can take longer; cancel
+// ctx to stop it.
+func (r *UserRepository) Iterate(ctx context.Context, fn func(*models.User) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.reads.Find(ctx, bson.M{"deleted_at": nil}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// GetDeletedUsers retrieves soft-deleted users, most recently deleted first
+func (r *UserRepository) GetDeletedUsers(ctx context.Context, limit, offset int) ([]*models.User, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
+	opts := &options.FindOptions{
+		Sort:  bson.D{{Key: "deleted_at", Value: -1}},
+		Limit: int64ptr(limit),
+		Skip:  int64ptr(offset),
+	}
+
+	cursor, err := r.reads.Find(ctx, bson.M{"deleted_at": bson.M{"$ne": nil}}, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -123,6 +351,9 @@ func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*models
 
 // AddFavorite adds a template to user's favorites
 func (r *UserRepository) AddFavorite(ctx context.Context, userID, templateID string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": userID},
@@ -136,6 +367,9 @@ func (r *UserRepository) AddFavorite(ctx context.Context, userID, templateID str
 
 // RemoveFavorite removes a template from user's favorites
 func (r *UserRepository) RemoveFavorite(ctx context.Context, userID, templateID string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
 	_, err := r.collection.UpdateOne(
 		ctx,
 		bson.M{"_id": userID},
@@ -147,10 +381,56 @@ func (r *UserRepository) RemoveFavorite(ctx context.Context, userID, templateID
 	return err
 }
 
+// RecordDownload adds a template download to the front of the user's
+// history in one update, keeping the newest models.MaxDownloadHistory
+func (r *UserRepository) RecordDownload(ctx context.Context, userID, templateID string, at time.Time) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$push": bson.M{"download_history": bson.M{
+			"$each":     []models.DownloadHistoryItem{{TemplateID: templateID, DownloadedAt: at}},
+			"$position": 0,
+			"$slice":    models.MaxDownloadHistory,
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// BumpMembershipVersion increments the user's membership version
+func (r *UserRepository) BumpMembershipVersion(ctx context.Context, userID string) error {
+	ctx, cancel := r.client.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$inc": bson.M{"membership_version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
 // GetFavorites retrieves user's favorite template IDs
 func (r *UserRepository) GetFavorites(ctx context.Context, userID string) ([]string, error) {
+	ctx, cancel := r.client.ReadContext(ctx)
+	defer cancel()
+
 	var user models.User
-	err := r.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	err := r.reads.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return []string{}, nil
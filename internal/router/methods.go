@@ -0,0 +1,102 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"dotfiles-api/internal/middleware"
+	"dotfiles-api/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// methodNotAllowed answers requests whose path exists under other methods
+// with 405 and an Allow header listing those methods
+func methodNotAllowed(engine *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed := allowedMethods(engine.Routes(), c.Request.URL.Path)
+
+		method := c.Request.Method
+		if middleware.IsHead(c) {
+			method = http.MethodHead
+		}
+
+		c.Header("Allow", strings.Join(allowed, ", "))
+		c.JSON(http.StatusMethodNotAllowed, gin.H{
+			"error": errors.NewMethodNotAllowedError(method + " is not supported for this path"),
+		})
+	}
+}
+
+// routeNotFound answers unknown paths with the standard error envelope
+// rather than gin's plain text body
+func routeNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, gin.H{
+		"error": errors.NewNotFoundError("Endpoint " + c.Request.Method + " " + c.Request.URL.Path),
+	})
+}
+
+// allowHeader adds an Allow header to OPTIONS requests for known paths, so
+// they list the same methods a 405 would. It runs ahead of the CORS
+// middleware, which answers every OPTIONS request.
+func allowHeader(engine *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions {
+			if allowed := allowedMethods(engine.Routes(), c.Request.URL.Path); len(allowed) > 1 {
+				c.Header("Allow", strings.Join(allowed, ", "))
+			}
+		}
+		c.Next()
+	}
+}
+
+// allowedMethods lists, in sorted order, the methods of every route matching
+// path. OPTIONS is always included because the CORS middleware answers
+// preflight requests for any path, and HEAD wherever GET is, since
+// ServeHeadAsGet serves it.
+func allowedMethods(routes gin.RoutesInfo, path string) []string {
+	seen := map[string]bool{http.MethodOptions: true}
+	for _, route := range routes {
+		if matchRoute(route.Path, path) {
+			seen[route.Method] = true
+			if route.Method == http.MethodGet {
+				seen[http.MethodHead] = true
+			}
+		}
+	}
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// matchRoute reports whether path fits a gin route pattern, where :name
+// matches one segment and *name matches the rest of the path
+func matchRoute(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if strings.HasPrefix(segment, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return len(patternSegments) == len(pathSegments)
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"dotfiles-api/internal/config"
+	"dotfiles-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{pattern: "/api/templates", path: "/api/templates", match: true},
+		{pattern: "/api/templates", path: "/api/templates/", match: true},
+		{pattern: "/api/templates/:id", path: "/api/templates/abc", match: true},
+		{pattern: "/api/templates/:id", path: "/api/templates", match: false},
+		{pattern: "/api/templates/:id/reviews", path: "/api/templates/abc/reviews", match: true},
+		{pattern: "/api/templates/:id/reviews", path: "/api/templates/abc/rating", match: false},
+		{pattern: "/static/*filepath", path: "/static/css/site.css", match: true},
+		{pattern: "/", path: "/", match: true},
+		{pattern: "/", path: "/health", match: false},
+	}
+
+	for _, tt := range tests {
+		if got := matchRoute(tt.pattern, tt.path); got != tt.match {
+			t.Errorf("Expected matchRoute(%q, %q) to be %v, got %v", tt.pattern, tt.path, tt.match, got)
+		}
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/reviews/:id", ok)
+	r.PUT("/api/reviews/:id", ok)
+	r.DELETE("/api/reviews/:id", ok)
+	r.POST("/api/reviews/:id/helpful", ok)
+	r.NoMethod(methodNotAllowed(r))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/reviews/123", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected status 405, got %d", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "DELETE, GET, HEAD, OPTIONS, PUT" {
+		t.Errorf("Expected Allow %q, got %q", "DELETE, GET, HEAD, OPTIONS, PUT", allow)
+	}
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error.Code != "METHOD_NOT_ALLOWED" {
+		t.Errorf("Expected the error envelope, got %s", w.Body.String())
+	}
+
+	// HEAD is only allowed where GET is
+	w = httptest.NewRecorder()
+	middleware.ServeHeadAsGet(r).ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/api/reviews/123/helpful", nil))
+	if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "OPTIONS, POST" {
+		t.Errorf("Expected status 405 allowing OPTIONS, POST for HEAD, got %d with %q", w.Code, w.Header().Get("Allow"))
+	}
+
+	// Unknown paths are still 404, with a JSON body
+	r.NoRoute(routeNotFound)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/unknown", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for unknown path, got %d", w.Code)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error.Code != "NOT_FOUND" {
+		t.Errorf("Expected the error envelope for unknown path, got %s", w.Body.String())
+	}
+
+	if got := allowedMethods(r.Routes(), "/api/reviews/123/helpful"); !reflect.DeepEqual(got, []string{"OPTIONS", "POST"}) {
+		t.Errorf("Expected [OPTIONS POST], got %v", got)
+	}
+}
+
+func TestOptionsAllowHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.HandleMethodNotAllowed = true
+	r.Use(allowHeader(r))
+	r.Use(middleware.CORS([]string{"*"}, config.CORSConfig{}))
+	r.GET("/api/templates/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.PATCH("/api/templates/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.NoMethod(methodNotAllowed(r))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/api/templates/abc", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "GET, HEAD, OPTIONS, PATCH" {
+		t.Errorf("Expected Allow %q, got %q", "GET, HEAD, OPTIONS, PATCH", allow)
+	}
+}
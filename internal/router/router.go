@@ -1,6 +1,8 @@
 package router
 
 import (
+	"dotfiles-api/internal/config"
+	"dotfiles-api/internal/dto"
 	"dotfiles-api/internal/handlers"
 	"dotfiles-api/internal/middleware"
 
@@ -15,7 +17,15 @@ type Router struct {
 	authHandler         *handlers.AuthHandler
 	reviewHandler       *handlers.ReviewHandler
 	organizationHandler *handlers.OrganizationHandler
+	subscriptionHandler *handlers.SubscriptionHandler
+	maintenanceHandler  *handlers.MaintenanceHandler
+	reportHandler       *handlers.ReportHandler
+	metricsHandler      *handlers.MetricsHandler
 	authMiddleware      *middleware.AuthMiddleware
+	corsConfig          config.CORSConfig
+	features            config.FeatureConfig
+	rateLimits          config.RateLimitConfig
+	maxUploadSize       int64
 }
 
 // NewRouter creates a new router with all handlers
@@ -26,7 +36,15 @@ func NewRouter(
 	authHandler *handlers.AuthHandler,
 	reviewHandler *handlers.ReviewHandler,
 	organizationHandler *handlers.OrganizationHandler,
+	subscriptionHandler *handlers.SubscriptionHandler,
+	maintenanceHandler *handlers.MaintenanceHandler,
+	reportHandler *handlers.ReportHandler,
+	metricsHandler *handlers.MetricsHandler,
 	authMiddleware *middleware.AuthMiddleware,
+	corsConfig config.CORSConfig,
+	features config.FeatureConfig,
+	rateLimits config.RateLimitConfig,
+	maxUploadSize int64,
 ) *Router {
 	return &Router{
 		configHandler:       configHandler,
@@ -35,14 +53,44 @@ func NewRouter(
 		authHandler:         authHandler,
 		reviewHandler:       reviewHandler,
 		organizationHandler: organizationHandler,
+		subscriptionHandler: subscriptionHandler,
+		maintenanceHandler:  maintenanceHandler,
+		reportHandler:       reportHandler,
+		metricsHandler:      metricsHandler,
 		authMiddleware:      authMiddleware,
+		corsConfig:          corsConfig,
+		features:            features,
+		rateLimits:          rateLimits,
+		maxUploadSize:       maxUploadSize,
 	}
 }
 
 // SetupRoutes configures all the routes
 func (router *Router) SetupRoutes(r *gin.Engine) {
-	// Add CORS middleware
-	r.Use(middleware.CORS([]string{"*"}))
+	// Add CORS middleware, with OPTIONS answers listing the path's methods
+	r.Use(allowHeader(r))
+	r.Use(middleware.CORS([]string{"*"}, router.corsConfig))
+
+	// Authenticate API keys first, so their requests are limited per key
+	// rather than per IP
+	r.Use(router.authMiddleware.APIKeyAuth())
+
+	// Limit requests per client IP, with a tighter limit on writes
+	if limiter := router.newRateLimiter(router.rateLimits.Global); limiter != nil {
+		r.Use(limiter.MiddlewareFor(middleware.ByClientIP))
+	}
+	if limiter := router.newRateLimiter(router.rateLimits.Write); limiter != nil {
+		r.Use(middleware.OnlyWrites(limiter.MiddlewareFor(middleware.ByClientIP)))
+	}
+	if limiter := router.newRateLimiter(router.rateLimits.APIKey); limiter != nil {
+		r.Use(limiter.MiddlewareFor(middleware.ByAPIKey))
+	}
+
+	// Answer known paths hit with the wrong method with 405 instead of 404,
+	// and unknown paths with a JSON 404
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(methodNotAllowed(r))
+	r.NoRoute(routeNotFound)
 
 	// API root endpoint
 	r.GET("/", func(c *gin.Context) {
@@ -53,6 +101,7 @@ func (router *Router) SetupRoutes(r *gin.Engine) {
 				"auth":          "/auth",
 				"api":           "/api",
 				"health":        "/health",
+				"metrics":       "/metrics",
 				"documentation": "/docs",
 			},
 		})
@@ -66,58 +115,206 @@ func (router *Router) SetupRoutes(r *gin.Engine) {
 		})
 	})
 
+	// Session metrics in the Prometheus text format, for site admins; a
+	// scraper can authenticate with an admin's API key
+	r.GET("/metrics", router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.metricsHandler.GetMetrics)
+
 	// Authentication routes
 	auth := r.Group("/auth")
 	{
-		auth.GET("/github", router.authHandler.GitHubLogin)
-		auth.GET("/github/callback", router.authHandler.GitHubCallback)
 		auth.GET("/logout", router.authHandler.Logout)
 		auth.GET("/user", router.authHandler.GetCurrentUser)
+		auth.GET("/status", router.authHandler.GetStatus)
+		auth.GET("/:provider", router.authHandler.Login)
+		auth.GET("/:provider/callback", router.authHandler.Callback)
+		auth.GET("/:provider/link", router.authHandler.Link)
 	}
 
+	// Feature flags, checked before auth so disabled features answer 503
+	// to everyone
+	orgsEnabled := middleware.RequireFeature(router.features.EnableOrganizations, "Organizations")
+	reviewsEnabled := middleware.RequireFeature(router.features.EnableReviews, "Reviews")
+
+	// Config uploads and template creation need a signed-in caller unless
+	// anonymous uploads are enabled
+	uploadAuth := router.authMiddleware.RequireAuth()
+	if router.features.EnableAnonymousUploads {
+		uploadAuth = router.authMiddleware.OptionalAuth()
+	}
+
+	// Cap the bodies of endpoints that accept whole configs and templates
+	bodyLimit := middleware.MaxBodySize(router.maxUploadSize)
+
+	// Template reports are signed in and held to their own, tighter limit
+	reportTemplate := []gin.HandlerFunc{router.authMiddleware.RequireAuth()}
+	if limiter := router.newRateLimiter(router.rateLimits.Report); limiter != nil {
+		reportTemplate = append(reportTemplate, limiter.Middleware())
+	}
+	reportTemplate = append(reportTemplate, router.reportHandler.ReportTemplate)
+
 	// API routes
 	api := r.Group("/api")
+	if router.features.EnableOrganizations {
+		// Scope default listings on an organization's verified custom domain
+		api.Use(router.organizationHandler.CustomDomain())
+	}
 	{
+		// API metadata, including compatibility and deprecation notices
+		api.GET("/meta", func(c *gin.Context) {
+			c.JSON(200, gin.H{
+				"version": "1.0",
+				"compat": gin.H{
+					"header":    "X-API-Compat",
+					"query":     "compat",
+					"supported": []string{dto.LegacyCompatVersion},
+				},
+				"rate_limits": gin.H{
+					"global":       rateLimitMeta(router.rateLimits.Global),
+					"write":        rateLimitMeta(router.rateLimits.Write),
+					"report":       rateLimitMeta(router.rateLimits.Report),
+					"api_key":      rateLimitMeta(router.rateLimits.APIKey),
+					"exempt_paths": router.rateLimits.ExemptPaths,
+					"headers":      []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset"},
+				},
+				"deprecations": []gin.H{
+					{
+						"field":       "addOnly",
+						"replacement": "add_only",
+						"message":     "Template responses use snake_case keys. Send X-API-Compat: v0 (or ?compat=v0) to also receive the legacy camelCase aliases; request bodies accept either key.",
+					},
+				},
+			})
+		})
+
+		// JSON Schema for template bodies, for editor validation
+		api.GET("/schema/template.json", router.templateHandler.GetTemplateSchema)
+
 		// Config endpoints
-		api.POST("/configs/upload", router.configHandler.UploadConfig)
-		api.GET("/configs/:id", router.configHandler.GetConfig)
-		api.GET("/configs/:id/download", router.configHandler.DownloadConfig)
+		api.GET("/configs", router.authMiddleware.OptionalAuth(), router.configHandler.ListConfigs)
+		api.POST("/configs/upload", bodyLimit, uploadAuth, router.configHandler.UploadConfig)
+		api.GET("/configs/owned", router.authMiddleware.RequireAuth(), router.configHandler.GetMyConfigs)
+		api.GET("/configs/:id", router.authMiddleware.OptionalAuth(), router.configHandler.GetConfig)
+		api.GET("/configs/:id/download", router.authMiddleware.OptionalAuth(), router.configHandler.DownloadConfig)
+		api.GET("/configs/:id/downloads/history", router.authMiddleware.OptionalAuth(), router.configHandler.GetConfigDownloadHistory)
+		api.PUT("/configs/:id/owner", router.authMiddleware.RequireAuth(), router.configHandler.TransferOwnership)
 		api.GET("/configs/search", router.configHandler.SearchConfigs)
+		api.GET("/configs/tags", router.configHandler.ListConfigTags)
 		api.GET("/configs/featured", router.configHandler.GetFeaturedConfigs)
 		api.GET("/configs/stats", router.configHandler.GetStats)
+		api.GET("/configs/count", router.configHandler.GetCount)
 
 		// Template endpoints
-		api.POST("/templates", router.templateHandler.CreateTemplate)
-		api.GET("/templates", router.templateHandler.ListTemplates)
-		api.GET("/templates/:id", router.templateHandler.GetTemplate)
-		api.GET("/templates/:id/download", router.templateHandler.DownloadTemplate)
-		api.GET("/templates/:id/reviews", router.reviewHandler.GetTemplateReviews)
-		api.POST("/templates/:id/reviews", router.authMiddleware.RequireAuth(), router.reviewHandler.CreateReview)
-		api.GET("/templates/:id/rating", router.reviewHandler.GetTemplateRating)
+		api.POST("/templates", bodyLimit, uploadAuth, router.templateHandler.CreateTemplate)
+		api.POST("/templates/bulk-import", bodyLimit, router.authMiddleware.RequireAuth(), router.templateHandler.BulkImportTemplates)
+		api.POST("/templates/validate", bodyLimit, router.authMiddleware.OptionalAuth(), router.templateHandler.ValidateTemplate)
+		api.POST("/templates/from-github", bodyLimit, router.authMiddleware.RequireAuth(), router.templateHandler.ImportFromGitHub)
+		api.GET("/templates", router.authMiddleware.OptionalAuth(), router.templateHandler.ListTemplates)
+		api.GET("/templates/search", router.authMiddleware.OptionalAuth(), router.templateHandler.SearchTemplates)
+		api.GET("/templates/count", router.templateHandler.CountTemplates)
+		api.GET("/templates/stats", router.templateHandler.GetTemplateStats)
+		api.GET("/templates/random", router.templateHandler.GetRandomTemplate)
+		api.GET("/templates/tags", router.templateHandler.ListTags)
+		api.GET("/templates/:id", router.authMiddleware.OptionalAuth(), router.templateHandler.GetTemplate)
+		api.PATCH("/templates/:id", bodyLimit, router.authMiddleware.RequireAuth(), router.templateHandler.PatchTemplate)
+		api.DELETE("/templates/:id", router.authMiddleware.RequireAuth(), router.templateHandler.DeleteTemplate)
+		api.POST("/templates/:id/images", bodyLimit, router.authMiddleware.RequireAuth(), router.templateHandler.UploadTemplateImage)
+		api.DELETE("/templates/:id/images/:imageId", router.authMiddleware.RequireAuth(), router.templateHandler.DeleteTemplateImage)
+		api.GET("/templates/:id/acl", router.authMiddleware.RequireAuth(), router.templateHandler.GetTemplateACL)
+		api.POST("/templates/:id/acl", bodyLimit, router.authMiddleware.RequireAuth(), router.templateHandler.AddTemplateACLEntry)
+		api.DELETE("/templates/:id/acl/:username", router.authMiddleware.RequireAuth(), router.templateHandler.RemoveTemplateACLEntry)
+		api.POST("/templates/:id/share-links", router.authMiddleware.RequireAuth(), router.templateHandler.CreateShareLink)
+		api.GET("/templates/:id/share-links", router.authMiddleware.RequireAuth(), router.templateHandler.ListShareLinks)
+		api.DELETE("/templates/:id/share-links/:linkId", router.authMiddleware.RequireAuth(), router.templateHandler.DeleteShareLink)
+		api.GET("/templates/shared/:token", router.templateHandler.GetSharedTemplate)
+		api.GET("/templates/shared/:token/download", router.templateHandler.DownloadSharedTemplate)
+		api.GET("/templates/:id/download", router.authMiddleware.OptionalAuth(), router.templateHandler.DownloadTemplate)
+		api.GET("/templates/:id/downloads/history", router.authMiddleware.OptionalAuth(), router.templateHandler.GetTemplateDownloadHistory)
+		api.GET("/templates/:id/analytics/platforms", router.authMiddleware.RequireAuth(), router.templateHandler.GetTemplatePlatformAnalytics)
+		api.GET("/templates/:id/docker-setup", router.authMiddleware.OptionalAuth(), router.templateHandler.GetDockerSetup)
+		api.GET("/templates/:id/install-snippet", router.authMiddleware.OptionalAuth(), router.templateHandler.GetInstallSnippet)
+		api.GET("/templates/:id/share", router.authMiddleware.OptionalAuth(), router.templateHandler.GetShareMetadata)
+		api.GET("/templates/:id/plan", router.authMiddleware.OptionalAuth(), router.templateHandler.GetTemplatePlan)
+		api.GET("/templates/:id/estimate", router.authMiddleware.OptionalAuth(), router.templateHandler.GetTemplateEstimate)
+		api.GET("/templates/:id/related-configs", router.authMiddleware.OptionalAuth(), router.templateHandler.GetRelatedConfigs)
+		api.GET("/templates/:id/versions/latest", router.authMiddleware.OptionalAuth(), router.templateHandler.GetLatestVersion)
+		api.POST("/templates/:id/sync-github", router.authMiddleware.RequireAuth(), router.templateHandler.SyncFromGitHub)
+		api.GET("/templates/:id/reviews", reviewsEnabled, router.reviewHandler.GetTemplateReviews)
+		api.POST("/templates/:id/reviews", reviewsEnabled, router.authMiddleware.RequireAuth(), router.reviewHandler.CreateReview)
+		api.GET("/templates/:id/reviews/user", reviewsEnabled, router.authMiddleware.RequireAuth(), router.reviewHandler.GetMyReviewForTemplate)
+		api.GET("/templates/:id/rating", reviewsEnabled, router.reviewHandler.GetTemplateRating)
+		api.GET("/templates/:id/detail", reviewsEnabled, router.authMiddleware.OptionalAuth(), router.templateHandler.GetTemplateDetail)
+		api.PUT("/templates/:id/rating", reviewsEnabled, router.authMiddleware.RequireAuth(), router.reviewHandler.RateTemplate)
+		api.POST("/templates/:id/report", reportTemplate...)
 
 		// User endpoints
 		api.GET("/users/:username", router.userHandler.GetUserByUsername)
+		api.GET("/avatars/:userID", router.userHandler.GetAvatar)
+		api.GET("/users/me/templates", router.authMiddleware.RequireAuth(), router.templateHandler.GetMyTemplates)
 		api.POST("/users/favorites/:templateId", router.authMiddleware.RequireAuth(), router.userHandler.AddFavorite)
 		api.DELETE("/users/favorites/:templateId", router.authMiddleware.RequireAuth(), router.userHandler.RemoveFavorite)
+		api.GET("/users/:username/following", router.userHandler.GetFollowing)
+		api.GET("/users/:username/followers", router.userHandler.GetFollowers)
+		api.POST("/users/:username/follow", router.authMiddleware.RequireAuth(), router.userHandler.FollowUser)
+		api.DELETE("/users/:username/follow", router.authMiddleware.RequireAuth(), router.userHandler.UnfollowUser)
+		api.GET("/me", router.authMiddleware.RequireAuth(), router.userHandler.GetMe)
+		api.GET("/me/claim", router.authMiddleware.RequireAuth(), router.configHandler.GetClaimableConfigs)
+		api.POST("/me/claim", router.authMiddleware.RequireAuth(), router.configHandler.ClaimConfigs)
+		api.GET("/me/download-history", router.authMiddleware.RequireAuth(), router.userHandler.GetDownloadHistory)
+		api.GET("/me/recommendations", router.authMiddleware.RequireAuth(), router.userHandler.GetRecommendations)
+		api.POST("/me/api-keys", router.authMiddleware.RequireAuth(), router.userHandler.CreateAPIKey)
+		api.GET("/me/api-keys", router.authMiddleware.RequireAuth(), router.userHandler.ListAPIKeys)
+		api.DELETE("/me/api-keys/:id", router.authMiddleware.RequireAuth(), router.userHandler.DeleteAPIKey)
+
+		// Tag subscription endpoints, feeding the new-template digest
+		api.GET("/subscriptions/tags", router.authMiddleware.RequireAuth(), router.subscriptionHandler.GetTagSubscriptions)
+		api.POST("/subscriptions/tags", router.authMiddleware.RequireAuth(), router.subscriptionHandler.SubscribeTags)
+		api.DELETE("/subscriptions/tags/:tag", router.authMiddleware.RequireAuth(), router.subscriptionHandler.UnsubscribeTag)
 
 		// Review endpoints
-		api.PUT("/reviews/:id", router.authMiddleware.RequireAuth(), router.reviewHandler.UpdateReview)
-		api.DELETE("/reviews/:id", router.authMiddleware.RequireAuth(), router.reviewHandler.DeleteReview)
-		api.POST("/reviews/:id/helpful", router.authMiddleware.RequireAuth(), router.reviewHandler.MarkReviewHelpful)
+		api.PATCH("/reviews/:id", reviewsEnabled, router.authMiddleware.RequireAuth(), router.reviewHandler.UpdateReview)
+		api.DELETE("/reviews/:id", reviewsEnabled, router.authMiddleware.RequireAuth(), router.reviewHandler.DeleteReview)
+		api.POST("/reviews/:id/helpful", reviewsEnabled, router.authMiddleware.RequireAuth(), router.reviewHandler.MarkReviewHelpful)
+		api.GET("/me/reviews", reviewsEnabled, router.authMiddleware.RequireAuth(), router.reviewHandler.GetMyReviews)
 
 		// Organization endpoints
-		api.POST("/organizations", router.authMiddleware.RequireAuth(), router.organizationHandler.CreateOrganization)
-		api.GET("/organizations", router.organizationHandler.GetOrganizations)
-		api.GET("/organizations/:slug", router.organizationHandler.GetOrganizationBySlug)
-		api.PUT("/organizations/:slug", router.authMiddleware.RequireAuth(), router.organizationHandler.UpdateOrganization)
-		api.DELETE("/organizations/:slug", router.authMiddleware.RequireAuth(), router.organizationHandler.DeleteOrganization)
-		api.GET("/organizations/:slug/members", router.organizationHandler.GetOrganizationMembers)
-		api.POST("/organizations/:slug/members", router.authMiddleware.RequireAuth(), router.organizationHandler.InviteMember)
-		api.DELETE("/organizations/:slug/members/:username", router.authMiddleware.RequireAuth(), router.organizationHandler.RemoveMember)
-		api.PUT("/organizations/:slug/members/:username", router.authMiddleware.RequireAuth(), router.organizationHandler.UpdateMemberRole)
-		api.GET("/organizations/:slug/invites", router.authMiddleware.RequireAuth(), router.organizationHandler.GetOrganizationInvites)
-		api.POST("/invites/:token/accept", router.authMiddleware.RequireAuth(), router.organizationHandler.AcceptInvite)
-		api.GET("/users/:username/organizations", router.userHandler.GetUserOrganizations)
+		api.POST("/organizations", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.CreateOrganization)
+		api.GET("/organizations", orgsEnabled, router.organizationHandler.GetOrganizations)
+		api.GET("/organizations/:slug", orgsEnabled, router.authMiddleware.OptionalAuth(), router.organizationHandler.GetOrganizationBySlug)
+		api.PUT("/organizations/:slug", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.UpdateOrganization)
+		api.DELETE("/organizations/:slug", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.DeleteOrganization)
+		api.GET("/organizations/:slug/members", orgsEnabled, router.organizationHandler.GetOrganizationMembers)
+		api.GET("/organizations/:slug/share", orgsEnabled, router.organizationHandler.GetShareMetadata)
+		api.GET("/organizations/:slug/featured-templates", orgsEnabled, router.authMiddleware.OptionalAuth(), router.organizationHandler.GetFeaturedTemplates)
+		api.PUT("/organizations/:slug/featured-templates", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.SetFeaturedTemplates)
+		api.GET("/organizations/:slug/templates/count", orgsEnabled, router.authMiddleware.OptionalAuth(), router.organizationHandler.GetOrganizationTemplateCount)
+		api.POST("/organizations/:slug/join-requests", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.CreateJoinRequest)
+		api.GET("/organizations/:slug/join-requests", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.GetJoinRequests)
+		api.PUT("/organizations/:slug/join-requests/settings", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.SetJoinRequests)
+		api.POST("/organizations/:slug/join-requests/:id/approve", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.ApproveJoinRequest)
+		api.POST("/organizations/:slug/join-requests/:id/reject", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.RejectJoinRequest)
+		api.POST("/organizations/:slug/members", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.InviteMember)
+		api.DELETE("/organizations/:slug/members/:username", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.RemoveMember)
+		api.PUT("/organizations/:slug/members/:username", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.UpdateMemberRole)
+		api.GET("/organizations/:slug/invites", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.GetOrganizationInvites)
+		api.POST("/organizations/:slug/invites/:id/resend", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.ResendInvite)
+		api.GET("/organizations/:slug/domain", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.GetCustomDomain)
+		api.POST("/organizations/:slug/domain/verify", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.VerifyCustomDomain)
+		api.POST("/invites/:token/accept", orgsEnabled, router.authMiddleware.RequireAuth(), router.organizationHandler.AcceptInvite)
+		api.POST("/auth/refresh-memberships", router.authMiddleware.RequireAuth(), router.authHandler.RefreshMemberships)
+
+		// Site admin endpoints
+		api.POST("/auth/impersonate", router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.authHandler.ImpersonateUser)
+		api.GET("/admin/users", router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.userHandler.ListUsers)
+		api.GET("/admin/users/deleted", router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.userHandler.GetDeletedUsers)
+		api.PUT("/admin/tags/synonyms", router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.templateHandler.SetTagSynonyms)
+		api.POST("/admin/tags/backfill", router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.templateHandler.BackfillTags)
+		api.POST("/admin/maintenance/integrity-sweep", router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.maintenanceHandler.RunIntegritySweep)
+		api.POST("/admin/maintenance/reconcile-counters", router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.maintenanceHandler.ReconcileCounters)
+		api.GET("/admin/reports", router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.reportHandler.ListReports)
+		api.POST("/admin/reports/:id/resolve", router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.reportHandler.ResolveReport)
+		api.PUT("/admin/organizations/:slug/max-members", orgsEnabled, router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.organizationHandler.SetMaxMembers)
+		api.PUT("/admin/organizations/:slug/domain", orgsEnabled, router.authMiddleware.RequireAuth(), router.authMiddleware.RequireAdmin(), router.organizationHandler.SetCustomDomain)
+		api.GET("/users/:username/organizations", orgsEnabled, router.userHandler.GetUserOrganizations)
 	}
 
 	// API documentation endpoint
@@ -127,52 +324,167 @@ func (router *Router) SetupRoutes(r *gin.Engine) {
 			"version": "1.0",
 			"endpoints": gin.H{
 				"auth": gin.H{
-					"GET /auth/github":          "GitHub OAuth login",
-					"GET /auth/github/callback": "GitHub OAuth callback",
-					"GET /auth/logout":          "Logout user",
-					"GET /auth/user":            "Get current user",
+					"GET /auth/:provider":                "OAuth login with an enabled provider (github, gitlab)",
+					"GET /auth/:provider/callback":       "OAuth callback",
+					"GET /auth/:provider/link":           "Link a provider account to the signed-in user",
+					"GET /auth/logout":                   "Logout user",
+					"GET /auth/user":                     "Get current user",
+					"GET /auth/status":                   "Configured sign-in providers and the signed-in user, if any; never 401",
+					"POST /api/auth/refresh-memberships": "Reload the organization roles cached in the session (auth required)",
+				},
+				"meta": gin.H{
+					"GET /api/meta":                 "API metadata: compatibility modes, deprecations and rate limits",
+					"GET /api/schema/template.json": "JSON Schema for template request bodies",
+					"GET /metrics":                  "Session counts and ages in the Prometheus text format (site admin required)",
 				},
 				"configs": gin.H{
-					"POST /api/configs/upload":     "Upload config",
+					"GET /api/configs":              "List configs (owner, tags, sort_by, sort_order, limit, offset)",
+					"POST /api/configs/upload":     "Upload config (auth required unless anonymous uploads are enabled)",
+					"GET /api/configs/owned":       "List the current user's configs, including private ones (auth required)",
 					"GET /api/configs/:id":         "Get config by ID",
 					"GET /api/configs/:id/download": "Download config",
-					"GET /api/configs/search":      "Search configs",
-					"GET /api/configs/featured":    "Get featured configs",
-					"GET /api/configs/stats":       "Get config statistics",
+					"GET /api/configs/:id/downloads/history": "Downloads per day over the last days (default 30, max 90)",
+					"PUT /api/configs/:id/owner":    "Transfer config ownership (auth required)",
+					"GET /api/configs/search":       "Search configs (tags narrows to configs with every tag)",
+					"GET /api/configs/tags":         "Canonical tags used by public configs with their counts",
+					"GET /api/configs/featured":     "Get featured configs (window=7d ranks by recent downloads)",
+					"GET /api/configs/stats":        "Get config statistics, including downloads over the last 7 and 30 days (format=csv for key,value rows)",
+					"GET /api/configs/count":        "Number of configs and of public configs, cached for 30 seconds",
 				},
 				"templates": gin.H{
-					"POST /api/templates":              "Create template",
-					"GET /api/templates":               "List templates",
-					"GET /api/templates/:id":           "Get template by ID",
-					"GET /api/templates/:id/download":  "Download template",
-					"GET /api/templates/:id/reviews":   "Get template reviews",
-					"POST /api/templates/:id/reviews":  "Create review (auth required)",
-					"GET /api/templates/:id/rating":    "Get template rating",
+					"POST /api/templates":                       "Create template (auth required unless anonymous uploads are enabled)",
+					"POST /api/templates/validate":              "Validate a template without saving it",
+					"POST /api/templates/bulk-import":           "Create up to 20 templates at once; all or nothing (auth required)",
+					"POST /api/templates/from-github":           "Create a template from a Brewfile in a GitHub repository (auth required)",
+					"GET /api/templates":                        "List templates (sort_by=featured:desc,downloads:desc for multiple keys; sort_by=popularity for the composite score; expand=owner embeds the owner; Accept: application/x-ndjson streams all matches; format=csv exports a page of up to 1000)",
+					"GET /api/templates/search":                 "Search templates (q=terms), with matched fields and highlights",
+					"GET /api/templates/count":                  "Number of public templates, cached for 30 seconds",
+					"GET /api/templates/stats":                  "Get template statistics (format=csv for key,value rows)",
+					"GET /api/templates/random":                 "Random public templates for discovery (count=1, up to 5)",
+					"GET /api/templates/tags":                   "Canonical tags with their synonyms and template counts",
+					"GET /api/templates/:id":                    "Get template by ID (expand=owner embeds the owner; installed_version=1.1.0 adds is_outdated)",
+					"PATCH /api/templates/:id":                  "Update a template with a JSON Merge Patch (Content-Type: application/merge-patch+json; author only)",
+					"DELETE /api/templates/:id":                 "Delete a template and its images (author only)",
+					"POST /api/templates/:id/images":            "Upload a PNG, JPEG or WebP screenshot as multipart \"image\" with an optional \"caption\"; up to 3 of 2MB each (author only)",
+					"DELETE /api/templates/:id/images/:imageId": "Remove a template image (author only)",
+					"GET /api/templates/:id/acl":                "Users a private template is shared with (author only)",
+					"POST /api/templates/:id/acl":               "Share a private template with a user by username (author only)",
+					"DELETE /api/templates/:id/acl/:username":   "Stop sharing a template with a user (author only)",
+					"POST /api/templates/:id/share-links":       "Create a link anyone can use to see the template while it is private, for 7 days by default (author only)",
+					"GET /api/templates/:id/share-links":        "Usable share links, without their tokens (author only)",
+					"DELETE /api/templates/:id/share-links/:linkId": "Revoke a share link (author only)",
+					"GET /api/templates/shared/:token":          "The template behind a share link, whatever its visibility; 410 once expired or used up",
+					"GET /api/templates/shared/:token/download": "Download through a share link, without counting a download",
+					"GET /api/templates/:id/download":           "Download template (send X-Client to be warned about unsupported features)",
+					"GET /api/templates/:id/downloads/history":  "Downloads per day over the last days (default 30, max 90)",
+					"GET /api/templates/:id/analytics/platforms": "Downloads per platform (mac_arm64, mac_x86, linux_arm64, linux_x86, unknown) from the User-Agent over the last days (author only)",
+					"GET /api/templates/:id/docker-setup":       "Dockerfile snippet installing the template (text/plain)",
+					"GET /api/templates/:id/install-snippet":    "Copy-paste curl and CLI install commands (format=text for the curl line only)",
+					"GET /api/templates/:id/share":              "Open Graph and Twitter Card metadata for sharing a template",
+					"GET /api/templates/:id/plan":               "Ordered install steps with dangerous-command warnings, for a dry run",
+					"GET /api/templates/:id/estimate":           "Approximate download size and time of the template's brews and casks (mbps=25)",
+					"GET /api/templates/:id/related-configs":    "Public configs sharing brews or casks with the template, most shared first (limit)",
+					"GET /api/templates/:id/versions/latest":    "The template's current version, for upgrade checks",
+					"POST /api/templates/:id/sync-github":       "Re-import a GitHub template's Brewfile (author only)",
+					"GET /api/templates/:id/reviews":            "Get template reviews (lang=en,es filters by detected language)",
+					"POST /api/templates/:id/reviews":           "Create review (auth required)",
+					"GET /api/templates/:id/reviews/user":       "Current user's review of the template, or null (auth required)",
+					"GET /api/templates/:id/rating":             "Get template rating",
+					"GET /api/templates/:id/detail":             "Template, rating, a page of reviews (limit, offset) and is_favorited in one response",
+					"PUT /api/templates/:id/rating":             "Rate a template without a comment, creating or updating your review (auth required)",
+					"POST /api/templates/:id/report":            "Report a template to site admins (reason=malicious|spam|broken|other; auth required, rate limited)",
 				},
 				"users": gin.H{
 					"GET /api/users/:username":                "Get user profile",
+					"GET /api/avatars/:userID":                "User avatar served through the API's cache, or a generated identicon",
 					"POST /api/users/favorites/:templateId":   "Add to favorites (auth required)",
 					"DELETE /api/users/favorites/:templateId": "Remove from favorites (auth required)",
+					"GET /api/users/:username/following":      "Users this user follows, most recently followed first (paginated)",
+					"GET /api/users/:username/followers":      "Users following this user, most recent first (paginated)",
+					"POST /api/users/:username/follow":        "Follow a user (auth required)",
+					"DELETE /api/users/:username/follow":      "Stop following a user (auth required)",
+					"GET /api/users/me/templates":             "List the current user's templates, private ones included (status=draft|published, auth required)",
+					"GET /api/me":                             "Current user's profile with template, favorite, organization and review counts (auth required)",
+					"GET /api/me/claim":                       "Configs uploaded without an account under the current user's email (auth required)",
+					"POST /api/me/claim":                      "Take ownership of those configs (config_ids; auth required)",
+					"GET /api/me/download-history":            "Templates the current user recently downloaded (auth required)",
+					"GET /api/me/recommendations":             "Up to 10 public templates sharing tags with the current user's favorites (auth required)",
+					"POST /api/me/api-keys":                   "Create an API key for scripts and CI; the key is shown once (session auth required)",
+					"GET /api/me/api-keys":                    "List the current user's API keys (session auth required)",
+					"DELETE /api/me/api-keys/:id":             "Revoke an API key (session auth required)",
+				},
+				"subscriptions": gin.H{
+					"GET /api/subscriptions/tags":         "Tags the current user follows (auth required)",
+					"POST /api/subscriptions/tags":        "Follow tags for the new-template digest (auth required)",
+					"DELETE /api/subscriptions/tags/:tag": "Unfollow a tag (auth required)",
 				},
 				"reviews": gin.H{
-					"PUT /api/reviews/:id":        "Update review (auth required)",
+					"PATCH /api/reviews/:id":      "Update a review's rating and/or comment (auth required)",
 					"DELETE /api/reviews/:id":     "Delete review (auth required)",
 					"POST /api/reviews/:id/helpful": "Mark review helpful (auth required)",
+					"GET /api/me/reviews":           "Reviews written by the current user (auth required; limit, offset)",
 				},
 				"organizations": gin.H{
 					"POST /api/organizations":                            "Create organization (auth required)",
 					"GET /api/organizations":                             "List organizations",
-					"GET /api/organizations/:slug":                       "Get organization by slug",
+					"GET /api/organizations/:slug":                       "Get organization by slug (seat usage shown to admins)",
 					"PUT /api/organizations/:slug":                       "Update organization (auth required)",
 					"DELETE /api/organizations/:slug":                    "Delete organization (auth required)",
 					"GET /api/organizations/:slug/members":               "Get organization members",
+					"GET /api/organizations/:slug/share":                 "Social card metadata for a public organization",
+					"GET /api/organizations/:slug/featured-templates":    "Pinned templates, or the top 5 by downloads",
+					"PUT /api/organizations/:slug/featured-templates":    "Pin up to 5 organization templates (owners and admins)",
+					"GET /api/organizations/:slug/templates/count":       "Number of public organization templates, cached for 60 seconds",
+					"POST /api/organizations/:slug/join-requests":        "Ask to join a public organization that accepts join requests (auth required)",
+					"GET /api/organizations/:slug/join-requests":         "List join requests, pending unless status is given (org owner/admin)",
+					"PUT /api/organizations/:slug/join-requests/settings": "Turn join requests on or off (org owner/admin)",
+					"POST /api/organizations/:slug/join-requests/:id/approve": "Approve a join request, adding the requester as a member (org owner/admin)",
+					"POST /api/organizations/:slug/join-requests/:id/reject":  "Reject a join request with an optional reason (org owner/admin)",
 					"POST /api/organizations/:slug/members":              "Invite member (auth required)",
 					"DELETE /api/organizations/:slug/members/:username":  "Remove member (auth required)",
 					"PUT /api/organizations/:slug/members/:username":     "Update member role (auth required)",
 					"GET /api/organizations/:slug/invites":               "Get organization invites (auth required)",
+					"POST /api/organizations/:slug/invites/:id/resend":   "Regenerate and resend a pending invite (org owner/admin)",
+					"GET /api/organizations/:slug/domain":                "Custom domain status and TXT challenge (org owner/admin)",
+					"POST /api/organizations/:slug/domain/verify":        "Verify the custom domain's TXT challenge (org owner/admin)",
 					"POST /api/invites/:token/accept":                    "Accept invite (auth required)",
 				},
+				"admin": gin.H{
+					"POST /api/auth/impersonate":                     "Sign in as another user for support; the session expires after an hour (site admin required)",
+					"GET /api/admin/users":                           "List users; Accept: application/x-ndjson streams all of them, format=csv exports a page (site admin required)",
+					"GET /api/admin/users/deleted":                   "List soft-deleted users (site admin required)",
+					"PUT /api/admin/organizations/:slug/max-members": "Set organization member limit, 0 = unlimited (site admin required)",
+					"PUT /api/admin/organizations/:slug/domain":      "Assign an organization custom domain, empty = remove (site admin required)",
+					"PUT /api/admin/tags/synonyms":                   "Replace a canonical tag's synonyms (site admin required)",
+					"POST /api/admin/tags/backfill":                  "Rewrite stored template tags to canonical form (site admin required)",
+					"GET /api/admin/reports":                         "Open template reports grouped by template, most reported first (site admin required)",
+					"POST /api/admin/reports/:id/resolve":            "Resolve a report and its template's other open reports (action=dismiss|unlist_template|delete_template; site admin required)",
+					"POST /api/admin/maintenance/integrity-sweep":    "Remove reviews, favorites and memberships pointing at deleted templates or users, dry_run=true to only count (site admin required)",
+					"POST /api/admin/maintenance/reconcile-counters": "Recount organization member counts and correct those that drifted, dry_run=true to only count (site admin required)",
+				},
 			},
 		})
 	})
-}
\ No newline at end of file
+}
+
+// newRateLimiter builds a limiter for limit that skips the exempt paths, or
+// returns nil when limit is turned off
+func (router *Router) newRateLimiter(limit config.RateLimit) *middleware.RateLimiter {
+	if limit.Requests <= 0 || limit.Window <= 0 {
+		return nil
+	}
+	limiter := middleware.NewRateLimiter(limit.Requests, limit.Window)
+	limiter.Exempt(router.rateLimits.ExemptPaths...)
+	return limiter
+}
+
+// rateLimitMeta describes a limit for GET /api/meta, or nil when it is off
+func rateLimitMeta(limit config.RateLimit) gin.H {
+	if limit.Requests <= 0 || limit.Window <= 0 {
+		return nil
+	}
+	return gin.H{
+		"requests":       limit.Requests,
+		"window_seconds": int(limit.Window.Seconds()),
+	}
+}
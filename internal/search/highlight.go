@@ -0,0 +1,189 @@
+// Package search holds the query matching and snippet helpers shared by the
+// template and config search endpoints.
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Markers wrapped around each match in a snippet. Snippet text is
+// HTML-escaped before the markers are inserted, so the markers are the only
+// markup a client will ever receive.
+const (
+	MarkStart = "<mark>"
+	MarkEnd   = "</mark>"
+)
+
+// DefaultRadius is the number of characters of context kept on each side of
+// the first match in a snippet.
+const DefaultRadius = 40
+
+// ellipsis marks text trimmed from either end of a snippet
+const ellipsis = "…"
+
+// Field is a named piece of text that a query can match
+type Field struct {
+	Name string
+	Text string
+}
+
+// Highlight is a short excerpt of the field a result matched in
+type Highlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
+
+// Terms splits a query into lowercase, de-duplicated search terms
+func Terms(query string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+
+	for _, term := range strings.Fields(strings.ToLower(query)) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Contains reports whether text contains any of the terms, ignoring case
+func Contains(text string, terms []string) bool {
+	return len(matchRanges([]rune(text), terms)) > 0
+}
+
+// Match returns the names of the fields that contain any term, in the order
+// given, and a highlighted snippet from the first matching field. The
+// highlight is nil when nothing matched.
+func Match(fields []Field, terms []string, radius int) ([]string, *Highlight) {
+	matched := []string{}
+	var highlight *Highlight
+
+	for _, field := range fields {
+		snippet := Snippet(field.Text, terms, radius)
+		if snippet == "" {
+			continue
+		}
+
+		matched = append(matched, field.Name)
+		if highlight == nil {
+			highlight = &Highlight{Field: field.Name, Snippet: snippet}
+		}
+	}
+
+	return matched, highlight
+}
+
+// Snippet returns up to radius characters either side of the first match in
+// text, with every match of every term wrapped in MarkStart/MarkEnd. The text
+// is HTML-escaped so user content cannot inject markup. It returns "" when
+// no term matches.
+func Snippet(text string, terms []string, radius int) string {
+	runes := []rune(text)
+	ranges := matchRanges(runes, terms)
+	if len(ranges) == 0 {
+		return ""
+	}
+
+	start := ranges[0][0] - radius
+	if start < 0 {
+		start = 0
+	}
+	end := ranges[0][1] + radius
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString(ellipsis)
+	}
+
+	pos := start
+	for _, r := range ranges {
+		if r[1] <= start {
+			continue
+		}
+		if r[0] >= end {
+			break
+		}
+
+		// Clip matches that straddle the window edges
+		from, to := r[0], r[1]
+		if from < start {
+			from = start
+		}
+		if to > end {
+			to = end
+		}
+
+		b.WriteString(html.EscapeString(string(runes[pos:from])))
+		b.WriteString(MarkStart)
+		b.WriteString(html.EscapeString(string(runes[from:to])))
+		b.WriteString(MarkEnd)
+		pos = to
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+
+	if end < len(runes) {
+		b.WriteString(ellipsis)
+	}
+	return b.String()
+}
+
+// matchRanges returns the sorted, merged [start, end) rune ranges where any
+// term occurs in text, ignoring case
+func matchRanges(text []rune, terms []string) [][2]int {
+	// Lowercase rune by rune so indexes line up with the original text
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	marked := make([]bool, len(text))
+	found := false
+	for _, term := range terms {
+		needle := []rune(strings.ToLower(term))
+		if len(needle) == 0 {
+			continue
+		}
+
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if runesEqual(lower[i:i+len(needle)], needle) {
+				for j := i; j < i+len(needle); j++ {
+					marked[j] = true
+				}
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	var ranges [][2]int
+	for i := 0; i < len(marked); i++ {
+		if !marked[i] {
+			continue
+		}
+		j := i
+		for j < len(marked) && marked[j] {
+			j++
+		}
+		ranges = append(ranges, [2]int{i, j})
+		i = j
+	}
+	return ranges
+}
+
+func runesEqual(a, b []rune) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,124 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	got := Terms("  Neovim tmux NEOVIM ")
+	want := []string{"neovim", "tmux"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if got := Terms("   "); len(got) != 0 {
+		t.Errorf("Expected no terms for blank query, got %v", got)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		terms  []string
+		radius int
+		want   string
+	}{
+		{
+			name:   "single match",
+			text:   "Modern Neovim setup",
+			terms:  []string{"neovim"},
+			radius: 40,
+			want:   "Modern <mark>Neovim</mark> setup",
+		},
+		{
+			name:   "no match",
+			text:   "Modern Neovim setup",
+			terms:  []string{"emacs"},
+			radius: 40,
+			want:   "",
+		},
+		{
+			name:   "multiple terms",
+			text:   "tmux and neovim",
+			terms:  []string{"neovim", "tmux"},
+			radius: 40,
+			want:   "<mark>tmux</mark> and <mark>neovim</mark>",
+		},
+		{
+			name:   "overlapping terms merge",
+			text:   "neovim",
+			terms:  []string{"neo", "vim"},
+			radius: 40,
+			want:   "<mark>neovim</mark>",
+		},
+		{
+			name:   "context window",
+			text:   "a long description that eventually mentions neovim and then keeps going",
+			terms:  []string{"neovim"},
+			radius: 5,
+			want:   "…ions <mark>neovim</mark> and …",
+		},
+		{
+			name:   "later match outside window",
+			text:   "zsh then a very long gap then zshrc",
+			terms:  []string{"zsh"},
+			radius: 4,
+			want:   "<mark>zsh</mark> the…",
+		},
+		{
+			name:   "html is escaped",
+			text:   `<script>alert("neovim")</script>`,
+			terms:  []string{"neovim"},
+			radius: 40,
+			want:   `&lt;script&gt;alert(&#34;<mark>neovim</mark>&#34;)&lt;/script&gt;`,
+		},
+		{
+			name:   "multibyte text",
+			text:   "Ünïcode Neovim café",
+			terms:  []string{"café"},
+			radius: 3,
+			want:   "…im <mark>café</mark>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Snippet(tt.text, tt.terms, tt.radius); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSnippetCannotForgeMarkers(t *testing.T) {
+	got := Snippet("<mark>fake</mark> neovim", []string{"neovim"}, 40)
+	if strings.Count(got, MarkStart) != 1 {
+		t.Errorf("Expected user-supplied markers to be escaped, got %q", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	fields := []Field{
+		{Name: "name", Text: "Terminal Setup"},
+		{Name: "description", Text: "Neovim and tmux for the terminal"},
+		{Name: "tags", Text: "editor, shell"},
+		{Name: "author", Text: "octocat"},
+	}
+
+	matched, highlight := Match(fields, Terms("terminal neovim"), DefaultRadius)
+
+	if want := []string{"name", "description"}; !reflect.DeepEqual(matched, want) {
+		t.Errorf("Expected matched fields %v, got %v", want, matched)
+	}
+	if highlight == nil || highlight.Field != "name" || highlight.Snippet != "<mark>Terminal</mark> Setup" {
+		t.Errorf("Expected highlight from the name field, got %+v", highlight)
+	}
+
+	matched, highlight = Match(fields, Terms("emacs"), DefaultRadius)
+	if len(matched) != 0 || highlight != nil {
+		t.Errorf("Expected no match, got %v and %+v", matched, highlight)
+	}
+}
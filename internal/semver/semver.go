@@ -0,0 +1,193 @@
+// Package semver parses and orders Semantic Versioning 2.0.0 version
+// strings. A leading "v" is accepted and dropped, so "v1.2.0" and "1.2.0"
+// are the same version.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      []string
+}
+
+// Parse parses a semantic version such as "1.2.3", "v1.2.3-rc.1" or
+// "1.2.3+build.5"
+func Parse(s string) (Version, error) {
+	raw := s
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "v") || strings.HasPrefix(s, "V") {
+		s = s[1:]
+	}
+
+	var v Version
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		build, err := identifiers(s[i+1:], false)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid build metadata in %q: %w", raw, err)
+		}
+		v.Build = build
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		prerelease, err := identifiers(s[i+1:], true)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid pre-release in %q: %w", raw, err)
+		}
+		v.Prerelease = prerelease
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("%q is not of the form MAJOR.MINOR.PATCH", raw)
+	}
+	numbers := []*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := numeric(part)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version %q: %w", raw, err)
+		}
+		*numbers[i] = n
+	}
+
+	return v, nil
+}
+
+// IsValid reports whether s is a semantic version
+func IsValid(s string) bool {
+	_, err := Parse(s)
+	return err == nil
+}
+
+// Normalize returns s in canonical form, without the leading "v"
+func Normalize(s string) (string, error) {
+	v, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return v.String(), nil
+}
+
+// String formats the version without a leading "v"
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 as a is lower than, equal to or higher than b
+// in semver precedence. Build metadata is ignored, and a pre-release sorts
+// before its release.
+func Compare(a, b Version) int {
+	for _, pair := range [][2]uint64{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case len(a.Prerelease) == 0 && len(b.Prerelease) == 0:
+		return 0
+	case len(a.Prerelease) == 0:
+		return 1
+	case len(b.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.Prerelease) && i < len(b.Prerelease); i++ {
+		if c := compareIdentifier(a.Prerelease[i], b.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(a.Prerelease) < len(b.Prerelease):
+		return -1
+	case len(a.Prerelease) > len(b.Prerelease):
+		return 1
+	}
+	return 0
+}
+
+// Less reports whether a has lower precedence than b
+func Less(a, b Version) bool {
+	return Compare(a, b) < 0
+}
+
+// compareIdentifier orders pre-release identifiers: numeric ones compare
+// numerically and sort before alphanumeric ones, which compare in ASCII order
+func compareIdentifier(a, b string) int {
+	an, aErr := strconv.ParseUint(a, 10, 64)
+	bn, bErr := strconv.ParseUint(b, 10, 64)
+	switch {
+	case aErr == nil && bErr == nil:
+		if an < bn {
+			return -1
+		}
+		if an > bn {
+			return 1
+		}
+		return 0
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// identifiers splits dot-separated pre-release or build identifiers. Numeric
+// pre-release identifiers may not have leading zeros.
+func identifiers(s string, prerelease bool) ([]string, error) {
+	parts := strings.Split(s, ".")
+	for _, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("empty identifier")
+		}
+		allDigits := true
+		for _, r := range part {
+			switch {
+			case r >= '0' && r <= '9':
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+				allDigits = false
+			default:
+				return nil, fmt.Errorf("identifier %q contains %q", part, r)
+			}
+		}
+		if prerelease && allDigits && len(part) > 1 && part[0] == '0' {
+			return nil, fmt.Errorf("numeric identifier %q has a leading zero", part)
+		}
+	}
+	return parts, nil
+}
+
+// numeric parses a MAJOR, MINOR or PATCH component
+func numeric(s string) (uint64, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty version number")
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("version number %q has a leading zero", s)
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("version number %q is not numeric", s)
+		}
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
@@ -0,0 +1,74 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"1.2.3", "1.2.3"},
+		{"v1.2.3", "1.2.3"},
+		{"V0.0.0", "0.0.0"},
+		{" 1.0.0 ", "1.0.0"},
+		{"1.0.0-rc.1", "1.0.0-rc.1"},
+		{"1.0.0-alpha-beta.0", "1.0.0-alpha-beta.0"},
+		{"1.0.0+build.5", "1.0.0+build.5"},
+		{"v2.1.0-beta+exp.sha.5114f85", "2.1.0-beta+exp.sha.5114f85"},
+		{"1.0.0+001", "1.0.0+001"},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{
+		"", "latest", "v1", "1.0", "1.0.0.0", "01.0.0", "1.02.0", "1.0.0-",
+		"1.0.0-01", "1.0.0-rc..1", "1.0.0+", "1.0.0-rc_1", "vv1.0.0", "-1.0.0", "1.0.x",
+	} {
+		if IsValid(in) {
+			t.Errorf("Expected %q to be rejected", in)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Each version has lower precedence than the next, following the
+	// ordering example in section 11 of the spec
+	ordered := []string{
+		"0.9.9",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"1.10.0",
+		"2.0.0",
+	}
+	for i := 0; i+1 < len(ordered); i++ {
+		a, _ := Parse(ordered[i])
+		b, _ := Parse(ordered[i+1])
+		if Compare(a, b) != -1 || Compare(b, a) != 1 {
+			t.Errorf("Expected %s < %s", ordered[i], ordered[i+1])
+		}
+	}
+
+	equal := [][2]string{
+		{"1.0.0", "v1.0.0"},
+		{"1.0.0+build.1", "1.0.0+build.2"},
+		{"1.0.0-rc.1+a", "v1.0.0-rc.1"},
+	}
+	for _, pair := range equal {
+		a, _ := Parse(pair[0])
+		b, _ := Parse(pair[1])
+		if Compare(a, b) != 0 {
+			t.Errorf("Expected %s == %s", pair[0], pair[1])
+		}
+	}
+}
@@ -0,0 +1,282 @@
+// Package tags normalizes template tags and maps synonyms such as "js" and
+// "javascript" onto a single canonical tag.
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ErrConflict is returned when a synonym change would make a tag map to two
+// canonical tags
+var ErrConflict = errors.New("conflicting tag synonym")
+
+// builtinSynonyms is the synonym table every registry starts with, keyed by
+// canonical tag
+var builtinSynonyms = map[string][]string{
+	"javascript":       {"js", "ecmascript"},
+	"typescript":       {"ts"},
+	"kubernetes":       {"k8s", "kube"},
+	"frontend":         {"front-end"},
+	"backend":          {"back-end"},
+	"python":           {"py", "python3"},
+	"golang":           {"go"},
+	"postgresql":       {"postgres", "psql"},
+	"macos":            {"mac", "osx", "mac-os"},
+	"devops":           {"dev-ops"},
+	"machine-learning": {"ml"},
+	"database":         {"db"},
+	"cli":              {"command-line"},
+}
+
+// Normalize lowercases a tag, trims it and joins its words with single
+// hyphens, so "Front End", "front_end" and "front--end" all become
+// "front-end"
+func Normalize(tag string) string {
+	words := strings.FieldsFunc(strings.ToLower(tag), func(r rune) bool {
+		return unicode.IsSpace(r) || r == '-' || r == '_'
+	})
+	return strings.Join(words, "-")
+}
+
+// Registry maps normalized tags to their canonical form. It is safe for
+// concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	canonical map[string]string   // synonym -> canonical tag
+	synonyms  map[string][]string // canonical tag -> sorted synonyms
+}
+
+// NewRegistry creates a registry seeded with the built-in synonyms
+func NewRegistry() *Registry {
+	r := &Registry{
+		canonical: make(map[string]string),
+		synonyms:  make(map[string][]string),
+	}
+
+	if err := r.Load(builtinSynonyms); err != nil {
+		panic(fmt.Sprintf("tags: invalid built-in synonyms: %v", err))
+	}
+
+	return r
+}
+
+// Canonical returns the canonical form of a tag. Tags without synonyms are
+// returned normalized.
+func (r *Registry) Canonical(tag string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.canonicalLocked(Normalize(tag))
+}
+
+// CanonicalTags maps every tag to its canonical form, dropping empty tags and
+// duplicates while keeping the original order
+func (r *Registry) CanonicalTags(tags []string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+
+	for _, tag := range tags {
+		canonical := r.canonicalLocked(Normalize(tag))
+		if canonical == "" || seen[canonical] {
+			continue
+		}
+		seen[canonical] = true
+		result = append(result, canonical)
+	}
+	return result
+}
+
+// Variants returns every normalized tag that means the same as tag: its
+// canonical form followed by the canonical form's synonyms
+func (r *Registry) Variants(tag string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	canonical := r.canonicalLocked(Normalize(tag))
+	if canonical == "" {
+		return nil
+	}
+
+	return append([]string{canonical}, r.synonyms[canonical]...)
+}
+
+// ExpandTerms adds the variants of any search term that is a known tag, so a
+// search for "k8s" also finds templates that only mention "kubernetes"
+func (r *Registry) ExpandTerms(terms []string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	expanded := make([]string, 0, len(terms))
+	seen := make(map[string]bool)
+	add := func(term string) {
+		if !seen[term] {
+			seen[term] = true
+			expanded = append(expanded, term)
+		}
+	}
+
+	for _, term := range terms {
+		add(term)
+
+		canonical := r.canonicalLocked(Normalize(term))
+		synonyms, known := r.synonyms[canonical]
+		if !known {
+			continue
+		}
+
+		add(canonical)
+		for _, synonym := range synonyms {
+			add(synonym)
+		}
+	}
+	return expanded
+}
+
+// Synonyms returns a copy of the synonym table keyed by canonical tag
+func (r *Registry) Synonyms() map[string][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	table := make(map[string][]string, len(r.synonyms))
+	for canonical, synonyms := range r.synonyms {
+		table[canonical] = append([]string(nil), synonyms...)
+	}
+	return table
+}
+
+// Check reports whether Set would accept the synonyms without changing the
+// registry
+func (r *Registry) Check(canonical string, synonyms []string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, _, err := r.prepareLocked(canonical, synonyms)
+	return err
+}
+
+// Set replaces the synonyms of a canonical tag. An empty list removes the
+// canonical tag's synonyms. Synonyms already used by another canonical tag,
+// or a canonical tag that is itself a synonym, are rejected with ErrConflict.
+func (r *Registry) Set(canonical string, synonyms []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.setLocked(canonical, synonyms)
+}
+
+// Load applies a synonym table keyed by canonical tag, as stored by an admin
+// or built in
+func (r *Registry) Load(table map[string][]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Apply in a fixed order so conflicts are reported deterministically
+	canonicals := make([]string, 0, len(table))
+	for canonical := range table {
+		canonicals = append(canonicals, canonical)
+	}
+	sort.Strings(canonicals)
+
+	// Drop the old synonyms first so a synonym may move between two
+	// canonical tags in the same table
+	for _, canonical := range canonicals {
+		for _, synonym := range r.synonyms[Normalize(canonical)] {
+			delete(r.canonical, synonym)
+		}
+		delete(r.synonyms, Normalize(canonical))
+	}
+
+	for _, canonical := range canonicals {
+		if err := r.setLocked(canonical, table[canonical]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) setLocked(canonical string, synonyms []string) error {
+	canonical, normalized, err := r.prepareLocked(canonical, synonyms)
+	if err != nil {
+		return err
+	}
+
+	for _, synonym := range r.synonyms[canonical] {
+		delete(r.canonical, synonym)
+	}
+
+	if len(normalized) == 0 {
+		delete(r.synonyms, canonical)
+		return nil
+	}
+
+	for _, synonym := range normalized {
+		r.canonical[synonym] = canonical
+	}
+	r.synonyms[canonical] = normalized
+	return nil
+}
+
+// prepareLocked normalizes a synonym change and checks it against the
+// current table
+func (r *Registry) prepareLocked(canonical string, synonyms []string) (string, []string, error) {
+	canonical = Normalize(canonical)
+	if canonical == "" {
+		return "", nil, fmt.Errorf("canonical tag is required")
+	}
+
+	if owner, ok := r.canonical[canonical]; ok {
+		return "", nil, fmt.Errorf("%w: %q is already a synonym of %q", ErrConflict, canonical, owner)
+	}
+
+	var normalized []string
+	seen := map[string]bool{canonical: true}
+
+	for _, synonym := range synonyms {
+		synonym = Normalize(synonym)
+		if synonym == "" || seen[synonym] {
+			continue
+		}
+		seen[synonym] = true
+
+		if owner, ok := r.canonical[synonym]; ok && owner != canonical {
+			return "", nil, fmt.Errorf("%w: %q is already a synonym of %q", ErrConflict, synonym, owner)
+		}
+		if _, ok := r.synonyms[synonym]; ok {
+			return "", nil, fmt.Errorf("%w: %q is a canonical tag with its own synonyms", ErrConflict, synonym)
+		}
+
+		normalized = append(normalized, synonym)
+	}
+
+	sort.Strings(normalized)
+	return canonical, normalized, nil
+}
+
+func (r *Registry) canonicalLocked(tag string) string {
+	if canonical, ok := r.canonical[tag]; ok {
+		return canonical
+	}
+	return tag
+}
+
+// Pattern returns a regular expression that, matched case-insensitively,
+// accepts any raw tag normalizing to the given normalized tag. It lets stores
+// that cannot call Normalize themselves match stored tags.
+func Pattern(normalized string) string {
+	const separators = `[\s_-]`
+
+	words := strings.Split(normalized, "-")
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	return "^" + separators + "*" + strings.Join(words, separators+"+") + separators + "*$"
+}
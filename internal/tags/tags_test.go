@@ -0,0 +1,154 @@
+package tags
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"JavaScript":    "javascript",
+		"  Front End ":  "front-end",
+		"front_end":     "front-end",
+		"front--end":    "front-end",
+		"-machine  ml-": "machine-ml",
+		"   ":           "",
+	}
+
+	for input, want := range tests {
+		if got := Normalize(input); got != want {
+			t.Errorf("Normalize(%q): expected %q, got %q", input, want, got)
+		}
+	}
+}
+
+func TestCanonicalTags(t *testing.T) {
+	r := NewRegistry()
+
+	got := r.CanonicalTags([]string{"JS", "javascript", "Front End", "K8s", "dotfiles", " "})
+	want := []string{"javascript", "frontend", "kubernetes", "dotfiles"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestVariants(t *testing.T) {
+	r := NewRegistry()
+
+	want := []string{"kubernetes", "k8s", "kube"}
+	for _, tag := range []string{"kubernetes", "K8S", "kube"} {
+		if got := r.Variants(tag); !reflect.DeepEqual(got, want) {
+			t.Errorf("Variants(%q): expected %v, got %v", tag, want, got)
+		}
+	}
+
+	if got := r.Variants("Dot Files"); !reflect.DeepEqual(got, []string{"dot-files"}) {
+		t.Errorf("Expected a tag without synonyms to only match itself, got %v", got)
+	}
+}
+
+func TestExpandTerms(t *testing.T) {
+	r := NewRegistry()
+
+	got := r.ExpandTerms([]string{"k8s", "setup"})
+	want := []string{"k8s", "kubernetes", "kube", "setup"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestSetSynonyms(t *testing.T) {
+	r := NewRegistry()
+
+	if err := r.Set("Rust", []string{"rustlang", "RS"}); err != nil {
+		t.Fatalf("Failed to set synonyms: %v", err)
+	}
+	if got := r.Canonical("rs"); got != "rust" {
+		t.Errorf("Expected rs to map to rust, got %q", got)
+	}
+
+	// Replacing the set drops synonyms that are no longer listed
+	if err := r.Set("rust", []string{"rustlang"}); err != nil {
+		t.Fatalf("Failed to replace synonyms: %v", err)
+	}
+	if got := r.Canonical("rs"); got != "rs" {
+		t.Errorf("Expected rs to no longer map to rust, got %q", got)
+	}
+
+	// An empty set removes a built-in entry
+	if err := r.Set("golang", nil); err != nil {
+		t.Fatalf("Failed to clear synonyms: %v", err)
+	}
+	if got := r.Canonical("go"); got != "go" {
+		t.Errorf("Expected go to no longer map to golang, got %q", got)
+	}
+	if _, ok := r.Synonyms()["golang"]; ok {
+		t.Error("Expected golang to be removed from the synonym table")
+	}
+}
+
+func TestSetSynonymsConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		canonical string
+		synonyms  []string
+	}{
+		{name: "synonym owned by another tag", canonical: "node", synonyms: []string{"js"}},
+		{name: "canonical is a synonym", canonical: "k8s", synonyms: []string{"kates"}},
+		{name: "synonym is a canonical tag", canonical: "web", synonyms: []string{"frontend"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			before := r.Synonyms()
+
+			if err := r.Check(tt.canonical, tt.synonyms); !errors.Is(err, ErrConflict) {
+				t.Errorf("Expected Check to report ErrConflict, got %v", err)
+			}
+			if err := r.Set(tt.canonical, tt.synonyms); !errors.Is(err, ErrConflict) {
+				t.Errorf("Expected Set to report ErrConflict, got %v", err)
+			}
+			if !reflect.DeepEqual(r.Synonyms(), before) {
+				t.Error("Expected a rejected change to leave the registry untouched")
+			}
+		})
+	}
+}
+
+func TestLoadMovesSynonyms(t *testing.T) {
+	r := NewRegistry()
+
+	// "go" moves from golang to a tag that sorts before it
+	err := r.Load(map[string][]string{
+		"gc":     {"go"},
+		"golang": {},
+	})
+	if err != nil {
+		t.Fatalf("Failed to load synonyms: %v", err)
+	}
+	if got := r.Canonical("go"); got != "gc" {
+		t.Errorf("Expected go to map to gc, got %q", got)
+	}
+}
+
+func TestPattern(t *testing.T) {
+	pattern := regexp.MustCompile("(?i)" + Pattern("front-end"))
+
+	for _, tag := range []string{"front-end", "Front End", "FRONT_END", " front -- end "} {
+		if !pattern.MatchString(tag) {
+			t.Errorf("Expected %q to match", tag)
+		}
+	}
+	for _, tag := range []string{"frontend", "front-ends", "backfront-end"} {
+		if pattern.MatchString(tag) {
+			t.Errorf("Expected %q not to match", tag)
+		}
+	}
+
+	if !regexp.MustCompile("(?i)" + Pattern("c++")).MatchString("C++") {
+		t.Error("Expected regexp metacharacters to be quoted")
+	}
+}
@@ -1,33 +1,53 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"log/slog"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"dotfiles-api/internal/auth"
+	"dotfiles-api/internal/config"
+	"dotfiles-api/internal/digest"
+	"dotfiles-api/internal/dto"
 	"dotfiles-api/internal/handlers"
+	"dotfiles-api/internal/images"
+	"dotfiles-api/internal/integrity"
 	"dotfiles-api/internal/middleware"
+	"dotfiles-api/internal/popularity"
+	"dotfiles-api/internal/reconcile"
 	"dotfiles-api/internal/repository"
 	"dotfiles-api/internal/repository/memory"
 	"dotfiles-api/internal/repository/mongo"
 	"dotfiles-api/internal/router"
+	"dotfiles-api/internal/tags"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
 func main() {
+	migrateOnly := flag.Bool("migrate", false, "apply pending MongoDB migrations and exit")
+	flag.Parse()
+
 	// Load .env file if it exists (for local development)
 	// Silently ignore if .env doesn't exist (production uses environment variables)
 	_ = godotenv.Load()
 
+	logger := newLogger(os.Getenv("ENVIRONMENT"), os.Getenv("LOG_LEVEL"))
+	slog.SetDefault(logger)
+
 	// Initialize OAuth service
-	oauthService := auth.NewOAuthService()
+	oauthService := auth.NewOAuthService(config.LoadOAuthProviders()...)
 
 	// Initialize session manager
 	sessionTimeout := 24 * time.Hour // 24 hours
 	sessionManager := auth.NewSessionManager(sessionTimeout)
+	memoryLimits := config.LoadMemoryLimits()
+	sessionManager.SetMaxSessions(memoryLimits.Sessions)
 
 	// Initialize storage
 	var mongoClient *mongo.Client
@@ -40,12 +60,29 @@ func main() {
 			dbName = "dotfiles"
 		}
 
-		mongoClient, err = mongo.NewClient(mongoURI, dbName)
+		mongoConfig := config.LoadMongoDB()
+		mongoClient, err = mongo.NewClient(mongoURI, dbName, mongo.ClientOptions{
+			ReadPreference: mongoConfig.ReadPreference,
+			ReadTimeout:    mongoConfig.ReadTimeout,
+			WriteTimeout:   mongoConfig.WriteTimeout,
+		}, logger)
 		if err != nil {
-			log.Printf("Failed to connect to MongoDB: %v", err)
-			log.Println("Falling back to memory storage")
-		} else {
-			log.Println("Connected to MongoDB")
+			logger.Error("failed to connect to MongoDB, falling back to memory storage", "error", err)
+		}
+	}
+
+	// Apply pending data migrations before serving
+	if *migrateOnly && mongoClient == nil {
+		logger.Error("migrations require a MongoDB connection; set MONGODB_URI")
+		os.Exit(1)
+	}
+	if mongoClient != nil && (*migrateOnly || config.LoadRunMigrations()) {
+		if err := mongo.NewMigrator(mongoClient, mongo.Migrations()).Run(context.Background()); err != nil {
+			logger.Error("failed to apply migrations", "error", err)
+			os.Exit(1)
+		}
+		if *migrateOnly {
+			return
 		}
 	}
 
@@ -55,33 +92,156 @@ func main() {
 	var userRepo repository.UserRepository
 	var reviewRepo repository.ReviewRepository
 	var orgRepo repository.OrganizationRepository
+	var tagRepo repository.TagRepository
+	var subscriptionRepo repository.SubscriptionRepository
+	var reportRepo repository.ReportRepository
+	var auditRepo repository.AuditRepository
+	var downloadEventRepo repository.DownloadEventRepository
+	var apiKeyRepo repository.APIKeyRepository
+	var shareLinkRepo repository.ShareLinkRepository
 
 	if mongoClient != nil {
 		configRepo = mongo.NewConfigRepository(mongoClient)
 		templateRepo = mongo.NewTemplateRepository(mongoClient)
-		userRepo = mongo.NewUserRepository(mongoClient)
-		reviewRepo = mongo.NewReviewRepository(mongoClient)
-		orgRepo = mongo.NewOrganizationRepository(mongoClient)
-		log.Println("Using MongoDB repositories")
+		mongoUserRepo := mongo.NewUserRepository(mongoClient)
+		if err := mongoUserRepo.EnsureIndexes(context.Background()); err != nil {
+			logger.Error("failed to create user indexes", "error", err)
+		}
+		userRepo = mongoUserRepo
+		mongoReviewRepo := mongo.NewReviewRepository(mongoClient)
+		if err := mongoReviewRepo.EnsureIndexes(context.Background()); err != nil {
+			logger.Error("failed to create review indexes", "error", err)
+		}
+		reviewRepo = mongoReviewRepo
+		mongoOrgRepo := mongo.NewOrganizationRepository(mongoClient)
+		if err := mongoOrgRepo.EnsureIndexes(context.Background()); err != nil {
+			logger.Error("failed to create organization indexes", "error", err)
+		}
+		orgRepo = mongoOrgRepo
+		tagRepo = mongo.NewTagRepository(mongoClient)
+		subscriptionRepo = mongo.NewSubscriptionRepository(mongoClient)
+		mongoReportRepo := mongo.NewReportRepository(mongoClient)
+		if err := mongoReportRepo.EnsureIndexes(context.Background()); err != nil {
+			logger.Error("failed to create report indexes", "error", err)
+		}
+		reportRepo = mongoReportRepo
+		auditRepo = mongo.NewAuditRepository(mongoClient)
+		mongoDownloadEventRepo := mongo.NewDownloadEventRepository(mongoClient)
+		if err := mongoDownloadEventRepo.EnsureIndexes(context.Background()); err != nil {
+			logger.Error("failed to create download event indexes", "error", err)
+		}
+		downloadEventRepo = mongoDownloadEventRepo
+		mongoAPIKeyRepo := mongo.NewAPIKeyRepository(mongoClient)
+		if err := mongoAPIKeyRepo.EnsureIndexes(context.Background()); err != nil {
+			logger.Error("failed to create API key indexes", "error", err)
+		}
+		apiKeyRepo = mongoAPIKeyRepo
+		mongoShareLinkRepo := mongo.NewShareLinkRepository(mongoClient)
+		if err := mongoShareLinkRepo.EnsureIndexes(context.Background()); err != nil {
+			logger.Error("failed to create share link indexes", "error", err)
+		}
+		shareLinkRepo = mongoShareLinkRepo
+		logger.Info("using MongoDB repositories")
 	} else {
 		// Use in-memory repositories as fallback
-		templateRepo = memory.NewTemplateRepository()
-		userRepo = memory.NewUserRepository()
-		reviewRepo = memory.NewReviewRepository()
-		log.Println("Using in-memory repositories (MongoDB not configured)")
-		log.Println("Note: Some features (config, organizations) are not available without MongoDB")
+		memoryConfigs := memory.NewConfigRepository()
+		memoryConfigs.SetMaxEntries(memoryLimits.Configs)
+		configRepo = memoryConfigs
+		memoryTemplates := memory.NewTemplateRepository()
+		memoryTemplates.SetMaxEntries(memoryLimits.Templates)
+		templateRepo = memoryTemplates
+		memoryUsers := memory.NewUserRepository()
+		memoryUsers.SetMaxEntries(memoryLimits.Users)
+		userRepo = memoryUsers
+		memoryReviews := memory.NewReviewRepository()
+		memoryReviews.SetMaxEntries(memoryLimits.Reviews)
+		reviewRepo = memoryReviews
+		orgRepo = memory.NewOrganizationRepository()
+		tagRepo = memory.NewTagRepository()
+		subscriptionRepo = memory.NewSubscriptionRepository()
+		reportRepo = memory.NewReportRepository()
+		auditRepo = memory.NewAuditRepository()
+		downloadEventRepo = memory.NewDownloadEventRepository()
+		apiKeyRepo = memory.NewAPIKeyRepository()
+		shareLinkRepo = memory.NewShareLinkRepository()
+		logger.Info("using in-memory repositories", "reason", "MongoDB not configured")
+	}
+
+	// Load admin-defined tag synonyms on top of the built-in table
+	tagRegistry := tags.NewRegistry()
+	if synonyms, err := tagRepo.GetSynonyms(context.Background()); err != nil {
+		logger.Error("failed to load tag synonyms", "error", err)
+	} else if err := tagRegistry.Load(synonyms); err != nil {
+		logger.Warn("ignoring invalid tag synonyms", "error", err)
 	}
 
 	// Initialize auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(sessionManager)
+	authMiddleware := middleware.NewAuthMiddleware(sessionManager, config.LoadAdminUsers())
+	authMiddleware.ConfigureAPIKeys(apiKeyRepo, userRepo)
 
 	// Initialize handlers
-	configHandler := handlers.NewConfigHandler(configRepo)
-	authHandler := handlers.NewAuthHandler(oauthService, sessionManager, userRepo)
-	templateHandler := handlers.NewTemplateHandler(templateRepo)
-	userHandler := handlers.NewUserHandler(userRepo)
-	reviewHandler := handlers.NewReviewHandler(reviewRepo)
-	organizationHandler := handlers.NewOrganizationHandler(orgRepo)
+	configHandler := handlers.NewConfigHandler(configRepo, userRepo)
+	configHandler.ConfigureDownloadEvents(downloadEventRepo)
+	configHandler.ConfigureTags(tagRegistry)
+	features := config.LoadFeatures()
+	contentLimits := dto.ContentLimits{
+		MaxListEntries:    features.MaxListEntries,
+		MaxHookCommands:   features.MaxHookCommands,
+		MaxCommandLength:  features.MaxCommandLength,
+		MaxPackageConfigs: features.MaxPackageConfigs,
+		MaxTotalBytes:     features.MaxContentBytes,
+	}
+	configHandler.ConfigureContentLimits(contentLimits)
+	// Access checks share the organization roles cached in sessions
+	authorizer := handlers.NewAuthorizer(orgRepo)
+	authorizer.ConfigureMembershipCache(sessionManager, userRepo, config.LoadMembershipCacheTTL())
+	authHandler := handlers.NewAuthHandler(oauthService, sessionManager, userRepo, features.EnableRegistration)
+	authHandler.ConfigureMemberships(authorizer)
+	templateHandler := handlers.NewTemplateHandler(templateRepo, tagRepo, userRepo, reviewRepo, tagRegistry, authorizer)
+	templateHandler.ConfigureInstallSnippets(handlers.InstallSnippetConfig{
+		PublicAPIURL: config.LoadPublicAPIURL(),
+		CLIName:      config.LoadCLIName(),
+	})
+	templateHandler.ConfigureClientMatrix(config.LoadClientMatrix())
+	templateHandler.ConfigureContentLimits(contentLimits)
+	templateHandler.ConfigureShareLinks(config.LoadFrontendURL())
+	templateHandler.ConfigureHomebrew(config.LoadHomebrew())
+	templateHandler.ConfigureConfigs(configRepo)
+	templateHandler.ConfigureDownloadEvents(downloadEventRepo)
+	templateHandler.ConfigureShareLinkStore(shareLinkRepo)
+	imageConfig := config.LoadTemplateImages()
+	templateImages := images.NewStore(imageConfig)
+	templateHandler.ConfigureImages(templateImages)
+	userHandler := handlers.NewUserHandler(userRepo, templateRepo, reviewRepo, orgRepo)
+	userHandler.ConfigureAvatarProxy(config.LoadAvatarProxy())
+	userHandler.ConfigureAPIKeys(apiKeyRepo)
+	reviewHandler := handlers.NewReviewHandler(reviewRepo, templateRepo, authorizer)
+	reviewHandler.ConfigureComments(features.ReviewMinCommentLength, features.EnableRequireReviewComment)
+	organizationHandler := handlers.NewOrganizationHandler(orgRepo, userRepo, templateRepo)
+	organizationHandler.ConfigureShareLinks(config.LoadFrontendURL())
+	organizationHandler.ConfigureInviteTokens(config.LoadInviteTokenBytes())
+	organizationHandler.ConfigurePrimaryHosts(config.LoadPrimaryHosts())
+	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionRepo, tagRegistry)
+	reportHandler := handlers.NewReportHandler(reportRepo, templateRepo, auditRepo, authorizer)
+	reportHandler.ConfigureAutoUnlist(config.LoadReportAutoUnlistThreshold())
+	reportHandler.ConfigureImages(templateImages)
+
+	// Send digests of new templates matching followed tags
+	digester := digest.New(subscriptionRepo, templateRepo, userRepo, tagRegistry, digest.LogNotifier{})
+	go digester.Start(context.Background(), config.LoadDigestInterval())
+
+	// Keep template popularity scores fresh for sort_by=popularity
+	popularityUpdater := popularity.NewUpdater(templateRepo, reviewRepo, userRepo, config.LoadPopularityWeights())
+	go popularityUpdater.Start(context.Background(), config.LoadPopularityInterval())
+
+	// Sweep out records pointing at deleted templates and users
+	sweeper := integrity.New(templateRepo, reviewRepo, userRepo, orgRepo)
+	go sweeper.Start(context.Background(), config.LoadIntegritySweepInterval())
+
+	// Correct counters that drifted from the records they count
+	reconciler := reconcile.New(orgRepo)
+	go reconciler.Start(context.Background(), config.LoadReconcileInterval())
+	maintenanceHandler := handlers.NewMaintenanceHandler(sweeper, reconciler)
 
 	// Initialize router
 	appRouter := router.NewRouter(
@@ -91,14 +251,31 @@ func main() {
 		authHandler,
 		reviewHandler,
 		organizationHandler,
+		subscriptionHandler,
+		maintenanceHandler,
+		reportHandler,
+		handlers.NewMetricsHandler(sessionManager),
 		authMiddleware,
+		config.LoadCORS(),
+		features,
+		config.LoadRateLimits(),
+		config.LoadMaxUploadSize(),
 	)
 
 	// Initialize Gin
 	r := gin.Default()
 
-	// Trust Railway's proxy infrastructure
-	r.SetTrustedProxies(nil)
+	// Only proxies listed in TRUSTED_PROXIES, such as Railway's, may set the
+	// client IP that rate limiting and logging see
+	trustedProxies, err := config.LoadTrustedProxies()
+	if err != nil {
+		logger.Error("invalid trusted proxies", "error", err)
+		os.Exit(1)
+	}
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		logger.Error("failed to set trusted proxies", "error", err)
+		os.Exit(1)
+	}
 
 	// Add logging middleware
 	r.Use(middleware.Logger())
@@ -106,14 +283,47 @@ func main() {
 	// Setup routes
 	appRouter.SetupRoutes(r)
 
+	// Uploaded template images are named after their content, so they never
+	// change and can be cached for good
+	imageFiles := r.Group(imageConfig.URLPath, func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	})
+	imageFiles.Static("/", imageConfig.Dir)
+
 	// Get port from environment or default to 8080
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	// Serve HEAD requests through the GET routes
+	logger.Info("server starting", "port", port)
+	if err := http.ListenAndServe(":"+port, middleware.ServeHeadAsGet(r.Handler())); err != nil {
+		logger.Error("failed to start server", "error", err)
+		os.Exit(1)
+	}
+}
+
+// newLogger logs JSON in production and human-readable text elsewhere, at
+// the given LOG_LEVEL
+func newLogger(environment, level string) *slog.Logger {
+	options := &slog.HandlerOptions{Level: parseLogLevel(level)}
+	if environment == "production" {
+		return slog.New(slog.NewJSONHandler(os.Stdout, options))
+	}
+	return slog.New(slog.NewTextHandler(os.Stdout, options))
+}
+
+// parseLogLevel reads debug, info, warn or error, defaulting to info
+func parseLogLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
 	}
 }
\ No newline at end of file
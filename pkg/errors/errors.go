@@ -8,16 +8,21 @@ import (
 type ErrorCode string
 
 const (
-	ErrCodeValidation     ErrorCode = "VALIDATION_ERROR"
-	ErrCodeNotFound       ErrorCode = "NOT_FOUND"
-	ErrCodeUnauthorized   ErrorCode = "UNAUTHORIZED"
-	ErrCodeForbidden      ErrorCode = "FORBIDDEN"
-	ErrCodeConflict       ErrorCode = "CONFLICT"
-	ErrCodeInternal       ErrorCode = "INTERNAL_ERROR"
-	ErrCodeBadRequest     ErrorCode = "BAD_REQUEST"
-	ErrCodeRateLimit      ErrorCode = "RATE_LIMIT"
-	ErrCodeInvalidToken   ErrorCode = "INVALID_TOKEN"
-	ErrCodeExpiredToken   ErrorCode = "EXPIRED_TOKEN"
+	ErrCodeValidation   ErrorCode = "VALIDATION_ERROR"
+	ErrCodeNotFound     ErrorCode = "NOT_FOUND"
+	ErrCodeUnauthorized ErrorCode = "UNAUTHORIZED"
+	ErrCodeForbidden    ErrorCode = "FORBIDDEN"
+	ErrCodeConflict     ErrorCode = "CONFLICT"
+	ErrCodeInternal     ErrorCode = "INTERNAL_ERROR"
+	ErrCodeBadRequest   ErrorCode = "BAD_REQUEST"
+	ErrCodeRateLimit    ErrorCode = "RATE_LIMIT"
+	ErrCodeInvalidToken ErrorCode = "INVALID_TOKEN"
+	ErrCodeExpiredToken ErrorCode = "EXPIRED_TOKEN"
+	ErrCodeUnavailable  ErrorCode = "SERVICE_UNAVAILABLE"
+	ErrCodeMethod       ErrorCode = "METHOD_NOT_ALLOWED"
+	ErrCodeTooLarge     ErrorCode = "PAYLOAD_TOO_LARGE"
+	ErrCodeMediaType    ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	ErrCodeGone         ErrorCode = "GONE"
 )
 
 type AppError struct {
@@ -26,6 +31,9 @@ type AppError struct {
 	Details    string    `json:"details,omitempty"`
 	StatusCode int       `json:"status_code"`
 	Internal   error     `json:"-"`
+	// Fields maps request fields (JSON names, dotted for nesting) to what is
+	// wrong with them
+	Fields map[string]string `json:"fields,omitempty"`
 }
 
 func (e *AppError) Error() string {
@@ -35,6 +43,12 @@ func (e *AppError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Code, e.Message)
 }
 
+// Unwrap returns the internal cause so errors.Is and errors.As can see through
+// an AppError
+func (e *AppError) Unwrap() error {
+	return e.Internal
+}
+
 func NewValidationError(message string) *AppError {
 	return &AppError{
 		Code:       ErrCodeValidation,
@@ -43,6 +57,17 @@ func NewValidationError(message string) *AppError {
 	}
 }
 
+// NewFieldValidationError reports a request that failed validation on
+// specific fields
+func NewFieldValidationError(message string, fields map[string]string) *AppError {
+	return &AppError{
+		Code:       ErrCodeValidation,
+		Message:    message,
+		StatusCode: http.StatusBadRequest,
+		Fields:     fields,
+	}
+}
+
 func NewNotFoundError(resource string) *AppError {
 	return &AppError{
 		Code:       ErrCodeNotFound,
@@ -114,4 +139,60 @@ func NewExpiredTokenError(message string) *AppError {
 		Message:    message,
 		StatusCode: http.StatusUnauthorized,
 	}
-}
\ No newline at end of file
+}
+
+func NewUnavailableError(message string) *AppError {
+	return &AppError{
+		Code:       ErrCodeUnavailable,
+		Message:    message,
+		StatusCode: http.StatusServiceUnavailable,
+	}
+}
+
+func NewMethodNotAllowedError(message string) *AppError {
+	return &AppError{
+		Code:       ErrCodeMethod,
+		Message:    message,
+		StatusCode: http.StatusMethodNotAllowed,
+	}
+}
+
+// NewPayloadTooLargeError reports a request body over the limit in bytes
+func NewPayloadTooLargeError(limit int64) *AppError {
+	return &AppError{
+		Code:       ErrCodeTooLarge,
+		Message:    fmt.Sprintf("Request body exceeds the %d byte limit", limit),
+		StatusCode: http.StatusRequestEntityTooLarge,
+	}
+}
+
+// NewContentTooLargeError reports a well-formed body whose content is over
+// a size limit, such as a template with too many packages
+func NewContentTooLargeError(message string, fields map[string]string) *AppError {
+	return &AppError{
+		Code:       ErrCodeTooLarge,
+		Message:    message,
+		StatusCode: http.StatusRequestEntityTooLarge,
+		Fields:     fields,
+	}
+}
+
+// NewUnsupportedMediaTypeError reports a request body sent with a content
+// type the endpoint does not accept
+func NewUnsupportedMediaTypeError(message string) *AppError {
+	return &AppError{
+		Code:       ErrCodeMediaType,
+		Message:    message,
+		StatusCode: http.StatusUnsupportedMediaType,
+	}
+}
+
+// NewGoneError reports a resource that existed but can no longer be used,
+// such as an expired link
+func NewGoneError(message string) *AppError {
+	return &AppError{
+		Code:       ErrCodeGone,
+		Message:    message,
+		StatusCode: http.StatusGone,
+	}
+}
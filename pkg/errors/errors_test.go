@@ -0,0 +1,26 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"testing"
+)
+
+func TestAppErrorUnwrap(t *testing.T) {
+	cause := stderrors.New("connection reset")
+	appErr := NewInternalError("failed to get user", cause)
+
+	if !stderrors.Is(appErr, cause) {
+		t.Error("Expected errors.Is to find the internal cause")
+	}
+
+	wrapped := fmt.Errorf("loading profile: %w", appErr)
+	var target *AppError
+	if !stderrors.As(wrapped, &target) || target != appErr {
+		t.Fatalf("Expected errors.As to find the wrapped AppError, got %v", target)
+	}
+
+	if NewNotFoundError("user").Unwrap() != nil {
+		t.Error("Expected no cause for an AppError without an internal error")
+	}
+}